@@ -14,11 +14,20 @@ import (
 	db_manager "github.com/SaeedAlian/econest/api/db/manager"
 	_ "github.com/SaeedAlian/econest/api/docs"
 	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/services/cart"
+	"github.com/SaeedAlian/econest/api/services/commission"
+	"github.com/SaeedAlian/econest/api/services/coupon"
+	"github.com/SaeedAlian/econest/api/services/order"
+	"github.com/SaeedAlian/econest/api/services/order_return"
+	"github.com/SaeedAlian/econest/api/services/payment"
 	"github.com/SaeedAlian/econest/api/services/product"
+	"github.com/SaeedAlian/econest/api/services/shipping"
 	"github.com/SaeedAlian/econest/api/services/smtp"
 	"github.com/SaeedAlian/econest/api/services/store"
+	"github.com/SaeedAlian/econest/api/services/tax"
 	"github.com/SaeedAlian/econest/api/services/user"
 	"github.com/SaeedAlian/econest/api/services/wallet"
+	"github.com/SaeedAlian/econest/api/types"
 )
 
 type Server struct {
@@ -53,6 +62,13 @@ func (s *Server) Run() error {
 	roleAndPermissionSubrouter := router.PathPrefix("/rp").Subrouter()
 	walletSubrouter := router.PathPrefix("/wallet").Subrouter()
 	orderSubrouter := router.PathPrefix("/order").Subrouter()
+	cartSubrouter := router.PathPrefix("/cart").Subrouter()
+	orderReturnSubrouter := router.PathPrefix("/return").Subrouter()
+	couponSubrouter := router.PathPrefix("/coupon").Subrouter()
+	shippingSubrouter := router.PathPrefix("/shipping").Subrouter()
+	taxSubrouter := router.PathPrefix("/tax").Subrouter()
+	commissionSubrouter := router.PathPrefix("/commission").Subrouter()
+	paymentSubrouter := router.PathPrefix("/payment").Subrouter()
 
 	authCache := redis.NewClient(&redis.Options{
 		Addr: config.Env.KeyServerRedisAddr,
@@ -67,6 +83,14 @@ func (s *Server) Run() error {
 		config.Env.SMTPPassword,
 	)
 
+	// the rest of the api works without payments, so a missing payment
+	// provider only turns off the deposits and the external order payments
+	paymentProvider, err := payment.NewPaymentProvider(config.Env.PaymentProvider)
+	if err != nil {
+		log.Println("payments are disabled:", err)
+		paymentProvider = nil
+	}
+
 	userService := user.NewHandler(dbManager, authHandler, smtpServer)
 	userService.RegisterRoutes(userSubrouter)
 
@@ -79,16 +103,43 @@ func (s *Server) Run() error {
 	roleAndPermissionService := product.NewHandler(dbManager, authHandler)
 	roleAndPermissionService.RegisterRoutes(roleAndPermissionSubrouter)
 
-	walletService := wallet.NewHandler(dbManager, authHandler)
+	walletService := wallet.NewHandler(dbManager, authHandler, paymentProvider)
 	walletService.RegisterRoutes(walletSubrouter)
 
-	orderService := store.NewHandler(dbManager, authHandler)
+	orderService := order.NewHandler(dbManager, authHandler, paymentProvider)
 	orderService.RegisterRoutes(orderSubrouter)
 
+	cartService := cart.NewHandler(dbManager, authHandler)
+	cartService.RegisterRoutes(cartSubrouter)
+
+	orderReturnService := order_return.NewHandler(dbManager, authHandler)
+	orderReturnService.RegisterRoutes(orderReturnSubrouter)
+
+	couponService := coupon.NewHandler(dbManager, authHandler)
+	couponService.RegisterRoutes(couponSubrouter)
+
+	shippingService := shipping.NewHandler(dbManager, authHandler)
+	shippingService.RegisterRoutes(shippingSubrouter)
+
+	taxService := tax.NewHandler(dbManager, authHandler)
+	taxService.RegisterRoutes(taxSubrouter)
+
+	commissionService := commission.NewHandler(dbManager, authHandler)
+	commissionService.RegisterRoutes(commissionSubrouter)
+
+	if paymentProvider != nil {
+		paymentService := payment.NewHandler(dbManager, authHandler, paymentProvider)
+		paymentService.RegisterRoutes(paymentSubrouter)
+	}
+
 	log.Println("API Listening on ", s.addr)
 
 	originsOk := handlers.AllowedOrigins(config.Env.CORSAllowedOrigins)
 	methodsOk := handlers.AllowedMethods(config.Env.CORSAllowedMethods)
+	exposedHeadersOk := handlers.ExposedHeaders([]string{types.NextCursorHeader})
 
-	return http.ListenAndServe(s.addr, handlers.CORS(originsOk, methodsOk)(router))
+	return http.ListenAndServe(
+		s.addr,
+		handlers.CORS(originsOk, methodsOk, exposedHeadersOk)(router),
+	)
 }
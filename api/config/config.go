@@ -32,6 +32,7 @@ type Config struct {
 	CSRFTokenExpirationInMin              float64
 	ForgotPasswordTokenExpirationInMin    float64
 	EmailVerificationTokenExpirationInMin float64
+	IdempotencyKeyExpirationInMin         float64
 	MaxUsersInPage                        int32
 	MaxProductsInPage                     int32
 	MaxProductTagsInPage                  int32
@@ -41,7 +42,19 @@ type Config struct {
 	MaxProductCategoriesInPage            int32
 	MaxStoresInPage                       int32
 	MaxOrdersInPage                       int32
+	MaxOrderReturnsInPage                 int32
+	MaxCouponsInPage                      int32
+	MaxShippingZonesInPage                int32
+	MaxTaxRulesInPage                     int32
+	MaxCommissionRulesInPage              int32
+	MaxCommissionReportsInPage            int32
 	MaxWalletTransactionsInPage           int32
+	MaxWalletLedgerEntriesInPage          int32
+	MaxWalletStatementPeriodInDays        int32
+	MaxWalletDriftsInPage                 int32
+	MaxProductImportsInPage               int32
+	ProductImportMaxRows                  int32
+	ProductImportMaxSizeInMB              int64
 	SMTPHost                              string
 	SMTPPort                              string
 	SMTPEmail                             string
@@ -53,7 +66,22 @@ type Config struct {
 	EmailVerificationWebsitePageUrl       string
 	UploadsRootDir                        string
 	ShipmentPrice                         float64
-	OrderFeeFactor                        float64
+	InventoryHoldTTLInMin                 float64
+	InventoryHoldSweepIntervalInMin       float64
+	DefaultTransitTimeInDays              int64
+	PaymentProvider                       string
+	PaymentWebhookSecret                  string
+	PaymentWebhookToleranceInSec          int64
+	TransferMaxAmount                     float64
+	TransferDailyLimit                    float64
+	TransferDailyCount                    int64
+	EscrowAutoReleaseInDays               int64
+	EscrowReleaseSweepIntervalInMin       float64
+	WalletReconciliationIntervalInMin     float64
+	WalletReconciliationFreezeDrifted     bool
+	ProductPriceFacetBounds               []float64
+	ProductImportSweepIntervalInMin       float64
+	ProductImportTimeoutInMin             float64
 }
 
 var Env = InitConfig()
@@ -109,9 +137,16 @@ func InitConfig() Config {
 		CSRFTokenExpirationInMin:              float64(30),
 		ForgotPasswordTokenExpirationInMin:    float64(10),
 		EmailVerificationTokenExpirationInMin: float64(10),
+		IdempotencyKeyExpirationInMin:         float64(60 * 24),
 		MaxUsersInPage:                        int32(10),
 		MaxStoresInPage:                       int32(5),
 		MaxWalletTransactionsInPage:           int32(20),
+		MaxWalletLedgerEntriesInPage:          int32(20),
+		MaxWalletStatementPeriodInDays:        int32(366),
+		MaxWalletDriftsInPage:                 int32(20),
+		MaxProductImportsInPage:               int32(10),
+		ProductImportMaxRows:                  int32(5000),
+		ProductImportMaxSizeInMB:              int64(10),
 		MaxProductsInPage:                     int32(15),
 		MaxProductTagsInPage:                  int32(20),
 		MaxProductOffersInPage:                int32(15),
@@ -119,6 +154,12 @@ func InitConfig() Config {
 		MaxProductCommentsInPage:              int32(5),
 		MaxProductCategoriesInPage:            int32(15),
 		MaxOrdersInPage:                       int32(10),
+		MaxOrderReturnsInPage:                 int32(10),
+		MaxCouponsInPage:                      int32(15),
+		MaxShippingZonesInPage:                int32(20),
+		MaxTaxRulesInPage:                     int32(20),
+		MaxCommissionRulesInPage:              int32(20),
+		MaxCommissionReportsInPage:            int32(20),
 		SMTPHost:                              getEnv("SMTP_HOST", ""),
 		SMTPPort:                              getEnv("SMTP_PORT", ""),
 		SMTPEmail:                             getEnv("SMTP_MAIL", ""),
@@ -136,7 +177,52 @@ func InitConfig() Config {
 		),
 		UploadsRootDir: getEnv("UPLOADS_ROOT_DIR", "uploads"),
 		ShipmentPrice:  getEnvAsFloat64("SHIPMENT_PRICE", 10.0),
-		OrderFeeFactor: getEnvAsFloat64("ORDER_FEE_FACTOR", 0.05),
+		InventoryHoldTTLInMin: getEnvAsFloat64(
+			"INVENTORY_HOLD_TTL_IN_MIN",
+			15,
+		),
+		InventoryHoldSweepIntervalInMin: getEnvAsFloat64(
+			"INVENTORY_HOLD_SWEEP_INTERVAL_IN_MIN",
+			1,
+		),
+		DefaultTransitTimeInDays: getEnvAsInt(
+			"DEFAULT_TRANSIT_TIME_IN_DAYS",
+			5,
+		),
+		PaymentProvider:              getEnv("PAYMENT_PROVIDER", "fake"),
+		PaymentWebhookSecret:         getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+		PaymentWebhookToleranceInSec: int64(5 * 60),
+		TransferMaxAmount:            getEnvAsFloat64("TRANSFER_MAX_AMOUNT", 1000),
+		TransferDailyLimit:           getEnvAsFloat64("TRANSFER_DAILY_LIMIT", 2000),
+		TransferDailyCount:           getEnvAsInt("TRANSFER_DAILY_COUNT", 10),
+		EscrowAutoReleaseInDays: getEnvAsInt(
+			"ESCROW_AUTO_RELEASE_IN_DAYS",
+			14,
+		),
+		EscrowReleaseSweepIntervalInMin: getEnvAsFloat64(
+			"ESCROW_RELEASE_SWEEP_INTERVAL_IN_MIN",
+			60,
+		),
+		WalletReconciliationIntervalInMin: getEnvAsFloat64(
+			"WALLET_RECONCILIATION_INTERVAL_IN_MIN",
+			24*60,
+		),
+		WalletReconciliationFreezeDrifted: getEnvAsBool(
+			"WALLET_RECONCILIATION_FREEZE_DRIFTED",
+			false,
+		),
+		ProductPriceFacetBounds: getEnvAsFloat64List(
+			"PRODUCT_PRICE_FACET_BOUNDS",
+			[]float64{50, 100, 250, 500, 1000},
+		),
+		ProductImportSweepIntervalInMin: getEnvAsFloat64(
+			"PRODUCT_IMPORT_SWEEP_INTERVAL_IN_MIN",
+			1,
+		),
+		ProductImportTimeoutInMin: getEnvAsFloat64(
+			"PRODUCT_IMPORT_TIMEOUT_IN_MIN",
+			30,
+		),
 	}
 }
 
@@ -189,6 +275,39 @@ func getEnvAsFloat64(key string, fallback float64) float64 {
 	return fallback
 }
 
+func getEnvAsFloat64List(key string, fallback []float64) []float64 {
+	if val, ok := os.LookupEnv(key); ok && len(val) > 0 {
+		arr := []float64{}
+		sp := strings.Split(val, ",")
+
+		for _, s := range sp {
+			v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return fallback
+			}
+
+			arr = append(arr, v)
+		}
+
+		return arr
+	}
+
+	return fallback
+}
+
+func getEnvAsBool(key string, fallback bool) bool {
+	if val, ok := os.LookupEnv(key); ok && len(val) > 0 {
+		v, err := strconv.ParseBool(val)
+		if err != nil {
+			return fallback
+		}
+
+		return v
+	}
+
+	return fallback
+}
+
 func getProjectRoot() string {
 	dir, err := os.Getwd()
 	if err != nil {
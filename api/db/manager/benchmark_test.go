@@ -0,0 +1,286 @@
+package db_manager_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	db_manager "github.com/SaeedAlian/econest/api/db/manager"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+	testutils "github.com/SaeedAlian/econest/api/utils/tests"
+)
+
+const (
+	benchmarkProductsCount = 60
+	benchmarkOrdersCount   = 60
+)
+
+type benchmarkFixture struct {
+	manager    *db_manager.Manager
+	userId     int
+	addressId  int
+	productIds []int
+	variantIds []int
+}
+
+// setupBenchmarkFixture seeds the test database with a store that has
+// products with every relation that is loaded with them, and a user who has
+// ordered them. The database is seeded once for each benchmark, so the
+// benchmarks run their measured loops in sub-benchmarks.
+func setupBenchmarkFixture(b *testing.B) benchmarkFixture {
+	b.Helper()
+
+	db := testutils.SetupTestDB(b)
+	manager := db_manager.NewManager(db)
+
+	must := func(id int, err error) int {
+		b.Helper()
+		if err != nil {
+			b.Fatal(err)
+		}
+		return id
+	}
+
+	role, err := manager.GetRoleByName("Customer")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	userId := must(manager.CreateUser(types.CreateUserPayload{
+		Username:  "benchuser",
+		Email:     "bench@example.com",
+		Password:  "securepassword",
+		BirthDate: time.Date(1999, 4, 1, 0, 0, 0, 0, time.UTC),
+		FullName:  "Bench User",
+		RoleId:    role.Id,
+	}))
+
+	addressId := must(manager.CreateUserAddress(types.CreateUserAddressPayload{
+		State:   "S",
+		City:    "C",
+		Street:  "SS",
+		Zipcode: "Z",
+		UserId:  userId,
+	}))
+
+	storeId := must(manager.CreateStore(types.CreateStorePayload{
+		Name:        "BENCH STORE",
+		Description: "Bench Store",
+		OwnerId:     userId,
+	}))
+
+	rootCategoryId := must(manager.CreateProductCategory(types.CreateProductCategoryPayload{
+		Name:      "bench root",
+		ImageName: "benchRoot",
+	}))
+	parentCategoryId := must(manager.CreateProductCategory(types.CreateProductCategoryPayload{
+		Name:             "bench parent",
+		ImageName:        "benchParent",
+		ParentCategoryId: &rootCategoryId,
+	}))
+	categoryId := must(manager.CreateProductCategory(types.CreateProductCategoryPayload{
+		Name:             "bench category",
+		ImageName:        "benchCategory",
+		ParentCategoryId: &parentCategoryId,
+	}))
+
+	tagId := must(manager.CreateProductTag(types.CreateProductTagPayload{
+		Name: "bench",
+	}))
+
+	attributes := []*types.ProductAttributeWithOptions{}
+	for i := 0; i < 3; i++ {
+		attributeId := must(manager.CreateProductAttribute(types.CreateProductAttributePayload{
+			Label:   fmt.Sprintf("bench attribute %d", i),
+			Options: []string{"a", "b", "c"},
+		}))
+
+		attribute, err := manager.GetProductAttributeWithOptionsById(attributeId)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		attributes = append(attributes, attribute)
+	}
+
+	productIds := []int{}
+	variantIds := []int{}
+	for i := 0; i < benchmarkProductsCount; i++ {
+		productId := must(manager.CreateProductBase(types.CreateProductBasePayload{
+			Name:           fmt.Sprintf("bench product %d", i),
+			Slug:           fmt.Sprintf("bench-product-%d", i),
+			Price:          types.MoneyFromFloat(float64(100 + i)),
+			Description:    "BENCH PRODUCT",
+			ShipmentFactor: 0.1,
+			SubcategoryId:  categoryId,
+			StoreId:        storeId,
+		}))
+
+		err = manager.CreateProductTagAssignments(productId, []int{tagId})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		must(manager.CreateProductSpec(productId, types.CreateProductSpecPayload{
+			Label: "bench",
+			Value: "spec",
+		}))
+
+		must(manager.CreateProductOffer(types.CreateProductOfferPayload{
+			Discount:  0.1,
+			ExpireAt:  time.Now().Add(24 * time.Hour),
+			ProductId: productId,
+		}))
+
+		must(manager.CreateProductImage(productId, types.CreateProductImagePayload{
+			ImageName: fmt.Sprintf("bench-main-%d", i),
+			IsMain:    true,
+		}))
+		must(manager.CreateProductImage(productId, types.CreateProductImagePayload{
+			ImageName: fmt.Sprintf("bench-other-%d", i),
+			IsMain:    false,
+		}))
+
+		for j := 0; j < 2; j++ {
+			attributeSets := []types.ProductVariantAttributeSetPayload{}
+			for _, attribute := range attributes {
+				attributeSets = append(attributeSets, types.ProductVariantAttributeSetPayload{
+					AttributeId: attribute.Id,
+					OptionId:    attribute.Options[j].Id,
+				})
+			}
+
+			variantIds = append(variantIds, must(manager.CreateProductVariant(
+				productId,
+				types.CreateProductVariantPayload{
+					Quantity:      1000,
+					AttributeSets: attributeSets,
+				},
+			)))
+		}
+
+		must(manager.CreateProductComment(types.CreateProductCommentPayload{
+			Scoring:   4,
+			Comment:   "bench comment",
+			ProductId: productId,
+			UserId:    userId,
+		}))
+
+		productIds = append(productIds, productId)
+	}
+
+	for i := 0; i < benchmarkOrdersCount; i++ {
+		must(manager.CreateOrder(types.CreateOrderPayload{
+			UserId: userId,
+			ProductVariants: []types.OrderProductVariantAssignmentPayload{
+				{
+					Quantity:  1,
+					VariantId: variantIds[i%len(variantIds)],
+				},
+				{
+					Quantity:  1,
+					VariantId: variantIds[(i+1)%len(variantIds)],
+				},
+			},
+			ReceiverAddressId: addressId,
+		}))
+	}
+
+	return benchmarkFixture{
+		manager:    manager,
+		userId:     userId,
+		addressId:  addressId,
+		productIds: productIds,
+		variantIds: variantIds,
+	}
+}
+
+func BenchmarkGetProducts(b *testing.B) {
+	f := setupBenchmarkFixture(b)
+
+	for _, limit := range []int{15, 60} {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				products, err := f.manager.GetProducts(types.ProductSearchQuery{
+					Limit: utils.Ptr(limit),
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(products) != limit {
+					b.Fatalf("expected %d products, got %d", limit, len(products))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetProductExtendedById(b *testing.B) {
+	f := setupBenchmarkFixture(b)
+
+	b.Run("variants=2", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := f.manager.GetProductExtendedById(f.productIds[i%len(f.productIds)])
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetOrdersWithFullInfo(b *testing.B) {
+	f := setupBenchmarkFixture(b)
+
+	for _, limit := range []int{15, 60} {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				orders, err := f.manager.GetOrdersWithFullInfo(types.OrderSearchQuery{
+					UserId: utils.Ptr(f.userId),
+					Limit:  utils.Ptr(limit),
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(orders) != limit {
+					b.Fatalf("expected %d orders, got %d", limit, len(orders))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetOrderProductVariantsInfo(b *testing.B) {
+	f := setupBenchmarkFixture(b)
+
+	for _, lines := range []int{2, 60} {
+		productVariants := []types.OrderProductVariantAssignmentPayload{}
+		for _, variantId := range f.variantIds[:lines] {
+			productVariants = append(productVariants, types.OrderProductVariantAssignmentPayload{
+				Quantity:  1,
+				VariantId: variantId,
+			})
+		}
+
+		orderId, err := f.manager.CreateOrder(types.CreateOrderPayload{
+			UserId:            f.userId,
+			ProductVariants:   productVariants,
+			ReceiverAddressId: f.addressId,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("lines=%d", lines), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				variantsInfo, err := f.manager.GetOrderProductVariantsInfo(orderId)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(variantsInfo) != lines {
+					b.Fatalf("expected %d lines, got %d", lines, len(variantsInfo))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,431 @@
+package db_manager
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+func (m *Manager) AddCartItem(userId int, p types.AddCartItemPayload) (int, error) {
+	if p.Quantity < 1 {
+		return -1, types.ErrInvalidCartItemQuantity
+	}
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	cartId := -1
+	err = tx.QueryRow(`
+		INSERT INTO carts (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET updated_at = CURRENT_TIMESTAMP
+		RETURNING id;
+	`, userId).Scan(&cartId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	rowId := -1
+	quantity := 0
+	err = tx.QueryRow(`
+		INSERT INTO cart_items (quantity, cart_id, variant_id) VALUES ($1, $2, $3)
+		ON CONFLICT (cart_id, variant_id) DO UPDATE
+		SET quantity = cart_items.quantity + EXCLUDED.quantity,
+				updated_at = CURRENT_TIMESTAMP
+		RETURNING id, quantity;
+	`, p.Quantity, cartId, p.VariantId).Scan(&rowId, &quantity)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	err = checkCartItemQuantityAsDBTx(tx, p.VariantId, quantity)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) CheckoutCart(userId int, p types.CheckoutCartPayload) (int, error) {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	cartId := -1
+	err = tx.QueryRow("SELECT id FROM carts WHERE user_id = $1 FOR UPDATE;", userId).
+		Scan(&cartId)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return -1, types.ErrCartIsEmpty
+		}
+		return -1, err
+	}
+
+	rows, err := tx.Query(
+		"SELECT variant_id, quantity FROM cart_items WHERE cart_id = $1 ORDER BY id;",
+		cartId,
+	)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+	defer rows.Close()
+
+	variants := []types.OrderProductVariantAssignmentPayload{}
+	for rows.Next() {
+		v := types.OrderProductVariantAssignmentPayload{}
+		err := rows.Scan(&v.VariantId, &v.Quantity)
+		if err != nil {
+			tx.Rollback()
+			return -1, err
+		}
+
+		variants = append(variants, v)
+	}
+	rows.Close()
+
+	if len(variants) == 0 {
+		tx.Rollback()
+		return -1, types.ErrCartIsEmpty
+	}
+
+	orderId, err := createOrderAsDBTx(tx, types.CreateOrderPayload{
+		UserId:            userId,
+		ProductVariants:   variants,
+		ReceiverAddressId: p.ReceiverAddressId,
+		CouponCode:        p.CouponCode,
+	})
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	err = clearCartAsDBTx(tx, cartId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	return orderId, nil
+}
+
+func (m *Manager) GetUserCart(userId int) (*types.Cart, error) {
+	rows, err := m.db.Query("SELECT * FROM carts WHERE user_id = $1;", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cart := new(types.Cart)
+	cart.Id = -1
+
+	for rows.Next() {
+		cart, err = scanCartRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cart.Id == -1 {
+		return nil, types.ErrCartNotFound
+	}
+
+	return cart, nil
+}
+
+func (m *Manager) GetCartItems(cartId int) ([]types.CartItem, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM cart_items WHERE cart_id = $1 ORDER BY id;",
+		cartId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []types.CartItem{}
+
+	for rows.Next() {
+		item, err := scanCartItemRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, *item)
+	}
+
+	return items, nil
+}
+
+func (m *Manager) GetUserCartWithItemsInfo(userId int) (*types.CartWithItemsInfo, error) {
+	cart, err := m.GetUserCart(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := m.GetCartItems(cart.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	variantIds := make([]int, len(items))
+	for i, item := range items {
+		variantIds[i] = item.VariantId
+	}
+
+	pricingRows, err := m.db.Query(productVariantPricingQuery, pq.Array(variantIds))
+	if err != nil {
+		return nil, err
+	}
+	defer pricingRows.Close()
+
+	pricingMap := make(map[int]*types.ProductVariantPricing, len(items))
+	for pricingRows.Next() {
+		pricing, err := scanProductVariantPricingRow(pricingRows)
+		if err != nil {
+			return nil, err
+		}
+
+		pricingMap[pricing.VariantId] = pricing
+	}
+	pricingRows.Close()
+
+	storeIds := make([]int, len(items))
+	for i, item := range items {
+		pricing, ok := pricingMap[item.VariantId]
+		if !ok {
+			return nil, types.ErrProductVariantNotFound
+		}
+
+		storeIds[i] = pricing.StoreId
+	}
+
+	commissionRates, err := m.getCommissionRates(variantIds, storeIds)
+	if err != nil {
+		return nil, err
+	}
+
+	res := types.CartWithItemsInfo{
+		Cart:  *cart,
+		Items: make([]types.CartItemInfo, 0, len(items)),
+	}
+
+	for _, item := range items {
+		pricing, ok := pricingMap[item.VariantId]
+		if !ok {
+			return nil, types.ErrProductVariantNotFound
+		}
+
+		selectedVariant, err := m.GetProductVariantWithAttributeSetById(item.VariantId)
+		if err != nil {
+			return nil, err
+		}
+
+		product, err := m.GetProductById(pricing.ProductId)
+		if err != nil {
+			return nil, err
+		}
+
+		shippingPrice := getDefaultShippingPrice(pricing.ShipmentFactor)
+
+		itemPrice := pricing.FinalPrice.Mul(item.Quantity)
+
+		res.TotalShipmentPrice = res.TotalShipmentPrice.Add(shippingPrice)
+		res.TotalVariantsPrice = res.TotalVariantsPrice.Add(itemPrice)
+		res.Fee = res.Fee.Add(itemPrice.MulRate(commissionRates[item.VariantId]))
+
+		res.Items = append(res.Items, types.CartItemInfo{
+			CartItem:          item,
+			VariantPrice:      pricing.FinalPrice,
+			ShippingPrice:     shippingPrice,
+			AvailableQuantity: pricing.Quantity,
+			SelectedVariant:   *selectedVariant,
+			Product:           *product,
+		})
+	}
+
+	return &res, nil
+}
+
+func (m *Manager) UpdateCartItem(
+	userId int,
+	variantId int,
+	p types.UpdateCartItemPayload,
+) error {
+	if p.Quantity < 1 {
+		return types.ErrInvalidCartItemQuantity
+	}
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`
+		UPDATE cart_items ci SET quantity = $1, updated_at = CURRENT_TIMESTAMP
+		FROM carts c
+		WHERE c.id = ci.cart_id AND c.user_id = $2 AND ci.variant_id = $3;
+	`, p.Quantity, userId, variantId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if affected == 0 {
+		tx.Rollback()
+		return types.ErrCartItemNotFound
+	}
+
+	err = checkCartItemQuantityAsDBTx(tx, variantId, p.Quantity)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+func (m *Manager) DeleteCartItem(userId int, variantId int) error {
+	res, err := m.db.Exec(`
+		DELETE FROM cart_items ci
+		USING carts c
+		WHERE c.id = ci.cart_id AND c.user_id = $1 AND ci.variant_id = $2;
+	`, userId, variantId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return types.ErrCartItemNotFound
+	}
+
+	return nil
+}
+
+func (m *Manager) ClearCart(userId int) error {
+	_, err := m.db.Exec(`
+		DELETE FROM cart_items ci
+		USING carts c
+		WHERE c.id = ci.cart_id AND c.user_id = $1;
+	`, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func checkCartItemQuantityAsDBTx(tx *sql.Tx, variantId int, quantity int) error {
+	rows, err := tx.Query(productVariantPricingQuery, pq.Array([]int{variantId}))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var pricing *types.ProductVariantPricing = nil
+	for rows.Next() {
+		pricing, err = scanProductVariantPricingRow(rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pricing == nil {
+		return types.ErrProductVariantNotFound
+	}
+
+	if pricing.Quantity < quantity {
+		return types.ErrProductQuantityIsNotEnough(pricing.ProductId)
+	}
+
+	return nil
+}
+
+func clearCartAsDBTx(tx *sql.Tx, cartId int) error {
+	_, err := tx.Exec("DELETE FROM cart_items WHERE cart_id = $1;", cartId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE carts SET updated_at = CURRENT_TIMESTAMP WHERE id = $1;",
+		cartId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanCartRow(rows *sql.Rows) (*types.Cart, error) {
+	n := new(types.Cart)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.UserId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func scanCartItemRow(rows *sql.Rows) (*types.CartItem, error) {
+	n := new(types.CartItem)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Quantity,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.CartId,
+		&n.VariantId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
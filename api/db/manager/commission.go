@@ -0,0 +1,555 @@
+package db_manager
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+// commissionRateQuery selects the commission rate of the variant whose id is
+// passed as the first argument when it is sold by the store whose id is passed
+// as the second one. A store rule wins over a platform rule, and between the
+// rules of the same owner the rule of the nearest category of the product wins
+// over the general one.
+const commissionRateQuery = `
+	WITH RECURSIVE ancestors AS (
+		SELECT pc.id, pc.parent_category_id, 0 AS depth FROM product_categories pc
+		JOIN products p ON p.subcategory_id = pc.id
+		JOIN product_variants pv ON pv.product_id = p.id
+		WHERE pv.id = $1
+		UNION ALL
+		SELECT pc.id, pc.parent_category_id, a.depth + 1 FROM product_categories pc
+		JOIN ancestors a ON pc.id = a.parent_category_id
+	)
+	SELECT cr.rate FROM commission_rules cr
+	LEFT JOIN ancestors a ON a.id = cr.category_id
+	WHERE (cr.store_id IS NULL OR cr.store_id = $2)
+	AND (cr.category_id IS NULL OR a.id IS NOT NULL)
+	ORDER BY cr.store_id IS NOT NULL DESC, a.depth ASC NULLS LAST
+	LIMIT 1;
+`
+
+// commissionRatesQuery selects the commission rates of many variants at once
+// in the same way as commissionRateQuery. The ids of the variants are passed
+// as the first argument and the ids of the stores that sell them as the second
+// one, in the same order.
+const commissionRatesQuery = `
+	WITH RECURSIVE lines AS (
+		SELECT * FROM unnest($1::int[], $2::int[]) AS l(variant_id, store_id)
+	), ancestors AS (
+		SELECT l.variant_id, pc.id, pc.parent_category_id, 0 AS depth FROM lines l
+		JOIN product_variants pv ON pv.id = l.variant_id
+		JOIN products p ON p.id = pv.product_id
+		JOIN product_categories pc ON pc.id = p.subcategory_id
+		UNION ALL
+		SELECT a.variant_id, pc.id, pc.parent_category_id, a.depth + 1 FROM product_categories pc
+		JOIN ancestors a ON pc.id = a.parent_category_id
+	)
+	SELECT l.variant_id, r.rate FROM lines l
+	JOIN LATERAL (
+		SELECT cr.rate FROM commission_rules cr
+		LEFT JOIN ancestors a ON a.id = cr.category_id AND a.variant_id = l.variant_id
+		WHERE (cr.store_id IS NULL OR cr.store_id = l.store_id)
+		AND (cr.category_id IS NULL OR a.id IS NOT NULL)
+		ORDER BY cr.store_id IS NOT NULL DESC, a.depth ASC NULLS LAST
+		LIMIT 1
+	) r ON TRUE;
+`
+
+func (m *Manager) CreateCommissionRule(p types.CreateCommissionRulePayload) (int, error) {
+	rowId := -1
+	err := m.db.QueryRow(
+		"INSERT INTO commission_rules (rate, store_id, category_id) VALUES ($1, $2, $3) RETURNING id;",
+		p.Rate,
+		p.StoreId,
+		p.CategoryId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) GetCommissionRules(
+	query types.CommissionRuleSearchQuery,
+) ([]types.CommissionRule, error) {
+	var base string
+	base = "SELECT * FROM commission_rules"
+
+	q, args := buildCommissionRuleSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []types.CommissionRule{}
+
+	for rows.Next() {
+		rule, err := scanCommissionRuleRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, *rule)
+	}
+
+	return rules, nil
+}
+
+func (m *Manager) GetCommissionRulesCount(query types.CommissionRuleSearchQuery) (int, error) {
+	var base string
+	base = "SELECT COUNT(*) as count FROM commission_rules"
+
+	q, args := buildCommissionRuleSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		err := rows.Scan(&count)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return count, nil
+}
+
+func (m *Manager) GetCommissionRuleById(id int) (*types.CommissionRule, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM commission_rules WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rule := new(types.CommissionRule)
+	rule.Id = -1
+
+	for rows.Next() {
+		rule, err = scanCommissionRuleRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.Id == -1 {
+		return nil, types.ErrCommissionRuleNotFound
+	}
+
+	return rule, nil
+}
+
+func (m *Manager) GetPlatformWallet() (*types.PlatformWallet, error) {
+	wallet := new(types.PlatformWallet)
+	err := m.db.QueryRow("SELECT balance, created_at, updated_at FROM platform_wallet;").
+		Scan(&wallet.Balance, &wallet.CreatedAt, &wallet.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, types.ErrPlatformWalletNotFound
+		}
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// GetCommissionReport returns the commission earned from the orders that were
+// paid in the period of the query.
+func (m *Manager) GetCommissionReport(
+	query types.CommissionReportQuery,
+) (*types.CommissionReport, error) {
+	base := `
+		SELECT
+			COUNT(DISTINCT opv.order_id),
+			COALESCE(SUM(opv.variant_price * opv.quantity - opv.discount), 0),
+			COALESCE(SUM(opv.commission), 0)
+		FROM order_product_variants opv
+		JOIN order_payments op ON op.order_id = opv.order_id
+		WHERE op.status = 'successful'
+	`
+
+	q, args := buildCommissionReportQuery(query, base, "", "")
+
+	report := new(types.CommissionReport)
+	err := m.db.QueryRow(q, args...).
+		Scan(&report.TotalOrders, &report.TotalSales, &report.TotalCommission)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetStoresCommissionReport returns the commission earned from each store in
+// the period of the query, the stores that earned the most come first.
+func (m *Manager) GetStoresCommissionReport(
+	query types.CommissionReportQuery,
+) ([]types.StoreCommissionReport, error) {
+	base := `
+		SELECT
+			s.id, s.name,
+			COUNT(DISTINCT opv.order_id),
+			SUM(opv.variant_price * opv.quantity - opv.discount),
+			SUM(opv.commission)
+		FROM order_product_variants opv
+		JOIN order_payments op ON op.order_id = opv.order_id
+		JOIN stores s ON s.id = opv.store_id
+		WHERE op.status = 'successful'
+	`
+
+	q, args := buildCommissionReportQuery(
+		query,
+		base,
+		"GROUP BY s.id, s.name",
+		"ORDER BY SUM(opv.commission) DESC, s.id",
+	)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []types.StoreCommissionReport{}
+
+	for rows.Next() {
+		report := types.StoreCommissionReport{}
+		err := rows.Scan(
+			&report.StoreId,
+			&report.StoreName,
+			&report.TotalOrders,
+			&report.TotalSales,
+			&report.TotalCommission,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// GetCategoriesCommissionReport returns the commission earned from the products
+// of each category in the period of the query, the categories that earned the
+// most come first. The lines are counted for the category that their products
+// are directly in.
+func (m *Manager) GetCategoriesCommissionReport(
+	query types.CommissionReportQuery,
+) ([]types.CategoryCommissionReport, error) {
+	base := `
+		SELECT
+			pc.id, pc.name,
+			COUNT(DISTINCT opv.order_id),
+			SUM(opv.variant_price * opv.quantity - opv.discount),
+			SUM(opv.commission)
+		FROM order_product_variants opv
+		JOIN order_payments op ON op.order_id = opv.order_id
+		JOIN product_variants pv ON pv.id = opv.variant_id
+		JOIN products p ON p.id = pv.product_id
+		JOIN product_categories pc ON pc.id = p.subcategory_id
+		WHERE op.status = 'successful'
+	`
+
+	q, args := buildCommissionReportQuery(
+		query,
+		base,
+		"GROUP BY pc.id, pc.name",
+		"ORDER BY SUM(opv.commission) DESC, pc.id",
+	)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []types.CategoryCommissionReport{}
+
+	for rows.Next() {
+		report := types.CategoryCommissionReport{}
+		err := rows.Scan(
+			&report.CategoryId,
+			&report.CategoryName,
+			&report.TotalOrders,
+			&report.TotalSales,
+			&report.TotalCommission,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (m *Manager) UpdateCommissionRule(id int, p types.UpdateCommissionRulePayload) error {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if p.Rate != nil {
+		clauses = append(clauses, fmt.Sprintf("rate = $%d", argsPos))
+		args = append(args, *p.Rate)
+		argsPos++
+	}
+
+	if len(clauses) == 0 {
+		return types.ErrNoFieldsReceivedToUpdate
+	}
+
+	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
+	args = append(args, time.Now())
+	argsPos++
+
+	args = append(args, id)
+	q := fmt.Sprintf(
+		"UPDATE commission_rules SET %s WHERE id = $%d",
+		strings.Join(clauses, ", "),
+		argsPos,
+	)
+
+	res, err := m.db.Exec(q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return types.ErrCommissionRuleNotFound
+	}
+
+	return nil
+}
+
+func (m *Manager) DeleteCommissionRule(id int) error {
+	isDefault := false
+	err := m.db.QueryRow(
+		"SELECT store_id IS NULL AND category_id IS NULL FROM commission_rules WHERE id = $1;",
+		id,
+	).
+		Scan(&isDefault)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.ErrCommissionRuleNotFound
+		}
+		return err
+	}
+
+	if isDefault {
+		return types.ErrCannotDeleteDefaultCommissionRule
+	}
+
+	_, err = m.db.Exec(
+		"DELETE FROM commission_rules WHERE id = $1 AND (store_id IS NOT NULL OR category_id IS NOT NULL);",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getCommissionRates returns the commission rates of the variants by their
+// ids, each variant is sold by the store at the same index of storeIds. The
+// variants without any rule have no commission.
+func (m *Manager) getCommissionRates(variantIds []int, storeIds []int) (map[int]float64, error) {
+	rows, err := m.db.Query(commissionRatesQuery, pq.Array(variantIds), pq.Array(storeIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make(map[int]float64, len(variantIds))
+
+	for rows.Next() {
+		var variantId int
+		var rate float64
+		if err := rows.Scan(&variantId, &rate); err != nil {
+			return nil, err
+		}
+
+		rates[variantId] = rate
+	}
+
+	return rates, nil
+}
+
+// applyCommissionAsDBTx sets the commission of the order lines and returns the
+// fee of the order, which is the sum of them. The commission of a line is
+// computed on its price after the coupon discount.
+func applyCommissionAsDBTx(
+	tx *sql.Tx,
+	lines []types.OrderProductVariantInsertData,
+) (types.Money, error) {
+	fee := types.Money{}
+
+	for i := range lines {
+		var rate float64
+		err := tx.QueryRow(commissionRateQuery, lines[i].VariantId, lines[i].StoreId).
+			Scan(&rate)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return types.Money{}, err
+		}
+
+		amount := lines[i].VariantPrice.Mul(lines[i].Quantity).Sub(lines[i].Discount)
+		if amount.IsNegative() {
+			amount = types.Money{}
+		}
+
+		lines[i].Commission = amount.MulRate(rate)
+		lines[i].CommissionRate = rate
+		fee = fee.Add(lines[i].Commission)
+	}
+
+	return fee, nil
+}
+
+func scanCommissionRuleRow(rows *sql.Rows) (*types.CommissionRule, error) {
+	n := new(types.CommissionRule)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Rate,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.StoreId,
+		&n.CategoryId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func buildCommissionRuleSearchQuery(
+	query types.CommissionRuleSearchQuery,
+	base string,
+) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if query.StoreId != nil {
+		clauses = append(clauses, fmt.Sprintf("store_id = $%d", argsPos))
+		args = append(args, *query.StoreId)
+		argsPos++
+	}
+
+	if query.CategoryId != nil {
+		clauses = append(clauses, fmt.Sprintf("category_id = $%d", argsPos))
+		args = append(args, *query.CategoryId)
+		argsPos++
+	}
+
+	q := base
+	if len(clauses) > 0 {
+		q += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	q += " ORDER BY id"
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	q += ";"
+	return q, args
+}
+
+// buildCommissionReportQuery adds the filters of the query to a report base
+// that already has a WHERE clause, followed by the given grouping and order.
+// The payment of an order is final once it is successful, so its last update
+// is the time the order was paid.
+func buildCommissionReportQuery(
+	query types.CommissionReportQuery,
+	base string,
+	groupBy string,
+	orderBy string,
+) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if query.StoreId != nil {
+		clauses = append(clauses, fmt.Sprintf("opv.store_id = $%d", argsPos))
+		args = append(args, *query.StoreId)
+		argsPos++
+	}
+
+	if query.AfterDate != nil {
+		clauses = append(clauses, fmt.Sprintf("op.updated_at >= $%d", argsPos))
+		args = append(args, *query.AfterDate)
+		argsPos++
+	}
+
+	if query.BeforeDate != nil {
+		clauses = append(clauses, fmt.Sprintf("op.updated_at <= $%d", argsPos))
+		args = append(args, *query.BeforeDate)
+		argsPos++
+	}
+
+	q := base
+	if len(clauses) > 0 {
+		q += " AND " + strings.Join(clauses, " AND ")
+	}
+
+	if groupBy != "" {
+		q += " " + groupBy
+	}
+
+	if orderBy != "" {
+		q += " " + orderBy
+	}
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	q += ";"
+	return q, args
+}
@@ -0,0 +1,532 @@
+package db_manager
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+func (m *Manager) CreateCoupon(p types.CreateCouponPayload) (int, error) {
+	if !p.DiscountType.IsValid() {
+		return -1, types.ErrInvalidCouponDiscountTypeEnum
+	}
+
+	err := validateCouponDiscount(p.DiscountType, p.Rate, p.Amount)
+	if err != nil {
+		return -1, err
+	}
+
+	if !p.ExpiresAt.After(p.StartsAt) {
+		return -1, types.ErrInvalidCouponValidityWindow
+	}
+
+	rowId := -1
+	err = m.db.QueryRow(
+		`INSERT INTO coupons (
+			code, description, discount_type, rate, amount, min_basket, usage_limit, per_user_limit,
+			starts_at, expires_at, store_id, category_id, tag_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id;`,
+		p.Code,
+		p.Description,
+		p.DiscountType,
+		p.Rate,
+		p.Amount,
+		p.MinBasket,
+		p.UsageLimit,
+		p.PerUserLimit,
+		p.StartsAt,
+		p.ExpiresAt,
+		p.StoreId,
+		p.CategoryId,
+		p.TagId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) GetCoupons(query types.CouponSearchQuery) ([]types.Coupon, error) {
+	var base string
+	base = "SELECT * FROM coupons"
+
+	q, args := buildCouponSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := []types.Coupon{}
+
+	for rows.Next() {
+		coupon, err := scanCouponRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		coupons = append(coupons, *coupon)
+	}
+
+	return coupons, nil
+}
+
+func (m *Manager) GetCouponsCount(query types.CouponSearchQuery) (int, error) {
+	var base string
+	base = "SELECT COUNT(*) as count FROM coupons"
+
+	q, args := buildCouponSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		err := rows.Scan(&count)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return count, nil
+}
+
+func (m *Manager) GetCouponById(id int) (*types.Coupon, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM coupons WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupon := new(types.Coupon)
+	coupon.Id = -1
+
+	for rows.Next() {
+		coupon, err = scanCouponRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if coupon.Id == -1 {
+		return nil, types.ErrCouponNotFound
+	}
+
+	return coupon, nil
+}
+
+func (m *Manager) GetCouponByCode(code string) (*types.Coupon, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM coupons WHERE code = $1;",
+		code,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupon := new(types.Coupon)
+	coupon.Id = -1
+
+	for rows.Next() {
+		coupon, err = scanCouponRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if coupon.Id == -1 {
+		return nil, types.ErrCouponNotFound
+	}
+
+	return coupon, nil
+}
+
+func (m *Manager) UpdateCoupon(id int, p types.UpdateCouponPayload) error {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if p.Description != nil {
+		clauses = append(clauses, fmt.Sprintf("description = $%d", argsPos))
+		args = append(args, *p.Description)
+		argsPos++
+	}
+
+	if p.Rate != nil || p.Amount != nil {
+		coupon, err := m.GetCouponById(id)
+		if err != nil {
+			return err
+		}
+
+		rate, amount := p.Rate, p.Amount
+		if rate == nil && coupon.Rate.Valid {
+			rate = &coupon.Rate.Float64
+		}
+		if amount == nil && coupon.Amount.Valid {
+			amount = &coupon.Amount.Money
+		}
+
+		err = validateCouponDiscount(coupon.DiscountType, rate, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.Rate != nil {
+		clauses = append(clauses, fmt.Sprintf("rate = $%d", argsPos))
+		args = append(args, *p.Rate)
+		argsPos++
+	}
+
+	if p.Amount != nil {
+		clauses = append(clauses, fmt.Sprintf("amount = $%d", argsPos))
+		args = append(args, *p.Amount)
+		argsPos++
+	}
+
+	if p.MinBasket != nil {
+		clauses = append(clauses, fmt.Sprintf("min_basket = $%d", argsPos))
+		args = append(args, *p.MinBasket)
+		argsPos++
+	}
+
+	if p.UsageLimit != nil {
+		clauses = append(clauses, fmt.Sprintf("usage_limit = $%d", argsPos))
+		args = append(args, *p.UsageLimit)
+		argsPos++
+	}
+
+	if p.PerUserLimit != nil {
+		clauses = append(clauses, fmt.Sprintf("per_user_limit = $%d", argsPos))
+		args = append(args, *p.PerUserLimit)
+		argsPos++
+	}
+
+	if p.StartsAt != nil {
+		clauses = append(clauses, fmt.Sprintf("starts_at = $%d", argsPos))
+		args = append(args, *p.StartsAt)
+		argsPos++
+	}
+
+	if p.ExpiresAt != nil {
+		clauses = append(clauses, fmt.Sprintf("expires_at = $%d", argsPos))
+		args = append(args, *p.ExpiresAt)
+		argsPos++
+	}
+
+	if p.IsActive != nil {
+		clauses = append(clauses, fmt.Sprintf("is_active = $%d", argsPos))
+		args = append(args, *p.IsActive)
+		argsPos++
+	}
+
+	if len(clauses) == 0 {
+		return types.ErrNoFieldsReceivedToUpdate
+	}
+
+	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
+	args = append(args, time.Now())
+	argsPos++
+
+	args = append(args, id)
+	q := fmt.Sprintf(
+		"UPDATE coupons SET %s WHERE id = $%d",
+		strings.Join(clauses, ", "),
+		argsPos,
+	)
+
+	res, err := m.db.Exec(q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return types.ErrCouponNotFound
+	}
+
+	return nil
+}
+
+func (m *Manager) DeleteCoupon(id int) error {
+	_, err := m.db.Exec(
+		"DELETE FROM coupons WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getUsableCouponByCodeAsDBTx locks the coupon with the given code and checks
+// that it is active and has not reached its usage limits for the given user.
+// Orders whose payment failed do not count as a usage of the coupon.
+func getUsableCouponByCodeAsDBTx(tx *sql.Tx, code string, userId int) (*types.Coupon, error) {
+	rows, err := tx.Query("SELECT * FROM coupons WHERE code = $1 FOR UPDATE;", code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupon := new(types.Coupon)
+	coupon.Id = -1
+
+	for rows.Next() {
+		coupon, err = scanCouponRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rows.Close()
+
+	if coupon.Id == -1 {
+		return nil, types.ErrCouponNotFound
+	}
+
+	var isInWindow bool
+	var totalUsage int
+	var userUsage int
+	err = tx.QueryRow(`
+		SELECT
+			c.starts_at <= CURRENT_TIMESTAMP AND c.expires_at > CURRENT_TIMESTAMP,
+			COUNT(op.id),
+			COUNT(op.id) FILTER (WHERE o.user_id = $2)
+		FROM coupons c
+		LEFT JOIN order_payments op ON op.coupon_id = c.id AND op.status <> $3
+		LEFT JOIN orders o ON o.id = op.order_id
+		WHERE c.id = $1
+		GROUP BY c.id;
+	`, coupon.Id, userId, types.OrderPaymentStatusFailed).
+		Scan(&isInWindow, &totalUsage, &userUsage)
+	if err != nil {
+		return nil, err
+	}
+
+	if !coupon.IsActive || !isInWindow {
+		return nil, types.ErrCouponIsNotActive
+	}
+
+	if coupon.UsageLimit.Valid && totalUsage >= int(coupon.UsageLimit.Int32) {
+		return nil, types.ErrCouponUsageLimitReached
+	}
+
+	if coupon.PerUserLimit.Valid && userUsage >= int(coupon.PerUserLimit.Int32) {
+		return nil, types.ErrCouponUsageLimitReached
+	}
+
+	return coupon, nil
+}
+
+// applyCouponAsDBTx sets the discount of the order lines that the coupon is
+// applicable to and returns the total discount of the order. The discount is
+// split between the eligible lines in proportion to their price, so each
+// store only bears the discount of its own products.
+func applyCouponAsDBTx(
+	tx *sql.Tx,
+	coupon *types.Coupon,
+	lines []types.OrderProductVariantInsertData,
+) (types.Money, error) {
+	variantIds := make([]int, len(lines))
+	for i, l := range lines {
+		variantIds[i] = l.VariantId
+	}
+
+	rows, err := tx.Query(`
+		SELECT pv.id FROM product_variants pv
+		JOIN products p ON p.id = pv.product_id
+		JOIN store_owned_products sop ON sop.product_id = p.id
+		WHERE pv.id = ANY($1)
+		AND ($2::INTEGER IS NULL OR sop.store_id = $2)
+		AND ($3::INTEGER IS NULL OR p.subcategory_id IN (
+			WITH RECURSIVE subcategories AS (
+				SELECT id FROM product_categories WHERE id = $3
+				UNION
+				SELECT pc.id FROM product_categories pc
+				JOIN subcategories s ON pc.parent_category_id = s.id
+			)
+			SELECT id FROM subcategories
+		))
+		AND ($4::INTEGER IS NULL OR EXISTS (
+			SELECT 1 FROM product_tag_assignments pta
+			WHERE pta.product_id = p.id AND pta.tag_id = $4
+		));
+	`, pq.Array(variantIds), coupon.StoreId, coupon.CategoryId, coupon.TagId)
+	if err != nil {
+		return types.Money{}, err
+	}
+	defer rows.Close()
+
+	eligible := map[int]bool{}
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			return types.Money{}, err
+		}
+
+		eligible[id] = true
+	}
+	rows.Close()
+
+	eligibleTotal := types.Money{}
+	eligibleLines := []int{}
+	eligibleLineTotals := []float64{}
+	for i, l := range lines {
+		if eligible[l.VariantId] {
+			lineTotal := l.VariantPrice.Mul(l.Quantity)
+			eligibleTotal = eligibleTotal.Add(lineTotal)
+			eligibleLines = append(eligibleLines, i)
+			eligibleLineTotals = append(eligibleLineTotals, lineTotal.Float64())
+		}
+	}
+
+	if eligibleTotal.IsZero() {
+		return types.Money{}, types.ErrCouponNotApplicable
+	}
+
+	if eligibleTotal.LessThan(coupon.MinBasket) {
+		return types.Money{}, types.ErrCouponMinBasketNotMet(coupon.MinBasket)
+	}
+
+	var discount types.Money
+	switch coupon.DiscountType {
+	case types.CouponDiscountTypePercentage:
+		discount = eligibleTotal.MulRate(coupon.Rate.Float64)
+	case types.CouponDiscountTypeFixed:
+		discount = types.MinMoney(coupon.Amount.Money, eligibleTotal)
+	}
+
+	// the discount is split between the eligible lines by their prices, the
+	// split discounts add up to the discount of the order exactly
+	for j, part := range discount.Allocate(eligibleLineTotals) {
+		lines[eligibleLines[j]].Discount = part
+	}
+
+	return discount, nil
+}
+
+// validateCouponDiscount checks that a percentage coupon only has a rate
+// between 0 and 1 and a fixed coupon only has a positive amount.
+func validateCouponDiscount(
+	discountType types.CouponDiscountType,
+	rate *float64,
+	amount *types.Money,
+) error {
+	switch discountType {
+	case types.CouponDiscountTypePercentage:
+		if rate == nil || *rate <= 0 || *rate > 1 {
+			return types.ErrInvalidCouponRate
+		}
+
+		if amount != nil {
+			return types.ErrInvalidCouponAmount
+		}
+	case types.CouponDiscountTypeFixed:
+		if amount == nil || !amount.IsPositive() {
+			return types.ErrInvalidCouponAmount
+		}
+
+		if rate != nil {
+			return types.ErrInvalidCouponRate
+		}
+	}
+
+	return nil
+}
+
+func scanCouponRow(rows *sql.Rows) (*types.Coupon, error) {
+	n := new(types.Coupon)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Code,
+		&n.Description,
+		&n.DiscountType,
+		&n.Rate,
+		&n.MinBasket,
+		&n.UsageLimit,
+		&n.PerUserLimit,
+		&n.StartsAt,
+		&n.ExpiresAt,
+		&n.IsActive,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.StoreId,
+		&n.CategoryId,
+		&n.TagId,
+		&n.Amount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func buildCouponSearchQuery(
+	query types.CouponSearchQuery,
+	base string,
+) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if query.Code != nil {
+		clauses = append(clauses, fmt.Sprintf("code ILIKE $%d", argsPos))
+		args = append(args, fmt.Sprintf("%%%s%%", *query.Code))
+		argsPos++
+	}
+
+	if query.StoreId != nil {
+		clauses = append(clauses, fmt.Sprintf("store_id = $%d", argsPos))
+		args = append(args, *query.StoreId)
+		argsPos++
+	}
+
+	if query.IsActive != nil {
+		clauses = append(clauses, fmt.Sprintf("is_active = $%d", argsPos))
+		args = append(args, *query.IsActive)
+		argsPos++
+	}
+
+	q := base
+	if len(clauses) > 0 {
+		q += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	q += ";"
+	return q, args
+}
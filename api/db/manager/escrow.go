@@ -0,0 +1,254 @@
+package db_manager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+// ReleaseDueEscrowHolds releases the held earnings whose release date is
+// reached, each hold is released in its own tx. Only the holds of the
+// shipments that have been sent are released, a shipment that is not sent
+// yet keeps its hold until it is sent or cancelled. A hold that fails does
+// not stop the holds after it, the failures are returned together with the
+// ids of their holds. It returns the number of released holds.
+func (m *Manager) ReleaseDueEscrowHolds() (int, error) {
+	rows, err := m.db.Query(`
+		SELECT eh.id FROM escrow_holds eh
+		JOIN order_shipments os ON os.order_id = eh.order_id AND os.store_id = eh.store_id
+		WHERE eh.status = $1 AND eh.release_at <= $2 AND os.status IN ($3, $4)
+		ORDER BY eh.id;
+	`,
+		types.EscrowHoldStatusHeld,
+		time.Now(),
+		types.OrderShipmentStatusOnTheWay,
+		types.OrderShipmentStatusDelivered,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	ids := []int{}
+	for rows.Next() {
+		id := -1
+		err = rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return -1, err
+		}
+
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	released := 0
+	failures := []error{}
+	for _, id := range ids {
+		err := m.releaseEscrowHold(id)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("escrow hold %d: %w", id, err))
+			continue
+		}
+
+		released++
+	}
+
+	return released, errors.Join(failures...)
+}
+
+func (m *Manager) releaseEscrowHold(id int) error {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = releaseEscrowHoldAsDBTx(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+func createEscrowHoldAsDBTx(tx *sql.Tx, d types.EscrowHoldInsertData) error {
+	_, err := tx.Exec(
+		"INSERT INTO escrow_holds (amount, release_at, order_id, store_id, wallet_id) VALUES ($1, $2, $3, $4, $5);",
+		d.Amount,
+		d.ReleaseAt,
+		d.OrderId,
+		d.StoreId,
+		d.WalletId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// lockHeldEscrowHoldAsDBTx locks the held earnings of a store in an order
+// until the end of the tx and returns the id of the hold with the amount that
+// is not refunded yet. The id is -1 if nothing is held.
+func lockHeldEscrowHoldAsDBTx(tx *sql.Tx, orderId int, storeId int) (int, types.Money, error) {
+	holdId := -1
+	remaining := types.Money{}
+	err := tx.QueryRow(`
+		SELECT id, amount - refunded_amount FROM escrow_holds
+		WHERE order_id = $1 AND store_id = $2 AND status = $3
+		FOR UPDATE;
+	`, orderId, storeId, types.EscrowHoldStatusHeld).
+		Scan(&holdId, &remaining)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, types.Money{}, nil
+		}
+		return -1, types.Money{}, err
+	}
+
+	return holdId, remaining, nil
+}
+
+// releaseEscrowHoldAsDBTx moves the held earnings that are not refunded to
+// the wallet of the store owner, a hold that is already released is left
+// as is.
+func releaseEscrowHoldAsDBTx(tx *sql.Tx, id int) error {
+	var status types.EscrowHoldStatus
+	walletId := -1
+	orderId := -1
+	storeId := -1
+	remaining := types.Money{}
+	err := tx.QueryRow(`
+		SELECT status, wallet_id, order_id, store_id, amount - refunded_amount
+		FROM escrow_holds WHERE id = $1
+		FOR UPDATE;
+	`, id).
+		Scan(&status, &walletId, &orderId, &storeId, &remaining)
+	if err != nil {
+		return err
+	}
+
+	if status != types.EscrowHoldStatusHeld {
+		return nil
+	}
+
+	walletAccountId, _, err := lockWalletLedgerAccountAsDBTx(tx, walletId)
+	if err != nil {
+		return err
+	}
+
+	escrowAccountId, err := getLedgerAccountIdByKindAsDBTx(tx, types.LedgerAccountKindEscrow)
+	if err != nil {
+		return err
+	}
+
+	_, err = postJournalEntryAsDBTx(tx, types.JournalEntryInsertData{
+		Kind:        types.JournalEntryKindEscrowRelease,
+		Description: fmt.Sprintf("Release of order #%d for store #%d", orderId, storeId),
+		OrderId:     &orderId,
+		Postings: []types.LedgerPostingInsertData{
+			{AccountId: escrowAccountId, Amount: remaining.Neg()},
+			{AccountId: walletAccountId, Amount: remaining},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(
+		"UPDATE escrow_holds SET status = $1, released_at = $2, updated_at = $2 WHERE id = $3;",
+		types.EscrowHoldStatusReleased,
+		now,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// refundEscrowHoldAsDBTx gives the held earnings that are not refunded yet
+// back to the wallet of the customer of the order, a hold that is not held
+// anymore is left as is.
+func refundEscrowHoldAsDBTx(tx *sql.Tx, id int) error {
+	var status types.EscrowHoldStatus
+	customerWalletId := -1
+	orderId := -1
+	storeId := -1
+	remaining := types.Money{}
+	err := tx.QueryRow(`
+		SELECT eh.status, w.id, eh.order_id, eh.store_id, eh.amount - eh.refunded_amount
+		FROM escrow_holds eh
+		JOIN orders o ON o.id = eh.order_id
+		JOIN wallets w ON w.user_id = o.user_id
+		WHERE eh.id = $1
+		FOR UPDATE OF eh;
+	`, id).
+		Scan(&status, &customerWalletId, &orderId, &storeId, &remaining)
+	if err != nil {
+		return err
+	}
+
+	if status != types.EscrowHoldStatusHeld {
+		return nil
+	}
+
+	if remaining.IsPositive() {
+		customerAccountId, _, err := lockWalletLedgerAccountAsDBTx(tx, customerWalletId)
+		if err != nil {
+			return err
+		}
+
+		escrowAccountId, err := getLedgerAccountIdByKindAsDBTx(tx, types.LedgerAccountKindEscrow)
+		if err != nil {
+			return err
+		}
+
+		refundTxId, err := createSuccessfulWalletTransactionAsDBTx(tx, types.CreateWalletTransactionPayload{
+			Amount:   remaining,
+			TxType:   types.TransactionTypeRefund,
+			WalletId: customerWalletId,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = postJournalEntryAsDBTx(tx, types.JournalEntryInsertData{
+			Kind:                types.JournalEntryKindEscrowRefund,
+			Description:         fmt.Sprintf("Refund of order #%d for store #%d", orderId, storeId),
+			WalletTransactionId: &refundTxId,
+			OrderId:             &orderId,
+			Postings: []types.LedgerPostingInsertData{
+				{AccountId: escrowAccountId, Amount: remaining.Neg()},
+				{AccountId: customerAccountId, Amount: remaining},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		"UPDATE escrow_holds SET status = $1, refunded_amount = amount, updated_at = $2 WHERE id = $3;",
+		types.EscrowHoldStatusRefunded,
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -198,7 +198,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	// get permission groups
 	pgroups, err := s.manager.GetPermissionGroups(types.PermissionGroupSearchQuery{})
 	s.Require().NoError(err)
-	s.Require().Equal(16, len(pgroups))
+	s.Require().Equal(20, len(pgroups))
 
 	// get permission groups with query
 	pgroups, err = s.manager.GetPermissionGroups(types.PermissionGroupSearchQuery{
@@ -278,7 +278,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 		types.PermissionGroupSearchQuery{},
 	)
 	s.Require().NoError(err)
-	s.Require().Equal(16, len(pgroupsWithPermissions))
+	s.Require().Equal(20, len(pgroupsWithPermissions))
 
 	found = false
 
@@ -454,13 +454,13 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().NoError(err)
 	s.Require().NotNil(userWallet)
 	s.Require().Equal(user.Id, userWallet.UserId)
-	s.Require().Equal(float64(0), userWallet.Balance)
+	s.Require().Equal(types.Money{}, userWallet.Balance)
 
 	user2Wallet, err := s.manager.GetUserWallet(userId2)
 	s.Require().NoError(err)
 	s.Require().NotNil(user2Wallet)
 	s.Require().Equal(userId2, user2Wallet.UserId)
-	s.Require().Equal(float64(0), user2Wallet.Balance)
+	s.Require().Equal(types.Money{}, user2Wallet.Balance)
 
 	phoneId, err := s.manager.CreateUserPhoneNumber(types.CreateUserPhoneNumberPayload{
 		CountryCode: "+98",
@@ -551,7 +551,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().Len(userPhones, 1)
 
 	tx1Id, err := s.manager.CreateWalletTransaction(types.CreateWalletTransactionPayload{
-		Amount:   100,
+		Amount:   types.MoneyFromFloat(100),
 		TxType:   types.TransactionTypeDeposit,
 		WalletId: userWallet.Id,
 	})
@@ -559,7 +559,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().Greater(tx1Id, 0)
 
 	tx2Id, err := s.manager.CreateWalletTransaction(types.CreateWalletTransactionPayload{
-		Amount:   200,
+		Amount:   types.MoneyFromFloat(200),
 		TxType:   types.TransactionTypeWithdraw,
 		WalletId: userWallet.Id,
 	})
@@ -567,7 +567,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().Greater(tx2Id, 1)
 
 	tx3Id, err := s.manager.CreateWalletTransaction(types.CreateWalletTransactionPayload{
-		Amount:   10000,
+		Amount:   types.MoneyFromFloat(10000),
 		TxType:   types.TransactionTypeDeposit,
 		WalletId: user2Wallet.Id,
 	})
@@ -575,14 +575,14 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().Greater(tx3Id, 2)
 
 	_, err = s.manager.CreateWalletTransaction(types.CreateWalletTransactionPayload{
-		Amount:   200,
+		Amount:   types.MoneyFromFloat(200),
 		TxType:   types.TransactionTypeWithdraw,
 		WalletId: 999999,
 	})
 	s.Require().Error(err)
 
 	_, err = s.manager.CreateWalletTransaction(types.CreateWalletTransactionPayload{
-		Amount:   200,
+		Amount:   types.MoneyFromFloat(200),
 		TxType:   "ERROR",
 		WalletId: userWallet.Id,
 	})
@@ -680,7 +680,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	user2Wallet, err = s.manager.GetUserWallet(userId2)
 	s.Require().NoError(err)
 	s.Require().NotNil(user2Wallet)
-	s.Require().Equal(user2Wallet.Balance, float64(10000))
+	s.Require().Equal(user2Wallet.Balance, types.MoneyFromFloat(10000))
 
 	storeId, err := s.manager.CreateStore(types.CreateStorePayload{
 		Name:        "STORE",
@@ -784,7 +784,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	product1Id, err := s.manager.CreateProductBase(types.CreateProductBasePayload{
 		Name:           "furniture",
 		Slug:           "furniture",
-		Price:          1000,
+		Price:          types.MoneyFromFloat(1000),
 		Description:    "PRODUCT1",
 		ShipmentFactor: 0.3,
 		SubcategoryId:  prodCat3Id,
@@ -796,7 +796,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	product2Id, err := s.manager.CreateProductBase(types.CreateProductBasePayload{
 		Name:           "xbox controller",
 		Slug:           "xbox-controller",
-		Price:          1000,
+		Price:          types.MoneyFromFloat(1000),
 		Description:    "PRODUCT2",
 		ShipmentFactor: 0.25,
 		SubcategoryId:  prodCat1Id,
@@ -808,7 +808,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	product3Id, err := s.manager.CreateProductBase(types.CreateProductBasePayload{
 		Name:           "xbox series x",
 		Slug:           "xbox-series-x",
-		Price:          5000,
+		Price:          types.MoneyFromFloat(5000),
 		Description:    "PRODUCT3",
 		ShipmentFactor: 0.1,
 		SubcategoryId:  prodCat2Id,
@@ -1192,14 +1192,13 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().NoError(err)
 	s.Require().Len(productOffers, 1)
 
-	prod1Inv, prod1InStock, err := s.manager.GetProductInventory(prod1.Id)
+	prod1Inv, prod1Reserved, err := s.manager.GetProductInventory(prod1.Id)
 	s.Require().NoError(err)
 	s.Require().Equal(prod1Inv, 620)
-	s.Require().Equal(prod1InStock, true)
+	s.Require().Equal(prod1Reserved, 0)
 
 	orderId, err := s.manager.CreateOrder(types.CreateOrderPayload{
-		UserId:      userId2,
-		ArrivalDate: time.Date(2025, 11, 2, 5, 4, 4, 3, time.UTC),
+		UserId: userId2,
 		ProductVariants: []types.OrderProductVariantAssignmentPayload{
 			{
 				Quantity:  1,
@@ -1216,8 +1215,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().Greater(orderId, 0)
 
 	order2Id, err := s.manager.CreateOrder(types.CreateOrderPayload{
-		UserId:      userId2,
-		ArrivalDate: time.Date(2025, 11, 2, 5, 4, 4, 3, time.UTC),
+		UserId: userId2,
 		ProductVariants: []types.OrderProductVariantAssignmentPayload{
 			{
 				Quantity:  100,
@@ -1233,9 +1231,17 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().NoError(err)
 	s.Require().Greater(order2Id, 1)
 
+	orderReservations, err := s.manager.GetOrderInventoryReservations(orderId)
+	s.Require().NoError(err)
+	s.Require().Len(orderReservations, 2)
+
+	prod1Inv, prod1Reserved, err = s.manager.GetProductInventory(prod1.Id)
+	s.Require().NoError(err)
+	s.Require().Equal(prod1Inv, 620)
+	s.Require().Equal(prod1Reserved, 152)
+
 	_, err = s.manager.CreateOrder(types.CreateOrderPayload{
-		UserId:      userId2,
-		ArrivalDate: time.Date(2025, 11, 2, 5, 4, 4, 3, time.UTC),
+		UserId: userId2,
 		ProductVariants: []types.OrderProductVariantAssignmentPayload{
 			{
 				Quantity:  1,
@@ -1251,8 +1257,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().Error(err)
 
 	_, err = s.manager.CreateOrder(types.CreateOrderPayload{
-		UserId:      userId2,
-		ArrivalDate: time.Date(2025, 11, 2, 5, 4, 4, 3, time.UTC),
+		UserId: userId2,
 		ProductVariants: []types.OrderProductVariantAssignmentPayload{
 			{
 				Quantity:  1,
@@ -1268,8 +1273,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().Error(err)
 
 	_, err = s.manager.CreateOrder(types.CreateOrderPayload{
-		UserId:      userId2,
-		ArrivalDate: time.Date(2025, 11, 2, 5, 4, 4, 3, time.UTC),
+		UserId: userId2,
 		ProductVariants: []types.OrderProductVariantAssignmentPayload{
 			{
 				Quantity:  1,
@@ -1316,7 +1320,7 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().NoError(err)
 	s.Require().Len(orderProdVariantsInfo, 2)
 
-	err = s.manager.UpdateOrderPayment(orderId, types.UpdateOrderPaymentPayload{
+	err = s.manager.UpdateOrderPayment(orderId, userId2, types.UpdateOrderPaymentPayload{
 		Status: utils.Ptr(types.OrderPaymentStatusSuccessful),
 	})
 	s.Require().NoError(err)
@@ -1334,7 +1338,16 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().NoError(err)
 	s.Require().NotNil(user2Wallet)
 	s.Require().Equal(userId2, user2Wallet.UserId)
-	s.Require().Less(user2Wallet.Balance, float64(10000))
+	s.Require().True(user2Wallet.Balance.LessThan(types.MoneyFromFloat(10000)))
+
+	orderReservations, err = s.manager.GetOrderInventoryReservations(orderId)
+	s.Require().NoError(err)
+	s.Require().Len(orderReservations, 0)
+
+	prod1Inv, prod1Reserved, err = s.manager.GetProductInventory(prod1.Id)
+	s.Require().NoError(err)
+	s.Require().Equal(prod1Inv, 618)
+	s.Require().Equal(prod1Reserved, 150)
 
 	store1Orders, err := s.manager.GetOrders(types.OrderSearchQuery{
 		StoreId: &storeId,
@@ -1356,11 +1369,134 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	s.Require().NoError(err)
 	s.Require().False(isStore2HasPartInOrder1)
 
+	orderShipments, err := s.manager.GetOrderShipments(orderId)
+	s.Require().NoError(err)
+	s.Require().Len(orderShipments, 1)
+	s.Require().Equal(orderShipments[0].StoreId, storeId)
+
+	store1Order, err := s.manager.GetStoreOrderById(orderId, storeId)
+	s.Require().NoError(err)
+	s.Require().Equal(store1Order.Id, orderId)
+	s.Require().Equal(store1Order.Shipment.StoreId, storeId)
+	s.Require().Equal(store1Order.TotalProducts, 2)
+
+	_, err = s.manager.GetStoreOrderById(orderId, store2Id)
+	s.Require().ErrorIs(err, types.ErrOrderNotFound)
+
+	store1OrderProdVariants, err := s.manager.GetStoreOrderProductVariantsInfo(orderId, storeId)
+	s.Require().NoError(err)
+	s.Require().Len(store1OrderProdVariants, 2)
+
+	err = s.manager.UpdateOrderShipment(orderId, storeId, userId, types.UpdateOrderShipmentPayload{
+		Status:         utils.Ptr(types.OrderShipmentStatusOnTheWay),
+		TrackingNumber: utils.Ptr("TRK-1"),
+	})
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateOrderShipment(orderId, store2Id, userId, types.UpdateOrderShipmentPayload{
+		Status: utils.Ptr(types.OrderShipmentStatusOnTheWay),
+	})
+	s.Require().ErrorIs(err, types.ErrOrderShipmentNotFound)
+
+	order, err = s.manager.GetOrderById(orderId)
+	s.Require().NoError(err)
+	s.Require().Equal(order.ShipmentStatus, types.OrderShipmentStatusOnTheWay)
+
+	orderWithFullInfo, err = s.manager.GetOrderWithFullInfoById(orderId)
+	s.Require().NoError(err)
+	s.Require().Len(orderWithFullInfo.Shipments, 1)
+	s.Require().Equal(orderWithFullInfo.Shipments[0].TrackingNumber.String, "TRK-1")
+
+	_, err = s.manager.CreateOrderReturn(userId, types.CreateOrderReturnPayload{
+		OrderId: orderId,
+		Reason:  "not mine",
+		Items: []types.OrderReturnItemPayload{
+			{Quantity: 1, OrderProductVariantId: orderProdVariants[0].Id},
+		},
+	})
+	s.Require().ErrorIs(err, types.ErrCannotAccessOrder)
+
+	_, err = s.manager.CreateOrderReturn(userId2, types.CreateOrderReturnPayload{
+		OrderId: orderId,
+		Reason:  "too many",
+		Items: []types.OrderReturnItemPayload{
+			{
+				Quantity:              orderProdVariants[0].Quantity + 1,
+				OrderProductVariantId: orderProdVariants[0].Id,
+			},
+		},
+	})
+	s.Require().Error(err)
+
+	returnId, err := s.manager.CreateOrderReturn(userId2, types.CreateOrderReturnPayload{
+		OrderId: orderId,
+		Reason:  "damaged",
+		Items: []types.OrderReturnItemPayload{
+			{Quantity: 1, OrderProductVariantId: orderProdVariants[0].Id},
+		},
+	})
+	s.Require().NoError(err)
+
+	orderReturn, err := s.manager.GetOrderReturnWithItemsById(returnId)
+	s.Require().NoError(err)
+	s.Require().Equal(orderReturn.Status, types.OrderReturnStatusPending)
+	s.Require().Equal(orderReturn.StoreId, storeId)
+	s.Require().Equal(orderReturn.TotalRefund, orderProdVariants[0].VariantPrice)
+	s.Require().Len(orderReturn.Items, 1)
+
+	err = s.manager.RefundOrderReturn(returnId, types.ReceiveOrderReturnPayload{})
+	s.Require().Error(err)
+
+	err = s.manager.ReviewOrderReturn(returnId, types.ReviewOrderReturnPayload{
+		Status:    types.OrderReturnStatusApproved,
+		StoreNote: utils.Ptr("send it back"),
+	})
+	s.Require().NoError(err)
+
+	err = s.manager.ReviewOrderReturn(returnId, types.ReviewOrderReturnPayload{
+		Status: types.OrderReturnStatusRejected,
+	})
+	s.Require().Error(err)
+
+	user2WalletBeforeRefund, err := s.manager.GetUserWallet(userId2)
+	s.Require().NoError(err)
+
+	err = s.manager.RefundOrderReturn(returnId, types.ReceiveOrderReturnPayload{
+		Restock: true,
+	})
+	s.Require().NoError(err)
+
+	user2WalletAfterRefund, err := s.manager.GetUserWallet(userId2)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		user2WalletBeforeRefund.Balance.Add(orderReturn.TotalRefund),
+		user2WalletAfterRefund.Balance,
+	)
+
+	orderReturn, err = s.manager.GetOrderReturnWithItemsById(returnId)
+	s.Require().NoError(err)
+	s.Require().Equal(orderReturn.Status, types.OrderReturnStatusRefunded)
+	s.Require().True(orderReturn.Restocked)
+	s.Require().True(orderReturn.RefundTxId.Valid)
+	s.Require().False(orderReturn.ChargeTxId.Valid)
+
+	user2Returns, err := s.manager.GetOrderReturns(types.OrderReturnSearchQuery{
+		UserId: &userId2,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(user2Returns, 1)
+
+	store2ReturnsCount, err := s.manager.GetOrderReturnsCount(types.OrderReturnSearchQuery{
+		StoreId: &store2Id,
+	})
+	s.Require().NoError(err)
+	s.Require().Equal(store2ReturnsCount, 0)
+
 	newProductId, err := s.manager.CreateProduct(types.CreateProductPayload{
 		Base: types.CreateProductBasePayload{
 			Name:          "new prod",
 			Slug:          "new-prod",
-			Price:         10310,
+			Price:         types.MoneyFromFloat(10310),
 			Description:   "NEW PRODUCT",
 			SubcategoryId: prodCat1Id,
 			StoreId:       storeWithSettings.Id,
@@ -1412,4 +1548,2223 @@ func (s *DBIntegrationTestSuite) TestOperations() {
 	newProduct, err := s.manager.GetProductExtendedById(newProductId)
 	s.Require().NoError(err)
 	s.Require().Equal(newProduct.Id, newProductId)
+
+	_, err = s.manager.GetUserCart(userId2)
+	s.Require().ErrorIs(err, types.ErrCartNotFound)
+
+	cartItemId, err := s.manager.AddCartItem(userId2, types.AddCartItemPayload{
+		Quantity:  1,
+		VariantId: var11Id,
+	})
+	s.Require().NoError(err)
+	s.Require().Greater(cartItemId, 0)
+
+	sameCartItemId, err := s.manager.AddCartItem(userId2, types.AddCartItemPayload{
+		Quantity:  2,
+		VariantId: var11Id,
+	})
+	s.Require().NoError(err)
+	s.Require().Equal(cartItemId, sameCartItemId)
+
+	_, err = s.manager.AddCartItem(userId2, types.AddCartItemPayload{
+		Quantity:  2000000000,
+		VariantId: var31Id,
+	})
+	s.Require().Error(err)
+
+	_, err = s.manager.AddCartItem(userId2, types.AddCartItemPayload{
+		Quantity:  1,
+		VariantId: 999999,
+	})
+	s.Require().Error(err)
+
+	_, err = s.manager.AddCartItem(userId2, types.AddCartItemPayload{
+		Quantity:  1,
+		VariantId: var31Id,
+	})
+	s.Require().NoError(err)
+
+	cart, err := s.manager.GetUserCartWithItemsInfo(userId2)
+	s.Require().NoError(err)
+	s.Require().Equal(cart.UserId, userId2)
+	s.Require().Len(cart.Items, 2)
+	s.Require().Equal(cart.Items[0].VariantId, var11Id)
+	s.Require().Equal(cart.Items[0].Quantity, 3)
+	s.Require().True(cart.TotalVariantsPrice.IsPositive())
+
+	err = s.manager.UpdateCartItem(userId2, var11Id, types.UpdateCartItemPayload{
+		Quantity: 2,
+	})
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateCartItem(userId, var11Id, types.UpdateCartItemPayload{
+		Quantity: 2,
+	})
+	s.Require().ErrorIs(err, types.ErrCartItemNotFound)
+
+	err = s.manager.DeleteCartItem(userId2, var31Id)
+	s.Require().NoError(err)
+
+	err = s.manager.DeleteCartItem(userId2, var31Id)
+	s.Require().ErrorIs(err, types.ErrCartItemNotFound)
+
+	cartItems, err := s.manager.GetCartItems(cart.Id)
+	s.Require().NoError(err)
+	s.Require().Len(cartItems, 1)
+	s.Require().Equal(cartItems[0].Quantity, 2)
+
+	cartOrderId, err := s.manager.CheckoutCart(userId2, types.CheckoutCartPayload{
+		ReceiverAddressId: addr2Id,
+	})
+	s.Require().NoError(err)
+	s.Require().Greater(cartOrderId, order2Id)
+
+	cartOrderProdVariants, err := s.manager.GetOrderProductVariants(cartOrderId)
+	s.Require().NoError(err)
+	s.Require().Len(cartOrderProdVariants, 1)
+	s.Require().Equal(cartOrderProdVariants[0].Quantity, 2)
+
+	cartItems, err = s.manager.GetCartItems(cart.Id)
+	s.Require().NoError(err)
+	s.Require().Len(cartItems, 0)
+
+	_, err = s.manager.CheckoutCart(userId2, types.CheckoutCartPayload{
+		ReceiverAddressId: addr2Id,
+	})
+	s.Require().ErrorIs(err, types.ErrCartIsEmpty)
+
+	_, err = s.db.Exec(
+		"UPDATE inventory_reservations SET expires_at = CURRENT_TIMESTAMP - INTERVAL '1 minute' WHERE order_id = ANY(ARRAY[$1, $2]::INTEGER[]);",
+		order2Id,
+		cartOrderId,
+	)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateOrderPayment(cartOrderId, userId2, types.UpdateOrderPaymentPayload{
+		Status: utils.Ptr(types.OrderPaymentStatusSuccessful),
+	})
+	s.Require().Error(err)
+
+	cancelledOrders, err := s.manager.CancelExpiredPendingOrders()
+	s.Require().NoError(err)
+	s.Require().Equal(cancelledOrders, 2)
+
+	order2Reservations, err := s.manager.GetOrderInventoryReservations(order2Id)
+	s.Require().NoError(err)
+	s.Require().Len(order2Reservations, 0)
+
+	order2WithFullInfo, err := s.manager.GetOrderWithFullInfoById(order2Id)
+	s.Require().NoError(err)
+	s.Require().Equal(order2WithFullInfo.Payment.Status, types.OrderPaymentStatusFailed)
+	s.Require().Equal(order2WithFullInfo.ShipmentStatus, types.OrderShipmentStatusCancelled)
+	for _, shipment := range order2WithFullInfo.Shipments {
+		s.Require().Equal(shipment.Status, types.OrderShipmentStatusCancelled)
+	}
+
+	cancelledOrders, err = s.manager.CancelExpiredPendingOrders()
+	s.Require().NoError(err)
+	s.Require().Equal(cancelledOrders, 0)
+
+	couponId, err := s.manager.CreateCoupon(types.CreateCouponPayload{
+		Code:         "SAVE10",
+		DiscountType: types.CouponDiscountTypePercentage,
+		Rate:         utils.Ptr(0.1),
+		UsageLimit:   utils.Ptr(1),
+		StartsAt:     time.Now().Add(-time.Hour),
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+		StoreId:      &storeId,
+	})
+	s.Require().NoError(err)
+	s.Require().Greater(couponId, 0)
+
+	_, err = s.manager.CreateCoupon(types.CreateCouponPayload{
+		Code:         "SAVE10",
+		DiscountType: types.CouponDiscountTypeFixed,
+		Amount:       utils.Ptr(types.MoneyFromMinor(500)),
+		StartsAt:     time.Now().Add(-time.Hour),
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+	})
+	s.Require().Error(err)
+
+	_, err = s.manager.CreateCoupon(types.CreateCouponPayload{
+		Code:         "TOOMUCH",
+		DiscountType: types.CouponDiscountTypePercentage,
+		Rate:         utils.Ptr(1.5),
+		StartsAt:     time.Now().Add(-time.Hour),
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidCouponRate)
+
+	_, err = s.manager.CreateCoupon(types.CreateCouponPayload{
+		Code:         "MIXED",
+		DiscountType: types.CouponDiscountTypePercentage,
+		Rate:         utils.Ptr(0.2),
+		Amount:       utils.Ptr(types.MoneyFromMinor(500)),
+		StartsAt:     time.Now().Add(-time.Hour),
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidCouponAmount)
+
+	otherStoreCouponId, err := s.manager.CreateCoupon(types.CreateCouponPayload{
+		Code:         "STORE2",
+		DiscountType: types.CouponDiscountTypeFixed,
+		Amount:       utils.Ptr(types.MoneyFromMinor(500)),
+		StartsAt:     time.Now().Add(-time.Hour),
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+		StoreId:      &store2Id,
+	})
+	s.Require().NoError(err)
+
+	coupon, err := s.manager.GetCouponByCode("SAVE10")
+	s.Require().NoError(err)
+	s.Require().Equal(coupon.Id, couponId)
+	s.Require().Equal(coupon.IsActive, true)
+	s.Require().Equal(coupon.Rate.Float64, 0.1)
+	s.Require().Equal(coupon.Amount.Valid, false)
+
+	otherStoreCoupon, err := s.manager.GetCouponById(otherStoreCouponId)
+	s.Require().NoError(err)
+	s.Require().Equal(otherStoreCoupon.Amount.Money, types.MoneyFromMinor(500))
+	s.Require().Equal(otherStoreCoupon.Rate.Valid, false)
+
+	err = s.manager.UpdateCoupon(otherStoreCouponId, types.UpdateCouponPayload{
+		Rate: utils.Ptr(0.2),
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidCouponRate)
+
+	err = s.manager.UpdateCoupon(otherStoreCouponId, types.UpdateCouponPayload{
+		Amount: utils.Ptr(types.MoneyFromMinor(750)),
+	})
+	s.Require().NoError(err)
+
+	coupons, err := s.manager.GetCoupons(types.CouponSearchQuery{
+		Code: utils.Ptr("save"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(coupons, 1)
+
+	couponOrderPayload := types.CreateOrderPayload{
+		UserId: userId2,
+		ProductVariants: []types.OrderProductVariantAssignmentPayload{
+			{
+				Quantity:  2,
+				VariantId: var11Id,
+			},
+			{
+				Quantity:  1,
+				VariantId: var31Id,
+			},
+		},
+		ReceiverAddressId: addr2Id,
+	}
+
+	couponOrderPayload.CouponCode = utils.Ptr("NOPE")
+	_, err = s.manager.CreateOrder(couponOrderPayload)
+	s.Require().ErrorIs(err, types.ErrCouponNotFound)
+
+	couponOrderPayload.CouponCode = utils.Ptr("STORE2")
+	_, err = s.manager.CreateOrder(couponOrderPayload)
+	s.Require().ErrorIs(err, types.ErrCouponNotApplicable)
+
+	couponOrderPayload.CouponCode = utils.Ptr("SAVE10")
+	couponOrderId, err := s.manager.CreateOrder(couponOrderPayload)
+	s.Require().NoError(err)
+
+	couponOrder, err := s.manager.GetOrderWithFullInfoById(couponOrderId)
+	s.Require().NoError(err)
+	s.Require().Equal(couponOrder.Payment.TotalVariantsPrice.MulRate(0.1), couponOrder.Payment.Discount)
+	s.Require().Equal(couponOrder.Payment.CouponId.Valid, true)
+	s.Require().Equal(int(couponOrder.Payment.CouponId.Int32), couponId)
+
+	couponOrderProdVariants, err := s.manager.GetOrderProductVariants(couponOrderId)
+	s.Require().NoError(err)
+
+	linesDiscount := types.Money{}
+	for _, v := range couponOrderProdVariants {
+		linesDiscount = linesDiscount.Add(v.Discount)
+	}
+	s.Require().Equal(couponOrder.Payment.Discount, linesDiscount)
+
+	_, err = s.manager.CreateOrder(couponOrderPayload)
+	s.Require().ErrorIs(err, types.ErrCouponUsageLimitReached)
+
+	err = s.manager.UpdateOrderPayment(couponOrderId, userId2, types.UpdateOrderPaymentPayload{
+		Status: utils.Ptr(types.OrderPaymentStatusFailed),
+	})
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateCoupon(couponId, types.UpdateCouponPayload{
+		IsActive: utils.Ptr(false),
+	})
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateOrder(couponOrderPayload)
+	s.Require().ErrorIs(err, types.ErrCouponIsNotActive)
+
+	err = s.manager.DeleteCoupon(couponId)
+	s.Require().Error(err)
+
+	err = s.manager.DeleteCoupon(otherStoreCouponId)
+	s.Require().NoError(err)
+
+	_, err = s.manager.GetCouponById(otherStoreCouponId)
+	s.Require().ErrorIs(err, types.ErrCouponNotFound)
+
+	stateZoneId, err := s.manager.CreateShippingZone(types.CreateShippingZonePayload{
+		Name:  "State S",
+		State: "s",
+	})
+	s.Require().NoError(err)
+
+	cityZoneId, err := s.manager.CreateShippingZone(types.CreateShippingZonePayload{
+		Name:  "City C",
+		State: "S",
+		City:  utils.Ptr("C"),
+	})
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateShippingZone(types.CreateShippingZonePayload{
+		Name:  "City C again",
+		State: "S",
+		City:  utils.Ptr("c"),
+	})
+	s.Require().Error(err)
+
+	shippingZones, err := s.manager.GetShippingZones(types.ShippingZoneSearchQuery{
+		State: utils.Ptr("S"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(shippingZones, 2)
+
+	_, err = s.manager.CreateShippingRateTable(types.CreateShippingRateTablePayload{
+		Basis: types.ShippingRateBasisQuantity,
+		Tiers: []types.CreateShippingRateTierPayload{
+			{MinValue: 0, MaxValue: utils.Ptr(5.0), BasePrice: types.MoneyFromFloat(3)},
+			{MinValue: 4, BasePrice: types.MoneyFromFloat(2)},
+		},
+		StoreId: storeId,
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidShippingRateTiers)
+
+	defaultRateTableId, err := s.manager.CreateShippingRateTable(
+		types.CreateShippingRateTablePayload{
+			Basis: types.ShippingRateBasisQuantity,
+			Tiers: []types.CreateShippingRateTierPayload{
+				{MinValue: 0, MaxValue: utils.Ptr(5.0), BasePrice: types.MoneyFromFloat(3), PricePerUnit: types.MoneyFromFloat(1)},
+				{MinValue: 5, PricePerUnit: types.MoneyFromFloat(0.5)},
+			},
+			StoreId: storeId,
+		},
+	)
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateShippingRateTable(types.CreateShippingRateTablePayload{
+		Basis: types.ShippingRateBasisWeight,
+		Tiers: []types.CreateShippingRateTierPayload{
+			{MinValue: 0, BasePrice: types.MoneyFromFloat(1)},
+		},
+		StoreId: storeId,
+	})
+	s.Require().Error(err)
+
+	shippingQuotePayload := types.ShippingQuotePayload{
+		ProductVariants: []types.OrderProductVariantAssignmentPayload{
+			{
+				Quantity:  2,
+				VariantId: var11Id,
+			},
+			{
+				Quantity:  1,
+				VariantId: var31Id,
+			},
+		},
+		ReceiverAddressId: addr2Id,
+	}
+
+	shippingQuote, err := s.manager.QuoteShipping(userId2, shippingQuotePayload)
+	s.Require().NoError(err)
+	s.Require().Len(shippingQuote.Stores, 1)
+	s.Require().Equal(types.MoneyFromFloat(6), shippingQuote.TotalShipmentPrice)
+	s.Require().Equal(int(shippingQuote.Stores[0].RateTableId.Int32), defaultRateTableId)
+
+	_, err = s.manager.QuoteShipping(userId2, types.ShippingQuotePayload{
+		ProductVariants:   shippingQuotePayload.ProductVariants,
+		ReceiverAddressId: addrId,
+	})
+	s.Require().ErrorIs(err, types.ErrShipmentAddressNotFound)
+
+	cityRateTableId, err := s.manager.CreateShippingRateTable(types.CreateShippingRateTablePayload{
+		Basis:                 types.ShippingRateBasisWeight,
+		FreeShippingThreshold: utils.Ptr(types.MoneyFromFloat(1.0)),
+		ZoneId:                &cityZoneId,
+		Tiers: []types.CreateShippingRateTierPayload{
+			{MinValue: 0, BasePrice: types.MoneyFromFloat(100)},
+		},
+		StoreId: storeId,
+	})
+	s.Require().NoError(err)
+
+	shippingQuote, err = s.manager.QuoteShipping(userId2, shippingQuotePayload)
+	s.Require().NoError(err)
+	s.Require().Equal(int(shippingQuote.Stores[0].RateTableId.Int32), cityRateTableId)
+	s.Require().Equal(shippingQuote.Stores[0].IsFreeShipping, true)
+	s.Require().True(shippingQuote.TotalShipmentPrice.IsZero())
+
+	freeShippingOrderId, err := s.manager.CreateOrder(types.CreateOrderPayload{
+		UserId:            userId2,
+		ProductVariants:   shippingQuotePayload.ProductVariants,
+		ReceiverAddressId: addr2Id,
+	})
+	s.Require().NoError(err)
+
+	freeShippingOrder, err := s.manager.GetOrderById(freeShippingOrderId)
+	s.Require().NoError(err)
+	s.Require().True(freeShippingOrder.TotalShipmentPrice.IsZero())
+
+	err = s.manager.DeleteShippingRateTable(cityRateTableId, storeId)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateShippingRateTable(
+		defaultRateTableId,
+		storeId,
+		types.UpdateShippingRateTablePayload{
+			Tiers: []types.CreateShippingRateTierPayload{
+				{MinValue: 10, BasePrice: types.MoneyFromFloat(5)},
+			},
+		},
+	)
+	s.Require().NoError(err)
+
+	_, err = s.manager.QuoteShipping(userId2, shippingQuotePayload)
+	s.Require().Error(err)
+
+	err = s.manager.UpdateShippingRateTable(
+		defaultRateTableId,
+		store2Id,
+		types.UpdateShippingRateTablePayload{
+			FreeShippingThreshold: utils.Ptr(types.MoneyFromFloat(0.0)),
+		},
+	)
+	s.Require().ErrorIs(err, types.ErrShippingRateTableNotFound)
+
+	rateTables, err := s.manager.GetStoreShippingRateTables(storeId)
+	s.Require().NoError(err)
+	s.Require().Len(rateTables, 1)
+	s.Require().Len(rateTables[0].Tiers, 1)
+
+	err = s.manager.DeleteShippingZone(stateZoneId)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateStoreSettings(storeId, types.UpdateStoreSettingsPayload{
+		HandlingDays: utils.Ptr(-1),
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidStoreHandlingDays)
+
+	err = s.manager.UpdateStoreSettings(storeId, types.UpdateStoreSettingsPayload{
+		HandlingDays: utils.Ptr(2),
+	})
+	s.Require().NoError(err)
+
+	storeSettings, err := s.manager.GetStoreSettings(storeId)
+	s.Require().NoError(err)
+	s.Require().Equal(storeSettings.HandlingDays, 2)
+
+	originZoneId, err := s.manager.CreateShippingZone(types.CreateShippingZonePayload{
+		Name:  "State A",
+		State: "A",
+	})
+	s.Require().NoError(err)
+
+	transitTimeId, err := s.manager.CreateShippingTransitTime(
+		types.CreateShippingTransitTimePayload{
+			Days:              3,
+			OriginZoneId:      originZoneId,
+			DestinationZoneId: cityZoneId,
+		},
+	)
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateShippingTransitTime(types.CreateShippingTransitTimePayload{
+		Days:              4,
+		OriginZoneId:      originZoneId,
+		DestinationZoneId: cityZoneId,
+	})
+	s.Require().ErrorIs(err, types.ErrDuplicateShippingTransitTime)
+
+	_, err = s.manager.CreateShippingTransitTime(types.CreateShippingTransitTimePayload{
+		Days:              -1,
+		OriginZoneId:      cityZoneId,
+		DestinationZoneId: originZoneId,
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidShippingTransitDays)
+
+	transitTimes, err := s.manager.GetShippingTransitTimes(types.ShippingTransitTimeSearchQuery{
+		DestinationZoneId: &cityZoneId,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(transitTimes, 1)
+	s.Require().Equal(transitTimes[0].Id, transitTimeId)
+
+	estimateQuotePayload := types.ShippingQuotePayload{
+		ProductVariants: []types.OrderProductVariantAssignmentPayload{
+			{VariantId: var11Id, Quantity: 10},
+		},
+		ReceiverAddressId: addr2Id,
+	}
+
+	shippingQuote, err = s.manager.QuoteShipping(userId2, estimateQuotePayload)
+	s.Require().NoError(err)
+	s.Require().WithinDuration(
+		time.Now().AddDate(0, 0, 5),
+		shippingQuote.Stores[0].EstimatedArrivalDate,
+		time.Minute,
+	)
+
+	err = s.manager.UpdateShippingTransitTime(transitTimeId, types.UpdateShippingTransitTimePayload{
+		Days: utils.Ptr(1),
+	})
+	s.Require().NoError(err)
+
+	shippingQuote, err = s.manager.QuoteShipping(userId2, estimateQuotePayload)
+	s.Require().NoError(err)
+	s.Require().WithinDuration(
+		time.Now().AddDate(0, 0, 3),
+		shippingQuote.Stores[0].EstimatedArrivalDate,
+		time.Minute,
+	)
+
+	err = s.manager.UpdateOrderShipment(
+		freeShippingOrderId,
+		storeId,
+		userId,
+		types.UpdateOrderShipmentPayload{
+			Status:         utils.Ptr(types.OrderShipmentStatusOnTheWay),
+			TrackingNumber: utils.Ptr("TRACK-1"),
+		},
+	)
+	s.Require().NoError(err)
+
+	shipments, err := s.manager.GetOrderShipments(freeShippingOrderId)
+	s.Require().NoError(err)
+	s.Require().Len(shipments, 1)
+	s.Require().Equal(
+		time.Now().AddDate(0, 0, 1).Format(time.DateOnly),
+		shipments[0].ArrivalDate.Format(time.DateOnly),
+	)
+
+	err = s.manager.DeleteShippingTransitTime(transitTimeId)
+	s.Require().NoError(err)
+
+	shippingQuote, err = s.manager.QuoteShipping(userId2, estimateQuotePayload)
+	s.Require().NoError(err)
+	s.Require().WithinDuration(
+		time.Now().AddDate(0, 0, 2+int(config.Env.DefaultTransitTimeInDays)),
+		shippingQuote.Stores[0].EstimatedArrivalDate,
+		time.Minute,
+	)
+
+	err = s.manager.UpdateOrderShipment(
+		freeShippingOrderId,
+		storeId,
+		userId,
+		types.UpdateOrderShipmentPayload{
+			Status: utils.Ptr(types.OrderShipmentStatusDelivered),
+		},
+	)
+	s.Require().NoError(err)
+
+	shipments, err = s.manager.GetOrderShipments(freeShippingOrderId)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		time.Now().Format(time.DateOnly),
+		shipments[0].ArrivalDate.Format(time.DateOnly),
+	)
+
+	err = s.manager.DeleteShippingZone(originZoneId)
+	s.Require().NoError(err)
+
+	generalTaxRuleId, err := s.manager.CreateTaxRule(types.CreateTaxRulePayload{
+		Name:        "City C sales tax",
+		Rate:        0.1,
+		PricingMode: types.TaxPricingModeExclusive,
+		ZoneId:      cityZoneId,
+	})
+	s.Require().NoError(err)
+
+	categoryTaxRuleId, err := s.manager.CreateTaxRule(types.CreateTaxRulePayload{
+		Name:        "City C furniture VAT",
+		Rate:        0.2,
+		PricingMode: types.TaxPricingModeInclusive,
+		ZoneId:      cityZoneId,
+		CategoryId:  &prodCat3Id,
+	})
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateTaxRule(types.CreateTaxRulePayload{
+		Name:        "City C sales tax again",
+		Rate:        0.1,
+		PricingMode: types.TaxPricingModeExclusive,
+		ZoneId:      cityZoneId,
+	})
+	s.Require().ErrorIs(err, types.ErrDuplicateTaxRule)
+
+	_, err = s.manager.CreateTaxRule(types.CreateTaxRulePayload{
+		Name:        "Invalid rate",
+		Rate:        1.5,
+		PricingMode: types.TaxPricingModeExclusive,
+		ZoneId:      cityZoneId,
+		CategoryId:  &prodCat1Id,
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidTaxRate)
+
+	taxRules, err := s.manager.GetTaxRules(types.TaxRuleSearchQuery{ZoneId: &cityZoneId})
+	s.Require().NoError(err)
+	s.Require().Len(taxRules, 2)
+
+	err = s.manager.UpdateShippingRateTable(
+		defaultRateTableId,
+		storeId,
+		types.UpdateShippingRateTablePayload{
+			Tiers: []types.CreateShippingRateTierPayload{
+				{MinValue: 0, BasePrice: types.MoneyFromFloat(5)},
+			},
+		},
+	)
+	s.Require().NoError(err)
+
+	taxOrderPayload := types.CreateOrderPayload{
+		UserId: userId2,
+		ProductVariants: []types.OrderProductVariantAssignmentPayload{
+			{VariantId: var11Id, Quantity: 1},
+		},
+		ReceiverAddressId: addr2Id,
+	}
+
+	inclusiveTaxOrderId, err := s.manager.CreateOrder(taxOrderPayload)
+	s.Require().NoError(err)
+
+	inclusiveTaxOrder, err := s.manager.GetOrderWithFullInfoById(inclusiveTaxOrderId)
+	s.Require().NoError(err)
+	s.Require().Len(inclusiveTaxOrder.TaxLines, 1)
+	s.Require().Equal(inclusiveTaxOrder.TaxLines[0].PricingMode, types.TaxPricingModeInclusive)
+	s.Require().InDelta(0.2, inclusiveTaxOrder.TaxLines[0].Rate, 0.0001)
+
+	inclusiveBase := inclusiveTaxOrder.Payment.TotalVariantsPrice
+	s.Require().Equal(
+		inclusiveBase.Sub(inclusiveBase.MulRate(1/(1+0.2))),
+		inclusiveTaxOrder.Payment.TotalTax,
+	)
+	s.Require().True(inclusiveTaxOrder.Payment.ExclusiveTax.IsZero())
+
+	err = s.manager.DeleteTaxRule(categoryTaxRuleId)
+	s.Require().NoError(err)
+
+	exclusiveTaxOrderId, err := s.manager.CreateOrder(taxOrderPayload)
+	s.Require().NoError(err)
+
+	exclusiveTaxOrder, err := s.manager.GetOrderWithFullInfoById(exclusiveTaxOrderId)
+	s.Require().NoError(err)
+	s.Require().Len(exclusiveTaxOrder.TaxLines, 1)
+	s.Require().Equal(exclusiveTaxOrder.TaxLines[0].PricingMode, types.TaxPricingModeExclusive)
+	s.Require().Equal(
+		exclusiveTaxOrder.Payment.TotalVariantsPrice.MulRate(0.1),
+		exclusiveTaxOrder.Payment.ExclusiveTax,
+	)
+	s.Require().Equal(
+		exclusiveTaxOrder.Payment.ExclusiveTax,
+		exclusiveTaxOrder.Payment.TotalTax,
+	)
+
+	exclusiveTaxOrderVariants, err := s.manager.GetOrderProductVariants(exclusiveTaxOrderId)
+	s.Require().NoError(err)
+	s.Require().Len(exclusiveTaxOrderVariants, 1)
+	s.Require().Equal(
+		exclusiveTaxOrder.Payment.ExclusiveTax,
+		exclusiveTaxOrderVariants[0].Tax,
+	)
+	s.Require().Equal(exclusiveTaxOrderVariants[0].TaxPricingMode.String, "exclusive")
+
+	err = s.manager.DeleteTaxRule(generalTaxRuleId)
+	s.Require().NoError(err)
+
+	untaxedOrderId, err := s.manager.CreateOrder(taxOrderPayload)
+	s.Require().NoError(err)
+
+	untaxedOrder, err := s.manager.GetOrderWithFullInfoById(untaxedOrderId)
+	s.Require().NoError(err)
+	s.Require().Len(untaxedOrder.TaxLines, 0)
+	s.Require().True(untaxedOrder.Payment.TotalTax.IsZero())
+
+	firstInvoice, err := s.manager.GetOrderInvoice(orderId)
+	s.Require().NoError(err)
+	s.Require().Equal(firstInvoice.Number, 1)
+	s.Require().Equal(firstInvoice.Snapshot.OrderId, orderId)
+	s.Require().Len(firstInvoice.Snapshot.Lines, 2)
+
+	_, err = s.manager.GetOrderInvoice(exclusiveTaxOrderId)
+	s.Require().ErrorIs(err, types.ErrInvoiceNotFound)
+
+	err = s.manager.UpdateOrderPayment(exclusiveTaxOrderId, userId2, types.UpdateOrderPaymentPayload{
+		Status: utils.Ptr(types.OrderPaymentStatusSuccessful),
+	})
+	s.Require().NoError(err)
+
+	taxInvoice, err := s.manager.GetOrderInvoice(exclusiveTaxOrderId)
+	s.Require().NoError(err)
+	s.Require().Equal(taxInvoice.Number, firstInvoice.Number+1)
+	s.Require().Equal(taxInvoice.Snapshot.Buyer.UserId, userId2)
+	s.Require().Len(taxInvoice.Snapshot.Lines, 1)
+	s.Require().Len(taxInvoice.Snapshot.Stores, 1)
+	s.Require().Equal(taxInvoice.Snapshot.Stores[0].StoreId, storeId)
+	s.Require().Equal(
+		exclusiveTaxOrder.Payment.ExclusiveTax,
+		taxInvoice.Snapshot.ExclusiveTax,
+	)
+	s.Require().Equal(
+		exclusiveTaxOrder.Payment.TotalVariantsPrice.
+			Add(exclusiveTaxOrder.Payment.TotalShipmentPrice).
+			Add(exclusiveTaxOrder.Payment.Fee).
+			Sub(exclusiveTaxOrder.Payment.Discount).
+			Add(exclusiveTaxOrder.Payment.ExclusiveTax),
+		taxInvoice.Snapshot.GrandTotal,
+	)
+
+	err = s.manager.UpdateProduct(product1Id, types.UpdateProductPayload{
+		Base: &types.UpdateProductBasePayload{
+			Name: utils.Ptr("Renamed Product"),
+		},
+	})
+	s.Require().NoError(err)
+
+	taxInvoiceAfterUpdate, err := s.manager.GetOrderInvoice(exclusiveTaxOrderId)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		taxInvoice.Snapshot.Lines[0].ProductName,
+		taxInvoiceAfterUpdate.Snapshot.Lines[0].ProductName,
+	)
+	s.Require().NotEqual(taxInvoiceAfterUpdate.Snapshot.Lines[0].ProductName, "Renamed Product")
+
+	err = s.manager.UpdateOrderPayment(untaxedOrderId, userId2, types.UpdateOrderPaymentPayload{
+		Status: utils.Ptr(types.OrderPaymentStatusFailed),
+	})
+	s.Require().NoError(err)
+
+	_, err = s.manager.GetOrderInvoice(untaxedOrderId)
+	s.Require().ErrorIs(err, types.ErrInvoiceNotFound)
+
+	err = s.manager.UpdateOrderPayment(untaxedOrderId, userId2, types.UpdateOrderPaymentPayload{
+		Status: utils.Ptr(types.OrderPaymentStatusSuccessful),
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidOrderPaymentTransition)
+
+	err = s.manager.UpdateOrderShipment(
+		freeShippingOrderId,
+		storeId,
+		userId,
+		types.UpdateOrderShipmentPayload{
+			Status: utils.Ptr(types.OrderShipmentStatusOnTheWay),
+		},
+	)
+	s.Require().ErrorIs(err, types.ErrInvalidOrderShipmentTransition)
+
+	timeline, err := s.manager.GetOrderStatusEvents(freeShippingOrderId, nil)
+	s.Require().NoError(err)
+	s.Require().Len(timeline, 4)
+	s.Require().Equal(timeline[0].Kind, types.OrderStatusEventKindShipment)
+	s.Require().False(timeline[0].OldStatus.Valid)
+	s.Require().Equal(timeline[1].Kind, types.OrderStatusEventKindPayment)
+	s.Require().Equal(timeline[1].NewStatus, types.OrderPaymentStatusPending.String())
+	s.Require().Equal(int(timeline[1].ActorId.Int32), userId2)
+	s.Require().Equal(timeline[2].OldStatus.String, types.OrderShipmentStatusToBeDetermined.String())
+	s.Require().Equal(timeline[2].NewStatus, types.OrderShipmentStatusOnTheWay.String())
+	s.Require().Equal(int(timeline[2].StoreId.Int32), storeId)
+	s.Require().Equal(int(timeline[2].ActorId.Int32), userId)
+	s.Require().Equal(timeline[3].NewStatus, types.OrderShipmentStatusDelivered.String())
+
+	storeTimeline, err := s.manager.GetOrderStatusEvents(freeShippingOrderId, &store2Id)
+	s.Require().NoError(err)
+	s.Require().Len(storeTimeline, 1)
+	s.Require().Equal(storeTimeline[0].Kind, types.OrderStatusEventKindPayment)
+
+	order2Timeline, err := s.manager.GetOrderStatusEvents(order2Id, nil)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(order2Timeline)
+	expiredEvent := order2Timeline[len(order2Timeline)-1]
+	s.Require().Equal(expiredEvent.NewStatus, types.OrderPaymentStatusFailed.String())
+	s.Require().False(expiredEvent.ActorId.Valid)
+	s.Require().Equal(expiredEvent.Reason.String, "inventory reservation expired")
+
+	cancelledShipmentEvents := 0
+	for _, event := range order2Timeline {
+		if event.Kind == types.OrderStatusEventKindShipment &&
+			event.NewStatus == types.OrderShipmentStatusCancelled.String() {
+			s.Require().Equal(event.OldStatus.String, types.OrderShipmentStatusToBeDetermined.String())
+			s.Require().Equal(event.Reason.String, "inventory reservation expired")
+			s.Require().True(event.StoreId.Valid)
+			cancelledShipmentEvents++
+		}
+	}
+	s.Require().Equal(len(order2WithFullInfo.Shipments), cancelledShipmentEvents)
+
+	commissionRules, err := s.manager.GetCommissionRules(types.CommissionRuleSearchQuery{})
+	s.Require().NoError(err)
+	s.Require().Len(commissionRules, 1)
+	s.Require().InDelta(0.05, commissionRules[0].Rate, 0.0001)
+	s.Require().False(commissionRules[0].StoreId.Valid)
+	s.Require().False(commissionRules[0].CategoryId.Valid)
+
+	s.Require().Equal(
+		exclusiveTaxOrder.Payment.TotalVariantsPrice.Sub(exclusiveTaxOrder.Payment.Discount).MulRate(0.05),
+		exclusiveTaxOrder.Payment.Fee,
+	)
+	s.Require().Equal(exclusiveTaxOrder.Payment.Fee, exclusiveTaxOrderVariants[0].Commission)
+	s.Require().InDelta(0.05, exclusiveTaxOrderVariants[0].CommissionRate, 0.0001)
+
+	platformWallet, err := s.manager.GetPlatformWallet()
+	s.Require().NoError(err)
+
+	commissionReport, err := s.manager.GetCommissionReport(types.CommissionReportQuery{})
+	s.Require().NoError(err)
+	s.Require().Greater(commissionReport.TotalOrders, 0)
+	s.Require().Equal(commissionReport.TotalCommission, platformWallet.Balance)
+
+	storeCommissionRuleId, err := s.manager.CreateCommissionRule(types.CreateCommissionRulePayload{
+		Rate:    0.1,
+		StoreId: &storeId,
+	})
+	s.Require().NoError(err)
+
+	categoryCommissionRuleId, err := s.manager.CreateCommissionRule(
+		types.CreateCommissionRulePayload{
+			Rate:       0.2,
+			CategoryId: &prodCat3Id,
+		},
+	)
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateCommissionRule(types.CreateCommissionRulePayload{
+		Rate:    0.15,
+		StoreId: &storeId,
+	})
+	s.Require().Error(err)
+
+	_, err = s.manager.CreateCommissionRule(types.CreateCommissionRulePayload{
+		Rate:    1.5,
+		StoreId: &store2Id,
+	})
+	s.Require().Error(err)
+
+	commissionRules, err = s.manager.GetCommissionRules(types.CommissionRuleSearchQuery{
+		StoreId: &storeId,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(commissionRules, 1)
+	s.Require().Equal(commissionRules[0].Id, storeCommissionRuleId)
+
+	storeCommissionOrderId, err := s.manager.CreateOrder(taxOrderPayload)
+	s.Require().NoError(err)
+
+	storeCommissionOrder, err := s.manager.GetOrderWithFullInfoById(storeCommissionOrderId)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		storeCommissionOrder.Payment.TotalVariantsPrice.Sub(storeCommissionOrder.Payment.Discount).MulRate(0.1),
+		storeCommissionOrder.Payment.Fee,
+	)
+
+	err = s.manager.DeleteCommissionRule(storeCommissionRuleId)
+	s.Require().NoError(err)
+
+	err = s.manager.DeleteCommissionRule(storeCommissionRuleId)
+	s.Require().ErrorIs(err, types.ErrCommissionRuleNotFound)
+
+	defaultCommissionRuleId := -1
+	err = s.db.QueryRow(
+		"SELECT id FROM commission_rules WHERE store_id IS NULL AND category_id IS NULL;",
+	).
+		Scan(&defaultCommissionRuleId)
+	s.Require().NoError(err)
+
+	err = s.manager.DeleteCommissionRule(defaultCommissionRuleId)
+	s.Require().ErrorIs(err, types.ErrCannotDeleteDefaultCommissionRule)
+
+	err = s.manager.UpdateCommissionRule(
+		categoryCommissionRuleId,
+		types.UpdateCommissionRulePayload{Rate: utils.Ptr(0.25)},
+	)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateCommissionRule(
+		99999,
+		types.UpdateCommissionRulePayload{Rate: utils.Ptr(0.25)},
+	)
+	s.Require().ErrorIs(err, types.ErrCommissionRuleNotFound)
+
+	categoryCommissionOrderId, err := s.manager.CreateOrder(taxOrderPayload)
+	s.Require().NoError(err)
+
+	categoryCommissionVariants, err := s.manager.GetOrderProductVariants(
+		categoryCommissionOrderId,
+	)
+	s.Require().NoError(err)
+	s.Require().Len(categoryCommissionVariants, 1)
+	s.Require().InDelta(0.25, categoryCommissionVariants[0].CommissionRate, 0.0001)
+
+	commissionDepositId, err := s.manager.CreateWalletTransaction(
+		types.CreateWalletTransactionPayload{
+			Amount:   storeCommissionOrder.Payment.TotalVariantsPrice.Mul(2),
+			TxType:   types.TransactionTypeDeposit,
+			WalletId: user2Wallet.Id,
+		},
+	)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateWalletTransaction(
+		user2Wallet.Id,
+		commissionDepositId,
+		types.UpdateWalletTransactionPayload{
+			Status: utils.Ptr(types.TransactionStatusSuccessful),
+		},
+	)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateOrderPayment(
+		storeCommissionOrderId,
+		userId2,
+		types.UpdateOrderPaymentPayload{
+			Status: utils.Ptr(types.OrderPaymentStatusSuccessful),
+		},
+	)
+	s.Require().NoError(err)
+
+	platformWalletAfterPayment, err := s.manager.GetPlatformWallet()
+	s.Require().NoError(err)
+	s.Require().Equal(
+		platformWallet.Balance.Add(storeCommissionOrder.Payment.Fee),
+		platformWalletAfterPayment.Balance,
+	)
+
+	storesCommissionReport, err := s.manager.GetStoresCommissionReport(
+		types.CommissionReportQuery{StoreId: &storeId},
+	)
+	s.Require().NoError(err)
+	s.Require().Len(storesCommissionReport, 1)
+	s.Require().Equal(storesCommissionReport[0].StoreId, storeId)
+
+	categoriesCommissionReport, err := s.manager.GetCategoriesCommissionReport(
+		types.CommissionReportQuery{},
+	)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(categoriesCommissionReport)
+
+	commissionReport, err = s.manager.GetCommissionReport(types.CommissionReportQuery{})
+	s.Require().NoError(err)
+	s.Require().Equal(
+		commissionReport.TotalCommission,
+		platformWalletAfterPayment.Balance,
+	)
+
+	user2Wallet, err = s.manager.GetUserWallet(userId2)
+	s.Require().NoError(err)
+
+	user2LedgerBalance, err := s.manager.GetWalletLedgerBalance(user2Wallet.Id)
+	s.Require().NoError(err)
+	s.Require().Equal(user2Wallet.Balance, user2LedgerBalance)
+
+	userWallet, err = s.manager.GetUserWallet(userId)
+	s.Require().NoError(err)
+
+	userLedgerBalance, err := s.manager.GetWalletLedgerBalance(userWallet.Id)
+	s.Require().NoError(err)
+	s.Require().Equal(userWallet.Balance, userLedgerBalance)
+
+	platformLedgerBalance, err := s.manager.GetPlatformLedgerBalance()
+	s.Require().NoError(err)
+	s.Require().Equal(platformWalletAfterPayment.Balance, platformLedgerBalance)
+
+	user2LedgerEntries, err := s.manager.GetWalletLedgerEntries(
+		user2Wallet.Id,
+		types.WalletLedgerEntrySearchQuery{},
+	)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(user2LedgerEntries)
+
+	user2LedgerSum := types.Money{}
+	for _, e := range user2LedgerEntries {
+		user2LedgerSum = user2LedgerSum.Add(e.Amount)
+	}
+	s.Require().Equal(user2LedgerBalance, user2LedgerSum)
+
+	storeCommissionPaymentEntries, err := s.manager.GetWalletLedgerEntries(
+		user2Wallet.Id,
+		types.WalletLedgerEntrySearchQuery{
+			Kind: utils.Ptr(types.JournalEntryKindOrderPayment),
+		},
+	)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(storeCommissionPaymentEntries)
+	s.Require().True(storeCommissionPaymentEntries[0].OrderId.Valid)
+	s.Require().Equal(storeCommissionOrderId, int(storeCommissionPaymentEntries[0].OrderId.Int32))
+	s.Require().True(storeCommissionPaymentEntries[0].Amount.IsNegative())
+
+	user2DepositEntriesCount, err := s.manager.GetWalletLedgerEntriesCount(
+		user2Wallet.Id,
+		types.WalletLedgerEntrySearchQuery{
+			Kind: utils.Ptr(types.JournalEntryKindDeposit),
+		},
+	)
+	s.Require().NoError(err)
+	s.Require().GreaterOrEqual(user2DepositEntriesCount, 2)
+
+	user2EntriesCount, err := s.manager.GetWalletLedgerEntriesCount(
+		user2Wallet.Id,
+		types.WalletLedgerEntrySearchQuery{},
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(len(user2LedgerEntries), user2EntriesCount)
+
+	overdrawnWithdrawId, err := s.manager.CreateWalletTransaction(
+		types.CreateWalletTransactionPayload{
+			Amount:   user2Wallet.Balance.Add(types.MoneyFromFloat(1)),
+			TxType:   types.TransactionTypeWithdraw,
+			WalletId: user2Wallet.Id,
+		},
+	)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateWalletTransaction(
+		user2Wallet.Id,
+		overdrawnWithdrawId,
+		types.UpdateWalletTransactionPayload{
+			Status: utils.Ptr(types.TransactionStatusSuccessful),
+		},
+	)
+	s.Require().ErrorIs(err, types.ErrBalanceInsufficient)
+
+	user2EntriesCountAfterWithdraw, err := s.manager.GetWalletLedgerEntriesCount(
+		user2Wallet.Id,
+		types.WalletLedgerEntrySearchQuery{},
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(user2EntriesCount, user2EntriesCountAfterWithdraw)
+
+	withdrawId, err := s.manager.CreateWalletTransaction(
+		types.CreateWalletTransactionPayload{
+			Amount:   types.MoneyFromFloat(1),
+			TxType:   types.TransactionTypeWithdraw,
+			WalletId: user2Wallet.Id,
+		},
+	)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateWalletTransaction(
+		user2Wallet.Id,
+		withdrawId,
+		types.UpdateWalletTransactionPayload{
+			Status: utils.Ptr(types.TransactionStatusSuccessful),
+		},
+	)
+	s.Require().NoError(err)
+
+	user2WalletAfterWithdraw, err := s.manager.GetUserWallet(userId2)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		user2Wallet.Balance.Sub(types.MoneyFromFloat(1)),
+		user2WalletAfterWithdraw.Balance,
+	)
+
+	withdrawEntries, err := s.manager.GetWalletLedgerEntries(
+		user2Wallet.Id,
+		types.WalletLedgerEntrySearchQuery{Limit: utils.Ptr(1)},
+	)
+	s.Require().NoError(err)
+	s.Require().Len(withdrawEntries, 1)
+	s.Require().Equal(types.JournalEntryKindWithdrawal, withdrawEntries[0].Kind)
+	s.Require().Equal(withdrawId, int(withdrawEntries[0].WalletTransactionId.Int32))
+	s.Require().Equal(types.MoneyFromFloat(-1), withdrawEntries[0].Amount)
+
+	depositIntentId, err := s.manager.CreatePaymentIntent(types.CreatePaymentIntentPayload{
+		Provider:  "fake",
+		Reference: "fake_deposit",
+		Amount:    types.MoneyFromFloat(20),
+		WalletId:  user2Wallet.Id,
+	})
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreatePaymentIntent(types.CreatePaymentIntentPayload{
+		Provider:  "fake",
+		Reference: "fake_deposit",
+		Amount:    types.MoneyFromFloat(20),
+		WalletId:  user2Wallet.Id,
+	})
+	s.Require().Error(err)
+
+	depositIntent, err := s.manager.GetPaymentIntentByReference("fake", "fake_deposit")
+	s.Require().NoError(err)
+	s.Require().Equal(depositIntentId, depositIntent.Id)
+	s.Require().Equal(types.PaymentIntentStatusPending, depositIntent.Status)
+	s.Require().False(depositIntent.OrderId.Valid)
+
+	err = s.manager.ConfirmPaymentIntent(depositIntentId)
+	s.Require().NoError(err)
+
+	err = s.manager.ConfirmPaymentIntent(depositIntentId)
+	s.Require().ErrorIs(err, types.ErrPaymentIntentNotPending)
+
+	user2WalletAfterDeposit, err := s.manager.GetUserWallet(userId2)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		user2WalletAfterWithdraw.Balance.Add(types.MoneyFromFloat(20)),
+		user2WalletAfterDeposit.Balance,
+	)
+
+	depositTx, err := s.manager.GetWalletTransactionById(depositIntent.WalletTransactionId)
+	s.Require().NoError(err)
+	s.Require().Equal(types.TransactionStatusSuccessful, depositTx.Status)
+
+	failedIntentId, err := s.manager.CreatePaymentIntent(types.CreatePaymentIntentPayload{
+		Provider:  "fake",
+		Reference: "fake_failed_deposit",
+		Amount:    types.MoneyFromFloat(5),
+		WalletId:  user2Wallet.Id,
+	})
+	s.Require().NoError(err)
+
+	err = s.manager.FailPaymentIntent(failedIntentId, types.PaymentIntentStatusSucceeded)
+	s.Require().ErrorIs(err, types.ErrInvalidPaymentIntentStatusEnum)
+
+	err = s.manager.FailPaymentIntent(failedIntentId, types.PaymentIntentStatusFailed)
+	s.Require().NoError(err)
+
+	failedIntent, err := s.manager.GetPaymentIntentById(failedIntentId)
+	s.Require().NoError(err)
+	s.Require().Equal(types.PaymentIntentStatusFailed, failedIntent.Status)
+
+	failedTx, err := s.manager.GetWalletTransactionById(failedIntent.WalletTransactionId)
+	s.Require().NoError(err)
+	s.Require().Equal(types.TransactionStatusFailed, failedTx.Status)
+
+	paidOrderId, err := s.manager.CreateOrder(taxOrderPayload)
+	s.Require().NoError(err)
+
+	paidOrder, err := s.manager.GetOrderWithFullInfoById(paidOrderId)
+	s.Require().NoError(err)
+
+	orderIntentId, err := s.manager.CreatePaymentIntent(types.CreatePaymentIntentPayload{
+		Provider:  "fake",
+		Reference: "fake_order",
+		Amount:    paidOrder.Payment.Total(),
+		WalletId:  user2Wallet.Id,
+		OrderId:   &paidOrderId,
+	})
+	s.Require().NoError(err)
+
+	err = s.manager.ConfirmPaymentIntent(orderIntentId)
+	s.Require().NoError(err)
+
+	paidOrder, err = s.manager.GetOrderWithFullInfoById(paidOrderId)
+	s.Require().NoError(err)
+	s.Require().Equal(types.OrderPaymentStatusSuccessful, paidOrder.Payment.Status)
+
+	user2WalletAfterOrder, err := s.manager.GetUserWallet(userId2)
+	s.Require().NoError(err)
+	s.Require().Equal(user2WalletAfterDeposit.Balance, user2WalletAfterOrder.Balance)
+
+	_, err = s.manager.CreatePaymentIntent(types.CreatePaymentIntentPayload{
+		Provider:  "fake",
+		Reference: "fake_paid_order",
+		Amount:    paidOrder.Payment.Total(),
+		WalletId:  user2Wallet.Id,
+		OrderId:   &paidOrderId,
+	})
+	s.Require().ErrorIs(err, types.ErrOrderPaymentIsNotPending)
+
+	_, err = s.manager.CreateWalletTransfer(types.WalletTransferInsertData{
+		SenderWalletId:   user2Wallet.Id,
+		ReceiverWalletId: user2Wallet.Id,
+		Amount:           types.MoneyFromFloat(5),
+	})
+	s.Require().ErrorIs(err, types.ErrCannotTransferToSameWallet)
+
+	_, err = s.manager.CreateWalletTransfer(types.WalletTransferInsertData{
+		SenderWalletId:   user2Wallet.Id,
+		ReceiverWalletId: userWallet.Id,
+		Amount:           types.MoneyFromFloat(config.Env.TransferMaxAmount).Add(types.MoneyFromFloat(1)),
+	})
+	s.Require().Error(err)
+
+	user3Wallet, err := s.manager.GetUserWallet(userId3)
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateWalletTransfer(types.WalletTransferInsertData{
+		SenderWalletId:   user3Wallet.Id,
+		ReceiverWalletId: user2Wallet.Id,
+		Amount:           user3Wallet.Balance.Add(types.MoneyFromFloat(1)),
+	})
+	s.Require().ErrorIs(err, types.ErrBalanceInsufficient)
+
+	userWalletBeforeTransfer, err := s.manager.GetUserWallet(userId)
+	s.Require().NoError(err)
+
+	transferId, err := s.manager.CreateWalletTransfer(types.WalletTransferInsertData{
+		SenderWalletId:   user2Wallet.Id,
+		ReceiverWalletId: userWallet.Id,
+		Amount:           types.MoneyFromFloat(5),
+		Memo:             utils.Ptr("thanks"),
+	})
+	s.Require().NoError(err)
+
+	user2WalletAfterTransfer, err := s.manager.GetUserWallet(userId2)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		user2WalletAfterOrder.Balance.Sub(types.MoneyFromFloat(5)),
+		user2WalletAfterTransfer.Balance,
+	)
+
+	userWalletAfterTransfer, err := s.manager.GetUserWallet(userId)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		userWalletBeforeTransfer.Balance.Add(types.MoneyFromFloat(5)),
+		userWalletAfterTransfer.Balance,
+	)
+
+	transferOutTxs, err := s.manager.GetWalletTransactions(types.WalletTransactionSearchQuery{
+		UserId: &userId2,
+		TxType: utils.Ptr(types.TransactionTypeTransferOut),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(transferOutTxs, 1)
+	s.Require().Equal(types.TransactionStatusSuccessful, transferOutTxs[0].Status)
+	s.Require().Equal(transferId, int(transferOutTxs[0].TransferId.Int32))
+	s.Require().Equal("thanks", transferOutTxs[0].Memo.String)
+	s.Require().Equal(userId, int(transferOutTxs[0].CounterpartyUserId.Int32))
+	s.Require().Equal("testuser", transferOutTxs[0].CounterpartyUsername.String)
+
+	transferInTxs, err := s.manager.GetWalletTransactions(types.WalletTransactionSearchQuery{
+		UserId: &userId,
+		TxType: utils.Ptr(types.TransactionTypeTransferIn),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(transferInTxs, 1)
+	s.Require().Equal(transferId, int(transferInTxs[0].TransferId.Int32))
+	s.Require().Equal(userId2, int(transferInTxs[0].CounterpartyUserId.Int32))
+	s.Require().Equal("testuser2", transferInTxs[0].CounterpartyUsername.String)
+
+	transferInTx, err := s.manager.GetWalletTransactionById(transferInTxs[0].Id)
+	s.Require().NoError(err)
+	s.Require().Equal(transferInTxs[0], *transferInTx)
+
+	depositTxAfterTransfer, err := s.manager.GetWalletTransactionById(depositTx.Id)
+	s.Require().NoError(err)
+	s.Require().False(depositTxAfterTransfer.TransferId.Valid)
+
+	transferEntries, err := s.manager.GetWalletLedgerEntries(
+		userWallet.Id,
+		types.WalletLedgerEntrySearchQuery{
+			Kind: utils.Ptr(types.JournalEntryKindTransfer),
+		},
+	)
+	s.Require().NoError(err)
+	s.Require().Len(transferEntries, 1)
+	s.Require().Equal(transferId, int(transferEntries[0].WalletTransferId.Int32))
+	s.Require().Equal(types.MoneyFromFloat(5), transferEntries[0].Amount)
+
+	escrowOrderId, err := s.manager.CreateOrder(taxOrderPayload)
+	s.Require().NoError(err)
+
+	escrowOrder, err := s.manager.GetOrderWithFullInfoById(escrowOrderId)
+	s.Require().NoError(err)
+
+	escrowDepositId, err := s.manager.CreateWalletTransaction(types.CreateWalletTransactionPayload{
+		Amount:   escrowOrder.Payment.Total(),
+		TxType:   types.TransactionTypeDeposit,
+		WalletId: user2Wallet.Id,
+	})
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateWalletTransaction(
+		user2Wallet.Id,
+		escrowDepositId,
+		types.UpdateWalletTransactionPayload{
+			Status: utils.Ptr(types.TransactionStatusSuccessful),
+		},
+	)
+	s.Require().NoError(err)
+
+	userWalletBeforeEscrow, err := s.manager.GetUserWallet(userId)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateOrderPayment(escrowOrderId, userId2, types.UpdateOrderPaymentPayload{
+		Status: utils.Ptr(types.OrderPaymentStatusSuccessful),
+	})
+	s.Require().NoError(err)
+
+	escrowHeld := escrowOrder.Payment.Total().Sub(escrowOrder.Payment.Fee)
+
+	userWalletInEscrow, err := s.manager.GetUserWallet(userId)
+	s.Require().NoError(err)
+	s.Require().Equal(userWalletBeforeEscrow.Balance, userWalletInEscrow.Balance)
+	s.Require().Equal(
+		userWalletBeforeEscrow.PendingBalance.Add(escrowHeld),
+		userWalletInEscrow.PendingBalance,
+	)
+
+	released, err := s.manager.ReleaseDueEscrowHolds()
+	s.Require().NoError(err)
+	s.Require().Equal(0, released)
+
+	err = s.manager.UpdateOrderShipment(
+		escrowOrderId,
+		storeId,
+		userId,
+		types.UpdateOrderShipmentPayload{
+			Status: utils.Ptr(types.OrderShipmentStatusOnTheWay),
+		},
+	)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateOrderShipment(
+		escrowOrderId,
+		storeId,
+		userId,
+		types.UpdateOrderShipmentPayload{
+			Status: utils.Ptr(types.OrderShipmentStatusDelivered),
+		},
+	)
+	s.Require().NoError(err)
+
+	userWalletAfterRelease, err := s.manager.GetUserWallet(userId)
+	s.Require().NoError(err)
+	s.Require().Equal(userWalletBeforeEscrow.PendingBalance, userWalletAfterRelease.PendingBalance)
+	s.Require().Equal(
+		userWalletBeforeEscrow.Balance.Add(escrowHeld),
+		userWalletAfterRelease.Balance,
+	)
+
+	userLedgerBalanceAfterRelease, err := s.manager.GetWalletLedgerBalance(userWallet.Id)
+	s.Require().NoError(err)
+	s.Require().Equal(userWalletAfterRelease.Balance, userLedgerBalanceAfterRelease)
+
+	releaseEntries, err := s.manager.GetWalletLedgerEntries(
+		userWallet.Id,
+		types.WalletLedgerEntrySearchQuery{
+			Kind:  utils.Ptr(types.JournalEntryKindEscrowRelease),
+			Limit: utils.Ptr(1),
+		},
+	)
+	s.Require().NoError(err)
+	s.Require().Len(releaseEntries, 1)
+	s.Require().Equal(escrowOrderId, int(releaseEntries[0].OrderId.Int32))
+	s.Require().Equal(escrowHeld, releaseEntries[0].Amount)
+
+	payEscrowOrder := func() (int, types.Money) {
+		orderId, err := s.manager.CreateOrder(taxOrderPayload)
+		s.Require().NoError(err)
+
+		order, err := s.manager.GetOrderWithFullInfoById(orderId)
+		s.Require().NoError(err)
+
+		depositId, err := s.manager.CreateWalletTransaction(types.CreateWalletTransactionPayload{
+			Amount:   order.Payment.Total(),
+			TxType:   types.TransactionTypeDeposit,
+			WalletId: user2Wallet.Id,
+		})
+		s.Require().NoError(err)
+
+		err = s.manager.UpdateWalletTransaction(
+			user2Wallet.Id,
+			depositId,
+			types.UpdateWalletTransactionPayload{
+				Status: utils.Ptr(types.TransactionStatusSuccessful),
+			},
+		)
+		s.Require().NoError(err)
+
+		err = s.manager.UpdateOrderPayment(orderId, userId2, types.UpdateOrderPaymentPayload{
+			Status: utils.Ptr(types.OrderPaymentStatusSuccessful),
+		})
+		s.Require().NoError(err)
+
+		_, err = s.db.Exec(
+			"UPDATE escrow_holds SET release_at = $1 WHERE order_id = $2;",
+			time.Now().Add(-time.Hour),
+			orderId,
+		)
+		s.Require().NoError(err)
+
+		return orderId, order.Payment.Total().Sub(order.Payment.Fee)
+	}
+
+	dueOrderId, _ := payEscrowOrder()
+
+	released, err = s.manager.ReleaseDueEscrowHolds()
+	s.Require().NoError(err)
+	s.Require().Equal(0, released)
+
+	err = s.manager.UpdateOrderShipment(
+		dueOrderId,
+		storeId,
+		userId,
+		types.UpdateOrderShipmentPayload{
+			Status: utils.Ptr(types.OrderShipmentStatusOnTheWay),
+		},
+	)
+	s.Require().NoError(err)
+
+	released, err = s.manager.ReleaseDueEscrowHolds()
+	s.Require().NoError(err)
+	s.Require().Equal(1, released)
+
+	cancelledOrderId, cancelledHeld := payEscrowOrder()
+
+	userWalletBeforeCancel, err := s.manager.GetUserWallet(userId)
+	s.Require().NoError(err)
+
+	user2WalletBeforeCancel, err := s.manager.GetUserWallet(userId2)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateOrderShipment(
+		cancelledOrderId,
+		storeId,
+		userId,
+		types.UpdateOrderShipmentPayload{
+			Status: utils.Ptr(types.OrderShipmentStatusCancelled),
+		},
+	)
+	s.Require().NoError(err)
+
+	released, err = s.manager.ReleaseDueEscrowHolds()
+	s.Require().NoError(err)
+	s.Require().Equal(0, released)
+
+	userWalletAfterCancel, err := s.manager.GetUserWallet(userId)
+	s.Require().NoError(err)
+	s.Require().Equal(userWalletBeforeCancel.Balance, userWalletAfterCancel.Balance)
+	s.Require().Equal(
+		userWalletBeforeCancel.PendingBalance.Sub(cancelledHeld),
+		userWalletAfterCancel.PendingBalance,
+	)
+
+	user2WalletAfterCancel, err := s.manager.GetUserWallet(userId2)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		user2WalletBeforeCancel.Balance.Add(cancelledHeld),
+		user2WalletAfterCancel.Balance,
+	)
+
+	var cancelledHoldStatus types.EscrowHoldStatus
+	err = s.db.QueryRow(
+		"SELECT status FROM escrow_holds WHERE order_id = $1;",
+		cancelledOrderId,
+	).
+		Scan(&cancelledHoldStatus)
+	s.Require().NoError(err)
+	s.Require().Equal(types.EscrowHoldStatusRefunded, cancelledHoldStatus)
+
+	escrowRefundEntries, err := s.manager.GetWalletLedgerEntries(
+		user2Wallet.Id,
+		types.WalletLedgerEntrySearchQuery{
+			Kind: utils.Ptr(types.JournalEntryKindEscrowRefund),
+		},
+	)
+	s.Require().NoError(err)
+	s.Require().Len(escrowRefundEntries, 1)
+	s.Require().Equal(cancelledOrderId, int(escrowRefundEntries[0].OrderId.Int32))
+	s.Require().Equal(cancelledHeld, escrowRefundEntries[0].Amount)
+
+	tiers, err := s.manager.GetWithdrawalTiers()
+	s.Require().NoError(err)
+	s.Require().NotEmpty(tiers)
+	s.Require().True(tiers[0].IsDefault)
+	defaultTierId := tiers[0].Id
+
+	withdrawUserId, err := s.manager.CreateUser(types.CreateUserPayload{
+		Username:  "testuser4",
+		Email:     "test4@example.com",
+		Password:  "securepassword",
+		BirthDate: time.Date(1999, 4, 1, 0, 0, 0, 0, time.UTC),
+		FullName:  "Test User 4",
+		RoleId:    role.Id,
+	})
+	s.Require().NoError(err)
+
+	withdrawWallet, err := s.manager.GetUserWallet(withdrawUserId)
+	s.Require().NoError(err)
+
+	withdrawDepositId, err := s.manager.CreateWalletTransaction(types.CreateWalletTransactionPayload{
+		Amount:   types.MoneyFromFloat(500),
+		TxType:   types.TransactionTypeDeposit,
+		WalletId: withdrawWallet.Id,
+	})
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateWalletTransaction(
+		withdrawWallet.Id,
+		withdrawDepositId,
+		types.UpdateWalletTransactionPayload{
+			Status: utils.Ptr(types.TransactionStatusSuccessful),
+		},
+	)
+	s.Require().NoError(err)
+
+	withdrawPolicy, err := s.manager.GetUserWithdrawalPolicy(withdrawUserId)
+	s.Require().NoError(err)
+	s.Require().Equal(defaultTierId, withdrawPolicy.Tier.Id)
+	s.Require().False(withdrawPolicy.KycVerified)
+	s.Require().False(withdrawPolicy.CooldownEndsAt.Valid)
+
+	withdrawalTierId, err := s.manager.CreateWithdrawalTier(types.CreateWithdrawalTierPayload{
+		Name:            "Test Tier",
+		MinAmount:       types.MoneyFromFloat(5),
+		DailyLimit:      types.MoneyFromFloat(50),
+		MonthlyLimit:    types.MoneyFromFloat(80),
+		KycDailyLimit:   types.MoneyFromFloat(200),
+		KycMonthlyLimit: types.MoneyFromFloat(300),
+		CooldownInHours: 24,
+	})
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateWithdrawalTier(types.CreateWithdrawalTierPayload{
+		Name: "Test Tier",
+	})
+	s.Require().Error(err)
+
+	err = s.manager.SetRoleWithdrawalTier(role.Id, withdrawalTierId)
+	s.Require().NoError(err)
+
+	withdrawPolicy, err = s.manager.GetUserWithdrawalPolicy(withdrawUserId)
+	s.Require().NoError(err)
+	s.Require().Equal(withdrawalTierId, withdrawPolicy.Tier.Id)
+	s.Require().Equal(types.MoneyFromFloat(50), withdrawPolicy.DailyLimit)
+
+	err = s.manager.DeleteRoleWithdrawalTier(role.Id)
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateUserWithdrawalProfile(
+		withdrawUserId,
+		types.UpdateUserWithdrawalProfilePayload{
+			TierId: utils.Ptr(withdrawalTierId),
+		},
+	)
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateWithdrawTransaction(withdrawWallet.Id, types.MoneyFromFloat(1))
+	s.Require().EqualError(
+		err,
+		types.ErrWithdrawAmountBelowMinimum(types.MoneyFromFloat(5)).Error(),
+	)
+
+	withdrawTxId, err := s.manager.CreateWithdrawTransaction(
+		withdrawWallet.Id,
+		types.MoneyFromFloat(40),
+	)
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateWithdrawTransaction(withdrawWallet.Id, types.MoneyFromFloat(20))
+	s.Require().EqualError(
+		err,
+		types.ErrWithdrawDailyLimitExceeded(types.MoneyFromFloat(50)).Error(),
+	)
+
+	err = s.manager.UpdateUserWithdrawalProfile(
+		withdrawUserId,
+		types.UpdateUserWithdrawalProfilePayload{
+			KycVerified: utils.Ptr(true),
+		},
+	)
+	s.Require().NoError(err)
+
+	withdrawProfile, err := s.manager.GetUserWithdrawalProfile(withdrawUserId)
+	s.Require().NoError(err)
+	s.Require().True(withdrawProfile.KycVerified)
+	s.Require().True(withdrawProfile.KycVerifiedAt.Valid)
+	s.Require().Equal(withdrawalTierId, int(withdrawProfile.TierId.Int32))
+
+	_, err = s.manager.CreateWithdrawTransaction(withdrawWallet.Id, types.MoneyFromFloat(20))
+	s.Require().NoError(err)
+
+	err = s.manager.UpdateWithdrawalTier(withdrawalTierId, types.UpdateWithdrawalTierPayload{
+		KycMonthlyLimit: utils.Ptr(types.MoneyFromFloat(100)),
+	})
+	s.Require().NoError(err)
+
+	_, err = s.manager.CreateWithdrawTransaction(withdrawWallet.Id, types.MoneyFromFloat(50))
+	s.Require().EqualError(
+		err,
+		types.ErrWithdrawMonthlyLimitExceeded(types.MoneyFromFloat(100)).Error(),
+	)
+
+	withdrawPolicy, err = s.manager.GetUserWithdrawalPolicy(withdrawUserId)
+	s.Require().NoError(err)
+	s.Require().True(withdrawPolicy.KycVerified)
+	s.Require().Equal(types.MoneyFromFloat(200), withdrawPolicy.DailyLimit)
+	s.Require().Equal(types.MoneyFromFloat(60), withdrawPolicy.DailyUsed)
+	s.Require().Equal(types.MoneyFromFloat(60), withdrawPolicy.MonthlyUsed)
+
+	// the cancelled withdrawals do not count against the limits
+	err = s.manager.UpdateWalletTransaction(
+		withdrawWallet.Id,
+		withdrawTxId,
+		types.UpdateWalletTransactionPayload{
+			Status: utils.Ptr(types.TransactionStatusFailed),
+		},
+	)
+	s.Require().NoError(err)
+
+	withdrawPolicy, err = s.manager.GetUserWithdrawalPolicy(withdrawUserId)
+	s.Require().NoError(err)
+	s.Require().Equal(types.MoneyFromFloat(20), withdrawPolicy.DailyUsed)
+
+	err = s.manager.UpdateUser(withdrawUserId, types.UpdateUserPayload{
+		Password: utils.Ptr("changedpassword"),
+	})
+	s.Require().NoError(err)
+
+	withdrawPolicy, err = s.manager.GetUserWithdrawalPolicy(withdrawUserId)
+	s.Require().NoError(err)
+	s.Require().True(withdrawPolicy.CooldownEndsAt.Valid)
+
+	_, err = s.manager.CreateWithdrawTransaction(withdrawWallet.Id, types.MoneyFromFloat(10))
+	s.Require().EqualError(
+		err,
+		types.ErrWithdrawCooldownActive(withdrawPolicy.CooldownEndsAt.Time).Error(),
+	)
+
+	err = s.manager.UpdateWithdrawalTier(defaultTierId, types.UpdateWithdrawalTierPayload{
+		IsDefault: utils.Ptr(false),
+	})
+	s.Require().ErrorIs(err, types.ErrDefaultWithdrawalTierRequired)
+
+	err = s.manager.DeleteWithdrawalTier(defaultTierId)
+	s.Require().ErrorIs(err, types.ErrCannotDeleteDefaultWithdrawalTier)
+
+	err = s.manager.DeleteWithdrawalTier(withdrawalTierId)
+	s.Require().NoError(err)
+
+	_, err = s.manager.GetWithdrawalTierById(withdrawalTierId)
+	s.Require().ErrorIs(err, types.ErrWithdrawalTierNotFound)
+
+	withdrawProfile, err = s.manager.GetUserWithdrawalProfile(withdrawUserId)
+	s.Require().NoError(err)
+	s.Require().False(withdrawProfile.TierId.Valid)
+	s.Require().True(withdrawProfile.CredentialsChangedAt.Valid)
+
+	// the default tier has no cooldown
+	_, err = s.manager.CreateWithdrawTransaction(withdrawWallet.Id, types.MoneyFromFloat(10))
+	s.Require().NoError(err)
+
+	statementTo := time.Now().Add(time.Minute)
+	statement, err := s.manager.GetWalletStatement(
+		userWallet.Id,
+		statementTo.Add(-24*time.Hour),
+		statementTo,
+	)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(statement.Lines)
+
+	userLedgerBalance, err = s.manager.GetWalletLedgerBalance(userWallet.Id)
+	s.Require().NoError(err)
+	s.Require().Equal(userLedgerBalance, statement.ClosingBalance)
+	s.Require().Equal(
+		statement.OpeningBalance.Add(statement.TotalCredits).Sub(statement.TotalDebits),
+		statement.ClosingBalance,
+	)
+
+	statementBalance := statement.OpeningBalance
+	hasEscrowRelease := false
+	for _, l := range statement.Lines {
+		statementBalance = statementBalance.Add(l.Amount)
+		s.Require().Equal(statementBalance, l.Balance)
+
+		if l.Kind == types.JournalEntryKindEscrowRelease && int(l.OrderId.Int32) == escrowOrderId {
+			hasEscrowRelease = true
+		}
+	}
+	s.Require().Equal(statement.ClosingBalance, statementBalance)
+	s.Require().True(hasEscrowRelease)
+
+	// a statement that starts at an entry opens with the balance before it
+	lastLine := statement.Lines[len(statement.Lines)-1]
+	lastLineStatement, err := s.manager.GetWalletStatement(
+		userWallet.Id,
+		lastLine.CreatedAt,
+		statementTo,
+	)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(lastLineStatement.Lines)
+	s.Require().Equal(
+		lastLineStatement.Lines[0].Balance.Sub(lastLineStatement.Lines[0].Amount),
+		lastLineStatement.OpeningBalance,
+	)
+	s.Require().Equal(statement.ClosingBalance, lastLineStatement.ClosingBalance)
+
+	futureStatement, err := s.manager.GetWalletStatement(
+		userWallet.Id,
+		statementTo,
+		statementTo.Add(time.Hour),
+	)
+	s.Require().NoError(err)
+	s.Require().Empty(futureStatement.Lines)
+	s.Require().Equal(statement.ClosingBalance, futureStatement.OpeningBalance)
+	s.Require().Equal(statement.ClosingBalance, futureStatement.ClosingBalance)
+
+	reconciliation, err := s.manager.ReconcileWallets(false)
+	s.Require().NoError(err)
+	s.Require().Positive(reconciliation.CheckedWallets)
+	for _, d := range reconciliation.Drifts {
+		s.Require().NotContains(
+			[]int{userWallet.Id, user2Wallet.Id, withdrawWallet.Id},
+			d.WalletId,
+		)
+	}
+
+	_, err = s.db.Exec("UPDATE wallets SET balance = balance + 5 WHERE id = $1;", withdrawWallet.Id)
+	s.Require().NoError(err)
+
+	withdrawLedgerBalance, err := s.manager.GetWalletLedgerBalance(withdrawWallet.Id)
+	s.Require().NoError(err)
+
+	reconciliation, err = s.manager.ReconcileWallets(true)
+	s.Require().NoError(err)
+	s.Require().Positive(reconciliation.FrozenWallets)
+
+	var withdrawDrift *types.WalletDrift
+	for _, d := range reconciliation.Drifts {
+		if d.WalletId == withdrawWallet.Id {
+			withdrawDrift = &d
+		}
+	}
+	s.Require().NotNil(withdrawDrift)
+	s.Require().Equal(withdrawUserId, withdrawDrift.UserId)
+	s.Require().Equal(withdrawLedgerBalance, withdrawDrift.LedgerBalance)
+	s.Require().Equal(withdrawLedgerBalance, withdrawDrift.ExpectedBalance)
+	s.Require().Equal(withdrawLedgerBalance.Add(types.MoneyFromFloat(5)), withdrawDrift.CachedBalance)
+	s.Require().True(withdrawDrift.Frozen)
+
+	withdrawWallet, err = s.manager.GetUserWallet(withdrawUserId)
+	s.Require().NoError(err)
+	s.Require().True(withdrawWallet.IsFrozen)
+
+	_, err = s.manager.CreateWithdrawTransaction(withdrawWallet.Id, types.MoneyFromFloat(10))
+	s.Require().ErrorIs(err, types.ErrWalletFrozen)
+
+	_, err = s.manager.CreateWalletTransfer(types.WalletTransferInsertData{
+		SenderWalletId:   withdrawWallet.Id,
+		ReceiverWalletId: userWallet.Id,
+		Amount:           types.MoneyFromFloat(1),
+	})
+	s.Require().ErrorIs(err, types.ErrWalletFrozen)
+
+	// the next reconciliation updates the open drift and keeps it frozen
+	reconciliation, err = s.manager.ReconcileWallets(false)
+	s.Require().NoError(err)
+	openDrifts, err := s.manager.GetWalletDrifts(types.WalletDriftSearchQuery{
+		Resolved: utils.Ptr(false),
+		UserId:   &withdrawUserId,
+	})
+	s.Require().NoError(err)
+	s.Require().Len(openDrifts, 1)
+	s.Require().Equal(withdrawDrift.Id, openDrifts[0].Id)
+	s.Require().True(openDrifts[0].Frozen)
+
+	_, err = s.db.Exec("UPDATE wallets SET balance = balance - 5 WHERE id = $1;", withdrawWallet.Id)
+	s.Require().NoError(err)
+
+	err = s.manager.ResolveWalletDrift(withdrawDrift.Id, userId, types.ResolveWalletDriftPayload{
+		Note: "Cached balance fixed",
+	})
+	s.Require().NoError(err)
+
+	err = s.manager.ResolveWalletDrift(withdrawDrift.Id, userId, types.ResolveWalletDriftPayload{
+		Note: "Cached balance fixed",
+	})
+	s.Require().ErrorIs(err, types.ErrWalletDriftAlreadyResolved)
+
+	err = s.manager.ResolveWalletDrift(-1, userId, types.ResolveWalletDriftPayload{
+		Note: "Cached balance fixed",
+	})
+	s.Require().ErrorIs(err, types.ErrWalletDriftNotFound)
+
+	withdrawDrift, err = s.manager.GetWalletDriftById(withdrawDrift.Id)
+	s.Require().NoError(err)
+	s.Require().True(withdrawDrift.ResolvedAt.Valid)
+	s.Require().Equal(userId, int(withdrawDrift.ResolvedBy.Int32))
+	s.Require().Equal("Cached balance fixed", withdrawDrift.Note.String)
+
+	withdrawWallet, err = s.manager.GetUserWallet(withdrawUserId)
+	s.Require().NoError(err)
+	s.Require().False(withdrawWallet.IsFrozen)
+
+	reconciliation, err = s.manager.ReconcileWallets(true)
+	s.Require().NoError(err)
+	for _, d := range reconciliation.Drifts {
+		s.Require().NotEqual(withdrawWallet.Id, d.WalletId)
+	}
+
+	_, err = s.manager.CreateWithdrawTransaction(withdrawWallet.Id, types.MoneyFromFloat(10))
+	s.Require().NoError(err)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Keyword: utils.Ptr("xbox"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 2)
+	for _, p := range products {
+		s.Require().NotNil(p.Snippet)
+		s.Require().Contains(*p.Snippet, "<mark>xbox</mark>")
+	}
+
+	// a match in the specs ranks below the matches in the names
+	_, err = s.manager.CreateProductSpec(product1Id, types.CreateProductSpecPayload{
+		Label: "compatibility",
+		Value: "works with xbox",
+	})
+	s.Require().NoError(err)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Keyword: utils.Ptr("xbox"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 3)
+	s.Require().Equal(product1Id, products[2].Id)
+
+	prodCount, err = s.manager.GetProductsCount(types.ProductSearchQuery{
+		Keyword: utils.Ptr("xbox"),
+	})
+	s.Require().NoError(err)
+	s.Require().Equal(3, prodCount)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Keyword: utils.Ptr("contr*"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 1)
+	s.Require().Equal(product2Id, products[0].Id)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Keyword: utils.Ptr("contr"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 0)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Keyword: utils.Ptr(`"xbox series"`),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 1)
+	s.Require().Equal(product3Id, products[0].Id)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Keyword: utils.Ptr(`"series xbox"`),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 0)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Keyword: utils.Ptr("xbox & | !"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 3)
+
+	// the search documents follow the renamed tags and categories
+	err = s.manager.UpdateProductTag(prodTag3Id, types.UpdateProductTagPayload{
+		Name: utils.Ptr("collector edition"),
+	})
+	s.Require().NoError(err)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Keyword: utils.Ptr("collector"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 1)
+	s.Require().Equal(newProductId, products[0].Id)
+
+	err = s.manager.UpdateProductCategory(prodCat3Id, types.UpdateProductCategoryPayload{
+		Name: utils.Ptr("living room"),
+	})
+	s.Require().NoError(err)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Keyword: utils.Ptr("living room"),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 1)
+	s.Require().Equal(product1Id, products[0].Id)
+
+	facets, err := s.manager.GetProductFacets(
+		types.ProductSearchQuery{
+			Keyword: utils.Ptr("xbox"),
+			Limit:   utils.Ptr(1),
+		},
+		[]types.Money{types.MoneyFromFloat(1000), types.MoneyFromFloat(2000)},
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(3, facets.Total)
+	s.Require().Len(facets.Categories, 3)
+	for _, c := range facets.Categories {
+		s.Require().Equal(1, c.Count)
+	}
+	s.Require().Len(facets.Stores, 1)
+	s.Require().Equal(storeId, facets.Stores[0].Id)
+	s.Require().Equal(3, facets.Stores[0].Count)
+	s.Require().Equal(1, facets.Offer.WithOffer)
+	s.Require().Equal(2, facets.Offer.WithoutOffer)
+
+	tagCounts := map[int]int{}
+	for _, t := range facets.Tags {
+		tagCounts[t.Id] = t.Count
+	}
+	s.Require().Equal(2, tagCounts[prodTag1Id])
+	s.Require().Equal(2, tagCounts[prodTag2Id])
+	s.Require().NotContains(tagCounts, prodTag3Id)
+
+	for _, o := range facets.AttributeOptions {
+		s.Require().Greater(o.Count, 0)
+		s.Require().LessOrEqual(o.Count, facets.Total)
+	}
+
+	s.Require().Len(facets.PriceRanges, 3)
+	s.Require().Nil(facets.PriceRanges[0].Min)
+	s.Require().Equal(0, facets.PriceRanges[0].Count)
+	s.Require().Equal(types.MoneyFromFloat(1000), *facets.PriceRanges[1].Min)
+	s.Require().Equal(types.MoneyFromFloat(2000), *facets.PriceRanges[1].Max)
+	s.Require().Equal(2, facets.PriceRanges[1].Count)
+	s.Require().Nil(facets.PriceRanges[2].Max)
+	s.Require().Equal(1, facets.PriceRanges[2].Count)
+
+	facets, err = s.manager.GetProductFacets(types.ProductSearchQuery{
+		Keyword: utils.Ptr("xbox"),
+		StoreId: utils.Ptr(storeWithSettings.Id),
+	}, []types.Money{})
+	s.Require().NoError(err)
+	s.Require().Equal(0, facets.Total)
+	s.Require().Len(facets.Categories, 0)
+	s.Require().Len(facets.PriceRanges, 1)
+	s.Require().Equal(0, facets.PriceRanges[0].Count)
+
+	firstPage, err := s.manager.GetProductsPage(types.ProductSearchQuery{
+		Limit: utils.Ptr(2),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(firstPage.Items, 2)
+	s.Require().NotNil(firstPage.NextCursor)
+	s.Require().Equal(types.ProductSortNewest.String(), firstPage.NextCursor.Sort)
+	s.Require().Equal(newProductId, firstPage.Items[0].Id)
+
+	// a product added between the pages does not shift the next page
+	cursorProductId, err := s.manager.CreateProductBase(types.CreateProductBasePayload{
+		Name:           "cursor product",
+		Slug:           "cursor-product",
+		Price:          types.MoneyFromFloat(10),
+		ShipmentFactor: 0.1,
+		SubcategoryId:  prodCat3Id,
+		StoreId:        storeId,
+	})
+	s.Require().NoError(err)
+
+	secondPage, err := s.manager.GetProductsPage(types.ProductSearchQuery{
+		Cursor: firstPage.NextCursor,
+		Limit:  utils.Ptr(2),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(secondPage.Items, 2)
+	s.Require().NotNil(secondPage.NextCursor)
+
+	seenProducts := map[int]bool{}
+	for _, p := range append(firstPage.Items, secondPage.Items...) {
+		s.Require().NotEqual(cursorProductId, p.Id)
+		seenProducts[p.Id] = true
+	}
+	s.Require().Len(seenProducts, 4)
+
+	lastPage, err := s.manager.GetProductsPage(types.ProductSearchQuery{
+		Cursor: secondPage.NextCursor,
+		Limit:  utils.Ptr(2),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(lastPage.Items, 0)
+	s.Require().Nil(lastPage.NextCursor)
+
+	_, err = s.manager.GetProductsPage(types.ProductSearchQuery{
+		Sort:   utils.Ptr(types.ProductSortPriceAsc),
+		Cursor: firstPage.NextCursor,
+		Limit:  utils.Ptr(2),
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidCursor)
+
+	firstPage, err = s.manager.GetProductsPage(types.ProductSearchQuery{
+		Sort:  utils.Ptr(types.ProductSortPriceAsc),
+		Limit: utils.Ptr(2),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(firstPage.Items, 2)
+	s.Require().Equal(cursorProductId, firstPage.Items[0].Id)
+	s.Require().Equal(product1Id, firstPage.Items[1].Id)
+
+	secondPage, err = s.manager.GetProductsPage(types.ProductSearchQuery{
+		Sort:   utils.Ptr(types.ProductSortPriceAsc),
+		Cursor: firstPage.NextCursor,
+		Limit:  utils.Ptr(2),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(secondPage.Items, 2)
+	s.Require().Equal(product2Id, secondPage.Items[0].Id)
+	s.Require().Equal(product3Id, secondPage.Items[1].Id)
+
+	products, err = s.manager.GetProducts(types.ProductSearchQuery{
+		Sort: utils.Ptr(types.ProductSortPriceDesc),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(products, 5)
+	s.Require().Equal(newProductId, products[0].Id)
+	s.Require().Equal(cursorProductId, products[4].Id)
+
+	for _, sort := range types.ValidProductSorts {
+		products, err = s.manager.GetProducts(types.ProductSearchQuery{
+			Keyword: utils.Ptr("xbox"),
+			Sort:    utils.Ptr(sort),
+		})
+		s.Require().NoError(err)
+		s.Require().Len(products, 3)
+	}
+
+	ordersPage, err := s.manager.GetOrdersPage(types.OrderSearchQuery{
+		Limit: utils.Ptr(1),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(ordersPage.Items, 1)
+	s.Require().NotNil(ordersPage.NextCursor)
+
+	nextOrdersPage, err := s.manager.GetOrdersPage(types.OrderSearchQuery{
+		Cursor: ordersPage.NextCursor,
+		Limit:  utils.Ptr(1),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(nextOrdersPage.Items, 1)
+	s.Require().NotEqual(ordersPage.Items[0].Id, nextOrdersPage.Items[0].Id)
+	s.Require().False(nextOrdersPage.Items[0].CreatedAt.After(ordersPage.Items[0].CreatedAt))
+
+	txsPage, err := s.manager.GetWalletTransactionsPage(types.WalletTransactionSearchQuery{
+		UserId: &userId,
+		Limit:  utils.Ptr(1),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(txsPage.Items, 1)
+	s.Require().NotNil(txsPage.NextCursor)
+
+	nextTxsPage, err := s.manager.GetWalletTransactionsPage(types.WalletTransactionSearchQuery{
+		UserId: &userId,
+		Cursor: txsPage.NextCursor,
+		Limit:  utils.Ptr(1),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(nextTxsPage.Items, 1)
+	s.Require().NotEqual(txsPage.Items[0].Id, nextTxsPage.Items[0].Id)
+
+	_, err = s.manager.GetWalletTransactionsPage(types.WalletTransactionSearchQuery{
+		UserId: &userId,
+		Cursor: firstPage.NextCursor,
+	})
+	s.Require().ErrorIs(err, types.ErrInvalidCursor)
+
+	attr2, err = s.manager.GetProductAttributeWithOptionsById(att2Id)
+	s.Require().NoError(err)
+	attr4, err = s.manager.GetProductAttributeWithOptionsById(att4Id)
+	s.Require().NoError(err)
+
+	importRow := types.ProductCatalogRow{
+		Slug:           "imported-product",
+		Name:           "imported product",
+		Price:          types.MoneyFromFloat(25.5),
+		ShipmentFactor: 0.2,
+		Description:    "IMPORTED PRODUCT",
+		IsActive:       utils.Ptr(false),
+		SubcategoryId:  prodCat3Id,
+		TagIds:         []int{prodTag1Id, prodTag2Id},
+		Specs: []types.CreateProductSpecPayload{
+			{Label: "material", Value: "wood"},
+		},
+		Images: []types.CreateProductImagePayload{
+			{ImageName: "imported-main", IsMain: true},
+		},
+		Variants: []types.CreateProductVariantPayload{
+			{
+				Quantity: 5,
+				AttributeSets: []types.ProductVariantAttributeSetPayload{
+					{AttributeId: attr2.Id, OptionId: attr2.Options[0].Id},
+					{AttributeId: attr4.Id, OptionId: attr4.Options[0].Id},
+				},
+			},
+		},
+	}
+
+	// a dry run checks the row without saving it
+	created, err := s.manager.ImportProductCatalogRow(storeId, importRow, true)
+	s.Require().NoError(err)
+	s.Require().True(created)
+
+	importedCount := 0
+	err = s.db.QueryRow("SELECT COUNT(*) FROM products WHERE slug = $1;", importRow.Slug).
+		Scan(&importedCount)
+	s.Require().NoError(err)
+	s.Require().Equal(0, importedCount)
+
+	created, err = s.manager.ImportProductCatalogRow(storeId, importRow, false)
+	s.Require().NoError(err)
+	s.Require().True(created)
+
+	catalog, err := s.manager.GetStoreProductCatalog(storeId)
+	s.Require().NoError(err)
+
+	var importedRow *types.ProductCatalogRow
+	for i := range catalog {
+		if catalog[i].Slug == importRow.Slug {
+			importedRow = &catalog[i]
+		}
+	}
+	s.Require().NotNil(importedRow)
+	s.Require().Equal(importRow.Price, importedRow.Price)
+	s.Require().False(*importedRow.IsActive)
+	s.Require().ElementsMatch(importRow.TagIds, importedRow.TagIds)
+	s.Require().Equal(importRow.Specs, importedRow.Specs)
+	s.Require().Equal(importRow.Images, importedRow.Images)
+	s.Require().Len(importedRow.Variants, 1)
+	s.Require().Equal(5, importedRow.Variants[0].Quantity)
+
+	// the same slug updates the product, its variant with the same attributes
+	// in another order gets the new quantity and the new variant is added
+	importRow.Name = "updated imported product"
+	importRow.IsActive = nil
+	importRow.TagIds = []int{prodTag3Id}
+	importRow.Specs = []types.CreateProductSpecPayload{}
+	importRow.Variants = []types.CreateProductVariantPayload{
+		{
+			Quantity: 8,
+			AttributeSets: []types.ProductVariantAttributeSetPayload{
+				{AttributeId: attr4.Id, OptionId: attr4.Options[0].Id},
+				{AttributeId: attr2.Id, OptionId: attr2.Options[0].Id},
+			},
+		},
+		{
+			Quantity: 3,
+			AttributeSets: []types.ProductVariantAttributeSetPayload{
+				{AttributeId: attr2.Id, OptionId: attr2.Options[1].Id},
+			},
+		},
+	}
+
+	created, err = s.manager.ImportProductCatalogRow(storeId, importRow, false)
+	s.Require().NoError(err)
+	s.Require().False(created)
+
+	catalog, err = s.manager.GetStoreProductCatalog(storeId)
+	s.Require().NoError(err)
+
+	importedRow = nil
+	for i := range catalog {
+		if catalog[i].Slug == importRow.Slug {
+			importedRow = &catalog[i]
+		}
+	}
+	s.Require().NotNil(importedRow)
+	s.Require().Equal("updated imported product", importedRow.Name)
+	s.Require().False(*importedRow.IsActive)
+	s.Require().Equal([]int{prodTag3Id}, importedRow.TagIds)
+	s.Require().Len(importedRow.Specs, 0)
+	s.Require().Len(importedRow.Variants, 2)
+	s.Require().Equal(8, importedRow.Variants[0].Quantity)
+	s.Require().Equal(3, importedRow.Variants[1].Quantity)
+
+	_, err = s.manager.ImportProductCatalogRow(store2Id, importRow, false)
+	s.Require().ErrorIs(err, types.ErrProductSlugOwnedByAnotherStore)
+
+	invalidRow := importRow
+	invalidRow.Slug = "invalid-imported-product"
+	invalidRow.Images = []types.CreateProductImagePayload{}
+	invalidRow.Variants = []types.CreateProductVariantPayload{
+		{
+			Quantity: 1,
+			AttributeSets: []types.ProductVariantAttributeSetPayload{
+				{AttributeId: attr4.Id, OptionId: attr2.Options[0].Id},
+			},
+		},
+	}
+	_, err = s.manager.ImportProductCatalogRow(storeId, invalidRow, false)
+	s.Require().ErrorIs(err, types.ErrAttributeOptionMismatch)
+
+	invalidRow.Variants = []types.CreateProductVariantPayload{}
+	invalidRow.TagIds = []int{prodTag1Id, 999999}
+	_, err = s.manager.ImportProductCatalogRow(storeId, invalidRow, false)
+	s.Require().ErrorIs(err, types.ErrProductTagNotFound)
+
+	invalidRow.TagIds = []int{}
+	invalidRow.SubcategoryId = 999999
+	_, err = s.manager.ImportProductCatalogRow(storeId, invalidRow, false)
+	s.Require().ErrorIs(err, types.ErrSubcategoryNotFound)
+
+	// the imports are queued and claimed once
+	importId, err := s.manager.CreateProductImport(types.CreateProductImportPayload{
+		Format:  types.ProductImportFormatJSONL,
+		DryRun:  true,
+		Data:    "{}",
+		StoreId: storeId,
+		UserId:  userId,
+	})
+	s.Require().NoError(err)
+
+	claimedImport, data, err := s.manager.ClaimPendingProductImport()
+	s.Require().NoError(err)
+	s.Require().NotNil(claimedImport)
+	s.Require().Equal(importId, claimedImport.Id)
+	s.Require().Equal(types.ProductImportStatusRunning, claimedImport.Status)
+	s.Require().Equal("{}", data)
+
+	claimedImport, _, err = s.manager.ClaimPendingProductImport()
+	s.Require().NoError(err)
+	s.Require().Nil(claimedImport)
+
+	_, err = s.db.Exec(
+		"UPDATE product_imports SET started_at = $1 WHERE id = $2;",
+		time.Now().Add(-time.Duration((config.Env.ProductImportTimeoutInMin+1)*float64(time.Minute))),
+		importId,
+	)
+	s.Require().NoError(err)
+
+	claimedImport, _, err = s.manager.ClaimPendingProductImport()
+	s.Require().NoError(err)
+	s.Require().NotNil(claimedImport)
+	s.Require().Equal(importId, claimedImport.Id)
+
+	claimedImport, _, err = s.manager.ClaimPendingProductImport()
+	s.Require().NoError(err)
+	s.Require().Nil(claimedImport)
+
+	err = s.manager.CompleteProductImport(importId, types.ProductImportResult{
+		TotalRows:   2,
+		UpdatedRows: 1,
+		Errors: []types.ProductImportRowError{
+			{RowNumber: 2, Slug: utils.Ptr("bad-row"), Message: "bad row"},
+		},
+	})
+	s.Require().NoError(err)
+
+	imp, err := s.manager.GetProductImportById(importId)
+	s.Require().NoError(err)
+	s.Require().Equal(types.ProductImportStatusCompleted, imp.Status)
+	s.Require().Equal(2, imp.TotalRows)
+	s.Require().Equal(1, imp.UpdatedRows)
+	s.Require().Equal(1, imp.FailedRows)
+	s.Require().True(imp.FinishedAt.Valid)
+	s.Require().Len(imp.Errors, 1)
+	s.Require().Equal(2, imp.Errors[0].RowNumber)
+	s.Require().Equal("bad-row", imp.Errors[0].Slug.String)
+
+	imports, err := s.manager.GetProductImports(types.ProductImportSearchQuery{
+		StoreId: &storeId,
+		Status:  utils.Ptr(types.ProductImportStatusCompleted),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(imports, 1)
+
+	_, err = s.manager.GetProductImportById(importId + 1)
+	s.Require().ErrorIs(err, types.ErrProductImportNotFound)
 }
@@ -0,0 +1,107 @@
+package db_manager
+
+import (
+	"database/sql"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+func (m *Manager) GetOrderInventoryReservations(
+	orderId int,
+) ([]types.InventoryReservation, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM inventory_reservations WHERE order_id = $1 ORDER BY id;",
+		orderId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := []types.InventoryReservation{}
+
+	for rows.Next() {
+		r, err := scanInventoryReservationRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		reservations = append(reservations, *r)
+	}
+
+	return reservations, nil
+}
+
+// CancelExpiredPendingOrders fails the payment of the pending orders whose
+// inventory holds are expired, which releases the holds of these orders, and
+// cancels their shipments that are not finished. The changes are recorded in
+// the timeline of these orders, the shipments before the payment, and the
+// number of cancelled orders is returned.
+func (m *Manager) CancelExpiredPendingOrders() (int, error) {
+	cancelled := 0
+	err := m.db.QueryRow(`
+		WITH cancelled AS (
+			UPDATE order_payments op
+			SET status = $1, updated_at = CURRENT_TIMESTAMP
+			WHERE op.status = $2 AND EXISTS (
+				SELECT 1 FROM inventory_reservations ir
+				WHERE ir.order_id = op.order_id AND ir.expires_at <= CURRENT_TIMESTAMP
+			)
+			RETURNING op.order_id
+		), cancelled_shipments AS (
+			UPDATE order_shipments os
+			SET status = $5, updated_at = CURRENT_TIMESTAMP
+			FROM order_shipments old
+			WHERE old.id = os.id AND os.status IN ($6, $7)
+			AND os.order_id IN (SELECT order_id FROM cancelled)
+			RETURNING os.id, os.order_id, os.store_id, old.status AS old_status
+		), events AS (
+			INSERT INTO order_status_events (kind, old_status, new_status, reason, order_id, store_id)
+			SELECT e.kind::order_status_event_kinds, e.old_status, e.new_status, $4, e.order_id, e.store_id
+			FROM (
+				SELECT
+					$8::TEXT AS kind, cs.old_status::TEXT AS old_status, $5::TEXT AS new_status,
+					cs.order_id, cs.store_id, 0 AS step, cs.id
+				FROM cancelled_shipments cs
+				UNION ALL
+				SELECT $3::TEXT, $2::TEXT, $1::TEXT, c.order_id, NULL::INTEGER, 1, c.order_id
+				FROM cancelled c
+			) e
+			ORDER BY e.order_id, e.step, e.id
+		)
+		SELECT COUNT(*) FROM cancelled;
+	`,
+		types.OrderPaymentStatusFailed,
+		types.OrderPaymentStatusPending,
+		types.OrderStatusEventKindPayment,
+		"inventory reservation expired",
+		types.OrderShipmentStatusCancelled,
+		types.OrderShipmentStatusToBeDetermined,
+		types.OrderShipmentStatusOnTheWay,
+		types.OrderStatusEventKindShipment,
+	).
+		Scan(&cancelled)
+	if err != nil {
+		return -1, err
+	}
+
+	return cancelled, nil
+}
+
+func scanInventoryReservationRow(rows *sql.Rows) (*types.InventoryReservation, error) {
+	n := new(types.InventoryReservation)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Quantity,
+		&n.ExpiresAt,
+		&n.CreatedAt,
+		&n.OrderId,
+		&n.VariantId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,327 @@
+package db_manager
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+func (m *Manager) GetOrderInvoice(orderId int) (*types.Invoice, error) {
+	rows, err := m.db.Query("SELECT * FROM invoices WHERE order_id = $1;", orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoice := new(types.Invoice)
+	invoice.Id = -1
+
+	for rows.Next() {
+		invoice, err = scanInvoiceRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if invoice.Id == -1 {
+		return nil, types.ErrInvoiceNotFound
+	}
+
+	return invoice, nil
+}
+
+// createOrderInvoiceAsDBTx issues the invoice of an order with the next
+// invoice number. The table is locked until the transaction ends so that
+// concurrent payments cannot take the same number or leave a gap.
+func createOrderInvoiceAsDBTx(tx *sql.Tx, orderId int, paidAt time.Time) (int, error) {
+	snapshot, err := buildInvoiceSnapshotAsDBTx(tx, orderId, paidAt)
+	if err != nil {
+		return -1, err
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return -1, err
+	}
+
+	_, err = tx.Exec("LOCK TABLE invoices IN EXCLUSIVE MODE;")
+	if err != nil {
+		return -1, err
+	}
+
+	rowId := -1
+	err = tx.QueryRow(`
+		INSERT INTO invoices (number, snapshot, order_id)
+		SELECT COALESCE(MAX(number), 0) + 1, $1, $2 FROM invoices
+		RETURNING id;
+	`, snapshotJSON, orderId).
+		Scan(&rowId)
+	if err != nil {
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+// buildInvoiceSnapshotAsDBTx reads the order, the buyer, the lines and the
+// stores of an invoice in the transaction that issues it, so the snapshot
+// matches the payment that is being settled.
+func buildInvoiceSnapshotAsDBTx(
+	tx *sql.Tx,
+	orderId int,
+	paidAt time.Time,
+) (*types.InvoiceSnapshot, error) {
+	snapshot := types.InvoiceSnapshot{
+		OrderId: orderId,
+		PaidAt:  paidAt,
+		Stores:  []types.InvoiceStore{},
+		Lines:   []types.InvoiceLine{},
+	}
+
+	var fullName, details sql.NullString
+	var username string
+
+	err := tx.QueryRow(`
+		SELECT
+			o.created_at, op.total_variants_price, op.total_shipment_price, op.fee,
+			op.discount, op.total_tax, op.exclusive_tax, u.id, u.username, u.full_name,
+			u.email, a.state, a.city, a.street, a.zipcode, a.details
+		FROM orders o
+		JOIN order_payments op ON op.order_id = o.id
+		JOIN users u ON u.id = o.user_id
+		JOIN addresses a ON a.id = (
+			SELECT os.receiver_address_id FROM order_shipments os
+			WHERE os.order_id = o.id
+			ORDER BY os.id
+			LIMIT 1
+		) AND a.user_id IS NOT NULL
+		WHERE o.id = $1;
+	`, orderId).
+		Scan(
+			&snapshot.OrderedAt,
+			&snapshot.TotalVariantsPrice,
+			&snapshot.TotalShipmentPrice,
+			&snapshot.Fee,
+			&snapshot.Discount,
+			&snapshot.TotalTax,
+			&snapshot.ExclusiveTax,
+			&snapshot.Buyer.UserId,
+			&username,
+			&fullName,
+			&snapshot.Buyer.Email,
+			&snapshot.Buyer.Address.State,
+			&snapshot.Buyer.Address.City,
+			&snapshot.Buyer.Address.Street,
+			&snapshot.Buyer.Address.Zipcode,
+			&details,
+		)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, types.ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	snapshot.Buyer.Name = username
+	if fullName.Valid && fullName.String != "" {
+		snapshot.Buyer.Name = fullName.String
+	}
+	snapshot.Buyer.Address.Details = details.String
+
+	snapshot.GrandTotal = snapshot.TotalVariantsPrice.
+		Add(snapshot.TotalShipmentPrice).
+		Add(snapshot.Fee).
+		Sub(snapshot.Discount).
+		Add(snapshot.ExclusiveTax)
+
+	attributes, err := getOrderVariantAttributesAsDBTx(tx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT
+			opv.id, opv.store_id, p.id, p.name, opv.variant_id, opv.quantity,
+			opv.variant_price, opv.shipping_price, opv.discount, opv.tax, opv.tax_rate,
+			opv.tax_pricing_mode
+		FROM order_product_variants opv
+		JOIN product_variants pv ON pv.id = opv.variant_id
+		JOIN products p ON p.id = pv.product_id
+		WHERE opv.order_id = $1
+		ORDER BY opv.id;
+	`, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		line := types.InvoiceLine{}
+		var taxPricingMode sql.NullString
+
+		err := rows.Scan(
+			&line.OrderProductVariantId,
+			&line.StoreId,
+			&line.ProductId,
+			&line.ProductName,
+			&line.VariantId,
+			&line.Quantity,
+			&line.UnitPrice,
+			&line.ShippingPrice,
+			&line.Discount,
+			&line.Tax,
+			&line.TaxRate,
+			&taxPricingMode,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		exclusiveTax := types.Money{}
+		if taxPricingMode.Valid &&
+			taxPricingMode.String == types.TaxPricingModeExclusive.String() {
+			exclusiveTax = line.Tax
+		}
+
+		line.Attributes = strings.Join(attributes[line.VariantId], ", ")
+		line.TaxPricingMode = taxPricingMode.String
+		line.Total = line.UnitPrice.Mul(line.Quantity).Sub(line.Discount).Add(exclusiveTax)
+
+		snapshot.Lines = append(snapshot.Lines, line)
+	}
+	rows.Close()
+
+	stores, err := getInvoiceStoresAsDBTx(tx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	storeIndexes := map[int]int{}
+
+	for _, line := range snapshot.Lines {
+		i, ok := storeIndexes[line.StoreId]
+		if !ok {
+			i = len(snapshot.Stores)
+			storeIndexes[line.StoreId] = i
+			snapshot.Stores = append(snapshot.Stores, stores[line.StoreId])
+		}
+
+		exclusiveTax := types.Money{}
+		if line.TaxPricingMode == types.TaxPricingModeExclusive.String() {
+			exclusiveTax = line.Tax
+		}
+
+		st := &snapshot.Stores[i]
+		st.TotalVariantsPrice = st.TotalVariantsPrice.Add(line.UnitPrice.Mul(line.Quantity))
+		st.TotalShipmentPrice = st.TotalShipmentPrice.Add(line.ShippingPrice)
+		st.TotalDiscount = st.TotalDiscount.Add(line.Discount)
+		st.TotalTax = st.TotalTax.Add(line.Tax)
+		st.ExclusiveTax = st.ExclusiveTax.Add(exclusiveTax)
+	}
+
+	return &snapshot, nil
+}
+
+// getOrderVariantAttributesAsDBTx returns the "label: value" pairs of the
+// selected options of the variants of an order by the variant ids.
+func getOrderVariantAttributesAsDBTx(tx *sql.Tx, orderId int) (map[int][]string, error) {
+	rows, err := tx.Query(`
+		SELECT pvao.variant_id, pa.label, pao.value
+		FROM product_variant_attribute_options pvao
+		JOIN product_attributes pa ON pa.id = pvao.attribute_id
+		JOIN product_attribute_options pao ON pao.id = pvao.option_id
+		WHERE pvao.variant_id IN (
+			SELECT variant_id FROM order_product_variants WHERE order_id = $1
+		)
+		ORDER BY pvao.variant_id, pa.id;
+	`, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attributes := map[int][]string{}
+
+	for rows.Next() {
+		var variantId int
+		var label, value string
+		if err := rows.Scan(&variantId, &label, &value); err != nil {
+			return nil, err
+		}
+
+		attributes[variantId] = append(attributes[variantId], fmt.Sprintf("%s: %s", label, value))
+	}
+
+	return attributes, nil
+}
+
+// getInvoiceStoresAsDBTx returns the stores of the lines of an order by their
+// ids, with the first address of each store.
+func getInvoiceStoresAsDBTx(tx *sql.Tx, orderId int) (map[int]types.InvoiceStore, error) {
+	rows, err := tx.Query(`
+		SELECT s.id, s.name, a.state, a.city, a.street, a.zipcode, a.details
+		FROM stores s
+		LEFT JOIN LATERAL (
+			SELECT * FROM addresses
+			WHERE store_id = s.id
+			ORDER BY id
+			LIMIT 1
+		) a ON TRUE
+		WHERE s.id IN (SELECT store_id FROM order_product_variants WHERE order_id = $1);
+	`, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := map[int]types.InvoiceStore{}
+
+	for rows.Next() {
+		var state, city, street, zipcode, details sql.NullString
+		store := types.InvoiceStore{}
+
+		err := rows.Scan(&store.StoreId, &store.Name, &state, &city, &street, &zipcode, &details)
+		if err != nil {
+			return nil, err
+		}
+
+		store.Address = types.InvoiceAddress{
+			State:   state.String,
+			City:    city.String,
+			Street:  street.String,
+			Zipcode: zipcode.String,
+			Details: details.String,
+		}
+
+		stores[store.StoreId] = store
+	}
+
+	return stores, nil
+}
+
+func scanInvoiceRow(rows *sql.Rows) (*types.Invoice, error) {
+	n := new(types.Invoice)
+	var snapshot []byte
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Number,
+		&snapshot,
+		&n.CreatedAt,
+		&n.OrderId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(snapshot, &n.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
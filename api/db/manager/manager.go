@@ -1,6 +1,11 @@
 package db_manager
 
-import "database/sql"
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
 
 type Manager struct {
 	db *sql.DB
@@ -9,3 +14,14 @@ type Manager struct {
 func NewManager(db *sql.DB) *Manager {
 	return &Manager{db: db}
 }
+
+// isUniqueViolation reports whether err is a violation of the unique
+// constraint or index with the given name.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pq.Error
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == "23505" && pgErr.Constraint == constraint
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 
@@ -13,146 +14,13 @@ import (
 )
 
 func (m *Manager) CreateOrder(p types.CreateOrderPayload) (int, error) {
-	if len(p.ProductVariants) == 0 {
-		return -1, types.ErrProductVariantsAreEmpty
-	}
-
-	rowId := -1
 	ctx := context.Background()
 	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return -1, err
 	}
 
-	err = tx.QueryRow("INSERT INTO orders (user_id) VALUES ($1) RETURNING id;",
-		p.UserId,
-	).
-		Scan(&rowId)
-	if err != nil {
-		tx.Rollback()
-		return -1, err
-	}
-
-	var totalShipmentPrice float64 = 0
-	var totalVariantsPrice float64 = 0
-	var orderFee float64 = 0
-
-	variantQtyMap := make(map[int]int, len(p.ProductVariants))
-	variantIds := make([]int, len(p.ProductVariants))
-	for i, pv := range p.ProductVariants {
-		variantQtyMap[pv.VariantId] = pv.Quantity
-		variantIds[i] = pv.VariantId
-	}
-
-	variantRows, err := tx.Query(`
-		SELECT
-			p.id, pv.id, pv.quantity, p.shipment_factor,
-			COALESCE(
-				p.price * (1 - (
-					SELECT discount FROM product_offers po
-					WHERE po.product_id = p.id AND po.expire_at > NOW()
-					LIMIT 1
-				)), p.price
-			) AS final_price
-		FROM product_variants pv
-		JOIN products p ON p.id = pv.product_id
-		WHERE pv.id = ANY($1)
-	`, pq.Array(variantIds))
-	if err != nil {
-		tx.Rollback()
-		return -1, err
-	}
-	defer variantRows.Close()
-
-	insertData := make([]types.OrderProductVariantInsertData, 0, len(p.ProductVariants))
-
-	for variantRows.Next() {
-		var productId int = -1
-		var variantId int = -1
-		var currentQuantity int = -1
-		var shipmentFactor float64 = 0
-		var variantPrice float64 = 0
-		err := variantRows.Scan(
-			&productId,
-			&variantId,
-			&currentQuantity,
-			&shipmentFactor,
-			&variantPrice,
-		)
-		if err != nil {
-			tx.Rollback()
-			return -1, err
-		}
-		if productId == -1 {
-			tx.Rollback()
-			return -1, types.ErrProductNotFound
-		}
-
-		selectedQuantity, ok := variantQtyMap[variantId]
-		if !ok {
-			tx.Rollback()
-			return -1, types.ErrProductVariantNotFound
-		}
-
-		if currentQuantity < selectedQuantity {
-			tx.Rollback()
-			return -1, types.ErrProductQuantityIsNotEnough(productId)
-		}
-
-		shippingPrice := config.Env.ShipmentPrice * shipmentFactor
-
-		totalShipmentPrice += shippingPrice
-		totalVariantsPrice += variantPrice * float64(selectedQuantity)
-
-		insertData = append(insertData, types.OrderProductVariantInsertData{
-			Quantity:      selectedQuantity,
-			VariantPrice:  variantPrice,
-			ShippingPrice: shippingPrice,
-			VariantId:     variantId,
-			OrderId:       rowId,
-		})
-	}
-
-	if len(insertData) != len(variantIds) {
-		tx.Rollback()
-		return -1, types.ErrProductVariantNotFound
-	}
-
-	for _, d := range insertData {
-		_, err = tx.Exec(
-			"INSERT INTO order_product_variants (quantity, variant_price, shipping_price, variant_id, order_id) VALUES ($1, $2, $3, $4, $5)",
-			d.Quantity,
-			d.VariantPrice,
-			d.ShippingPrice,
-			d.VariantId,
-			d.OrderId,
-		)
-		if err != nil {
-			tx.Rollback()
-			return -1, err
-		}
-	}
-
-	orderFee = totalVariantsPrice * config.Env.OrderFeeFactor
-
-	_, err = tx.Exec(
-		"INSERT INTO order_shipments (arrival_date, order_id, receiver_address_id) VALUES ($1, $2, $3)",
-		p.ArrivalDate,
-		rowId,
-		p.ReceiverAddressId,
-	)
-	if err != nil {
-		tx.Rollback()
-		return -1, err
-	}
-
-	_, err = tx.Exec(
-		"INSERT INTO order_payments (total_variants_price, total_shipment_price, fee, order_id) VALUES ($1, $2, $3, $4)",
-		totalVariantsPrice,
-		totalShipmentPrice,
-		orderFee,
-		rowId,
-	)
+	rowId, err := createOrderAsDBTx(tx, p)
 	if err != nil {
 		tx.Rollback()
 		return -1, err
@@ -169,11 +37,28 @@ func (m *Manager) CreateOrder(p types.CreateOrderPayload) (int, error) {
 func (m *Manager) GetOrders(
 	query types.OrderSearchQuery,
 ) ([]types.Order, error) {
+	page, err := m.GetOrdersPage(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Items, nil
+}
+
+// GetOrdersPage returns the orders of the query, the newest first, with the
+// cursor of the next page if the page is full.
+func (m *Manager) GetOrdersPage(
+	query types.OrderSearchQuery,
+) (*types.Page[types.Order], error) {
+	if query.Cursor != nil && query.Cursor.Sort != types.CursorSortNewest {
+		return nil, types.ErrInvalidCursor
+	}
+
 	var base string
 	base = `
 		SELECT
-			o.*, op.status, os.status,
-			op.total_variants_price, op.total_shipment_price, op.fee,
+			o.*, op.status, derive_order_shipment_status(o.id),
+			op.total_variants_price, op.total_shipment_price, op.fee, op.discount, op.total_tax,
 			(
 				SELECT COUNT(*) 
 				FROM order_product_variants opv 
@@ -181,10 +66,9 @@ func (m *Manager) GetOrders(
 		  ) AS total_products
 		FROM orders o 
 		JOIN order_payments op ON op.order_id = o.id
-		JOIN order_shipments os ON os.order_id = o.id
 	`
 
-	q, args := buildOrderSearchQuery(query, base)
+	q, args := buildOrderSearchQuery(query, base, true)
 
 	rows, err := m.db.Query(q, args...)
 	if err != nil {
@@ -203,28 +87,45 @@ func (m *Manager) GetOrders(
 		orders = append(orders, *order)
 	}
 
-	return orders, nil
+	page := types.Page[types.Order]{Items: orders}
+	if query.Limit != nil && len(orders) > 0 && len(orders) == *query.Limit {
+		last := orders[len(orders)-1]
+		page.NextCursor = types.NewNewestCursor(last.CreatedAt, last.Id)
+	}
+
+	return &page, nil
 }
 
 func (m *Manager) GetOrdersWithFullInfo(
 	query types.OrderSearchQuery,
 ) ([]types.OrderWithFullInfo, error) {
+	// the receiver address and the product count are joined laterally, so
+	// they are read along with the page of orders, and the shipments and the
+	// tax lines are loaded for the whole page after it
 	var base string
 	base = `
 		SELECT
-			o.*, op.*, os.*, a.*,
-			(
-				SELECT COUNT(*) 
-				FROM order_product_variants opv 
-				WHERE opv.order_id = o.id
-		  ) AS total_products
-		FROM orders o 
+			o.*, op.*, derive_order_shipment_status(o.id), a.*, opvc.total_products
+		FROM orders o
 		JOIN order_payments op ON op.order_id = o.id
-		JOIN order_shipments os ON os.order_id = o.id
-		JOIN addresses a ON a.id = os.receiver_address_id AND a.user_id IS NOT NULL
+		JOIN LATERAL (
+			SELECT os.receiver_address_id FROM order_shipments os
+			WHERE os.order_id = o.id
+			ORDER BY os.id
+			LIMIT 1
+		) fs ON true
+		JOIN addresses a ON a.id = fs.receiver_address_id AND a.user_id IS NOT NULL
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS total_products FROM order_product_variants opv
+			WHERE opv.order_id = o.id
+		) opvc
 	`
 
-	q, args := buildOrderSearchQuery(query, base)
+	if query.Cursor != nil && query.Cursor.Sort != types.CursorSortNewest {
+		return nil, types.ErrInvalidCursor
+	}
+
+	q, args := buildOrderSearchQuery(query, base, true)
 
 	rows, err := m.db.Query(q, args...)
 	if err != nil {
@@ -233,6 +134,7 @@ func (m *Manager) GetOrdersWithFullInfo(
 	defer rows.Close()
 
 	orders := []types.OrderWithFullInfo{}
+	orderIds := []int{}
 
 	for rows.Next() {
 		order, err := scanOrderWithFullInfoRow(rows)
@@ -241,6 +143,22 @@ func (m *Manager) GetOrdersWithFullInfo(
 		}
 
 		orders = append(orders, *order)
+		orderIds = append(orderIds, order.Id)
+	}
+
+	shipments, err := m.getOrdersShipments(orderIds)
+	if err != nil {
+		return nil, err
+	}
+
+	taxLines, err := m.getOrdersTaxLines(orderIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		orders[i].Shipments = shipments[orders[i].Id]
+		orders[i].TaxLines = taxLines[orders[i].Id]
 	}
 
 	return orders, nil
@@ -253,10 +171,9 @@ func (m *Manager) GetOrdersCount(
 	base = `
 		SELECT COUNT(DISTINCT o.id) as count FROM orders o
 		JOIN order_payments op ON op.order_id = o.id
-		JOIN order_shipments os ON os.order_id = o.id
 	`
 
-	q, args := buildOrderSearchQuery(query, base)
+	q, args := buildOrderSearchQuery(query, base, false)
 
 	rows, err := m.db.Query(q, args...)
 	if err != nil {
@@ -299,13 +216,37 @@ func (m *Manager) GetOrderProductVariants(orderId int) ([]types.OrderProductVari
 func (m *Manager) GetOrderProductVariantsInfo(
 	orderId int,
 ) ([]types.OrderProductVariantInfo, error) {
-	rows, err := m.db.Query("SELECT * FROM order_product_variants WHERE order_id = $1;", orderId)
+	return m.getOrderProductVariantsInfo(
+		"SELECT * FROM order_product_variants WHERE order_id = $1;",
+		orderId,
+	)
+}
+
+func (m *Manager) GetStoreOrderProductVariantsInfo(
+	orderId int,
+	storeId int,
+) ([]types.OrderProductVariantInfo, error) {
+	return m.getOrderProductVariantsInfo(
+		"SELECT * FROM order_product_variants WHERE order_id = $1 AND store_id = $2;",
+		orderId,
+		storeId,
+	)
+}
+
+// getOrderProductVariantsInfo selects the order lines with q and loads their
+// variants and products for all of the lines at once.
+func (m *Manager) getOrderProductVariantsInfo(
+	q string,
+	args ...any,
+) ([]types.OrderProductVariantInfo, error) {
+	rows, err := m.db.Query(q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	variantsInfo := []types.OrderProductVariantInfo{}
+	orderProductVariants := []types.OrderProductVariant{}
+	variantIds := []int{}
 
 	for rows.Next() {
 		orderProductVariant, err := scanOrderProductVariantRow(rows)
@@ -313,22 +254,52 @@ func (m *Manager) GetOrderProductVariantsInfo(
 			return nil, err
 		}
 
-		selectedVariant, err := m.GetProductVariantWithAttributeSetById(
-			orderProductVariant.VariantId,
-		)
-		if err != nil {
-			return nil, err
+		orderProductVariants = append(orderProductVariants, *orderProductVariant)
+		variantIds = append(variantIds, orderProductVariant.VariantId)
+	}
+	rows.Close()
+
+	variantsInfo := []types.OrderProductVariantInfo{}
+
+	if len(orderProductVariants) == 0 {
+		return variantsInfo, nil
+	}
+
+	variantList, err := m.getProductVariantsWithAttributeSet(
+		productVariantWithAttributeSetQuery+" WHERE pv.id = ANY($1) ORDER BY pv.id, pa.id;",
+		pq.Array(variantIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make(map[int]types.ProductVariantWithAttributeSet, len(variantList))
+	productIds := make([]int, len(variantList))
+	for i, v := range variantList {
+		variants[v.Id] = v
+		productIds[i] = v.ProductId
+	}
+
+	products, err := m.getProductsByIds(productIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, orderProductVariant := range orderProductVariants {
+		selectedVariant, ok := variants[orderProductVariant.VariantId]
+		if !ok {
+			return nil, types.ErrProductVariantNotFound
 		}
 
-		product, err := m.GetProductById(selectedVariant.ProductId)
-		if err != nil {
-			return nil, err
+		product, ok := products[selectedVariant.ProductId]
+		if !ok {
+			return nil, types.ErrProductNotFound
 		}
 
 		variantsInfo = append(variantsInfo, types.OrderProductVariantInfo{
-			OrderProductVariant: *orderProductVariant,
-			SelectedVariant:     *selectedVariant,
-			Product:             *product,
+			OrderProductVariant: orderProductVariant,
+			SelectedVariant:     selectedVariant,
+			Product:             product,
 		})
 	}
 
@@ -338,8 +309,8 @@ func (m *Manager) GetOrderProductVariantsInfo(
 func (m *Manager) GetOrderById(id int) (*types.Order, error) {
 	rows, err := m.db.Query(`
 		SELECT
-			o.*, op.status, os.status,
-			op.total_variants_price, op.total_shipment_price, op.fee,
+			o.*, op.status, derive_order_shipment_status(o.id),
+			op.total_variants_price, op.total_shipment_price, op.fee, op.discount, op.total_tax,
 			(
 				SELECT COUNT(*) 
 				FROM order_product_variants opv 
@@ -347,7 +318,6 @@ func (m *Manager) GetOrderById(id int) (*types.Order, error) {
 		  ) AS total_products
 		FROM orders o 
 		JOIN order_payments op ON op.order_id = o.id
-		JOIN order_shipments os ON os.order_id = o.id
 		WHERE o.id = $1
 	`, id)
 	if err != nil {
@@ -375,7 +345,7 @@ func (m *Manager) GetOrderById(id int) (*types.Order, error) {
 func (m *Manager) GetOrderWithFullInfoById(id int) (*types.OrderWithFullInfo, error) {
 	rows, err := m.db.Query(`
 		SELECT
-			o.*, op.*, os.*, a.*,
+			o.*, op.*, derive_order_shipment_status(o.id), a.*,
 			(
 				SELECT COUNT(*) 
 				FROM order_product_variants opv 
@@ -383,8 +353,12 @@ func (m *Manager) GetOrderWithFullInfoById(id int) (*types.OrderWithFullInfo, er
 		  ) AS total_products
 		FROM orders o 
 		JOIN order_payments op ON op.order_id = o.id
-		JOIN order_shipments os ON os.order_id = o.id
-		JOIN addresses a ON a.id = os.receiver_address_id AND a.user_id IS NOT NULL
+		JOIN addresses a ON a.id = (
+			SELECT os.receiver_address_id FROM order_shipments os
+			WHERE os.order_id = o.id
+			ORDER BY os.id
+			LIMIT 1
+		) AND a.user_id IS NOT NULL
 		WHERE o.id = $1
 	`, id)
 	if err != nil {
@@ -406,18 +380,91 @@ func (m *Manager) GetOrderWithFullInfoById(id int) (*types.OrderWithFullInfo, er
 		return nil, types.ErrOrderNotFound
 	}
 
+	order.Shipments, err = m.GetOrderShipments(order.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	taxLines, err := m.getOrdersTaxLines([]int{order.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	order.TaxLines = taxLines[order.Id]
+
+	return order, nil
+}
+
+func (m *Manager) GetStoreOrderById(orderId int, storeId int) (*types.StoreOrder, error) {
+	rows, err := m.db.Query(`
+		SELECT
+			o.*, op.status, os.*, a.*,
+			(
+				SELECT COALESCE(SUM(opv.variant_price * opv.quantity), 0)
+				FROM order_product_variants opv
+				WHERE opv.order_id = o.id AND opv.store_id = os.store_id
+			) AS total_variants_price,
+			(
+				SELECT COALESCE(SUM(opv.shipping_price), 0)
+				FROM order_product_variants opv
+				WHERE opv.order_id = o.id AND opv.store_id = os.store_id
+			) AS total_shipment_price,
+			(
+				SELECT COALESCE(SUM(opv.discount), 0)
+				FROM order_product_variants opv
+				WHERE opv.order_id = o.id AND opv.store_id = os.store_id
+			) AS total_discount,
+			(
+				SELECT COALESCE(SUM(opv.tax), 0)
+				FROM order_product_variants opv
+				WHERE opv.order_id = o.id AND opv.store_id = os.store_id
+			) AS total_tax,
+			(
+				SELECT COUNT(*)
+				FROM order_product_variants opv
+				WHERE opv.order_id = o.id AND opv.store_id = os.store_id
+			) AS total_products
+		FROM orders o
+		JOIN order_payments op ON op.order_id = o.id
+		JOIN order_shipments os ON os.order_id = o.id AND os.store_id = $2
+		JOIN addresses a ON a.id = os.receiver_address_id AND a.user_id IS NOT NULL
+		WHERE o.id = $1
+	`, orderId, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	order := new(types.StoreOrder)
+	order.Id = -1
+
+	for rows.Next() {
+		order, err = scanStoreOrderRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if order.Id == -1 {
+		return nil, types.ErrOrderNotFound
+	}
+
 	return order, nil
 }
 
+func (m *Manager) GetOrderShipments(orderId int) ([]types.OrderShipment, error) {
+	shipments, err := m.getOrdersShipments([]int{orderId})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipments[orderId], nil
+}
+
 func (m *Manager) IsStoreHasParticipationInOrder(orderId int, storeId int) (bool, error) {
 	rows, err := m.db.Query(`
-		SELECT o.id FROM orders o 
-		WHERE o.id = $1 AND EXISTS (
-			SELECT 1 FROM order_product_variants opv
-			JOIN product_variants pv ON pv.id = opv.variant_id
-			JOIN store_owned_products sop ON sop.product_id = pv.product_id
-			WHERE sop.store_id = $2
-		)
+		SELECT os.order_id FROM order_shipments os
+		WHERE os.order_id = $1 AND os.store_id = $2
 	`, orderId, storeId)
 	if err != nil {
 		return false, err
@@ -440,47 +487,490 @@ func (m *Manager) IsStoreHasParticipationInOrder(orderId int, storeId int) (bool
 	return true, nil
 }
 
+// UpdateOrderShipment updates the shipment of a store in an order. When its
+// status is changed, the change is validated and recorded in the order
+// timeline and its estimated arrival date is recomputed. When the shipment is
+// delivered, the earnings of the store in the order are released from escrow.
 func (m *Manager) UpdateOrderShipment(
 	orderId int,
+	storeId int,
+	actorId int,
 	p types.UpdateOrderShipmentPayload,
 ) error {
 	clauses := []string{}
 	args := []any{}
 	argsPos := 1
 
-	if p.ArrivalDate != nil {
-		clauses = append(clauses, fmt.Sprintf("arrival_date = $%d", argsPos))
-		args = append(args, *p.ArrivalDate)
-		argsPos++
-	}
-
 	if p.Status != nil {
 		clauses = append(clauses, fmt.Sprintf("status = $%d", argsPos))
 		args = append(args, *p.Status)
 		argsPos++
 	}
 
+	if p.TrackingNumber != nil {
+		clauses = append(clauses, fmt.Sprintf("tracking_number = $%d", argsPos))
+		args = append(args, *p.TrackingNumber)
+		argsPos++
+	}
+
 	if len(clauses) == 0 {
 		return fmt.Errorf("No fields received to update")
 	}
 
-	args = append(args, orderId)
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var currentStatus types.OrderShipmentStatus
+	var createdAt time.Time
+	var state string
+	var city string
+	err = tx.QueryRow(`
+		SELECT os.status, os.created_at, a.state, a.city FROM order_shipments os
+		JOIN addresses a ON a.id = os.receiver_address_id
+		WHERE os.order_id = $1 AND os.store_id = $2
+		FOR UPDATE OF os;
+	`, orderId, storeId).
+		Scan(&currentStatus, &createdAt, &state, &city)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return types.ErrOrderShipmentNotFound
+		}
+		return err
+	}
+
+	if p.Status != nil && *p.Status != currentStatus {
+		if !currentStatus.CanTransitionTo(*p.Status) {
+			tx.Rollback()
+			return types.ErrInvalidOrderShipmentTransition
+		}
+
+		oldStatus := currentStatus.String()
+		err = insertOrderStatusEventAsDBTx(tx, types.OrderStatusEventInsertData{
+			Kind:      types.OrderStatusEventKindShipment,
+			OldStatus: &oldStatus,
+			NewStatus: p.Status.String(),
+			Reason:    p.Reason,
+			OrderId:   orderId,
+			StoreId:   &storeId,
+			ActorId:   &actorId,
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		var arrivalDate *time.Time
+
+		switch *p.Status {
+		case types.OrderShipmentStatusToBeDetermined:
+			{
+				date, err := estimateArrivalDateAsDBTx(tx, storeId, state, city, createdAt, true)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+				arrivalDate = &date
+			}
+
+		case types.OrderShipmentStatusOnTheWay:
+			{
+				date, err := estimateArrivalDateAsDBTx(tx, storeId, state, city, time.Now(), false)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+				arrivalDate = &date
+			}
+
+		case types.OrderShipmentStatusDelivered:
+			{
+				now := time.Now()
+				arrivalDate = &now
+			}
+		}
+
+		if arrivalDate != nil {
+			clauses = append(clauses, fmt.Sprintf("arrival_date = $%d", argsPos))
+			args = append(args, *arrivalDate)
+			argsPos++
+		}
+	}
+
+	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
+	args = append(args, time.Now())
+	argsPos++
+
+	args = append(args, orderId, storeId)
 	q := fmt.Sprintf(
-		"UPDATE order_shipments SET %s WHERE order_id = $%d",
+		"UPDATE order_shipments SET %s WHERE order_id = $%d AND store_id = $%d",
 		strings.Join(clauses, ", "),
 		argsPos,
+		argsPos+1,
 	)
 
-	_, err := m.db.Exec(q, args...)
+	_, err = tx.Exec(q, args...)
 	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// the earnings of the store are released once the customer has received
+	// the shipment, and given back to the customer if it is cancelled
+	if p.Status != nil && *p.Status != currentStatus &&
+		(*p.Status == types.OrderShipmentStatusDelivered ||
+			*p.Status == types.OrderShipmentStatusCancelled) {
+		holdId, _, err := lockHeldEscrowHoldAsDBTx(tx, orderId, storeId)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if holdId != -1 {
+			if *p.Status == types.OrderShipmentStatusDelivered {
+				err = releaseEscrowHoldAsDBTx(tx, holdId)
+			} else {
+				err = refundEscrowHoldAsDBTx(tx, holdId)
+			}
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
 		return err
 	}
 
 	return nil
 }
 
+// UpdateOrderPayment updates the payment of an order. When its status is
+// changed, the change is validated and recorded in the order timeline, and
+// if the payment succeeds it is posted to the ledger and the invoice of the
+// order is issued.
 func (m *Manager) UpdateOrderPayment(
 	orderId int,
+	actorId int,
+	p types.UpdateOrderPaymentPayload,
+) error {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = m.updateOrderPaymentAsDBTx(tx, orderId, &actorId, p)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+func (m *Manager) DeleteOrder(id int) error {
+	_, err := m.db.Exec(
+		"DELETE FROM orders WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Manager) getOrdersShipments(orderIds []int) (map[int][]types.OrderShipment, error) {
+	shipments := make(map[int][]types.OrderShipment, len(orderIds))
+	for _, id := range orderIds {
+		shipments[id] = []types.OrderShipment{}
+	}
+
+	if len(orderIds) == 0 {
+		return shipments, nil
+	}
+
+	rows, err := m.db.Query(
+		"SELECT * FROM order_shipments WHERE order_id = ANY($1) ORDER BY id;",
+		pq.Array(orderIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		shipment, err := scanOrderShipmentRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		shipments[shipment.OrderId] = append(shipments[shipment.OrderId], *shipment)
+	}
+
+	return shipments, nil
+}
+
+func (m *Manager) getOrdersTaxLines(orderIds []int) (map[int][]types.OrderTaxLine, error) {
+	taxLines := make(map[int][]types.OrderTaxLine, len(orderIds))
+	for _, id := range orderIds {
+		taxLines[id] = []types.OrderTaxLine{}
+	}
+
+	if len(orderIds) == 0 {
+		return taxLines, nil
+	}
+
+	rows, err := m.db.Query(`
+		SELECT
+			id, order_id, variant_id, store_id,
+			variant_price * quantity - discount AS taxable_amount,
+			tax_rate, tax_pricing_mode, tax
+		FROM order_product_variants
+		WHERE order_id = ANY($1) AND tax_pricing_mode IS NOT NULL
+		ORDER BY id;
+	`, pq.Array(orderIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		taxLine, err := scanOrderTaxLineRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		taxLines[taxLine.OrderId] = append(taxLines[taxLine.OrderId], *taxLine)
+	}
+
+	return taxLines, nil
+}
+
+func createOrderAsDBTx(tx *sql.Tx, p types.CreateOrderPayload) (int, error) {
+	if len(p.ProductVariants) == 0 {
+		return -1, types.ErrProductVariantsAreEmpty
+	}
+
+	state, city, err := getReceiverAddressAsDBTx(tx, p.ReceiverAddressId, p.UserId)
+	if err != nil {
+		return -1, err
+	}
+
+	rowId := -1
+	err = tx.QueryRow("INSERT INTO orders (user_id) VALUES ($1) RETURNING id;",
+		p.UserId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		return -1, err
+	}
+
+	totalShipmentPrice := types.Money{}
+	totalVariantsPrice := types.Money{}
+	orderFee := types.Money{}
+
+	variantIds := make([]int, len(p.ProductVariants))
+	for i, pv := range p.ProductVariants {
+		variantIds[i] = pv.VariantId
+	}
+
+	// lock the variants before reading their available quantity so the
+	// concurrent orders of the same variants wait for each other's holds
+	_, err = tx.Exec(
+		"SELECT id FROM product_variants WHERE id = ANY($1) ORDER BY id FOR UPDATE;",
+		pq.Array(variantIds),
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	lines, err := getShippingLinesAsDBTx(tx, p.ProductVariants)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, l := range lines {
+		if l.Pricing.Quantity < l.Quantity {
+			return -1, types.ErrProductQuantityIsNotEnough(l.Pricing.ProductId)
+		}
+	}
+
+	quote, err := computeShippingAsDBTx(tx, state, city, lines)
+	if err != nil {
+		return -1, err
+	}
+
+	insertData := make([]types.OrderProductVariantInsertData, 0, len(lines))
+
+	for _, l := range lines {
+		totalShipmentPrice = totalShipmentPrice.Add(l.ShippingPrice)
+		totalVariantsPrice = totalVariantsPrice.Add(l.Pricing.FinalPrice.Mul(l.Quantity))
+
+		insertData = append(insertData, types.OrderProductVariantInsertData{
+			Quantity:      l.Quantity,
+			VariantPrice:  l.Pricing.FinalPrice,
+			ShippingPrice: l.ShippingPrice,
+			VariantId:     l.Pricing.VariantId,
+			OrderId:       rowId,
+			StoreId:       l.Pricing.StoreId,
+		})
+	}
+
+	orderDiscount := types.Money{}
+	var couponId sql.NullInt32
+
+	if p.CouponCode != nil {
+		coupon, err := getUsableCouponByCodeAsDBTx(tx, *p.CouponCode, p.UserId)
+		if err != nil {
+			return -1, err
+		}
+
+		orderDiscount, err = applyCouponAsDBTx(tx, coupon, insertData)
+		if err != nil {
+			return -1, err
+		}
+
+		couponId = sql.NullInt32{Int32: int32(coupon.Id), Valid: true}
+	}
+
+	totalTax, exclusiveTax, err := applyTaxAsDBTx(tx, state, city, insertData)
+	if err != nil {
+		return -1, err
+	}
+
+	orderFee, err = applyCommissionAsDBTx(tx, insertData)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, d := range insertData {
+		_, err = tx.Exec(
+			"INSERT INTO order_product_variants (quantity, variant_price, shipping_price, discount, tax, tax_rate, tax_pricing_mode, commission, commission_rate, variant_id, order_id, store_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+			d.Quantity,
+			d.VariantPrice,
+			d.ShippingPrice,
+			d.Discount,
+			d.Tax,
+			d.TaxRate,
+			d.TaxPricingMode,
+			d.Commission,
+			d.CommissionRate,
+			d.VariantId,
+			d.OrderId,
+			d.StoreId,
+		)
+		if err != nil {
+			return -1, err
+		}
+
+		_, err = tx.Exec(
+			"INSERT INTO inventory_reservations (quantity, expires_at, order_id, variant_id) VALUES ($1, CURRENT_TIMESTAMP + $2 * INTERVAL '1 minute', $3, $4)",
+			d.Quantity,
+			config.Env.InventoryHoldTTLInMin,
+			d.OrderId,
+			d.VariantId,
+		)
+		if err != nil {
+			return -1, err
+		}
+
+	}
+
+	for _, storeQuote := range quote.Stores {
+		_, err = tx.Exec(
+			"INSERT INTO order_shipments (arrival_date, order_id, receiver_address_id, store_id) VALUES ($1, $2, $3, $4)",
+			storeQuote.EstimatedArrivalDate,
+			rowId,
+			p.ReceiverAddressId,
+			storeQuote.StoreId,
+		)
+		if err != nil {
+			return -1, err
+		}
+
+		err = insertOrderStatusEventAsDBTx(tx, types.OrderStatusEventInsertData{
+			Kind:      types.OrderStatusEventKindShipment,
+			NewStatus: types.OrderShipmentStatusToBeDetermined.String(),
+			OrderId:   rowId,
+			StoreId:   &storeQuote.StoreId,
+			ActorId:   &p.UserId,
+		})
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO order_payments (total_variants_price, total_shipment_price, fee, discount, coupon_id, total_tax, exclusive_tax, order_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		totalVariantsPrice,
+		totalShipmentPrice,
+		orderFee,
+		orderDiscount,
+		couponId,
+		totalTax,
+		exclusiveTax,
+		rowId,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	err = insertOrderStatusEventAsDBTx(tx, types.OrderStatusEventInsertData{
+		Kind:      types.OrderStatusEventKindPayment,
+		NewStatus: types.OrderPaymentStatusPending.String(),
+		OrderId:   rowId,
+		ActorId:   &p.UserId,
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+// productVariantPricingQuery selects the current offer-aware price and the
+// quantity that is not held by pending orders of the variants whose ids are
+// passed as the first argument.
+const productVariantPricingQuery = `
+	SELECT
+		p.id, pv.id, sop.store_id,
+		pv.quantity - COALESCE((
+			SELECT SUM(ir.quantity) FROM inventory_reservations ir
+			WHERE ir.variant_id = pv.id AND ir.expires_at > CURRENT_TIMESTAMP
+		), 0) AS available_quantity,
+		p.shipment_factor,
+		COALESCE(
+			p.price * (1 - (
+				SELECT discount FROM product_offers po
+				WHERE po.product_id = p.id AND po.expire_at > NOW()
+				LIMIT 1
+			)), p.price
+		) AS final_price
+	FROM product_variants pv
+	JOIN products p ON p.id = pv.product_id
+	JOIN store_owned_products sop ON sop.product_id = p.id
+	WHERE pv.id = ANY($1)
+`
+
+// updateOrderPaymentAsDBTx updates the payment of an order, the actor is
+// nil when the change is not made by a user, such as a confirmation of a
+// payment provider.
+func (m *Manager) updateOrderPaymentAsDBTx(
+	tx *sql.Tx,
+	orderId int,
+	actorId *int,
 	p types.UpdateOrderPaymentPayload,
 ) error {
 	clauses := []string{}
@@ -497,6 +987,29 @@ func (m *Manager) UpdateOrderPayment(
 		return fmt.Errorf("No fields received to update")
 	}
 
+	var oldStatus types.OrderPaymentStatus
+	err := tx.QueryRow(
+		"SELECT status FROM order_payments WHERE order_id = $1 FOR UPDATE;",
+		orderId,
+	).
+		Scan(&oldStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.ErrOrderNotFound
+		}
+		return err
+	}
+
+	if p.Status != nil && *p.Status != oldStatus && !oldStatus.CanTransitionTo(*p.Status) {
+		return types.ErrInvalidOrderPaymentTransition
+	}
+
+	now := time.Now()
+
+	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
+	args = append(args, now)
+	argsPos++
+
 	args = append(args, orderId)
 	q := fmt.Sprintf(
 		"UPDATE order_payments SET %s WHERE order_id = $%d",
@@ -504,24 +1017,59 @@ func (m *Manager) UpdateOrderPayment(
 		argsPos,
 	)
 
-	_, err := m.db.Exec(q, args...)
+	_, err = tx.Exec(q, args...)
 	if err != nil {
 		return err
 	}
 
+	if p.Status != nil && *p.Status != oldStatus {
+		oldStatusText := oldStatus.String()
+		err = insertOrderStatusEventAsDBTx(tx, types.OrderStatusEventInsertData{
+			Kind:      types.OrderStatusEventKindPayment,
+			OldStatus: &oldStatusText,
+			NewStatus: p.Status.String(),
+			Reason:    p.Reason,
+			OrderId:   orderId,
+			ActorId:   actorId,
+		})
+		if err != nil {
+			return err
+		}
+
+		if *p.Status == types.OrderPaymentStatusSuccessful {
+			err = postOrderPaymentAsDBTx(tx, orderId)
+			if err != nil {
+				return err
+			}
+
+			_, err = createOrderInvoiceAsDBTx(tx, orderId, now)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
-func (m *Manager) DeleteOrder(id int) error {
-	_, err := m.db.Exec(
-		"DELETE FROM orders WHERE id = $1;",
-		id,
+func scanProductVariantPricingRow(rows *sql.Rows) (*types.ProductVariantPricing, error) {
+	n := new(types.ProductVariantPricing)
+	n.ProductId = -1
+	n.VariantId = -1
+
+	err := rows.Scan(
+		&n.ProductId,
+		&n.VariantId,
+		&n.StoreId,
+		&n.Quantity,
+		&n.ShipmentFactor,
+		&n.FinalPrice,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return n, nil
 }
 
 func scanOrderRow(rows *sql.Rows) (*types.Order, error) {
@@ -537,6 +1085,8 @@ func scanOrderRow(rows *sql.Rows) (*types.Order, error) {
 		&n.TotalVariantsPrice,
 		&n.TotalShipmentPrice,
 		&n.Fee,
+		&n.Discount,
+		&n.TotalTax,
 		&n.TotalProducts,
 	)
 	if err != nil {
@@ -562,6 +1112,43 @@ func scanOrderWithFullInfoRow(rows *sql.Rows) (*types.OrderWithFullInfo, error)
 		&n.Payment.CreatedAt,
 		&n.Payment.UpdatedAt,
 		&n.Payment.OrderId,
+		&n.Payment.Discount,
+		&n.Payment.CouponId,
+		&n.Payment.TotalTax,
+		&n.Payment.ExclusiveTax,
+		&n.ShipmentStatus,
+		&n.ReceiverAddress.Id,
+		&n.ReceiverAddress.State,
+		&n.ReceiverAddress.City,
+		&n.ReceiverAddress.Street,
+		&n.ReceiverAddress.Zipcode,
+		&n.ReceiverAddress.Details,
+		&n.ReceiverAddress.IsPublic,
+		&n.ReceiverAddress.CreatedAt,
+		&n.ReceiverAddress.UpdatedAt,
+		&n.ReceiverAddress.UserId,
+		new(sql.NullInt32),
+		&n.TotalProducts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Shipments = []types.OrderShipment{}
+	n.TaxLines = []types.OrderTaxLine{}
+
+	return n, nil
+}
+
+func scanStoreOrderRow(rows *sql.Rows) (*types.StoreOrder, error) {
+	n := new(types.StoreOrder)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.UserId,
+		&n.PaymentStatus,
 		&n.Shipment.Id,
 		&n.Shipment.ArrivalDate,
 		&n.Shipment.Status,
@@ -569,6 +1156,8 @@ func scanOrderWithFullInfoRow(rows *sql.Rows) (*types.OrderWithFullInfo, error)
 		&n.Shipment.UpdatedAt,
 		&n.Shipment.OrderId,
 		&n.Shipment.ReceiverAddressId,
+		&n.Shipment.StoreId,
+		&n.Shipment.TrackingNumber,
 		&n.Shipment.ReceiverAddress.Id,
 		&n.Shipment.ReceiverAddress.State,
 		&n.Shipment.ReceiverAddress.City,
@@ -580,6 +1169,10 @@ func scanOrderWithFullInfoRow(rows *sql.Rows) (*types.OrderWithFullInfo, error)
 		&n.Shipment.ReceiverAddress.UpdatedAt,
 		&n.Shipment.ReceiverAddress.UserId,
 		new(sql.NullInt32),
+		&n.TotalVariantsPrice,
+		&n.TotalShipmentPrice,
+		&n.TotalDiscount,
+		&n.TotalTax,
 		&n.TotalProducts,
 	)
 	if err != nil {
@@ -589,6 +1182,27 @@ func scanOrderWithFullInfoRow(rows *sql.Rows) (*types.OrderWithFullInfo, error)
 	return n, nil
 }
 
+func scanOrderShipmentRow(rows *sql.Rows) (*types.OrderShipment, error) {
+	n := new(types.OrderShipment)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.ArrivalDate,
+		&n.Status,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.OrderId,
+		&n.ReceiverAddressId,
+		&n.StoreId,
+		&n.TrackingNumber,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
 func scanOrderProductVariantRow(rows *sql.Rows) (*types.OrderProductVariant, error) {
 	n := new(types.OrderProductVariant)
 
@@ -599,6 +1213,13 @@ func scanOrderProductVariantRow(rows *sql.Rows) (*types.OrderProductVariant, err
 		&n.ShippingPrice,
 		&n.OrderId,
 		&n.VariantId,
+		&n.StoreId,
+		&n.Discount,
+		&n.Tax,
+		&n.TaxRate,
+		&n.TaxPricingMode,
+		&n.Commission,
+		&n.CommissionRate,
 	)
 	if err != nil {
 		return nil, err
@@ -607,9 +1228,33 @@ func scanOrderProductVariantRow(rows *sql.Rows) (*types.OrderProductVariant, err
 	return n, nil
 }
 
+func scanOrderTaxLineRow(rows *sql.Rows) (*types.OrderTaxLine, error) {
+	n := new(types.OrderTaxLine)
+
+	err := rows.Scan(
+		&n.OrderProductVariantId,
+		&n.OrderId,
+		&n.VariantId,
+		&n.StoreId,
+		&n.TaxableAmount,
+		&n.Rate,
+		&n.PricingMode,
+		&n.Tax,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// buildOrderSearchQuery builds the order search on top of base. If sorted is
+// set, the newest orders come first and the orders start after the cursor of
+// the query.
 func buildOrderSearchQuery(
 	query types.OrderSearchQuery,
 	base string,
+	sorted bool,
 ) (string, []any) {
 	clauses := []string{}
 	args := []any{}
@@ -628,7 +1273,7 @@ func buildOrderSearchQuery(
 	}
 
 	if query.ShipmentStatus != nil {
-		clauses = append(clauses, fmt.Sprintf("os.status = $%d", argsPos))
+		clauses = append(clauses, fmt.Sprintf("derive_order_shipment_status(o.id) = $%d", argsPos))
 		args = append(args, *query.ShipmentStatus)
 		argsPos++
 	}
@@ -636,10 +1281,8 @@ func buildOrderSearchQuery(
 	if query.StoreId != nil {
 		clauses = append(clauses, fmt.Sprintf(`
       EXISTS (
-				SELECT 1 FROM order_product_variants opv
-				JOIN product_variants pv ON pv.id = opv.variant_id
-				JOIN store_owned_products sop ON sop.product_id = pv.product_id
-				WHERE sop.store_id = $%d
+				SELECT 1 FROM order_shipments os
+				WHERE os.order_id = o.id AND os.store_id = $%d
 			)
     `, argsPos))
 		args = append(args, *query.StoreId)
@@ -658,11 +1301,25 @@ func buildOrderSearchQuery(
 		argsPos++
 	}
 
+	if sorted && query.Cursor != nil {
+		clauses = append(clauses, fmt.Sprintf(
+			"(o.created_at, o.id) < ($%d::timestamp, $%d)",
+			argsPos,
+			argsPos+1,
+		))
+		args = append(args, query.Cursor.Key, query.Cursor.Id)
+		argsPos += 2
+	}
+
 	q := base
 	if len(clauses) > 0 {
 		q += " WHERE " + strings.Join(clauses, " AND ")
 	}
 
+	if sorted {
+		q += " ORDER BY o.created_at DESC, o.id DESC"
+	}
+
 	if query.Offset != nil {
 		q += fmt.Sprintf(" OFFSET $%d", argsPos)
 		args = append(args, *query.Offset)
@@ -0,0 +1,639 @@
+package db_manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+func (m *Manager) CreateOrderReturn(userId int, p types.CreateOrderReturnPayload) (int, error) {
+	if len(p.Items) == 0 {
+		return -1, types.ErrOrderReturnItemsAreEmpty
+	}
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	var customerId int = -1
+	var paymentStatus types.OrderPaymentStatus
+	err = tx.QueryRow(`
+		SELECT o.user_id, op.status FROM orders o
+		JOIN order_payments op ON op.order_id = o.id
+		WHERE o.id = $1
+		FOR UPDATE OF op;
+	`, p.OrderId).Scan(&customerId, &paymentStatus)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return -1, types.ErrOrderNotFound
+		}
+		return -1, err
+	}
+
+	if customerId != userId {
+		tx.Rollback()
+		return -1, types.ErrCannotAccessOrder
+	}
+
+	if paymentStatus != types.OrderPaymentStatusSuccessful {
+		tx.Rollback()
+		return -1, types.ErrOrderPaymentIsNotSuccessful
+	}
+
+	itemIds := make([]int, len(p.Items))
+	for i, item := range p.Items {
+		itemIds[i] = item.OrderProductVariantId
+	}
+
+	rows, err := tx.Query(`
+		SELECT
+			opv.id, opv.quantity, opv.variant_price, opv.discount, opv.store_id,
+			CASE WHEN opv.tax_pricing_mode = 'exclusive' THEN opv.tax ELSE 0 END AS exclusive_tax,
+			(
+				SELECT COALESCE(SUM(ri.quantity), 0)
+				FROM order_return_items ri
+				JOIN order_returns r ON r.id = ri.return_id
+				WHERE ri.order_product_variant_id = opv.id AND r.status <> 'rejected'
+			) AS returned_quantity
+		FROM order_product_variants opv
+		WHERE opv.order_id = $1 AND opv.id = ANY($2);
+	`, p.OrderId, pq.Array(itemIds))
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+	defer rows.Close()
+
+	type orderLine struct {
+		quantity         int
+		variantPrice     types.Money
+		discount         types.Money
+		storeId          int
+		exclusiveTax     types.Money
+		returnedQuantity int
+	}
+
+	lines := make(map[int]orderLine, len(p.Items))
+	for rows.Next() {
+		var id int
+		var line orderLine
+		err := rows.Scan(
+			&id,
+			&line.quantity,
+			&line.variantPrice,
+			&line.discount,
+			&line.storeId,
+			&line.exclusiveTax,
+			&line.returnedQuantity,
+		)
+		if err != nil {
+			tx.Rollback()
+			return -1, err
+		}
+
+		lines[id] = line
+	}
+	rows.Close()
+
+	storeId := -1
+	totalRefund := types.Money{}
+	refundAmounts := make([]types.Money, len(p.Items))
+
+	for i, item := range p.Items {
+		line, ok := lines[item.OrderProductVariantId]
+		if !ok {
+			tx.Rollback()
+			return -1, types.ErrOrderReturnItemNotInOrder
+		}
+
+		if storeId == -1 {
+			storeId = line.storeId
+		} else if storeId != line.storeId {
+			tx.Rollback()
+			return -1, types.ErrOrderReturnItemsFromManyStores
+		}
+
+		if item.Quantity < 1 || line.returnedQuantity+item.Quantity > line.quantity {
+			tx.Rollback()
+			return -1, types.ErrOrderReturnQuantityExceeded(item.OrderProductVariantId)
+		}
+
+		// the coupon discount of the line is refunded back proportionally and
+		// the tax paid on top of the price is refunded with it
+		refundAmounts[i] = line.variantPrice.Mul(item.Quantity).Add(
+			line.exclusiveTax.Sub(line.discount).
+				MulRate(float64(item.Quantity) / float64(line.quantity)),
+		)
+		totalRefund = totalRefund.Add(refundAmounts[i])
+	}
+
+	rowId := -1
+	err = tx.QueryRow(
+		"INSERT INTO order_returns (reason, total_refund, order_id, store_id) VALUES ($1, $2, $3, $4) RETURNING id;",
+		p.Reason,
+		totalRefund,
+		p.OrderId,
+		storeId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	for i, item := range p.Items {
+		_, err := tx.Exec(
+			"INSERT INTO order_return_items (quantity, refund_amount, return_id, order_product_variant_id) VALUES ($1, $2, $3, $4);",
+			item.Quantity,
+			refundAmounts[i],
+			rowId,
+			item.OrderProductVariantId,
+		)
+		if err != nil {
+			tx.Rollback()
+			return -1, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) GetOrderReturns(
+	query types.OrderReturnSearchQuery,
+) ([]types.OrderReturn, error) {
+	var base string
+	base = `
+		SELECT r.* FROM order_returns r
+		JOIN orders o ON o.id = r.order_id
+	`
+
+	q, args := buildOrderReturnSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := []types.OrderReturn{}
+
+	for rows.Next() {
+		r, err := scanOrderReturnRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		returns = append(returns, *r)
+	}
+
+	return returns, nil
+}
+
+func (m *Manager) GetOrderReturnsCount(
+	query types.OrderReturnSearchQuery,
+) (int, error) {
+	var base string
+	base = `
+		SELECT COUNT(*) as count FROM order_returns r
+		JOIN orders o ON o.id = r.order_id
+	`
+
+	q, args := buildOrderReturnSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		err := rows.Scan(&count)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return count, nil
+}
+
+func (m *Manager) GetOrderReturnById(id int) (*types.OrderReturn, error) {
+	rows, err := m.db.Query("SELECT * FROM order_returns WHERE id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	r := new(types.OrderReturn)
+	r.Id = -1
+
+	for rows.Next() {
+		r, err = scanOrderReturnRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Id == -1 {
+		return nil, types.ErrOrderReturnNotFound
+	}
+
+	return r, nil
+}
+
+func (m *Manager) GetOrderReturnItems(returnId int) ([]types.OrderReturnItem, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM order_return_items WHERE return_id = $1 ORDER BY id;",
+		returnId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []types.OrderReturnItem{}
+
+	for rows.Next() {
+		item, err := scanOrderReturnItemRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, *item)
+	}
+
+	return items, nil
+}
+
+func (m *Manager) GetOrderReturnWithItemsById(id int) (*types.OrderReturnWithItems, error) {
+	r, err := m.GetOrderReturnById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := m.GetOrderReturnItems(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.OrderReturnWithItems{
+		OrderReturn: *r,
+		Items:       items,
+	}, nil
+}
+
+func (m *Manager) ReviewOrderReturn(id int, p types.ReviewOrderReturnPayload) error {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	status, err := lockOrderReturnStatusAsDBTx(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if status != types.OrderReturnStatusPending ||
+		(p.Status != types.OrderReturnStatusApproved && p.Status != types.OrderReturnStatusRejected) {
+		tx.Rollback()
+		return types.ErrInvalidOrderReturnStatusTransition(status, p.Status)
+	}
+
+	clauses := []string{"status = $1", "updated_at = $2"}
+	args := []any{p.Status, time.Now()}
+	argsPos := 3
+
+	if p.StoreNote != nil {
+		clauses = append(clauses, fmt.Sprintf("store_note = $%d", argsPos))
+		args = append(args, *p.StoreNote)
+		argsPos++
+	}
+
+	args = append(args, id)
+	q := fmt.Sprintf(
+		"UPDATE order_returns SET %s WHERE id = $%d",
+		strings.Join(clauses, ", "),
+		argsPos,
+	)
+
+	_, err = tx.Exec(q, args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+// RefundOrderReturn confirms the receipt of the returned items of an approved
+// return, charges the store owner and refunds the customer wallet. The refund
+// is taken from the earnings of the store in the order that are still held in
+// escrow first, and only the rest is taken from the store owner wallet.
+func (m *Manager) RefundOrderReturn(id int, p types.ReceiveOrderReturnPayload) error {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	status, err := lockOrderReturnStatusAsDBTx(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if status != types.OrderReturnStatusApproved {
+		tx.Rollback()
+		return types.ErrInvalidOrderReturnStatusTransition(
+			status,
+			types.OrderReturnStatusRefunded,
+		)
+	}
+
+	var totalRefund types.Money
+	var customerWalletId int = -1
+	var orderId int = -1
+	var storeId int = -1
+	err = tx.QueryRow(`
+		SELECT r.total_refund, w.id, r.order_id, r.store_id FROM order_returns r
+		JOIN orders o ON o.id = r.order_id
+		JOIN wallets w ON w.user_id = o.user_id
+		WHERE r.id = $1;
+	`, id).Scan(&totalRefund, &customerWalletId, &orderId, &storeId)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return types.ErrWalletNotFound
+		}
+		return err
+	}
+
+	var storeWalletId int = -1
+	err = tx.QueryRow(`
+		SELECT w.id FROM order_returns r
+		JOIN stores s ON s.id = r.store_id
+		JOIN wallets w ON w.user_id = s.owner_id
+		WHERE r.id = $1;
+	`, id).Scan(&storeWalletId)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return types.ErrWalletNotFound
+		}
+		return err
+	}
+
+	holdId, heldAmount, err := lockHeldEscrowHoldAsDBTx(tx, orderId, storeId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	fromEscrow := types.MinMoney(heldAmount, totalRefund)
+	fromStore := totalRefund.Sub(fromEscrow)
+
+	storeAccountId, storeWalletBalance, err := lockWalletLedgerAccountAsDBTx(tx, storeWalletId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if storeWalletBalance.LessThan(fromStore) {
+		tx.Rollback()
+		return types.ErrBalanceInsufficient
+	}
+
+	escrowAccountId, err := getLedgerAccountIdByKindAsDBTx(tx, types.LedgerAccountKindEscrow)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	customerAccountId, _, err := lockWalletLedgerAccountAsDBTx(tx, customerWalletId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// the store owner wallet is only charged for the part of the refund that
+	// the held earnings do not cover
+	var chargeTxId *int
+	if fromStore.IsPositive() {
+		txId, err := createSuccessfulWalletTransactionAsDBTx(tx, types.CreateWalletTransactionPayload{
+			Amount:   fromStore,
+			TxType:   types.TransactionTypeRefundCharge,
+			WalletId: storeWalletId,
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		chargeTxId = &txId
+	}
+
+	refundTxId, err := createSuccessfulWalletTransactionAsDBTx(tx, types.CreateWalletTransactionPayload{
+		Amount:   totalRefund,
+		TxType:   types.TransactionTypeRefund,
+		WalletId: customerWalletId,
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = postJournalEntryAsDBTx(tx, types.JournalEntryInsertData{
+		Kind:          types.JournalEntryKindOrderReturnRefund,
+		Description:   fmt.Sprintf("Refund of order return #%d", id),
+		OrderReturnId: &id,
+		Postings: []types.LedgerPostingInsertData{
+			{AccountId: escrowAccountId, Amount: fromEscrow.Neg()},
+			{AccountId: storeAccountId, Amount: fromStore.Neg()},
+			{AccountId: customerAccountId, Amount: totalRefund},
+		},
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if holdId != -1 && fromEscrow.IsPositive() {
+		_, err = tx.Exec(
+			"UPDATE escrow_holds SET refunded_amount = refunded_amount + $1, updated_at = $2 WHERE id = $3;",
+			fromEscrow,
+			time.Now(),
+			holdId,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if p.Restock {
+		_, err = tx.Exec(`
+			UPDATE product_variants pv
+			SET quantity = pv.quantity + ri.quantity
+			FROM order_return_items ri
+			JOIN order_product_variants opv ON opv.id = ri.order_product_variant_id
+			WHERE ri.return_id = $1 AND pv.id = opv.variant_id;
+		`, id)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE order_returns
+		SET status = $1, restocked = $2, refund_tx_id = $3, charge_tx_id = $4, updated_at = $5
+		WHERE id = $6;
+	`,
+		types.OrderReturnStatusRefunded,
+		p.Restock,
+		refundTxId,
+		chargeTxId,
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+func lockOrderReturnStatusAsDBTx(tx *sql.Tx, id int) (types.OrderReturnStatus, error) {
+	var status types.OrderReturnStatus
+	err := tx.QueryRow("SELECT status FROM order_returns WHERE id = $1 FOR UPDATE;", id).
+		Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", types.ErrOrderReturnNotFound
+		}
+		return "", err
+	}
+
+	return status, nil
+}
+
+func scanOrderReturnRow(rows *sql.Rows) (*types.OrderReturn, error) {
+	n := new(types.OrderReturn)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Reason,
+		&n.Status,
+		&n.StoreNote,
+		&n.TotalRefund,
+		&n.Restocked,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.OrderId,
+		&n.StoreId,
+		&n.RefundTxId,
+		&n.ChargeTxId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func scanOrderReturnItemRow(rows *sql.Rows) (*types.OrderReturnItem, error) {
+	n := new(types.OrderReturnItem)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Quantity,
+		&n.RefundAmount,
+		&n.ReturnId,
+		&n.OrderProductVariantId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func buildOrderReturnSearchQuery(
+	query types.OrderReturnSearchQuery,
+	base string,
+) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if query.UserId != nil {
+		clauses = append(clauses, fmt.Sprintf("o.user_id = $%d", argsPos))
+		args = append(args, *query.UserId)
+		argsPos++
+	}
+
+	if query.StoreId != nil {
+		clauses = append(clauses, fmt.Sprintf("r.store_id = $%d", argsPos))
+		args = append(args, *query.StoreId)
+		argsPos++
+	}
+
+	if query.OrderId != nil {
+		clauses = append(clauses, fmt.Sprintf("r.order_id = $%d", argsPos))
+		args = append(args, *query.OrderId)
+		argsPos++
+	}
+
+	if query.Status != nil {
+		clauses = append(clauses, fmt.Sprintf("r.status = $%d", argsPos))
+		args = append(args, *query.Status)
+		argsPos++
+	}
+
+	q := base
+	if len(clauses) > 0 {
+		q += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	q += ";"
+	return q, args
+}
@@ -0,0 +1,77 @@
+package db_manager
+
+import (
+	"database/sql"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+// GetOrderStatusEvents returns the status history of an order in the order
+// of the changes. If storeId is set, the shipment events of the other stores
+// of the order are left out.
+func (m *Manager) GetOrderStatusEvents(
+	orderId int,
+	storeId *int,
+) ([]types.OrderStatusEvent, error) {
+	rows, err := m.db.Query(`
+		SELECT * FROM order_status_events
+		WHERE order_id = $1 AND ($2::INTEGER IS NULL OR kind = $3 OR store_id = $2)
+		ORDER BY created_at, id;
+	`, orderId, storeId, types.OrderStatusEventKindPayment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []types.OrderStatusEvent{}
+
+	for rows.Next() {
+		event, err := scanOrderStatusEventRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, *event)
+	}
+
+	return events, nil
+}
+
+func insertOrderStatusEventAsDBTx(tx *sql.Tx, d types.OrderStatusEventInsertData) error {
+	_, err := tx.Exec(
+		"INSERT INTO order_status_events (kind, old_status, new_status, reason, order_id, store_id, actor_id) VALUES ($1, $2, $3, $4, $5, $6, $7);",
+		d.Kind,
+		d.OldStatus,
+		d.NewStatus,
+		d.Reason,
+		d.OrderId,
+		d.StoreId,
+		d.ActorId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanOrderStatusEventRow(rows *sql.Rows) (*types.OrderStatusEvent, error) {
+	n := new(types.OrderStatusEvent)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Kind,
+		&n.OldStatus,
+		&n.NewStatus,
+		&n.Reason,
+		&n.CreatedAt,
+		&n.OrderId,
+		&n.StoreId,
+		&n.ActorId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,358 @@
+package db_manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+// CreatePaymentIntent saves a payment started at a payment provider with
+// the pending deposit transaction that receives its money. If the payment
+// pays an order, the payment of the order must be pending.
+func (m *Manager) CreatePaymentIntent(p types.CreatePaymentIntentPayload) (int, error) {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	if p.OrderId != nil {
+		var paymentStatus types.OrderPaymentStatus
+		err = tx.QueryRow(
+			"SELECT status FROM order_payments WHERE order_id = $1 FOR UPDATE;",
+			*p.OrderId,
+		).
+			Scan(&paymentStatus)
+		if err != nil {
+			tx.Rollback()
+			if err == sql.ErrNoRows {
+				return -1, types.ErrOrderNotFound
+			}
+			return -1, err
+		}
+
+		if paymentStatus != types.OrderPaymentStatusPending {
+			tx.Rollback()
+			return -1, types.ErrOrderPaymentIsNotPending
+		}
+	}
+
+	walletTxId := -1
+	err = tx.QueryRow(
+		"INSERT INTO wallet_transactions (amount, tx_type, wallet_id) VALUES ($1, $2, $3) RETURNING id;",
+		p.Amount,
+		types.TransactionTypeDeposit,
+		p.WalletId,
+	).
+		Scan(&walletTxId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	rowId := -1
+	err = tx.QueryRow(
+		`INSERT INTO payment_intents
+		(provider, reference, amount, wallet_transaction_id, order_id)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id;`,
+		p.Provider,
+		p.Reference,
+		p.Amount,
+		walletTxId,
+		p.OrderId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) GetPaymentIntentById(id int) (*types.PaymentIntent, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM payment_intents WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	intent := new(types.PaymentIntent)
+	intent.Id = -1
+
+	for rows.Next() {
+		intent, err = scanPaymentIntentRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if intent.Id == -1 {
+		return nil, types.ErrPaymentIntentNotFound
+	}
+
+	return intent, nil
+}
+
+func (m *Manager) GetPaymentIntentByReference(
+	provider string,
+	reference string,
+) (*types.PaymentIntent, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM payment_intents WHERE provider = $1 AND reference = $2;",
+		provider,
+		reference,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	intent := new(types.PaymentIntent)
+	intent.Id = -1
+
+	for rows.Next() {
+		intent, err = scanPaymentIntentRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if intent.Id == -1 {
+		return nil, types.ErrPaymentIntentNotFound
+	}
+
+	return intent, nil
+}
+
+// ConfirmPaymentIntent applies a payment that is collected by its payment
+// provider. The deposit of the payment succeeds and if the payment pays an
+// order, the payment of the order succeeds with the deposited money, all in
+// the same tx. If the deposit or the order payment is not pending anymore,
+// ErrPaymentIntentCannotBeApplied is returned and nothing is changed, so the
+// payment can be refunded.
+func (m *Manager) ConfirmPaymentIntent(id int) error {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	intent, err := lockPendingPaymentIntentAsDBTx(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	walletId := -1
+	var walletTxStatus types.TransactionStatus
+	err = tx.QueryRow(
+		"SELECT wallet_id, status FROM wallet_transactions WHERE id = $1 FOR UPDATE;",
+		intent.WalletTransactionId,
+	).
+		Scan(&walletId, &walletTxStatus)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return types.ErrWalletTransactionNotFound
+		}
+		return err
+	}
+
+	if walletTxStatus != types.TransactionStatusPending {
+		tx.Rollback()
+		return types.ErrPaymentIntentCannotBeApplied
+	}
+
+	if intent.OrderId.Valid {
+		var paymentStatus types.OrderPaymentStatus
+		err = tx.QueryRow(
+			"SELECT status FROM order_payments WHERE order_id = $1 FOR UPDATE;",
+			intent.OrderId.Int32,
+		).
+			Scan(&paymentStatus)
+		if err != nil && err != sql.ErrNoRows {
+			tx.Rollback()
+			return err
+		}
+
+		if err == sql.ErrNoRows || paymentStatus != types.OrderPaymentStatusPending {
+			tx.Rollback()
+			return types.ErrPaymentIntentCannotBeApplied
+		}
+	}
+
+	err = updateWalletTransactionAsDBTx(
+		tx,
+		walletId,
+		intent.WalletTransactionId,
+		types.UpdateWalletTransactionPayload{
+			Status: utils.Ptr(types.TransactionStatusSuccessful),
+		},
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if intent.OrderId.Valid {
+		err = m.updateOrderPaymentAsDBTx(
+			tx,
+			int(intent.OrderId.Int32),
+			nil,
+			types.UpdateOrderPaymentPayload{
+				Status: utils.Ptr(types.OrderPaymentStatusSuccessful),
+				Reason: utils.Ptr(fmt.Sprintf("paid through %s", intent.Provider)),
+			},
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = updatePaymentIntentStatusAsDBTx(tx, id, types.PaymentIntentStatusSucceeded)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+// FailPaymentIntent settles a payment that is not applied, either because it
+// failed at its payment provider or because it was refunded. Its deposit
+// fails with it and the order that it was going to pay stays pending.
+func (m *Manager) FailPaymentIntent(id int, status types.PaymentIntentStatus) error {
+	if status != types.PaymentIntentStatusFailed && status != types.PaymentIntentStatusRefunded {
+		return types.ErrInvalidPaymentIntentStatusEnum
+	}
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	intent, err := lockPendingPaymentIntentAsDBTx(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE wallet_transactions SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4;`,
+		types.TransactionStatusFailed,
+		time.Now(),
+		intent.WalletTransactionId,
+		types.TransactionStatusPending,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = updatePaymentIntentStatusAsDBTx(tx, id, status)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+// lockPendingPaymentIntentAsDBTx locks a payment intent until the end of the
+// tx, it returns ErrPaymentIntentNotPending if the intent is already settled.
+func lockPendingPaymentIntentAsDBTx(tx *sql.Tx, id int) (*types.PaymentIntent, error) {
+	rows, err := tx.Query(
+		"SELECT * FROM payment_intents WHERE id = $1 FOR UPDATE;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	intent := new(types.PaymentIntent)
+	intent.Id = -1
+
+	for rows.Next() {
+		intent, err = scanPaymentIntentRow(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+	}
+	rows.Close()
+
+	if intent.Id == -1 {
+		return nil, types.ErrPaymentIntentNotFound
+	}
+
+	if intent.Status != types.PaymentIntentStatusPending {
+		return nil, types.ErrPaymentIntentNotPending
+	}
+
+	return intent, nil
+}
+
+func updatePaymentIntentStatusAsDBTx(
+	tx *sql.Tx,
+	id int,
+	status types.PaymentIntentStatus,
+) error {
+	_, err := tx.Exec(
+		"UPDATE payment_intents SET status = $1, updated_at = $2 WHERE id = $3;",
+		status,
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanPaymentIntentRow(rows *sql.Rows) (*types.PaymentIntent, error) {
+	n := new(types.PaymentIntent)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Provider,
+		&n.Reference,
+		&n.Amount,
+		&n.Status,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.WalletTransactionId,
+		&n.OrderId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
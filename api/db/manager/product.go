@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/lib/pq"
 
@@ -14,6 +15,122 @@ import (
 	"github.com/SaeedAlian/econest/api/utils"
 )
 
+// productBaseColumns selects the columns of the products that are scanned by
+// scanProductBaseRow, which leaves out their search documents.
+const productBaseColumns = `
+	p.id, p.name, p.slug, p.price, p.shipment_factor, p.description, p.is_active,
+	p.created_at, p.updated_at, p.subcategory_id
+`
+
+// productSnippetColumn selects the part of the name and the description of
+// each product that matches the search keyword, with the matched words
+// wrapped in mark tags. It can only be selected when the keyword is set.
+const productSnippetColumn = `
+	ts_headline(
+		'english',
+		p.name || ': ' || p.description,
+		kq,
+		'StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=8, MaxFragments=2, FragmentDelimiter=" ... "'
+	)
+`
+
+// productStatsColumns selects the total quantity of the variants and the
+// average score of the comments of each product.
+const productStatsColumns = `
+	(
+		SELECT COALESCE(SUM(pv.quantity), 0) FROM product_variants pv
+		WHERE pv.product_id = p.id
+	),
+	(
+		SELECT COALESCE(AVG(pc.scoring), 0) FROM product_comments pc
+		WHERE pc.product_id = p.id
+	)
+`
+
+// productVariantWithAttributeSetQuery selects the variants with their selected
+// attribute options, which is a row for each option of a variant or a single
+// row with null options for a variant without any.
+const productVariantWithAttributeSetQuery = `
+	SELECT
+		pv.id, pv.quantity, pv.product_id,
+		pa.id, pa.label, pao.id, pao.value, pao.attribute_id
+	FROM product_variants pv
+	LEFT JOIN product_variant_attribute_options pvao ON pvao.variant_id = pv.id
+	LEFT JOIN product_attributes pa ON pa.id = pvao.attribute_id
+	LEFT JOIN product_attribute_options pao ON pao.id = pvao.option_id
+`
+
+// productSortKey is the expression that the products are ordered by for a
+// sort, with the type that its text form is cast back to when it is compared
+// with a cursor. The ties are broken by the ids of the products, in the same
+// direction as the key.
+type productSortKey struct {
+	expr string
+	cast string
+	desc bool
+}
+
+// productCurrentPriceExpr selects the price of a product after its active
+// offer.
+const productCurrentPriceExpr = `
+	COALESCE(
+		p.price * (1 - (
+			SELECT discount FROM product_offers po
+			WHERE po.product_id = p.id AND po.expire_at > NOW()
+		)),
+		p.price
+	)
+`
+
+var productSortKeys = map[types.ProductSort]productSortKey{
+	types.ProductSortRelevance: {
+		expr: "ts_rank(p.search_document, kq)",
+		cast: "real",
+		desc: true,
+	},
+	types.ProductSortPriceAsc: {
+		expr: productCurrentPriceExpr,
+		cast: "float8",
+		desc: false,
+	},
+	types.ProductSortPriceDesc: {
+		expr: productCurrentPriceExpr,
+		cast: "float8",
+		desc: true,
+	},
+	types.ProductSortNewest: {
+		expr: "p.created_at",
+		cast: "timestamp",
+		desc: true,
+	},
+	types.ProductSortTopRated: {
+		expr: `(
+			SELECT COALESCE(AVG(pc.scoring), 0) FROM product_comments pc
+			WHERE pc.product_id = p.id
+		)`,
+		cast: "numeric",
+		desc: true,
+	},
+	types.ProductSortBestSelling: {
+		expr: fmt.Sprintf(`(
+			SELECT COALESCE(SUM(opv.quantity), 0) FROM order_product_variants opv
+			JOIN product_variants pv ON pv.id = opv.variant_id
+			JOIN order_payments op ON op.order_id = opv.order_id
+			WHERE pv.product_id = p.id AND op.status = '%s'
+		)`, types.OrderPaymentStatusSuccessful),
+		cast: "bigint",
+		desc: true,
+	},
+	types.ProductSortBiggestDiscount: {
+		expr: `COALESCE((
+			SELECT discount FROM product_offers po
+			WHERE po.product_id = p.id AND po.expire_at > NOW()
+		), 0)`,
+		cast: "float8",
+		desc: true,
+	},
+}
+
 func (m *Manager) CreateProduct(p types.CreateProductPayload) (int, error) {
 	ctx := context.Background()
 	tx, err := m.db.BeginTx(ctx, nil)
@@ -375,9 +492,13 @@ func (m *Manager) CreateProductComment(p types.CreateProductCommentPayload) (int
 
 func (m *Manager) GetProductsBase(query types.ProductSearchQuery) ([]types.ProductBase, error) {
 	var base string
-	base = "SELECT p.* FROM products p"
+	base = "SELECT " + productBaseColumns + " FROM products p"
+
+	if query.Cursor != nil && query.Cursor.Sort != query.ResolvedSort().String() {
+		return nil, types.ErrInvalidCursor
+	}
 
-	q, args := buildProductSearchQuery(query, base)
+	q, args := buildProductSearchQuery(query, base, true)
 
 	rows, err := m.db.Query(q, args...)
 	if err != nil {
@@ -402,10 +523,42 @@ func (m *Manager) GetProductsBase(query types.ProductSearchQuery) ([]types.Produ
 func (m *Manager) GetProducts(
 	query types.ProductSearchQuery,
 ) ([]types.Product, error) {
+	page, err := m.GetProductsPage(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Items, nil
+}
+
+// GetProductsPage returns the products of the query in the order of its
+// sort, with the cursor of the next page if the page is full. The quantities
+// and the scores are selected with the products and the other relations are
+// loaded for the whole page at once, so the number of queries does not grow
+// with the size of the page.
+func (m *Manager) GetProductsPage(
+	query types.ProductSearchQuery,
+) (*types.Page[types.Product], error) {
+	sort := query.ResolvedSort()
+	if query.Cursor != nil && query.Cursor.Sort != sort.String() {
+		return nil, types.ErrInvalidCursor
+	}
+
+	snippetColumn := "NULL"
+	if query.Keyword != nil {
+		snippetColumn = productSnippetColumn
+	}
+
 	var base string
-	base = "SELECT p.* FROM products p"
+	base = fmt.Sprintf(
+		"SELECT %s, %s, %s, (%s)::text FROM products p",
+		productBaseColumns,
+		productStatsColumns,
+		snippetColumn,
+		productSortKeys[sort].expr,
+	)
 
-	q, args := buildProductSearchQuery(query, base)
+	q, args := buildProductSearchQuery(query, base, true)
 
 	rows, err := m.db.Query(q, args...)
 	if err != nil {
@@ -414,114 +567,44 @@ func (m *Manager) GetProducts(
 	defer rows.Close()
 
 	products := []types.Product{}
+	var nextCursor *types.Cursor
 
 	for rows.Next() {
-		productBase, err := scanProductBaseRow(rows)
+		product, sortKey, err := scanProductSearchRow(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		var totalQuantity int
-		err = m.db.QueryRow(
-			"SELECT COALESCE(SUM(quantity), 0) FROM product_variants WHERE product_id = $1;",
-			productBase.Id,
-		).Scan(&totalQuantity)
-		if err != nil {
-			return nil, err
+		nextCursor = &types.Cursor{
+			Sort: sort.String(),
+			Key:  sortKey,
+			Id:   product.Id,
 		}
 
-		var averageScore float32
-		err = m.db.QueryRow(
-			"SELECT COALESCE(AVG(scoring), 0) FROM product_comments WHERE product_id = $1;",
-			productBase.Id,
-		).Scan(&averageScore)
-		if err != nil {
-			return nil, err
-		}
-
-		var offer *types.ProductOffer
-		offerRows, err := m.db.Query(
-			"SELECT * FROM product_offers WHERE product_id = $1;",
-			productBase.Id,
-		)
-		if err != nil {
-			return nil, err
-		}
-		defer offerRows.Close()
-		if offerRows.Next() {
-			offer, err = scanProductOfferRow(offerRows)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		var mainImage *types.ProductImage
-		imageRows, err := m.db.Query(
-			"SELECT * FROM product_images WHERE product_id = $1 AND is_main = true;",
-			productBase.Id,
-		)
-		if err != nil {
-			return nil, err
-		}
-		defer imageRows.Close()
-		if imageRows.Next() {
-			mainImage, err = scanProductImageRow(imageRows)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		var storeInfo *types.StoreInfo
-		storeInfoRows, err := m.db.Query(`
-      SELECT s.id, s.name, s.description FROM stores s WHERE s.id IN (
-        SELECT sop.store_id FROM store_owned_products sop WHERE sop.product_id = $1
-      )
-    `, productBase.Id)
-		if err != nil {
-			return nil, err
-		}
-		defer storeInfoRows.Close()
-		if storeInfoRows.Next() {
-			storeInfo, err = scanStoreInfoRow(storeInfoRows)
-			if err != nil {
-				return nil, err
-			}
-		}
+		products = append(products, *product)
+	}
+	rows.Close()
 
-		var subcategory *types.ProductCategory
-		subcategoryRows, err := m.db.Query(
-			`SELECT * FROM product_categories WHERE id = $1`, productBase.SubcategoryId,
-		)
-		if err != nil {
-			return nil, err
-		}
-		defer subcategoryRows.Close()
-		if subcategoryRows.Next() {
-			subcategory, err = scanProductCategoryRow(subcategoryRows)
-			if err != nil {
-				return nil, err
-			}
-		}
+	err = m.loadProductsRelations(products)
+	if err != nil {
+		return nil, err
+	}
 
-		products = append(products, types.Product{
-			ProductBase:   *productBase,
-			Subcategory:   *subcategory,
-			AverageScore:  utils.RoundToNDecimals32(averageScore, 2),
-			TotalQuantity: totalQuantity,
-			Offer:         offer,
-			MainImage:     mainImage,
-			Store:         *storeInfo,
-		})
+	if query.Limit == nil || len(products) < *query.Limit {
+		nextCursor = nil
 	}
 
-	return products, nil
+	return &types.Page[types.Product]{
+		Items:      products,
+		NextCursor: nextCursor,
+	}, nil
 }
 
 func (m *Manager) GetProductsCount(query types.ProductSearchQuery) (int, error) {
 	var base string
 	base = "SELECT COUNT(*) as count FROM products p"
 
-	q, args := buildProductSearchQuery(query, base)
+	q, args := buildProductSearchQuery(query, base, false)
 
 	rows, err := m.db.Query(q, args...)
 	if err != nil {
@@ -944,85 +1027,10 @@ func (m *Manager) GetProductVariants(productId int) ([]types.ProductVariant, err
 func (m *Manager) GetProductVariantsWithAttributeSet(
 	productId int,
 ) ([]types.ProductVariantWithAttributeSet, error) {
-	variantRows, err := m.db.Query(
-		"SELECT * FROM product_variants WHERE product_id = $1;",
+	return m.getProductVariantsWithAttributeSet(
+		productVariantWithAttributeSetQuery+" WHERE pv.product_id = $1 ORDER BY pv.id, pa.id;",
 		productId,
 	)
-	if err != nil {
-		return nil, err
-	}
-	defer variantRows.Close()
-
-	variants := []types.ProductVariantWithAttributeSet{}
-
-	for variantRows.Next() {
-		variant, err := scanProductVariantRow(variantRows)
-		if err != nil {
-			return nil, err
-		}
-
-		attrOptionRows, err := m.db.Query(
-			"SELECT * FROM product_variant_attribute_options WHERE variant_id = $1;",
-			variant.Id,
-		)
-		if err != nil {
-			return nil, err
-		}
-		defer attrOptionRows.Close()
-
-		attrOptions := []types.ProductVariantSelectedAttributeOption{}
-
-		for attrOptionRows.Next() {
-			attrOpt, err := scanProductVariantAttributeOptionRow(attrOptionRows)
-			if err != nil {
-				return nil, err
-			}
-
-			var attr *types.ProductAttribute
-			attrRows, err := m.db.Query(
-				"SELECT * FROM product_attributes WHERE id = $1;",
-				attrOpt.AttributeId,
-			)
-			if err != nil {
-				return nil, err
-			}
-			defer attrRows.Close()
-			if attrRows.Next() {
-				attr, err = scanProductAttributeRow(attrRows)
-				if err != nil {
-					return nil, err
-				}
-			}
-
-			var opt *types.ProductAttributeOption
-			optRows, err := m.db.Query(
-				"SELECT * FROM product_attribute_options WHERE id = $1;",
-				attrOpt.OptionId,
-			)
-			if err != nil {
-				return nil, err
-			}
-			defer optRows.Close()
-			if optRows.Next() {
-				opt, err = scanProductAttributeOptionRow(optRows)
-				if err != nil {
-					return nil, err
-				}
-			}
-
-			attrOptions = append(attrOptions, types.ProductVariantSelectedAttributeOption{
-				ProductAttribute: *attr,
-				SelectedOption:   *opt,
-			})
-		}
-
-		variants = append(variants, types.ProductVariantWithAttributeSet{
-			ProductVariant: *variant,
-			AttributeSet:   attrOptions,
-		})
-	}
-
-	return variants, nil
 }
 
 func (m *Manager) GetProductCommentsByProductId(
@@ -1172,7 +1180,7 @@ func (m *Manager) GetProductCommentsCountByUserId(
 
 func (m *Manager) GetProductBaseById(id int) (*types.ProductBase, error) {
 	rows, err := m.db.Query(
-		"SELECT * FROM products WHERE id = $1;",
+		"SELECT "+productBaseColumns+" FROM products p WHERE p.id = $1;",
 		id,
 	)
 	if err != nil {
@@ -1198,120 +1206,42 @@ func (m *Manager) GetProductBaseById(id int) (*types.ProductBase, error) {
 }
 
 func (m *Manager) GetProductById(id int) (*types.Product, error) {
-	productRows, err := m.db.Query(
-		"SELECT * FROM products WHERE id = $1;",
+	// the product is scanned as a search row without a snippet or a sort key
+	rows, err := m.db.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, NULL, '' FROM products p WHERE p.id = $1;",
+			productBaseColumns,
+			productStatsColumns,
+		),
 		id,
 	)
 	if err != nil {
 		return nil, err
 	}
-	defer productRows.Close()
+	defer rows.Close()
 
-	if !productRows.Next() {
+	if !rows.Next() {
 		return nil, types.ErrProductNotFound
 	}
 
-	productBase, err := scanProductBaseRow(productRows)
-	if err != nil {
-		return nil, err
-	}
-
-	var totalQuantity int
-	err = m.db.QueryRow(
-		"SELECT COALESCE(SUM(quantity), 0) FROM product_variants WHERE product_id = $1;",
-		productBase.Id,
-	).Scan(&totalQuantity)
+	product, _, err := scanProductSearchRow(rows)
 	if err != nil {
 		return nil, err
 	}
+	rows.Close()
 
-	var averageScore float32
-	err = m.db.QueryRow(
-		"SELECT COALESCE(AVG(scoring), 0) FROM product_comments WHERE product_id = $1;",
-		productBase.Id,
-	).Scan(&averageScore)
+	products := []types.Product{*product}
+	err = m.loadProductsRelations(products)
 	if err != nil {
 		return nil, err
 	}
 
-	var offer *types.ProductOffer
-	offerRows, err := m.db.Query(
-		"SELECT * FROM product_offers WHERE product_id = $1;",
-		productBase.Id,
-	)
-	defer offerRows.Close()
-	if err != nil {
-		return nil, err
-	}
-	if offerRows.Next() {
-		offer, err = scanProductOfferRow(offerRows)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	var mainImage *types.ProductImage
-	imageRows, err := m.db.Query(
-		"SELECT * FROM product_images WHERE product_id = $1 AND is_main = true;",
-		productBase.Id,
-	)
-	defer imageRows.Close()
-	if err != nil {
-		return nil, err
-	}
-	if imageRows.Next() {
-		mainImage, err = scanProductImageRow(imageRows)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	var storeInfo *types.StoreInfo
-	storeInfoRows, err := m.db.Query(`
-    SELECT s.id, s.name, s.description FROM stores s WHERE s.id IN (
-      SELECT sop.store_id FROM store_owned_products sop WHERE sop.product_id = $1
-    )
-  `, productBase.Id)
-	defer storeInfoRows.Close()
-	if err != nil {
-		return nil, err
-	}
-	if storeInfoRows.Next() {
-		storeInfo, err = scanStoreInfoRow(storeInfoRows)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	var subcategory *types.ProductCategory
-	subcategoryRows, err := m.db.Query(
-		`SELECT * FROM product_categories WHERE id = $1`, productBase.SubcategoryId,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer subcategoryRows.Close()
-	if subcategoryRows.Next() {
-		subcategory, err = scanProductCategoryRow(subcategoryRows)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return &types.Product{
-		ProductBase:   *productBase,
-		Subcategory:   *subcategory,
-		AverageScore:  utils.RoundToNDecimals32(averageScore, 2),
-		TotalQuantity: totalQuantity,
-		Offer:         offer,
-		MainImage:     mainImage,
-		Store:         *storeInfo,
-	}, nil
+	return &products[0], nil
 }
 
 func (m *Manager) GetProductExtendedById(id int) (*types.ProductExtended, error) {
 	productRows, err := m.db.Query(
-		"SELECT * FROM products WHERE id = $1;",
+		"SELECT "+productBaseColumns+" FROM products p WHERE p.id = $1;",
 		id,
 	)
 	if err != nil {
@@ -1327,77 +1257,17 @@ func (m *Manager) GetProductExtendedById(id int) (*types.ProductExtended, error)
 	if err != nil {
 		return nil, err
 	}
+	productRows.Close()
 
-	var totalQuantity int
-	err = m.db.QueryRow(
-		"SELECT COALESCE(SUM(quantity), 0) FROM product_variants WHERE product_id = $1;",
-		id,
-	).Scan(&totalQuantity)
+	subcategory, err := m.getProductCategoryWithParents(productBase.SubcategoryId)
 	if err != nil {
 		return nil, err
 	}
 
-	var subcategory types.ProductCategoryWithParents
-	subcategoryRows, err := m.db.Query(
-		"SELECT * FROM product_categories WHERE id = $1;",
-		productBase.SubcategoryId,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer subcategoryRows.Close()
-
-	if !subcategoryRows.Next() {
+	if subcategory == nil {
 		return nil, types.ErrSubcategoryNotFound
 	}
 
-	cat, err := scanProductCategoryRow(subcategoryRows)
-	if err != nil {
-		return nil, err
-	}
-
-	subcategory.Id = cat.Id
-	subcategory.Name = cat.Name
-	subcategory.CreatedAt = cat.CreatedAt
-	subcategory.UpdatedAt = cat.UpdatedAt
-
-	currentParentId := cat.ParentCategoryId
-	var currentParent *types.ProductCategoryWithParents = nil
-
-	for currentParentId.Int32 != 0 {
-		parentRows, err := m.db.Query(
-			"SELECT * FROM product_categories WHERE id = $1;",
-			currentParentId.Int32,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		if !parentRows.Next() {
-			parentRows.Close()
-			break
-		}
-
-		parentCat, err := scanProductCategoryRow(parentRows)
-		parentRows.Close()
-		if err != nil {
-			return nil, err
-		}
-
-		newParent := &types.ProductCategoryWithParents{
-			ProductCategory: *parentCat,
-		}
-
-		if currentParent == nil {
-			subcategory.ParentCategory = newParent
-		} else {
-			currentParent.ParentCategory = newParent
-		}
-
-		currentParent = newParent
-		currentParentId = parentCat.ParentCategoryId
-	}
-
 	specRows, err := m.db.Query(
 		"SELECT * FROM product_specs WHERE product_id = $1;",
 		id,
@@ -1435,112 +1305,36 @@ func (m *Manager) GetProductExtendedById(id int) (*types.ProductExtended, error)
 		tags = append(tags, *tag)
 	}
 
-	variantRows, err := m.db.Query(
-		"SELECT * FROM product_variants WHERE product_id = $1;",
-		id,
-	)
+	variants, err := m.GetProductVariantsWithAttributeSet(id)
 	if err != nil {
 		return nil, err
 	}
-	defer variantRows.Close()
 
-	variants := make([]types.ProductVariantWithAttributeSet, 0)
-	attributesMap := make(map[int]types.ProductAttributeWithOptions, 0)
+	// the attributes are listed in the order that they are first used by the
+	// variants, with the options that are selected by each of them
 	attributes := make([]types.ProductAttributeWithOptions, 0)
-
-	for variantRows.Next() {
-		variant, err := scanProductVariantRow(variantRows)
-		if err != nil {
-			return nil, err
-		}
-
-		attrOptionRows, err := m.db.Query(
-			"SELECT * FROM product_variant_attribute_options WHERE variant_id = $1;",
-			variant.Id,
-		)
-		if err != nil {
-			return nil, err
-		}
-		defer attrOptionRows.Close()
-
-		attrOptions := []types.ProductVariantSelectedAttributeOption{}
-
-		for attrOptionRows.Next() {
-			attrOpt, err := scanProductVariantAttributeOptionRow(attrOptionRows)
-			if err != nil {
-				return nil, err
-			}
-
-			var attr *types.ProductAttribute
-			attrRows, err := m.db.Query(
-				"SELECT * FROM product_attributes WHERE id = $1;",
-				attrOpt.AttributeId,
-			)
-			if err != nil {
-				return nil, err
-			}
-			defer attrRows.Close()
-			if attrRows.Next() {
-				attr, err = scanProductAttributeRow(attrRows)
-				if err != nil {
-					return nil, err
-				}
-			}
-
-			var opt *types.ProductAttributeOption
-			optRows, err := m.db.Query(
-				"SELECT * FROM product_attribute_options WHERE id = $1;",
-				attrOpt.OptionId,
-			)
-			if err != nil {
-				return nil, err
-			}
-			defer optRows.Close()
-			if optRows.Next() {
-				opt, err = scanProductAttributeOptionRow(optRows)
-				if err != nil {
-					return nil, err
-				}
+	attributeIndexes := make(map[int]int)
+
+	for _, v := range variants {
+		for _, attrOption := range v.AttributeSet {
+			i, ok := attributeIndexes[attrOption.ProductAttribute.Id]
+			if !ok {
+				attributes = append(attributes, types.ProductAttributeWithOptions{
+					ProductAttribute: attrOption.ProductAttribute,
+					Options:          []types.ProductAttributeOption{},
+				})
+				i = len(attributes) - 1
+				attributeIndexes[attrOption.ProductAttribute.Id] = i
 			}
 
-			if val, ok := attributesMap[attr.Id]; ok {
-				val.Options = append(val.Options, *opt)
-				attributesMap[attr.Id] = val
-			} else {
-				attributesMap[attr.Id] = types.ProductAttributeWithOptions{
-					ProductAttribute: *attr,
-					Options:          []types.ProductAttributeOption{*opt},
-				}
-			}
-
-			attrOptions = append(attrOptions, types.ProductVariantSelectedAttributeOption{
-				ProductAttribute: *attr,
-				SelectedOption:   *opt,
-			})
+			attributes[i].Options = append(attributes[i].Options, attrOption.SelectedOption)
 		}
-
-		variants = append(variants, types.ProductVariantWithAttributeSet{
-			ProductVariant: *variant,
-			AttributeSet:   attrOptions,
-		})
 	}
 
-	var offer *types.ProductOffer
-	offerRows, err := m.db.Query(
-		"SELECT * FROM product_offers WHERE product_id = $1;",
-		id,
-	)
+	offers, err := m.getProductsOffers([]int{id})
 	if err != nil {
 		return nil, err
 	}
-	if offerRows.Next() {
-		offer, err = scanProductOfferRow(offerRows)
-		if err != nil {
-			offerRows.Close()
-			return nil, err
-		}
-	}
-	offerRows.Close()
 
 	imageRows, err := m.db.Query(
 		"SELECT * FROM product_images WHERE product_id = $1;",
@@ -1560,38 +1354,21 @@ func (m *Manager) GetProductExtendedById(id int) (*types.ProductExtended, error)
 		images = append(images, *img)
 	}
 
-	var storeInfo *types.StoreInfo
-	storeInfoRows, err := m.db.Query(`
-      SELECT s.id, s.name, s.description FROM stores s WHERE s.id IN (
-        SELECT sop.store_id FROM store_owned_products sop WHERE sop.product_id = $1
-      )
-    `, productBase.Id)
+	stores, err := m.getProductsStores([]int{id})
 	if err != nil {
 		return nil, err
 	}
-	if storeInfoRows.Next() {
-		storeInfo, err = scanStoreInfoRow(storeInfoRows)
-		if err != nil {
-			storeInfoRows.Close()
-			return nil, err
-		}
-	}
-	storeInfoRows.Close()
-
-	for _, v := range attributesMap {
-		attributes = append(attributes, v)
-	}
 
 	return &types.ProductExtended{
 		ProductBase: *productBase,
-		Subcategory: subcategory,
+		Subcategory: *subcategory,
 		Specs:       specs,
 		Tags:        tags,
 		Variants:    variants,
 		Attributes:  attributes,
-		Offer:       offer,
+		Offer:       offers[id],
 		Images:      images,
-		Store:       *storeInfo,
+		Store:       stores[id],
 	}, nil
 }
 
@@ -1625,66 +1402,16 @@ func (m *Manager) GetProductCategoryById(id int) (*types.ProductCategory, error)
 func (m *Manager) GetProductCategoryWithParentsById(
 	id int,
 ) (*types.ProductCategoryWithParents, error) {
-	rows, err := m.db.Query(
-		"SELECT * FROM product_categories WHERE id = $1;",
-		id,
-	)
+	category, err := m.getProductCategoryWithParents(id)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	if !rows.Next() {
+	if category == nil {
 		return nil, types.ErrProductCategoryNotFound
 	}
 
-	cat, err := scanProductCategoryRow(rows)
-	if err != nil {
-		return nil, err
-	}
-
-	result := &types.ProductCategoryWithParents{
-		ProductCategory: *cat,
-	}
-
-	currentParentId := cat.ParentCategoryId
-	var currentParent *types.ProductCategoryWithParents = nil
-
-	for currentParentId.Int32 != 0 {
-		parentRows, err := m.db.Query(
-			"SELECT * FROM product_categories WHERE id = $1;",
-			currentParentId.Int32,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		if !parentRows.Next() {
-			parentRows.Close()
-			break
-		}
-
-		parentCat, err := scanProductCategoryRow(parentRows)
-		parentRows.Close()
-		if err != nil {
-			return nil, err
-		}
-
-		newParent := &types.ProductCategoryWithParents{
-			ProductCategory: *parentCat,
-		}
-
-		if currentParent == nil {
-			result.ParentCategory = newParent
-		} else {
-			currentParent.ParentCategory = newParent
-		}
-
-		currentParent = newParent
-		currentParentId = parentCat.ParentCategoryId
-	}
-
-	return result, nil
+	return category, nil
 }
 
 func (m *Manager) GetProductTagById(id int) (*types.ProductTag, error) {
@@ -1930,102 +1657,39 @@ func (m *Manager) GetProductVariantById(id int) (*types.ProductVariant, error) {
 func (m *Manager) GetProductVariantWithAttributeSetById(
 	id int,
 ) (*types.ProductVariantWithAttributeSet, error) {
-	rows, err := m.db.Query(
-		"SELECT * FROM product_variants WHERE id = $1;",
+	variants, err := m.getProductVariantsWithAttributeSet(
+		productVariantWithAttributeSetQuery+" WHERE pv.id = $1 ORDER BY pa.id;",
 		id,
 	)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	variant := new(types.ProductVariantWithAttributeSet)
-	variant.Id = -1
-
-	if rows.Next() {
-		v, err := scanProductVariantRow(rows)
-		if err != nil {
-			return nil, err
-		}
-
-		variant.ProductVariant = *v
-	}
-
-	if variant.Id == -1 {
-		return nil, types.ErrProductVariantNotFound
-	}
-
-	attrOptionRows, err := m.db.Query(
-		"SELECT * FROM product_variant_attribute_options WHERE variant_id = $1;",
-		variant.Id,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer attrOptionRows.Close()
-
-	attrOptions := []types.ProductVariantSelectedAttributeOption{}
-
-	for attrOptionRows.Next() {
-		attrOpt, err := scanProductVariantAttributeOptionRow(attrOptionRows)
-		if err != nil {
-			return nil, err
-		}
-
-		var attr *types.ProductAttribute
-		attrRows, err := m.db.Query(
-			"SELECT * FROM product_attributes WHERE id = $1;",
-			attrOpt.AttributeId,
-		)
-		if err != nil {
-			return nil, err
-		}
-		defer attrRows.Close()
-		if attrRows.Next() {
-			attr, err = scanProductAttributeRow(attrRows)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		var opt *types.ProductAttributeOption
-		optRows, err := m.db.Query(
-			"SELECT * FROM product_attribute_options WHERE id = $1;",
-			attrOpt.OptionId,
-		)
-		if err != nil {
-			return nil, err
-		}
-		defer optRows.Close()
-		if optRows.Next() {
-			opt, err = scanProductAttributeOptionRow(optRows)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		attrOptions = append(attrOptions, types.ProductVariantSelectedAttributeOption{
-			ProductAttribute: *attr,
-			SelectedOption:   *opt,
-		})
+		return nil, err
 	}
 
-	variant.AttributeSet = attrOptions
+	if len(variants) == 0 {
+		return nil, types.ErrProductVariantNotFound
+	}
 
-	return variant, nil
+	return &variants[0], nil
 }
 
-func (m *Manager) GetProductInventory(id int) (total int, inStock bool, err error) {
-	err = m.db.QueryRow(
-		"SELECT COALESCE(SUM(quantity), 0) FROM product_variants WHERE product_id = $1;",
-		id,
-	).Scan(&total)
+// GetProductInventory returns the quantity of the product in stock and the
+// part of it that is held by the active reservations of pending orders.
+func (m *Manager) GetProductInventory(id int) (total int, reserved int, err error) {
+	err = m.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(pv.quantity), 0),
+			COALESCE((
+				SELECT SUM(ir.quantity) FROM inventory_reservations ir
+				JOIN product_variants rpv ON rpv.id = ir.variant_id
+				WHERE rpv.product_id = $1 AND ir.expires_at > CURRENT_TIMESTAMP
+			), 0)
+		FROM product_variants pv WHERE pv.product_id = $1;
+	`, id).Scan(&total, &reserved)
 	if err != nil {
-		return 0, false, err
+		return 0, 0, err
 	}
 
-	inStock = total > 0
-	return total, inStock, nil
+	return total, reserved, nil
 }
 
 func (m *Manager) GetProductCommentById(id int) (*types.ProductComment, error) {
@@ -2925,6 +2589,291 @@ func (m *Manager) DeleteProductComment(id int) error {
 	return nil
 }
 
+func (m *Manager) getProductCategoriesByIds(ids []int) (map[int]types.ProductCategory, error) {
+	categories := make(map[int]types.ProductCategory, len(ids))
+
+	if len(ids) == 0 {
+		return categories, nil
+	}
+
+	rows, err := m.db.Query(
+		"SELECT * FROM product_categories WHERE id = ANY($1);",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		cat, err := scanProductCategoryRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		categories[cat.Id] = *cat
+	}
+
+	return categories, nil
+}
+
+// getProductCategoryWithParents returns the category with the chain of its
+// parents, which is selected with one recursive query. It returns nil if the
+// category does not exist.
+func (m *Manager) getProductCategoryWithParents(
+	id int,
+) (*types.ProductCategoryWithParents, error) {
+	rows, err := m.db.Query(`
+		WITH RECURSIVE chain AS (
+			SELECT pc.*, 0 AS depth FROM product_categories pc WHERE pc.id = $1
+			UNION ALL
+			SELECT pc.*, chain.depth + 1 FROM product_categories pc
+			JOIN chain ON pc.id = chain.parent_category_id
+		)
+		SELECT id, name, image_name, created_at, updated_at, parent_category_id
+		FROM chain ORDER BY depth;
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result *types.ProductCategoryWithParents = nil
+	var current *types.ProductCategoryWithParents = nil
+
+	for rows.Next() {
+		cat, err := scanProductCategoryRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		next := &types.ProductCategoryWithParents{
+			ProductCategory: *cat,
+		}
+
+		if current == nil {
+			result = next
+		} else {
+			current.ParentCategory = next
+		}
+
+		current = next
+	}
+
+	return result, nil
+}
+
+// getProductsByIds returns the products with the given ids by their ids,
+// the missing products are left out.
+func (m *Manager) getProductsByIds(ids []int) (map[int]types.Product, error) {
+	products := make(map[int]types.Product, len(ids))
+
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	// the products are scanned as search rows without a snippet or a sort key
+	rows, err := m.db.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, NULL, '' FROM products p WHERE p.id = ANY($1);",
+			productBaseColumns,
+			productStatsColumns,
+		),
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []types.Product{}
+	for rows.Next() {
+		product, _, err := scanProductSearchRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, *product)
+	}
+	rows.Close()
+
+	err = m.loadProductsRelations(list)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range list {
+		products[p.Id] = p
+	}
+
+	return products, nil
+}
+
+// loadProductsRelations sets the subcategories, offers, main images and
+// stores of the products with a query for each relation.
+func (m *Manager) loadProductsRelations(products []types.Product) error {
+	productIds := make([]int, len(products))
+	subcategoryIds := make([]int, len(products))
+	for i, p := range products {
+		productIds[i] = p.Id
+		subcategoryIds[i] = p.SubcategoryId
+	}
+
+	subcategories, err := m.getProductCategoriesByIds(subcategoryIds)
+	if err != nil {
+		return err
+	}
+
+	offers, err := m.getProductsOffers(productIds)
+	if err != nil {
+		return err
+	}
+
+	mainImages, err := m.getProductsMainImages(productIds)
+	if err != nil {
+		return err
+	}
+
+	stores, err := m.getProductsStores(productIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		products[i].Subcategory = subcategories[products[i].SubcategoryId]
+		products[i].Offer = offers[products[i].Id]
+		products[i].MainImage = mainImages[products[i].Id]
+		products[i].Store = stores[products[i].Id]
+	}
+
+	return nil
+}
+
+func (m *Manager) getProductsOffers(productIds []int) (map[int]*types.ProductOffer, error) {
+	offers := make(map[int]*types.ProductOffer, len(productIds))
+
+	if len(productIds) == 0 {
+		return offers, nil
+	}
+
+	rows, err := m.db.Query(
+		"SELECT * FROM product_offers WHERE product_id = ANY($1);",
+		pq.Array(productIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		offer, err := scanProductOfferRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		offers[offer.ProductId] = offer
+	}
+
+	return offers, nil
+}
+
+func (m *Manager) getProductsMainImages(productIds []int) (map[int]*types.ProductImage, error) {
+	images := make(map[int]*types.ProductImage, len(productIds))
+
+	if len(productIds) == 0 {
+		return images, nil
+	}
+
+	rows, err := m.db.Query(
+		"SELECT * FROM product_images WHERE product_id = ANY($1) AND is_main = true;",
+		pq.Array(productIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		image, err := scanProductImageRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		images[image.ProductId] = image
+	}
+
+	return images, nil
+}
+
+func (m *Manager) getProductsStores(productIds []int) (map[int]types.StoreInfo, error) {
+	stores := make(map[int]types.StoreInfo, len(productIds))
+
+	if len(productIds) == 0 {
+		return stores, nil
+	}
+
+	rows, err := m.db.Query(`
+		SELECT sop.product_id, s.id, s.name, s.description FROM store_owned_products sop
+		JOIN stores s ON s.id = sop.store_id
+		WHERE sop.product_id = ANY($1);
+	`, pq.Array(productIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productId int
+		store := types.StoreInfo{}
+
+		err := rows.Scan(&productId, &store.Id, &store.Name, &store.Description)
+		if err != nil {
+			return nil, err
+		}
+
+		stores[productId] = store
+	}
+
+	return stores, nil
+}
+
+// getProductVariantsWithAttributeSet selects the variants with
+// productVariantWithAttributeSetQuery and groups the rows of each variant,
+// so the rows have to be ordered by the variants.
+func (m *Manager) getProductVariantsWithAttributeSet(
+	q string,
+	args ...any,
+) ([]types.ProductVariantWithAttributeSet, error) {
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []types.ProductVariantWithAttributeSet{}
+
+	for rows.Next() {
+		variant, attrOption, err := scanProductVariantWithAttributeOptionRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(variants) == 0 || variants[len(variants)-1].Id != variant.Id {
+			variants = append(variants, types.ProductVariantWithAttributeSet{
+				ProductVariant: *variant,
+				AttributeSet:   []types.ProductVariantSelectedAttributeOption{},
+			})
+		}
+
+		if attrOption != nil {
+			last := &variants[len(variants)-1]
+			last.AttributeSet = append(last.AttributeSet, *attrOption)
+		}
+	}
+
+	return variants, nil
+}
+
 func scanProductCategoryRow(rows *sql.Rows) (*types.ProductCategory, error) {
 	n := new(types.ProductCategory)
 
@@ -2965,6 +2914,41 @@ func scanProductBaseRow(rows *sql.Rows) (*types.ProductBase, error) {
 	return n, nil
 }
 
+func scanProductSearchRow(rows *sql.Rows) (*types.Product, string, error) {
+	n := new(types.Product)
+	var averageScore float32
+	var snippet sql.NullString
+	var sortKey string
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Name,
+		&n.Slug,
+		&n.Price,
+		&n.ShipmentFactor,
+		&n.Description,
+		&n.IsActive,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.SubcategoryId,
+		&n.TotalQuantity,
+		&averageScore,
+		&snippet,
+		&sortKey,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	n.AverageScore = utils.RoundToNDecimals32(averageScore, 2)
+
+	if snippet.Valid {
+		n.Snippet = &snippet.String
+	}
+
+	return n, sortKey, nil
+}
+
 func scanProductOfferRow(rows *sql.Rows) (*types.ProductOffer, error) {
 	n := new(types.ProductOffer)
 
@@ -3092,6 +3076,47 @@ func scanProductVariantAttributeOptionRow(
 	return n, nil
 }
 
+func scanProductVariantWithAttributeOptionRow(
+	rows *sql.Rows,
+) (*types.ProductVariant, *types.ProductVariantSelectedAttributeOption, error) {
+	n := new(types.ProductVariant)
+	var attributeId sql.NullInt32
+	var attributeLabel sql.NullString
+	var optionId sql.NullInt32
+	var optionValue sql.NullString
+	var optionAttributeId sql.NullInt32
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Quantity,
+		&n.ProductId,
+		&attributeId,
+		&attributeLabel,
+		&optionId,
+		&optionValue,
+		&optionAttributeId,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !attributeId.Valid || !optionId.Valid {
+		return n, nil, nil
+	}
+
+	return n, &types.ProductVariantSelectedAttributeOption{
+		ProductAttribute: types.ProductAttribute{
+			Id:    int(attributeId.Int32),
+			Label: attributeLabel.String,
+		},
+		SelectedOption: types.ProductAttributeOption{
+			Id:          int(optionId.Int32),
+			Value:       optionValue.String,
+			AttributeId: int(optionAttributeId.Int32),
+		},
+	}, nil
+}
+
 func scanProductCommentRow(rows *sql.Rows) (*types.ProductComment, error) {
 	n := new(types.ProductComment)
 
@@ -3133,49 +3158,52 @@ func scanProductCommentWithUserRow(rows *sql.Rows) (*types.ProductCommentWithUse
 	return n, nil
 }
 
+// buildProductSearchQuery builds the product search on top of base. If the
+// keyword is set, the products are matched against it through their search
+// documents, which are joined with the keyword query as kq. If sorted is set,
+// the products are ordered by the sort of the query and start after its
+// cursor.
 func buildProductSearchQuery(
 	query types.ProductSearchQuery,
 	base string,
+	sorted bool,
 ) (string, []any) {
 	clauses := []string{}
 	args := []any{}
 	argsPos := 1
+	joins := ""
+	orderBy := ""
 
 	if query.Keyword != nil {
-		keywordClauses := []string{
-			"p.name ILIKE $%d",
-			"p.slug ILIKE $%d",
-			`EXISTS (SELECT 1 FROM product_tag_assignments pta
-				JOIN product_tags pt ON pta.tag_id = pt.id
-				WHERE pt.name ILIKE $%d AND pta.product_id = p.id
-			)`,
-			`EXISTS (
-				SELECT 1 FROM product_categories pc WHERE 
-				(pc.id = p.subcategory_id AND pc.name ILIKE $%d)
-			)`,
-			`p.subcategory_id IN (
-				WITH RECURSIVE cat_tree AS (
-					SELECT id, parent_category_id FROM product_categories WHERE name ILIKE $%d
-					UNION ALL SELECT pc.id, pc.parent_category_id FROM product_categories pc
-					JOIN cat_tree ct ON pc.id = ct.parent_category_id
-				)
-				SELECT id FROM cat_tree
-			)`,
-		}
+		joins = fmt.Sprintf(" CROSS JOIN to_tsquery('english', $%d) kq", argsPos)
+		args = append(args, buildProductKeywordTSQuery(*query.Keyword))
+		argsPos++
 
-		for i, c := range keywordClauses {
-			c = fmt.Sprintf(c, argsPos)
-			args = append(
-				args,
-				fmt.Sprintf("%%%s%%", *query.Keyword),
-			)
-			argsPos++
-			keywordClauses[i] = c
-		}
+		clauses = append(clauses, "p.search_document @@ kq")
+	}
 
-		keywordQ := fmt.Sprintf("(%s)", strings.Join(keywordClauses, " OR "))
+	if sorted {
+		key := productSortKeys[query.ResolvedSort()]
+
+		direction, after := "ASC", ">"
+		if key.desc {
+			direction, after = "DESC", "<"
+		}
 
-		clauses = append(clauses, keywordQ)
+		orderBy = fmt.Sprintf(" ORDER BY %s %s, p.id %s", key.expr, direction, direction)
+
+		if query.Cursor != nil {
+			clauses = append(clauses, fmt.Sprintf(
+				"(%s, p.id) %s ($%d::%s, $%d)",
+				key.expr,
+				after,
+				argsPos,
+				key.cast,
+				argsPos+1,
+			))
+			args = append(args, query.Cursor.Key, query.Cursor.Id)
+			argsPos += 2
+		}
 	}
 
 	if query.Name != nil {
@@ -3191,29 +3219,13 @@ func buildProductSearchQuery(
 	}
 
 	if query.PriceLessThan != nil {
-		clauses = append(clauses, fmt.Sprintf(`
-			COALESCE(
-				p.price * (1 - (
-					SELECT discount FROM product_offers po
-					WHERE po.product_id = p.id AND po.expire_at > NOW()
-				)),
-				p.price
-			) <= $%d
-		`, argsPos))
+		clauses = append(clauses, fmt.Sprintf("%s <= $%d", productCurrentPriceExpr, argsPos))
 		args = append(args, *query.PriceLessThan)
 		argsPos++
 	}
 
 	if query.PriceMoreThan != nil {
-		clauses = append(clauses, fmt.Sprintf(`
-			COALESCE(
-				p.price * (1 - (
-					SELECT discount FROM product_offers po
-					WHERE po.product_id = p.id AND po.expire_at > NOW()
-				)),
-				p.price
-			) >= $%d
-		`, argsPos))
+		clauses = append(clauses, fmt.Sprintf("%s >= $%d", productCurrentPriceExpr, argsPos))
 		args = append(args, *query.PriceMoreThan)
 		argsPos++
 	}
@@ -3316,11 +3328,13 @@ func buildProductSearchQuery(
 		argsPos++
 	}
 
-	q := base
+	q := base + joins
 	if len(clauses) > 0 {
 		q += " WHERE " + strings.Join(clauses, " AND ")
 	}
 
+	q += orderBy
+
 	if query.Offset != nil {
 		q += fmt.Sprintf(" OFFSET $%d", argsPos)
 		args = append(args, *query.Offset)
@@ -3337,6 +3351,51 @@ func buildProductSearchQuery(
 	return q, args
 }
 
+// buildProductKeywordTSQuery turns a search keyword into the input of
+// to_tsquery. The quoted parts of the keyword are matched as phrases, the
+// words that end with '*' are matched as prefixes and all of the parts have
+// to match. Only the letters and digits of the words are kept, so the
+// keyword cannot add operators of its own.
+func buildProductKeywordTSQuery(keyword string) string {
+	parts := []string{}
+
+	for i, segment := range strings.Split(keyword, `"`) {
+		terms := []string{}
+
+		for _, field := range strings.Fields(segment) {
+			words := strings.FieldsFunc(field, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			if len(words) == 0 {
+				continue
+			}
+
+			if strings.HasSuffix(field, "*") {
+				words[len(words)-1] += ":*"
+			}
+
+			if len(words) == 1 {
+				terms = append(terms, words[0])
+			} else {
+				terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			}
+		}
+
+		if len(terms) == 0 {
+			continue
+		}
+
+		// the odd segments are between quotes
+		if i%2 == 1 {
+			parts = append(parts, "("+strings.Join(terms, " <-> ")+")")
+		} else {
+			parts = append(parts, terms...)
+		}
+	}
+
+	return strings.Join(parts, " & ")
+}
+
 func buildProductCategorySearchQuery(
 	query types.ProductCategorySearchQuery,
 	base string,
@@ -0,0 +1,218 @@
+package db_manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+// GetProductFacets counts the products that match the query for each
+// subcategory, tag, attribute option, store, offer presence and price range.
+// The prices are split into ranges at the given bounds, which must be in
+// ascending order, so n bounds make n+1 ranges. The limit and the offset of
+// the query are ignored and all of the counts are read in one snapshot.
+func (m *Manager) GetProductFacets(
+	query types.ProductSearchQuery,
+	priceBounds []types.Money,
+) (*types.ProductFacets, error) {
+	query.Limit = nil
+	query.Offset = nil
+	query.Cursor = nil
+
+	matched, args := buildProductSearchQuery(query, "SELECT p.id FROM products p", false)
+	with := fmt.Sprintf("WITH matched AS (%s)", strings.TrimSuffix(matched, ";"))
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	facets := types.ProductFacets{
+		PriceRanges: []types.ProductPriceRangeFacet{},
+	}
+
+	err = tx.QueryRow(with+`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM product_offers po WHERE po.product_id = matched.id
+			))
+		FROM matched;
+	`, args...).
+		Scan(&facets.Total, &facets.Offer.WithOffer)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	facets.Offer.WithoutOffer = facets.Total - facets.Offer.WithOffer
+
+	facets.Categories, err = getProductFacetCountsAsDBTx(tx, with+`
+		SELECT pc.id, pc.name, COUNT(*) AS count FROM matched
+		JOIN products p ON p.id = matched.id
+		JOIN product_categories pc ON pc.id = p.subcategory_id
+		GROUP BY pc.id, pc.name
+		ORDER BY count DESC, pc.name;
+	`, args)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	facets.Tags, err = getProductFacetCountsAsDBTx(tx, with+`
+		SELECT pt.id, pt.name, COUNT(*) AS count FROM matched
+		JOIN product_tag_assignments pta ON pta.product_id = matched.id
+		JOIN product_tags pt ON pt.id = pta.tag_id
+		GROUP BY pt.id, pt.name
+		ORDER BY count DESC, pt.name;
+	`, args)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	facets.Stores, err = getProductFacetCountsAsDBTx(tx, with+`
+		SELECT s.id, s.name, COUNT(*) AS count FROM matched
+		JOIN store_owned_products sop ON sop.product_id = matched.id
+		JOIN stores s ON s.id = sop.store_id
+		GROUP BY s.id, s.name
+		ORDER BY count DESC, s.name;
+	`, args)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	facets.AttributeOptions, err = getProductAttributeOptionFacetsAsDBTx(tx, with, args)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	bounds := []string{}
+	for _, b := range priceBounds {
+		bounds = append(bounds, b.String())
+	}
+
+	// width_bucket puts the prices below the first bound in bucket 0 and the
+	// prices from the bound i-1 up to the bound i in bucket i
+	bucketCounts := map[int]int{}
+	rows, err := tx.Query(with+fmt.Sprintf(`
+		SELECT width_bucket(%s, $%d::float8[]) AS bucket, COUNT(*)
+		FROM matched
+		JOIN products p ON p.id = matched.id
+		GROUP BY bucket;
+	`, productCurrentPriceExpr, len(args)+1), append(args, pq.Array(bounds))...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for rows.Next() {
+		var bucket, count int
+		err = rows.Scan(&bucket, &count)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, err
+		}
+
+		bucketCounts[bucket] = count
+	}
+	rows.Close()
+
+	for i := 0; i <= len(priceBounds); i++ {
+		r := types.ProductPriceRangeFacet{
+			Count: bucketCounts[i],
+		}
+
+		if i > 0 {
+			r.Min = &priceBounds[i-1]
+		}
+
+		if i < len(priceBounds) {
+			r.Max = &priceBounds[i]
+		}
+
+		facets.PriceRanges = append(facets.PriceRanges, r)
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return &facets, nil
+}
+
+func getProductFacetCountsAsDBTx(
+	tx *sql.Tx,
+	q string,
+	args []any,
+) ([]types.ProductFacetCount, error) {
+	rows, err := tx.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []types.ProductFacetCount{}
+
+	for rows.Next() {
+		c := types.ProductFacetCount{}
+		err = rows.Scan(&c.Id, &c.Name, &c.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, c)
+	}
+
+	return counts, nil
+}
+
+// getProductAttributeOptionFacetsAsDBTx counts the matching products that
+// have at least one variant with each attribute option.
+func getProductAttributeOptionFacetsAsDBTx(
+	tx *sql.Tx,
+	with string,
+	args []any,
+) ([]types.ProductAttributeOptionFacet, error) {
+	rows, err := tx.Query(with+`
+		SELECT pa.id, pa.label, pao.id, pao.value, COUNT(DISTINCT matched.id) AS count
+		FROM matched
+		JOIN product_variants pv ON pv.product_id = matched.id
+		JOIN product_variant_attribute_options pvao ON pvao.variant_id = pv.id
+		JOIN product_attribute_options pao ON pao.id = pvao.option_id
+		JOIN product_attributes pa ON pa.id = pao.attribute_id
+		GROUP BY pa.id, pa.label, pao.id, pao.value
+		ORDER BY pa.label, pa.id, count DESC, pao.value;
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := []types.ProductAttributeOptionFacet{}
+
+	for rows.Next() {
+		f := types.ProductAttributeOptionFacet{}
+		err = rows.Scan(&f.AttributeId, &f.AttributeLabel, &f.OptionId, &f.OptionValue, &f.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		facets = append(facets, f)
+	}
+
+	return facets, nil
+}
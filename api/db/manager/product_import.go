@@ -0,0 +1,859 @@
+package db_manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/SaeedAlian/econest/api/config"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+// productImportColumns selects the columns of the imports that are scanned by
+// scanProductImportRow, which leaves out their data.
+const productImportColumns = `
+	pi.id, pi.format, pi.dry_run, pi.status, pi.total_rows, pi.created_rows,
+	pi.updated_rows, pi.failed_rows, pi.error, pi.created_at, pi.started_at,
+	pi.finished_at, pi.store_id, pi.user_id
+`
+
+func (m *Manager) CreateProductImport(p types.CreateProductImportPayload) (int, error) {
+	rowId := -1
+	err := m.db.QueryRow(
+		"INSERT INTO product_imports (format, dry_run, data, store_id, user_id) VALUES ($1, $2, $3, $4, $5) RETURNING id;",
+		p.Format,
+		p.DryRun,
+		p.Data,
+		p.StoreId,
+		p.UserId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) GetProductImports(
+	query types.ProductImportSearchQuery,
+) ([]types.ProductImport, error) {
+	q, args := buildProductImportSearchQuery(
+		query,
+		"SELECT "+productImportColumns+" FROM product_imports pi",
+		"ORDER BY pi.id DESC",
+	)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imports := []types.ProductImport{}
+
+	for rows.Next() {
+		imp, err := scanProductImportRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		imports = append(imports, *imp)
+	}
+
+	return imports, nil
+}
+
+func (m *Manager) GetProductImportsCount(query types.ProductImportSearchQuery) (int, error) {
+	q, args := buildProductImportSearchQuery(
+		query,
+		"SELECT COUNT(*) as count FROM product_imports pi",
+		"",
+	)
+
+	count := 0
+	err := m.db.QueryRow(q, args...).Scan(&count)
+	if err != nil {
+		return -1, err
+	}
+
+	return count, nil
+}
+
+func (m *Manager) GetProductImportById(id int) (*types.ProductImportWithErrors, error) {
+	rows, err := m.db.Query(
+		"SELECT "+productImportColumns+" FROM product_imports pi WHERE pi.id = $1;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, types.ErrProductImportNotFound
+	}
+
+	imp, err := scanProductImportRow(rows)
+	if err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	errorRows, err := m.db.Query(
+		"SELECT * FROM product_import_errors WHERE import_id = $1 ORDER BY row_number, id;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer errorRows.Close()
+
+	result := types.ProductImportWithErrors{
+		ProductImport: *imp,
+		Errors:        []types.ProductImportError{},
+	}
+
+	for errorRows.Next() {
+		importError, err := scanProductImportErrorRow(errorRows)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Errors = append(result.Errors, *importError)
+	}
+
+	return &result, nil
+}
+
+// ClaimPendingProductImport marks the oldest pending import as running and
+// returns it with its data, or nil if there is no pending import. The
+// pending imports are claimed with SKIP LOCKED, so the workers never pick up
+// the same import. An import that has been running for longer than the
+// import timeout is claimed again, as its worker has been stopped before it
+// could finish it.
+func (m *Manager) ClaimPendingProductImport() (*types.ProductImport, string, error) {
+	now := time.Now()
+	timeout := time.Duration(config.Env.ProductImportTimeoutInMin * float64(time.Minute))
+
+	rows, err := m.db.Query(`
+		UPDATE product_imports pi SET status = $1, started_at = $2
+		WHERE pi.id = (
+			SELECT id FROM product_imports
+			WHERE status = $3 OR (status = $1 AND started_at < $4)
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+productImportColumns+`, pi.data;
+	`,
+		types.ProductImportStatusRunning,
+		now,
+		types.ProductImportStatusPending,
+		now.Add(-timeout),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, "", nil
+	}
+
+	imp := new(types.ProductImport)
+	var data string
+
+	err = rows.Scan(
+		&imp.Id,
+		&imp.Format,
+		&imp.DryRun,
+		&imp.Status,
+		&imp.TotalRows,
+		&imp.CreatedRows,
+		&imp.UpdatedRows,
+		&imp.FailedRows,
+		&imp.Error,
+		&imp.CreatedAt,
+		&imp.StartedAt,
+		&imp.FinishedAt,
+		&imp.StoreId,
+		&imp.UserId,
+		&data,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return imp, data, nil
+}
+
+// CompleteProductImport saves the result of a running import with the
+// errors of its failed rows.
+func (m *Manager) CompleteProductImport(id int, result types.ProductImportResult) error {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range result.Errors {
+		var slug *string = nil
+		if e.Slug != nil {
+			slug = utils.Ptr(truncateProductImportText(*e.Slug, 255))
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO product_import_errors (row_number, slug, message, import_id) VALUES ($1, $2, $3, $4);",
+			e.RowNumber,
+			slug,
+			truncateProductImportText(e.Message, 1023),
+			id,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE product_imports SET
+			status = $1, total_rows = $2, created_rows = $3, updated_rows = $4,
+			failed_rows = $5, finished_at = $6, data = ''
+		WHERE id = $7;
+	`,
+		types.ProductImportStatusCompleted,
+		result.TotalRows,
+		result.CreatedRows,
+		result.UpdatedRows,
+		len(result.Errors),
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+// FailProductImport marks a running import as failed when its file cannot
+// be read.
+func (m *Manager) FailProductImport(id int, reason string) error {
+	_, err := m.db.Exec(
+		"UPDATE product_imports SET status = $1, error = $2, finished_at = $3, data = '' WHERE id = $4;",
+		types.ProductImportStatusFailed,
+		truncateProductImportText(reason, 1023),
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ImportProductCatalogRow creates the product of a row in the store, or
+// updates the product of the store with the same slug. The row is checked
+// against the categories, the tags and the attributes before it is saved,
+// and it is rolled back after it is saved if dryRun is set. It returns
+// whether the product was created.
+func (m *Manager) ImportProductCatalogRow(
+	storeId int,
+	row types.ProductCatalogRow,
+	dryRun bool,
+) (bool, error) {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	err = validateProductCatalogRowAsDBTx(tx, row)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	created, err := importProductCatalogRowAsDBTx(tx, storeId, row)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if dryRun {
+		tx.Rollback()
+		return created, nil
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return created, nil
+}
+
+// GetStoreProductCatalog returns the products of a store as the rows of an
+// export, with their tags, specs, images and variants loaded for all the
+// products at once.
+func (m *Manager) GetStoreProductCatalog(storeId int) ([]types.ProductCatalogRow, error) {
+	rows, err := m.db.Query(`
+		SELECT `+productBaseColumns+` FROM products p
+		JOIN store_owned_products sop ON sop.product_id = p.id
+		WHERE sop.store_id = $1
+		ORDER BY p.id;
+	`, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	catalog := []types.ProductCatalogRow{}
+	productIds := []int{}
+	indexes := map[int]int{}
+
+	for rows.Next() {
+		p, err := scanProductBaseRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes[p.Id] = len(catalog)
+		productIds = append(productIds, p.Id)
+		catalog = append(catalog, types.ProductCatalogRow{
+			Slug:           p.Slug,
+			Name:           p.Name,
+			Price:          p.Price,
+			ShipmentFactor: p.ShipmentFactor,
+			Description:    p.Description,
+			IsActive:       &p.IsActive,
+			SubcategoryId:  p.SubcategoryId,
+			TagIds:         []int{},
+			Specs:          []types.CreateProductSpecPayload{},
+			Images:         []types.CreateProductImagePayload{},
+			Variants:       []types.CreateProductVariantPayload{},
+		})
+	}
+	rows.Close()
+
+	if len(productIds) == 0 {
+		return catalog, nil
+	}
+
+	tagRows, err := m.db.Query(
+		"SELECT product_id, tag_id FROM product_tag_assignments WHERE product_id = ANY($1) ORDER BY tag_id;",
+		pq.Array(productIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var productId, tagId int
+		if err := tagRows.Scan(&productId, &tagId); err != nil {
+			return nil, err
+		}
+
+		c := &catalog[indexes[productId]]
+		c.TagIds = append(c.TagIds, tagId)
+	}
+	tagRows.Close()
+
+	specRows, err := m.db.Query(
+		"SELECT * FROM product_specs WHERE product_id = ANY($1) ORDER BY id;",
+		pq.Array(productIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer specRows.Close()
+
+	for specRows.Next() {
+		spec, err := scanProductSpecRow(specRows)
+		if err != nil {
+			return nil, err
+		}
+
+		c := &catalog[indexes[spec.ProductId]]
+		c.Specs = append(c.Specs, types.CreateProductSpecPayload{
+			Label: spec.Label,
+			Value: spec.Value,
+		})
+	}
+	specRows.Close()
+
+	imageRows, err := m.db.Query(
+		"SELECT * FROM product_images WHERE product_id = ANY($1) ORDER BY id;",
+		pq.Array(productIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer imageRows.Close()
+
+	for imageRows.Next() {
+		image, err := scanProductImageRow(imageRows)
+		if err != nil {
+			return nil, err
+		}
+
+		c := &catalog[indexes[image.ProductId]]
+		c.Images = append(c.Images, types.CreateProductImagePayload{
+			ImageName: image.ImageName,
+			IsMain:    image.IsMain,
+		})
+	}
+	imageRows.Close()
+
+	variants, err := m.getProductVariantsWithAttributeSet(
+		productVariantWithAttributeSetQuery+" WHERE pv.product_id = ANY($1) ORDER BY pv.id, pa.id;",
+		pq.Array(productIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range variants {
+		attributeSets := []types.ProductVariantAttributeSetPayload{}
+		for _, a := range v.AttributeSet {
+			attributeSets = append(attributeSets, types.ProductVariantAttributeSetPayload{
+				AttributeId: a.ProductAttribute.Id,
+				OptionId:    a.SelectedOption.Id,
+			})
+		}
+
+		c := &catalog[indexes[v.ProductId]]
+		c.Variants = append(c.Variants, types.CreateProductVariantPayload{
+			Quantity:      v.Quantity,
+			AttributeSets: attributeSets,
+		})
+	}
+
+	return catalog, nil
+}
+
+// validateProductCatalogRowAsDBTx checks that the subcategory, the tags and
+// the attribute options of a row exist, and that each variant selects an
+// option of its own attribute at most once for each attribute.
+func validateProductCatalogRowAsDBTx(tx *sql.Tx, row types.ProductCatalogRow) error {
+	subcategoryExists := false
+	err := tx.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM product_categories WHERE id = $1);",
+		row.SubcategoryId,
+	).
+		Scan(&subcategoryExists)
+	if err != nil {
+		return err
+	}
+
+	if !subcategoryExists {
+		return types.ErrSubcategoryNotFound
+	}
+
+	if len(row.TagIds) > 0 {
+		tagIds := slices.Clone(row.TagIds)
+		slices.Sort(tagIds)
+		tagIds = slices.Compact(tagIds)
+
+		tagsCount := 0
+		err := tx.QueryRow(
+			"SELECT COUNT(*) FROM product_tags WHERE id = ANY($1);",
+			pq.Array(tagIds),
+		).
+			Scan(&tagsCount)
+		if err != nil {
+			return err
+		}
+
+		if tagsCount != len(tagIds) {
+			return types.ErrProductTagNotFound
+		}
+	}
+
+	optionIds := []int{}
+	for _, v := range row.Variants {
+		for _, attrSet := range v.AttributeSets {
+			optionIds = append(optionIds, attrSet.OptionId)
+		}
+	}
+
+	if len(optionIds) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(
+		"SELECT id, attribute_id FROM product_attribute_options WHERE id = ANY($1);",
+		pq.Array(optionIds),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	optionAttributes := map[int]int{}
+	for rows.Next() {
+		var optionId, attributeId int
+		if err := rows.Scan(&optionId, &attributeId); err != nil {
+			return err
+		}
+
+		optionAttributes[optionId] = attributeId
+	}
+	rows.Close()
+
+	for _, v := range row.Variants {
+		attributeIds := map[int]bool{}
+
+		for _, attrSet := range v.AttributeSets {
+			attributeId, ok := optionAttributes[attrSet.OptionId]
+			if !ok {
+				return types.ErrProductAttributeOptionNotFound
+			}
+
+			if attributeId != attrSet.AttributeId {
+				return types.ErrAttributeOptionMismatch
+			}
+
+			if attributeIds[attributeId] {
+				return types.ErrDuplicateVariantAttribute
+			}
+
+			attributeIds[attributeId] = true
+		}
+	}
+
+	return nil
+}
+
+// importProductCatalogRowAsDBTx creates the product of a row or updates the
+// product with its slug. The tags, the specs and the images of an updated
+// product are replaced by the ones of the row. Its variants with the same
+// attribute set as a variant of the row get the quantity of that variant,
+// the other variants of the row are added and the variants that are not in
+// the row are kept, since they may be in orders.
+func importProductCatalogRowAsDBTx(
+	tx *sql.Tx,
+	storeId int,
+	row types.ProductCatalogRow,
+) (bool, error) {
+	productId := -1
+	ownerStoreId := -1
+	err := tx.QueryRow(`
+		SELECT p.id, sop.store_id FROM products p
+		JOIN store_owned_products sop ON sop.product_id = p.id
+		WHERE p.slug = $1
+		FOR UPDATE OF p;
+	`, row.Slug).
+		Scan(&productId, &ownerStoreId)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	if err == sql.ErrNoRows {
+		productId, err = createProductBaseAsDBTx(tx, types.CreateProductBasePayload{
+			Name:           row.Name,
+			Slug:           row.Slug,
+			Price:          row.Price,
+			ShipmentFactor: row.ShipmentFactor,
+			Description:    row.Description,
+			SubcategoryId:  row.SubcategoryId,
+			StoreId:        storeId,
+		})
+		if err != nil {
+			return false, err
+		}
+
+		if row.IsActive != nil && !*row.IsActive {
+			err = updateProductBaseAsDBTx(tx, productId, types.UpdateProductBasePayload{
+				IsActive: row.IsActive,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		err = createProductCatalogRowRelationsAsDBTx(tx, productId, row)
+		if err != nil {
+			return false, err
+		}
+
+		for _, variant := range row.Variants {
+			_, err := createProductVariantAsDBTx(tx, productId, variant)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	}
+
+	if ownerStoreId != storeId {
+		return false, types.ErrProductSlugOwnedByAnotherStore
+	}
+
+	err = updateProductBaseAsDBTx(tx, productId, types.UpdateProductBasePayload{
+		Name:           &row.Name,
+		Price:          &row.Price,
+		ShipmentFactor: &row.ShipmentFactor,
+		Description:    &row.Description,
+		SubcategoryId:  &row.SubcategoryId,
+		IsActive:       row.IsActive,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	err = updateProductUpdatedAtColumnAsDBTx(tx, productId, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	for _, table := range []string{"product_tag_assignments", "product_specs", "product_images"} {
+		_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE product_id = $1;", table), productId)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = createProductCatalogRowRelationsAsDBTx(tx, productId, row)
+	if err != nil {
+		return false, err
+	}
+
+	variantIds, err := getProductVariantIdsByAttributeSetAsDBTx(tx, productId)
+	if err != nil {
+		return false, err
+	}
+
+	for _, variant := range row.Variants {
+		variantId, ok := variantIds[productVariantAttributeSetKey(variant.AttributeSets)]
+		if !ok {
+			_, err := createProductVariantAsDBTx(tx, productId, variant)
+			if err != nil {
+				return false, err
+			}
+
+			continue
+		}
+
+		_, err := tx.Exec(
+			"UPDATE product_variants SET quantity = $1 WHERE id = $2;",
+			variant.Quantity,
+			variantId,
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+func createProductCatalogRowRelationsAsDBTx(
+	tx *sql.Tx,
+	productId int,
+	row types.ProductCatalogRow,
+) error {
+	tagIds := slices.Clone(row.TagIds)
+	slices.Sort(tagIds)
+	tagIds = slices.Compact(tagIds)
+
+	err := createProductTagAssignmentsAsDBTx(tx, productId, tagIds)
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range row.Specs {
+		_, err := createProductSpecAsDBTx(tx, productId, spec)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, img := range row.Images {
+		_, err := createProductImageAsDBTx(tx, productId, img)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getProductVariantIdsByAttributeSetAsDBTx returns the ids of the variants of
+// a product by the keys of their attribute sets.
+func getProductVariantIdsByAttributeSetAsDBTx(
+	tx *sql.Tx,
+	productId int,
+) (map[string]int, error) {
+	rows, err := tx.Query(`
+		SELECT pv.id, pvao.attribute_id, pvao.option_id FROM product_variants pv
+		LEFT JOIN product_variant_attribute_options pvao ON pvao.variant_id = pv.id
+		WHERE pv.product_id = $1
+		ORDER BY pv.id;
+	`, productId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attributeSets := map[int][]types.ProductVariantAttributeSetPayload{}
+	for rows.Next() {
+		var variantId int
+		var attributeId, optionId sql.NullInt32
+		if err := rows.Scan(&variantId, &attributeId, &optionId); err != nil {
+			return nil, err
+		}
+
+		if _, ok := attributeSets[variantId]; !ok {
+			attributeSets[variantId] = []types.ProductVariantAttributeSetPayload{}
+		}
+
+		if attributeId.Valid && optionId.Valid {
+			attributeSets[variantId] = append(
+				attributeSets[variantId],
+				types.ProductVariantAttributeSetPayload{
+					AttributeId: int(attributeId.Int32),
+					OptionId:    int(optionId.Int32),
+				},
+			)
+		}
+	}
+
+	variantIds := make(map[string]int, len(attributeSets))
+	for variantId, attributeSet := range attributeSets {
+		key := productVariantAttributeSetKey(attributeSet)
+		if id, ok := variantIds[key]; !ok || variantId < id {
+			variantIds[key] = variantId
+		}
+	}
+
+	return variantIds, nil
+}
+
+// productVariantAttributeSetKey returns a key of an attribute set that does
+// not depend on the order of its attributes.
+func productVariantAttributeSetKey(attributeSets []types.ProductVariantAttributeSetPayload) string {
+	parts := make([]string, 0, len(attributeSets))
+	for _, a := range attributeSets {
+		parts = append(parts, fmt.Sprintf("%d:%d", a.AttributeId, a.OptionId))
+	}
+
+	slices.Sort(parts)
+
+	return strings.Join(parts, ",")
+}
+
+// truncateProductImportText keeps a text of an import within the size of
+// its column.
+func truncateProductImportText(text string, size int) string {
+	r := []rune(text)
+	if len(r) <= size {
+		return text
+	}
+
+	return string(r[:size-3]) + "..."
+}
+
+func scanProductImportRow(rows *sql.Rows) (*types.ProductImport, error) {
+	n := new(types.ProductImport)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Format,
+		&n.DryRun,
+		&n.Status,
+		&n.TotalRows,
+		&n.CreatedRows,
+		&n.UpdatedRows,
+		&n.FailedRows,
+		&n.Error,
+		&n.CreatedAt,
+		&n.StartedAt,
+		&n.FinishedAt,
+		&n.StoreId,
+		&n.UserId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func scanProductImportErrorRow(rows *sql.Rows) (*types.ProductImportError, error) {
+	n := new(types.ProductImportError)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.RowNumber,
+		&n.Slug,
+		&n.Message,
+		&n.ImportId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func buildProductImportSearchQuery(
+	query types.ProductImportSearchQuery,
+	base string,
+	orderBy string,
+) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if query.StoreId != nil {
+		clauses = append(clauses, fmt.Sprintf("pi.store_id = $%d", argsPos))
+		args = append(args, *query.StoreId)
+		argsPos++
+	}
+
+	if query.Status != nil {
+		clauses = append(clauses, fmt.Sprintf("pi.status = $%d", argsPos))
+		args = append(args, *query.Status)
+		argsPos++
+	}
+
+	q := base
+	if len(clauses) > 0 {
+		q += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	if orderBy != "" {
+		q += " " + orderBy
+	}
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	return q, args
+}
@@ -0,0 +1,1173 @@
+package db_manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/SaeedAlian/econest/api/config"
+	"github.com/SaeedAlian/econest/api/types"
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+func (m *Manager) CreateShippingZone(p types.CreateShippingZonePayload) (int, error) {
+	rowId := -1
+	err := m.db.QueryRow(
+		"INSERT INTO shipping_zones (name, state, city) VALUES ($1, $2, NULLIF($3, '')) RETURNING id;",
+		p.Name,
+		p.State,
+		p.City,
+	).
+		Scan(&rowId)
+	if err != nil {
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) CreateShippingRateTable(p types.CreateShippingRateTablePayload) (int, error) {
+	if !p.Basis.IsValid() {
+		return -1, types.ErrInvalidShippingRateBasisEnum
+	}
+
+	err := validateShippingRateTiers(p.Tiers)
+	if err != nil {
+		return -1, err
+	}
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	rowId := -1
+	err = tx.QueryRow(
+		"INSERT INTO shipping_rate_tables (basis, free_shipping_threshold, store_id, zone_id) VALUES ($1, $2, $3, $4) RETURNING id;",
+		p.Basis,
+		p.FreeShippingThreshold,
+		p.StoreId,
+		p.ZoneId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	err = createShippingRateTiersAsDBTx(tx, rowId, p.Tiers)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) CreateShippingTransitTime(
+	p types.CreateShippingTransitTimePayload,
+) (int, error) {
+	if p.Days < 0 {
+		return -1, types.ErrInvalidShippingTransitDays
+	}
+
+	rowId := -1
+	err := m.db.QueryRow(
+		"INSERT INTO shipping_transit_times (days, origin_zone_id, destination_zone_id) VALUES ($1, $2, $3) RETURNING id;",
+		p.Days,
+		p.OriginZoneId,
+		p.DestinationZoneId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		if isUniqueViolation(
+			err,
+			"shipping_transit_times_origin_zone_id_destination_zone_id_key",
+		) {
+			return -1, types.ErrDuplicateShippingTransitTime
+		}
+
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) GetShippingZones(
+	query types.ShippingZoneSearchQuery,
+) ([]types.ShippingZone, error) {
+	var base string
+	base = "SELECT * FROM shipping_zones"
+
+	q, args := buildShippingZoneSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := []types.ShippingZone{}
+
+	for rows.Next() {
+		zone, err := scanShippingZoneRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		zones = append(zones, *zone)
+	}
+
+	return zones, nil
+}
+
+func (m *Manager) GetShippingZonesCount(
+	query types.ShippingZoneSearchQuery,
+) (int, error) {
+	var base string
+	base = "SELECT COUNT(*) as count FROM shipping_zones"
+
+	q, args := buildShippingZoneSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		err := rows.Scan(&count)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return count, nil
+}
+
+func (m *Manager) GetShippingZoneById(id int) (*types.ShippingZone, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM shipping_zones WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zone := new(types.ShippingZone)
+	zone.Id = -1
+
+	for rows.Next() {
+		zone, err = scanShippingZoneRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if zone.Id == -1 {
+		return nil, types.ErrShippingZoneNotFound
+	}
+
+	return zone, nil
+}
+
+func (m *Manager) GetShippingTransitTimes(
+	query types.ShippingTransitTimeSearchQuery,
+) ([]types.ShippingTransitTime, error) {
+	var base string
+	base = "SELECT * FROM shipping_transit_times"
+
+	q, args := buildShippingTransitTimeSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitTimes := []types.ShippingTransitTime{}
+
+	for rows.Next() {
+		transitTime, err := scanShippingTransitTimeRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		transitTimes = append(transitTimes, *transitTime)
+	}
+
+	return transitTimes, nil
+}
+
+func (m *Manager) GetShippingTransitTimesCount(
+	query types.ShippingTransitTimeSearchQuery,
+) (int, error) {
+	var base string
+	base = "SELECT COUNT(*) as count FROM shipping_transit_times"
+
+	q, args := buildShippingTransitTimeSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		err := rows.Scan(&count)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return count, nil
+}
+
+func (m *Manager) GetStoreShippingRateTables(
+	storeId int,
+) ([]types.ShippingRateTableWithTiers, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM shipping_rate_tables WHERE store_id = $1 ORDER BY id;",
+		storeId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []types.ShippingRateTableWithTiers{}
+	tableIds := []int{}
+
+	for rows.Next() {
+		table, err := scanShippingRateTableRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, types.ShippingRateTableWithTiers{
+			ShippingRateTable: *table,
+			Tiers:             []types.ShippingRateTier{},
+		})
+		tableIds = append(tableIds, table.Id)
+	}
+	rows.Close()
+
+	tiers, err := m.getShippingRateTablesTiers(tableIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tables {
+		tables[i].Tiers = tiers[tables[i].Id]
+	}
+
+	return tables, nil
+}
+
+func (m *Manager) GetShippingRateTableById(id int) (*types.ShippingRateTableWithTiers, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM shipping_rate_tables WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table := new(types.ShippingRateTable)
+	table.Id = -1
+
+	for rows.Next() {
+		table, err = scanShippingRateTableRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rows.Close()
+
+	if table.Id == -1 {
+		return nil, types.ErrShippingRateTableNotFound
+	}
+
+	tiers, err := m.getShippingRateTablesTiers([]int{table.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ShippingRateTableWithTiers{
+		ShippingRateTable: *table,
+		Tiers:             tiers[table.Id],
+	}, nil
+}
+
+// QuoteShipping computes the shipping price of the given variants for one of
+// the user's addresses, the same way it is computed when the order is placed.
+func (m *Manager) QuoteShipping(
+	userId int,
+	p types.ShippingQuotePayload,
+) (*types.ShippingQuote, error) {
+	if len(p.ProductVariants) == 0 {
+		return nil, types.ErrProductVariantsAreEmpty
+	}
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	state, city, err := getReceiverAddressAsDBTx(tx, p.ReceiverAddressId, userId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	lines, err := getShippingLinesAsDBTx(tx, p.ProductVariants)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	quote, err := computeShippingAsDBTx(tx, state, city, lines)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+func (m *Manager) UpdateShippingZone(id int, p types.UpdateShippingZonePayload) error {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if p.Name != nil {
+		clauses = append(clauses, fmt.Sprintf("name = $%d", argsPos))
+		args = append(args, *p.Name)
+		argsPos++
+	}
+
+	if p.State != nil {
+		clauses = append(clauses, fmt.Sprintf("state = $%d", argsPos))
+		args = append(args, *p.State)
+		argsPos++
+	}
+
+	if p.City != nil {
+		clauses = append(clauses, fmt.Sprintf("city = NULLIF($%d, '')", argsPos))
+		args = append(args, *p.City)
+		argsPos++
+	}
+
+	if len(clauses) == 0 {
+		return types.ErrNoFieldsReceivedToUpdate
+	}
+
+	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
+	args = append(args, time.Now())
+	argsPos++
+
+	args = append(args, id)
+	q := fmt.Sprintf(
+		"UPDATE shipping_zones SET %s WHERE id = $%d",
+		strings.Join(clauses, ", "),
+		argsPos,
+	)
+
+	res, err := m.db.Exec(q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return types.ErrShippingZoneNotFound
+	}
+
+	return nil
+}
+
+func (m *Manager) UpdateShippingTransitTime(
+	id int,
+	p types.UpdateShippingTransitTimePayload,
+) error {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if p.Days != nil {
+		if *p.Days < 0 {
+			return types.ErrInvalidShippingTransitDays
+		}
+
+		clauses = append(clauses, fmt.Sprintf("days = $%d", argsPos))
+		args = append(args, *p.Days)
+		argsPos++
+	}
+
+	if len(clauses) == 0 {
+		return types.ErrNoFieldsReceivedToUpdate
+	}
+
+	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
+	args = append(args, time.Now())
+	argsPos++
+
+	args = append(args, id)
+	q := fmt.Sprintf(
+		"UPDATE shipping_transit_times SET %s WHERE id = $%d",
+		strings.Join(clauses, ", "),
+		argsPos,
+	)
+
+	res, err := m.db.Exec(q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return types.ErrShippingTransitTimeNotFound
+	}
+
+	return nil
+}
+
+func (m *Manager) UpdateShippingRateTable(
+	id int,
+	storeId int,
+	p types.UpdateShippingRateTablePayload,
+) error {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if p.Basis != nil {
+		if !p.Basis.IsValid() {
+			return types.ErrInvalidShippingRateBasisEnum
+		}
+
+		clauses = append(clauses, fmt.Sprintf("basis = $%d", argsPos))
+		args = append(args, *p.Basis)
+		argsPos++
+	}
+
+	if p.FreeShippingThreshold != nil {
+		clauses = append(clauses, fmt.Sprintf("free_shipping_threshold = $%d", argsPos))
+		args = append(args, *p.FreeShippingThreshold)
+		argsPos++
+	}
+
+	if len(clauses) == 0 && p.Tiers == nil {
+		return types.ErrNoFieldsReceivedToUpdate
+	}
+
+	if p.Tiers != nil {
+		err := validateShippingRateTiers(p.Tiers)
+		if err != nil {
+			return err
+		}
+	}
+
+	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
+	args = append(args, time.Now())
+	argsPos++
+
+	args = append(args, id, storeId)
+	q := fmt.Sprintf(
+		"UPDATE shipping_rate_tables SET %s WHERE id = $%d AND store_id = $%d",
+		strings.Join(clauses, ", "),
+		argsPos,
+		argsPos+1,
+	)
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(q, args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if affected == 0 {
+		tx.Rollback()
+		return types.ErrShippingRateTableNotFound
+	}
+
+	if p.Tiers != nil {
+		_, err = tx.Exec("DELETE FROM shipping_rate_tiers WHERE rate_table_id = $1;", id)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = createShippingRateTiersAsDBTx(tx, id, p.Tiers)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+func (m *Manager) DeleteShippingZone(id int) error {
+	_, err := m.db.Exec(
+		"DELETE FROM shipping_zones WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Manager) DeleteShippingTransitTime(id int) error {
+	_, err := m.db.Exec(
+		"DELETE FROM shipping_transit_times WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Manager) DeleteShippingRateTable(id int, storeId int) error {
+	res, err := m.db.Exec(
+		"DELETE FROM shipping_rate_tables WHERE id = $1 AND store_id = $2;",
+		id,
+		storeId,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return types.ErrShippingRateTableNotFound
+	}
+
+	return nil
+}
+
+func (m *Manager) getShippingRateTablesTiers(
+	tableIds []int,
+) (map[int][]types.ShippingRateTier, error) {
+	tiers := make(map[int][]types.ShippingRateTier, len(tableIds))
+	for _, id := range tableIds {
+		tiers[id] = []types.ShippingRateTier{}
+	}
+
+	if len(tableIds) == 0 {
+		return tiers, nil
+	}
+
+	rows, err := m.db.Query(
+		"SELECT * FROM shipping_rate_tiers WHERE rate_table_id = ANY($1) ORDER BY min_value;",
+		pq.Array(tableIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tier, err := scanShippingRateTierRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tiers[tier.RateTableId] = append(tiers[tier.RateTableId], *tier)
+	}
+
+	return tiers, nil
+}
+
+func createShippingRateTiersAsDBTx(
+	tx *sql.Tx,
+	rateTableId int,
+	tiers []types.CreateShippingRateTierPayload,
+) error {
+	for _, t := range tiers {
+		_, err := tx.Exec(
+			"INSERT INTO shipping_rate_tiers (min_value, max_value, base_price, price_per_unit, rate_table_id) VALUES ($1, $2, $3, $4, $5)",
+			t.MinValue,
+			t.MaxValue,
+			t.BasePrice,
+			t.PricePerUnit,
+			rateTableId,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateShippingRateTiers checks that the tiers have valid prices and
+// ranges, and that no measured value falls in more than one tier.
+func validateShippingRateTiers(tiers []types.CreateShippingRateTierPayload) error {
+	if len(tiers) == 0 {
+		return types.ErrShippingRateTiersAreEmpty
+	}
+
+	sorted := slices.Clone(tiers)
+	slices.SortFunc(sorted, func(a, b types.CreateShippingRateTierPayload) int {
+		if a.MinValue < b.MinValue {
+			return -1
+		}
+		if a.MinValue > b.MinValue {
+			return 1
+		}
+		return 0
+	})
+
+	for i, t := range sorted {
+		if t.MinValue < 0 || t.BasePrice.IsNegative() || t.PricePerUnit.IsNegative() {
+			return types.ErrInvalidShippingRateTiers
+		}
+
+		if t.MaxValue != nil && *t.MaxValue <= t.MinValue {
+			return types.ErrInvalidShippingRateTiers
+		}
+
+		if i > 0 {
+			prev := sorted[i-1]
+			if prev.MaxValue == nil || *prev.MaxValue > t.MinValue {
+				return types.ErrInvalidShippingRateTiers
+			}
+		}
+	}
+
+	return nil
+}
+
+func getReceiverAddressAsDBTx(
+	tx *sql.Tx,
+	addressId int,
+	userId int,
+) (state string, city string, err error) {
+	err = tx.QueryRow(
+		"SELECT state, city FROM addresses WHERE id = $1 AND user_id = $2;",
+		addressId,
+		userId,
+	).
+		Scan(&state, &city)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", types.ErrShipmentAddressNotFound
+		}
+		return "", "", err
+	}
+
+	return state, city, nil
+}
+
+// getShippingLinesAsDBTx prices the given variants with their current
+// offer-aware price and available quantity.
+func getShippingLinesAsDBTx(
+	tx *sql.Tx,
+	variants []types.OrderProductVariantAssignmentPayload,
+) ([]types.ShippingLine, error) {
+	variantQtyMap := make(map[int]int, len(variants))
+	variantIds := make([]int, len(variants))
+	for i, pv := range variants {
+		variantQtyMap[pv.VariantId] = pv.Quantity
+		variantIds[i] = pv.VariantId
+	}
+
+	rows, err := tx.Query(productVariantPricingQuery, pq.Array(variantIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]types.ShippingLine, 0, len(variants))
+
+	for rows.Next() {
+		pricing, err := scanProductVariantPricingRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		if pricing.ProductId == -1 {
+			return nil, types.ErrProductNotFound
+		}
+
+		selectedQuantity, ok := variantQtyMap[pricing.VariantId]
+		if !ok {
+			return nil, types.ErrProductVariantNotFound
+		}
+
+		lines = append(lines, types.ShippingLine{
+			Pricing:  *pricing,
+			Quantity: selectedQuantity,
+		})
+	}
+	rows.Close()
+
+	if len(lines) != len(variantIds) {
+		return nil, types.ErrProductVariantNotFound
+	}
+
+	return lines, nil
+}
+
+// computeShippingAsDBTx sets the shipping price of each line for a receiver
+// address in the given state and city and returns the quote of each store.
+func computeShippingAsDBTx(
+	tx *sql.Tx,
+	state string,
+	city string,
+	lines []types.ShippingLine,
+) (*types.ShippingQuote, error) {
+	storeIds := []int{}
+	storeLines := map[int][]int{}
+	for i, l := range lines {
+		if !slices.Contains(storeIds, l.Pricing.StoreId) {
+			storeIds = append(storeIds, l.Pricing.StoreId)
+		}
+
+		storeLines[l.Pricing.StoreId] = append(storeLines[l.Pricing.StoreId], i)
+	}
+
+	quote := types.ShippingQuote{
+		Stores: make([]types.StoreShippingQuote, 0, len(storeIds)),
+	}
+
+	for _, storeId := range storeIds {
+		storeQuote, err := computeStoreShippingAsDBTx(
+			tx,
+			storeId,
+			state,
+			city,
+			lines,
+			storeLines[storeId],
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		storeQuote.EstimatedArrivalDate, err = estimateArrivalDateAsDBTx(
+			tx,
+			storeId,
+			state,
+			city,
+			time.Now(),
+			true,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		quote.Stores = append(quote.Stores, *storeQuote)
+		quote.TotalShipmentPrice = quote.TotalShipmentPrice.Add(storeQuote.ShippingPrice)
+	}
+
+	return &quote, nil
+}
+
+// computeStoreShippingAsDBTx computes the shipping price of the lines of a
+// single store, whose indices are given, with the most specific rate table of
+// the store for the address: a table of a city zone, then a table of a state
+// zone and then the default table of the store. Stores without any rate table
+// ship with the default shipping price of the products.
+func computeStoreShippingAsDBTx(
+	tx *sql.Tx,
+	storeId int,
+	state string,
+	city string,
+	lines []types.ShippingLine,
+	indices []int,
+) (*types.StoreShippingQuote, error) {
+	storeQuote := types.StoreShippingQuote{StoreId: storeId}
+
+	rows, err := tx.Query(`
+		SELECT srt.* FROM shipping_rate_tables srt
+		LEFT JOIN shipping_zones sz ON sz.id = srt.zone_id
+		WHERE srt.store_id = $1 AND (
+			srt.zone_id IS NULL OR (
+				LOWER(sz.state) = LOWER($2) AND (sz.city IS NULL OR LOWER(sz.city) = LOWER($3))
+			)
+		)
+		ORDER BY
+			CASE
+				WHEN sz.city IS NOT NULL THEN 2
+				WHEN sz.id IS NOT NULL THEN 1
+				ELSE 0
+			END DESC
+		LIMIT 1;
+	`, storeId, state, city)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table := new(types.ShippingRateTable)
+	table.Id = -1
+
+	for rows.Next() {
+		table, err = scanShippingRateTableRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rows.Close()
+
+	if table.Id == -1 {
+		hasRateTables := false
+		err = tx.QueryRow(
+			"SELECT EXISTS (SELECT 1 FROM shipping_rate_tables WHERE store_id = $1);",
+			storeId,
+		).
+			Scan(&hasRateTables)
+		if err != nil {
+			return nil, err
+		}
+
+		if hasRateTables {
+			return nil, types.ErrStoreDoesNotShipToAddress(storeId)
+		}
+
+		for _, i := range indices {
+			lines[i].ShippingPrice = getDefaultShippingPrice(lines[i].Pricing.ShipmentFactor)
+			storeQuote.ShippingPrice = storeQuote.ShippingPrice.Add(lines[i].ShippingPrice)
+		}
+
+		return &storeQuote, nil
+	}
+
+	storeQuote.RateTableId = json_types.JSONNullInt32{
+		NullInt32: sql.NullInt32{Int32: int32(table.Id), Valid: true},
+	}
+
+	subtotal := types.Money{}
+	var measure float64 = 0
+	lineMeasures := make([]float64, len(indices))
+	for j, i := range indices {
+		subtotal = subtotal.Add(lines[i].Pricing.FinalPrice.Mul(lines[i].Quantity))
+
+		lineMeasures[j] = float64(lines[i].Quantity)
+		if table.Basis == types.ShippingRateBasisWeight {
+			lineMeasures[j] *= lines[i].Pricing.ShipmentFactor
+		}
+		measure += lineMeasures[j]
+	}
+
+	if table.FreeShippingThreshold.Valid && !subtotal.LessThan(table.FreeShippingThreshold.Money) {
+		storeQuote.IsFreeShipping = true
+		for _, i := range indices {
+			lines[i].ShippingPrice = types.Money{}
+		}
+
+		return &storeQuote, nil
+	}
+
+	tier := types.ShippingRateTier{Id: -1}
+	err = tx.QueryRow(`
+		SELECT * FROM shipping_rate_tiers
+		WHERE rate_table_id = $1 AND min_value <= $2 AND (max_value IS NULL OR max_value > $2)
+		LIMIT 1;
+	`, table.Id, measure).
+		Scan(
+			&tier.Id,
+			&tier.MinValue,
+			&tier.MaxValue,
+			&tier.BasePrice,
+			&tier.PricePerUnit,
+			&tier.RateTableId,
+		)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, types.ErrStoreDoesNotShipToAddress(storeId)
+		}
+		return nil, err
+	}
+
+	storeQuote.ShippingPrice = tier.BasePrice.Add(tier.PricePerUnit.MulRate(measure))
+
+	// the store's shipping price is split between its lines in proportion to
+	// their measured value, so the store is credited for the whole price
+	for j, part := range storeQuote.ShippingPrice.Allocate(lineMeasures) {
+		lines[indices[j]].ShippingPrice = part
+	}
+
+	return &storeQuote, nil
+}
+
+// estimateArrivalDateAsDBTx estimates when the shipment of a store reaches a
+// receiver address in the given state and city, counting from the given time.
+// The handling time of the store is added if the shipment is not handed to
+// the carrier yet.
+func estimateArrivalDateAsDBTx(
+	tx *sql.Tx,
+	storeId int,
+	state string,
+	city string,
+	from time.Time,
+	withHandling bool,
+) (time.Time, error) {
+	days, err := getShippingTransitDaysAsDBTx(tx, storeId, state, city)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if withHandling {
+		handlingDays := 0
+		err = tx.QueryRow(
+			"SELECT handling_days FROM stores_settings WHERE store_id = $1;",
+			storeId,
+		).
+			Scan(&handlingDays)
+		if err != nil && err != sql.ErrNoRows {
+			return time.Time{}, err
+		}
+
+		days += handlingDays
+	}
+
+	return from.AddDate(0, 0, days), nil
+}
+
+// getShippingTransitDaysAsDBTx returns the transit time from the addresses of
+// the store to a receiver address in the given state and city. The most
+// specific destination zone wins over the origin zone and the fastest of the
+// store's addresses is used. Without a matching transit time the default
+// transit time is returned.
+func getShippingTransitDaysAsDBTx(
+	tx *sql.Tx,
+	storeId int,
+	state string,
+	city string,
+) (int, error) {
+	days := -1
+	err := tx.QueryRow(`
+		SELECT stt.days FROM shipping_transit_times stt
+		JOIN shipping_zones oz ON oz.id = stt.origin_zone_id
+		JOIN shipping_zones dz ON dz.id = stt.destination_zone_id
+		JOIN addresses a ON a.store_id = $1
+			AND LOWER(oz.state) = LOWER(a.state)
+			AND (oz.city IS NULL OR LOWER(oz.city) = LOWER(a.city))
+		WHERE LOWER(dz.state) = LOWER($2) AND (dz.city IS NULL OR LOWER(dz.city) = LOWER($3))
+		ORDER BY dz.city IS NOT NULL DESC, oz.city IS NOT NULL DESC, stt.days ASC
+		LIMIT 1;
+	`, storeId, state, city).
+		Scan(&days)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return int(config.Env.DefaultTransitTimeInDays), nil
+		}
+		return -1, err
+	}
+
+	return days, nil
+}
+
+func getDefaultShippingPrice(shipmentFactor float64) types.Money {
+	return types.MoneyFromFloat(config.Env.ShipmentPrice).MulRate(shipmentFactor)
+}
+
+func scanShippingZoneRow(rows *sql.Rows) (*types.ShippingZone, error) {
+	n := new(types.ShippingZone)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Name,
+		&n.State,
+		&n.City,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func scanShippingRateTableRow(rows *sql.Rows) (*types.ShippingRateTable, error) {
+	n := new(types.ShippingRateTable)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Basis,
+		&n.FreeShippingThreshold,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.StoreId,
+		&n.ZoneId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func scanShippingTransitTimeRow(rows *sql.Rows) (*types.ShippingTransitTime, error) {
+	n := new(types.ShippingTransitTime)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Days,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.OriginZoneId,
+		&n.DestinationZoneId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func scanShippingRateTierRow(rows *sql.Rows) (*types.ShippingRateTier, error) {
+	n := new(types.ShippingRateTier)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.MinValue,
+		&n.MaxValue,
+		&n.BasePrice,
+		&n.PricePerUnit,
+		&n.RateTableId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func buildShippingZoneSearchQuery(
+	query types.ShippingZoneSearchQuery,
+	base string,
+) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if query.Name != nil {
+		clauses = append(clauses, fmt.Sprintf("name ILIKE $%d", argsPos))
+		args = append(args, fmt.Sprintf("%%%s%%", *query.Name))
+		argsPos++
+	}
+
+	if query.State != nil {
+		clauses = append(clauses, fmt.Sprintf("LOWER(state) = LOWER($%d)", argsPos))
+		args = append(args, *query.State)
+		argsPos++
+	}
+
+	q := base
+	if len(clauses) > 0 {
+		q += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	q += ";"
+	return q, args
+}
+
+func buildShippingTransitTimeSearchQuery(
+	query types.ShippingTransitTimeSearchQuery,
+	base string,
+) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if query.OriginZoneId != nil {
+		clauses = append(clauses, fmt.Sprintf("origin_zone_id = $%d", argsPos))
+		args = append(args, *query.OriginZoneId)
+		argsPos++
+	}
+
+	if query.DestinationZoneId != nil {
+		clauses = append(clauses, fmt.Sprintf("destination_zone_id = $%d", argsPos))
+		args = append(args, *query.DestinationZoneId)
+		argsPos++
+	}
+
+	q := base
+	if len(clauses) > 0 {
+		q += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	q += ";"
+	return q, args
+}
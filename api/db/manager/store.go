@@ -188,7 +188,7 @@ func (m *Manager) GetStoresWithSettings(
     s.id, s.name, s.description, s.verified,
     s.created_at, s.updated_at, s.owner_id,
 
-    t.id, t.public_owner, t.updated_at
+    t.id, t.public_owner, t.updated_at, t.handling_days
 
     FROM stores s LEFT JOIN stores_settings t ON s.id = t.store_id
   `
@@ -220,7 +220,7 @@ func (m *Manager) GetStoreWithSettingsById(id int) (*types.StoreWithSettings, er
     s.id, s.name, s.description, s.verified,
     s.created_at, s.updated_at, s.owner_id,
 
-    t.id, t.public_owner, t.updated_at
+    t.id, t.public_owner, t.updated_at, t.handling_days
 
     FROM stores s LEFT JOIN stores_settings t ON s.id = t.store_id WHERE s.id = $1;`,
 		id,
@@ -583,6 +583,16 @@ func (m *Manager) UpdateStoreSettings(storeId int, p types.UpdateStoreSettingsPa
 		argsPos++
 	}
 
+	if p.HandlingDays != nil {
+		if *p.HandlingDays < 0 {
+			return types.ErrInvalidStoreHandlingDays
+		}
+
+		clauses = append(clauses, fmt.Sprintf("handling_days = $%d", argsPos))
+		args = append(args, *p.HandlingDays)
+		argsPos++
+	}
+
 	if len(clauses) == 0 {
 		return types.ErrNoFieldsReceivedToUpdate
 	}
@@ -820,6 +830,7 @@ func scanStoreWithSettingsRow(rows *sql.Rows) (*types.StoreWithSettings, error)
 		&n.SettingsId,
 		&n.PublicOwner,
 		&n.SettingsUpdatedAt,
+		&n.HandlingDays,
 	)
 	if err != nil {
 		return nil, err
@@ -880,6 +891,7 @@ func scanStoreSettingsRow(rows *sql.Rows) (*types.StoreSettings, error) {
 		&n.PublicOwner,
 		&n.UpdatedAt,
 		&n.StoreId,
+		&n.HandlingDays,
 	)
 	if err != nil {
 		return nil, err
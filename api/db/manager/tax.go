@@ -0,0 +1,308 @@
+package db_manager
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+func (m *Manager) CreateTaxRule(p types.CreateTaxRulePayload) (int, error) {
+	if !p.PricingMode.IsValid() {
+		return -1, types.ErrInvalidTaxPricingModeEnum
+	}
+
+	if p.Rate < 0 || p.Rate > 1 {
+		return -1, types.ErrInvalidTaxRate
+	}
+
+	rowId := -1
+	err := m.db.QueryRow(
+		"INSERT INTO tax_rules (name, rate, pricing_mode, zone_id, category_id) VALUES ($1, $2, $3, $4, $5) RETURNING id;",
+		p.Name,
+		p.Rate,
+		p.PricingMode,
+		p.ZoneId,
+		p.CategoryId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		if isUniqueViolation(err, "tax_rules_zone_id_category_id_key") {
+			return -1, types.ErrDuplicateTaxRule
+		}
+
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) GetTaxRules(query types.TaxRuleSearchQuery) ([]types.TaxRule, error) {
+	var base string
+	base = "SELECT * FROM tax_rules"
+
+	q, args := buildTaxRuleSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []types.TaxRule{}
+
+	for rows.Next() {
+		rule, err := scanTaxRuleRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, *rule)
+	}
+
+	return rules, nil
+}
+
+func (m *Manager) GetTaxRulesCount(query types.TaxRuleSearchQuery) (int, error) {
+	var base string
+	base = "SELECT COUNT(*) as count FROM tax_rules"
+
+	q, args := buildTaxRuleSearchQuery(query, base)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		err := rows.Scan(&count)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return count, nil
+}
+
+func (m *Manager) GetTaxRuleById(id int) (*types.TaxRule, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM tax_rules WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rule := new(types.TaxRule)
+	rule.Id = -1
+
+	for rows.Next() {
+		rule, err = scanTaxRuleRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.Id == -1 {
+		return nil, types.ErrTaxRuleNotFound
+	}
+
+	return rule, nil
+}
+
+func (m *Manager) UpdateTaxRule(id int, p types.UpdateTaxRulePayload) error {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if p.Name != nil {
+		clauses = append(clauses, fmt.Sprintf("name = $%d", argsPos))
+		args = append(args, *p.Name)
+		argsPos++
+	}
+
+	if p.Rate != nil {
+		if *p.Rate < 0 || *p.Rate > 1 {
+			return types.ErrInvalidTaxRate
+		}
+
+		clauses = append(clauses, fmt.Sprintf("rate = $%d", argsPos))
+		args = append(args, *p.Rate)
+		argsPos++
+	}
+
+	if p.PricingMode != nil {
+		if !p.PricingMode.IsValid() {
+			return types.ErrInvalidTaxPricingModeEnum
+		}
+
+		clauses = append(clauses, fmt.Sprintf("pricing_mode = $%d", argsPos))
+		args = append(args, *p.PricingMode)
+		argsPos++
+	}
+
+	if len(clauses) == 0 {
+		return types.ErrNoFieldsReceivedToUpdate
+	}
+
+	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
+	args = append(args, time.Now())
+	argsPos++
+
+	args = append(args, id)
+	q := fmt.Sprintf(
+		"UPDATE tax_rules SET %s WHERE id = $%d",
+		strings.Join(clauses, ", "),
+		argsPos,
+	)
+
+	res, err := m.db.Exec(q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return types.ErrTaxRuleNotFound
+	}
+
+	return nil
+}
+
+func (m *Manager) DeleteTaxRule(id int) error {
+	_, err := m.db.Exec(
+		"DELETE FROM tax_rules WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyTaxAsDBTx sets the tax of the order lines for a receiver address in the
+// given state and city and returns the total tax of the order with the part
+// of it that is added on top of the prices. The tax of a line is computed on
+// its price after the coupon discount with the rule of the nearest category
+// of its product, where a category rule wins over a general rule and a city
+// zone wins over a state zone. Lines without any rule are not taxed.
+func applyTaxAsDBTx(
+	tx *sql.Tx,
+	state string,
+	city string,
+	lines []types.OrderProductVariantInsertData,
+) (totalTax types.Money, exclusiveTax types.Money, err error) {
+	for i := range lines {
+		var rate float64
+		var mode types.TaxPricingMode
+		err = tx.QueryRow(`
+			WITH RECURSIVE ancestors AS (
+				SELECT pc.id, pc.parent_category_id, 0 AS depth FROM product_categories pc
+				JOIN products p ON p.subcategory_id = pc.id
+				JOIN product_variants pv ON pv.product_id = p.id
+				WHERE pv.id = $1
+				UNION ALL
+				SELECT pc.id, pc.parent_category_id, a.depth + 1 FROM product_categories pc
+				JOIN ancestors a ON pc.id = a.parent_category_id
+			)
+			SELECT tr.rate, tr.pricing_mode FROM tax_rules tr
+			JOIN shipping_zones sz ON sz.id = tr.zone_id
+			LEFT JOIN ancestors a ON a.id = tr.category_id
+			WHERE LOWER(sz.state) = LOWER($2) AND (sz.city IS NULL OR LOWER(sz.city) = LOWER($3))
+			AND (tr.category_id IS NULL OR a.id IS NOT NULL)
+			ORDER BY a.depth ASC NULLS LAST, sz.city IS NOT NULL DESC
+			LIMIT 1;
+		`, lines[i].VariantId, state, city).
+			Scan(&rate, &mode)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return types.Money{}, types.Money{}, err
+		}
+
+		taxableAmount := lines[i].VariantPrice.Mul(lines[i].Quantity).Sub(lines[i].Discount)
+
+		switch mode {
+		case types.TaxPricingModeInclusive:
+			lines[i].Tax = taxableAmount.Sub(taxableAmount.MulRate(1 / (1 + rate)))
+		case types.TaxPricingModeExclusive:
+			lines[i].Tax = taxableAmount.MulRate(rate)
+			exclusiveTax = exclusiveTax.Add(lines[i].Tax)
+		}
+
+		lines[i].TaxRate = rate
+		lines[i].TaxPricingMode = &mode
+		totalTax = totalTax.Add(lines[i].Tax)
+	}
+
+	return totalTax, exclusiveTax, nil
+}
+
+func scanTaxRuleRow(rows *sql.Rows) (*types.TaxRule, error) {
+	n := new(types.TaxRule)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Name,
+		&n.Rate,
+		&n.PricingMode,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.ZoneId,
+		&n.CategoryId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func buildTaxRuleSearchQuery(query types.TaxRuleSearchQuery, base string) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if query.ZoneId != nil {
+		clauses = append(clauses, fmt.Sprintf("zone_id = $%d", argsPos))
+		args = append(args, *query.ZoneId)
+		argsPos++
+	}
+
+	if query.CategoryId != nil {
+		clauses = append(clauses, fmt.Sprintf("category_id = $%d", argsPos))
+		args = append(args, *query.CategoryId)
+		argsPos++
+	}
+
+	q := base
+	if len(clauses) > 0 {
+		q += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	q += ";"
+	return q, args
+}
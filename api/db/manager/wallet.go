@@ -1,14 +1,30 @@
 package db_manager
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/SaeedAlian/econest/api/config"
 	"github.com/SaeedAlian/econest/api/types"
 )
 
+// walletTransactionQuery selects the wallet transactions with the transfer
+// that they are part of and the user on the other side of the transfer.
+const walletTransactionQuery = `
+	SELECT wt.*, wtr.id, wtr.memo, cw.user_id, cu.username
+	FROM wallet_transactions wt
+	LEFT JOIN wallet_transfers wtr ON wt.id IN (wtr.sender_tx_id, wtr.receiver_tx_id)
+	LEFT JOIN wallet_transactions ct ON ct.id = CASE
+		WHEN wtr.sender_tx_id = wt.id THEN wtr.receiver_tx_id
+		ELSE wtr.sender_tx_id
+	END
+	LEFT JOIN wallets cw ON cw.id = ct.wallet_id
+	LEFT JOIN users cu ON cu.id = cw.user_id
+`
+
 func (m *Manager) CreateWalletTransaction(p types.CreateWalletTransactionPayload) (int, error) {
 	rowId := -1
 	err := m.db.QueryRow(
@@ -28,10 +44,27 @@ func (m *Manager) CreateWalletTransaction(p types.CreateWalletTransactionPayload
 func (m *Manager) GetWalletTransactions(
 	query types.WalletTransactionSearchQuery,
 ) ([]types.WalletTransaction, error) {
+	page, err := m.GetWalletTransactionsPage(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Items, nil
+}
+
+// GetWalletTransactionsPage returns the wallet transactions of the query, the
+// newest first, with the cursor of the next page if the page is full.
+func (m *Manager) GetWalletTransactionsPage(
+	query types.WalletTransactionSearchQuery,
+) (*types.Page[types.WalletTransaction], error) {
+	if query.Cursor != nil && query.Cursor.Sort != types.CursorSortNewest {
+		return nil, types.ErrInvalidCursor
+	}
+
 	var base string
-	base = "SELECT * FROM wallet_transactions"
+	base = walletTransactionQuery
 
-	q, args := buildWalletTransactionSearchQuery(query, base)
+	q, args := buildWalletTransactionSearchQuery(query, base, true)
 
 	rows, err := m.db.Query(q, args...)
 	if err != nil {
@@ -50,16 +83,22 @@ func (m *Manager) GetWalletTransactions(
 		txs = append(txs, *tx)
 	}
 
-	return txs, nil
+	page := types.Page[types.WalletTransaction]{Items: txs}
+	if query.Limit != nil && len(txs) > 0 && len(txs) == *query.Limit {
+		last := txs[len(txs)-1]
+		page.NextCursor = types.NewNewestCursor(last.CreatedAt, last.Id)
+	}
+
+	return &page, nil
 }
 
 func (m *Manager) GetWalletTransactionsCount(
 	query types.WalletTransactionSearchQuery,
 ) (int, error) {
 	var base string
-	base = "SELECT COUNT(*) as count FROM wallet_transactions"
+	base = "SELECT COUNT(*) as count FROM wallet_transactions wt"
 
-	q, args := buildWalletTransactionSearchQuery(query, base)
+	q, args := buildWalletTransactionSearchQuery(query, base, false)
 
 	rows, err := m.db.Query(q, args...)
 	if err != nil {
@@ -79,10 +118,7 @@ func (m *Manager) GetWalletTransactionsCount(
 }
 
 func (m *Manager) GetWalletTransactionById(id int) (*types.WalletTransaction, error) {
-	rows, err := m.db.Query(
-		"SELECT * FROM wallet_transactions WHERE id = $1;",
-		id,
-	)
+	rows, err := m.db.Query(walletTransactionQuery+" WHERE wt.id = $1;", id)
 	if err != nil {
 		return nil, err
 	}
@@ -106,10 +142,17 @@ func (m *Manager) GetWalletTransactionById(id int) (*types.WalletTransaction, er
 }
 
 func (m *Manager) GetUserWallet(userId int) (*types.Wallet, error) {
-	rows, err := m.db.Query(
-		"SELECT * FROM wallets WHERE user_id = $1;",
-		userId,
-	)
+	rows, err := m.db.Query(`
+		SELECT w.*, COALESCE((
+			SELECT SUM(eh.amount - eh.refunded_amount) FROM escrow_holds eh
+			WHERE eh.wallet_id = w.id AND eh.status = $2
+		), 0), EXISTS (
+			SELECT 1 FROM wallet_drifts wd
+			WHERE wd.wallet_id = w.id AND wd.frozen AND wd.resolved_at IS NULL
+		)
+		FROM wallets w
+		WHERE w.user_id = $1;
+	`, userId, types.EscrowHoldStatusHeld)
 	if err != nil {
 		return nil, err
 	}
@@ -132,41 +175,402 @@ func (m *Manager) GetUserWallet(userId int) (*types.Wallet, error) {
 	return wallet, nil
 }
 
-func (m *Manager) UpdateWallet(id int, p types.UpdateWalletPayload) error {
-	clauses := []string{}
-	args := []any{}
-	argsPos := 1
+// GetWalletLedgerEntries returns the journal entries that posted money to a
+// wallet, the latest entries come first.
+func (m *Manager) GetWalletLedgerEntries(
+	walletId int,
+	query types.WalletLedgerEntrySearchQuery,
+) ([]types.WalletLedgerEntry, error) {
+	base := `
+		SELECT
+			je.id, je.kind, je.description, SUM(lp.amount), je.created_at,
+			je.wallet_transaction_id, je.order_id, je.order_return_id, je.wallet_transfer_id
+		FROM ledger_postings lp
+		JOIN ledger_accounts la ON la.id = lp.account_id
+		JOIN journal_entries je ON je.id = lp.entry_id
+	`
 
-	if p.Balance != nil {
-		clauses = append(clauses, fmt.Sprintf("balance = $%d", argsPos))
-		args = append(args, *p.Balance)
-		argsPos++
+	q, args := buildWalletLedgerEntrySearchQuery(
+		walletId,
+		query,
+		base,
+		"GROUP BY je.id",
+		"ORDER BY je.created_at DESC, je.id DESC",
+	)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	if len(clauses) == 0 {
-		return types.ErrNoFieldsReceivedToUpdate
+	entries := []types.WalletLedgerEntry{}
+
+	for rows.Next() {
+		entry, err := scanWalletLedgerEntryRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, *entry)
 	}
 
-	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
-	args = append(args, time.Now())
-	argsPos++
+	return entries, nil
+}
 
-	args = append(args, id)
-	q := fmt.Sprintf(
-		"UPDATE wallets SET %s WHERE id = $%d",
-		strings.Join(clauses, ", "),
-		argsPos,
-	)
+func (m *Manager) GetWalletLedgerEntriesCount(
+	walletId int,
+	query types.WalletLedgerEntrySearchQuery,
+) (int, error) {
+	base := `
+		SELECT COUNT(DISTINCT je.id) as count
+		FROM ledger_postings lp
+		JOIN ledger_accounts la ON la.id = lp.account_id
+		JOIN journal_entries je ON je.id = lp.entry_id
+	`
+
+	q, args := buildWalletLedgerEntrySearchQuery(walletId, query, base, "", "")
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		err := rows.Scan(&count)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return count, nil
+}
+
+// GetWalletLedgerBalance returns the balance of a wallet derived from the
+// postings of its ledger account, which is the value that the cached
+// balance of the wallet must be equal to.
+func (m *Manager) GetWalletLedgerBalance(walletId int) (types.Money, error) {
+	balance := types.Money{}
+	err := m.db.QueryRow(`
+		SELECT COALESCE(SUM(lp.amount), 0) FROM ledger_accounts la
+		LEFT JOIN ledger_postings lp ON lp.account_id = la.id
+		WHERE la.wallet_id = $1
+		GROUP BY la.id;
+	`, walletId).
+		Scan(&balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Money{}, types.ErrLedgerAccountNotFound
+		}
+		return types.Money{}, err
+	}
+
+	return balance, nil
+}
+
+// GetWalletStatement returns the ledger entries of a wallet between from
+// (inclusive) and to (exclusive) with the balance of the wallet after each
+// entry. The opening balance and the entries are read in one snapshot, so
+// an entry posted while the statement is read cannot be counted twice or
+// missed.
+func (m *Manager) GetWalletStatement(
+	walletId int,
+	from time.Time,
+	to time.Time,
+) (*types.WalletStatement, error) {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statement := types.WalletStatement{
+		WalletId: walletId,
+		From:     from,
+		To:       to,
+		Lines:    []types.WalletStatementLine{},
+	}
+
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(lp.amount), 0) FROM ledger_accounts la
+		LEFT JOIN ledger_postings lp ON lp.account_id = la.id
+			AND EXISTS (
+				SELECT 1 FROM journal_entries je
+				WHERE je.id = lp.entry_id AND je.created_at < $2
+			)
+		WHERE la.wallet_id = $1
+		GROUP BY la.id;
+	`, walletId, from).
+		Scan(&statement.OpeningBalance)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, types.ErrLedgerAccountNotFound
+		}
+		return nil, err
+	}
 
-	_, err := m.db.Exec(q, args...)
+	rows, err := tx.Query(`
+		SELECT
+			je.id, je.kind, je.description, SUM(lp.amount), je.created_at,
+			je.wallet_transaction_id, je.order_id, je.order_return_id, je.wallet_transfer_id
+		FROM ledger_postings lp
+		JOIN ledger_accounts la ON la.id = lp.account_id
+		JOIN journal_entries je ON je.id = lp.entry_id
+		WHERE la.wallet_id = $1 AND je.created_at >= $2 AND je.created_at < $3
+		GROUP BY je.id
+		ORDER BY je.created_at ASC, je.id ASC;
+	`, walletId, from, to)
 	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	defer rows.Close()
+
+	balance := statement.OpeningBalance
+	for rows.Next() {
+		entry, err := scanWalletLedgerEntryRow(rows)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		balance = balance.Add(entry.Amount)
+		if entry.Amount.IsPositive() {
+			statement.TotalCredits = statement.TotalCredits.Add(entry.Amount)
+		} else {
+			statement.TotalDebits = statement.TotalDebits.Sub(entry.Amount)
+		}
+
+		statement.Lines = append(statement.Lines, types.WalletStatementLine{
+			WalletLedgerEntry: *entry,
+			Balance:           balance,
+		})
+	}
+
+	statement.ClosingBalance = balance
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return &statement, nil
+}
+
+// GetPlatformLedgerBalance returns the balance of the platform wallet derived
+// from the postings of the platform account.
+func (m *Manager) GetPlatformLedgerBalance() (types.Money, error) {
+	balance := types.Money{}
+	err := m.db.QueryRow(`
+		SELECT COALESCE(SUM(lp.amount), 0) FROM ledger_accounts la
+		LEFT JOIN ledger_postings lp ON lp.account_id = la.id
+		WHERE la.kind = $1
+		GROUP BY la.id;
+	`, types.LedgerAccountKindPlatform).
+		Scan(&balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.Money{}, types.ErrLedgerAccountNotFound
+		}
+		return types.Money{}, err
+	}
+
+	return balance, nil
+}
+
+// UpdateWalletTransaction updates a transaction of a wallet. When a pending
+// deposit or withdrawal becomes successful, its money is posted to the
+// ledger in the same tx.
+func (m *Manager) UpdateWalletTransaction(
+	walletId int,
+	transactionId int,
+	p types.UpdateWalletTransactionPayload,
+) error {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = updateWalletTransactionAsDBTx(tx, walletId, transactionId, p)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
 		return err
 	}
 
 	return nil
 }
 
-func (m *Manager) UpdateWalletTransaction(
+func (m *Manager) DeleteWalletTransaction(walletId int, transactionId int) error {
+	_, err := m.db.Exec(
+		"DELETE FROM wallet_transactions WHERE id = $1 AND wallet_id = $2;",
+		transactionId, walletId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateWalletTransfer moves money from one wallet to another. The sender is
+// debited by an outgoing transaction and the receiver is credited by an
+// incoming one, both are linked by the transfer and posted to the ledger in
+// the same tx. The transfer must stay in the transfer limits of the sender.
+func (m *Manager) CreateWalletTransfer(d types.WalletTransferInsertData) (int, error) {
+	if d.SenderWalletId == d.ReceiverWalletId {
+		return -1, types.ErrCannotTransferToSameWallet
+	}
+
+	if !d.Amount.IsPositive() {
+		return -1, types.ErrInvalidMoneyAmount
+	}
+
+	maxAmount := types.MoneyFromFloat(config.Env.TransferMaxAmount)
+	if maxAmount.IsPositive() && d.Amount.GreaterThan(maxAmount) {
+		return -1, types.ErrTransferAmountLimitExceeded(maxAmount)
+	}
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	// the wallets are locked in the order of their ids, so two opposite
+	// transfers between the same wallets cannot deadlock
+	accountIds := map[int]int{}
+	balances := map[int]types.Money{}
+	for _, walletId := range []int{
+		min(d.SenderWalletId, d.ReceiverWalletId),
+		max(d.SenderWalletId, d.ReceiverWalletId),
+	} {
+		accountId, balance, err := lockWalletLedgerAccountAsDBTx(tx, walletId)
+		if err != nil {
+			tx.Rollback()
+			if err == types.ErrLedgerAccountNotFound {
+				return -1, types.ErrWalletNotFound
+			}
+			return -1, err
+		}
+
+		accountIds[walletId] = accountId
+		balances[walletId] = balance
+	}
+
+	err = ensureWalletNotFrozenAsDBTx(tx, d.SenderWalletId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	// the sender wallet is locked, so the transfers of the day cannot change
+	// until the end of the tx
+	dailyCount := 0
+	dailyAmount := types.Money{}
+	err = tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(amount), 0) FROM wallet_transactions
+		WHERE wallet_id = $1 AND tx_type = $2 AND status = $3 AND created_at > $4;
+	`,
+		d.SenderWalletId,
+		types.TransactionTypeTransferOut,
+		types.TransactionStatusSuccessful,
+		time.Now().Add(-24*time.Hour),
+	).
+		Scan(&dailyCount, &dailyAmount)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	dailyCountLimit := int(config.Env.TransferDailyCount)
+	if dailyCountLimit > 0 && dailyCount >= dailyCountLimit {
+		tx.Rollback()
+		return -1, types.ErrTransferDailyCountExceeded(dailyCountLimit)
+	}
+
+	dailyLimit := types.MoneyFromFloat(config.Env.TransferDailyLimit)
+	if dailyLimit.IsPositive() && dailyAmount.Add(d.Amount).GreaterThan(dailyLimit) {
+		tx.Rollback()
+		return -1, types.ErrTransferDailyLimitExceeded(dailyLimit)
+	}
+
+	if balances[d.SenderWalletId].LessThan(d.Amount) {
+		tx.Rollback()
+		return -1, types.ErrBalanceInsufficient
+	}
+
+	senderTxId, err := createSuccessfulWalletTransactionAsDBTx(tx, types.CreateWalletTransactionPayload{
+		Amount:   d.Amount,
+		TxType:   types.TransactionTypeTransferOut,
+		WalletId: d.SenderWalletId,
+	})
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	receiverTxId, err := createSuccessfulWalletTransactionAsDBTx(tx, types.CreateWalletTransactionPayload{
+		Amount:   d.Amount,
+		TxType:   types.TransactionTypeTransferIn,
+		WalletId: d.ReceiverWalletId,
+	})
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	rowId := -1
+	err = tx.QueryRow(
+		"INSERT INTO wallet_transfers (amount, memo, sender_tx_id, receiver_tx_id) VALUES ($1, $2, $3, $4) RETURNING id;",
+		d.Amount,
+		d.Memo,
+		senderTxId,
+		receiverTxId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	_, err = postJournalEntryAsDBTx(tx, types.JournalEntryInsertData{
+		Kind:             types.JournalEntryKindTransfer,
+		Description:      fmt.Sprintf("Transfer #%d", rowId),
+		WalletTransferId: &rowId,
+		Postings: []types.LedgerPostingInsertData{
+			{AccountId: accountIds[d.SenderWalletId], Amount: d.Amount.Neg()},
+			{AccountId: accountIds[d.ReceiverWalletId], Amount: d.Amount},
+		},
+	})
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+// updateWalletTransactionAsDBTx updates a wallet transaction and posts its
+// money to the ledger when it succeeds.
+func updateWalletTransactionAsDBTx(
+	tx *sql.Tx,
 	walletId int,
 	transactionId int,
 	p types.UpdateWalletTransactionPayload,
@@ -185,6 +589,31 @@ func (m *Manager) UpdateWalletTransaction(
 		return types.ErrNoFieldsReceivedToUpdate
 	}
 
+	rows, err := tx.Query(
+		walletTransactionQuery+" WHERE wt.wallet_id = $1 AND wt.id = $2 FOR UPDATE OF wt;",
+		walletId,
+		transactionId,
+	)
+	if err != nil {
+		return err
+	}
+
+	walletTx := new(types.WalletTransaction)
+	walletTx.Id = -1
+
+	for rows.Next() {
+		walletTx, err = scanWalletTransactionRow(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+
+	if walletTx.Id == -1 {
+		return types.ErrWalletTransactionNotFound
+	}
+
 	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
 	args = append(args, time.Now())
 	argsPos++
@@ -198,23 +627,302 @@ func (m *Manager) UpdateWalletTransaction(
 		argsPos+1,
 	)
 
-	_, err := m.db.Exec(q, args...)
+	_, err = tx.Exec(q, args...)
 	if err != nil {
 		return err
 	}
 
+	if p.Status != nil && *p.Status == types.TransactionStatusSuccessful &&
+		walletTx.Status == types.TransactionStatusPending {
+		err = postWalletTransactionAsDBTx(tx, walletTx)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (m *Manager) DeleteWalletTransaction(walletId int, transactionId int) error {
-	_, err := m.db.Exec(
-		"DELETE FROM wallet_transactions WHERE id = $1 AND wallet_id = $2;",
-		transactionId, walletId,
+// createSuccessfulWalletTransactionAsDBTx inserts a transaction that is
+// finalized right away, its money must be posted to the ledger by the caller
+// in the same tx.
+func createSuccessfulWalletTransactionAsDBTx(
+	tx *sql.Tx,
+	p types.CreateWalletTransactionPayload,
+) (int, error) {
+	rowId := -1
+	err := tx.QueryRow(
+		"INSERT INTO wallet_transactions (amount, tx_type, wallet_id) VALUES ($1, $2, $3) RETURNING id;",
+		p.Amount,
+		p.TxType,
+		p.WalletId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		return -1, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE wallet_transactions SET status = $1, updated_at = $2 WHERE id = $3;",
+		types.TransactionStatusSuccessful,
+		time.Now(),
+		rowId,
 	)
+	if err != nil {
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+// postJournalEntryAsDBTx appends a journal entry and its postings to the
+// ledger. The postings without an amount are left out and nothing is posted
+// if none remains, the remaining postings must add up to zero. The balances
+// of the wallets are updated by the postings trigger.
+func postJournalEntryAsDBTx(tx *sql.Tx, d types.JournalEntryInsertData) (int, error) {
+	postings := []types.LedgerPostingInsertData{}
+	sum := types.Money{}
+	for _, p := range d.Postings {
+		if p.Amount.IsZero() {
+			continue
+		}
+
+		postings = append(postings, p)
+		sum = sum.Add(p.Amount)
+	}
+
+	if len(postings) == 0 {
+		return -1, nil
+	}
+
+	if len(postings) < 2 || !sum.IsZero() {
+		return -1, types.ErrUnbalancedJournalEntry
+	}
+
+	rowId := -1
+	err := tx.QueryRow(
+		"INSERT INTO journal_entries (kind, description, wallet_transaction_id, order_id, order_return_id, wallet_transfer_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;",
+		d.Kind,
+		d.Description,
+		d.WalletTransactionId,
+		d.OrderId,
+		d.OrderReturnId,
+		d.WalletTransferId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, p := range postings {
+		_, err = tx.Exec(
+			"INSERT INTO ledger_postings (amount, entry_id, account_id) VALUES ($1, $2, $3);",
+			p.Amount,
+			rowId,
+			p.AccountId,
+		)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return rowId, nil
+}
+
+// lockWalletLedgerAccountAsDBTx locks a wallet until the end of the tx and
+// returns the id of its ledger account with its current balance.
+func lockWalletLedgerAccountAsDBTx(tx *sql.Tx, walletId int) (int, types.Money, error) {
+	accountId := -1
+	balance := types.Money{}
+	err := tx.QueryRow(`
+		SELECT la.id, w.balance FROM wallets w
+		JOIN ledger_accounts la ON la.wallet_id = w.id
+		WHERE w.id = $1
+		FOR UPDATE OF w;
+	`, walletId).
+		Scan(&accountId, &balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, types.Money{}, types.ErrLedgerAccountNotFound
+		}
+		return -1, types.Money{}, err
+	}
+
+	return accountId, balance, nil
+}
+
+func getLedgerAccountIdByKindAsDBTx(tx *sql.Tx, kind types.LedgerAccountKind) (int, error) {
+	accountId := -1
+	err := tx.QueryRow("SELECT id FROM ledger_accounts WHERE kind = $1;", kind).
+		Scan(&accountId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, types.ErrLedgerAccountNotFound
+		}
+		return -1, err
+	}
+
+	return accountId, nil
+}
+
+// postWalletTransactionAsDBTx posts the money of a successful deposit or
+// withdrawal between the wallet and the external account.
+func postWalletTransactionAsDBTx(tx *sql.Tx, walletTx *types.WalletTransaction) error {
+	walletAccountId, balance, err := lockWalletLedgerAccountAsDBTx(tx, walletTx.WalletId)
+	if err != nil {
+		return err
+	}
+
+	externalAccountId, err := getLedgerAccountIdByKindAsDBTx(tx, types.LedgerAccountKindExternal)
+	if err != nil {
+		return err
+	}
+
+	entry := types.JournalEntryInsertData{
+		WalletTransactionId: &walletTx.Id,
+	}
+
+	switch walletTx.TxType {
+	case types.TransactionTypeDeposit:
+		entry.Kind = types.JournalEntryKindDeposit
+		entry.Description = fmt.Sprintf("Deposit #%d", walletTx.Id)
+		entry.Postings = []types.LedgerPostingInsertData{
+			{AccountId: walletAccountId, Amount: walletTx.Amount},
+			{AccountId: externalAccountId, Amount: walletTx.Amount.Neg()},
+		}
+	case types.TransactionTypeWithdraw:
+		if balance.LessThan(walletTx.Amount) {
+			return types.ErrBalanceInsufficient
+		}
+
+		err = ensureWalletNotFrozenAsDBTx(tx, walletTx.WalletId)
+		if err != nil {
+			return err
+		}
+
+		entry.Kind = types.JournalEntryKindWithdrawal
+		entry.Description = fmt.Sprintf("Withdrawal #%d", walletTx.Id)
+		entry.Postings = []types.LedgerPostingInsertData{
+			{AccountId: walletAccountId, Amount: walletTx.Amount.Neg()},
+			{AccountId: externalAccountId, Amount: walletTx.Amount},
+		}
+	default:
+		return types.ErrInvalidTransactionTypeEnum
+	}
+
+	_, err = postJournalEntryAsDBTx(tx, entry)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// postOrderPaymentAsDBTx posts the payment of an order. The customer pays the
+// total of the order and the fee goes to the platform. The earnings of each
+// store, which are the lines of the store with the shipping and the exclusive
+// tax, are held in escrow until they are released to the store owner.
+func postOrderPaymentAsDBTx(tx *sql.Tx, orderId int) error {
+	customerWalletId := -1
+	total := types.Money{}
+	fee := types.Money{}
+	err := tx.QueryRow(`
+		SELECT
+			w.id,
+			op.total_variants_price + op.total_shipment_price + op.fee - op.discount + op.exclusive_tax,
+			op.fee
+		FROM order_payments op
+		JOIN orders o ON o.id = op.order_id
+		JOIN wallets w ON w.user_id = o.user_id
+		WHERE op.order_id = $1;
+	`, orderId).
+		Scan(&customerWalletId, &total, &fee)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.ErrWalletNotFound
+		}
+		return err
+	}
+
+	customerAccountId, balance, err := lockWalletLedgerAccountAsDBTx(tx, customerWalletId)
+	if err != nil {
+		return err
+	}
+
+	if balance.LessThan(total) {
+		return types.ErrBalanceInsufficient
+	}
+
+	platformAccountId, err := getLedgerAccountIdByKindAsDBTx(tx, types.LedgerAccountKindPlatform)
+	if err != nil {
+		return err
+	}
+
+	escrowAccountId, err := getLedgerAccountIdByKindAsDBTx(tx, types.LedgerAccountKindEscrow)
+	if err != nil {
+		return err
+	}
+
+	// the exclusive tax is paid on top of the price and is collected by the
+	// store, the inclusive tax is already part of the variant price
+	rows, err := tx.Query(`
+		SELECT opv.store_id, w.id, SUM(
+			opv.quantity * opv.variant_price + opv.shipping_price - opv.discount +
+			CASE WHEN opv.tax_pricing_mode = 'exclusive' THEN opv.tax ELSE 0 END
+		)
+		FROM order_product_variants opv
+		JOIN stores s ON s.id = opv.store_id
+		JOIN wallets w ON w.user_id = s.owner_id
+		WHERE opv.order_id = $1
+		GROUP BY opv.store_id, w.id
+		ORDER BY opv.store_id;
+	`, orderId)
 	if err != nil {
 		return err
 	}
 
+	holds := []types.EscrowHoldInsertData{}
+	held := types.Money{}
+	for rows.Next() {
+		hold := types.EscrowHoldInsertData{OrderId: orderId}
+		err = rows.Scan(&hold.StoreId, &hold.WalletId, &hold.Amount)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		if !hold.Amount.IsPositive() {
+			continue
+		}
+
+		holds = append(holds, hold)
+		held = held.Add(hold.Amount)
+	}
+	rows.Close()
+
+	_, err = postJournalEntryAsDBTx(tx, types.JournalEntryInsertData{
+		Kind:        types.JournalEntryKindOrderPayment,
+		Description: fmt.Sprintf("Payment of order #%d", orderId),
+		OrderId:     &orderId,
+		Postings: []types.LedgerPostingInsertData{
+			{AccountId: customerAccountId, Amount: total.Neg()},
+			{AccountId: platformAccountId, Amount: fee},
+			{AccountId: escrowAccountId, Amount: held},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	releaseAt := time.Now().AddDate(0, 0, int(config.Env.EscrowAutoReleaseInDays))
+	for _, hold := range holds {
+		hold.ReleaseAt = releaseAt
+		err = createEscrowHoldAsDBTx(tx, hold)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -227,6 +935,29 @@ func scanWalletRow(rows *sql.Rows) (*types.Wallet, error) {
 		&n.CreatedAt,
 		&n.UpdatedAt,
 		&n.UserId,
+		&n.PendingBalance,
+		&n.IsFrozen,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func scanWalletLedgerEntryRow(rows *sql.Rows) (*types.WalletLedgerEntry, error) {
+	n := new(types.WalletLedgerEntry)
+
+	err := rows.Scan(
+		&n.EntryId,
+		&n.Kind,
+		&n.Description,
+		&n.Amount,
+		&n.CreatedAt,
+		&n.WalletTransactionId,
+		&n.OrderId,
+		&n.OrderReturnId,
+		&n.WalletTransferId,
 	)
 	if err != nil {
 		return nil, err
@@ -246,6 +977,10 @@ func scanWalletTransactionRow(rows *sql.Rows) (*types.WalletTransaction, error)
 		&n.CreatedAt,
 		&n.UpdatedAt,
 		&n.WalletId,
+		&n.TransferId,
+		&n.Memo,
+		&n.CounterpartyUserId,
+		&n.CounterpartyUsername,
 	)
 	if err != nil {
 		return nil, err
@@ -254,34 +989,38 @@ func scanWalletTransactionRow(rows *sql.Rows) (*types.WalletTransaction, error)
 	return n, nil
 }
 
+// buildWalletTransactionSearchQuery builds the wallet transaction search on
+// top of base. If sorted is set, the newest transactions come first and the
+// transactions start after the cursor of the query.
 func buildWalletTransactionSearchQuery(
 	query types.WalletTransactionSearchQuery,
 	base string,
+	sorted bool,
 ) (string, []any) {
 	clauses := []string{}
 	args := []any{}
 	argsPos := 1
 
 	if query.TxType != nil {
-		clauses = append(clauses, fmt.Sprintf("tx_type = $%d", argsPos))
+		clauses = append(clauses, fmt.Sprintf("wt.tx_type = $%d", argsPos))
 		args = append(args, *query.TxType)
 		argsPos++
 	}
 
 	if query.Status != nil {
-		clauses = append(clauses, fmt.Sprintf("status = $%d", argsPos))
+		clauses = append(clauses, fmt.Sprintf("wt.status = $%d", argsPos))
 		args = append(args, *query.Status)
 		argsPos++
 	}
 
 	if query.BeforeDate != nil {
-		clauses = append(clauses, fmt.Sprintf("created_at <= $%d", argsPos))
+		clauses = append(clauses, fmt.Sprintf("wt.created_at <= $%d", argsPos))
 		args = append(args, *query.BeforeDate)
 		argsPos++
 	}
 
 	if query.AfterDate != nil {
-		clauses = append(clauses, fmt.Sprintf("created_at >= $%d", argsPos))
+		clauses = append(clauses, fmt.Sprintf("wt.created_at >= $%d", argsPos))
 		args = append(args, *query.AfterDate)
 		argsPos++
 	}
@@ -289,17 +1028,86 @@ func buildWalletTransactionSearchQuery(
 	if query.UserId != nil {
 		clauses = append(
 			clauses,
-			fmt.Sprintf("wallet_id IN (SELECT id FROM wallets WHERE user_id = $%d)", argsPos),
+			fmt.Sprintf("wt.wallet_id IN (SELECT id FROM wallets WHERE user_id = $%d)", argsPos),
 		)
 		args = append(args, *query.UserId)
 		argsPos++
 	}
 
+	if sorted && query.Cursor != nil {
+		clauses = append(clauses, fmt.Sprintf(
+			"(wt.created_at, wt.id) < ($%d::timestamp, $%d)",
+			argsPos,
+			argsPos+1,
+		))
+		args = append(args, query.Cursor.Key, query.Cursor.Id)
+		argsPos += 2
+	}
+
 	q := base
 	if len(clauses) > 0 {
 		q += " WHERE " + strings.Join(clauses, " AND ")
 	}
 
+	if sorted {
+		q += " ORDER BY wt.created_at DESC, wt.id DESC"
+	}
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	q += ";"
+	return q, args
+}
+
+func buildWalletLedgerEntrySearchQuery(
+	walletId int,
+	query types.WalletLedgerEntrySearchQuery,
+	base string,
+	groupBy string,
+	orderBy string,
+) (string, []any) {
+	clauses := []string{"la.wallet_id = $1"}
+	args := []any{walletId}
+	argsPos := 2
+
+	if query.Kind != nil {
+		clauses = append(clauses, fmt.Sprintf("je.kind = $%d", argsPos))
+		args = append(args, *query.Kind)
+		argsPos++
+	}
+
+	if query.BeforeDate != nil {
+		clauses = append(clauses, fmt.Sprintf("je.created_at <= $%d", argsPos))
+		args = append(args, *query.BeforeDate)
+		argsPos++
+	}
+
+	if query.AfterDate != nil {
+		clauses = append(clauses, fmt.Sprintf("je.created_at >= $%d", argsPos))
+		args = append(args, *query.AfterDate)
+		argsPos++
+	}
+
+	q := base + " WHERE " + strings.Join(clauses, " AND ")
+
+	if groupBy != "" {
+		q += " " + groupBy
+	}
+
+	if orderBy != "" {
+		q += " " + orderBy
+	}
+
 	if query.Offset != nil {
 		q += fmt.Sprintf(" OFFSET $%d", argsPos)
 		args = append(args, *query.Offset)
@@ -0,0 +1,418 @@
+package db_manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+// walletDriftQuery selects the wallet drifts with the owners of their wallets.
+const walletDriftQuery = `
+	SELECT
+		wd.id, wd.cached_balance, wd.ledger_balance, wd.expected_balance, wd.frozen,
+		wd.note, wd.detected_at, wd.checked_at, wd.resolved_at, wd.wallet_id,
+		w.user_id, wd.resolved_by
+	FROM wallet_drifts wd
+	JOIN wallets w ON w.id = wd.wallet_id
+`
+
+// walletBalancesQuery selects the cached balance, the ledger balance and the
+// expected balance of each wallet. The expected balance is recomputed from
+// the history of the wallet, which is its successful transactions, the
+// successful payments of the orders of its owner and the earnings of the
+// stores of its owner, which were paid directly before the escrow was
+// started and through the released escrow holds after that. The history
+// before the ledger is only known through the opening balances, so the
+// records that were settled before the ledger was started are left to them.
+// The ledger and the escrow are started when their accounts are created.
+const walletBalancesQuery = `
+	WITH ledger AS (
+		SELECT
+			(SELECT created_at FROM ledger_accounts WHERE kind = $6) AS started_at,
+			(SELECT created_at FROM ledger_accounts WHERE kind = $7) AS escrow_started_at
+	)
+	SELECT
+		w.id,
+		w.balance,
+		COALESCE((
+			SELECT SUM(lp.amount) FROM ledger_postings lp
+			WHERE lp.account_id = la.id
+		), 0),
+		COALESCE((
+			SELECT SUM(lp.amount) FROM ledger_postings lp
+			JOIN journal_entries je ON je.id = lp.entry_id
+			WHERE lp.account_id = la.id AND je.kind = $1
+		), 0) + COALESCE((
+			SELECT SUM(CASE WHEN wt.tx_type = ANY($2) THEN wt.amount ELSE -wt.amount END)
+			FROM wallet_transactions wt
+			WHERE wt.wallet_id = w.id AND wt.status = $3 AND wt.updated_at >= ledger.started_at
+		), 0) - COALESCE((
+			SELECT SUM(
+				op.total_variants_price + op.total_shipment_price + op.fee - op.discount +
+				op.exclusive_tax
+			)
+			FROM order_payments op
+			JOIN orders o ON o.id = op.order_id
+			WHERE o.user_id = w.user_id AND op.status = $4 AND op.updated_at >= ledger.started_at
+		), 0) + COALESCE((
+			SELECT SUM(
+				opv.quantity * opv.variant_price + opv.shipping_price - opv.discount +
+				CASE WHEN opv.tax_pricing_mode = 'exclusive' THEN opv.tax ELSE 0 END
+			)
+			FROM order_product_variants opv
+			JOIN stores s ON s.id = opv.store_id
+			JOIN order_payments op ON op.order_id = opv.order_id
+			WHERE s.owner_id = w.user_id AND op.status = $4
+				AND op.updated_at >= ledger.started_at
+				AND op.updated_at < ledger.escrow_started_at
+		), 0) + COALESCE((
+			SELECT SUM(eh.amount - eh.refunded_amount) FROM escrow_holds eh
+			WHERE eh.wallet_id = w.id AND eh.status = $5
+		), 0)
+	FROM wallets w
+	JOIN ledger_accounts la ON la.wallet_id = w.id
+	CROSS JOIN ledger
+	ORDER BY w.id;
+`
+
+// ReconcileWallets compares the balance of each wallet with the balance
+// expected from its history and opens a drift for every wallet that does not
+// match, or updates the drift that is already open for it. If freeze is set,
+// the drifted wallets are frozen until their drifts are resolved. The
+// balances are read in one snapshot, so a posting made while the wallets are
+// checked cannot show up as a drift.
+func (m *Manager) ReconcileWallets(freeze bool) (*types.WalletReconciliationReport, error) {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+
+	report := types.WalletReconciliationReport{
+		Drifts: []types.WalletDrift{},
+		RanAt:  time.Now(),
+	}
+
+	rows, err := tx.Query(
+		walletBalancesQuery,
+		types.JournalEntryKindOpeningBalance,
+		pq.Array([]string{
+			types.TransactionTypeDeposit.String(),
+			types.TransactionTypeRefund.String(),
+			types.TransactionTypeTransferIn.String(),
+		}),
+		types.TransactionStatusSuccessful,
+		types.OrderPaymentStatusSuccessful,
+		types.EscrowHoldStatusReleased,
+		types.LedgerAccountKindExternal,
+		types.LedgerAccountKindEscrow,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	drifted := []types.WalletDrift{}
+	for rows.Next() {
+		d := types.WalletDrift{}
+		err = rows.Scan(&d.WalletId, &d.CachedBalance, &d.LedgerBalance, &d.ExpectedBalance)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, err
+		}
+
+		report.CheckedWallets++
+
+		if d.CachedBalance == d.ExpectedBalance && d.LedgerBalance == d.ExpectedBalance {
+			continue
+		}
+
+		drifted = append(drifted, d)
+	}
+	rows.Close()
+
+	driftIds := []int{}
+	for _, d := range drifted {
+		id := -1
+		err = tx.QueryRow(`
+			INSERT INTO wallet_drifts
+			(cached_balance, ledger_balance, expected_balance, frozen, wallet_id)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (wallet_id) WHERE resolved_at IS NULL DO UPDATE SET
+				cached_balance = EXCLUDED.cached_balance,
+				ledger_balance = EXCLUDED.ledger_balance,
+				expected_balance = EXCLUDED.expected_balance,
+				frozen = wallet_drifts.frozen OR EXCLUDED.frozen,
+				checked_at = $6
+			RETURNING id;
+		`,
+			d.CachedBalance,
+			d.LedgerBalance,
+			d.ExpectedBalance,
+			freeze,
+			d.WalletId,
+			report.RanAt,
+		).
+			Scan(&id)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		driftIds = append(driftIds, id)
+	}
+
+	if len(driftIds) > 0 {
+		rows, err = tx.Query(
+			walletDriftQuery+" WHERE wd.id = ANY($1) ORDER BY wd.wallet_id;",
+			pq.Array(driftIds),
+		)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		for rows.Next() {
+			drift, err := scanWalletDriftRow(rows)
+			if err != nil {
+				rows.Close()
+				tx.Rollback()
+				return nil, err
+			}
+
+			if drift.Frozen {
+				report.FrozenWallets++
+			}
+
+			report.Drifts = append(report.Drifts, *drift)
+		}
+		rows.Close()
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (m *Manager) GetWalletDrifts(query types.WalletDriftSearchQuery) ([]types.WalletDrift, error) {
+	q, args := buildWalletDriftSearchQuery(query, walletDriftQuery, "ORDER BY wd.detected_at DESC")
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drifts := []types.WalletDrift{}
+
+	for rows.Next() {
+		drift, err := scanWalletDriftRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		drifts = append(drifts, *drift)
+	}
+
+	return drifts, nil
+}
+
+func (m *Manager) GetWalletDriftsCount(query types.WalletDriftSearchQuery) (int, error) {
+	q, args := buildWalletDriftSearchQuery(query, `
+		SELECT COUNT(*) as count FROM wallet_drifts wd
+		JOIN wallets w ON w.id = wd.wallet_id
+	`, "")
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return -1, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		err := rows.Scan(&count)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return count, nil
+}
+
+func (m *Manager) GetWalletDriftById(id int) (*types.WalletDrift, error) {
+	rows, err := m.db.Query(walletDriftQuery+" WHERE wd.id = $1;", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drift := new(types.WalletDrift)
+	drift.Id = -1
+
+	for rows.Next() {
+		drift, err = scanWalletDriftRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if drift.Id == -1 {
+		return nil, types.ErrWalletDriftNotFound
+	}
+
+	return drift, nil
+}
+
+// ResolveWalletDrift closes an open drift with the note of the admin who
+// reviewed it, which unfreezes its wallet. If the wallet still does not
+// match its history, the next reconciliation opens a new drift for it.
+func (m *Manager) ResolveWalletDrift(
+	id int,
+	resolverId int,
+	p types.ResolveWalletDriftPayload,
+) error {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var resolvedAt sql.NullTime
+	err = tx.QueryRow("SELECT resolved_at FROM wallet_drifts WHERE id = $1 FOR UPDATE;", id).
+		Scan(&resolvedAt)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return types.ErrWalletDriftNotFound
+		}
+		return err
+	}
+
+	if resolvedAt.Valid {
+		tx.Rollback()
+		return types.ErrWalletDriftAlreadyResolved
+	}
+
+	_, err = tx.Exec(
+		"UPDATE wallet_drifts SET resolved_at = $1, resolved_by = $2, note = $3 WHERE id = $4;",
+		time.Now(),
+		resolverId,
+		p.Note,
+		id,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+// ensureWalletNotFrozenAsDBTx returns ErrWalletFrozen if the wallet has an
+// open drift that froze it.
+func ensureWalletNotFrozenAsDBTx(tx *sql.Tx, walletId int) error {
+	frozen := false
+	err := tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM wallet_drifts
+			WHERE wallet_id = $1 AND frozen AND resolved_at IS NULL
+		);
+	`, walletId).
+		Scan(&frozen)
+	if err != nil {
+		return err
+	}
+
+	if frozen {
+		return types.ErrWalletFrozen
+	}
+
+	return nil
+}
+
+func scanWalletDriftRow(rows *sql.Rows) (*types.WalletDrift, error) {
+	n := new(types.WalletDrift)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.CachedBalance,
+		&n.LedgerBalance,
+		&n.ExpectedBalance,
+		&n.Frozen,
+		&n.Note,
+		&n.DetectedAt,
+		&n.CheckedAt,
+		&n.ResolvedAt,
+		&n.WalletId,
+		&n.UserId,
+		&n.ResolvedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func buildWalletDriftSearchQuery(
+	query types.WalletDriftSearchQuery,
+	base string,
+	orderBy string,
+) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if query.Resolved != nil {
+		if *query.Resolved {
+			clauses = append(clauses, "wd.resolved_at IS NOT NULL")
+		} else {
+			clauses = append(clauses, "wd.resolved_at IS NULL")
+		}
+	}
+
+	if query.UserId != nil {
+		clauses = append(clauses, fmt.Sprintf("w.user_id = $%d", argsPos))
+		args = append(args, *query.UserId)
+		argsPos++
+	}
+
+	q := base
+	if len(clauses) > 0 {
+		q += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	if orderBy != "" {
+		q += " " + orderBy
+	}
+
+	if query.Offset != nil {
+		q += fmt.Sprintf(" OFFSET $%d", argsPos)
+		args = append(args, *query.Offset)
+		argsPos++
+	}
+
+	if query.Limit != nil {
+		q += fmt.Sprintf(" LIMIT $%d", argsPos)
+		args = append(args, *query.Limit)
+		argsPos++
+	}
+
+	return q, args
+}
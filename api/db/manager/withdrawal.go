@@ -0,0 +1,616 @@
+package db_manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+// withdrawalPolicyQuery selects the withdrawal tier of the user whose id is
+// passed as the first argument with the withdrawal profile of the user. The
+// tier that is set for the user alone wins over the tier of the role of the
+// user, the default tier applies when there is neither.
+const withdrawalPolicyQuery = `
+	SELECT t.*, COALESCE(p.kyc_verified, FALSE), p.credentials_changed_at
+	FROM users u
+	LEFT JOIN user_withdrawal_profiles p ON p.user_id = u.id
+	LEFT JOIN role_withdrawal_tiers rt ON rt.role_id = u.role_id
+	JOIN withdrawal_tiers t ON t.id = COALESCE(
+		p.tier_id,
+		rt.tier_id,
+		(SELECT id FROM withdrawal_tiers WHERE is_default)
+	)
+	WHERE u.id = $1;
+`
+
+func (m *Manager) CreateWithdrawalTier(p types.CreateWithdrawalTierPayload) (int, error) {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	if p.IsDefault {
+		err = unsetDefaultWithdrawalTierAsDBTx(tx)
+		if err != nil {
+			tx.Rollback()
+			return -1, err
+		}
+	}
+
+	rowId := -1
+	err = tx.QueryRow(
+		`INSERT INTO withdrawal_tiers (
+			name, min_amount, daily_limit, monthly_limit,
+			kyc_daily_limit, kyc_monthly_limit, cooldown_in_hours, is_default
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;`,
+		p.Name,
+		p.MinAmount,
+		p.DailyLimit,
+		p.MonthlyLimit,
+		p.KycDailyLimit,
+		p.KycMonthlyLimit,
+		p.CooldownInHours,
+		p.IsDefault,
+	).
+		Scan(&rowId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) GetWithdrawalTiers() ([]types.WithdrawalTier, error) {
+	rows, err := m.db.Query("SELECT * FROM withdrawal_tiers ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tiers := []types.WithdrawalTier{}
+
+	for rows.Next() {
+		tier, err := scanWithdrawalTierRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tiers = append(tiers, *tier)
+	}
+
+	return tiers, nil
+}
+
+func (m *Manager) GetWithdrawalTierById(id int) (*types.WithdrawalTier, error) {
+	rows, err := m.db.Query(
+		"SELECT * FROM withdrawal_tiers WHERE id = $1;",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tier := new(types.WithdrawalTier)
+	tier.Id = -1
+
+	for rows.Next() {
+		tier, err = scanWithdrawalTierRow(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tier.Id == -1 {
+		return nil, types.ErrWithdrawalTierNotFound
+	}
+
+	return tier, nil
+}
+
+// GetUserWithdrawalProfile returns the withdrawal settings of a user, a user
+// that has none yet gets the settings that apply to all users.
+func (m *Manager) GetUserWithdrawalProfile(userId int) (*types.UserWithdrawalProfile, error) {
+	profile := new(types.UserWithdrawalProfile)
+	err := m.db.QueryRow(`
+		SELECT u.id, COALESCE(p.kyc_verified, FALSE), p.kyc_verified_at,
+			p.credentials_changed_at, p.tier_id
+		FROM users u
+		LEFT JOIN user_withdrawal_profiles p ON p.user_id = u.id
+		WHERE u.id = $1;
+	`, userId).
+		Scan(
+			&profile.UserId,
+			&profile.KycVerified,
+			&profile.KycVerifiedAt,
+			&profile.CredentialsChangedAt,
+			&profile.TierId,
+		)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, types.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// GetUserWithdrawalPolicy returns the limits that apply to the next
+// withdrawal of a user with the withdrawals that already count against them.
+func (m *Manager) GetUserWithdrawalPolicy(userId int) (*types.WithdrawalPolicy, error) {
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := getUserWithdrawalPolicyAsDBTx(tx, userId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// CreateWithdrawTransaction creates a pending withdrawal of a wallet that is
+// left for an admin to approve. The withdrawal must be in the limits of the
+// withdrawal tier of the owner of the wallet and the owner must not be in
+// the cooldown after a password or email change.
+func (m *Manager) CreateWithdrawTransaction(walletId int, amount types.Money) (int, error) {
+	if !amount.IsPositive() {
+		return -1, types.ErrInvalidMoneyAmount
+	}
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+
+	// the wallet is locked, so the withdrawals that count against the limits
+	// cannot change until the end of the tx
+	_, balance, err := lockWalletLedgerAccountAsDBTx(tx, walletId)
+	if err != nil {
+		tx.Rollback()
+		if err == types.ErrLedgerAccountNotFound {
+			return -1, types.ErrWalletNotFound
+		}
+		return -1, err
+	}
+
+	err = ensureWalletNotFrozenAsDBTx(tx, walletId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	userId := -1
+	err = tx.QueryRow("SELECT user_id FROM wallets WHERE id = $1;", walletId).Scan(&userId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	policy, err := getUserWithdrawalPolicyAsDBTx(tx, userId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if policy.CooldownEndsAt.Valid {
+		tx.Rollback()
+		return -1, types.ErrWithdrawCooldownActive(policy.CooldownEndsAt.Time)
+	}
+
+	if amount.LessThan(policy.MinAmount) {
+		tx.Rollback()
+		return -1, types.ErrWithdrawAmountBelowMinimum(policy.MinAmount)
+	}
+
+	if balance.LessThan(amount) {
+		tx.Rollback()
+		return -1, types.ErrBalanceInsufficient
+	}
+
+	if policy.DailyLimit.IsPositive() &&
+		policy.DailyUsed.Add(amount).GreaterThan(policy.DailyLimit) {
+		tx.Rollback()
+		return -1, types.ErrWithdrawDailyLimitExceeded(policy.DailyLimit)
+	}
+
+	if policy.MonthlyLimit.IsPositive() &&
+		policy.MonthlyUsed.Add(amount).GreaterThan(policy.MonthlyLimit) {
+		tx.Rollback()
+		return -1, types.ErrWithdrawMonthlyLimitExceeded(policy.MonthlyLimit)
+	}
+
+	rowId := -1
+	err = tx.QueryRow(
+		"INSERT INTO wallet_transactions (amount, tx_type, wallet_id) VALUES ($1, $2, $3) RETURNING id;",
+		amount,
+		types.TransactionTypeWithdraw,
+		walletId,
+	).
+		Scan(&rowId)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	return rowId, nil
+}
+
+func (m *Manager) UpdateWithdrawalTier(id int, p types.UpdateWithdrawalTierPayload) error {
+	clauses := []string{}
+	args := []any{}
+	argsPos := 1
+
+	if p.Name != nil {
+		clauses = append(clauses, fmt.Sprintf("name = $%d", argsPos))
+		args = append(args, *p.Name)
+		argsPos++
+	}
+
+	if p.MinAmount != nil {
+		clauses = append(clauses, fmt.Sprintf("min_amount = $%d", argsPos))
+		args = append(args, *p.MinAmount)
+		argsPos++
+	}
+
+	if p.DailyLimit != nil {
+		clauses = append(clauses, fmt.Sprintf("daily_limit = $%d", argsPos))
+		args = append(args, *p.DailyLimit)
+		argsPos++
+	}
+
+	if p.MonthlyLimit != nil {
+		clauses = append(clauses, fmt.Sprintf("monthly_limit = $%d", argsPos))
+		args = append(args, *p.MonthlyLimit)
+		argsPos++
+	}
+
+	if p.KycDailyLimit != nil {
+		clauses = append(clauses, fmt.Sprintf("kyc_daily_limit = $%d", argsPos))
+		args = append(args, *p.KycDailyLimit)
+		argsPos++
+	}
+
+	if p.KycMonthlyLimit != nil {
+		clauses = append(clauses, fmt.Sprintf("kyc_monthly_limit = $%d", argsPos))
+		args = append(args, *p.KycMonthlyLimit)
+		argsPos++
+	}
+
+	if p.CooldownInHours != nil {
+		clauses = append(clauses, fmt.Sprintf("cooldown_in_hours = $%d", argsPos))
+		args = append(args, *p.CooldownInHours)
+		argsPos++
+	}
+
+	if p.IsDefault != nil {
+		clauses = append(clauses, fmt.Sprintf("is_default = $%d", argsPos))
+		args = append(args, *p.IsDefault)
+		argsPos++
+	}
+
+	if len(clauses) == 0 {
+		return types.ErrNoFieldsReceivedToUpdate
+	}
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	isDefault := false
+	err = tx.QueryRow("SELECT is_default FROM withdrawal_tiers WHERE id = $1 FOR UPDATE;", id).
+		Scan(&isDefault)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return types.ErrWithdrawalTierNotFound
+		}
+		return err
+	}
+
+	// there is always a default tier, so it only changes by making another
+	// tier the default
+	if p.IsDefault != nil && *p.IsDefault != isDefault {
+		if !*p.IsDefault {
+			tx.Rollback()
+			return types.ErrDefaultWithdrawalTierRequired
+		}
+
+		err = unsetDefaultWithdrawalTierAsDBTx(tx)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	clauses = append(clauses, fmt.Sprintf("updated_at = $%d", argsPos))
+	args = append(args, time.Now())
+	argsPos++
+
+	args = append(args, id)
+	q := fmt.Sprintf(
+		"UPDATE withdrawal_tiers SET %s WHERE id = $%d",
+		strings.Join(clauses, ", "),
+		argsPos,
+	)
+
+	_, err = tx.Exec(q, args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+// SetRoleWithdrawalTier sets the withdrawal tier of the users of a role that
+// have no tier of their own.
+func (m *Manager) SetRoleWithdrawalTier(roleId int, tierId int) error {
+	_, err := m.db.Exec(`
+		INSERT INTO role_withdrawal_tiers (role_id, tier_id) VALUES ($1, $2)
+		ON CONFLICT (role_id) DO UPDATE SET tier_id = EXCLUDED.tier_id;
+	`, roleId, tierId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateUserWithdrawalProfile updates the withdrawal settings of a user, the
+// settings are created for the users that have none yet.
+func (m *Manager) UpdateUserWithdrawalProfile(
+	userId int,
+	p types.UpdateUserWithdrawalProfilePayload,
+) error {
+	columns := []string{}
+	args := []any{userId}
+
+	if p.KycVerified != nil {
+		columns = append(columns, "kyc_verified", "kyc_verified_at")
+		args = append(args, *p.KycVerified, sql.NullTime{Time: time.Now(), Valid: *p.KycVerified})
+	}
+
+	if p.TierId != nil {
+		columns = append(columns, "tier_id")
+		args = append(args, *p.TierId)
+	}
+
+	if len(columns) == 0 {
+		return types.ErrNoFieldsReceivedToUpdate
+	}
+
+	columns = append(columns, "updated_at")
+	args = append(args, time.Now())
+
+	placeholders := []string{"$1"}
+	clauses := []string{}
+	for i, col := range columns {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+2))
+		clauses = append(clauses, fmt.Sprintf("%s = EXCLUDED.%s", col, col))
+	}
+
+	q := fmt.Sprintf(
+		"INSERT INTO user_withdrawal_profiles (user_id, %s) VALUES (%s) ON CONFLICT (user_id) DO UPDATE SET %s",
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "),
+		strings.Join(clauses, ", "),
+	)
+
+	_, err := m.db.Exec(q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteWithdrawalTier deletes a withdrawal tier, the roles and the users of
+// the tier fall back to the default tier.
+func (m *Manager) DeleteWithdrawalTier(id int) error {
+	isDefault := false
+	err := m.db.QueryRow("SELECT is_default FROM withdrawal_tiers WHERE id = $1;", id).
+		Scan(&isDefault)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return types.ErrWithdrawalTierNotFound
+		}
+		return err
+	}
+
+	if isDefault {
+		return types.ErrCannotDeleteDefaultWithdrawalTier
+	}
+
+	_, err = m.db.Exec(
+		"DELETE FROM withdrawal_tiers WHERE id = $1 AND NOT is_default;",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Manager) DeleteRoleWithdrawalTier(roleId int) error {
+	_, err := m.db.Exec(
+		"DELETE FROM role_withdrawal_tiers WHERE role_id = $1;",
+		roleId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUserWithdrawalTier removes the tier that is set for a user alone, the
+// user falls back to the tier of its role or the default tier.
+func (m *Manager) DeleteUserWithdrawalTier(userId int) error {
+	_, err := m.db.Exec(
+		"UPDATE user_withdrawal_profiles SET tier_id = NULL, updated_at = $1 WHERE user_id = $2;",
+		time.Now(),
+		userId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getUserWithdrawalPolicyAsDBTx resolves the withdrawal tier of a user and
+// sums the pending and the successful withdrawals of the user that count
+// against its limits.
+func getUserWithdrawalPolicyAsDBTx(tx *sql.Tx, userId int) (*types.WithdrawalPolicy, error) {
+	rows, err := tx.Query(withdrawalPolicyQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := new(types.WithdrawalPolicy)
+	policy.Tier.Id = -1
+	credentialsChangedAt := sql.NullTime{}
+
+	for rows.Next() {
+		tier := new(types.WithdrawalTier)
+		err = rows.Scan(
+			&tier.Id,
+			&tier.Name,
+			&tier.MinAmount,
+			&tier.DailyLimit,
+			&tier.MonthlyLimit,
+			&tier.KycDailyLimit,
+			&tier.KycMonthlyLimit,
+			&tier.CooldownInHours,
+			&tier.IsDefault,
+			&tier.CreatedAt,
+			&tier.UpdatedAt,
+			&policy.KycVerified,
+			&credentialsChangedAt,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		policy.Tier = *tier
+	}
+	rows.Close()
+
+	// there is always a default tier, so only a missing user has no tier
+	if policy.Tier.Id == -1 {
+		return nil, types.ErrUserNotFound
+	}
+
+	policy.MinAmount = policy.Tier.MinAmount
+	policy.DailyLimit = policy.Tier.DailyLimit
+	policy.MonthlyLimit = policy.Tier.MonthlyLimit
+	if policy.KycVerified {
+		policy.DailyLimit = policy.Tier.KycDailyLimit
+		policy.MonthlyLimit = policy.Tier.KycMonthlyLimit
+	}
+
+	now := time.Now()
+	if credentialsChangedAt.Valid && policy.Tier.CooldownInHours > 0 {
+		cooldownEndsAt := credentialsChangedAt.Time.
+			Add(time.Duration(policy.Tier.CooldownInHours) * time.Hour)
+		if cooldownEndsAt.After(now) {
+			policy.CooldownEndsAt.Time = cooldownEndsAt
+			policy.CooldownEndsAt.Valid = true
+		}
+	}
+
+	err = tx.QueryRow(`
+		SELECT
+			COALESCE(SUM(wt.amount) FILTER (WHERE wt.created_at > $4), 0),
+			COALESCE(SUM(wt.amount), 0)
+		FROM wallet_transactions wt
+		JOIN wallets w ON w.id = wt.wallet_id
+		WHERE w.user_id = $1 AND wt.tx_type = $2 AND wt.status IN ($3, $5)
+		AND wt.created_at > $6;
+	`,
+		userId,
+		types.TransactionTypeWithdraw,
+		types.TransactionStatusPending,
+		now.Add(-24*time.Hour),
+		types.TransactionStatusSuccessful,
+		now.AddDate(0, 0, -30),
+	).
+		Scan(&policy.DailyUsed, &policy.MonthlyUsed)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func unsetDefaultWithdrawalTierAsDBTx(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		"UPDATE withdrawal_tiers SET is_default = FALSE, updated_at = $1 WHERE is_default;",
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanWithdrawalTierRow(rows *sql.Rows) (*types.WithdrawalTier, error) {
+	n := new(types.WithdrawalTier)
+
+	err := rows.Scan(
+		&n.Id,
+		&n.Name,
+		&n.MinAmount,
+		&n.DailyLimit,
+		&n.MonthlyLimit,
+		&n.KycDailyLimit,
+		&n.KycMonthlyLimit,
+		&n.CooldownInHours,
+		&n.IsDefault,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,22 @@
+DROP FUNCTION IF EXISTS derive_order_shipment_status;
+
+ALTER TABLE order_shipments
+  DROP CONSTRAINT IF EXISTS order_shipments_order_id_store_id_key;
+
+ALTER TABLE order_shipments DISABLE TRIGGER trg_prevent_order_shipment_deletion;
+DELETE FROM order_shipments os
+WHERE EXISTS (
+  SELECT 1 FROM order_shipments other
+  WHERE other.order_id = os.order_id AND other.id < os.id
+);
+ALTER TABLE order_shipments ENABLE TRIGGER trg_prevent_order_shipment_deletion;
+
+ALTER TABLE order_shipments
+  DROP COLUMN IF EXISTS tracking_number,
+  DROP COLUMN IF EXISTS store_id;
+
+ALTER TABLE order_shipments
+  ADD CONSTRAINT order_shipments_order_id_key UNIQUE (order_id);
+
+ALTER TABLE order_product_variants
+  DROP COLUMN IF EXISTS store_id;
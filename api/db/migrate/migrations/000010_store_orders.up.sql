@@ -0,0 +1,81 @@
+ALTER TABLE order_product_variants
+  ADD COLUMN store_id INTEGER REFERENCES stores(id) ON DELETE RESTRICT;
+
+UPDATE order_product_variants opv
+SET store_id = (
+  SELECT sop.store_id FROM store_owned_products sop
+  JOIN product_variants pv ON pv.product_id = sop.product_id
+  WHERE pv.id = opv.variant_id
+  LIMIT 1
+);
+
+ALTER TABLE order_product_variants
+  ALTER COLUMN store_id SET NOT NULL;
+
+ALTER TABLE order_shipments
+  DROP CONSTRAINT order_shipments_order_id_key;
+
+ALTER TABLE order_shipments
+  ADD COLUMN store_id INTEGER REFERENCES stores(id) ON DELETE RESTRICT,
+  ADD COLUMN tracking_number VARCHAR(255);
+
+UPDATE order_shipments os
+SET store_id = (
+  SELECT MIN(opv.store_id) FROM order_product_variants opv
+  WHERE opv.order_id = os.order_id
+);
+
+INSERT INTO order_shipments
+  (arrival_date, status, created_at, updated_at, order_id, receiver_address_id, store_id)
+SELECT os.arrival_date, os.status, os.created_at, os.updated_at, os.order_id, os.receiver_address_id, s.store_id
+FROM order_shipments os
+JOIN (
+  SELECT DISTINCT order_id, store_id FROM order_product_variants
+) s ON s.order_id = os.order_id AND s.store_id <> os.store_id;
+
+ALTER TABLE order_shipments DISABLE TRIGGER trg_prevent_order_shipment_deletion;
+DELETE FROM order_shipments WHERE store_id IS NULL;
+ALTER TABLE order_shipments ENABLE TRIGGER trg_prevent_order_shipment_deletion;
+
+ALTER TABLE order_shipments
+  ALTER COLUMN store_id SET NOT NULL;
+
+ALTER TABLE order_shipments
+  ADD CONSTRAINT order_shipments_order_id_store_id_key UNIQUE (order_id, store_id);
+
+CREATE OR REPLACE FUNCTION derive_order_shipment_status(o_id INTEGER)
+RETURNS order_shipment_statuses AS $$
+DECLARE
+  total_count INTEGER;
+  cancelled_count INTEGER;
+  delivered_count INTEGER;
+  on_the_way_count INTEGER;
+BEGIN
+  SELECT
+    COUNT(*),
+    COUNT(*) FILTER (WHERE status = 'cancelled'),
+    COUNT(*) FILTER (WHERE status = 'delivered'),
+    COUNT(*) FILTER (WHERE status = 'on_the_way')
+  INTO total_count, cancelled_count, delivered_count, on_the_way_count
+  FROM order_shipments
+  WHERE order_id = o_id;
+
+  IF total_count = 0 THEN
+    RETURN 'to_be_determined';
+  END IF;
+
+  IF cancelled_count = total_count THEN
+    RETURN 'cancelled';
+  END IF;
+
+  IF delivered_count + cancelled_count = total_count THEN
+    RETURN 'delivered';
+  END IF;
+
+  IF delivered_count + on_the_way_count > 0 THEN
+    RETURN 'on_the_way';
+  END IF;
+
+  RETURN 'to_be_determined';
+END;
+$$ LANGUAGE plpgsql STABLE;
@@ -0,0 +1,36 @@
+DROP TRIGGER IF EXISTS trg_prevent_order_return_status_change_after_final_state ON order_returns;
+DROP FUNCTION IF EXISTS prevent_order_return_status_change_after_final_state;
+
+DROP TABLE order_return_items;
+DROP TABLE order_returns;
+DROP TYPE "order_return_statuses";
+
+CREATE OR REPLACE FUNCTION update_wallet_balance_on_tx_success()
+RETURNS TRIGGER AS $$
+DECLARE
+  dl FLOAT8;
+BEGIN
+  IF NEW.status = 'successful' AND OLD.status = 'pending' THEN
+    PERFORM 1 FROM wallets WHERE id = NEW.wallet_id FOR UPDATE;
+
+    IF NEW.tx_type = 'deposit' THEN
+      dl := NEW.amount;
+    ELSIF NEW.tx_type = 'withdraw' THEN
+      dl := -NEW.amount;
+    ELSE
+      RAISE EXCEPTION 'unknown transaction type: %', NEW.tx_type;
+    END IF;
+
+    UPDATE wallets
+    SET balance = balance + dl,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = NEW.wallet_id;
+
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- postgres cannot drop values from an enum type, so the refund values
+-- stay in transaction_types until the type itself is dropped.
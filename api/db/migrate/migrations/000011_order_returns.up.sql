@@ -0,0 +1,80 @@
+ALTER TYPE transaction_types ADD VALUE IF NOT EXISTS 'refund';
+ALTER TYPE transaction_types ADD VALUE IF NOT EXISTS 'refund_charge';
+
+CREATE TYPE "order_return_statuses" AS ENUM ('pending', 'approved', 'rejected', 'refunded');
+
+CREATE TABLE order_returns (
+  id SERIAL PRIMARY KEY,
+  reason VARCHAR(1023) NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  store_note VARCHAR(1023),
+  total_refund FLOAT8 NOT NULL CHECK (total_refund >= 0),
+  restocked BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+  store_id INTEGER NOT NULL REFERENCES stores(id) ON DELETE RESTRICT,
+  refund_tx_id INTEGER REFERENCES wallet_transactions(id) ON DELETE RESTRICT,
+  charge_tx_id INTEGER REFERENCES wallet_transactions(id) ON DELETE RESTRICT
+);
+
+ALTER TABLE order_returns
+  ALTER COLUMN status TYPE order_return_statuses USING status::order_return_statuses;
+
+ALTER TABLE order_returns
+  ALTER COLUMN status SET DEFAULT 'pending'::order_return_statuses;
+
+CREATE TABLE order_return_items (
+  id SERIAL PRIMARY KEY,
+  quantity INTEGER NOT NULL CHECK (quantity >= 1),
+  refund_amount FLOAT8 NOT NULL CHECK (refund_amount >= 0),
+
+  return_id INTEGER NOT NULL REFERENCES order_returns(id) ON DELETE CASCADE,
+  order_product_variant_id INTEGER NOT NULL REFERENCES order_product_variants(id) ON DELETE RESTRICT,
+  UNIQUE (return_id, order_product_variant_id)
+);
+
+CREATE OR REPLACE FUNCTION update_wallet_balance_on_tx_success()
+RETURNS TRIGGER AS $$
+DECLARE
+  dl FLOAT8;
+BEGIN
+  IF NEW.status = 'successful' AND OLD.status = 'pending' THEN
+    PERFORM 1 FROM wallets WHERE id = NEW.wallet_id FOR UPDATE;
+
+    IF NEW.tx_type IN ('deposit', 'refund') THEN
+      dl := NEW.amount;
+    ELSIF NEW.tx_type IN ('withdraw', 'refund_charge') THEN
+      dl := -NEW.amount;
+    ELSE
+      RAISE EXCEPTION 'unknown transaction type: %', NEW.tx_type;
+    END IF;
+
+    UPDATE wallets
+    SET balance = balance + dl,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = NEW.wallet_id;
+
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION prevent_order_return_status_change_after_final_state()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF OLD.status IN ('rejected', 'refunded') AND NEW.status IS DISTINCT FROM OLD.status THEN
+    RAISE EXCEPTION 'cannot change return status from % to % after it is finalized', OLD.status, NEW.status;
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_prevent_order_return_status_change_after_final_state
+BEFORE UPDATE ON order_returns
+FOR EACH ROW
+WHEN (OLD.status IN ('rejected', 'refunded') AND NEW.status IS DISTINCT FROM OLD.status)
+EXECUTE FUNCTION prevent_order_return_status_change_after_final_state();
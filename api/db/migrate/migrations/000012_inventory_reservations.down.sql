@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trg_release_order_reservations_after_payment ON order_payments;
+DROP TRIGGER IF EXISTS trg_check_order_reservations_before_payment ON order_payments;
+
+DROP FUNCTION IF EXISTS release_order_reservations_after_payment;
+DROP FUNCTION IF EXISTS check_order_reservations_before_payment;
+
+DROP TABLE inventory_reservations;
@@ -0,0 +1,45 @@
+CREATE TABLE inventory_reservations (
+  id SERIAL PRIMARY KEY,
+  quantity INTEGER NOT NULL CHECK (quantity >= 1),
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  variant_id INTEGER NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+  UNIQUE (order_id, variant_id)
+);
+
+CREATE OR REPLACE FUNCTION check_order_reservations_before_payment()
+RETURNS TRIGGER AS $$
+BEGIN
+  PERFORM 1 FROM inventory_reservations
+  WHERE order_id = NEW.order_id AND expires_at <= CURRENT_TIMESTAMP;
+
+  IF FOUND THEN
+    RAISE EXCEPTION 'inventory reservation of order % is expired', NEW.order_id;
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION release_order_reservations_after_payment()
+RETURNS TRIGGER AS $$
+BEGIN
+  DELETE FROM inventory_reservations WHERE order_id = NEW.order_id;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_check_order_reservations_before_payment
+BEFORE UPDATE ON order_payments
+FOR EACH ROW
+WHEN (OLD.status = 'pending' AND NEW.status = 'successful')
+EXECUTE FUNCTION check_order_reservations_before_payment();
+
+CREATE TRIGGER trg_release_order_reservations_after_payment
+AFTER UPDATE ON order_payments
+FOR EACH ROW
+WHEN (OLD.status = 'pending' AND NEW.status IS DISTINCT FROM OLD.status)
+EXECUTE FUNCTION release_order_reservations_after_payment();
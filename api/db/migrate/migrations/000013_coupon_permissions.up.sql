@@ -0,0 +1,5 @@
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_add_coupon';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_update_coupon';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_delete_coupon';
+
+ALTER TYPE resources ADD VALUE IF NOT EXISTS 'coupons_full_access';
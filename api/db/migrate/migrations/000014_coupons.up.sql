@@ -0,0 +1,145 @@
+CREATE TYPE "coupon_discount_types" AS ENUM ('percentage', 'fixed');
+
+CREATE TABLE coupons (
+  id SERIAL PRIMARY KEY,
+  code VARCHAR(63) NOT NULL UNIQUE,
+  description VARCHAR(1023) NOT NULL DEFAULT '',
+  discount_type VARCHAR(20) NOT NULL,
+  amount FLOAT8 NOT NULL CHECK (amount > 0),
+  min_basket FLOAT8 NOT NULL DEFAULT 0 CHECK (min_basket >= 0),
+  usage_limit INTEGER CHECK (usage_limit >= 1),
+  per_user_limit INTEGER CHECK (per_user_limit >= 1),
+  starts_at TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  store_id INTEGER REFERENCES stores(id) ON DELETE CASCADE,
+  category_id INTEGER REFERENCES product_categories(id) ON DELETE CASCADE,
+  tag_id INTEGER REFERENCES product_tags(id) ON DELETE CASCADE,
+  CHECK (expires_at > starts_at)
+);
+
+ALTER TABLE coupons
+  ALTER COLUMN discount_type TYPE coupon_discount_types USING discount_type::coupon_discount_types;
+
+ALTER TABLE coupons
+  ADD CONSTRAINT coupons_percentage_amount_check
+  CHECK (discount_type <> 'percentage' OR amount <= 1);
+
+ALTER TABLE order_payments
+  ADD COLUMN discount FLOAT8 NOT NULL DEFAULT 0 CHECK (discount >= 0),
+  ADD COLUMN coupon_id INTEGER REFERENCES coupons(id) ON DELETE RESTRICT;
+
+ALTER TABLE order_product_variants
+  ADD COLUMN discount FLOAT8 NOT NULL DEFAULT 0 CHECK (discount >= 0);
+
+CREATE OR REPLACE FUNCTION handle_successful_order_payment()
+RETURNS TRIGGER AS $$
+DECLARE
+  customer_wallet_id INTEGER;
+  customer_wallet_balance FLOAT8;
+  dl FLOAT8;
+
+  variant_record RECORD;
+  variant_current_quantity INTEGER;
+  variant_store_owner_id INTEGER;
+  variant_store_owner_wallet_id INTEGER;
+  variant_total_price FLOAT8;
+BEGIN
+  IF NEW.status = 'successful' AND OLD.status = 'pending' THEN
+    SELECT w.id, w.balance INTO customer_wallet_id, customer_wallet_balance
+    FROM wallets w
+    JOIN orders o ON o.user_id = w.user_id
+    WHERE o.id = NEW.order_id
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+      RAISE EXCEPTION 'customer wallet not found for order %', NEW.order_id;
+    END IF;
+
+    dl := NEW.total_variants_price + NEW.total_shipment_price + NEW.fee - NEW.discount;
+
+    IF customer_wallet_balance < dl THEN
+      RAISE EXCEPTION 'insufficient wallet balance: required = %, available = %',
+        dl, customer_wallet_balance;
+    END IF;
+
+    UPDATE wallets
+    SET balance = balance - dl,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = customer_wallet_id;
+
+    FOR variant_record IN
+      SELECT opv.variant_id, opv.quantity, opv.variant_price, opv.shipping_price, opv.discount, pv.product_id
+      FROM order_product_variants opv
+      JOIN product_variants pv ON pv.id = opv.variant_id
+      WHERE opv.order_id = NEW.order_id
+    LOOP
+      SELECT quantity INTO variant_current_quantity
+      FROM product_variants
+      WHERE id = variant_record.variant_id
+      FOR UPDATE;
+
+      IF variant_current_quantity < variant_record.quantity THEN
+        RAISE EXCEPTION 'quantity is not enough for product: %',
+          variant_record.product_id;
+      END IF;
+
+      UPDATE product_variants
+      SET
+        quantity = quantity - variant_record.quantity
+      WHERE id = variant_record.variant_id;
+
+      SELECT s.owner_id INTO variant_store_owner_id
+      FROM store_owned_products sop
+      JOIN stores s ON sop.store_id = s.id
+      WHERE sop.product_id = variant_record.product_id;
+
+      IF NOT FOUND THEN
+        RAISE EXCEPTION 'store not found for product %', variant_record.product_id;
+      END IF;
+
+      SELECT id INTO variant_store_owner_wallet_id
+      FROM wallets
+      WHERE user_id = variant_store_owner_id
+      FOR UPDATE;
+
+      IF NOT FOUND THEN
+        RAISE EXCEPTION 'wallet not found for store owner %', variant_store_owner_id;
+      END IF;
+
+      variant_total_price := variant_record.quantity * variant_record.variant_price + variant_record.shipping_price - variant_record.discount;
+
+      UPDATE wallets
+      SET balance = balance + variant_total_price,
+          updated_at = CURRENT_TIMESTAMP
+      WHERE user_id = variant_store_owner_id;
+    END LOOP;
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO permission_groups
+  (name, description) VALUES
+  ('Coupon Management', 'Can manage & manipulate coupons');
+
+INSERT INTO group_resource_permissions
+  (resource, group_id) VALUES
+  ('coupons_full_access', (SELECT id FROM permission_groups WHERE name = 'Coupon Management'));
+
+INSERT INTO group_action_permissions
+  (action, group_id) VALUES
+  ('can_add_coupon', (SELECT id FROM permission_groups WHERE name = 'Coupon Management')),
+  ('can_update_coupon', (SELECT id FROM permission_groups WHERE name = 'Coupon Management')),
+  ('can_delete_coupon', (SELECT id FROM permission_groups WHERE name = 'Coupon Management'));
+
+INSERT INTO role_group_assignments
+  (role_id, permission_group_id) VALUES
+  (
+    (SELECT id FROM roles WHERE name = 'Admin'),
+    (SELECT id FROM permission_groups WHERE name = 'Coupon Management')
+  );
@@ -0,0 +1,2 @@
+-- postgres cannot drop values from an enum type, so the shipping zone values
+-- stay in actions until the type itself is dropped.
@@ -0,0 +1,3 @@
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_add_shipping_zone';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_update_shipping_zone';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_delete_shipping_zone';
@@ -0,0 +1,7 @@
+DELETE FROM permission_groups WHERE name = 'Shipping Management';
+
+DROP TABLE IF EXISTS shipping_rate_tiers;
+DROP TABLE IF EXISTS shipping_rate_tables;
+DROP TABLE IF EXISTS shipping_zones;
+
+DROP TYPE IF EXISTS "shipping_rate_bases";
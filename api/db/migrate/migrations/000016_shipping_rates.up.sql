@@ -0,0 +1,60 @@
+CREATE TYPE "shipping_rate_bases" AS ENUM ('quantity', 'weight');
+
+CREATE TABLE shipping_zones (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL UNIQUE,
+  state VARCHAR(127) NOT NULL,
+  city VARCHAR(127),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX shipping_zones_state_city_key
+  ON shipping_zones (LOWER(state), LOWER(COALESCE(city, '')));
+
+CREATE TABLE shipping_rate_tables (
+  id SERIAL PRIMARY KEY,
+  basis VARCHAR(20) NOT NULL,
+  free_shipping_threshold FLOAT8 CHECK (free_shipping_threshold >= 0),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  store_id INTEGER NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+  zone_id INTEGER REFERENCES shipping_zones(id) ON DELETE CASCADE
+);
+
+ALTER TABLE shipping_rate_tables
+  ALTER COLUMN basis TYPE shipping_rate_bases USING basis::shipping_rate_bases;
+
+-- a store has at most one rate table per zone and one default rate table
+-- (without a zone) that is used for the addresses outside of its zones
+CREATE UNIQUE INDEX shipping_rate_tables_store_id_zone_id_key
+  ON shipping_rate_tables (store_id, COALESCE(zone_id, 0));
+
+CREATE TABLE shipping_rate_tiers (
+  id SERIAL PRIMARY KEY,
+  min_value FLOAT8 NOT NULL DEFAULT 0 CHECK (min_value >= 0),
+  max_value FLOAT8,
+  base_price FLOAT8 NOT NULL DEFAULT 0 CHECK (base_price >= 0),
+  price_per_unit FLOAT8 NOT NULL DEFAULT 0 CHECK (price_per_unit >= 0),
+
+  rate_table_id INTEGER NOT NULL REFERENCES shipping_rate_tables(id) ON DELETE CASCADE,
+  CHECK (max_value IS NULL OR max_value > min_value)
+);
+
+INSERT INTO permission_groups
+  (name, description) VALUES
+  ('Shipping Management', 'Can manage & manipulate shipping zones');
+
+INSERT INTO group_action_permissions
+  (action, group_id) VALUES
+  ('can_add_shipping_zone', (SELECT id FROM permission_groups WHERE name = 'Shipping Management')),
+  ('can_update_shipping_zone', (SELECT id FROM permission_groups WHERE name = 'Shipping Management')),
+  ('can_delete_shipping_zone', (SELECT id FROM permission_groups WHERE name = 'Shipping Management'));
+
+INSERT INTO role_group_assignments
+  (role_id, permission_group_id) VALUES
+  (
+    (SELECT id FROM roles WHERE name = 'Admin'),
+    (SELECT id FROM permission_groups WHERE name = 'Shipping Management')
+  );
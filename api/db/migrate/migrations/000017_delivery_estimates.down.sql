@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS shipping_transit_times;
+
+ALTER TABLE stores_settings
+  DROP COLUMN IF EXISTS handling_days;
@@ -0,0 +1,18 @@
+ALTER TABLE stores_settings
+  ADD COLUMN handling_days INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE stores_settings
+  ADD CONSTRAINT stores_settings_handling_days_check CHECK (handling_days >= 0);
+
+-- the number of days a shipment takes from an origin zone, matched by the
+-- addresses of the store, to a destination zone, matched by the receiver address
+CREATE TABLE shipping_transit_times (
+  id SERIAL PRIMARY KEY,
+  days INTEGER NOT NULL CHECK (days >= 0),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  origin_zone_id INTEGER NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+  destination_zone_id INTEGER NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+  UNIQUE (origin_zone_id, destination_zone_id)
+);
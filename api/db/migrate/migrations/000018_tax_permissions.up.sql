@@ -0,0 +1,3 @@
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_add_tax_rule';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_update_tax_rule';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_delete_tax_rule';
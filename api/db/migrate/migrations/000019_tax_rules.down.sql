@@ -0,0 +1,101 @@
+DELETE FROM permission_groups WHERE name = 'Tax Management';
+
+CREATE OR REPLACE FUNCTION handle_successful_order_payment()
+RETURNS TRIGGER AS $$
+DECLARE
+  customer_wallet_id INTEGER;
+  customer_wallet_balance FLOAT8;
+  dl FLOAT8;
+
+  variant_record RECORD;
+  variant_current_quantity INTEGER;
+  variant_store_owner_id INTEGER;
+  variant_store_owner_wallet_id INTEGER;
+  variant_total_price FLOAT8;
+BEGIN
+  IF NEW.status = 'successful' AND OLD.status = 'pending' THEN
+    SELECT w.id, w.balance INTO customer_wallet_id, customer_wallet_balance
+    FROM wallets w
+    JOIN orders o ON o.user_id = w.user_id
+    WHERE o.id = NEW.order_id
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+      RAISE EXCEPTION 'customer wallet not found for order %', NEW.order_id;
+    END IF;
+
+    dl := NEW.total_variants_price + NEW.total_shipment_price + NEW.fee - NEW.discount;
+
+    IF customer_wallet_balance < dl THEN
+      RAISE EXCEPTION 'insufficient wallet balance: required = %, available = %',
+        dl, customer_wallet_balance;
+    END IF;
+
+    UPDATE wallets
+    SET balance = balance - dl,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = customer_wallet_id;
+
+    FOR variant_record IN
+      SELECT opv.variant_id, opv.quantity, opv.variant_price, opv.shipping_price, opv.discount, pv.product_id
+      FROM order_product_variants opv
+      JOIN product_variants pv ON pv.id = opv.variant_id
+      WHERE opv.order_id = NEW.order_id
+    LOOP
+      SELECT quantity INTO variant_current_quantity
+      FROM product_variants
+      WHERE id = variant_record.variant_id
+      FOR UPDATE;
+
+      IF variant_current_quantity < variant_record.quantity THEN
+        RAISE EXCEPTION 'quantity is not enough for product: %',
+          variant_record.product_id;
+      END IF;
+
+      UPDATE product_variants
+      SET
+        quantity = quantity - variant_record.quantity
+      WHERE id = variant_record.variant_id;
+
+      SELECT s.owner_id INTO variant_store_owner_id
+      FROM store_owned_products sop
+      JOIN stores s ON sop.store_id = s.id
+      WHERE sop.product_id = variant_record.product_id;
+
+      IF NOT FOUND THEN
+        RAISE EXCEPTION 'store not found for product %', variant_record.product_id;
+      END IF;
+
+      SELECT id INTO variant_store_owner_wallet_id
+      FROM wallets
+      WHERE user_id = variant_store_owner_id
+      FOR UPDATE;
+
+      IF NOT FOUND THEN
+        RAISE EXCEPTION 'wallet not found for store owner %', variant_store_owner_id;
+      END IF;
+
+      variant_total_price := variant_record.quantity * variant_record.variant_price + variant_record.shipping_price - variant_record.discount;
+
+      UPDATE wallets
+      SET balance = balance + variant_total_price,
+          updated_at = CURRENT_TIMESTAMP
+      WHERE user_id = variant_store_owner_id;
+    END LOOP;
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE order_payments
+  DROP COLUMN exclusive_tax,
+  DROP COLUMN total_tax;
+
+ALTER TABLE order_product_variants
+  DROP COLUMN tax_pricing_mode,
+  DROP COLUMN tax_rate,
+  DROP COLUMN tax;
+
+DROP TABLE tax_rules;
+DROP TYPE "tax_pricing_modes";
@@ -0,0 +1,142 @@
+CREATE TYPE "tax_pricing_modes" AS ENUM ('inclusive', 'exclusive');
+
+CREATE TABLE tax_rules (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  rate FLOAT8 NOT NULL CHECK (rate >= 0 AND rate <= 1),
+  pricing_mode VARCHAR(20) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  zone_id INTEGER NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+  category_id INTEGER REFERENCES product_categories(id) ON DELETE CASCADE
+);
+
+ALTER TABLE tax_rules
+  ALTER COLUMN pricing_mode TYPE tax_pricing_modes USING pricing_mode::tax_pricing_modes;
+
+-- a zone has at most one rule per category and one rule (without a category)
+-- that is used for the products outside of its categories
+CREATE UNIQUE INDEX tax_rules_zone_id_category_id_key
+  ON tax_rules (zone_id, COALESCE(category_id, 0));
+
+ALTER TABLE order_product_variants
+  ADD COLUMN tax FLOAT8 NOT NULL DEFAULT 0 CHECK (tax >= 0),
+  ADD COLUMN tax_rate FLOAT8 NOT NULL DEFAULT 0 CHECK (tax_rate >= 0),
+  ADD COLUMN tax_pricing_mode tax_pricing_modes;
+
+ALTER TABLE order_payments
+  ADD COLUMN total_tax FLOAT8 NOT NULL DEFAULT 0 CHECK (total_tax >= 0),
+  ADD COLUMN exclusive_tax FLOAT8 NOT NULL DEFAULT 0 CHECK (exclusive_tax >= 0);
+
+CREATE OR REPLACE FUNCTION handle_successful_order_payment()
+RETURNS TRIGGER AS $$
+DECLARE
+  customer_wallet_id INTEGER;
+  customer_wallet_balance FLOAT8;
+  dl FLOAT8;
+
+  variant_record RECORD;
+  variant_current_quantity INTEGER;
+  variant_store_owner_id INTEGER;
+  variant_store_owner_wallet_id INTEGER;
+  variant_total_price FLOAT8;
+BEGIN
+  IF NEW.status = 'successful' AND OLD.status = 'pending' THEN
+    SELECT w.id, w.balance INTO customer_wallet_id, customer_wallet_balance
+    FROM wallets w
+    JOIN orders o ON o.user_id = w.user_id
+    WHERE o.id = NEW.order_id
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+      RAISE EXCEPTION 'customer wallet not found for order %', NEW.order_id;
+    END IF;
+
+    dl := NEW.total_variants_price + NEW.total_shipment_price + NEW.fee - NEW.discount + NEW.exclusive_tax;
+
+    IF customer_wallet_balance < dl THEN
+      RAISE EXCEPTION 'insufficient wallet balance: required = %, available = %',
+        dl, customer_wallet_balance;
+    END IF;
+
+    UPDATE wallets
+    SET balance = balance - dl,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = customer_wallet_id;
+
+    FOR variant_record IN
+      SELECT opv.variant_id, opv.quantity, opv.variant_price, opv.shipping_price, opv.discount,
+        opv.tax, opv.tax_pricing_mode, pv.product_id
+      FROM order_product_variants opv
+      JOIN product_variants pv ON pv.id = opv.variant_id
+      WHERE opv.order_id = NEW.order_id
+    LOOP
+      SELECT quantity INTO variant_current_quantity
+      FROM product_variants
+      WHERE id = variant_record.variant_id
+      FOR UPDATE;
+
+      IF variant_current_quantity < variant_record.quantity THEN
+        RAISE EXCEPTION 'quantity is not enough for product: %',
+          variant_record.product_id;
+      END IF;
+
+      UPDATE product_variants
+      SET
+        quantity = quantity - variant_record.quantity
+      WHERE id = variant_record.variant_id;
+
+      SELECT s.owner_id INTO variant_store_owner_id
+      FROM store_owned_products sop
+      JOIN stores s ON sop.store_id = s.id
+      WHERE sop.product_id = variant_record.product_id;
+
+      IF NOT FOUND THEN
+        RAISE EXCEPTION 'store not found for product %', variant_record.product_id;
+      END IF;
+
+      SELECT id INTO variant_store_owner_wallet_id
+      FROM wallets
+      WHERE user_id = variant_store_owner_id
+      FOR UPDATE;
+
+      IF NOT FOUND THEN
+        RAISE EXCEPTION 'wallet not found for store owner %', variant_store_owner_id;
+      END IF;
+
+      variant_total_price := variant_record.quantity * variant_record.variant_price + variant_record.shipping_price - variant_record.discount;
+
+      -- the exclusive tax is paid on top of the price and is collected by the
+      -- store, the inclusive tax is already part of the variant price
+      IF variant_record.tax_pricing_mode = 'exclusive' THEN
+        variant_total_price := variant_total_price + variant_record.tax;
+      END IF;
+
+      UPDATE wallets
+      SET balance = balance + variant_total_price,
+          updated_at = CURRENT_TIMESTAMP
+      WHERE user_id = variant_store_owner_id;
+    END LOOP;
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO permission_groups
+  (name, description) VALUES
+  ('Tax Management', 'Can manage & manipulate tax rules');
+
+INSERT INTO group_action_permissions
+  (action, group_id) VALUES
+  ('can_add_tax_rule', (SELECT id FROM permission_groups WHERE name = 'Tax Management')),
+  ('can_update_tax_rule', (SELECT id FROM permission_groups WHERE name = 'Tax Management')),
+  ('can_delete_tax_rule', (SELECT id FROM permission_groups WHERE name = 'Tax Management'));
+
+INSERT INTO role_group_assignments
+  (role_id, permission_group_id) VALUES
+  (
+    (SELECT id FROM roles WHERE name = 'Admin'),
+    (SELECT id FROM permission_groups WHERE name = 'Tax Management')
+  );
@@ -0,0 +1,11 @@
+-- invoices are issued once the payment of an order succeeds, the number is
+-- assigned without gaps and the snapshot keeps the invoice unchanged even if
+-- the order, the products or the addresses are changed afterwards
+CREATE TABLE invoices (
+  id SERIAL PRIMARY KEY,
+  number INTEGER UNIQUE NOT NULL CHECK (number > 0),
+  snapshot JSONB NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  order_id INTEGER UNIQUE REFERENCES orders(id) ON DELETE SET NULL
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS order_status_events;
+
+DROP TYPE "order_status_event_kinds";
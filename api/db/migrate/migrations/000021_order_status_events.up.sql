@@ -0,0 +1,28 @@
+CREATE TYPE "order_status_event_kinds" AS ENUM ('payment', 'shipment');
+
+-- the history of the payment and shipment statuses of the orders, the
+-- statuses of both kinds are stored as text
+CREATE TABLE order_status_events (
+  id SERIAL PRIMARY KEY,
+  kind VARCHAR(20) NOT NULL,
+  old_status VARCHAR(20),
+  new_status VARCHAR(20) NOT NULL,
+  reason TEXT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  store_id INTEGER REFERENCES stores(id) ON DELETE SET NULL,
+  actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE order_status_events
+  ALTER COLUMN kind TYPE order_status_event_kinds USING kind::order_status_event_kinds;
+
+CREATE INDEX order_status_events_order_id_idx ON order_status_events(order_id, created_at);
+
+-- the existing orders start their history with their current statuses
+INSERT INTO order_status_events (kind, new_status, created_at, order_id)
+SELECT 'payment', status::TEXT, updated_at, order_id FROM order_payments;
+
+INSERT INTO order_status_events (kind, new_status, created_at, order_id, store_id)
+SELECT 'shipment', status::TEXT, updated_at, order_id, store_id FROM order_shipments;
@@ -0,0 +1,5 @@
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_add_commission_rule';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_update_commission_rule';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_delete_commission_rule';
+
+ALTER TYPE resources ADD VALUE IF NOT EXISTS 'commissions_full_access';
@@ -0,0 +1,176 @@
+-- commission rules set the rate of the fee that the platform takes from the
+-- order lines, a rule without a store applies to all stores and a rule
+-- without a category applies to all products. The rule without both is the
+-- platform default rate.
+CREATE TABLE commission_rules (
+  id SERIAL PRIMARY KEY,
+  rate FLOAT8 NOT NULL CHECK (rate >= 0 AND rate <= 1),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  store_id INTEGER REFERENCES stores(id) ON DELETE CASCADE,
+  category_id INTEGER REFERENCES product_categories(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX commission_rules_store_id_category_id_key
+  ON commission_rules (COALESCE(store_id, 0), COALESCE(category_id, 0));
+
+-- the rate that was used for all orders before the rules
+INSERT INTO commission_rules (rate) VALUES (0.05);
+
+ALTER TABLE order_product_variants
+  ADD COLUMN commission FLOAT8 NOT NULL DEFAULT 0 CHECK (commission >= 0),
+  ADD COLUMN commission_rate FLOAT8 NOT NULL DEFAULT 0 CHECK (commission_rate >= 0);
+
+-- the fee of the existing orders is split between their lines by their price
+-- after the discount, the same amount the fee was computed on
+UPDATE order_product_variants opv
+SET
+  commission = GREATEST(
+    op.fee * (opv.variant_price * opv.quantity - opv.discount) /
+      (op.total_variants_price - op.discount),
+    0
+  ),
+  commission_rate = op.fee / (op.total_variants_price - op.discount)
+FROM order_payments op
+WHERE op.order_id = opv.order_id AND op.total_variants_price - op.discount > 0;
+
+-- the platform has a single wallet that receives the fees of the paid orders
+CREATE TABLE platform_wallet (
+  id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+  balance FLOAT8 NOT NULL DEFAULT 0 CHECK (balance >= 0),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the fees that were already paid were taken from the customers without
+-- being credited anywhere, so they are credited now
+INSERT INTO platform_wallet (balance)
+SELECT COALESCE(SUM(fee), 0) FROM order_payments WHERE status = 'successful';
+
+CREATE OR REPLACE FUNCTION handle_successful_order_payment()
+RETURNS TRIGGER AS $$
+DECLARE
+  customer_wallet_id INTEGER;
+  customer_wallet_balance FLOAT8;
+  dl FLOAT8;
+
+  variant_record RECORD;
+  variant_current_quantity INTEGER;
+  variant_store_owner_id INTEGER;
+  variant_store_owner_wallet_id INTEGER;
+  variant_total_price FLOAT8;
+BEGIN
+  IF NEW.status = 'successful' AND OLD.status = 'pending' THEN
+    SELECT w.id, w.balance INTO customer_wallet_id, customer_wallet_balance
+    FROM wallets w
+    JOIN orders o ON o.user_id = w.user_id
+    WHERE o.id = NEW.order_id
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+      RAISE EXCEPTION 'customer wallet not found for order %', NEW.order_id;
+    END IF;
+
+    dl := NEW.total_variants_price + NEW.total_shipment_price + NEW.fee - NEW.discount + NEW.exclusive_tax;
+
+    IF customer_wallet_balance < dl THEN
+      RAISE EXCEPTION 'insufficient wallet balance: required = %, available = %',
+        dl, customer_wallet_balance;
+    END IF;
+
+    UPDATE wallets
+    SET balance = balance - dl,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = customer_wallet_id;
+
+    FOR variant_record IN
+      SELECT opv.variant_id, opv.quantity, opv.variant_price, opv.shipping_price, opv.discount,
+        opv.tax, opv.tax_pricing_mode, pv.product_id
+      FROM order_product_variants opv
+      JOIN product_variants pv ON pv.id = opv.variant_id
+      WHERE opv.order_id = NEW.order_id
+    LOOP
+      SELECT quantity INTO variant_current_quantity
+      FROM product_variants
+      WHERE id = variant_record.variant_id
+      FOR UPDATE;
+
+      IF variant_current_quantity < variant_record.quantity THEN
+        RAISE EXCEPTION 'quantity is not enough for product: %',
+          variant_record.product_id;
+      END IF;
+
+      UPDATE product_variants
+      SET
+        quantity = quantity - variant_record.quantity
+      WHERE id = variant_record.variant_id;
+
+      SELECT s.owner_id INTO variant_store_owner_id
+      FROM store_owned_products sop
+      JOIN stores s ON sop.store_id = s.id
+      WHERE sop.product_id = variant_record.product_id;
+
+      IF NOT FOUND THEN
+        RAISE EXCEPTION 'store not found for product %', variant_record.product_id;
+      END IF;
+
+      SELECT id INTO variant_store_owner_wallet_id
+      FROM wallets
+      WHERE user_id = variant_store_owner_id
+      FOR UPDATE;
+
+      IF NOT FOUND THEN
+        RAISE EXCEPTION 'wallet not found for store owner %', variant_store_owner_id;
+      END IF;
+
+      variant_total_price := variant_record.quantity * variant_record.variant_price + variant_record.shipping_price - variant_record.discount;
+
+      -- the exclusive tax is paid on top of the price and is collected by the
+      -- store, the inclusive tax is already part of the variant price
+      IF variant_record.tax_pricing_mode = 'exclusive' THEN
+        variant_total_price := variant_total_price + variant_record.tax;
+      END IF;
+
+      UPDATE wallets
+      SET balance = balance + variant_total_price,
+          updated_at = CURRENT_TIMESTAMP
+      WHERE user_id = variant_store_owner_id;
+    END LOOP;
+
+    -- the fee is the commission of the platform and is collected by its wallet
+    IF NEW.fee > 0 THEN
+      UPDATE platform_wallet
+      SET balance = balance + NEW.fee,
+          updated_at = CURRENT_TIMESTAMP;
+
+      IF NOT FOUND THEN
+        RAISE EXCEPTION 'platform wallet not found';
+      END IF;
+    END IF;
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO permission_groups
+  (name, description) VALUES
+  ('Commission Management', 'Can manage commission rules & view the commission reports');
+
+INSERT INTO group_resource_permissions
+  (resource, group_id) VALUES
+  ('commissions_full_access', (SELECT id FROM permission_groups WHERE name = 'Commission Management'));
+
+INSERT INTO group_action_permissions
+  (action, group_id) VALUES
+  ('can_add_commission_rule', (SELECT id FROM permission_groups WHERE name = 'Commission Management')),
+  ('can_update_commission_rule', (SELECT id FROM permission_groups WHERE name = 'Commission Management')),
+  ('can_delete_commission_rule', (SELECT id FROM permission_groups WHERE name = 'Commission Management'));
+
+INSERT INTO role_group_assignments
+  (role_id, permission_group_id) VALUES
+  (
+    (SELECT id FROM roles WHERE name = 'Admin'),
+    (SELECT id FROM permission_groups WHERE name = 'Commission Management')
+  );
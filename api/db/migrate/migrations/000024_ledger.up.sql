@@ -0,0 +1,234 @@
+CREATE TYPE "ledger_account_kinds" AS ENUM ('wallet', 'platform', 'external');
+CREATE TYPE "journal_entry_kinds" AS ENUM (
+  'opening_balance',
+  'deposit',
+  'withdrawal',
+  'order_payment',
+  'order_return_refund'
+);
+
+-- the accounts of the ledger, every wallet has its own account, the platform
+-- account holds the collected fees and the external account is the other
+-- side of the money that enters or leaves the system
+CREATE TABLE ledger_accounts (
+  id SERIAL PRIMARY KEY,
+  kind VARCHAR(20) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  wallet_id INTEGER UNIQUE REFERENCES wallets(id) ON DELETE CASCADE,
+  CHECK ((kind = 'wallet') = (wallet_id IS NOT NULL))
+);
+
+ALTER TABLE ledger_accounts
+  ALTER COLUMN kind TYPE ledger_account_kinds USING kind::ledger_account_kinds;
+
+CREATE UNIQUE INDEX ledger_accounts_kind_key ON ledger_accounts (kind) WHERE kind <> 'wallet';
+
+-- a journal entry groups the postings of a single money movement, the
+-- references are kept without foreign keys to the orders and returns so the
+-- entries outlive them
+CREATE TABLE journal_entries (
+  id SERIAL PRIMARY KEY,
+  kind VARCHAR(20) NOT NULL,
+  description VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  wallet_transaction_id INTEGER REFERENCES wallet_transactions(id) ON DELETE RESTRICT,
+  order_id INTEGER,
+  order_return_id INTEGER
+);
+
+ALTER TABLE journal_entries
+  ALTER COLUMN kind TYPE journal_entry_kinds USING kind::journal_entry_kinds;
+
+CREATE INDEX journal_entries_order_id_idx ON journal_entries(order_id);
+
+-- a positive amount is credited to the account and a negative amount is
+-- debited from it, the amounts of the postings of an entry add up to zero
+CREATE TABLE ledger_postings (
+  id SERIAL PRIMARY KEY,
+  amount FLOAT8 NOT NULL CHECK (amount <> 0),
+
+  entry_id INTEGER NOT NULL REFERENCES journal_entries(id) ON DELETE RESTRICT,
+  account_id INTEGER NOT NULL REFERENCES ledger_accounts(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX ledger_postings_account_id_idx ON ledger_postings(account_id, entry_id);
+CREATE INDEX ledger_postings_entry_id_idx ON ledger_postings(entry_id);
+
+INSERT INTO ledger_accounts (kind) VALUES ('platform'), ('external');
+
+INSERT INTO ledger_accounts (kind, wallet_id)
+SELECT 'wallet', id FROM wallets;
+
+-- the current balances become the opening balances of the accounts, they are
+-- posted before the balance triggers exist so the cached balances stay as is
+DO $$
+DECLARE
+  external_account_id INTEGER;
+  platform_account_id INTEGER;
+  opening_entry_id INTEGER;
+  platform_balance FLOAT8;
+  account_record RECORD;
+BEGIN
+  SELECT id INTO external_account_id FROM ledger_accounts WHERE kind = 'external';
+  SELECT id INTO platform_account_id FROM ledger_accounts WHERE kind = 'platform';
+
+  FOR account_record IN
+    SELECT la.id, w.balance
+    FROM wallets w
+    JOIN ledger_accounts la ON la.wallet_id = w.id
+    WHERE w.balance > 0
+    ORDER BY w.id
+  LOOP
+    INSERT INTO journal_entries (kind, description)
+    VALUES ('opening_balance', 'Wallet balance before the ledger')
+    RETURNING id INTO opening_entry_id;
+
+    INSERT INTO ledger_postings (amount, entry_id, account_id) VALUES
+      (account_record.balance, opening_entry_id, account_record.id),
+      (-account_record.balance, opening_entry_id, external_account_id);
+  END LOOP;
+
+  SELECT balance INTO platform_balance FROM platform_wallet;
+
+  IF platform_balance > 0 THEN
+    INSERT INTO journal_entries (kind, description)
+    VALUES ('opening_balance', 'Platform wallet balance before the ledger')
+    RETURNING id INTO opening_entry_id;
+
+    INSERT INTO ledger_postings (amount, entry_id, account_id) VALUES
+      (platform_balance, opening_entry_id, platform_account_id),
+      (-platform_balance, opening_entry_id, external_account_id);
+  END IF;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION create_wallet_ledger_account()
+RETURNS TRIGGER AS $$
+BEGIN
+  INSERT INTO ledger_accounts (kind, wallet_id) VALUES ('wallet', NEW.id);
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_create_wallet_ledger_account
+AFTER INSERT ON wallets
+FOR EACH ROW
+EXECUTE FUNCTION create_wallet_ledger_account();
+
+CREATE OR REPLACE FUNCTION prevent_ledger_change()
+RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'the ledger is append-only, cannot % rows of %', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_prevent_journal_entry_change
+BEFORE UPDATE OR DELETE ON journal_entries
+FOR EACH ROW
+EXECUTE FUNCTION prevent_ledger_change();
+
+CREATE TRIGGER trg_prevent_ledger_posting_change
+BEFORE UPDATE OR DELETE ON ledger_postings
+FOR EACH ROW
+EXECUTE FUNCTION prevent_ledger_change();
+
+-- the check is deferred to the end of the transaction, so the postings of an
+-- entry can be inserted after the entry itself
+CREATE OR REPLACE FUNCTION check_journal_entry_balance()
+RETURNS TRIGGER AS $$
+DECLARE
+  postings_count INTEGER;
+  postings_sum FLOAT8;
+BEGIN
+  SELECT COUNT(*), COALESCE(SUM(amount), 0) INTO postings_count, postings_sum
+  FROM ledger_postings
+  WHERE entry_id = NEW.id;
+
+  IF postings_count < 2 OR ABS(postings_sum) > 0.000001 THEN
+    RAISE EXCEPTION 'journal entry % is not balanced: postings = %, sum = %',
+      NEW.id, postings_count, postings_sum;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER trg_check_journal_entry_balance
+AFTER INSERT ON journal_entries
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION check_journal_entry_balance();
+
+-- the balances of the wallets are a cache of the sum of the postings of
+-- their accounts, they are kept up to date by the postings themselves
+CREATE OR REPLACE FUNCTION apply_ledger_posting_to_balance()
+RETURNS TRIGGER AS $$
+DECLARE
+  account_kind ledger_account_kinds;
+  account_wallet_id INTEGER;
+BEGIN
+  SELECT kind, wallet_id INTO account_kind, account_wallet_id
+  FROM ledger_accounts
+  WHERE id = NEW.account_id;
+
+  IF account_kind = 'wallet' THEN
+    UPDATE wallets
+    SET balance = balance + NEW.amount,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = account_wallet_id;
+  ELSIF account_kind = 'platform' THEN
+    UPDATE platform_wallet
+    SET balance = balance + NEW.amount,
+        updated_at = CURRENT_TIMESTAMP;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_apply_ledger_posting_to_balance
+AFTER INSERT ON ledger_postings
+FOR EACH ROW
+EXECUTE FUNCTION apply_ledger_posting_to_balance();
+
+-- the money of the wallet transactions and the order payments is posted to
+-- the ledger by the application, the triggers only keep the stock
+DROP TRIGGER IF EXISTS trg_update_wallet_balance_on_tx_success ON wallet_transactions;
+DROP FUNCTION IF EXISTS update_wallet_balance_on_tx_success();
+
+CREATE OR REPLACE FUNCTION handle_successful_order_payment()
+RETURNS TRIGGER AS $$
+DECLARE
+  variant_record RECORD;
+  variant_current_quantity INTEGER;
+BEGIN
+  IF NEW.status = 'successful' AND OLD.status = 'pending' THEN
+    FOR variant_record IN
+      SELECT opv.variant_id, opv.quantity, pv.product_id
+      FROM order_product_variants opv
+      JOIN product_variants pv ON pv.id = opv.variant_id
+      WHERE opv.order_id = NEW.order_id
+    LOOP
+      SELECT quantity INTO variant_current_quantity
+      FROM product_variants
+      WHERE id = variant_record.variant_id
+      FOR UPDATE;
+
+      IF variant_current_quantity < variant_record.quantity THEN
+        RAISE EXCEPTION 'quantity is not enough for product: %',
+          variant_record.product_id;
+      END IF;
+
+      UPDATE product_variants
+      SET
+        quantity = quantity - variant_record.quantity
+      WHERE id = variant_record.variant_id;
+    END LOOP;
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
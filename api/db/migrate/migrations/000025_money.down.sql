@@ -0,0 +1,64 @@
+CREATE OR REPLACE FUNCTION check_journal_entry_balance()
+RETURNS TRIGGER AS $$
+DECLARE
+  postings_count INTEGER;
+  postings_sum FLOAT8;
+BEGIN
+  SELECT COUNT(*), COALESCE(SUM(amount), 0) INTO postings_count, postings_sum
+  FROM ledger_postings
+  WHERE entry_id = NEW.id;
+
+  IF postings_count < 2 OR ABS(postings_sum) > 0.000001 THEN
+    RAISE EXCEPTION 'journal entry % is not balanced: postings = %, sum = %',
+      NEW.id, postings_count, postings_sum;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE ledger_postings
+  ALTER COLUMN amount TYPE FLOAT8 USING amount::FLOAT8;
+
+ALTER TABLE platform_wallet
+  ALTER COLUMN balance TYPE FLOAT8 USING balance::FLOAT8;
+
+ALTER TABLE shipping_rate_tiers
+  ALTER COLUMN base_price TYPE FLOAT8 USING base_price::FLOAT8,
+  ALTER COLUMN price_per_unit TYPE FLOAT8 USING price_per_unit::FLOAT8;
+
+ALTER TABLE shipping_rate_tables
+  ALTER COLUMN free_shipping_threshold TYPE FLOAT8 USING free_shipping_threshold::FLOAT8;
+
+ALTER TABLE coupons
+  ALTER COLUMN min_basket TYPE FLOAT8 USING min_basket::FLOAT8;
+
+ALTER TABLE order_return_items
+  ALTER COLUMN refund_amount TYPE FLOAT8 USING refund_amount::FLOAT8;
+
+ALTER TABLE order_returns
+  ALTER COLUMN total_refund TYPE FLOAT8 USING total_refund::FLOAT8;
+
+ALTER TABLE order_product_variants
+  ALTER COLUMN variant_price TYPE FLOAT8 USING variant_price::FLOAT8,
+  ALTER COLUMN shipping_price TYPE FLOAT8 USING shipping_price::FLOAT8,
+  ALTER COLUMN discount TYPE FLOAT8 USING discount::FLOAT8,
+  ALTER COLUMN tax TYPE FLOAT8 USING tax::FLOAT8,
+  ALTER COLUMN commission TYPE FLOAT8 USING commission::FLOAT8;
+
+ALTER TABLE order_payments
+  ALTER COLUMN total_variants_price TYPE FLOAT8 USING total_variants_price::FLOAT8,
+  ALTER COLUMN total_shipment_price TYPE FLOAT8 USING total_shipment_price::FLOAT8,
+  ALTER COLUMN fee TYPE FLOAT8 USING fee::FLOAT8,
+  ALTER COLUMN discount TYPE FLOAT8 USING discount::FLOAT8,
+  ALTER COLUMN total_tax TYPE FLOAT8 USING total_tax::FLOAT8,
+  ALTER COLUMN exclusive_tax TYPE FLOAT8 USING exclusive_tax::FLOAT8;
+
+ALTER TABLE wallet_transactions
+  ALTER COLUMN amount TYPE FLOAT8 USING amount::FLOAT8;
+
+ALTER TABLE wallets
+  ALTER COLUMN balance TYPE FLOAT8 USING balance::FLOAT8;
+
+ALTER TABLE products
+  ALTER COLUMN price TYPE FLOAT8 USING price::FLOAT8;
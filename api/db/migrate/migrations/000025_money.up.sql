@@ -0,0 +1,90 @@
+-- the amounts of money are kept as exact decimals with two places instead of
+-- floats, the existing amounts are rounded to the nearest cent
+ALTER TABLE products
+  ALTER COLUMN price TYPE NUMERIC(19, 2) USING ROUND(price::NUMERIC, 2);
+
+ALTER TABLE wallets
+  ALTER COLUMN balance TYPE NUMERIC(19, 2) USING ROUND(balance::NUMERIC, 2);
+
+ALTER TABLE wallet_transactions
+  ALTER COLUMN amount TYPE NUMERIC(19, 2) USING ROUND(amount::NUMERIC, 2);
+
+ALTER TABLE order_payments
+  ALTER COLUMN total_variants_price TYPE NUMERIC(19, 2) USING ROUND(total_variants_price::NUMERIC, 2),
+  ALTER COLUMN total_shipment_price TYPE NUMERIC(19, 2) USING ROUND(total_shipment_price::NUMERIC, 2),
+  ALTER COLUMN fee TYPE NUMERIC(19, 2) USING ROUND(fee::NUMERIC, 2),
+  ALTER COLUMN discount TYPE NUMERIC(19, 2) USING ROUND(discount::NUMERIC, 2),
+  ALTER COLUMN total_tax TYPE NUMERIC(19, 2) USING ROUND(total_tax::NUMERIC, 2),
+  ALTER COLUMN exclusive_tax TYPE NUMERIC(19, 2) USING ROUND(exclusive_tax::NUMERIC, 2);
+
+ALTER TABLE order_product_variants
+  ALTER COLUMN variant_price TYPE NUMERIC(19, 2) USING ROUND(variant_price::NUMERIC, 2),
+  ALTER COLUMN shipping_price TYPE NUMERIC(19, 2) USING ROUND(shipping_price::NUMERIC, 2),
+  ALTER COLUMN discount TYPE NUMERIC(19, 2) USING ROUND(discount::NUMERIC, 2),
+  ALTER COLUMN tax TYPE NUMERIC(19, 2) USING ROUND(tax::NUMERIC, 2),
+  ALTER COLUMN commission TYPE NUMERIC(19, 2) USING ROUND(commission::NUMERIC, 2);
+
+ALTER TABLE order_returns
+  ALTER COLUMN total_refund TYPE NUMERIC(19, 2) USING ROUND(total_refund::NUMERIC, 2);
+
+ALTER TABLE order_return_items
+  ALTER COLUMN refund_amount TYPE NUMERIC(19, 2) USING ROUND(refund_amount::NUMERIC, 2);
+
+ALTER TABLE coupons
+  ALTER COLUMN min_basket TYPE NUMERIC(19, 2) USING ROUND(min_basket::NUMERIC, 2);
+
+ALTER TABLE shipping_rate_tables
+  ALTER COLUMN free_shipping_threshold TYPE NUMERIC(19, 2)
+    USING ROUND(free_shipping_threshold::NUMERIC, 2);
+
+ALTER TABLE shipping_rate_tiers
+  ALTER COLUMN base_price TYPE NUMERIC(19, 2) USING ROUND(base_price::NUMERIC, 2),
+  ALTER COLUMN price_per_unit TYPE NUMERIC(19, 2) USING ROUND(price_per_unit::NUMERIC, 2);
+
+ALTER TABLE platform_wallet
+  ALTER COLUMN balance TYPE NUMERIC(19, 2) USING ROUND(balance::NUMERIC, 2);
+
+-- a posting that is rounded to zero is kept, the check only applies to the
+-- new postings
+ALTER TABLE ledger_postings DROP CONSTRAINT ledger_postings_amount_check;
+
+ALTER TABLE ledger_postings
+  ALTER COLUMN amount TYPE NUMERIC(19, 2) USING ROUND(amount::NUMERIC, 2);
+
+ALTER TABLE ledger_postings
+  ADD CONSTRAINT ledger_postings_amount_check CHECK (amount <> 0) NOT VALID;
+
+-- the cached balances are derived from the rounded postings again
+UPDATE wallets w
+SET balance = COALESCE((
+  SELECT SUM(lp.amount) FROM ledger_accounts la
+  JOIN ledger_postings lp ON lp.account_id = la.id
+  WHERE la.wallet_id = w.id
+), 0);
+
+UPDATE platform_wallet
+SET balance = COALESCE((
+  SELECT SUM(lp.amount) FROM ledger_accounts la
+  JOIN ledger_postings lp ON lp.account_id = la.id
+  WHERE la.kind = 'platform'
+), 0);
+
+-- the postings of an entry must add up to exactly zero now
+CREATE OR REPLACE FUNCTION check_journal_entry_balance()
+RETURNS TRIGGER AS $$
+DECLARE
+  postings_count INTEGER;
+  postings_sum NUMERIC(19, 2);
+BEGIN
+  SELECT COUNT(*), COALESCE(SUM(amount), 0) INTO postings_count, postings_sum
+  FROM ledger_postings
+  WHERE entry_id = NEW.id;
+
+  IF postings_count < 2 OR postings_sum <> 0 THEN
+    RAISE EXCEPTION 'journal entry % is not balanced: postings = %, sum = %',
+      NEW.id, postings_count, postings_sum;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
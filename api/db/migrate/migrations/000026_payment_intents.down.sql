@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS payment_intents;
+
+DROP TYPE "payment_intent_statuses";
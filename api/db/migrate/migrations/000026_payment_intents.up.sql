@@ -0,0 +1,29 @@
+CREATE TYPE "payment_intent_statuses" AS ENUM ('pending', 'succeeded', 'failed', 'refunded');
+
+-- a payment intent is a payment collected by an external payment provider,
+-- the money of an intent is deposited to a wallet and when the intent pays
+-- an order, it is spent on the order right away
+CREATE TABLE payment_intents (
+  id SERIAL PRIMARY KEY,
+  provider VARCHAR(50) NOT NULL,
+  reference VARCHAR(255) NOT NULL,
+  amount NUMERIC(19, 2) NOT NULL CHECK (amount > 0),
+  status VARCHAR(20) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  wallet_transaction_id INTEGER NOT NULL UNIQUE REFERENCES wallet_transactions(id) ON DELETE RESTRICT,
+  order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+
+  UNIQUE (provider, reference)
+);
+
+ALTER TABLE payment_intents
+  ALTER COLUMN status TYPE payment_intent_statuses USING status::payment_intent_statuses;
+
+ALTER TABLE payment_intents
+  ALTER COLUMN status SET DEFAULT 'pending'::payment_intent_statuses;
+
+-- an order can only be paid by one intent at a time
+CREATE UNIQUE INDEX payment_intents_pending_order_id_key
+  ON payment_intents (order_id) WHERE status = 'pending';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS wallet_transactions_wallet_id_tx_type_idx;
+
+ALTER TABLE journal_entries DROP COLUMN IF EXISTS wallet_transfer_id;
+
+DROP TABLE IF EXISTS wallet_transfers;
+
+-- postgres cannot drop values from an enum type, so the transfer values
+-- stay in transaction_types and journal_entry_kinds until the types
+-- themselves are dropped.
@@ -0,0 +1,24 @@
+ALTER TYPE transaction_types ADD VALUE IF NOT EXISTS 'transfer_out';
+ALTER TYPE transaction_types ADD VALUE IF NOT EXISTS 'transfer_in';
+ALTER TYPE journal_entry_kinds ADD VALUE IF NOT EXISTS 'transfer';
+
+-- a transfer moves money from one wallet to another, it links the
+-- transaction that debits the sender with the one that credits the receiver
+CREATE TABLE wallet_transfers (
+  id SERIAL PRIMARY KEY,
+  amount NUMERIC(19, 2) NOT NULL CHECK (amount > 0),
+  memo VARCHAR(255),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  sender_tx_id INTEGER NOT NULL UNIQUE REFERENCES wallet_transactions(id) ON DELETE RESTRICT,
+  receiver_tx_id INTEGER NOT NULL UNIQUE REFERENCES wallet_transactions(id) ON DELETE RESTRICT,
+  CHECK (sender_tx_id <> receiver_tx_id)
+);
+
+ALTER TABLE journal_entries
+  ADD COLUMN wallet_transfer_id INTEGER REFERENCES wallet_transfers(id) ON DELETE RESTRICT;
+
+-- the daily transfer limits of a user are checked against their latest
+-- outgoing transfers
+CREATE INDEX wallet_transactions_wallet_id_tx_type_idx
+  ON wallet_transactions (wallet_id, tx_type, created_at);
@@ -0,0 +1,3 @@
+-- postgres cannot drop values from an enum type, so the escrow values stay
+-- in ledger_account_kinds and journal_entry_kinds until the types themselves
+-- are dropped.
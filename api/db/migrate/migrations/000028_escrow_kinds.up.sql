@@ -0,0 +1,4 @@
+-- the new values cannot be used in the transaction that adds them, so they
+-- are added before the escrow account and holds are created
+ALTER TYPE ledger_account_kinds ADD VALUE IF NOT EXISTS 'escrow';
+ALTER TYPE journal_entry_kinds ADD VALUE IF NOT EXISTS 'escrow_release';
@@ -0,0 +1,37 @@
+-- the money that is still held is released to the wallets of the store
+-- owners, so it is not left in the escrow account
+DO $$
+DECLARE
+  escrow_account_id INTEGER;
+  release_entry_id INTEGER;
+  hold_record RECORD;
+BEGIN
+  SELECT id INTO escrow_account_id FROM ledger_accounts WHERE kind = 'escrow';
+
+  FOR hold_record IN
+    SELECT eh.id, eh.order_id, eh.store_id, eh.amount - eh.refunded_amount AS remaining, la.id AS account_id
+    FROM escrow_holds eh
+    JOIN ledger_accounts la ON la.wallet_id = eh.wallet_id
+    WHERE eh.status = 'held' AND eh.amount > eh.refunded_amount
+    ORDER BY eh.id
+  LOOP
+    INSERT INTO journal_entries (kind, description, order_id)
+    VALUES (
+      'escrow_release',
+      format('Release of order #%s for store #%s', hold_record.order_id, hold_record.store_id),
+      hold_record.order_id
+    )
+    RETURNING id INTO release_entry_id;
+
+    INSERT INTO ledger_postings (amount, entry_id, account_id) VALUES
+      (-hold_record.remaining, release_entry_id, escrow_account_id),
+      (hold_record.remaining, release_entry_id, hold_record.account_id);
+  END LOOP;
+END;
+$$;
+
+DROP TABLE IF EXISTS escrow_holds;
+DROP TYPE "escrow_hold_statuses";
+
+-- the escrow account stays in the ledger, it is referenced by the postings
+-- of the released holds
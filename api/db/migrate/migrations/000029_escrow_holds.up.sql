@@ -0,0 +1,35 @@
+CREATE TYPE "escrow_hold_statuses" AS ENUM ('held', 'released');
+
+-- the escrow account holds the earnings of the stores from the paid orders
+-- until they are released to the wallets of the store owners
+INSERT INTO ledger_accounts (kind) VALUES ('escrow');
+
+-- a hold is the part of the payment of an order that a store earns, it is
+-- released when the shipment of the store is delivered or once its release
+-- date is reached, the refunds of the store are taken from the hold first
+CREATE TABLE escrow_holds (
+  id SERIAL PRIMARY KEY,
+  amount NUMERIC(19, 2) NOT NULL CHECK (amount > 0),
+  refunded_amount NUMERIC(19, 2) NOT NULL DEFAULT 0,
+  status VARCHAR(20) NOT NULL,
+  release_at TIMESTAMP NOT NULL,
+  released_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+  store_id INTEGER NOT NULL REFERENCES stores(id) ON DELETE RESTRICT,
+  wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+
+  UNIQUE (order_id, store_id),
+  CHECK (refunded_amount >= 0 AND refunded_amount <= amount)
+);
+
+ALTER TABLE escrow_holds
+  ALTER COLUMN status TYPE escrow_hold_statuses USING status::escrow_hold_statuses;
+
+ALTER TABLE escrow_holds
+  ALTER COLUMN status SET DEFAULT 'held'::escrow_hold_statuses;
+
+CREATE INDEX escrow_holds_held_release_at_idx ON escrow_holds (release_at) WHERE status = 'held';
+CREATE INDEX escrow_holds_held_wallet_id_idx ON escrow_holds (wallet_id) WHERE status = 'held';
@@ -0,0 +1,6 @@
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_add_withdrawal_tier';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_update_withdrawal_tier';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_delete_withdrawal_tier';
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_update_user_withdrawal_profile';
+
+ALTER TYPE resources ADD VALUE IF NOT EXISTS 'withdrawal_tiers_full_access';
@@ -0,0 +1,8 @@
+DELETE FROM permission_groups WHERE name = 'Withdrawal Tier Management';
+
+DROP TRIGGER trg_record_user_credentials_change ON users;
+DROP FUNCTION record_user_credentials_change();
+
+DROP TABLE user_withdrawal_profiles;
+DROP TABLE role_withdrawal_tiers;
+DROP TABLE withdrawal_tiers;
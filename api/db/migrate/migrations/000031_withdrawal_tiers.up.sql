@@ -0,0 +1,88 @@
+-- withdrawal tiers set the limits of the withdrawals of the users, a limit
+-- of zero means that there is no limit. The daily and the monthly limits are
+-- checked against the pending and the successful withdrawals of the last 24
+-- hours and 30 days, the users whose identity is verified (KYC) get the kyc
+-- limits instead. After a password or email change, withdrawals are blocked
+-- for the cooldown of the tier.
+CREATE TABLE withdrawal_tiers (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(255) UNIQUE NOT NULL,
+  min_amount NUMERIC(19, 2) NOT NULL DEFAULT 0 CHECK (min_amount >= 0),
+  daily_limit NUMERIC(19, 2) NOT NULL DEFAULT 0 CHECK (daily_limit >= 0),
+  monthly_limit NUMERIC(19, 2) NOT NULL DEFAULT 0 CHECK (monthly_limit >= 0),
+  kyc_daily_limit NUMERIC(19, 2) NOT NULL DEFAULT 0 CHECK (kyc_daily_limit >= 0),
+  kyc_monthly_limit NUMERIC(19, 2) NOT NULL DEFAULT 0 CHECK (kyc_monthly_limit >= 0),
+  cooldown_in_hours INTEGER NOT NULL DEFAULT 0 CHECK (cooldown_in_hours >= 0),
+  is_default BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the default tier applies to the users that have no tier of their own and
+-- whose role has no tier, there is only one of it
+CREATE UNIQUE INDEX withdrawal_tiers_is_default_key
+  ON withdrawal_tiers (is_default) WHERE is_default;
+
+-- the default tier has no limits, so the withdrawals work as they did before
+-- the tiers until the admins set them
+INSERT INTO withdrawal_tiers (name, is_default) VALUES ('Default', TRUE);
+
+CREATE TABLE role_withdrawal_tiers (
+  role_id INTEGER PRIMARY KEY REFERENCES roles(id) ON DELETE CASCADE,
+  tier_id INTEGER NOT NULL REFERENCES withdrawal_tiers(id) ON DELETE CASCADE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the withdrawal profile of a user keeps the tier that is set for the user
+-- alone, whether the identity of the user is verified and the last time
+-- that the password or the email of the user was changed
+CREATE TABLE user_withdrawal_profiles (
+  user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  kyc_verified BOOLEAN NOT NULL DEFAULT FALSE,
+  kyc_verified_at TIMESTAMP,
+  credentials_changed_at TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  tier_id INTEGER REFERENCES withdrawal_tiers(id) ON DELETE SET NULL
+);
+
+CREATE OR REPLACE FUNCTION record_user_credentials_change()
+RETURNS TRIGGER AS $$
+BEGIN
+  INSERT INTO user_withdrawal_profiles (user_id, credentials_changed_at)
+  VALUES (NEW.id, CURRENT_TIMESTAMP)
+  ON CONFLICT (user_id) DO UPDATE
+  SET credentials_changed_at = EXCLUDED.credentials_changed_at,
+      updated_at = CURRENT_TIMESTAMP;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_record_user_credentials_change
+AFTER UPDATE ON users
+FOR EACH ROW
+WHEN (OLD.password IS DISTINCT FROM NEW.password OR OLD.email IS DISTINCT FROM NEW.email)
+EXECUTE FUNCTION record_user_credentials_change();
+
+INSERT INTO permission_groups
+  (name, description) VALUES
+  ('Withdrawal Tier Management', 'Can manage the withdrawal tiers & the withdrawal profiles of the users');
+
+INSERT INTO group_resource_permissions
+  (resource, group_id) VALUES
+  ('withdrawal_tiers_full_access', (SELECT id FROM permission_groups WHERE name = 'Withdrawal Tier Management'));
+
+INSERT INTO group_action_permissions
+  (action, group_id) VALUES
+  ('can_add_withdrawal_tier', (SELECT id FROM permission_groups WHERE name = 'Withdrawal Tier Management')),
+  ('can_update_withdrawal_tier', (SELECT id FROM permission_groups WHERE name = 'Withdrawal Tier Management')),
+  ('can_delete_withdrawal_tier', (SELECT id FROM permission_groups WHERE name = 'Withdrawal Tier Management')),
+  ('can_update_user_withdrawal_profile', (SELECT id FROM permission_groups WHERE name = 'Withdrawal Tier Management'));
+
+INSERT INTO role_group_assignments
+  (role_id, permission_group_id) VALUES
+  (
+    (SELECT id FROM roles WHERE name = 'Admin'),
+    (SELECT id FROM permission_groups WHERE name = 'Withdrawal Tier Management')
+  );
@@ -0,0 +1,2 @@
+-- postgres cannot drop values from an enum type, so the wallet drift values
+-- stay in actions and resources until the types themselves are dropped.
@@ -0,0 +1,3 @@
+ALTER TYPE actions ADD VALUE IF NOT EXISTS 'can_resolve_wallet_drift';
+
+ALTER TYPE resources ADD VALUE IF NOT EXISTS 'wallet_drifts_full_access';
@@ -0,0 +1,3 @@
+DELETE FROM permission_groups WHERE name = 'Wallet Reconciliation';
+
+DROP TABLE wallet_drifts;
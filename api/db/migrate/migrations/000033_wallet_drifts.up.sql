@@ -0,0 +1,42 @@
+-- a drift is a wallet whose cached balance or ledger balance is different
+-- from the balance expected from its history, the drift stays open until an
+-- admin resolves it and the next reconciliation updates the open drift
+-- instead of opening another one
+CREATE TABLE wallet_drifts (
+  id SERIAL PRIMARY KEY,
+  cached_balance NUMERIC(19, 2) NOT NULL,
+  ledger_balance NUMERIC(19, 2) NOT NULL,
+  expected_balance NUMERIC(19, 2) NOT NULL,
+  frozen BOOLEAN NOT NULL DEFAULT FALSE,
+  note VARCHAR(255),
+  detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  checked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  resolved_at TIMESTAMP,
+
+  wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+  resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX wallet_drifts_open_wallet_id_key ON wallet_drifts (wallet_id)
+WHERE resolved_at IS NULL;
+
+CREATE INDEX wallet_drifts_detected_at_idx ON wallet_drifts (detected_at);
+
+INSERT INTO permission_groups
+  (name, description) VALUES
+  ('Wallet Reconciliation', 'Can review & resolve the wallets whose balance has drifted from their history');
+
+INSERT INTO group_resource_permissions
+  (resource, group_id) VALUES
+  ('wallet_drifts_full_access', (SELECT id FROM permission_groups WHERE name = 'Wallet Reconciliation'));
+
+INSERT INTO group_action_permissions
+  (action, group_id) VALUES
+  ('can_resolve_wallet_drift', (SELECT id FROM permission_groups WHERE name = 'Wallet Reconciliation'));
+
+INSERT INTO role_group_assignments
+  (role_id, permission_group_id) VALUES
+  (
+    (SELECT id FROM roles WHERE name = 'Admin'),
+    (SELECT id FROM permission_groups WHERE name = 'Wallet Reconciliation')
+  );
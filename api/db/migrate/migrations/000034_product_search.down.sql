@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS trg_refresh_product_search_documents_on_category_change ON product_categories;
+DROP FUNCTION IF EXISTS refresh_product_search_documents_on_category_change;
+DROP FUNCTION IF EXISTS refresh_product_search_documents_of_category;
+DROP TRIGGER IF EXISTS trg_refresh_product_search_documents_on_tag_change ON product_tags;
+DROP FUNCTION IF EXISTS refresh_product_search_documents_on_tag_change;
+DROP TRIGGER IF EXISTS trg_refresh_product_search_document_on_tag_assignment_change ON product_tag_assignments;
+DROP TRIGGER IF EXISTS trg_refresh_product_search_document_on_spec_change ON product_specs;
+DROP FUNCTION IF EXISTS refresh_product_search_document_on_child_change;
+DROP TRIGGER IF EXISTS trg_set_product_search_document ON products;
+DROP FUNCTION IF EXISTS set_product_search_document;
+DROP FUNCTION IF EXISTS refresh_product_search_document;
+DROP FUNCTION IF EXISTS build_product_search_document;
+DROP INDEX IF EXISTS idx_products_search_document;
+ALTER TABLE products DROP COLUMN search_document;
@@ -0,0 +1,154 @@
+ALTER TABLE products ADD COLUMN search_document TSVECTOR NOT NULL DEFAULT ''::tsvector;
+
+CREATE INDEX idx_products_search_document ON products USING GIN (search_document);
+
+CREATE OR REPLACE FUNCTION build_product_search_document(
+  p_id INTEGER,
+  p_name VARCHAR,
+  p_description VARCHAR,
+  p_subcategory_id INTEGER
+)
+RETURNS TSVECTOR AS $$
+DECLARE
+  tags TEXT;
+  categories TEXT;
+  specs TEXT;
+BEGIN
+  SELECT string_agg(pt.name, ' ') INTO tags
+  FROM product_tag_assignments pta
+  JOIN product_tags pt ON pt.id = pta.tag_id
+  WHERE pta.product_id = p_id;
+
+  WITH RECURSIVE cat_tree AS (
+    SELECT id, name FROM product_categories WHERE id = p_subcategory_id
+    UNION ALL SELECT pc.id, pc.name FROM product_categories pc
+    JOIN cat_tree ct ON pc.parent_category_id = ct.id
+  )
+  SELECT string_agg(name, ' ') INTO categories FROM cat_tree;
+
+  SELECT string_agg(label || ' ' || value, ' ') INTO specs
+  FROM product_specs WHERE product_id = p_id;
+
+  RETURN
+    setweight(to_tsvector('english', COALESCE(p_name, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(tags, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(categories, '')), 'C') ||
+    setweight(
+      to_tsvector('english', COALESCE(p_description, '') || ' ' || COALESCE(specs, '')),
+      'D'
+    );
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+CREATE OR REPLACE FUNCTION refresh_product_search_document(p_id INTEGER)
+RETURNS VOID AS $$
+BEGIN
+  UPDATE products
+  SET search_document = build_product_search_document(id, name, description, subcategory_id)
+  WHERE id = p_id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION set_product_search_document()
+RETURNS TRIGGER AS $$
+BEGIN
+  NEW.search_document := build_product_search_document(
+    NEW.id, NEW.name, NEW.description, NEW.subcategory_id
+  );
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION refresh_product_search_document_on_child_change()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') THEN
+    PERFORM refresh_product_search_document(OLD.product_id);
+  END IF;
+
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    IF TG_OP = 'INSERT' OR NEW.product_id IS DISTINCT FROM OLD.product_id THEN
+      PERFORM refresh_product_search_document(NEW.product_id);
+    END IF;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION refresh_product_search_documents_on_tag_change()
+RETURNS TRIGGER AS $$
+BEGIN
+  UPDATE products p
+  SET search_document = build_product_search_document(p.id, p.name, p.description, p.subcategory_id)
+  WHERE p.id IN (SELECT product_id FROM product_tag_assignments WHERE tag_id = NEW.id);
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION refresh_product_search_documents_of_category(c_id INTEGER)
+RETURNS VOID AS $$
+BEGIN
+  UPDATE products p
+  SET search_document = build_product_search_document(p.id, p.name, p.description, p.subcategory_id)
+  WHERE p.subcategory_id IN (
+    WITH RECURSIVE cat_tree AS (
+      SELECT id, parent_category_id FROM product_categories WHERE id = c_id
+      UNION ALL SELECT pc.id, pc.parent_category_id FROM product_categories pc
+      JOIN cat_tree ct ON pc.id = ct.parent_category_id
+    )
+    SELECT id FROM cat_tree
+  );
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION refresh_product_search_documents_on_category_change()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    PERFORM refresh_product_search_documents_of_category(NEW.id);
+  ELSIF TG_OP = 'DELETE' THEN
+    PERFORM refresh_product_search_documents_of_category(OLD.parent_category_id);
+  ELSIF OLD.name IS DISTINCT FROM NEW.name OR
+    OLD.parent_category_id IS DISTINCT FROM NEW.parent_category_id THEN
+    PERFORM refresh_product_search_documents_of_category(NEW.id);
+
+    IF OLD.parent_category_id IS DISTINCT FROM NEW.parent_category_id THEN
+      PERFORM refresh_product_search_documents_of_category(OLD.parent_category_id);
+    END IF;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_set_product_search_document
+BEFORE INSERT OR UPDATE OF name, description, subcategory_id ON products
+FOR EACH ROW
+EXECUTE FUNCTION set_product_search_document();
+
+CREATE TRIGGER trg_refresh_product_search_document_on_spec_change
+AFTER INSERT OR UPDATE OR DELETE ON product_specs
+FOR EACH ROW
+EXECUTE FUNCTION refresh_product_search_document_on_child_change();
+
+CREATE TRIGGER trg_refresh_product_search_document_on_tag_assignment_change
+AFTER INSERT OR UPDATE OR DELETE ON product_tag_assignments
+FOR EACH ROW
+EXECUTE FUNCTION refresh_product_search_document_on_child_change();
+
+CREATE TRIGGER trg_refresh_product_search_documents_on_tag_change
+AFTER UPDATE OF name ON product_tags
+FOR EACH ROW
+WHEN (OLD.name IS DISTINCT FROM NEW.name)
+EXECUTE FUNCTION refresh_product_search_documents_on_tag_change();
+
+CREATE TRIGGER trg_refresh_product_search_documents_on_category_change
+AFTER INSERT OR UPDATE OR DELETE ON product_categories
+FOR EACH ROW
+EXECUTE FUNCTION refresh_product_search_documents_on_category_change();
+
+UPDATE products
+SET search_document = build_product_search_document(id, name, description, subcategory_id);
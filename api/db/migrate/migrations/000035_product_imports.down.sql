@@ -0,0 +1,5 @@
+DROP TABLE product_import_errors;
+DROP TABLE product_imports;
+
+DROP TYPE "product_import_statuses";
+DROP TYPE "product_import_formats";
@@ -0,0 +1,38 @@
+CREATE TYPE "product_import_formats" AS ENUM ('csv', 'jsonl');
+CREATE TYPE "product_import_statuses" AS ENUM ('pending', 'running', 'completed', 'failed');
+
+-- an import is a file of products that a store owner uploaded, it is kept
+-- until a worker picks it up and applies each of its rows in a transaction
+-- of its own, the rows of a dry run are checked and rolled back
+CREATE TABLE product_imports (
+  id SERIAL PRIMARY KEY,
+  format product_import_formats NOT NULL,
+  dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+  status product_import_statuses NOT NULL DEFAULT 'pending',
+  data TEXT NOT NULL,
+  total_rows INTEGER NOT NULL DEFAULT 0,
+  created_rows INTEGER NOT NULL DEFAULT 0,
+  updated_rows INTEGER NOT NULL DEFAULT 0,
+  failed_rows INTEGER NOT NULL DEFAULT 0,
+  error VARCHAR(1023),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP,
+
+  store_id INTEGER NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+  user_id INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX product_imports_pending_idx ON product_imports (id) WHERE status = 'pending';
+CREATE INDEX product_imports_store_id_idx ON product_imports (store_id);
+
+CREATE TABLE product_import_errors (
+  id SERIAL PRIMARY KEY,
+  row_number INTEGER NOT NULL,
+  slug VARCHAR(255),
+  message VARCHAR(1023) NOT NULL,
+
+  import_id INTEGER NOT NULL REFERENCES product_imports(id) ON DELETE CASCADE
+);
+
+CREATE INDEX product_import_errors_import_id_idx ON product_import_errors (import_id);
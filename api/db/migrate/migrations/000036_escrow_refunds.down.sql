@@ -0,0 +1,3 @@
+-- postgres cannot drop values from an enum type, so the refund values stay
+-- in escrow_hold_statuses and journal_entry_kinds until the types themselves
+-- are dropped.
@@ -0,0 +1,4 @@
+-- the held earnings of a store whose shipment is cancelled go back to the
+-- customer, the new values cannot be used in the transaction that adds them
+ALTER TYPE escrow_hold_statuses ADD VALUE IF NOT EXISTS 'refunded';
+ALTER TYPE journal_entry_kinds ADD VALUE IF NOT EXISTS 'escrow_refund';
@@ -0,0 +1,16 @@
+ALTER TABLE coupons
+  DROP CONSTRAINT coupons_fixed_amount_check,
+  DROP CONSTRAINT coupons_percentage_rate_check;
+
+UPDATE coupons
+SET rate = amount::FLOAT8
+WHERE discount_type = 'fixed';
+
+ALTER TABLE coupons DROP COLUMN amount;
+
+ALTER TABLE coupons RENAME COLUMN rate TO amount;
+
+ALTER TABLE coupons
+  ALTER COLUMN amount SET NOT NULL,
+  ADD CONSTRAINT coupons_percentage_amount_check
+  CHECK (discount_type <> 'percentage' OR amount <= 1);
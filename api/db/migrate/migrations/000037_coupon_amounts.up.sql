@@ -0,0 +1,18 @@
+-- percentage coupons keep their fraction as a rate, fixed coupons get their
+-- discount as an exact amount of money
+ALTER TABLE coupons RENAME COLUMN amount TO rate;
+
+ALTER TABLE coupons
+  ALTER COLUMN rate DROP NOT NULL,
+  ADD COLUMN amount NUMERIC(19, 2) CHECK (amount > 0);
+
+UPDATE coupons
+SET amount = ROUND(rate::NUMERIC, 2), rate = NULL
+WHERE discount_type = 'fixed';
+
+ALTER TABLE coupons
+  DROP CONSTRAINT coupons_percentage_amount_check,
+  ADD CONSTRAINT coupons_percentage_rate_check
+  CHECK (discount_type <> 'percentage' OR (rate IS NOT NULL AND rate <= 1 AND amount IS NULL)),
+  ADD CONSTRAINT coupons_fixed_amount_check
+  CHECK (discount_type <> 'fixed' OR (amount IS NOT NULL AND rate IS NULL));
@@ -17,10 +17,21 @@ import (
 	db_manager "github.com/SaeedAlian/econest/api/db/manager"
 	"github.com/SaeedAlian/econest/api/lib"
 	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/services/product"
 	"github.com/SaeedAlian/econest/api/types"
 )
 
 var cliMode = flag.Bool("cli", false, "Run in CLI mode")
+var reconcileMode = flag.Bool(
+	"reconcile-wallets",
+	false,
+	"Check the wallet balances against their history and exit",
+)
+var freezeDrifted = flag.Bool(
+	"freeze-drifted",
+	config.Env.WalletReconciliationFreezeDrifted,
+	"Freeze the wallets whose balance has drifted from their history",
+)
 
 // @title           EcoNest API
 // @version         0.1.0 (BETA)
@@ -46,6 +57,14 @@ func main() {
 		return
 	}
 
+	if *reconcileMode {
+		err := reconcileWallets(db_manager.NewManager(db), *freezeDrifted)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	ksCache := redis.NewClient(&redis.Options{
 		Addr: config.Env.KeyServerRedisAddr,
 	})
@@ -61,6 +80,43 @@ func main() {
 		}
 	}()
 
+	dbManager := db_manager.NewManager(db)
+
+	go func() {
+		sweepInterval := config.Env.InventoryHoldSweepIntervalInMin * float64(time.Minute)
+		c := time.Tick(time.Duration(sweepInterval))
+		for range c {
+			cancelExpiredPendingOrders(dbManager)
+		}
+	}()
+
+	go func() {
+		sweepInterval := config.Env.EscrowReleaseSweepIntervalInMin * float64(time.Minute)
+		c := time.Tick(time.Duration(sweepInterval))
+		for range c {
+			releaseDueEscrowHolds(dbManager)
+		}
+	}()
+
+	go func() {
+		reconcileInterval := config.Env.WalletReconciliationIntervalInMin * float64(time.Minute)
+		c := time.Tick(time.Duration(reconcileInterval))
+		for range c {
+			err := reconcileWallets(dbManager, config.Env.WalletReconciliationFreezeDrifted)
+			if err != nil {
+				log.Println("could not reconcile wallets:", err)
+			}
+		}
+	}()
+
+	go func() {
+		sweepInterval := config.Env.ProductImportSweepIntervalInMin * float64(time.Minute)
+		c := time.Tick(time.Duration(sweepInterval))
+		for range c {
+			processPendingProductImports(dbManager)
+		}
+	}()
+
 	server := api.NewServer(fmt.Sprintf(":%s", config.Env.Port), db, keyServer)
 
 	if err := server.Run(); err != nil {
@@ -88,6 +144,70 @@ func rotateKeys(keyServer *auth.KeyServer) {
 	log.Println("keys rotated")
 }
 
+func cancelExpiredPendingOrders(dbManager *db_manager.Manager) {
+	cancelled, err := dbManager.CancelExpiredPendingOrders()
+	if err != nil {
+		log.Println("could not cancel expired pending orders:", err)
+		return
+	}
+
+	if cancelled > 0 {
+		log.Printf("%d expired pending orders cancelled\n", cancelled)
+	}
+}
+
+func releaseDueEscrowHolds(dbManager *db_manager.Manager) {
+	released, err := dbManager.ReleaseDueEscrowHolds()
+	if err != nil {
+		log.Println("could not release due escrow holds:", err)
+	}
+
+	if released > 0 {
+		log.Printf("%d due escrow holds released\n", released)
+	}
+}
+
+func processPendingProductImports(dbManager *db_manager.Manager) {
+	processed, err := product.ProcessPendingProductImports(dbManager)
+	if err != nil {
+		log.Println("could not process pending product imports:", err)
+	}
+
+	if processed > 0 {
+		log.Printf("%d product imports processed\n", processed)
+	}
+}
+
+// reconcileWallets checks the balance of every wallet against its history
+// and logs the wallets whose balance has drifted.
+func reconcileWallets(dbManager *db_manager.Manager, freeze bool) error {
+	report, err := dbManager.ReconcileWallets(freeze)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range report.Drifts {
+		log.Printf(
+			"wallet #%d of user #%d has drifted: cached %s, ledger %s, expected %s, frozen: %t\n",
+			d.WalletId,
+			d.UserId,
+			d.CachedBalance,
+			d.LedgerBalance,
+			d.ExpectedBalance,
+			d.Frozen,
+		)
+	}
+
+	log.Printf(
+		"%d wallets reconciled, %d drifted, %d frozen\n",
+		report.CheckedWallets,
+		len(report.Drifts),
+		report.FrozenWallets,
+	)
+
+	return nil
+}
+
 func runCli(db *sql.DB) error {
 	reader := bufio.NewReader(os.Stdin)
 	dbManager := db_manager.NewManager(db)
@@ -1,9 +1,14 @@
 package auth
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -238,4 +243,49 @@ func TestAuthHandler(t *testing.T) {
 			t.Fatal("expected not valid csrf token, but got valid")
 		}
 	})
+
+	t.Run("should replay idempotent requests", func(t *testing.T) {
+		calls := 0
+		handler := h.WithIdempotencyKey(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"call":%d}`, calls)
+		})
+
+		key := fmt.Sprintf("test-key-%d", time.Now().UnixNano())
+		send := func(body string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("POST", "/order", strings.NewReader(body))
+			r.Header.Set("Idempotency-Key", key)
+			r = r.WithContext(context.WithValue(r.Context(), "userId", testUser.Id))
+
+			w := httptest.NewRecorder()
+			handler(w, r)
+			return w
+		}
+
+		first := send(`{"amount":10}`)
+		if first.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, but got %d", http.StatusCreated, first.Code)
+		}
+
+		replay := send(`{"amount":10}`)
+		if replay.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, but got %d", http.StatusCreated, replay.Code)
+		}
+		if replay.Body.String() != first.Body.String() {
+			t.Fatalf("expected replayed body %s, but got %s", first.Body.String(), replay.Body.String())
+		}
+		if replay.Header().Get("Idempotent-Replayed") != "true" {
+			t.Fatal("expected replayed response to be marked, but it is not")
+		}
+		if calls != 1 {
+			t.Fatalf("expected handler to be called once, but it was called %d times", calls)
+		}
+
+		reused := send(`{"amount":20}`)
+		if reused.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status %d, but got %d", http.StatusUnprocessableEntity, reused.Code)
+		}
+	})
 }
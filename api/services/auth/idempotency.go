@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/SaeedAlian/econest/api/config"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencySaveAttempts is how many times the response of a completed
+// request is tried to be saved under its key
+const idempotencySaveAttempts = 3
+
+// idempotencyRecord is the state of a request saved under its idempotency key,
+// the response is only set once the request is completed
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// idempotencyResponseWriter writes the response to the client and keeps a
+// copy of it to be replayed for the retries of the request
+type idempotencyResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WithIdempotencyKey makes a route safe to retry when the client sends an
+// Idempotency-Key header. The first request with a key is handled and its
+// response is saved per user, the retries with the same key and the same
+// request get the saved response, a retry that arrives while the first
+// request is still handled gets 409 and reusing a key for another request
+// gets 422. Requests without the header are handled as usual. It needs the
+// user id, so it must wrap the handler of an authenticated route.
+func (h *AuthHandler) WithIdempotencyKey(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			handler(w, r)
+			return
+		}
+
+		if len(key) > 255 {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrInvalidIdempotencyKey)
+			return
+		}
+
+		userId := r.Context().Value("userId")
+		if userId == nil {
+			utils.WriteErrorInResponse(
+				w,
+				http.StatusUnauthorized,
+				types.ErrAuthenticationCredentialsNotFound,
+			)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := sha256.New()
+		fingerprint.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+		fingerprint.Write(body)
+
+		record := idempotencyRecord{
+			Fingerprint: hex.EncodeToString(fingerprint.Sum(nil)),
+		}
+
+		cacheKey := fmt.Sprintf("idempotency:%d:%s", userId.(int), key)
+		ttl := time.Duration(config.Env.IdempotencyKeyExpirationInMin) * time.Minute
+
+		pending, err := json.Marshal(record)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		isFirst, err := h.cache.SetNX(ctx, cacheKey, pending, ttl).Result()
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !isFirst {
+			h.replayIdempotentRequest(w, cacheKey, record.Fingerprint)
+			return
+		}
+
+		rw := &idempotencyResponseWriter{ResponseWriter: w}
+
+		defer func() {
+			// the key is only released if the handler has not done the request,
+			// so it can be retried
+			if rw.status == 0 || rw.status >= http.StatusInternalServerError {
+				h.cache.Del(ctx, cacheKey)
+			}
+		}()
+
+		handler(rw, r)
+
+		if rw.status == 0 || rw.status >= http.StatusInternalServerError {
+			return
+		}
+
+		record.Completed = true
+		record.Status = rw.status
+		record.ContentType = rw.Header().Get("Content-Type")
+		record.Body = rw.body.Bytes()
+
+		saved, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+
+		// if the response cannot be saved, the pending record is kept until it
+		// expires, so the retries get 409 instead of doing the request again
+		for range idempotencySaveAttempts {
+			err = h.cache.Set(ctx, cacheKey, saved, ttl).Err()
+			if err == nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *AuthHandler) replayIdempotentRequest(
+	w http.ResponseWriter,
+	cacheKey string,
+	fingerprint string,
+) {
+	saved, err := h.cache.Get(ctx, cacheKey).Bytes()
+	if err == redis.Nil {
+		// the first request failed and released the key in the meantime
+		utils.WriteErrorInResponse(w, http.StatusConflict, types.ErrIdempotentRequestInProgress)
+		return
+	} else if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	record := idempotencyRecord{}
+	err = json.Unmarshal(saved, &record)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnprocessableEntity,
+			types.ErrIdempotencyKeyReused,
+		)
+		return
+	}
+
+	if !record.Completed {
+		utils.WriteErrorInResponse(w, http.StatusConflict, types.ErrIdempotentRequestInProgress)
+		return
+	}
+
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
@@ -0,0 +1,347 @@
+package cart
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	db_manager "github.com/SaeedAlian/econest/api/db/manager"
+	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+type Handler struct {
+	db          *db_manager.Manager
+	authHandler *auth.AuthHandler
+}
+
+func NewHandler(
+	db *db_manager.Manager,
+	authHandler *auth.AuthHandler,
+) *Handler {
+	return &Handler{
+		db:          db,
+		authHandler: authHandler,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	withAuthRouter := router.Methods("GET", "POST", "PATCH", "DELETE").Subrouter()
+	withAuthRouter.HandleFunc("/me", h.getMyCart).Methods("GET")
+	withAuthRouter.HandleFunc("/me", h.clearMyCart).Methods("DELETE")
+	withAuthRouter.HandleFunc("/me/item", h.addMyCartItem).Methods("POST")
+	withAuthRouter.HandleFunc("/me/item/{variantId}", h.updateMyCartItem).Methods("PATCH")
+	withAuthRouter.HandleFunc("/me/item/{variantId}", h.deleteMyCartItem).Methods("DELETE")
+	withAuthRouter.HandleFunc("/me/checkout", h.authHandler.WithActionPermissionAuth(
+		h.authHandler.WithIdempotencyKey(h.checkoutMyCart),
+		h.db,
+		[]types.Action{types.ActionCanCreateOrder},
+	)).Methods("POST")
+	withAuthRouter.Use(h.authHandler.WithJWTAuth(h.db))
+	withAuthRouter.Use(h.authHandler.WithCSRFToken())
+	withAuthRouter.Use(h.authHandler.WithVerifiedEmail(h.db))
+	withAuthRouter.Use(h.authHandler.WithUnbannedProfile(h.db))
+}
+
+// getMyCart godoc
+// @Summary      Get current user's cart
+// @Description  Retrieves the current user's cart with its items priced using the current product prices and offers.
+// @Tags         cart
+// @Produce      json
+// @Success      200  {object}  types.CartWithItemsInfo
+// @Failure      401  {object}  types.HTTPError
+// @Failure      404  {object}  types.HTTPError
+// @Failure      500  {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /cart/me [get]
+func (h *Handler) getMyCart(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	cart, err := h.db.GetUserCartWithItemsInfo(userId)
+	if err != nil {
+		if err == types.ErrCartNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, cart, nil)
+}
+
+// addMyCartItem godoc
+// @Summary      Add item to cart
+// @Description  Adds a product variant to the current user's cart. If the variant is already in the cart, the quantity is increased.
+// @Tags         cart
+// @Accept       json
+// @Produce      json
+// @Param        item  body      types.AddCartItemPayload  true  "Cart item details"
+// @Success      201   {object}  types.NewCartItemResponse
+// @Failure      400   {object}  types.HTTPError
+// @Failure      401   {object}  types.HTTPError
+// @Failure      500   {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /cart/me/item [post]
+func (h *Handler) addMyCartItem(w http.ResponseWriter, r *http.Request) {
+	var payload types.AddCartItemPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	itemId, err := h.db.AddCartItem(userId, payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewCartItemResponse{
+		ItemId: itemId,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
+// updateMyCartItem godoc
+// @Summary      Update cart item
+// @Description  Updates the quantity of a product variant in the current user's cart.
+// @Tags         cart
+// @Accept       json
+// @Produce      json
+// @Param        variantId  path      int                          true  "Product variant ID"
+// @Param        item       body      types.UpdateCartItemPayload  true  "Cart item details"
+// @Success      200        "Cart item updated"
+// @Failure      400        {object}  types.HTTPError
+// @Failure      401        {object}  types.HTTPError
+// @Failure      404        {object}  types.HTTPError
+// @Failure      500        {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /cart/me/item/{variantId} [patch]
+func (h *Handler) updateMyCartItem(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateCartItemPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	variantId, err := utils.ParseIntURLParam("variantId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	err = h.db.UpdateCartItem(userId, variantId, payload)
+	if err != nil {
+		if err == types.ErrCartItemNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteMyCartItem godoc
+// @Summary      Remove cart item
+// @Description  Removes a product variant from the current user's cart.
+// @Tags         cart
+// @Produce      json
+// @Param        variantId  path      int  true  "Product variant ID"
+// @Success      200        "Cart item removed"
+// @Failure      400        {object}  types.HTTPError
+// @Failure      401        {object}  types.HTTPError
+// @Failure      404        {object}  types.HTTPError
+// @Failure      500        {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /cart/me/item/{variantId} [delete]
+func (h *Handler) deleteMyCartItem(w http.ResponseWriter, r *http.Request) {
+	variantId, err := utils.ParseIntURLParam("variantId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	err = h.db.DeleteCartItem(userId, variantId)
+	if err != nil {
+		if err == types.ErrCartItemNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// clearMyCart godoc
+// @Summary      Clear cart
+// @Description  Removes all the items from the current user's cart.
+// @Tags         cart
+// @Produce      json
+// @Success      200  "Cart cleared"
+// @Failure      401  {object}  types.HTTPError
+// @Failure      500  {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /cart/me [delete]
+func (h *Handler) clearMyCart(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	err := h.db.ClearCart(userId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// checkoutMyCart godoc
+// @Summary      Checkout cart
+// @Description  Turns the current user's cart into a new order and empties the cart. Requires create order permission.
+// @Tags         cart
+// @Accept       json
+// @Produce      json
+// @Param        checkout  body      types.CheckoutCartPayload  true  "Checkout details"
+// @Param        Idempotency-Key  header  string  false  "Key to safely retry the request"
+// @Success      201       {object}  types.NewOrderResponse
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      409       {object}  types.HTTPError
+// @Failure      422       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /cart/me/checkout [post]
+func (h *Handler) checkoutMyCart(w http.ResponseWriter, r *http.Request) {
+	var payload types.CheckoutCartPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	address, err := h.db.GetUserAddressById(payload.ReceiverAddressId)
+	if err != nil {
+		if err == types.ErrUserAddressNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if address.UserId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessAddress)
+		return
+	}
+
+	createdOrder, err := h.db.CheckoutCart(userId, payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewOrderResponse{
+		OrderId: createdOrder,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
@@ -0,0 +1,483 @@
+package commission
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/SaeedAlian/econest/api/config"
+	db_manager "github.com/SaeedAlian/econest/api/db/manager"
+	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+type Handler struct {
+	db          *db_manager.Manager
+	authHandler *auth.AuthHandler
+}
+
+func NewHandler(
+	db *db_manager.Manager,
+	authHandler *auth.AuthHandler,
+) *Handler {
+	return &Handler{
+		db:          db,
+		authHandler: authHandler,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	withAuthRouter := router.Methods("GET", "POST", "PATCH", "DELETE").Subrouter()
+	withAuthRouter.HandleFunc("/rule", h.authHandler.WithResourcePermissionAuth(
+		h.getCommissionRules,
+		h.db,
+		[]types.Resource{types.ResourceCommissionsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/rule/pages", h.authHandler.WithResourcePermissionAuth(
+		h.getCommissionRulesPages,
+		h.db,
+		[]types.Resource{types.ResourceCommissionsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/rule/{commissionRuleId}", h.authHandler.WithResourcePermissionAuth(
+		h.getCommissionRule,
+		h.db,
+		[]types.Resource{types.ResourceCommissionsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/wallet", h.authHandler.WithResourcePermissionAuth(
+		h.getPlatformWallet,
+		h.db,
+		[]types.Resource{types.ResourceCommissionsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/report", h.authHandler.WithResourcePermissionAuth(
+		h.getCommissionReport,
+		h.db,
+		[]types.Resource{types.ResourceCommissionsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/report/stores", h.authHandler.WithResourcePermissionAuth(
+		h.getStoresCommissionReport,
+		h.db,
+		[]types.Resource{types.ResourceCommissionsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/report/categories", h.authHandler.WithResourcePermissionAuth(
+		h.getCategoriesCommissionReport,
+		h.db,
+		[]types.Resource{types.ResourceCommissionsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/rule", h.authHandler.WithActionPermissionAuth(
+		h.createCommissionRule,
+		h.db,
+		[]types.Action{types.ActionCanAddCommissionRule},
+	)).Methods("POST")
+	withAuthRouter.HandleFunc("/rule/{commissionRuleId}", h.authHandler.WithActionPermissionAuth(
+		h.updateCommissionRule,
+		h.db,
+		[]types.Action{types.ActionCanUpdateCommissionRule},
+	)).Methods("PATCH")
+	withAuthRouter.HandleFunc("/rule/{commissionRuleId}", h.authHandler.WithActionPermissionAuth(
+		h.deleteCommissionRule,
+		h.db,
+		[]types.Action{types.ActionCanDeleteCommissionRule},
+	)).Methods("DELETE")
+	withAuthRouter.Use(h.authHandler.WithJWTAuth(h.db))
+	withAuthRouter.Use(h.authHandler.WithCSRFToken())
+	withAuthRouter.Use(h.authHandler.WithVerifiedEmail(h.db))
+	withAuthRouter.Use(h.authHandler.WithUnbannedProfile(h.db))
+}
+
+// getCommissionRules godoc
+// @Summary      Get commission rules
+// @Description  Retrieves a paginated list of commission rules with optional filtering. Requires full commissions access.
+// @Tags         commission
+// @Produce      json
+// @Param        store     query     int  false  "Filter by store ID"
+// @Param        category  query     int  false  "Filter by product category ID"
+// @Param        p         query     int  false  "Page number (default: 1)"
+// @Success      200       {array}   types.CommissionRule
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/rule [get]
+func (h *Handler) getCommissionRules(w http.ResponseWriter, r *http.Request) {
+	query := types.CommissionRuleSearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"store":    &query.StoreId,
+		"category": &query.CategoryId,
+		"p":        &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxCommissionRulesInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	rules, err := h.db.GetCommissionRules(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, rules, nil)
+}
+
+// getCommissionRulesPages godoc
+// @Summary      Get commission rules page count
+// @Description  Returns the total number of pages available for commission rules based on filters. Requires full commissions access.
+// @Tags         commission
+// @Produce      json
+// @Param        store     query     int  false  "Filter by store ID"
+// @Param        category  query     int  false  "Filter by product category ID"
+// @Success      200       {object}  types.TotalPageCountResponse
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/rule/pages [get]
+func (h *Handler) getCommissionRulesPages(w http.ResponseWriter, r *http.Request) {
+	query := types.CommissionRuleSearchQuery{}
+
+	queryMapping := map[string]any{
+		"store":    &query.StoreId,
+		"category": &query.CategoryId,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	count, err := h.db.GetCommissionRulesCount(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxCommissionRulesInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getCommissionRule godoc
+// @Summary      Get a commission rule
+// @Description  Retrieves details of a specific commission rule by ID. Requires full commissions access.
+// @Tags         commission
+// @Produce      json
+// @Param        commissionRuleId  path      int  true  "Commission rule ID"
+// @Success      200               {object}  types.CommissionRule
+// @Failure      400               {object}  types.HTTPError
+// @Failure      401               {object}  types.HTTPError
+// @Failure      403               {object}  types.HTTPError
+// @Failure      404               {object}  types.HTTPError
+// @Failure      500               {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/rule/{commissionRuleId} [get]
+func (h *Handler) getCommissionRule(w http.ResponseWriter, r *http.Request) {
+	commissionRuleId, err := utils.ParseIntURLParam("commissionRuleId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rule, err := h.db.GetCommissionRuleById(commissionRuleId)
+	if err != nil {
+		if err == types.ErrCommissionRuleNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, rule, nil)
+}
+
+// getPlatformWallet godoc
+// @Summary      Get the platform wallet
+// @Description  Retrieves the wallet that receives the fees of the paid orders. Requires full commissions access.
+// @Tags         commission
+// @Produce      json
+// @Success      200  {object}  types.PlatformWallet
+// @Failure      401  {object}  types.HTTPError
+// @Failure      403  {object}  types.HTTPError
+// @Failure      404  {object}  types.HTTPError
+// @Failure      500  {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/wallet [get]
+func (h *Handler) getPlatformWallet(w http.ResponseWriter, r *http.Request) {
+	wallet, err := h.db.GetPlatformWallet()
+	if err != nil {
+		if err == types.ErrPlatformWalletNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, wallet, nil)
+}
+
+// getCommissionReport godoc
+// @Summary      Get the commission report
+// @Description  Returns the commission earned from the orders paid in a period. Requires full commissions access.
+// @Tags         commission
+// @Produce      json
+// @Param        store  query     int     false  "Filter by store ID"
+// @Param        aftd   query     string  false  "Filter by the orders paid after this date (RFC3339)"
+// @Param        befd   query     string  false  "Filter by the orders paid before this date (RFC3339)"
+// @Success      200    {object}  types.CommissionReport
+// @Failure      400    {object}  types.HTTPError
+// @Failure      401    {object}  types.HTTPError
+// @Failure      403    {object}  types.HTTPError
+// @Failure      500    {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/report [get]
+func (h *Handler) getCommissionReport(w http.ResponseWriter, r *http.Request) {
+	query := types.CommissionReportQuery{}
+
+	queryMapping := map[string]any{
+		"store": &query.StoreId,
+		"aftd":  &query.AfterDate,
+		"befd":  &query.BeforeDate,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.db.GetCommissionReport(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, report, nil)
+}
+
+// getStoresCommissionReport godoc
+// @Summary      Get the commission report of the stores
+// @Description  Returns the commission earned from each store in a period, the stores that earned the most come first. Requires full commissions access.
+// @Tags         commission
+// @Produce      json
+// @Param        store  query     int     false  "Filter by store ID"
+// @Param        aftd   query     string  false  "Filter by the orders paid after this date (RFC3339)"
+// @Param        befd   query     string  false  "Filter by the orders paid before this date (RFC3339)"
+// @Param        p      query     int     false  "Page number (default: 1)"
+// @Success      200    {array}   types.StoreCommissionReport
+// @Failure      400    {object}  types.HTTPError
+// @Failure      401    {object}  types.HTTPError
+// @Failure      403    {object}  types.HTTPError
+// @Failure      500    {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/report/stores [get]
+func (h *Handler) getStoresCommissionReport(w http.ResponseWriter, r *http.Request) {
+	query, err := parseCommissionReportPageQuery(r)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reports, err := h.db.GetStoresCommissionReport(*query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, reports, nil)
+}
+
+// getCategoriesCommissionReport godoc
+// @Summary      Get the commission report of the categories
+// @Description  Returns the commission earned from the products of each category in a period, the categories that earned the most come first. Requires full commissions access.
+// @Tags         commission
+// @Produce      json
+// @Param        store  query     int     false  "Filter by store ID"
+// @Param        aftd   query     string  false  "Filter by the orders paid after this date (RFC3339)"
+// @Param        befd   query     string  false  "Filter by the orders paid before this date (RFC3339)"
+// @Param        p      query     int     false  "Page number (default: 1)"
+// @Success      200    {array}   types.CategoryCommissionReport
+// @Failure      400    {object}  types.HTTPError
+// @Failure      401    {object}  types.HTTPError
+// @Failure      403    {object}  types.HTTPError
+// @Failure      500    {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/report/categories [get]
+func (h *Handler) getCategoriesCommissionReport(w http.ResponseWriter, r *http.Request) {
+	query, err := parseCommissionReportPageQuery(r)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reports, err := h.db.GetCategoriesCommissionReport(*query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, reports, nil)
+}
+
+// createCommissionRule godoc
+// @Summary      Create a commission rule
+// @Description  Creates a new commission rule, optionally limited to a store and to a product category and its subcategories
+// @Tags         commission
+// @Accept       json
+// @Produce      json
+// @Param        rule  body      types.CreateCommissionRulePayload  true  "Commission rule details"
+// @Success      201   {object}  types.NewCommissionRuleResponse
+// @Failure      400   {object}  types.HTTPError
+// @Failure      401   {object}  types.HTTPError
+// @Failure      403   {object}  types.HTTPError
+// @Failure      500   {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/rule [post]
+func (h *Handler) createCommissionRule(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateCommissionRulePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	createdRule, err := h.db.CreateCommissionRule(payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewCommissionRuleResponse{
+		CommissionRuleId: createdRule,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
+// updateCommissionRule godoc
+// @Summary      Update a commission rule
+// @Description  Updates the rate of an existing commission rule, the orders that are already placed keep their fees
+// @Tags         commission
+// @Accept       json
+// @Produce      json
+// @Param        commissionRuleId  path      int                                true  "Commission rule ID"
+// @Param        rule              body      types.UpdateCommissionRulePayload  true  "Commission rule update details"
+// @Success      200               "Commission rule updated"
+// @Failure      400               {object}  types.HTTPError
+// @Failure      401               {object}  types.HTTPError
+// @Failure      403               {object}  types.HTTPError
+// @Failure      404               {object}  types.HTTPError
+// @Failure      500               {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/rule/{commissionRuleId} [patch]
+func (h *Handler) updateCommissionRule(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateCommissionRulePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	commissionRuleId, err := utils.ParseIntURLParam("commissionRuleId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.UpdateCommissionRule(commissionRuleId, payload)
+	if err != nil {
+		if err == types.ErrCommissionRuleNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteCommissionRule godoc
+// @Summary      Delete a commission rule
+// @Description  Permanently deletes a commission rule, the orders that are already placed keep their fees
+// @Tags         commission
+// @Produce      json
+// @Param        commissionRuleId  path      int  true  "Commission rule ID"
+// @Success      200               "Commission rule deleted"
+// @Failure      400               {object}  types.HTTPError
+// @Failure      401               {object}  types.HTTPError
+// @Failure      403               {object}  types.HTTPError
+// @Failure      500               {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /commission/rule/{commissionRuleId} [delete]
+func (h *Handler) deleteCommissionRule(w http.ResponseWriter, r *http.Request) {
+	commissionRuleId, err := utils.ParseIntURLParam("commissionRuleId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.DeleteCommissionRule(commissionRuleId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+func parseCommissionReportPageQuery(r *http.Request) (*types.CommissionReportQuery, error) {
+	query := types.CommissionReportQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"store": &query.StoreId,
+		"aftd":  &query.AfterDate,
+		"befd":  &query.BeforeDate,
+		"p":     &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		return nil, err
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxCommissionReportsInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	return &query, nil
+}
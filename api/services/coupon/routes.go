@@ -0,0 +1,306 @@
+package coupon
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/SaeedAlian/econest/api/config"
+	db_manager "github.com/SaeedAlian/econest/api/db/manager"
+	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+type Handler struct {
+	db          *db_manager.Manager
+	authHandler *auth.AuthHandler
+}
+
+func NewHandler(
+	db *db_manager.Manager,
+	authHandler *auth.AuthHandler,
+) *Handler {
+	return &Handler{
+		db:          db,
+		authHandler: authHandler,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	withAuthRouter := router.Methods("GET", "POST", "PATCH", "DELETE").Subrouter()
+	withAuthRouter.HandleFunc("", h.authHandler.WithResourcePermissionAuth(
+		h.getCoupons,
+		h.db,
+		[]types.Resource{types.ResourceCouponsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/pages", h.authHandler.WithResourcePermissionAuth(
+		h.getCouponsPages,
+		h.db,
+		[]types.Resource{types.ResourceCouponsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/{couponId}", h.authHandler.WithResourcePermissionAuth(
+		h.getCoupon,
+		h.db,
+		[]types.Resource{types.ResourceCouponsFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("", h.authHandler.WithActionPermissionAuth(
+		h.createCoupon,
+		h.db,
+		[]types.Action{types.ActionCanAddCoupon},
+	)).Methods("POST")
+	withAuthRouter.HandleFunc("/{couponId}", h.authHandler.WithActionPermissionAuth(
+		h.updateCoupon,
+		h.db,
+		[]types.Action{types.ActionCanUpdateCoupon},
+	)).Methods("PATCH")
+	withAuthRouter.HandleFunc("/{couponId}", h.authHandler.WithActionPermissionAuth(
+		h.deleteCoupon,
+		h.db,
+		[]types.Action{types.ActionCanDeleteCoupon},
+	)).Methods("DELETE")
+	withAuthRouter.Use(h.authHandler.WithJWTAuth(h.db))
+	withAuthRouter.Use(h.authHandler.WithCSRFToken())
+	withAuthRouter.Use(h.authHandler.WithVerifiedEmail(h.db))
+	withAuthRouter.Use(h.authHandler.WithUnbannedProfile(h.db))
+}
+
+// getCoupons godoc
+// @Summary      Get coupons
+// @Description  Retrieves a paginated list of coupons with optional filtering. Requires full coupons access.
+// @Tags         coupon
+// @Produce      json
+// @Param        code    query     string  false  "Filter by code (partial match)"
+// @Param        store   query     int     false  "Filter by store ID"
+// @Param        active  query     bool    false  "Filter by active status"
+// @Param        p       query     int     false  "Page number (default: 1)"
+// @Success      200     {array}   types.Coupon
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /coupon [get]
+func (h *Handler) getCoupons(w http.ResponseWriter, r *http.Request) {
+	query := types.CouponSearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"code":   &query.Code,
+		"store":  &query.StoreId,
+		"active": &query.IsActive,
+		"p":      &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxCouponsInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	coupons, err := h.db.GetCoupons(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, coupons, nil)
+}
+
+// getCouponsPages godoc
+// @Summary      Get coupons pages count
+// @Description  Calculates the total number of pages available for coupons listing. Requires full coupons access.
+// @Tags         coupon
+// @Produce      json
+// @Param        code    query     string  false  "Filter by code (partial match)"
+// @Param        store   query     int     false  "Filter by store ID"
+// @Param        active  query     bool    false  "Filter by active status"
+// @Success      200     {object}  types.TotalPageCountResponse
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /coupon/pages [get]
+func (h *Handler) getCouponsPages(w http.ResponseWriter, r *http.Request) {
+	query := types.CouponSearchQuery{}
+
+	queryMapping := map[string]any{
+		"code":   &query.Code,
+		"store":  &query.StoreId,
+		"active": &query.IsActive,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	count, err := h.db.GetCouponsCount(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxCouponsInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getCoupon godoc
+// @Summary      Get a coupon
+// @Description  Retrieves details of a specific coupon by ID. Requires full coupons access.
+// @Tags         coupon
+// @Produce      json
+// @Param        couponId  path      int  true  "Coupon ID"
+// @Success      200       {object}  types.Coupon
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /coupon/{couponId} [get]
+func (h *Handler) getCoupon(w http.ResponseWriter, r *http.Request) {
+	couponId, err := utils.ParseIntURLParam("couponId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	coupon, err := h.db.GetCouponById(couponId)
+	if err != nil {
+		if err == types.ErrCouponNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, coupon, nil)
+}
+
+// createCoupon godoc
+// @Summary      Create a coupon
+// @Description  Creates a new coupon that can be applied to orders during checkout
+// @Tags         coupon
+// @Accept       json
+// @Produce      json
+// @Param        coupon  body      types.CreateCouponPayload  true  "Coupon details"
+// @Success      201     {object}  types.NewCouponResponse
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /coupon [post]
+func (h *Handler) createCoupon(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateCouponPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	createdCoupon, err := h.db.CreateCoupon(payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewCouponResponse{
+		CouponId: createdCoupon,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
+// updateCoupon godoc
+// @Summary      Update a coupon
+// @Description  Updates an existing coupon
+// @Tags         coupon
+// @Accept       json
+// @Produce      json
+// @Param        couponId  path      int                        true  "Coupon ID"
+// @Param        coupon    body      types.UpdateCouponPayload  true  "Coupon update details"
+// @Success      200       "Coupon updated"
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /coupon/{couponId} [patch]
+func (h *Handler) updateCoupon(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateCouponPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	couponId, err := utils.ParseIntURLParam("couponId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.UpdateCoupon(couponId, payload)
+	if err != nil {
+		if err == types.ErrCouponNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteCoupon godoc
+// @Summary      Delete a coupon
+// @Description  Permanently deletes a coupon that has not been used by any order
+// @Tags         coupon
+// @Produce      json
+// @Param        couponId  path      int  true  "Coupon ID"
+// @Success      200       "Coupon deleted"
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /coupon/{couponId} [delete]
+func (h *Handler) deleteCoupon(w http.ResponseWriter, r *http.Request) {
+	couponId, err := utils.ParseIntURLParam("couponId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.DeleteCoupon(couponId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
@@ -0,0 +1,281 @@
+package order
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils/pdf"
+)
+
+const (
+	invoiceMargin     = 40.0
+	invoiceLineHeight = 14.0
+	invoiceTextSize   = 9.0
+	invoiceSmallSize  = 7.5
+)
+
+// invoice table columns, the item column is left aligned and the others
+// are right aligned to their x
+var (
+	invoiceColumnItem      = invoiceMargin
+	invoiceColumnQuantity  = 320.0
+	invoiceColumnUnitPrice = 390.0
+	invoiceColumnDiscount  = 450.0
+	invoiceColumnTax       = 505.0
+	invoiceColumnTotal     = pdf.PageWidth - invoiceMargin
+)
+
+type invoiceWriter struct {
+	doc *pdf.Document
+	y   float64
+}
+
+// renderInvoicePDF renders an invoice as a PDF document. If storeId is set,
+// only the lines and totals of that store are rendered.
+func renderInvoicePDF(invoice *types.Invoice, storeId *int) []byte {
+	s := invoice.Snapshot
+
+	stores := s.Stores
+	lines := s.Lines
+	if storeId != nil {
+		stores = []types.InvoiceStore{}
+		for _, st := range s.Stores {
+			if st.StoreId == *storeId {
+				stores = append(stores, st)
+			}
+		}
+
+		lines = []types.InvoiceLine{}
+		for _, l := range s.Lines {
+			if l.StoreId == *storeId {
+				lines = append(lines, l)
+			}
+		}
+	}
+
+	iw := &invoiceWriter{doc: pdf.New()}
+	iw.newPage()
+
+	iw.doc.Text(invoiceMargin, iw.y, 20, true, "INVOICE")
+	iw.doc.TextRight(
+		invoiceColumnTotal,
+		iw.y,
+		invoiceTextSize,
+		true,
+		fmt.Sprintf("Invoice No. %06d", invoice.Number),
+	)
+	iw.next()
+	iw.doc.TextRight(
+		invoiceColumnTotal,
+		iw.y,
+		invoiceTextSize,
+		false,
+		fmt.Sprintf("Issued: %s", invoice.CreatedAt.Format("2006-01-02")),
+	)
+	iw.next()
+	iw.doc.TextRight(
+		invoiceColumnTotal,
+		iw.y,
+		invoiceTextSize,
+		false,
+		fmt.Sprintf("Order #%d placed on %s", s.OrderId, s.OrderedAt.Format("2006-01-02")),
+	)
+	iw.next()
+	if storeId != nil && len(stores) > 0 {
+		iw.doc.TextRight(
+			invoiceColumnTotal,
+			iw.y,
+			invoiceTextSize,
+			false,
+			fmt.Sprintf("Store copy: %s", stores[0].Name),
+		)
+		iw.next()
+	}
+	iw.next()
+
+	iw.doc.Text(invoiceMargin, iw.y, invoiceTextSize, true, "Bill to")
+	iw.next()
+	iw.text(s.Buyer.Name)
+	iw.text(s.Buyer.Email)
+	iw.address(s.Buyer.Address)
+	iw.next()
+
+	iw.doc.Text(invoiceMargin, iw.y, invoiceTextSize, true, "Sold by")
+	iw.next()
+	for _, st := range stores {
+		iw.text(st.Name)
+		iw.address(st.Address)
+	}
+	iw.next()
+
+	iw.tableHeader()
+	for _, l := range lines {
+		details := []string{}
+		if l.Attributes != "" {
+			details = append(details, l.Attributes)
+		}
+		if l.TaxPricingMode != "" {
+			details = append(
+				details,
+				fmt.Sprintf("Tax %s%% %s", formatInvoiceRate(l.TaxRate), l.TaxPricingMode),
+			)
+		}
+
+		height := invoiceLineHeight
+		if len(details) > 0 {
+			height += invoiceLineHeight - 4
+		}
+		iw.ensureSpace(height, true)
+
+		iw.doc.Text(
+			invoiceColumnItem,
+			iw.y,
+			invoiceTextSize,
+			false,
+			truncateInvoiceText(l.ProductName, invoiceColumnQuantity-invoiceColumnItem-30),
+		)
+		iw.cell(invoiceColumnQuantity, fmt.Sprint(l.Quantity))
+		iw.cell(invoiceColumnUnitPrice, formatInvoiceAmount(l.UnitPrice))
+		iw.cell(invoiceColumnDiscount, formatInvoiceAmount(l.Discount))
+		iw.cell(invoiceColumnTax, formatInvoiceAmount(l.Tax))
+		iw.cell(invoiceColumnTotal, formatInvoiceAmount(l.Total))
+
+		if len(details) > 0 {
+			iw.y -= invoiceLineHeight - 4
+			iw.doc.Text(
+				invoiceColumnItem+8,
+				iw.y,
+				invoiceSmallSize,
+				false,
+				truncateInvoiceText(
+					strings.Join(details, ", "),
+					invoiceColumnQuantity-invoiceColumnItem-30,
+				),
+			)
+		}
+		iw.next()
+	}
+
+	iw.doc.Line(invoiceMargin, iw.y+invoiceLineHeight-4, invoiceColumnTotal, iw.y+invoiceLineHeight-4)
+
+	if storeId != nil {
+		var variants, shipment, discount, totalTax, exclusiveTax types.Money
+		for _, st := range stores {
+			variants = variants.Add(st.TotalVariantsPrice)
+			shipment = shipment.Add(st.TotalShipmentPrice)
+			discount = discount.Add(st.TotalDiscount)
+			totalTax = totalTax.Add(st.TotalTax)
+			exclusiveTax = exclusiveTax.Add(st.ExclusiveTax)
+		}
+
+		iw.total("Subtotal", variants, false)
+		iw.total("Shipping", shipment, false)
+		iw.total("Discount", discount.Neg(), false)
+		iw.total("Tax included in prices", totalTax.Sub(exclusiveTax), false)
+		iw.total("Tax added to prices", exclusiveTax, false)
+		iw.total("Store total", variants.Add(shipment).Sub(discount).Add(exclusiveTax), true)
+	} else {
+		iw.total("Subtotal", s.TotalVariantsPrice, false)
+		iw.total("Shipping", s.TotalShipmentPrice, false)
+		iw.total("Discount", s.Discount.Neg(), false)
+		iw.total("Tax included in prices", s.TotalTax.Sub(s.ExclusiveTax), false)
+		iw.total("Tax added to prices", s.ExclusiveTax, false)
+		iw.total("Fee", s.Fee, false)
+		iw.total("Total paid", s.GrandTotal, true)
+	}
+
+	iw.next()
+	iw.ensureSpace(invoiceLineHeight, false)
+	iw.doc.Text(
+		invoiceMargin,
+		iw.y,
+		invoiceSmallSize,
+		false,
+		fmt.Sprintf("Paid on %s", s.PaidAt.Format("2006-01-02 15:04")),
+	)
+
+	return iw.doc.Bytes()
+}
+
+func (iw *invoiceWriter) newPage() {
+	iw.doc.AddPage()
+	iw.y = pdf.PageHeight - invoiceMargin - 20
+}
+
+func (iw *invoiceWriter) next() {
+	iw.y -= invoiceLineHeight
+}
+
+// ensureSpace starts a new page if the given height does not fit in the
+// current page, repeating the table header if the table is being written.
+func (iw *invoiceWriter) ensureSpace(height float64, inTable bool) {
+	if iw.y-height >= invoiceMargin {
+		return
+	}
+
+	iw.newPage()
+	if inTable {
+		iw.tableHeader()
+	}
+}
+
+func (iw *invoiceWriter) text(s string) {
+	if s == "" {
+		return
+	}
+
+	iw.ensureSpace(invoiceLineHeight, false)
+	iw.doc.Text(invoiceMargin, iw.y, invoiceTextSize, false, s)
+	iw.next()
+}
+
+func (iw *invoiceWriter) address(a types.InvoiceAddress) {
+	iw.text(a.Street)
+	iw.text(a.Details)
+	iw.text(strings.Trim(fmt.Sprintf("%s, %s %s", a.City, a.State, a.Zipcode), ", "))
+}
+
+func (iw *invoiceWriter) tableHeader() {
+	iw.doc.Text(invoiceColumnItem, iw.y, invoiceTextSize, true, "Item")
+	iw.doc.TextRight(invoiceColumnQuantity, iw.y, invoiceTextSize, true, "Qty")
+	iw.doc.TextRight(invoiceColumnUnitPrice, iw.y, invoiceTextSize, true, "Unit price")
+	iw.doc.TextRight(invoiceColumnDiscount, iw.y, invoiceTextSize, true, "Discount")
+	iw.doc.TextRight(invoiceColumnTax, iw.y, invoiceTextSize, true, "Tax")
+	iw.doc.TextRight(invoiceColumnTotal, iw.y, invoiceTextSize, true, "Total")
+	iw.doc.Line(invoiceMargin, iw.y-4, invoiceColumnTotal, iw.y-4)
+	iw.next()
+	iw.y -= 4
+}
+
+func (iw *invoiceWriter) cell(x float64, s string) {
+	iw.doc.TextRight(x, iw.y, invoiceTextSize, false, s)
+}
+
+func (iw *invoiceWriter) total(label string, amount types.Money, bold bool) {
+	iw.ensureSpace(invoiceLineHeight, false)
+	iw.doc.TextRight(invoiceColumnTax, iw.y, invoiceTextSize, bold, label)
+	iw.doc.TextRight(invoiceColumnTotal, iw.y, invoiceTextSize, bold, formatInvoiceAmount(amount))
+	iw.next()
+}
+
+func formatInvoiceAmount(amount types.Money) string {
+	return amount.String()
+}
+
+func formatInvoiceRate(rate float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", rate*100), "0"), ".")
+}
+
+func truncateInvoiceText(s string, width float64) string {
+	if pdf.TextWidth(s, invoiceTextSize) <= width {
+		return s
+	}
+
+	r := []rune(s)
+	for len(r) > 0 && pdf.TextWidth(string(r)+"...", invoiceTextSize) > width {
+		r = r[:len(r)-1]
+	}
+
+	return string(r) + "..."
+}
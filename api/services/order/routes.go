@@ -1,6 +1,7 @@
 package order
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -8,22 +9,26 @@ import (
 	"github.com/SaeedAlian/econest/api/config"
 	db_manager "github.com/SaeedAlian/econest/api/db/manager"
 	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/services/payment"
 	"github.com/SaeedAlian/econest/api/types"
 	"github.com/SaeedAlian/econest/api/utils"
 )
 
 type Handler struct {
-	db          *db_manager.Manager
-	authHandler *auth.AuthHandler
+	db              *db_manager.Manager
+	authHandler     *auth.AuthHandler
+	paymentProvider payment.PaymentProvider
 }
 
 func NewHandler(
 	db *db_manager.Manager,
 	authHandler *auth.AuthHandler,
+	paymentProvider payment.PaymentProvider,
 ) *Handler {
 	return &Handler{
-		db:          db,
-		authHandler: authHandler,
+		db:              db,
+		authHandler:     authHandler,
+		paymentProvider: paymentProvider,
 	}
 }
 
@@ -49,6 +54,11 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 		h.db,
 		[]types.Resource{types.ResourceOrdersFullAccess},
 	)).Methods("GET")
+	withAuthRouter.HandleFunc("/{orderId}/timeline", h.authHandler.WithResourcePermissionAuth(
+		h.getOrderTimeline,
+		h.db,
+		[]types.Resource{types.ResourceOrdersFullAccess},
+	)).Methods("GET")
 	withAuthRouter.HandleFunc("/store/{storeId}", h.authHandler.WithResourcePermissionAuth(
 		h.getStoreOrders,
 		h.db,
@@ -74,12 +84,24 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	withAuthRouter.HandleFunc("/store/me/{storeId}/{orderId}", h.getMyStoreOrder).Methods("GET")
 	withAuthRouter.HandleFunc("/store/me/{storeId}/{orderId}/products", h.getMyStoreOrderProducts).
 		Methods("GET")
+	withAuthRouter.HandleFunc("/store/me/{storeId}/{orderId}/invoice", h.getMyStoreOrderInvoice).
+		Methods("GET")
+	withAuthRouter.HandleFunc("/store/me/{storeId}/{orderId}/timeline", h.getMyStoreOrderTimeline).
+		Methods("GET")
+	withAuthRouter.HandleFunc("/store/me/{storeId}/{orderId}/shipment", h.updateMyStoreOrderShipment).
+		Methods("PATCH")
 	withAuthRouter.HandleFunc("/me", h.getMyOrders).Methods("GET")
 	withAuthRouter.HandleFunc("/me/pages", h.getMyOrdersPages).Methods("GET")
 	withAuthRouter.HandleFunc("/me/{orderId}", h.getMyOrder).Methods("GET")
 	withAuthRouter.HandleFunc("/me/{orderId}/products", h.getMyOrderProducts).Methods("GET")
+	withAuthRouter.HandleFunc("/me/{orderId}/invoice", h.getMyOrderInvoice).Methods("GET")
+	withAuthRouter.HandleFunc("/me/{orderId}/timeline", h.getMyOrderTimeline).Methods("GET")
+	withAuthRouter.HandleFunc(
+		"/me/{orderId}/payment",
+		h.authHandler.WithIdempotencyKey(h.createMyOrderPayment),
+	).Methods("POST")
 	withAuthRouter.HandleFunc("", h.authHandler.WithActionPermissionAuth(
-		h.createOrder,
+		h.authHandler.WithIdempotencyKey(h.createOrder),
 		h.db,
 		[]types.Action{types.ActionCanCreateOrder},
 	)).Methods("POST")
@@ -93,7 +115,7 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 		h.db,
 		[]types.Action{types.ActionCanCancelOrderPayment},
 	)).Methods("PATCH")
-	withAuthRouter.HandleFunc("/shipment/{orderId}", h.authHandler.WithActionPermissionAuth(
+	withAuthRouter.HandleFunc("/shipment/{orderId}/{storeId}", h.authHandler.WithActionPermissionAuth(
 		h.updateOrderShipment,
 		h.db,
 		[]types.Action{types.ActionCanUpdateOrderShipment},
@@ -121,7 +143,9 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 // @Param        calt      query     string  false  "Filter by created before date (RFC3339)"
 // @Param        camt      query     string  false  "Filter by created after date (RFC3339)"
 // @Param        p         query     int     false  "Page number (default: 1)"
+// @Param        cursor    query     string  false  "Cursor of the next page from the X-Next-Cursor header, used instead of the page number"
 // @Success      200       {array}   types.Order
+// @Header       200       {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure      400       {object}  types.HTTPError
 // @Failure      401       {object}  types.HTTPError
 // @Failure      403       {object}  types.HTTPError
@@ -131,6 +155,7 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 func (h *Handler) getOrders(w http.ResponseWriter, r *http.Request) {
 	query := types.OrderSearchQuery{}
 	var page *int = nil
+	var cursor *string = nil
 
 	queryMapping := map[string]any{
 		"user":     &query.UserId,
@@ -139,6 +164,7 @@ func (h *Handler) getOrders(w http.ResponseWriter, r *http.Request) {
 		"shipstat": &query.ShipmentStatus,
 		"calt":     &query.CreatedAtLessThan,
 		"camt":     &query.CreatedAtMoreThan,
+		"cursor":   &cursor,
 		"p":        &page,
 	}
 
@@ -174,19 +200,30 @@ func (h *Handler) getOrders(w http.ResponseWriter, r *http.Request) {
 
 	query.Limit = utils.Ptr(int(config.Env.MaxOrdersInPage))
 
-	if page != nil {
+	if cursor != nil {
+		query.Cursor, err = types.DecodeCursor(*cursor, types.CursorSortNewest)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else if page != nil {
 		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
 	} else {
 		query.Offset = utils.Ptr(0)
 	}
 
-	orders, err := h.db.GetOrders(query)
+	ordersPage, err := h.db.GetOrdersPage(query)
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, orders, nil)
+	utils.WriteJSONInResponse(
+		w,
+		http.StatusOK,
+		ordersPage.Items,
+		utils.NextCursorHeaders(ordersPage.NextCursor),
+	)
 }
 
 // getOrdersPages godoc
@@ -274,7 +311,9 @@ func (h *Handler) getOrdersPages(w http.ResponseWriter, r *http.Request) {
 // @Param        calt      query     string  false  "Filter by created before date (RFC3339)"
 // @Param        camt      query     string  false  "Filter by created after date (RFC3339)"
 // @Param        p         query     int     false  "Page number (default: 1)"
+// @Param        cursor    query     string  false  "Cursor of the next page from the X-Next-Cursor header, used instead of the page number"
 // @Success      200       {array}   types.Order
+// @Header       200       {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure      400       {object}  types.HTTPError
 // @Failure      401       {object}  types.HTTPError
 // @Failure      403       {object}  types.HTTPError
@@ -290,6 +329,7 @@ func (h *Handler) getStoreOrders(w http.ResponseWriter, r *http.Request) {
 
 	query := types.OrderSearchQuery{}
 	var page *int = nil
+	var cursor *string = nil
 
 	queryMapping := map[string]any{
 		"user":     &query.UserId,
@@ -297,6 +337,7 @@ func (h *Handler) getStoreOrders(w http.ResponseWriter, r *http.Request) {
 		"shipstat": &query.ShipmentStatus,
 		"calt":     &query.CreatedAtLessThan,
 		"camt":     &query.CreatedAtMoreThan,
+		"cursor":   &cursor,
 		"p":        &page,
 	}
 
@@ -332,19 +373,26 @@ func (h *Handler) getStoreOrders(w http.ResponseWriter, r *http.Request) {
 
 	query.Limit = utils.Ptr(int(config.Env.MaxOrdersInPage))
 
-	if page != nil {
+	if cursor != nil {
+		query.Cursor, err = types.DecodeCursor(*cursor, types.CursorSortNewest)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else if page != nil {
 		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
 	} else {
 		query.Offset = utils.Ptr(0)
 	}
 
-	orders, err := h.db.GetOrders(types.OrderSearchQuery{
+	ordersPage, err := h.db.GetOrdersPage(types.OrderSearchQuery{
 		UserId:            query.UserId,
 		StoreId:           &storeId,
 		PaymentStatus:     query.PaymentStatus,
 		ShipmentStatus:    query.ShipmentStatus,
 		CreatedAtLessThan: query.CreatedAtLessThan,
 		CreatedAtMoreThan: query.CreatedAtMoreThan,
+		Cursor:            query.Cursor,
 		Limit:             query.Limit,
 		Offset:            query.Offset,
 	})
@@ -353,7 +401,12 @@ func (h *Handler) getStoreOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, orders, nil)
+	utils.WriteJSONInResponse(
+		w,
+		http.StatusOK,
+		ordersPage.Items,
+		utils.NextCursorHeaders(ordersPage.NextCursor),
+	)
 }
 
 // getStoreOrdersPages godoc
@@ -457,7 +510,9 @@ func (h *Handler) getStoreOrdersPages(w http.ResponseWriter, r *http.Request) {
 // @Param        calt      query     string  false  "Filter by created before date (RFC3339)"
 // @Param        camt      query     string  false  "Filter by created after date (RFC3339)"
 // @Param        p         query     int     false  "Page number (default: 1)"
+// @Param        cursor    query     string  false  "Cursor of the next page from the X-Next-Cursor header, used instead of the page number"
 // @Success      200       {array}   types.Order
+// @Header       200       {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure      400       {object}  types.HTTPError
 // @Failure      401       {object}  types.HTTPError
 // @Failure      403       {object}  types.HTTPError
@@ -473,6 +528,7 @@ func (h *Handler) getUserOrders(w http.ResponseWriter, r *http.Request) {
 
 	query := types.OrderSearchQuery{}
 	var page *int = nil
+	var cursor *string = nil
 
 	queryMapping := map[string]any{
 		"store":    &query.StoreId,
@@ -480,6 +536,7 @@ func (h *Handler) getUserOrders(w http.ResponseWriter, r *http.Request) {
 		"shipstat": &query.ShipmentStatus,
 		"calt":     &query.CreatedAtLessThan,
 		"camt":     &query.CreatedAtMoreThan,
+		"cursor":   &cursor,
 		"p":        &page,
 	}
 
@@ -515,19 +572,26 @@ func (h *Handler) getUserOrders(w http.ResponseWriter, r *http.Request) {
 
 	query.Limit = utils.Ptr(int(config.Env.MaxOrdersInPage))
 
-	if page != nil {
+	if cursor != nil {
+		query.Cursor, err = types.DecodeCursor(*cursor, types.CursorSortNewest)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else if page != nil {
 		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
 	} else {
 		query.Offset = utils.Ptr(0)
 	}
 
-	orders, err := h.db.GetOrders(types.OrderSearchQuery{
+	ordersPage, err := h.db.GetOrdersPage(types.OrderSearchQuery{
 		UserId:            &userId,
 		StoreId:           query.StoreId,
 		PaymentStatus:     query.PaymentStatus,
 		ShipmentStatus:    query.ShipmentStatus,
 		CreatedAtLessThan: query.CreatedAtLessThan,
 		CreatedAtMoreThan: query.CreatedAtMoreThan,
+		Cursor:            query.Cursor,
 		Limit:             query.Limit,
 		Offset:            query.Offset,
 	})
@@ -536,7 +600,12 @@ func (h *Handler) getUserOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, orders, nil)
+	utils.WriteJSONInResponse(
+		w,
+		http.StatusOK,
+		ordersPage.Items,
+		utils.NextCursorHeaders(ordersPage.NextCursor),
+	)
 }
 
 // getUserOrdersPages godoc
@@ -640,7 +709,9 @@ func (h *Handler) getUserOrdersPages(w http.ResponseWriter, r *http.Request) {
 // @Param        calt      query     string  false  "Filter by created before date (RFC3339)"
 // @Param        camt      query     string  false  "Filter by created after date (RFC3339)"
 // @Param        p         query     int     false  "Page number (default: 1)"
+// @Param        cursor    query     string  false  "Cursor of the next page from the X-Next-Cursor header, used instead of the page number"
 // @Success      200       {array}   types.Order
+// @Header       200       {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure      400       {object}  types.HTTPError
 // @Failure      401       {object}  types.HTTPError
 // @Failure      403       {object}  types.HTTPError
@@ -688,6 +759,7 @@ func (h *Handler) getMyStoreOrders(w http.ResponseWriter, r *http.Request) {
 
 	query := types.OrderSearchQuery{}
 	var page *int = nil
+	var cursor *string = nil
 
 	queryMapping := map[string]any{
 		"user":     &query.UserId,
@@ -695,6 +767,7 @@ func (h *Handler) getMyStoreOrders(w http.ResponseWriter, r *http.Request) {
 		"shipstat": &query.ShipmentStatus,
 		"calt":     &query.CreatedAtLessThan,
 		"camt":     &query.CreatedAtMoreThan,
+		"cursor":   &cursor,
 		"p":        &page,
 	}
 
@@ -730,19 +803,26 @@ func (h *Handler) getMyStoreOrders(w http.ResponseWriter, r *http.Request) {
 
 	query.Limit = utils.Ptr(int(config.Env.MaxOrdersInPage))
 
-	if page != nil {
+	if cursor != nil {
+		query.Cursor, err = types.DecodeCursor(*cursor, types.CursorSortNewest)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else if page != nil {
 		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
 	} else {
 		query.Offset = utils.Ptr(0)
 	}
 
-	orders, err := h.db.GetOrders(types.OrderSearchQuery{
+	ordersPage, err := h.db.GetOrdersPage(types.OrderSearchQuery{
 		UserId:            query.UserId,
 		StoreId:           &storeId,
 		PaymentStatus:     query.PaymentStatus,
 		ShipmentStatus:    query.ShipmentStatus,
 		CreatedAtLessThan: query.CreatedAtLessThan,
 		CreatedAtMoreThan: query.CreatedAtMoreThan,
+		Cursor:            query.Cursor,
 		Limit:             query.Limit,
 		Offset:            query.Offset,
 	})
@@ -751,7 +831,12 @@ func (h *Handler) getMyStoreOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, orders, nil)
+	utils.WriteJSONInResponse(
+		w,
+		http.StatusOK,
+		ordersPage.Items,
+		utils.NextCursorHeaders(ordersPage.NextCursor),
+	)
 }
 
 // getMyStoreOrdersPages godoc
@@ -886,7 +971,9 @@ func (h *Handler) getMyStoreOrdersPages(w http.ResponseWriter, r *http.Request)
 // @Param        calt      query     string  false  "Filter by created before date (RFC3339)"
 // @Param        camt      query     string  false  "Filter by created after date (RFC3339)"
 // @Param        p         query     int     false  "Page number (default: 1)"
+// @Param        cursor    query     string  false  "Cursor of the next page from the X-Next-Cursor header, used instead of the page number"
 // @Success      200       {array}   types.Order
+// @Header       200       {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure      400       {object}  types.HTTPError
 // @Failure      401       {object}  types.HTTPError
 // @Failure      500       {object}  types.HTTPError
@@ -910,6 +997,7 @@ func (h *Handler) getMyOrders(w http.ResponseWriter, r *http.Request) {
 
 	query := types.OrderSearchQuery{}
 	var page *int = nil
+	var cursor *string = nil
 
 	queryMapping := map[string]any{
 		"store":    &query.StoreId,
@@ -917,6 +1005,7 @@ func (h *Handler) getMyOrders(w http.ResponseWriter, r *http.Request) {
 		"shipstat": &query.ShipmentStatus,
 		"calt":     &query.CreatedAtLessThan,
 		"camt":     &query.CreatedAtMoreThan,
+		"cursor":   &cursor,
 		"p":        &page,
 	}
 
@@ -952,19 +1041,26 @@ func (h *Handler) getMyOrders(w http.ResponseWriter, r *http.Request) {
 
 	query.Limit = utils.Ptr(int(config.Env.MaxOrdersInPage))
 
-	if page != nil {
+	if cursor != nil {
+		query.Cursor, err = types.DecodeCursor(*cursor, types.CursorSortNewest)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else if page != nil {
 		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
 	} else {
 		query.Offset = utils.Ptr(0)
 	}
 
-	orders, err := h.db.GetOrders(types.OrderSearchQuery{
+	ordersPage, err := h.db.GetOrdersPage(types.OrderSearchQuery{
 		UserId:            &userId,
 		StoreId:           query.StoreId,
 		PaymentStatus:     query.PaymentStatus,
 		ShipmentStatus:    query.ShipmentStatus,
 		CreatedAtLessThan: query.CreatedAtLessThan,
 		CreatedAtMoreThan: query.CreatedAtMoreThan,
+		Cursor:            query.Cursor,
 		Limit:             query.Limit,
 		Offset:            query.Offset,
 	})
@@ -973,7 +1069,12 @@ func (h *Handler) getMyOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, orders, nil)
+	utils.WriteJSONInResponse(
+		w,
+		http.StatusOK,
+		ordersPage.Items,
+		utils.NextCursorHeaders(ordersPage.NextCursor),
+	)
 }
 
 // getMyOrdersPages godoc
@@ -1142,14 +1243,55 @@ func (h *Handler) getOrderProducts(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSONInResponse(w, http.StatusOK, products, nil)
 }
 
+// getOrderTimeline godoc
+// @Summary      Get order timeline
+// @Description  Retrieves the history of the payment and shipment statuses of a specific order. Requires orders full access permission.
+// @Tags         order
+// @Produce      json
+// @Param        orderId  path      int  true  "Order ID"
+// @Success      200      {array}   types.OrderStatusEvent
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /order/{orderId}/timeline [get]
+func (h *Handler) getOrderTimeline(w http.ResponseWriter, r *http.Request) {
+	orderId, err := utils.ParseIntURLParam("orderId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = h.db.GetOrderById(orderId)
+	if err != nil {
+		if err == types.ErrOrderNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	events, err := h.db.GetOrderStatusEvents(orderId, nil)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, events, nil)
+}
+
 // getMyStoreOrder godoc
 // @Summary      Get current user's store order
-// @Description  Retrieves full details for a specific order belonging to the current user's store.
+// @Description  Retrieves the part of a specific order that is fulfilled by the current user's store, including its own shipment.
 // @Tags         order
 // @Produce      json
 // @Param        storeId  path      int  true  "Store ID"
 // @Param        orderId  path      int  true  "Order ID"
-// @Success      200      {object}  types.Order
+// @Success      200      {object}  types.StoreOrder
 // @Failure      400      {object}  types.HTTPError
 // @Failure      401      {object}  types.HTTPError
 // @Failure      403      {object}  types.HTTPError
@@ -1211,7 +1353,7 @@ func (h *Handler) getMyStoreOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.db.GetOrderWithFullInfoById(orderId)
+	order, err := h.db.GetStoreOrderById(orderId, storeId)
 	if err != nil {
 		if err == types.ErrOrderNotFound {
 			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
@@ -1227,7 +1369,7 @@ func (h *Handler) getMyStoreOrder(w http.ResponseWriter, r *http.Request) {
 
 // getMyStoreOrderProducts godoc
 // @Summary      Get current user's store order products
-// @Description  Retrieves product variants for a specific order belonging to the current user's store.
+// @Description  Retrieves the product variants of a specific order that are sold by the current user's store.
 // @Tags         order
 // @Produce      json
 // @Param        storeId  path      int  true  "Store ID"
@@ -1294,7 +1436,7 @@ func (h *Handler) getMyStoreOrderProducts(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	products, err := h.db.GetOrderProductVariantsInfo(orderId)
+	products, err := h.db.GetStoreOrderProductVariantsInfo(orderId, storeId)
 	if err != nil {
 		if err == types.ErrOrderNotFound {
 			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
@@ -1308,6 +1450,264 @@ func (h *Handler) getMyStoreOrderProducts(w http.ResponseWriter, r *http.Request
 	utils.WriteJSONInResponse(w, http.StatusOK, products, nil)
 }
 
+// getMyStoreOrderTimeline godoc
+// @Summary      Get current user's store order timeline
+// @Description  Retrieves the history of the payment status and the current user's store shipment status of a specific order.
+// @Tags         order
+// @Produce      json
+// @Param        storeId  path      int  true  "Store ID"
+// @Param        orderId  path      int  true  "Order ID"
+// @Success      200      {array}   types.OrderStatusEvent
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /order/store/me/{storeId}/{orderId}/timeline [get]
+func (h *Handler) getMyStoreOrderTimeline(w http.ResponseWriter, r *http.Request) {
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	orderId, err := utils.ParseIntURLParam("orderId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	isStorePartOfOrder, err := h.db.IsStoreHasParticipationInOrder(orderId, storeId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !isStorePartOfOrder {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrder)
+		return
+	}
+
+	events, err := h.db.GetOrderStatusEvents(orderId, &storeId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, events, nil)
+}
+
+// getMyStoreOrderInvoice godoc
+// @Summary      Download current user's store order invoice
+// @Description  Downloads the invoice of a paid order as a PDF, with only the lines and totals of the current user's store.
+// @Tags         order
+// @Produce      application/pdf
+// @Param        storeId  path      int  true  "Store ID"
+// @Param        orderId  path      int  true  "Order ID"
+// @Success      200      {file}    file
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /order/store/me/{storeId}/{orderId}/invoice [get]
+func (h *Handler) getMyStoreOrderInvoice(w http.ResponseWriter, r *http.Request) {
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	orderId, err := utils.ParseIntURLParam("orderId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	isStorePartOfOrder, err := h.db.IsStoreHasParticipationInOrder(orderId, storeId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !isStorePartOfOrder {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrder)
+		return
+	}
+
+	invoice, err := h.db.GetOrderInvoice(orderId)
+	if err != nil {
+		if err == types.ErrInvoiceNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteFileInResponse(
+		w,
+		http.StatusOK,
+		renderInvoicePDF(invoice, &storeId),
+		"application/pdf",
+		fmt.Sprintf("invoice-%06d-store-%d.pdf", invoice.Number, storeId),
+	)
+}
+
+// updateMyStoreOrderShipment godoc
+// @Summary      Update current user's store order shipment
+// @Description  Updates the shipment of the part of an order that is fulfilled by the current user's store. A status change must be allowed from the current status, it is recorded in the order timeline and the estimated arrival date is recomputed.
+// @Tags         order
+// @Accept       json
+// @Produce      json
+// @Param        storeId  path      int                               true  "Store ID"
+// @Param        orderId  path      int                               true  "Order ID"
+// @Param        shipment body      types.UpdateOrderShipmentPayload  true  "Shipment details"
+// @Success      200      "Order shipment updated"
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /order/store/me/{storeId}/{orderId}/shipment [patch]
+func (h *Handler) updateMyStoreOrderShipment(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateOrderShipmentPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	orderId, err := utils.ParseIntURLParam("orderId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	isStorePartOfOrder, err := h.db.IsStoreHasParticipationInOrder(orderId, storeId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !isStorePartOfOrder {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrder)
+		return
+	}
+
+	err = h.db.UpdateOrderShipment(orderId, storeId, userId, types.UpdateOrderShipmentPayload{
+		Status:         payload.Status,
+		TrackingNumber: payload.TrackingNumber,
+		Reason:         payload.Reason,
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
 // getMyOrder godoc
 // @Summary      Get current user's order
 // @Description  Retrieves full details for a specific order belonging to the current user.
@@ -1429,6 +1829,139 @@ func (h *Handler) getMyOrderProducts(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSONInResponse(w, http.StatusOK, products, nil)
 }
 
+// getMyOrderTimeline godoc
+// @Summary      Get current user's order timeline
+// @Description  Retrieves the history of the payment and shipment statuses of a specific order belonging to the current user.
+// @Tags         order
+// @Produce      json
+// @Param        orderId  path      int  true  "Order ID"
+// @Success      200      {array}   types.OrderStatusEvent
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /order/me/{orderId}/timeline [get]
+func (h *Handler) getMyOrderTimeline(w http.ResponseWriter, r *http.Request) {
+	orderId, err := utils.ParseIntURLParam("orderId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	order, err := h.db.GetOrderById(orderId)
+	if err != nil {
+		if err == types.ErrOrderNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if order.UserId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrder)
+		return
+	}
+
+	events, err := h.db.GetOrderStatusEvents(orderId, nil)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, events, nil)
+}
+
+// getMyOrderInvoice godoc
+// @Summary      Download current user's order invoice
+// @Description  Downloads the invoice of a paid order belonging to the current user as a PDF.
+// @Tags         order
+// @Produce      application/pdf
+// @Param        orderId  path      int  true  "Order ID"
+// @Success      200      {file}    file
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /order/me/{orderId}/invoice [get]
+func (h *Handler) getMyOrderInvoice(w http.ResponseWriter, r *http.Request) {
+	orderId, err := utils.ParseIntURLParam("orderId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	order, err := h.db.GetOrderById(orderId)
+	if err != nil {
+		if err == types.ErrOrderNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if order.UserId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrder)
+		return
+	}
+
+	invoice, err := h.db.GetOrderInvoice(orderId)
+	if err != nil {
+		if err == types.ErrInvoiceNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteFileInResponse(
+		w,
+		http.StatusOK,
+		renderInvoicePDF(invoice, nil),
+		"application/pdf",
+		fmt.Sprintf("invoice-%06d.pdf", invoice.Number),
+	)
+}
+
 // createOrder godoc
 // @Summary      Create a new order
 // @Description  Creates a new order with the provided details. Requires create order permission.
@@ -1436,11 +1969,14 @@ func (h *Handler) getMyOrderProducts(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        order  body      types.CreateOrderPayload  true  "Order details"
+// @Param        Idempotency-Key  header  string  false  "Key to safely retry the request"
 // @Success      201    {object}  types.NewOrderResponse
 // @Failure      400    {object}  types.HTTPError
 // @Failure      401    {object}  types.HTTPError
 // @Failure      403    {object}  types.HTTPError
 // @Failure      404    {object}  types.HTTPError
+// @Failure      409    {object}  types.HTTPError
+// @Failure      422    {object}  types.HTTPError
 // @Failure      500    {object}  types.HTTPError
 // @Security     ApiKeyAuth
 // @Router       /order [post]
@@ -1485,9 +2021,9 @@ func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
 
 	createdOrder, err := h.db.CreateOrder(types.CreateOrderPayload{
 		UserId:            userId,
-		ArrivalDate:       payload.ArrivalDate,
 		ProductVariants:   payload.ProductVariants,
 		ReceiverAddressId: payload.ReceiverAddressId,
+		CouponCode:        payload.CouponCode,
 	})
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
@@ -1503,7 +2039,7 @@ func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
 
 // completeOrderPayment godoc
 // @Summary      Complete order payment
-// @Description  Marks an order's payment as completed. Requires complete order payment permission.
+// @Description  Pays an order with the balance of the customer's wallet and issues its invoice. The orders that are paid through the payment provider are completed by its webhook instead. Requires complete order payment permission.
 // @Tags         order
 // @Produce      json
 // @Param        orderId  path      int  true  "Order ID"
@@ -1516,8 +2052,6 @@ func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
 // @Security     ApiKeyAuth
 // @Router       /order/complete/{orderId} [patch]
 func (h *Handler) completeOrderPayment(w http.ResponseWriter, r *http.Request) {
-	// TODO: handle payment validation
-
 	orderId, err := utils.ParseIntURLParam("orderId", mux.Vars(r))
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
@@ -1555,7 +2089,7 @@ func (h *Handler) completeOrderPayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.db.UpdateOrderPayment(orderId, types.UpdateOrderPaymentPayload{
+	err = h.db.UpdateOrderPayment(orderId, userId, types.UpdateOrderPaymentPayload{
 		Status: utils.Ptr(types.OrderPaymentStatusSuccessful),
 	})
 	if err != nil {
@@ -1566,6 +2100,126 @@ func (h *Handler) completeOrderPayment(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
 }
 
+// createMyOrderPayment godoc
+// @Summary      Pay an order through the payment provider
+// @Description  Starts the payment of a pending order of the current user at the payment provider. The payer is sent to the returned checkout url, the money is deposited to the customer's wallet and spent on the order when the payment provider webhook confirms the payment.
+// @Tags         order
+// @Produce      json
+// @Param        orderId          path      int     true   "Order ID"
+// @Param        Idempotency-Key  header    string  false  "Key to safely retry the request"
+// @Success      201      {object}  types.NewPaymentResponse
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      409      {object}  types.HTTPError
+// @Failure      422      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Failure      503      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /order/me/{orderId}/payment [post]
+func (h *Handler) createMyOrderPayment(w http.ResponseWriter, r *http.Request) {
+	if h.paymentProvider == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusServiceUnavailable,
+			types.ErrPaymentProviderNotConfigured,
+		)
+		return
+	}
+
+	orderId, err := utils.ParseIntURLParam("orderId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	order, err := h.db.GetOrderWithFullInfoById(orderId)
+	if err != nil {
+		if err == types.ErrOrderNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if order.UserId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrder)
+		return
+	}
+
+	if order.Payment.Status != types.OrderPaymentStatusPending {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrOrderPaymentIsNotPending)
+		return
+	}
+
+	wallet, err := h.db.GetUserWallet(userId)
+	if err != nil {
+		if err == types.ErrWalletNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	amount := order.Payment.Total()
+
+	providerPayment, err := h.paymentProvider.CreateIntent(types.CreateProviderPaymentPayload{
+		Amount:      amount,
+		Description: fmt.Sprintf("Payment of order #%d", orderId),
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	intentId, err := h.db.CreatePaymentIntent(types.CreatePaymentIntentPayload{
+		Provider:  h.paymentProvider.Name(),
+		Reference: providerPayment.Reference,
+		Amount:    amount,
+		WalletId:  wallet.Id,
+		OrderId:   &orderId,
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	intent, err := h.db.GetPaymentIntentById(intentId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := types.NewPaymentResponse{
+		TxId:            intent.WalletTransactionId,
+		PaymentIntentId: intent.Id,
+		Reference:       intent.Reference,
+		CheckoutUrl:     providerPayment.CheckoutUrl,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
 // cancelOrderPayment godoc
 // @Summary      Cancel order payment
 // @Description  Marks an order's payment as cancelled. Requires cancel order payment permission.
@@ -1618,8 +2272,9 @@ func (h *Handler) cancelOrderPayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.db.UpdateOrderPayment(orderId, types.UpdateOrderPaymentPayload{
+	err = h.db.UpdateOrderPayment(orderId, userId, types.UpdateOrderPaymentPayload{
 		Status: utils.Ptr(types.OrderPaymentStatusFailed),
+		Reason: utils.Ptr("cancelled by the customer"),
 	})
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
@@ -1631,11 +2286,12 @@ func (h *Handler) cancelOrderPayment(w http.ResponseWriter, r *http.Request) {
 
 // updateOrderShipment godoc
 // @Summary      Update order shipment
-// @Description  Updates shipment details for an order. Requires update order shipment permission.
+// @Description  Updates shipment details of the part of an order fulfilled by a store. A status change must be allowed from the current status, it is recorded in the order timeline and the estimated arrival date is recomputed. Requires update order shipment permission.
 // @Tags         order
 // @Accept       json
 // @Produce      json
 // @Param        orderId  path      int                               true  "Order ID"
+// @Param        storeId  path      int                               true  "Store ID"
 // @Param        shipment body      types.UpdateOrderShipmentPayload  true  "Shipment details"
 // @Success      200			"Order shipment updated"
 // @Failure      400      {object}  types.HTTPError
@@ -1644,7 +2300,7 @@ func (h *Handler) cancelOrderPayment(w http.ResponseWriter, r *http.Request) {
 // @Failure      404      {object}  types.HTTPError
 // @Failure      500      {object}  types.HTTPError
 // @Security     ApiKeyAuth
-// @Router       /order/shipment/{orderId} [patch]
+// @Router       /order/shipment/{orderId}/{storeId} [patch]
 func (h *Handler) updateOrderShipment(w http.ResponseWriter, r *http.Request) {
 	var payload types.UpdateOrderShipmentPayload
 	err := utils.ParseRequestPayload(r, &payload)
@@ -1659,15 +2315,42 @@ func (h *Handler) updateOrderShipment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.db.UpdateOrderShipment(orderId, types.UpdateOrderShipmentPayload{
-		Status:      payload.Status,
-		ArrivalDate: payload.ArrivalDate,
-	})
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
 		return
 	}
 
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	err = h.db.UpdateOrderShipment(orderId, storeId, userId, types.UpdateOrderShipmentPayload{
+		Status:         payload.Status,
+		TrackingNumber: payload.TrackingNumber,
+		Reason:         payload.Reason,
+	})
+	if err != nil {
+		if err == types.ErrOrderShipmentNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
 	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
 }
 
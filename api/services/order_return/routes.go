@@ -0,0 +1,971 @@
+package order_return
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/SaeedAlian/econest/api/config"
+	db_manager "github.com/SaeedAlian/econest/api/db/manager"
+	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+type Handler struct {
+	db          *db_manager.Manager
+	authHandler *auth.AuthHandler
+}
+
+func NewHandler(
+	db *db_manager.Manager,
+	authHandler *auth.AuthHandler,
+) *Handler {
+	return &Handler{
+		db:          db,
+		authHandler: authHandler,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	withAuthRouter := router.Methods("GET", "POST", "PATCH").Subrouter()
+	withAuthRouter.HandleFunc("/me", h.getMyOrderReturns).Methods("GET")
+	withAuthRouter.HandleFunc("/me", h.createMyOrderReturn).Methods("POST")
+	withAuthRouter.HandleFunc("/me/pages", h.getMyOrderReturnsPages).Methods("GET")
+	withAuthRouter.HandleFunc("/me/{returnId}", h.getMyOrderReturn).Methods("GET")
+	withAuthRouter.HandleFunc("/store/me/{storeId}", h.getMyStoreOrderReturns).Methods("GET")
+	withAuthRouter.HandleFunc("/store/me/{storeId}/pages", h.getMyStoreOrderReturnsPages).
+		Methods("GET")
+	withAuthRouter.HandleFunc("/store/me/{storeId}/{returnId}", h.getMyStoreOrderReturn).
+		Methods("GET")
+	withAuthRouter.HandleFunc("/store/me/{storeId}/{returnId}/review", h.reviewMyStoreOrderReturn).
+		Methods("PATCH")
+	withAuthRouter.HandleFunc("/store/me/{storeId}/{returnId}/receive", h.receiveMyStoreOrderReturn).
+		Methods("PATCH")
+	withAuthRouter.HandleFunc("", h.authHandler.WithResourcePermissionAuth(
+		h.getOrderReturns,
+		h.db,
+		[]types.Resource{types.ResourceOrdersFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/pages", h.authHandler.WithResourcePermissionAuth(
+		h.getOrderReturnsPages,
+		h.db,
+		[]types.Resource{types.ResourceOrdersFullAccess},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/{returnId}", h.authHandler.WithResourcePermissionAuth(
+		h.getOrderReturn,
+		h.db,
+		[]types.Resource{types.ResourceOrdersFullAccess},
+	)).Methods("GET")
+	withAuthRouter.Use(h.authHandler.WithJWTAuth(h.db))
+	withAuthRouter.Use(h.authHandler.WithCSRFToken())
+	withAuthRouter.Use(h.authHandler.WithVerifiedEmail(h.db))
+	withAuthRouter.Use(h.authHandler.WithUnbannedProfile(h.db))
+}
+
+// getOrderReturns godoc
+// @Summary      Get order returns
+// @Description  Retrieves a paginated list of order returns with optional filtering. Requires full orders access.
+// @Tags         return
+// @Produce      json
+// @Param        user    query     int     false  "Filter by customer ID"
+// @Param        store   query     int     false  "Filter by store ID"
+// @Param        order   query     int     false  "Filter by order ID"
+// @Param        status  query     string  false  "Filter by return status"
+// @Param        p       query     int     false  "Page number (default: 1)"
+// @Success      200     {array}   types.OrderReturn
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return [get]
+func (h *Handler) getOrderReturns(w http.ResponseWriter, r *http.Request) {
+	query := types.OrderReturnSearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"user":   &query.UserId,
+		"store":  &query.StoreId,
+		"order":  &query.OrderId,
+		"status": &query.Status,
+		"p":      &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if query.Status != nil {
+		if !query.Status.IsValid() {
+			utils.WriteErrorInResponse(
+				w,
+				http.StatusBadRequest,
+				types.ErrInvalidOrderReturnStatusEnum,
+			)
+			return
+		}
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxOrderReturnsInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	returns, err := h.db.GetOrderReturns(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, returns, nil)
+}
+
+// getOrderReturnsPages godoc
+// @Summary      Get order returns pages count
+// @Description  Calculates the total number of pages available for order returns listing. Requires full orders access.
+// @Tags         return
+// @Produce      json
+// @Param        user    query     int     false  "Filter by customer ID"
+// @Param        store   query     int     false  "Filter by store ID"
+// @Param        order   query     int     false  "Filter by order ID"
+// @Param        status  query     string  false  "Filter by return status"
+// @Success      200     {object}  types.TotalPageCountResponse
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/pages [get]
+func (h *Handler) getOrderReturnsPages(w http.ResponseWriter, r *http.Request) {
+	query := types.OrderReturnSearchQuery{}
+
+	queryMapping := map[string]any{
+		"user":   &query.UserId,
+		"store":  &query.StoreId,
+		"order":  &query.OrderId,
+		"status": &query.Status,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if query.Status != nil {
+		if !query.Status.IsValid() {
+			utils.WriteErrorInResponse(
+				w,
+				http.StatusBadRequest,
+				types.ErrInvalidOrderReturnStatusEnum,
+			)
+			return
+		}
+	}
+
+	count, err := h.db.GetOrderReturnsCount(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxOrderReturnsInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getOrderReturn godoc
+// @Summary      Get order return
+// @Description  Retrieves an order return with its returned items. Requires full orders access.
+// @Tags         return
+// @Produce      json
+// @Param        returnId  path      int  true  "Order return ID"
+// @Success      200       {object}  types.OrderReturnWithItems
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/{returnId} [get]
+func (h *Handler) getOrderReturn(w http.ResponseWriter, r *http.Request) {
+	returnId, err := utils.ParseIntURLParam("returnId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	orderReturn, err := h.db.GetOrderReturnWithItemsById(returnId)
+	if err != nil {
+		if err == types.ErrOrderReturnNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, orderReturn, nil)
+}
+
+// getMyOrderReturns godoc
+// @Summary      Get current user's order returns
+// @Description  Retrieves a paginated list of the current user's order returns with optional filtering.
+// @Tags         return
+// @Produce      json
+// @Param        order   query     int     false  "Filter by order ID"
+// @Param        status  query     string  false  "Filter by return status"
+// @Param        p       query     int     false  "Page number (default: 1)"
+// @Success      200     {array}   types.OrderReturn
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/me [get]
+func (h *Handler) getMyOrderReturns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	query := types.OrderReturnSearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"order":  &query.OrderId,
+		"status": &query.Status,
+		"p":      &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if query.Status != nil {
+		if !query.Status.IsValid() {
+			utils.WriteErrorInResponse(
+				w,
+				http.StatusBadRequest,
+				types.ErrInvalidOrderReturnStatusEnum,
+			)
+			return
+		}
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxOrderReturnsInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	returns, err := h.db.GetOrderReturns(types.OrderReturnSearchQuery{
+		UserId:  &userId,
+		OrderId: query.OrderId,
+		Status:  query.Status,
+		Limit:   query.Limit,
+		Offset:  query.Offset,
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, returns, nil)
+}
+
+// getMyOrderReturnsPages godoc
+// @Summary      Get current user's order returns pages count
+// @Description  Calculates the total number of pages available for the current user's order returns listing.
+// @Tags         return
+// @Produce      json
+// @Param        order   query     int     false  "Filter by order ID"
+// @Param        status  query     string  false  "Filter by return status"
+// @Success      200     {object}  types.TotalPageCountResponse
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/me/pages [get]
+func (h *Handler) getMyOrderReturnsPages(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	query := types.OrderReturnSearchQuery{}
+
+	queryMapping := map[string]any{
+		"order":  &query.OrderId,
+		"status": &query.Status,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if query.Status != nil {
+		if !query.Status.IsValid() {
+			utils.WriteErrorInResponse(
+				w,
+				http.StatusBadRequest,
+				types.ErrInvalidOrderReturnStatusEnum,
+			)
+			return
+		}
+	}
+
+	count, err := h.db.GetOrderReturnsCount(types.OrderReturnSearchQuery{
+		UserId:  &userId,
+		OrderId: query.OrderId,
+		Status:  query.Status,
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxOrderReturnsInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getMyOrderReturn godoc
+// @Summary      Get current user's order return
+// @Description  Retrieves an order return of the current user with its returned items.
+// @Tags         return
+// @Produce      json
+// @Param        returnId  path      int  true  "Order return ID"
+// @Success      200       {object}  types.OrderReturnWithItems
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/me/{returnId} [get]
+func (h *Handler) getMyOrderReturn(w http.ResponseWriter, r *http.Request) {
+	returnId, err := utils.ParseIntURLParam("returnId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	orderReturn, err := h.db.GetOrderReturnWithItemsById(returnId)
+	if err != nil {
+		if err == types.ErrOrderReturnNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	order, err := h.db.GetOrderById(orderReturn.OrderId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if order.UserId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrderReturn)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, orderReturn, nil)
+}
+
+// createMyOrderReturn godoc
+// @Summary      Request an order return
+// @Description  Requests the return of items of a paid order of the current user. All the items must belong to the same store.
+// @Tags         return
+// @Accept       json
+// @Produce      json
+// @Param        return  body      types.CreateOrderReturnPayload  true  "Order return details"
+// @Success      201     {object}  types.NewOrderReturnResponse
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/me [post]
+func (h *Handler) createMyOrderReturn(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateOrderReturnPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	returnId, err := h.db.CreateOrderReturn(userId, payload)
+	if err != nil {
+		switch err {
+		case types.ErrOrderNotFound:
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		case types.ErrCannotAccessOrder:
+			utils.WriteErrorInResponse(w, http.StatusForbidden, err)
+		default:
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	res := types.NewOrderReturnResponse{
+		ReturnId: returnId,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
+// getMyStoreOrderReturns godoc
+// @Summary      Get current user's store order returns
+// @Description  Retrieves a paginated list of order returns of a store owned by the current user.
+// @Tags         return
+// @Produce      json
+// @Param        storeId  path      int     true   "Store ID"
+// @Param        order    query     int     false  "Filter by order ID"
+// @Param        status   query     string  false  "Filter by return status"
+// @Param        p        query     int     false  "Page number (default: 1)"
+// @Success      200      {array}   types.OrderReturn
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/store/me/{storeId} [get]
+func (h *Handler) getMyStoreOrderReturns(w http.ResponseWriter, r *http.Request) {
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	query := types.OrderReturnSearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"order":  &query.OrderId,
+		"status": &query.Status,
+		"p":      &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err = utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if query.Status != nil {
+		if !query.Status.IsValid() {
+			utils.WriteErrorInResponse(
+				w,
+				http.StatusBadRequest,
+				types.ErrInvalidOrderReturnStatusEnum,
+			)
+			return
+		}
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxOrderReturnsInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	returns, err := h.db.GetOrderReturns(types.OrderReturnSearchQuery{
+		StoreId: &storeId,
+		OrderId: query.OrderId,
+		Status:  query.Status,
+		Limit:   query.Limit,
+		Offset:  query.Offset,
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, returns, nil)
+}
+
+// getMyStoreOrderReturnsPages godoc
+// @Summary      Get current user's store order returns pages count
+// @Description  Calculates the total number of pages available for order returns of a store owned by the current user.
+// @Tags         return
+// @Produce      json
+// @Param        storeId  path      int     true   "Store ID"
+// @Param        order    query     int     false  "Filter by order ID"
+// @Param        status   query     string  false  "Filter by return status"
+// @Success      200      {object}  types.TotalPageCountResponse
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/store/me/{storeId}/pages [get]
+func (h *Handler) getMyStoreOrderReturnsPages(w http.ResponseWriter, r *http.Request) {
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	query := types.OrderReturnSearchQuery{}
+
+	queryMapping := map[string]any{
+		"order":  &query.OrderId,
+		"status": &query.Status,
+	}
+
+	queryValues := r.URL.Query()
+
+	err = utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if query.Status != nil {
+		if !query.Status.IsValid() {
+			utils.WriteErrorInResponse(
+				w,
+				http.StatusBadRequest,
+				types.ErrInvalidOrderReturnStatusEnum,
+			)
+			return
+		}
+	}
+
+	count, err := h.db.GetOrderReturnsCount(types.OrderReturnSearchQuery{
+		StoreId: &storeId,
+		OrderId: query.OrderId,
+		Status:  query.Status,
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxOrderReturnsInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getMyStoreOrderReturn godoc
+// @Summary      Get current user's store order return
+// @Description  Retrieves an order return of a store owned by the current user with its returned items.
+// @Tags         return
+// @Produce      json
+// @Param        storeId   path      int  true  "Store ID"
+// @Param        returnId  path      int  true  "Order return ID"
+// @Success      200       {object}  types.OrderReturnWithItems
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/store/me/{storeId}/{returnId} [get]
+func (h *Handler) getMyStoreOrderReturn(w http.ResponseWriter, r *http.Request) {
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	returnId, err := utils.ParseIntURLParam("returnId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	orderReturn, err := h.db.GetOrderReturnWithItemsById(returnId)
+	if err != nil {
+		if err == types.ErrOrderReturnNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if orderReturn.StoreId != storeId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrderReturn)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, orderReturn, nil)
+}
+
+// reviewMyStoreOrderReturn godoc
+// @Summary      Review store order return
+// @Description  Approves or rejects a pending order return of a store owned by the current user.
+// @Tags         return
+// @Accept       json
+// @Produce      json
+// @Param        storeId   path      int                             true  "Store ID"
+// @Param        returnId  path      int                             true  "Order return ID"
+// @Param        review    body      types.ReviewOrderReturnPayload  true  "Review details"
+// @Success      200       "Order return reviewed"
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/store/me/{storeId}/{returnId}/review [patch]
+func (h *Handler) reviewMyStoreOrderReturn(w http.ResponseWriter, r *http.Request) {
+	var payload types.ReviewOrderReturnPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !payload.Status.IsValid() {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusBadRequest,
+			types.ErrInvalidOrderReturnStatusEnum,
+		)
+		return
+	}
+
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	returnId, err := utils.ParseIntURLParam("returnId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	orderReturn, err := h.db.GetOrderReturnById(returnId)
+	if err != nil {
+		if err == types.ErrOrderReturnNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if orderReturn.StoreId != storeId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrderReturn)
+		return
+	}
+
+	err = h.db.ReviewOrderReturn(returnId, payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// receiveMyStoreOrderReturn godoc
+// @Summary      Receive store order return
+// @Description  Confirms the receipt of the items of an approved order return of a store owned by the current user. The refund is charged from the store owner's wallet and paid into the customer's wallet, and the items can optionally be put back into stock.
+// @Tags         return
+// @Accept       json
+// @Produce      json
+// @Param        storeId   path      int                              true  "Store ID"
+// @Param        returnId  path      int                              true  "Order return ID"
+// @Param        receive   body      types.ReceiveOrderReturnPayload  true  "Receipt details"
+// @Success      200       "Order return refunded"
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /return/store/me/{storeId}/{returnId}/receive [patch]
+func (h *Handler) receiveMyStoreOrderReturn(w http.ResponseWriter, r *http.Request) {
+	var payload types.ReceiveOrderReturnPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	returnId, err := utils.ParseIntURLParam("returnId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	orderReturn, err := h.db.GetOrderReturnById(returnId)
+	if err != nil {
+		if err == types.ErrOrderReturnNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if orderReturn.StoreId != storeId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessOrderReturn)
+		return
+	}
+
+	err = h.db.RefundOrderReturn(returnId, payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
@@ -0,0 +1,119 @@
+package payment
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/SaeedAlian/econest/api/config"
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+const FakeProviderName = "fake"
+
+// FakeProvider is a payment provider that keeps its payments in memory, it
+// is meant for the local and the test environments. Its payments are paid
+// or declined by calling Settle, which is what the fake checkout route does.
+type FakeProvider struct {
+	mu       sync.Mutex
+	payments map[string]*types.ProviderPayment
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{payments: map[string]*types.ProviderPayment{}}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) CreateIntent(
+	payload types.CreateProviderPaymentPayload,
+) (*types.ProviderPayment, error) {
+	if !payload.Amount.IsPositive() {
+		return nil, types.ErrInvalidMoneyAmount
+	}
+
+	b := make([]byte, 12)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, err
+	}
+
+	reference := fmt.Sprintf("%s_%s", FakeProviderName, hex.EncodeToString(b))
+
+	payment := &types.ProviderPayment{
+		Reference: reference,
+		Amount:    payload.Amount,
+		Status:    types.PaymentIntentStatusPending,
+		CheckoutUrl: fmt.Sprintf(
+			"%s:%s/payment/fake/%s",
+			config.Env.Host,
+			config.Env.Port,
+			reference,
+		),
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.payments[reference] = payment
+
+	res := *payment
+	return &res, nil
+}
+
+func (p *FakeProvider) Verify(reference string) (*types.ProviderPayment, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return nil, types.ErrPaymentIntentNotFound
+	}
+
+	res := *payment
+	return &res, nil
+}
+
+func (p *FakeProvider) Refund(reference string, amount types.Money) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return types.ErrPaymentIntentNotFound
+	}
+
+	if payment.Status != types.PaymentIntentStatusSucceeded ||
+		amount.GreaterThan(payment.Amount) {
+		return types.ErrPaymentVerificationFailed
+	}
+
+	payment.Status = types.PaymentIntentStatusRefunded
+	return nil
+}
+
+// Settle marks a pending payment as paid or declined, as if the payer had
+// finished the checkout.
+func (p *FakeProvider) Settle(reference string, status types.PaymentIntentStatus) error {
+	if status != types.PaymentIntentStatusSucceeded && status != types.PaymentIntentStatusFailed {
+		return types.ErrInvalidPaymentIntentStatusEnum
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return types.ErrPaymentIntentNotFound
+	}
+
+	if payment.Status != types.PaymentIntentStatusPending {
+		return types.ErrPaymentIntentNotPending
+	}
+
+	payment.Status = status
+	return nil
+}
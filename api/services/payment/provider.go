@@ -0,0 +1,50 @@
+package payment
+
+import (
+	"slices"
+
+	"github.com/SaeedAlian/econest/api/config"
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+// fakeProviderEnvs are the environments that the fake payment provider can be
+// used in, it settles its payments without any money so it must never be used
+// in production
+var fakeProviderEnvs = []string{"devel", "test"}
+
+// PaymentProvider collects the payments that come from outside of the
+// wallets, such as the deposits and the orders that are paid by card. The
+// provider confirms a payment by sending a signed event to the webhook, the
+// state of the payment is verified with the provider before it is applied.
+type PaymentProvider interface {
+	// Name is saved with the payment intents to know which provider
+	// collects them
+	Name() string
+	// CreateIntent starts a payment, the payer is sent to the checkout url
+	// of the returned payment
+	CreateIntent(p types.CreateProviderPaymentPayload) (*types.ProviderPayment, error)
+	// Verify returns the current state of a payment at the provider
+	Verify(reference string) (*types.ProviderPayment, error)
+	// Refund gives the money of a collected payment back to the payer
+	Refund(reference string, amount types.Money) error
+}
+
+// IsFakeProviderAllowed reports whether the fake payment provider can be used
+// in the given environment.
+func IsFakeProviderAllowed(env string) bool {
+	return slices.Contains(fakeProviderEnvs, env)
+}
+
+// NewPaymentProvider returns the provider with the given name. The api runs
+// without payments if it returns an error.
+func NewPaymentProvider(name string) (PaymentProvider, error) {
+	switch name {
+	case FakeProviderName:
+		if !IsFakeProviderAllowed(config.Env.Env) {
+			return nil, types.ErrFakePaymentProviderNotAllowed
+		}
+		return NewFakeProvider(), nil
+	default:
+		return nil, types.ErrUnknownPaymentProvider
+	}
+}
@@ -0,0 +1,292 @@
+package payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/SaeedAlian/econest/api/config"
+	db_manager "github.com/SaeedAlian/econest/api/db/manager"
+	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+// maxWebhookBodySize is the largest webhook request body that is read
+const maxWebhookBodySize = 1 << 20
+
+type Handler struct {
+	db          *db_manager.Manager
+	authHandler *auth.AuthHandler
+	provider    PaymentProvider
+}
+
+func NewHandler(
+	db *db_manager.Manager,
+	authHandler *auth.AuthHandler,
+	provider PaymentProvider,
+) *Handler {
+	return &Handler{db: db, authHandler: authHandler, provider: provider}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/webhook", h.handleWebhook).Methods("POST")
+
+	fakeProvider, ok := h.provider.(*FakeProvider)
+	if ok && IsFakeProviderAllowed(config.Env.Env) {
+		fakeRouter := router.Methods("POST").Subrouter()
+		fakeRouter.HandleFunc("/fake/{reference}", h.settleFakePayment(fakeProvider)).
+			Methods("POST")
+		fakeRouter.Use(h.authHandler.WithJWTAuth(h.db))
+		fakeRouter.Use(h.authHandler.WithCSRFToken())
+	}
+}
+
+// handleWebhook godoc
+// @Summary      Payment provider webhook
+// @Description  Receives the payment events of the payment provider. The request must be signed with the webhook secret, the signature is the hex encoded HMAC-SHA256 of "<timestamp>.<body>" sent in X-Payment-Signature with the unix timestamp in X-Payment-Timestamp. This is the only way that a deposit or an order paid through the provider is finalised, a collected payment that can no longer be applied is refunded.
+// @Tags         payment
+// @Accept       json
+// @Produce      json
+// @Param        X-Payment-Signature  header    string                     true  "Signature of the request"
+// @Param        X-Payment-Timestamp  header    string                     true  "Unix timestamp of the request"
+// @Param        event                body      types.PaymentWebhookEvent  true  "Payment event"
+// @Success      200  "Event handled"
+// @Failure      400  {object}  types.HTTPError
+// @Failure      401  {object}  types.HTTPError
+// @Failure      404  {object}  types.HTTPError
+// @Failure      500  {object}  types.HTTPError
+// @Router       /payment/webhook [post]
+func (h *Handler) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = VerifyWebhookSignature(
+		config.Env.PaymentWebhookSecret,
+		r.Header,
+		body,
+		time.Now(),
+		time.Duration(config.Env.PaymentWebhookToleranceInSec)*time.Second,
+	)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusUnauthorized, types.ErrInvalidWebhookSignature)
+		return
+	}
+
+	var event types.PaymentWebhookEvent
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	err = utils.ParseRequestPayload(r, &event)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !event.Type.IsValid() {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusBadRequest,
+			types.ErrInvalidPaymentWebhookEventTypeEnum,
+		)
+		return
+	}
+
+	intent, err := h.db.GetPaymentIntentByReference(h.provider.Name(), event.Reference)
+	if err != nil {
+		if err == types.ErrPaymentIntentNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	// the providers retry their events, an event of a settled payment is
+	// already handled
+	if intent.Status != types.PaymentIntentStatusPending {
+		utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+		return
+	}
+
+	// the event is only a notification, the payment itself is checked with
+	// the provider before it is applied
+	payment, err := h.provider.Verify(intent.Reference)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	expectedStatus := types.PaymentIntentStatusSucceeded
+	if event.Type == types.PaymentWebhookEventTypeFailed {
+		expectedStatus = types.PaymentIntentStatusFailed
+	}
+
+	if payment.Status != expectedStatus || payment.Amount != intent.Amount ||
+		event.Amount != intent.Amount {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrPaymentVerificationFailed)
+		return
+	}
+
+	if expectedStatus == types.PaymentIntentStatusFailed {
+		err = h.db.FailPaymentIntent(intent.Id, types.PaymentIntentStatusFailed)
+		if err != nil && err != types.ErrPaymentIntentNotPending {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+		return
+	}
+
+	err = h.db.ConfirmPaymentIntent(intent.Id)
+	if err == types.ErrPaymentIntentCannotBeApplied {
+		err = h.provider.Refund(intent.Reference, intent.Amount)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		err = h.db.FailPaymentIntent(intent.Id, types.PaymentIntentStatusRefunded)
+	}
+
+	if err != nil && err != types.ErrPaymentIntentNotPending {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// settleFakePayment godoc
+// @Summary      Settle a payment of the fake payment provider
+// @Description  Pays or declines a payment of the fake payment provider as if the payer had finished the checkout, then delivers the signed event to the webhook. Only the owner of the wallet of the payment can settle it. Only available when the fake payment provider is used in a development or test environment.
+// @Tags         payment
+// @Produce      json
+// @Param        reference  path      string  true   "Reference of the payment"
+// @Param        status     query     string  false  "succeeded (default) or failed"
+// @Success      200  "Payment settled"
+// @Failure      400  {object}  types.HTTPError
+// @Failure      401  {object}  types.HTTPError
+// @Failure      403  {object}  types.HTTPError
+// @Failure      404  {object}  types.HTTPError
+// @Failure      500  {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /payment/fake/{reference} [post]
+func (h *Handler) settleFakePayment(provider *FakeProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reference := mux.Vars(r)["reference"]
+
+		ctx := r.Context()
+
+		userId := ctx.Value("userId")
+
+		if userId == nil {
+			utils.WriteErrorInResponse(
+				w,
+				http.StatusUnauthorized,
+				types.ErrAuthenticationCredentialsNotFound,
+			)
+			return
+		}
+
+		intent, err := h.db.GetPaymentIntentByReference(provider.Name(), reference)
+		if err != nil {
+			if err == types.ErrPaymentIntentNotFound {
+				utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+			} else {
+				utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			}
+
+			return
+		}
+
+		// the money of a payment goes to the wallet of its payer, so only the
+		// payer can settle it
+		walletTx, err := h.db.GetWalletTransactionById(intent.WalletTransactionId)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		wallet, err := h.db.GetUserWallet(userId.(int))
+		if err != nil {
+			if err == types.ErrWalletNotFound {
+				utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrAccessDenied)
+			} else {
+				utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			}
+
+			return
+		}
+
+		if wallet.Id != walletTx.WalletId {
+			utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrAccessDenied)
+			return
+		}
+
+		status := types.PaymentIntentStatusSucceeded
+		if s := r.URL.Query().Get("status"); s != "" {
+			status = types.PaymentIntentStatus(s)
+		}
+
+		err = provider.Settle(reference, status)
+		if err != nil {
+			if err == types.ErrPaymentIntentNotFound {
+				utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+			} else {
+				utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			}
+
+			return
+		}
+
+		payment, err := provider.Verify(reference)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		eventType := types.PaymentWebhookEventTypeSucceeded
+		if status == types.PaymentIntentStatusFailed {
+			eventType = types.PaymentWebhookEventTypeFailed
+		}
+
+		now := time.Now()
+		body, err := json.Marshal(types.PaymentWebhookEvent{
+			Id:        fmt.Sprintf("evt_%s_%d", reference, now.UnixNano()),
+			Type:      eventType,
+			Reference: reference,
+			Amount:    payment.Amount,
+		})
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// the event goes through the webhook like the events of a real
+		// provider, so it is signed with the same secret
+		req, err := http.NewRequest(http.MethodPost, "/payment/webhook", bytes.NewReader(body))
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(webhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(
+			webhookSignatureHeader,
+			SignWebhookPayload(config.Env.PaymentWebhookSecret, now.Unix(), body),
+		)
+
+		h.handleWebhook(w, req)
+	}
+}
@@ -0,0 +1,70 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+const (
+	webhookSignatureHeader = "X-Payment-Signature"
+	webhookTimestampHeader = "X-Payment-Timestamp"
+)
+
+// SignWebhookPayload returns the signature that a payment provider sends
+// with a webhook request, which is the hex encoded HMAC-SHA256 of the unix
+// timestamp of the request and its body joined by a dot.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks the signature of a webhook request. The
+// timestamp is signed with the body, so a captured request cannot be
+// replayed once it is older than the tolerance.
+func VerifyWebhookSignature(
+	secret string,
+	header http.Header,
+	body []byte,
+	now time.Time,
+	tolerance time.Duration,
+) error {
+	if secret == "" {
+		return types.ErrInvalidWebhookSignature
+	}
+
+	signature, err := hex.DecodeString(header.Get(webhookSignatureHeader))
+	if err != nil || len(signature) == 0 {
+		return types.ErrInvalidWebhookSignature
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get(webhookTimestampHeader), 10, 64)
+	if err != nil {
+		return types.ErrInvalidWebhookSignature
+	}
+
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return types.ErrInvalidWebhookSignature
+	}
+
+	expected, err := hex.DecodeString(SignWebhookPayload(secret, timestamp, body))
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(signature, expected) {
+		return types.ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
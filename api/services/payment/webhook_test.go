@@ -0,0 +1,114 @@
+package payment
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/SaeedAlian/econest/api/types"
+)
+
+func signedWebhookHeader(secret string, timestamp int64, body []byte) http.Header {
+	header := http.Header{}
+	header.Set(webhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	header.Set(webhookSignatureHeader, SignWebhookPayload(secret, timestamp, body))
+
+	return header
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	secret := "secret"
+	body := []byte(`{"type":"payment.succeeded"}`)
+	now := time.Now()
+	tolerance := 5 * time.Minute
+
+	header := signedWebhookHeader(secret, now.Unix(), body)
+	err := VerifyWebhookSignature(secret, header, body, now, tolerance)
+	if err != nil {
+		t.Errorf("There was an error on verifying a valid signature: %v", err)
+	}
+
+	err = VerifyWebhookSignature(secret, header, []byte(`{"type":"payment.failed"}`), now, tolerance)
+	if err != types.ErrInvalidWebhookSignature {
+		t.Errorf("The signature of a changed body is verified: %v", err)
+	}
+
+	err = VerifyWebhookSignature("other", header, body, now, tolerance)
+	if err != types.ErrInvalidWebhookSignature {
+		t.Errorf("The signature of another secret is verified: %v", err)
+	}
+
+	staleHeader := signedWebhookHeader(secret, now.Add(-10*time.Minute).Unix(), body)
+	err = VerifyWebhookSignature(secret, staleHeader, body, now, tolerance)
+	if err != types.ErrInvalidWebhookSignature {
+		t.Errorf("The signature of a stale request is verified: %v", err)
+	}
+
+	emptySecretHeader := signedWebhookHeader("", now.Unix(), body)
+	err = VerifyWebhookSignature("", emptySecretHeader, body, now, tolerance)
+	if err != types.ErrInvalidWebhookSignature {
+		t.Errorf("The signature is verified without a secret: %v", err)
+	}
+
+	err = VerifyWebhookSignature(secret, http.Header{}, body, now, tolerance)
+	if err != types.ErrInvalidWebhookSignature {
+		t.Errorf("A request without a signature is verified: %v", err)
+	}
+}
+
+func TestFakeProvider(t *testing.T) {
+	provider := NewFakeProvider()
+	amount := types.MoneyFromFloat(12.5)
+
+	_, err := provider.CreateIntent(types.CreateProviderPaymentPayload{})
+	if err != types.ErrInvalidMoneyAmount {
+		t.Errorf("A payment without an amount is created: %v", err)
+	}
+
+	payment, err := provider.CreateIntent(types.CreateProviderPaymentPayload{Amount: amount})
+	if err != nil {
+		t.Fatalf("There was an error on creating the payment: %v", err)
+	}
+
+	if payment.Status != types.PaymentIntentStatusPending || payment.Amount != amount {
+		t.Errorf("The created payment is not pending with its amount: %+v", payment)
+	}
+
+	err = provider.Refund(payment.Reference, amount)
+	if err != types.ErrPaymentVerificationFailed {
+		t.Errorf("A pending payment is refunded: %v", err)
+	}
+
+	err = provider.Settle(payment.Reference, types.PaymentIntentStatusSucceeded)
+	if err != nil {
+		t.Errorf("There was an error on settling the payment: %v", err)
+	}
+
+	err = provider.Settle(payment.Reference, types.PaymentIntentStatusFailed)
+	if err != types.ErrPaymentIntentNotPending {
+		t.Errorf("A settled payment is settled again: %v", err)
+	}
+
+	verified, err := provider.Verify(payment.Reference)
+	if err != nil {
+		t.Errorf("There was an error on verifying the payment: %v", err)
+	} else if verified.Status != types.PaymentIntentStatusSucceeded {
+		t.Errorf("The settled payment is not succeeded: %v", verified.Status)
+	}
+
+	err = provider.Refund(payment.Reference, amount.Add(types.MoneyFromFloat(1)))
+	if err != types.ErrPaymentVerificationFailed {
+		t.Errorf("A payment is refunded with more than its amount: %v", err)
+	}
+
+	err = provider.Refund(payment.Reference, amount)
+	if err != nil {
+		t.Errorf("There was an error on refunding the payment: %v", err)
+	}
+
+	_, err = provider.Verify("fake_unknown")
+	if err != types.ErrPaymentIntentNotFound {
+		t.Errorf("An unknown payment is verified: %v", err)
+	}
+}
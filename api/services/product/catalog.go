@@ -0,0 +1,464 @@
+package product
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/SaeedAlian/econest/api/config"
+	db_manager "github.com/SaeedAlian/econest/api/db/manager"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+// productCatalogColumns are the columns of a product catalog CSV file, the
+// list columns hold their values as JSON arrays
+var productCatalogColumns = []string{
+	"slug",
+	"name",
+	"price",
+	"shipment_factor",
+	"description",
+	"is_active",
+	"subcategory_id",
+	"tag_ids",
+	"specs",
+	"images",
+	"variants",
+}
+
+// productCatalogRequiredColumns are the columns that a product catalog CSV
+// file cannot leave out
+var productCatalogRequiredColumns = []string{"slug", "name", "price", "subcategory_id"}
+
+// productCatalogEntry is a row of a product catalog file that could be read
+type productCatalogEntry struct {
+	rowNumber int
+	row       types.ProductCatalogRow
+}
+
+// productCatalog is the content of a product catalog file, the rows that
+// could not be read are kept as errors
+type productCatalog struct {
+	totalRows int
+	entries   []productCatalogEntry
+	errors    []types.ProductImportRowError
+}
+
+// parseProductCatalog reads the rows of a product catalog file. It only
+// returns an error if the file cannot be read at all, the rows that cannot
+// be read are returned as the errors of the catalog.
+func parseProductCatalog(format types.ProductImportFormat, data string) (*productCatalog, error) {
+	var catalog *productCatalog
+	var err error
+
+	data = strings.TrimPrefix(data, "\ufeff")
+
+	switch format {
+	case types.ProductImportFormatCSV:
+		catalog, err = parseProductCatalogCSV(data)
+	case types.ProductImportFormatJSONL:
+		catalog, err = parseProductCatalogJSONL(data)
+	default:
+		return nil, types.ErrInvalidProductImportFormatEnum
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if catalog.totalRows == 0 {
+		return nil, types.ErrProductImportIsEmpty
+	}
+
+	maxRows := int(config.Env.ProductImportMaxRows)
+	if catalog.totalRows > maxRows {
+		return nil, types.ErrProductImportTooLarge(maxRows)
+	}
+
+	return catalog, nil
+}
+
+// parseProductCatalogJSONL reads a file with a product catalog row as a JSON
+// object on each line, the blank lines are skipped and the rows are numbered
+// by their lines.
+func parseProductCatalogJSONL(data string) (*productCatalog, error) {
+	catalog := productCatalog{}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		catalog.totalRows++
+
+		var row types.ProductCatalogRow
+		err := json.Unmarshal([]byte(line), &row)
+		if err != nil {
+			catalog.errors = append(catalog.errors, types.ProductImportRowError{
+				RowNumber: lineNumber,
+				Message:   types.ErrInvalidPayloadField(err).Error(),
+			})
+			continue
+		}
+
+		catalog.entries = append(catalog.entries, productCatalogEntry{
+			rowNumber: lineNumber,
+			row:       row,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &catalog, nil
+}
+
+// parseProductCatalogCSV reads a CSV file with a header of the catalog
+// columns in any order. The rows are numbered from the first row after the
+// header.
+func parseProductCatalogCSV(data string) (*productCatalog, error) {
+	cr := csv.NewReader(strings.NewReader(data))
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, types.ErrProductImportIsEmpty
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	for _, column := range productCatalogRequiredColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, types.ErrImportColumnMissing(column)
+		}
+	}
+
+	catalog := productCatalog{}
+
+	rowNumber := 0
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+
+		rowNumber++
+		catalog.totalRows++
+
+		if err != nil {
+			if !errors.Is(err, csv.ErrFieldCount) {
+				return nil, err
+			}
+
+			catalog.errors = append(catalog.errors, types.ProductImportRowError{
+				RowNumber: rowNumber,
+				Message:   err.Error(),
+			})
+			continue
+		}
+
+		cell := func(column string) string {
+			i, ok := columns[column]
+			if !ok {
+				return ""
+			}
+
+			return strings.TrimSpace(record[i])
+		}
+
+		row, err := parseProductCatalogCSVRecord(cell)
+		if err != nil {
+			slug := cell("slug")
+			catalog.errors = append(catalog.errors, types.ProductImportRowError{
+				RowNumber: rowNumber,
+				Slug:      &slug,
+				Message:   err.Error(),
+			})
+			continue
+		}
+
+		catalog.entries = append(catalog.entries, productCatalogEntry{
+			rowNumber: rowNumber,
+			row:       *row,
+		})
+	}
+
+	return &catalog, nil
+}
+
+func parseProductCatalogCSVRecord(cell func(column string) string) (*types.ProductCatalogRow, error) {
+	row := types.ProductCatalogRow{
+		Slug:        cell("slug"),
+		Name:        cell("name"),
+		Description: cell("description"),
+	}
+
+	price, err := types.ParseMoney(cell("price"))
+	if err != nil {
+		return nil, types.ErrInvalidImportColumn("price", err)
+	}
+	row.Price = price
+
+	if v := cell("shipment_factor"); v != "" {
+		row.ShipmentFactor, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, types.ErrInvalidImportColumn("shipment_factor", err)
+		}
+	}
+
+	if v := cell("is_active"); v != "" {
+		isActive, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, types.ErrInvalidImportColumn("is_active", err)
+		}
+		row.IsActive = &isActive
+	}
+
+	row.SubcategoryId, err = strconv.Atoi(cell("subcategory_id"))
+	if err != nil {
+		return nil, types.ErrInvalidImportColumn("subcategory_id", err)
+	}
+
+	lists := []struct {
+		column string
+		value  any
+	}{
+		{"tag_ids", &row.TagIds},
+		{"specs", &row.Specs},
+		{"images", &row.Images},
+		{"variants", &row.Variants},
+	}
+
+	for _, l := range lists {
+		v := cell(l.column)
+		if v == "" {
+			continue
+		}
+
+		if err := json.Unmarshal([]byte(v), l.value); err != nil {
+			return nil, types.ErrInvalidImportColumn(l.column, err)
+		}
+	}
+
+	return &row, nil
+}
+
+// validateProductCatalogRow checks the fields of a row that do not depend on
+// the database, the images are checked in the uploads of the products.
+func validateProductCatalogRow(row types.ProductCatalogRow, imageUploadDir string) error {
+	if err := utils.Validator.Struct(row); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			return types.ErrInvalidPayloadField(validationErrors[0])
+		}
+		return err
+	}
+
+	if row.Slug != utils.CreateSlug(row.Slug) {
+		return types.ErrInvalidProductSlug
+	}
+
+	if row.Price.IsNegative() {
+		return types.ErrNegativeProductPrice
+	}
+
+	for _, variant := range row.Variants {
+		if variant.Quantity < 0 {
+			return types.ErrNegativeVariantQuantity
+		}
+	}
+
+	for _, img := range row.Images {
+		if img.ImageName == "" {
+			return types.ErrImageNotExist
+		}
+
+		isFileExists, err := utils.PathExists(fmt.Sprintf("%s/%s", imageUploadDir, img.ImageName))
+		if err != nil {
+			return err
+		}
+
+		if !isFileExists {
+			return types.ErrImageNotExist
+		}
+	}
+
+	return nil
+}
+
+// importProductCatalog applies the rows of a product catalog to a store one
+// by one, a row that fails does not stop the rows after it. If dryRun is
+// set, the rows are checked against the database but not saved.
+func importProductCatalog(
+	dbManager *db_manager.Manager,
+	storeId int,
+	catalog *productCatalog,
+	dryRun bool,
+	imageUploadDir string,
+) types.ProductImportResult {
+	result := types.ProductImportResult{
+		TotalRows: catalog.totalRows,
+		Errors:    append([]types.ProductImportRowError{}, catalog.errors...),
+	}
+
+	seenSlugs := make(map[string]bool, len(catalog.entries))
+
+	for _, entry := range catalog.entries {
+		slug := entry.row.Slug
+
+		fail := func(err error) {
+			result.Errors = append(result.Errors, types.ProductImportRowError{
+				RowNumber: entry.rowNumber,
+				Slug:      &slug,
+				Message:   utils.FormatDBError(err).Error(),
+			})
+		}
+
+		if seenSlugs[slug] {
+			fail(types.ErrDuplicateProductImportSlug)
+			continue
+		}
+		seenSlugs[slug] = true
+
+		if err := validateProductCatalogRow(entry.row, imageUploadDir); err != nil {
+			fail(err)
+			continue
+		}
+
+		created, err := dbManager.ImportProductCatalogRow(storeId, entry.row, dryRun)
+		if err != nil {
+			fail(err)
+			continue
+		}
+
+		if created {
+			result.CreatedRows++
+		} else {
+			result.UpdatedRows++
+		}
+	}
+
+	slices.SortStableFunc(result.Errors, func(a, b types.ProductImportRowError) int {
+		return a.RowNumber - b.RowNumber
+	})
+
+	return result
+}
+
+// ProcessPendingProductImports applies the pending product imports one
+// after another until there is none left, and returns how many imports it
+// has processed.
+func ProcessPendingProductImports(dbManager *db_manager.Manager) (int, error) {
+	imageUploadDir := fmt.Sprintf("%s/products", config.Env.UploadsRootDir)
+	processed := 0
+
+	for {
+		imp, data, err := dbManager.ClaimPendingProductImport()
+		if err != nil {
+			return processed, err
+		}
+
+		if imp == nil {
+			return processed, nil
+		}
+
+		processed++
+
+		catalog, err := parseProductCatalog(imp.Format, data)
+		if err != nil {
+			err = dbManager.FailProductImport(imp.Id, err.Error())
+			if err != nil {
+				return processed, err
+			}
+			continue
+		}
+
+		result := importProductCatalog(dbManager, imp.StoreId, catalog, imp.DryRun, imageUploadDir)
+
+		err = dbManager.CompleteProductImport(imp.Id, result)
+		if err != nil {
+			return processed, err
+		}
+	}
+}
+
+// renderProductCatalogCSV renders the catalog of a store as a CSV file with
+// the catalog columns, which can be imported back.
+func renderProductCatalogCSV(catalog []types.ProductCatalogRow) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	cw := csv.NewWriter(buf)
+
+	records := [][]string{productCatalogColumns}
+
+	for _, row := range catalog {
+		lists := []any{row.TagIds, row.Specs, row.Images, row.Variants}
+		encoded := make([]string, len(lists))
+
+		for i, l := range lists {
+			b, err := json.Marshal(l)
+			if err != nil {
+				return nil, err
+			}
+			encoded[i] = string(b)
+		}
+
+		isActive := ""
+		if row.IsActive != nil {
+			isActive = strconv.FormatBool(*row.IsActive)
+		}
+
+		records = append(records, append([]string{
+			row.Slug,
+			row.Name,
+			row.Price.String(),
+			strconv.FormatFloat(row.ShipmentFactor, 'f', -1, 64),
+			row.Description,
+			isActive,
+			strconv.Itoa(row.SubcategoryId),
+		}, encoded...))
+	}
+
+	if err := cw.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderProductCatalogJSONL renders the catalog of a store with a row as a
+// JSON object on each line.
+func renderProductCatalogJSONL(catalog []types.ProductCatalogRow) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+
+	for _, row := range catalog {
+		if err := enc.Encode(row); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
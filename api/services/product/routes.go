@@ -2,7 +2,11 @@ package product
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
 
@@ -35,6 +39,7 @@ func NewHandler(
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("", h.getProducts).Methods("GET")
 	router.HandleFunc("/pages", h.getProductsPages).Methods("GET")
+	router.HandleFunc("/facets", h.getProductFacets).Methods("GET")
 	router.HandleFunc("/{productId}", h.getProduct).Methods("GET")
 	router.HandleFunc("/image/{filename}", h.getProductImage).Methods("GET")
 	router.HandleFunc("/{productId}/extended", h.getProductExtended).Methods("GET")
@@ -99,6 +104,31 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 		h.db,
 		[]types.Action{types.ActionCanDeleteProduct},
 	)).Methods("DELETE")
+	withAuthRouter.HandleFunc("/store/{storeId}/import", h.authHandler.WithActionPermissionAuth(
+		h.importStoreProducts,
+		h.db,
+		[]types.Action{types.ActionCanAddProduct, types.ActionCanUpdateProduct},
+	)).Methods("POST")
+	withAuthRouter.HandleFunc("/store/{storeId}/import", h.authHandler.WithActionPermissionAuth(
+		h.getStoreProductImports,
+		h.db,
+		[]types.Action{types.ActionCanAddProduct, types.ActionCanUpdateProduct},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/store/{storeId}/import/pages", h.authHandler.WithActionPermissionAuth(
+		h.getStoreProductImportsPages,
+		h.db,
+		[]types.Action{types.ActionCanAddProduct, types.ActionCanUpdateProduct},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/store/{storeId}/import/{importId}", h.authHandler.WithActionPermissionAuth(
+		h.getStoreProductImport,
+		h.db,
+		[]types.Action{types.ActionCanAddProduct, types.ActionCanUpdateProduct},
+	)).Methods("GET")
+	withAuthRouter.HandleFunc("/store/{storeId}/export", h.authHandler.WithActionPermissionAuth(
+		h.exportStoreProducts,
+		h.db,
+		[]types.Action{types.ActionCanUpdateProduct},
+	)).Methods("GET")
 	withAuthRouter.Use(h.authHandler.WithJWTAuth(h.db))
 	withAuthRouter.Use(h.authHandler.WithCSRFToken())
 	withAuthRouter.Use(h.authHandler.WithVerifiedEmail(h.db))
@@ -276,27 +306,31 @@ func (h *Handler) getProductCategoryImage(w http.ResponseWriter, r *http.Request
 
 // getProducts godoc
 // @Summary      Get products
-// @Description  Retrieves a paginated list of products with optional filtering
+// @Description  Retrieves a paginated list of products with optional filtering. When a keyword is given, the best matches come first by default and each product has a snippet with the matched words highlighted
 // @Tags         product
 // @Produce      json
-// @Param        k      query     string  false  "Search keyword"
+// @Param        k      query     string  false  "Search keyword (quoted parts match as phrases, words ending with '*' as prefixes)"
 // @Param        avgscr query     float32 false  "Minimum average score"
 // @Param        minq   query     int     false  "Minimum quantity filter"
 // @Param        maxq   query     int     false  "Maximum quantity filter"
 // @Param        offr   query     bool    false  "Filter products with offers"
 // @Param        cat    query     int     false  "Filter by category ID"
 // @Param        tags   query     string  false  "Filter by tag IDs (separated by comma ',')"
-// @Param        pmt    query     int     false  "Filter products with price more than value"
-// @Param        plt    query     int     false  "Filter products with price less than value"
+// @Param        pmt    query     number  false  "Filter products with price more than value"
+// @Param        plt    query     number  false  "Filter products with price less than value"
 // @Param        store  query     int     false  "Filter by store ID"
+// @Param        sort   query     string  false  "Sort (relevance, price_asc, price_desc, newest, top_rated, best_selling, biggest_discount), default: relevance with a keyword and newest otherwise"
 // @Param        p      query     int     false  "Page number (default: 1)"
+// @Param        cursor query     string  false  "Cursor of the next page from the X-Next-Cursor header, used instead of the page number"
 // @Success      200    {array}   types.Product
+// @Header       200    {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure      400    {object}  types.HTTPError
 // @Failure      500    {object}  types.HTTPError
 // @Router       /product [get]
 func (h *Handler) getProducts(w http.ResponseWriter, r *http.Request) {
 	query := types.ProductSearchQuery{}
 	var page *int = nil
+	var cursor *string = nil
 
 	queryMapping := map[string]any{
 		"k":      &query.Keyword,
@@ -309,7 +343,9 @@ func (h *Handler) getProducts(w http.ResponseWriter, r *http.Request) {
 		"pmt":    &query.PriceMoreThan,
 		"plt":    &query.PriceLessThan,
 		"store":  &query.StoreId,
+		"sort":   &query.Sort,
 		"p":      &page,
+		"cursor": &cursor,
 	}
 
 	queryValues := r.URL.Query()
@@ -320,21 +356,39 @@ func (h *Handler) getProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if query.Sort != nil {
+		if !query.Sort.IsValid() {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrInvalidProductSortEnum)
+			return
+		}
+	}
+
 	query.Limit = utils.Ptr(int(config.Env.MaxProductsInPage))
 
-	if page != nil {
+	if cursor != nil {
+		query.Cursor, err = types.DecodeCursor(*cursor, query.ResolvedSort().String())
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else if page != nil {
 		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
 	} else {
 		query.Offset = utils.Ptr(0)
 	}
 
-	products, err := h.db.GetProducts(query)
+	productsPage, err := h.db.GetProductsPage(query)
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, products, nil)
+	utils.WriteJSONInResponse(
+		w,
+		http.StatusOK,
+		productsPage.Items,
+		utils.NextCursorHeaders(productsPage.NextCursor),
+	)
 }
 
 // getProductsPages godoc
@@ -342,15 +396,15 @@ func (h *Handler) getProducts(w http.ResponseWriter, r *http.Request) {
 // @Description  Returns the total number of pages available for products based on filters
 // @Tags         product
 // @Produce      json
-// @Param        k      query     string  false  "Search keyword"
+// @Param        k      query     string  false  "Search keyword (quoted parts match as phrases, words ending with '*' as prefixes)"
 // @Param        avgscr query     float32 false  "Minimum average score"
 // @Param        minq   query     int     false  "Minimum quantity filter"
 // @Param        maxq   query     int     false  "Maximum quantity filter"
 // @Param        offr   query     bool    false  "Filter products with offers"
 // @Param        cat    query     int     false  "Filter by category ID"
 // @Param        tags   query     string  false  "Filter by tag IDs (separated by comma ',')"
-// @Param        pmt    query     int     false  "Filter products with price more than value"
-// @Param        plt    query     int     false  "Filter products with price less than value"
+// @Param        pmt    query     number  false  "Filter products with price more than value"
+// @Param        plt    query     number  false  "Filter products with price less than value"
 // @Param        store  query     int     false  "Filter by store ID"
 // @Success      200    {object}  types.TotalPageCountResponse
 // @Failure      400    {object}  types.HTTPError
@@ -393,6 +447,67 @@ func (h *Handler) getProductsPages(w http.ResponseWriter, r *http.Request) {
 	}, nil)
 }
 
+// getProductFacets godoc
+// @Summary      Get product facets
+// @Description  Counts the products that match the filters per subcategory, tag, attribute option, store, offer presence and price range
+// @Tags         product
+// @Produce      json
+// @Param        k      query     string  false  "Search keyword (quoted parts match as phrases, words ending with '*' as prefixes)"
+// @Param        avgscr query     float32 false  "Minimum average score"
+// @Param        minq   query     int     false  "Minimum quantity filter"
+// @Param        maxq   query     int     false  "Maximum quantity filter"
+// @Param        offr   query     bool    false  "Filter products with offers"
+// @Param        cat    query     int     false  "Filter by category ID"
+// @Param        tags   query     string  false  "Filter by tag IDs (separated by comma ',')"
+// @Param        pmt    query     number  false  "Filter products with price more than value"
+// @Param        plt    query     number  false  "Filter products with price less than value"
+// @Param        store  query     int     false  "Filter by store ID"
+// @Param        prng   query     string  false  "Ascending prices that split the price ranges (separated by comma ',', default from the config)"
+// @Success      200    {object}  types.ProductFacets
+// @Failure      400    {object}  types.HTTPError
+// @Failure      500    {object}  types.HTTPError
+// @Router       /product/facets [get]
+func (h *Handler) getProductFacets(w http.ResponseWriter, r *http.Request) {
+	query := types.ProductSearchQuery{}
+	var priceRanges *string = nil
+
+	queryMapping := map[string]any{
+		"k":      &query.Keyword,
+		"avgscr": &query.AverageScore,
+		"minq":   &query.MinQuantity,
+		"maxq":   &query.MaxQuantity,
+		"offr":   &query.HasOffer,
+		"cat":    &query.CategoryId,
+		"tags":   &query.TagIds,
+		"pmt":    &query.PriceMoreThan,
+		"plt":    &query.PriceLessThan,
+		"store":  &query.StoreId,
+		"prng":   &priceRanges,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	priceBounds, err := parseProductPriceFacetBounds(priceRanges)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	facets, err := h.db.GetProductFacets(query, priceBounds)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, facets, nil)
+}
+
 // getProduct godoc
 // @Summary      Get a product
 // @Description  Retrieves details of a specific product by ID
@@ -459,11 +574,11 @@ func (h *Handler) getProductExtended(w http.ResponseWriter, r *http.Request) {
 
 // getProductInventory godoc
 // @Summary      Get product inventory
-// @Description  Retrieves inventory information for a specific product by ID
+// @Description  Retrieves inventory information for a specific product by ID, including the quantity held by pending orders
 // @Tags         product
 // @Produce      json
 // @Param        productId  path      int  true  "Product ID"
-// @Success      200        {object}  types.ProductInventoryResponse  "Returns object with total and inStock counts"
+// @Success      200        {object}  types.ProductInventoryResponse  "Returns object with total, reserved, available and inStock counts"
 // @Failure      400        {object}  types.HTTPError
 // @Failure      500        {object}  types.HTTPError
 // @Router       /product/{productId}/inventory [get]
@@ -474,15 +589,19 @@ func (h *Handler) getProductInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	total, inStock, err := h.db.GetProductInventory(productId)
+	total, reserved, err := h.db.GetProductInventory(productId)
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, map[string]any{
-		"total":   total,
-		"inStock": inStock,
+	available := max(total-reserved, 0)
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.ProductInventoryResponse{
+		Total:     total,
+		Reserved:  reserved,
+		Available: available,
+		InStock:   available > 0,
 	}, nil)
 }
 
@@ -1676,6 +1795,356 @@ func (h *Handler) deleteProduct(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
 }
 
+// importStoreProducts godoc
+// @Summary      Import products into a store
+// @Description  Queues a CSV or JSON Lines file of products to be imported into a store in the background. Each row creates a product, or updates the product of the store with the same slug. The rows are checked against the categories, the tags and the attributes, and the rows that fail are reported in the import without stopping the others. With dryRun the rows are only checked and nothing is saved.
+// @Tags         product
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        storeId  path      int       true   "Store ID"
+// @Param        file     formData  file      true   "Products file"
+// @Param        format   query     string    false  "Format of the file, csv or jsonl (default: csv)"
+// @Param        dryRun   query     bool      false  "Only check the rows without saving them"
+// @Success      202      {object}  types.NewProductImportResponse
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /product/store/{storeId}/import [post]
+func (h *Handler) importStoreProducts(w http.ResponseWriter, r *http.Request) {
+	storeId, userId, ok := h.authorizeStoreOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var format *types.ProductImportFormat = nil
+	var dryRun *bool = nil
+
+	queryMapping := map[string]any{
+		"format": &format,
+		"dryRun": &dryRun,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payload := types.CreateProductImportPayload{
+		Format:  types.ProductImportFormatCSV,
+		StoreId: storeId,
+		UserId:  userId,
+	}
+
+	if format != nil {
+		if !format.IsValid() {
+			utils.WriteErrorInResponse(
+				w,
+				http.StatusBadRequest,
+				types.ErrInvalidProductImportFormatEnum,
+			)
+			return
+		}
+
+		payload.Format = *format
+	}
+
+	if dryRun != nil {
+		payload.DryRun = *dryRun
+	}
+
+	maxSizeInMB := config.Env.ProductImportMaxSizeInMB
+	maxSizeInBytes := maxSizeInMB * 1024 * 1024
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSizeInBytes)
+	if err := r.ParseMultipartForm(maxSizeInBytes); err != nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusBadRequest,
+			types.ErrUploadSizeTooBig(int(maxSizeInMB)),
+		)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrCannotRetrieveFile(err))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrFileUpload(err))
+		return
+	}
+
+	if !utf8.Valid(data) {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrInvalidPayload)
+		return
+	}
+
+	payload.Data = string(data)
+
+	_, err = parseProductCatalog(payload.Format, payload.Data)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	importId, err := h.db.CreateProductImport(payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := types.NewProductImportResponse{
+		ImportId: importId,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusAccepted, res, nil)
+}
+
+// getStoreProductImports godoc
+// @Summary      Get the product imports of a store
+// @Description  Retrieves a paginated list of the product imports of a store, the latest first
+// @Tags         product
+// @Produce      json
+// @Param        storeId  path      int     true   "Store ID"
+// @Param        status   query     string  false  "Filter by the status of the import"
+// @Param        p        query     int     false  "Page number (default: 1)"
+// @Success      200      {array}   types.ProductImport
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /product/store/{storeId}/import [get]
+func (h *Handler) getStoreProductImports(w http.ResponseWriter, r *http.Request) {
+	storeId, _, ok := h.authorizeStoreOwner(w, r)
+	if !ok {
+		return
+	}
+
+	query := types.ProductImportSearchQuery{
+		StoreId: &storeId,
+	}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"status": &query.Status,
+		"p":      &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if query.Status != nil && !query.Status.IsValid() {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrInvalidProductImportStatusEnum)
+		return
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxProductImportsInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	imports, err := h.db.GetProductImports(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, imports, nil)
+}
+
+// getStoreProductImportsPages godoc
+// @Summary      Get product import page count
+// @Description  Returns the total number of pages available for the product imports of a store based on filters
+// @Tags         product
+// @Produce      json
+// @Param        storeId  path      int     true   "Store ID"
+// @Param        status   query     string  false  "Filter by the status of the import"
+// @Success      200      {object}  types.TotalPageCountResponse
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /product/store/{storeId}/import/pages [get]
+func (h *Handler) getStoreProductImportsPages(w http.ResponseWriter, r *http.Request) {
+	storeId, _, ok := h.authorizeStoreOwner(w, r)
+	if !ok {
+		return
+	}
+
+	query := types.ProductImportSearchQuery{
+		StoreId: &storeId,
+	}
+
+	queryMapping := map[string]any{
+		"status": &query.Status,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if query.Status != nil && !query.Status.IsValid() {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrInvalidProductImportStatusEnum)
+		return
+	}
+
+	count, err := h.db.GetProductImportsCount(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxProductImportsInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getStoreProductImport godoc
+// @Summary      Get a product import of a store
+// @Description  Retrieves a product import of a store with the errors of the rows that could not be applied
+// @Tags         product
+// @Produce      json
+// @Param        storeId   path      int  true  "Store ID"
+// @Param        importId  path      int  true  "Product import ID"
+// @Success      200       {object}  types.ProductImportWithErrors
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /product/store/{storeId}/import/{importId} [get]
+func (h *Handler) getStoreProductImport(w http.ResponseWriter, r *http.Request) {
+	storeId, _, ok := h.authorizeStoreOwner(w, r)
+	if !ok {
+		return
+	}
+
+	importId, err := utils.ParseIntURLParam("importId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	imp, err := h.db.GetProductImportById(importId)
+	if err != nil {
+		if err == types.ErrProductImportNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if imp.StoreId != storeId {
+		utils.WriteErrorInResponse(w, http.StatusNotFound, types.ErrProductImportNotFound)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, imp, nil)
+}
+
+// exportStoreProducts godoc
+// @Summary      Export the products of a store
+// @Description  Downloads the products of a store with their tags, specs, images and variants as a CSV or JSON Lines file, in the same format that the imports read
+// @Tags         product
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        storeId  path      int     true   "Store ID"
+// @Param        format   query     string  false  "Format of the file, csv or jsonl (default: csv)"
+// @Success      200      {file}    binary  "Products file"
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /product/store/{storeId}/export [get]
+func (h *Handler) exportStoreProducts(w http.ResponseWriter, r *http.Request) {
+	storeId, _, ok := h.authorizeStoreOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var format *types.ProductImportFormat = nil
+
+	queryMapping := map[string]any{
+		"format": &format,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if format == nil {
+		format = utils.Ptr(types.ProductImportFormatCSV)
+	}
+
+	catalog, err := h.db.GetStoreProductCatalog(storeId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	filename := fmt.Sprintf("products-%d-%s", storeId, time.Now().Format("20060102"))
+
+	switch *format {
+	case types.ProductImportFormatCSV:
+		data, err := renderProductCatalogCSV(catalog)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteFileInResponse(w, http.StatusOK, data, "text/csv", filename+".csv")
+
+	case types.ProductImportFormatJSONL:
+		data, err := renderProductCatalogJSONL(catalog)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteFileInResponse(w, http.StatusOK, data, "application/x-ndjson", filename+".jsonl")
+
+	default:
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, types.ErrInvalidProductImportFormatEnum)
+	}
+}
+
 // createProductOffer godoc
 // @Summary      Create a product offer
 // @Description  Creates a new offer for a product
@@ -2485,3 +2954,75 @@ func (h *Handler) deleteProductTag(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
 }
+
+// parseProductPriceFacetBounds parses the comma separated prices that split
+// the price ranges of the facets, falling back to the configured ones. The
+// prices must be in ascending order.
+func parseProductPriceFacetBounds(raw *string) ([]types.Money, error) {
+	bounds := []types.Money{}
+
+	if raw == nil {
+		for _, b := range config.Env.ProductPriceFacetBounds {
+			bounds = append(bounds, types.MoneyFromFloat(b))
+		}
+	} else {
+		for _, val := range strings.Split(*raw, ",") {
+			b, err := types.ParseMoney(strings.TrimSpace(val))
+			if err != nil {
+				return nil, types.ErrInvalidQueryValue("prng")
+			}
+
+			bounds = append(bounds, b)
+		}
+	}
+
+	for i := 1; i < len(bounds); i++ {
+		if !bounds[i-1].LessThan(bounds[i]) {
+			return nil, types.ErrInvalidQueryValue("prng")
+		}
+	}
+
+	return bounds, nil
+}
+
+// authorizeStoreOwner reads the store of a request from its path and checks
+// that the current user owns it. It writes the error in the response and
+// returns false if the user cannot access the store.
+func (h *Handler) authorizeStoreOwner(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	cUserId := r.Context().Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return -1, -1, false
+	}
+
+	userId := cUserId.(int)
+
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return -1, -1, false
+	}
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return -1, -1, false
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return -1, -1, false
+	}
+
+	return storeId, userId, true
+}
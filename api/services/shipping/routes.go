@@ -0,0 +1,808 @@
+package shipping
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/SaeedAlian/econest/api/config"
+	db_manager "github.com/SaeedAlian/econest/api/db/manager"
+	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+type Handler struct {
+	db          *db_manager.Manager
+	authHandler *auth.AuthHandler
+}
+
+func NewHandler(
+	db *db_manager.Manager,
+	authHandler *auth.AuthHandler,
+) *Handler {
+	return &Handler{
+		db:          db,
+		authHandler: authHandler,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/zone", h.getShippingZones).Methods("GET")
+	router.HandleFunc("/zone/pages", h.getShippingZonesPages).Methods("GET")
+	router.HandleFunc("/zone/{zoneId}", h.getShippingZone).Methods("GET")
+	router.HandleFunc("/rate/store/{storeId}", h.getStoreShippingRateTables).Methods("GET")
+	router.HandleFunc("/transit", h.getShippingTransitTimes).Methods("GET")
+	router.HandleFunc("/transit/pages", h.getShippingTransitTimesPages).Methods("GET")
+
+	withAuthRouter := router.Methods("GET", "POST", "PATCH", "DELETE").Subrouter()
+	withAuthRouter.HandleFunc("/quote", h.quoteShipping).Methods("POST")
+	withAuthRouter.HandleFunc("/rate/store/me/{storeId}", h.createMyStoreShippingRateTable).
+		Methods("POST")
+	withAuthRouter.HandleFunc("/rate/store/me/{storeId}/{rateTableId}", h.updateMyStoreShippingRateTable).
+		Methods("PATCH")
+	withAuthRouter.HandleFunc("/rate/store/me/{storeId}/{rateTableId}", h.deleteMyStoreShippingRateTable).
+		Methods("DELETE")
+	withAuthRouter.HandleFunc("/zone", h.authHandler.WithActionPermissionAuth(
+		h.createShippingZone,
+		h.db,
+		[]types.Action{types.ActionCanAddShippingZone},
+	)).Methods("POST")
+	withAuthRouter.HandleFunc("/zone/{zoneId}", h.authHandler.WithActionPermissionAuth(
+		h.updateShippingZone,
+		h.db,
+		[]types.Action{types.ActionCanUpdateShippingZone},
+	)).Methods("PATCH")
+	withAuthRouter.HandleFunc("/zone/{zoneId}", h.authHandler.WithActionPermissionAuth(
+		h.deleteShippingZone,
+		h.db,
+		[]types.Action{types.ActionCanDeleteShippingZone},
+	)).Methods("DELETE")
+	withAuthRouter.HandleFunc("/transit", h.authHandler.WithActionPermissionAuth(
+		h.createShippingTransitTime,
+		h.db,
+		[]types.Action{types.ActionCanAddShippingZone},
+	)).Methods("POST")
+	withAuthRouter.HandleFunc("/transit/{transitTimeId}", h.authHandler.WithActionPermissionAuth(
+		h.updateShippingTransitTime,
+		h.db,
+		[]types.Action{types.ActionCanUpdateShippingZone},
+	)).Methods("PATCH")
+	withAuthRouter.HandleFunc("/transit/{transitTimeId}", h.authHandler.WithActionPermissionAuth(
+		h.deleteShippingTransitTime,
+		h.db,
+		[]types.Action{types.ActionCanDeleteShippingZone},
+	)).Methods("DELETE")
+	withAuthRouter.Use(h.authHandler.WithJWTAuth(h.db))
+	withAuthRouter.Use(h.authHandler.WithCSRFToken())
+	withAuthRouter.Use(h.authHandler.WithVerifiedEmail(h.db))
+	withAuthRouter.Use(h.authHandler.WithUnbannedProfile(h.db))
+}
+
+// getShippingZones godoc
+// @Summary      Get shipping zones
+// @Description  Retrieves a paginated list of shipping zones with optional filtering
+// @Tags         shipping
+// @Produce      json
+// @Param        name   query     string  false  "Filter by zone name"
+// @Param        state  query     string  false  "Filter by state"
+// @Param        p      query     int     false  "Page number (default: 1)"
+// @Success      200    {array}   types.ShippingZone
+// @Failure      400    {object}  types.HTTPError
+// @Failure      500    {object}  types.HTTPError
+// @Router       /shipping/zone [get]
+func (h *Handler) getShippingZones(w http.ResponseWriter, r *http.Request) {
+	query := types.ShippingZoneSearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"name":  &query.Name,
+		"state": &query.State,
+		"p":     &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxShippingZonesInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	zones, err := h.db.GetShippingZones(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, zones, nil)
+}
+
+// getShippingZonesPages godoc
+// @Summary      Get shipping zones page count
+// @Description  Returns the total number of pages available for shipping zones based on filters
+// @Tags         shipping
+// @Produce      json
+// @Param        name   query     string  false  "Filter by zone name"
+// @Param        state  query     string  false  "Filter by state"
+// @Success      200    {object}  types.TotalPageCountResponse
+// @Failure      400    {object}  types.HTTPError
+// @Failure      500    {object}  types.HTTPError
+// @Router       /shipping/zone/pages [get]
+func (h *Handler) getShippingZonesPages(w http.ResponseWriter, r *http.Request) {
+	query := types.ShippingZoneSearchQuery{}
+
+	queryMapping := map[string]any{
+		"name":  &query.Name,
+		"state": &query.State,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	count, err := h.db.GetShippingZonesCount(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxShippingZonesInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getShippingZone godoc
+// @Summary      Get a shipping zone
+// @Description  Retrieves details of a specific shipping zone by ID
+// @Tags         shipping
+// @Produce      json
+// @Param        zoneId  path      int  true  "Zone ID"
+// @Success      200     {object}  types.ShippingZone
+// @Failure      400     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Router       /shipping/zone/{zoneId} [get]
+func (h *Handler) getShippingZone(w http.ResponseWriter, r *http.Request) {
+	zoneId, err := utils.ParseIntURLParam("zoneId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	zone, err := h.db.GetShippingZoneById(zoneId)
+	if err != nil {
+		if err == types.ErrShippingZoneNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, zone, nil)
+}
+
+// getStoreShippingRateTables godoc
+// @Summary      Get store shipping rates
+// @Description  Retrieves the shipping rate tables of a store with their tiers
+// @Tags         shipping
+// @Produce      json
+// @Param        storeId  path      int  true  "Store ID"
+// @Success      200      {array}   types.ShippingRateTableWithTiers
+// @Failure      400      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Router       /shipping/rate/store/{storeId} [get]
+func (h *Handler) getStoreShippingRateTables(w http.ResponseWriter, r *http.Request) {
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tables, err := h.db.GetStoreShippingRateTables(storeId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, tables, nil)
+}
+
+// getShippingTransitTimes godoc
+// @Summary      Get shipping transit times
+// @Description  Retrieves a paginated list of the transit times between shipping zones that delivery dates are estimated with
+// @Tags         shipping
+// @Produce      json
+// @Param        origin       query     int  false  "Filter by origin zone ID"
+// @Param        destination  query     int  false  "Filter by destination zone ID"
+// @Param        p            query     int  false  "Page number (default: 1)"
+// @Success      200          {array}   types.ShippingTransitTime
+// @Failure      400          {object}  types.HTTPError
+// @Failure      500          {object}  types.HTTPError
+// @Router       /shipping/transit [get]
+func (h *Handler) getShippingTransitTimes(w http.ResponseWriter, r *http.Request) {
+	query := types.ShippingTransitTimeSearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"origin":      &query.OriginZoneId,
+		"destination": &query.DestinationZoneId,
+		"p":           &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxShippingZonesInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	transitTimes, err := h.db.GetShippingTransitTimes(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, transitTimes, nil)
+}
+
+// getShippingTransitTimesPages godoc
+// @Summary      Get shipping transit times page count
+// @Description  Returns the total number of pages available for shipping transit times based on filters
+// @Tags         shipping
+// @Produce      json
+// @Param        origin       query     int  false  "Filter by origin zone ID"
+// @Param        destination  query     int  false  "Filter by destination zone ID"
+// @Success      200          {object}  types.TotalPageCountResponse
+// @Failure      400          {object}  types.HTTPError
+// @Failure      500          {object}  types.HTTPError
+// @Router       /shipping/transit/pages [get]
+func (h *Handler) getShippingTransitTimesPages(w http.ResponseWriter, r *http.Request) {
+	query := types.ShippingTransitTimeSearchQuery{}
+
+	queryMapping := map[string]any{
+		"origin":      &query.OriginZoneId,
+		"destination": &query.DestinationZoneId,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	count, err := h.db.GetShippingTransitTimesCount(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxShippingZonesInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// quoteShipping godoc
+// @Summary      Quote shipping price
+// @Description  Computes the shipping price and the estimated arrival date of a list of product variants for one of the current user's addresses before placing the order
+// @Tags         shipping
+// @Accept       json
+// @Produce      json
+// @Param        quote  body      types.ShippingQuotePayload  true  "Variants and receiver address"
+// @Success      200    {object}  types.ShippingQuote
+// @Failure      400    {object}  types.HTTPError
+// @Failure      401    {object}  types.HTTPError
+// @Failure      500    {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/quote [post]
+func (h *Handler) quoteShipping(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	var payload types.ShippingQuotePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	quote, err := h.db.QuoteShipping(userId, payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, quote, nil)
+}
+
+// createMyStoreShippingRateTable godoc
+// @Summary      Create a shipping rate table for my store
+// @Description  Creates a shipping rate table with its tiers for a zone or as the default table of a store owned by the current user
+// @Tags         shipping
+// @Accept       json
+// @Produce      json
+// @Param        storeId  path      int                                   true  "Store ID"
+// @Param        table    body      types.CreateShippingRateTablePayload  true  "Rate table details"
+// @Success      201      {object}  types.NewShippingRateTableResponse
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/rate/store/me/{storeId} [post]
+func (h *Handler) createMyStoreShippingRateTable(w http.ResponseWriter, r *http.Request) {
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	var payload types.CreateShippingRateTablePayload
+	err = utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	createdTable, err := h.db.CreateShippingRateTable(types.CreateShippingRateTablePayload{
+		Basis:                 payload.Basis,
+		FreeShippingThreshold: payload.FreeShippingThreshold,
+		ZoneId:                payload.ZoneId,
+		Tiers:                 payload.Tiers,
+		StoreId:               storeId,
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewShippingRateTableResponse{
+		RateTableId: createdTable,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
+// updateMyStoreShippingRateTable godoc
+// @Summary      Update a shipping rate table of my store
+// @Description  Updates a shipping rate table of a store owned by the current user, the tiers are replaced if they are sent
+// @Tags         shipping
+// @Accept       json
+// @Produce      json
+// @Param        storeId      path      int                                   true  "Store ID"
+// @Param        rateTableId  path      int                                   true  "Rate table ID"
+// @Param        table        body      types.UpdateShippingRateTablePayload  true  "Rate table update details"
+// @Success      200          "Shipping rate table updated"
+// @Failure      400          {object}  types.HTTPError
+// @Failure      401          {object}  types.HTTPError
+// @Failure      403          {object}  types.HTTPError
+// @Failure      404          {object}  types.HTTPError
+// @Failure      500          {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/rate/store/me/{storeId}/{rateTableId} [patch]
+func (h *Handler) updateMyStoreShippingRateTable(w http.ResponseWriter, r *http.Request) {
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	rateTableId, err := utils.ParseIntURLParam("rateTableId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var payload types.UpdateShippingRateTablePayload
+	err = utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.UpdateShippingRateTable(rateTableId, storeId, payload)
+	if err != nil {
+		if err == types.ErrShippingRateTableNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteMyStoreShippingRateTable godoc
+// @Summary      Delete a shipping rate table of my store
+// @Description  Deletes a shipping rate table of a store owned by the current user
+// @Tags         shipping
+// @Produce      json
+// @Param        storeId      path      int  true  "Store ID"
+// @Param        rateTableId  path      int  true  "Rate table ID"
+// @Success      200          "Shipping rate table deleted"
+// @Failure      400          {object}  types.HTTPError
+// @Failure      401          {object}  types.HTTPError
+// @Failure      403          {object}  types.HTTPError
+// @Failure      404          {object}  types.HTTPError
+// @Failure      500          {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/rate/store/me/{storeId}/{rateTableId} [delete]
+func (h *Handler) deleteMyStoreShippingRateTable(w http.ResponseWriter, r *http.Request) {
+	storeId, err := utils.ParseIntURLParam("storeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	store, err := h.db.GetStoreById(storeId)
+	if err != nil {
+		if err == types.ErrStoreNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	if store.OwnerId != userId {
+		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessStore)
+		return
+	}
+
+	rateTableId, err := utils.ParseIntURLParam("rateTableId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.DeleteShippingRateTable(rateTableId, storeId)
+	if err != nil {
+		if err == types.ErrShippingRateTableNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// createShippingZone godoc
+// @Summary      Create a shipping zone
+// @Description  Creates a new shipping zone for a state or a city of a state
+// @Tags         shipping
+// @Accept       json
+// @Produce      json
+// @Param        zone  body      types.CreateShippingZonePayload  true  "Zone details"
+// @Success      201   {object}  types.NewShippingZoneResponse
+// @Failure      400   {object}  types.HTTPError
+// @Failure      401   {object}  types.HTTPError
+// @Failure      403   {object}  types.HTTPError
+// @Failure      500   {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/zone [post]
+func (h *Handler) createShippingZone(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateShippingZonePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	createdZone, err := h.db.CreateShippingZone(payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewShippingZoneResponse{
+		ZoneId: createdZone,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
+// updateShippingZone godoc
+// @Summary      Update a shipping zone
+// @Description  Updates an existing shipping zone, an empty city makes the zone cover the whole state
+// @Tags         shipping
+// @Accept       json
+// @Produce      json
+// @Param        zoneId  path      int                              true  "Zone ID"
+// @Param        zone    body      types.UpdateShippingZonePayload  true  "Zone update details"
+// @Success      200     "Shipping zone updated"
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/zone/{zoneId} [patch]
+func (h *Handler) updateShippingZone(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateShippingZonePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	zoneId, err := utils.ParseIntURLParam("zoneId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.UpdateShippingZone(zoneId, payload)
+	if err != nil {
+		if err == types.ErrShippingZoneNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteShippingZone godoc
+// @Summary      Delete a shipping zone
+// @Description  Permanently deletes a shipping zone with the rate tables of the stores and the transit times for it
+// @Tags         shipping
+// @Produce      json
+// @Param        zoneId  path      int  true  "Zone ID"
+// @Success      200     "Shipping zone deleted"
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/zone/{zoneId} [delete]
+func (h *Handler) deleteShippingZone(w http.ResponseWriter, r *http.Request) {
+	zoneId, err := utils.ParseIntURLParam("zoneId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.DeleteShippingZone(zoneId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// createShippingTransitTime godoc
+// @Summary      Create a shipping transit time
+// @Description  Sets the number of days a shipment takes from an origin zone to a destination zone
+// @Tags         shipping
+// @Accept       json
+// @Produce      json
+// @Param        transit  body      types.CreateShippingTransitTimePayload  true  "Transit time details"
+// @Success      201      {object}  types.NewShippingTransitTimeResponse
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/transit [post]
+func (h *Handler) createShippingTransitTime(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateShippingTransitTimePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	createdTransitTime, err := h.db.CreateShippingTransitTime(payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewShippingTransitTimeResponse{
+		TransitTimeId: createdTransitTime,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
+// updateShippingTransitTime godoc
+// @Summary      Update a shipping transit time
+// @Description  Updates the number of days of an existing shipping transit time
+// @Tags         shipping
+// @Accept       json
+// @Produce      json
+// @Param        transitTimeId  path      int                                     true  "Transit time ID"
+// @Param        transit        body      types.UpdateShippingTransitTimePayload  true  "Transit time update details"
+// @Success      200            "Shipping transit time updated"
+// @Failure      400            {object}  types.HTTPError
+// @Failure      401            {object}  types.HTTPError
+// @Failure      403            {object}  types.HTTPError
+// @Failure      404            {object}  types.HTTPError
+// @Failure      500            {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/transit/{transitTimeId} [patch]
+func (h *Handler) updateShippingTransitTime(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateShippingTransitTimePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	transitTimeId, err := utils.ParseIntURLParam("transitTimeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.UpdateShippingTransitTime(transitTimeId, payload)
+	if err != nil {
+		if err == types.ErrShippingTransitTimeNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteShippingTransitTime godoc
+// @Summary      Delete a shipping transit time
+// @Description  Permanently deletes a shipping transit time, the default transit time is used for its zones afterwards
+// @Tags         shipping
+// @Produce      json
+// @Param        transitTimeId  path      int  true  "Transit time ID"
+// @Success      200            "Shipping transit time deleted"
+// @Failure      400            {object}  types.HTTPError
+// @Failure      401            {object}  types.HTTPError
+// @Failure      403            {object}  types.HTTPError
+// @Failure      500            {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /shipping/transit/{transitTimeId} [delete]
+func (h *Handler) deleteShippingTransitTime(w http.ResponseWriter, r *http.Request) {
+	transitTimeId, err := utils.ParseIntURLParam("transitTimeId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.DeleteShippingTransitTime(transitTimeId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
@@ -0,0 +1,282 @@
+package tax
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/SaeedAlian/econest/api/config"
+	db_manager "github.com/SaeedAlian/econest/api/db/manager"
+	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils"
+)
+
+type Handler struct {
+	db          *db_manager.Manager
+	authHandler *auth.AuthHandler
+}
+
+func NewHandler(
+	db *db_manager.Manager,
+	authHandler *auth.AuthHandler,
+) *Handler {
+	return &Handler{
+		db:          db,
+		authHandler: authHandler,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/rule", h.getTaxRules).Methods("GET")
+	router.HandleFunc("/rule/pages", h.getTaxRulesPages).Methods("GET")
+	router.HandleFunc("/rule/{taxRuleId}", h.getTaxRule).Methods("GET")
+
+	withAuthRouter := router.Methods("POST", "PATCH", "DELETE").Subrouter()
+	withAuthRouter.HandleFunc("/rule", h.authHandler.WithActionPermissionAuth(
+		h.createTaxRule,
+		h.db,
+		[]types.Action{types.ActionCanAddTaxRule},
+	)).Methods("POST")
+	withAuthRouter.HandleFunc("/rule/{taxRuleId}", h.authHandler.WithActionPermissionAuth(
+		h.updateTaxRule,
+		h.db,
+		[]types.Action{types.ActionCanUpdateTaxRule},
+	)).Methods("PATCH")
+	withAuthRouter.HandleFunc("/rule/{taxRuleId}", h.authHandler.WithActionPermissionAuth(
+		h.deleteTaxRule,
+		h.db,
+		[]types.Action{types.ActionCanDeleteTaxRule},
+	)).Methods("DELETE")
+	withAuthRouter.Use(h.authHandler.WithJWTAuth(h.db))
+	withAuthRouter.Use(h.authHandler.WithCSRFToken())
+	withAuthRouter.Use(h.authHandler.WithVerifiedEmail(h.db))
+	withAuthRouter.Use(h.authHandler.WithUnbannedProfile(h.db))
+}
+
+// getTaxRules godoc
+// @Summary      Get tax rules
+// @Description  Retrieves a paginated list of tax rules with optional filtering
+// @Tags         tax
+// @Produce      json
+// @Param        zone      query     int  false  "Filter by shipping zone ID"
+// @Param        category  query     int  false  "Filter by product category ID"
+// @Param        p         query     int  false  "Page number (default: 1)"
+// @Success      200       {array}   types.TaxRule
+// @Failure      400       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Router       /tax/rule [get]
+func (h *Handler) getTaxRules(w http.ResponseWriter, r *http.Request) {
+	query := types.TaxRuleSearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"zone":     &query.ZoneId,
+		"category": &query.CategoryId,
+		"p":        &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxTaxRulesInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	rules, err := h.db.GetTaxRules(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, rules, nil)
+}
+
+// getTaxRulesPages godoc
+// @Summary      Get tax rules page count
+// @Description  Returns the total number of pages available for tax rules based on filters
+// @Tags         tax
+// @Produce      json
+// @Param        zone      query     int  false  "Filter by shipping zone ID"
+// @Param        category  query     int  false  "Filter by product category ID"
+// @Success      200       {object}  types.TotalPageCountResponse
+// @Failure      400       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Router       /tax/rule/pages [get]
+func (h *Handler) getTaxRulesPages(w http.ResponseWriter, r *http.Request) {
+	query := types.TaxRuleSearchQuery{}
+
+	queryMapping := map[string]any{
+		"zone":     &query.ZoneId,
+		"category": &query.CategoryId,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	count, err := h.db.GetTaxRulesCount(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxTaxRulesInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getTaxRule godoc
+// @Summary      Get a tax rule
+// @Description  Retrieves details of a specific tax rule by ID
+// @Tags         tax
+// @Produce      json
+// @Param        taxRuleId  path      int  true  "Tax rule ID"
+// @Success      200        {object}  types.TaxRule
+// @Failure      400        {object}  types.HTTPError
+// @Failure      404        {object}  types.HTTPError
+// @Failure      500        {object}  types.HTTPError
+// @Router       /tax/rule/{taxRuleId} [get]
+func (h *Handler) getTaxRule(w http.ResponseWriter, r *http.Request) {
+	taxRuleId, err := utils.ParseIntURLParam("taxRuleId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rule, err := h.db.GetTaxRuleById(taxRuleId)
+	if err != nil {
+		if err == types.ErrTaxRuleNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, rule, nil)
+}
+
+// createTaxRule godoc
+// @Summary      Create a tax rule
+// @Description  Creates a new tax rule for a shipping zone, optionally limited to a product category and its subcategories
+// @Tags         tax
+// @Accept       json
+// @Produce      json
+// @Param        rule  body      types.CreateTaxRulePayload  true  "Tax rule details"
+// @Success      201   {object}  types.NewTaxRuleResponse
+// @Failure      400   {object}  types.HTTPError
+// @Failure      401   {object}  types.HTTPError
+// @Failure      403   {object}  types.HTTPError
+// @Failure      500   {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /tax/rule [post]
+func (h *Handler) createTaxRule(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateTaxRulePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	createdRule, err := h.db.CreateTaxRule(payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewTaxRuleResponse{
+		TaxRuleId: createdRule,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
+// updateTaxRule godoc
+// @Summary      Update a tax rule
+// @Description  Updates an existing tax rule, the orders that are already placed keep their taxes
+// @Tags         tax
+// @Accept       json
+// @Produce      json
+// @Param        taxRuleId  path      int                         true  "Tax rule ID"
+// @Param        rule       body      types.UpdateTaxRulePayload  true  "Tax rule update details"
+// @Success      200        "Tax rule updated"
+// @Failure      400        {object}  types.HTTPError
+// @Failure      401        {object}  types.HTTPError
+// @Failure      403        {object}  types.HTTPError
+// @Failure      404        {object}  types.HTTPError
+// @Failure      500        {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /tax/rule/{taxRuleId} [patch]
+func (h *Handler) updateTaxRule(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateTaxRulePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	taxRuleId, err := utils.ParseIntURLParam("taxRuleId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.UpdateTaxRule(taxRuleId, payload)
+	if err != nil {
+		if err == types.ErrTaxRuleNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteTaxRule godoc
+// @Summary      Delete a tax rule
+// @Description  Permanently deletes a tax rule, the orders that are already placed keep their taxes
+// @Tags         tax
+// @Produce      json
+// @Param        taxRuleId  path      int  true  "Tax rule ID"
+// @Success      200        "Tax rule deleted"
+// @Failure      400        {object}  types.HTTPError
+// @Failure      401        {object}  types.HTTPError
+// @Failure      403        {object}  types.HTTPError
+// @Failure      500        {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /tax/rule/{taxRuleId} [delete]
+func (h *Handler) deleteTaxRule(w http.ResponseWriter, r *http.Request) {
+	taxRuleId, err := utils.ParseIntURLParam("taxRuleId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.DeleteTaxRule(taxRuleId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
@@ -1,27 +1,32 @@
 package wallet
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/SaeedAlian/econest/api/config"
 	db_manager "github.com/SaeedAlian/econest/api/db/manager"
 	"github.com/SaeedAlian/econest/api/services/auth"
+	"github.com/SaeedAlian/econest/api/services/payment"
 	"github.com/SaeedAlian/econest/api/types"
 	"github.com/SaeedAlian/econest/api/utils"
 )
 
 type Handler struct {
-	db          *db_manager.Manager
-	authHandler *auth.AuthHandler
+	db              *db_manager.Manager
+	authHandler     *auth.AuthHandler
+	paymentProvider payment.PaymentProvider
 }
 
 func NewHandler(
 	db *db_manager.Manager,
 	authHandler *auth.AuthHandler,
+	paymentProvider payment.PaymentProvider,
 ) *Handler {
-	return &Handler{db: db, authHandler: authHandler}
+	return &Handler{db: db, authHandler: authHandler, paymentProvider: paymentProvider}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
@@ -30,6 +35,9 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	withAuthRouter.HandleFunc("/me/transaction", h.getMyTransactions).Methods("GET")
 	withAuthRouter.HandleFunc("/me/transaction/pages", h.getMyTransactionsPages).Methods("GET")
 	withAuthRouter.HandleFunc("/me/transaction/{txId}", h.getMyTransaction).Methods("GET")
+	withAuthRouter.HandleFunc("/me/ledger", h.getMyLedgerEntries).Methods("GET")
+	withAuthRouter.HandleFunc("/me/ledger/pages", h.getMyLedgerEntriesPages).Methods("GET")
+	withAuthRouter.HandleFunc("/me/statement", h.getMyStatement).Methods("GET")
 	withAuthRouter.HandleFunc("/user/{userId}", h.authHandler.WithResourcePermissionAuth(
 		h.getUserWallet,
 		h.db,
@@ -53,13 +61,20 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 		[]types.Resource{types.ResourceWalletTransactionsFullAccess},
 	)).
 		Methods("GET")
+	withAuthRouter.HandleFunc("/user/{userId}/statement", h.authHandler.WithResourcePermissionAuth(
+		h.getUserStatement,
+		h.db,
+		[]types.Resource{types.ResourceWalletTransactionsFullAccess},
+	)).
+		Methods("GET")
 	withAuthRouter.Use(h.authHandler.WithJWTAuth(h.db))
 	withAuthRouter.Use(h.authHandler.WithCSRFToken())
 	withAuthRouter.Use(h.authHandler.WithVerifiedEmail(h.db))
 	withAuthRouter.Use(h.authHandler.WithUnbannedProfile(h.db))
 
 	withdrawRouter := withAuthRouter.PathPrefix("/withdraw").Subrouter()
-	withdrawRouter.HandleFunc("", h.createWithdrawTransaction).Methods("POST")
+	withdrawRouter.HandleFunc("", h.authHandler.WithIdempotencyKey(h.createWithdrawTransaction)).
+		Methods("POST")
 	withdrawRouter.HandleFunc("/complete/{txId}", h.authHandler.WithActionPermissionAuth(
 		h.completeWithdrawTransaction,
 		h.db,
@@ -72,29 +87,138 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 		[]types.Action{types.ActionCanCancelWithdrawTransaction},
 	)).
 		Methods("PATCH")
+	withdrawRouter.HandleFunc("/policy", h.getMyWithdrawalPolicy).Methods("GET")
+	withdrawRouter.HandleFunc("/tier", h.authHandler.WithResourcePermissionAuth(
+		h.getWithdrawalTiers,
+		h.db,
+		[]types.Resource{types.ResourceWithdrawalTiersFullAccess},
+	)).
+		Methods("GET")
+	withdrawRouter.HandleFunc("/tier/{tierId}", h.authHandler.WithResourcePermissionAuth(
+		h.getWithdrawalTier,
+		h.db,
+		[]types.Resource{types.ResourceWithdrawalTiersFullAccess},
+	)).
+		Methods("GET")
+	withdrawRouter.HandleFunc("/user/{userId}", h.authHandler.WithResourcePermissionAuth(
+		h.getUserWithdrawalProfile,
+		h.db,
+		[]types.Resource{types.ResourceWithdrawalTiersFullAccess},
+	)).
+		Methods("GET")
+	withdrawRouter.HandleFunc("/user/{userId}/policy", h.authHandler.WithResourcePermissionAuth(
+		h.getUserWithdrawalPolicy,
+		h.db,
+		[]types.Resource{types.ResourceWithdrawalTiersFullAccess},
+	)).
+		Methods("GET")
+	withdrawRouter.HandleFunc("/tier", h.authHandler.WithActionPermissionAuth(
+		h.createWithdrawalTier,
+		h.db,
+		[]types.Action{types.ActionCanAddWithdrawalTier},
+	)).
+		Methods("POST")
+	withdrawRouter.HandleFunc("/tier/{tierId}", h.authHandler.WithActionPermissionAuth(
+		h.updateWithdrawalTier,
+		h.db,
+		[]types.Action{types.ActionCanUpdateWithdrawalTier},
+	)).
+		Methods("PATCH")
+	withdrawRouter.HandleFunc("/tier/{tierId}", h.authHandler.WithActionPermissionAuth(
+		h.deleteWithdrawalTier,
+		h.db,
+		[]types.Action{types.ActionCanDeleteWithdrawalTier},
+	)).
+		Methods("DELETE")
+	withdrawRouter.HandleFunc("/role/{roleId}/tier/{tierId}", h.authHandler.WithActionPermissionAuth(
+		h.setRoleWithdrawalTier,
+		h.db,
+		[]types.Action{types.ActionCanUpdateWithdrawalTier},
+	)).
+		Methods("PATCH")
+	withdrawRouter.HandleFunc("/role/{roleId}/tier", h.authHandler.WithActionPermissionAuth(
+		h.deleteRoleWithdrawalTier,
+		h.db,
+		[]types.Action{types.ActionCanUpdateWithdrawalTier},
+	)).
+		Methods("DELETE")
+	withdrawRouter.HandleFunc("/user/{userId}", h.authHandler.WithActionPermissionAuth(
+		h.updateUserWithdrawalProfile,
+		h.db,
+		[]types.Action{types.ActionCanUpdateUserWithdrawalProfile},
+	)).
+		Methods("PATCH")
+	withdrawRouter.HandleFunc("/user/{userId}/tier", h.authHandler.WithActionPermissionAuth(
+		h.deleteUserWithdrawalTier,
+		h.db,
+		[]types.Action{types.ActionCanUpdateUserWithdrawalProfile},
+	)).
+		Methods("DELETE")
 
 	depositRouter := withAuthRouter.PathPrefix("/deposit").Subrouter()
-	depositRouter.HandleFunc("", h.createDepositTransaction).Methods("POST")
-	depositRouter.HandleFunc("/complete/{txId}", h.completeDepositTransaction).Methods("PATCH")
+	depositRouter.HandleFunc("", h.authHandler.WithIdempotencyKey(h.createDepositTransaction)).
+		Methods("POST")
 	depositRouter.HandleFunc("/cancel/{txId}", h.cancelDepositTransaction).Methods("PATCH")
+
+	withAuthRouter.HandleFunc("/transfer", h.authHandler.WithIdempotencyKey(h.createTransfer)).
+		Methods("POST")
+
+	driftRouter := withAuthRouter.PathPrefix("/drift").Subrouter()
+	driftRouter.HandleFunc("", h.authHandler.WithResourcePermissionAuth(
+		h.getWalletDrifts,
+		h.db,
+		[]types.Resource{types.ResourceWalletDriftsFullAccess},
+	)).
+		Methods("GET")
+	driftRouter.HandleFunc("/pages", h.authHandler.WithResourcePermissionAuth(
+		h.getWalletDriftsPages,
+		h.db,
+		[]types.Resource{types.ResourceWalletDriftsFullAccess},
+	)).
+		Methods("GET")
+	driftRouter.HandleFunc("/{driftId}", h.authHandler.WithResourcePermissionAuth(
+		h.getWalletDrift,
+		h.db,
+		[]types.Resource{types.ResourceWalletDriftsFullAccess},
+	)).
+		Methods("GET")
+	driftRouter.HandleFunc("/{driftId}/resolve", h.authHandler.WithActionPermissionAuth(
+		h.resolveWalletDrift,
+		h.db,
+		[]types.Action{types.ActionCanResolveWalletDrift},
+	)).
+		Methods("PATCH")
 }
 
 // createDepositTransaction godoc
 // @Summary      Create deposit transaction
-// @Description  Creates a new deposit transaction for the current user's wallet
+// @Description  Creates a new pending deposit transaction for the current user's wallet and starts its payment at the payment provider. The payer is sent to the returned checkout url, the deposit is finalised by the payment provider webhook.
 // @Tags         wallet
 // @Accept       json
 // @Produce      json
 // @Param        transaction  body      types.CreateWalletTransactionPayload  true  "Deposit transaction details"
-// @Success      201          {object}  types.NewWalletTransactionResponse
+// @Param        Idempotency-Key  header  string  false  "Key to safely retry the request"
+// @Success      201          {object}  types.NewPaymentResponse
 // @Failure      400          {object}  types.HTTPError
 // @Failure      401          {object}  types.HTTPError
 // @Failure      403          {object}  types.HTTPError
 // @Failure      404          {object}  types.HTTPError
+// @Failure      409          {object}  types.HTTPError
+// @Failure      422          {object}  types.HTTPError
 // @Failure      500          {object}  types.HTTPError
+// @Failure      503          {object}  types.HTTPError
 // @Security     ApiKeyAuth
 // @Router       /wallet/deposit [post]
 func (h *Handler) createDepositTransaction(w http.ResponseWriter, r *http.Request) {
+	if h.paymentProvider == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusServiceUnavailable,
+			types.ErrPaymentProviderNotConfigured,
+		)
+		return
+	}
+
 	var payload types.CreateWalletTransactionPayload
 	err := utils.ParseRequestPayload(r, &payload)
 	if err != nil {
@@ -126,18 +250,37 @@ func (h *Handler) createDepositTransaction(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	createdTx, err := h.db.CreateWalletTransaction(types.CreateWalletTransactionPayload{
-		Amount:   payload.Amount,
-		TxType:   types.TransactionTypeDeposit,
-		WalletId: wallet.Id,
+	providerPayment, err := h.paymentProvider.CreateIntent(types.CreateProviderPaymentPayload{
+		Amount:      payload.Amount,
+		Description: fmt.Sprintf("Deposit to wallet #%d", wallet.Id),
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	intentId, err := h.db.CreatePaymentIntent(types.CreatePaymentIntentPayload{
+		Provider:  h.paymentProvider.Name(),
+		Reference: providerPayment.Reference,
+		Amount:    payload.Amount,
+		WalletId:  wallet.Id,
 	})
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
 		return
 	}
 
-	res := types.NewWalletTransactionResponse{
-		TxId: createdTx,
+	intent, err := h.db.GetPaymentIntentById(intentId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := types.NewPaymentResponse{
+		TxId:            intent.WalletTransactionId,
+		PaymentIntentId: intent.Id,
+		Reference:       intent.Reference,
+		CheckoutUrl:     providerPayment.CheckoutUrl,
 	}
 
 	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
@@ -145,16 +288,19 @@ func (h *Handler) createDepositTransaction(w http.ResponseWriter, r *http.Reques
 
 // createWithdrawTransaction godoc
 // @Summary      Create withdraw transaction
-// @Description  Creates a new withdraw transaction request for the current user's wallet
+// @Description  Creates a new withdraw transaction request for the current user's wallet, which waits for an admin to approve it. The withdrawal must be in the limits of the withdrawal tier of the user, the limits are higher for the users whose identity is verified, and withdrawals are blocked for the cooldown of the tier after a password or email change.
 // @Tags         wallet
 // @Accept       json
 // @Produce      json
 // @Param        transaction  body      types.CreateWalletTransactionPayload  true  "Withdraw transaction details"
+// @Param        Idempotency-Key  header  string  false  "Key to safely retry the request"
 // @Success      201          {object}  types.NewWalletTransactionResponse
 // @Failure      400          {object}  types.HTTPError
 // @Failure      401          {object}  types.HTTPError
 // @Failure      403          {object}  types.HTTPError
 // @Failure      404          {object}  types.HTTPError
+// @Failure      409          {object}  types.HTTPError
+// @Failure      422          {object}  types.HTTPError
 // @Failure      500          {object}  types.HTTPError
 // @Security     ApiKeyAuth
 // @Router       /wallet/withdraw [post]
@@ -190,11 +336,7 @@ func (h *Handler) createWithdrawTransaction(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	createdTx, err := h.db.CreateWalletTransaction(types.CreateWalletTransactionPayload{
-		Amount:   payload.Amount,
-		TxType:   types.TransactionTypeWithdraw,
-		WalletId: wallet.Id,
-	})
+	createdTx, err := h.db.CreateWithdrawTransaction(wallet.Id, payload.Amount)
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
 		return
@@ -207,9 +349,99 @@ func (h *Handler) createWithdrawTransaction(w http.ResponseWriter, r *http.Reque
 	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
 }
 
+// createTransfer godoc
+// @Summary      Send money to another user
+// @Description  Moves money from the current user's wallet to the wallet of another user right away. Both users see the transfer in their transactions with the other user as the counterparty. A single transfer and the transfers of the last 24 hours of a user are limited.
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Param        transfer  body      types.CreateWalletTransferPayload  true  "Transfer details"
+// @Param        Idempotency-Key  header  string  false  "Key to safely retry the request"
+// @Success      201       {object}  types.NewWalletTransferResponse
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      404       {object}  types.HTTPError
+// @Failure      409       {object}  types.HTTPError
+// @Failure      422       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/transfer [post]
+func (h *Handler) createTransfer(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateWalletTransferPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	userId := ctx.Value("userId")
+
+	if userId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	wallet, err := h.db.GetUserWallet(userId.(int))
+	if err != nil {
+		if err == types.ErrWalletNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	receiver, err := h.db.GetUserByUsername(payload.ReceiverUsername)
+	if err != nil {
+		if err == types.ErrUserNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	receiverWallet, err := h.db.GetUserWallet(receiver.Id)
+	if err != nil {
+		if err == types.ErrWalletNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	transferId, err := h.db.CreateWalletTransfer(types.WalletTransferInsertData{
+		SenderWalletId:   wallet.Id,
+		ReceiverWalletId: receiverWallet.Id,
+		Amount:           payload.Amount,
+		Memo:             payload.Memo,
+	})
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewWalletTransferResponse{
+		TransferId: transferId,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
 // getMyWallet godoc
 // @Summary      Get current user's wallet
-// @Description  Retrieves the wallet information of the currently authenticated user
+// @Description  Retrieves the wallet information of the currently authenticated user. The balance is the money that is available to spend, the pending balance is the earnings of the stores of the user that are held in escrow until their shipments are delivered.
 // @Tags         wallet
 // @Produce      json
 // @Success      200  {object}  types.Wallet
@@ -258,7 +490,9 @@ func (h *Handler) getMyWallet(w http.ResponseWriter, r *http.Request) {
 // @Param        aftd  query     string  false  "Filter transactions after this date (YYYY-MM-DD)"
 // @Param        befd  query     string  false  "Filter transactions before this date (YYYY-MM-DD)"
 // @Param        p     query     int     false  "Page number (default: 1)"
+// @Param        cursor query     string  false  "Cursor of the next page from the X-Next-Cursor header, used instead of the page number"
 // @Success      200   {array}   types.WalletTransaction
+// @Header       200   {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure      400   {object}  types.HTTPError
 // @Failure      401   {object}  types.HTTPError
 // @Failure      500   {object}  types.HTTPError
@@ -282,13 +516,15 @@ func (h *Handler) getMyTransactions(w http.ResponseWriter, r *http.Request) {
 
 	query := types.WalletTransactionSearchQuery{}
 	var page *int = nil
+	var cursor *string = nil
 
 	queryMapping := map[string]any{
-		"typ":  &query.TxType,
-		"stat": &query.Status,
-		"aftd": &query.AfterDate,
-		"befd": &query.BeforeDate,
-		"p":    &page,
+		"typ":    &query.TxType,
+		"stat":   &query.Status,
+		"aftd":   &query.AfterDate,
+		"befd":   &query.BeforeDate,
+		"cursor": &cursor,
+		"p":      &page,
 	}
 
 	queryValues := r.URL.Query()
@@ -301,18 +537,25 @@ func (h *Handler) getMyTransactions(w http.ResponseWriter, r *http.Request) {
 
 	query.Limit = utils.Ptr(int(config.Env.MaxWalletTransactionsInPage))
 
-	if page != nil {
+	if cursor != nil {
+		query.Cursor, err = types.DecodeCursor(*cursor, types.CursorSortNewest)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else if page != nil {
 		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
 	} else {
 		query.Offset = utils.Ptr(0)
 	}
 
-	txs, err := h.db.GetWalletTransactions(types.WalletTransactionSearchQuery{
+	txsPage, err := h.db.GetWalletTransactionsPage(types.WalletTransactionSearchQuery{
 		Status:     query.Status,
 		TxType:     query.TxType,
 		BeforeDate: query.BeforeDate,
 		AfterDate:  query.AfterDate,
 		UserId:     &userId,
+		Cursor:     query.Cursor,
 		Limit:      query.Limit,
 		Offset:     query.Offset,
 	})
@@ -321,7 +564,12 @@ func (h *Handler) getMyTransactions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, txs, nil)
+	utils.WriteJSONInResponse(
+		w,
+		http.StatusOK,
+		txsPage.Items,
+		utils.NextCursorHeaders(txsPage.NextCursor),
+	)
 }
 
 // getMyTransactionsPages godoc
@@ -457,27 +705,64 @@ func (h *Handler) getMyTransaction(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSONInResponse(w, http.StatusOK, tx, nil)
 }
 
-// getUserWallet godoc
-// @Summary      Get user's wallet (admin)
-// @Description  Retrieves wallet information of a specific user (requires wallet transactions full access permission)
+// getMyLedgerEntries godoc
+// @Summary      Get current user's ledger entries
+// @Description  Retrieves a paginated list of the ledger entries that moved money in or out of the current user's wallet, the latest first
 // @Tags         wallet
 // @Produce      json
-// @Param        userId  path      int  true  "User ID"
-// @Success      200     {object}  types.Wallet
-// @Failure      400     {object}  types.HTTPError
-// @Failure      401     {object}  types.HTTPError
-// @Failure      403     {object}  types.HTTPError
-// @Failure      404     {object}  types.HTTPError
-// @Failure      500     {object}  types.HTTPError
+// @Param        kind  query     string  false  "Filter by entry kind"
+// @Param        aftd  query     string  false  "Filter entries after this date (YYYY-MM-DD)"
+// @Param        befd  query     string  false  "Filter entries before this date (YYYY-MM-DD)"
+// @Param        p     query     int     false  "Page number (default: 1)"
+// @Success      200   {array}   types.WalletLedgerEntry
+// @Failure      400   {object}  types.HTTPError
+// @Failure      401   {object}  types.HTTPError
+// @Failure      404   {object}  types.HTTPError
+// @Failure      500   {object}  types.HTTPError
 // @Security     ApiKeyAuth
-// @Router       /wallet/user/{userId} [get]
-func (h *Handler) getUserWallet(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.ParseIntURLParam("userId", mux.Vars(r))
+// @Router       /wallet/me/ledger [get]
+func (h *Handler) getMyLedgerEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	query := types.WalletLedgerEntrySearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"kind": &query.Kind,
+		"aftd": &query.AfterDate,
+		"befd": &query.BeforeDate,
+		"p":    &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
 		return
 	}
 
+	query.Limit = utils.Ptr(int(config.Env.MaxWalletLedgerEntriesInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
 	wallet, err := h.db.GetUserWallet(userId)
 	if err != nil {
 		if err == types.ErrWalletNotFound {
@@ -489,67 +774,190 @@ func (h *Handler) getUserWallet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, wallet, nil)
+	entries, err := h.db.GetWalletLedgerEntries(wallet.Id, query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, entries, nil)
 }
 
-// getUserTransactions godoc
-// @Summary      Get user's transactions (admin)
-// @Description  Retrieves a paginated list of transactions for a specific user's wallet (requires wallet transactions full access permission)
+// getMyLedgerEntriesPages godoc
+// @Summary      Get current user's ledger entry page count
+// @Description  Returns the total number of pages available for the ledger entries of the current user's wallet based on filters
 // @Tags         wallet
 // @Produce      json
-// @Param        userId  path      int     true  "User ID"
-// @Param        typ     query     string  false "Filter by transaction type"
-// @Param        stat    query     string  false "Filter by transaction status"
-// @Param        aftd    query     string  false "Filter transactions after this date (YYYY-MM-DD)"
-// @Param        befd    query     string  false "Filter transactions before this date (YYYY-MM-DD)"
-// @Param        p       query     int     false "Page number (default: 1)"
-// @Success      200     {array}   types.WalletTransaction
-// @Failure      400     {object}  types.HTTPError
-// @Failure      401     {object}  types.HTTPError
-// @Failure      403     {object}  types.HTTPError
-// @Failure      500     {object}  types.HTTPError
+// @Param        kind  query     string  false  "Filter by entry kind"
+// @Param        aftd  query     string  false  "Filter entries after this date (YYYY-MM-DD)"
+// @Param        befd  query     string  false  "Filter entries before this date (YYYY-MM-DD)"
+// @Success      200   {object}  types.TotalPageCountResponse
+// @Failure      400   {object}  types.HTTPError
+// @Failure      401   {object}  types.HTTPError
+// @Failure      404   {object}  types.HTTPError
+// @Failure      500   {object}  types.HTTPError
 // @Security     ApiKeyAuth
-// @Router       /wallet/user/{userId}/transaction [get]
-func (h *Handler) getUserTransactions(w http.ResponseWriter, r *http.Request) {
-	userId, err := utils.ParseIntURLParam("userId", mux.Vars(r))
-	if err != nil {
-		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+// @Router       /wallet/me/ledger/pages [get]
+func (h *Handler) getMyLedgerEntriesPages(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
 		return
 	}
 
-	query := types.WalletTransactionSearchQuery{}
-	var page *int = nil
+	userId := cUserId.(int)
+
+	query := types.WalletLedgerEntrySearchQuery{}
 
 	queryMapping := map[string]any{
-		"typ":  &query.TxType,
-		"stat": &query.Status,
+		"kind": &query.Kind,
 		"aftd": &query.AfterDate,
 		"befd": &query.BeforeDate,
-		"p":    &page,
 	}
 
 	queryValues := r.URL.Query()
 
-	err = utils.ParseURLQuery(queryMapping, queryValues)
+	err := utils.ParseURLQuery(queryMapping, queryValues)
 	if err != nil {
 		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
 		return
 	}
 
-	query.Limit = utils.Ptr(int(config.Env.MaxWalletTransactionsInPage))
+	wallet, err := h.db.GetUserWallet(userId)
+	if err != nil {
+		if err == types.ErrWalletNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
 
-	if page != nil {
-		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
-	} else {
-		query.Offset = utils.Ptr(0)
+		return
 	}
 
-	txs, err := h.db.GetWalletTransactions(types.WalletTransactionSearchQuery{
-		Status:     query.Status,
-		TxType:     query.TxType,
-		BeforeDate: query.BeforeDate,
+	count, err := h.db.GetWalletLedgerEntriesCount(wallet.Id, query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxWalletLedgerEntriesInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getUserWallet godoc
+// @Summary      Get user's wallet (admin)
+// @Description  Retrieves wallet information of a specific user (requires wallet transactions full access permission)
+// @Tags         wallet
+// @Produce      json
+// @Param        userId  path      int  true  "User ID"
+// @Success      200     {object}  types.Wallet
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/user/{userId} [get]
+func (h *Handler) getUserWallet(w http.ResponseWriter, r *http.Request) {
+	userId, err := utils.ParseIntURLParam("userId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	wallet, err := h.db.GetUserWallet(userId)
+	if err != nil {
+		if err == types.ErrWalletNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, wallet, nil)
+}
+
+// getUserTransactions godoc
+// @Summary      Get user's transactions (admin)
+// @Description  Retrieves a paginated list of transactions for a specific user's wallet (requires wallet transactions full access permission)
+// @Tags         wallet
+// @Produce      json
+// @Param        userId  path      int     true  "User ID"
+// @Param        typ     query     string  false "Filter by transaction type"
+// @Param        stat    query     string  false "Filter by transaction status"
+// @Param        aftd    query     string  false "Filter transactions after this date (YYYY-MM-DD)"
+// @Param        befd    query     string  false "Filter transactions before this date (YYYY-MM-DD)"
+// @Param        p       query     int     false "Page number (default: 1)"
+// @Param        cursor  query     string  false "Cursor of the next page from the X-Next-Cursor header, used instead of the page number"
+// @Success      200     {array}   types.WalletTransaction
+// @Header       200     {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/user/{userId}/transaction [get]
+func (h *Handler) getUserTransactions(w http.ResponseWriter, r *http.Request) {
+	userId, err := utils.ParseIntURLParam("userId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query := types.WalletTransactionSearchQuery{}
+	var page *int = nil
+	var cursor *string = nil
+
+	queryMapping := map[string]any{
+		"typ":    &query.TxType,
+		"stat":   &query.Status,
+		"aftd":   &query.AfterDate,
+		"befd":   &query.BeforeDate,
+		"cursor": &cursor,
+		"p":      &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err = utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxWalletTransactionsInPage))
+
+	if cursor != nil {
+		query.Cursor, err = types.DecodeCursor(*cursor, types.CursorSortNewest)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	txsPage, err := h.db.GetWalletTransactionsPage(types.WalletTransactionSearchQuery{
+		Status:     query.Status,
+		TxType:     query.TxType,
+		BeforeDate: query.BeforeDate,
 		AfterDate:  query.AfterDate,
 		UserId:     &userId,
+		Cursor:     query.Cursor,
 		Limit:      query.Limit,
 		Offset:     query.Offset,
 	})
@@ -558,7 +966,12 @@ func (h *Handler) getUserTransactions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSONInResponse(w, http.StatusOK, txs, nil)
+	utils.WriteJSONInResponse(
+		w,
+		http.StatusOK,
+		txsPage.Items,
+		utils.NextCursorHeaders(txsPage.NextCursor),
+	)
 }
 
 // getUserTransactionsPages godoc
@@ -679,89 +1092,6 @@ func (h *Handler) getUserTransaction(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSONInResponse(w, http.StatusOK, tx, nil)
 }
 
-// completeDepositTransaction godoc
-// @Summary      Complete a deposit transaction
-// @Description  Marks a deposit transaction as completed (successful) after payment validation
-// @Tags         wallet
-// @Produce      json
-// @Param        txId  path      int  true  "Transaction ID"
-// @Success      200  "Deposit transaction completed"
-// @Failure      400  {object}  types.HTTPError
-// @Failure      401  {object}  types.HTTPError
-// @Failure      403  {object}  types.HTTPError
-// @Failure      404  {object}  types.HTTPError
-// @Failure      500  {object}  types.HTTPError
-// @Security     ApiKeyAuth
-// @Router       /wallet/deposit/complete/{txId} [patch]
-func (h *Handler) completeDepositTransaction(w http.ResponseWriter, r *http.Request) {
-	// TODO: handle payment validation
-
-	txId, err := utils.ParseIntURLParam("txId", mux.Vars(r))
-	if err != nil {
-		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
-		return
-	}
-
-	ctx := r.Context()
-
-	cUserId := ctx.Value("userId")
-
-	if cUserId == nil {
-		utils.WriteErrorInResponse(
-			w,
-			http.StatusUnauthorized,
-			types.ErrAuthenticationCredentialsNotFound,
-		)
-		return
-	}
-
-	userId := cUserId.(int)
-
-	wallet, err := h.db.GetUserWallet(userId)
-	if err != nil {
-		if err == types.ErrWalletNotFound {
-			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
-		} else {
-			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
-		}
-
-		return
-	}
-
-	tx, err := h.db.GetWalletTransactionById(txId)
-	if err != nil {
-		if err == types.ErrWalletTransactionNotFound {
-			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
-		} else {
-			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
-		}
-
-		return
-	}
-
-	if tx.WalletId != wallet.Id || wallet.UserId != userId {
-		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrCannotAccessWalletTransaction)
-		return
-	}
-
-	if tx.TxType != types.TransactionTypeDeposit {
-		utils.WriteErrorInResponse(w, http.StatusForbidden, types.ErrInvalidTransactionTypeEnum)
-		return
-	}
-
-	err = h.db.UpdateWalletTransaction(tx.WalletId, txId,
-		types.UpdateWalletTransactionPayload{
-			Status: utils.Ptr(types.TransactionStatusSuccessful),
-		},
-	)
-	if err != nil {
-		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
-		return
-	}
-
-	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
-}
-
 // completeWithdrawTransaction godoc
 // @Summary      Complete a withdraw transaction
 // @Description  Marks a withdraw transaction request as completed (successful) after processing
@@ -942,3 +1272,823 @@ func (h *Handler) cancelWithdrawTransaction(w http.ResponseWriter, r *http.Reque
 
 	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
 }
+
+// getMyWithdrawalPolicy godoc
+// @Summary      Get my withdrawal limits
+// @Description  Returns the withdrawal limits of the current user with the withdrawals that already count against them and the end of the cooldown after a password or email change
+// @Tags         wallet
+// @Produce      json
+// @Success      200  {object}  types.WithdrawalPolicy
+// @Failure      401  {object}  types.HTTPError
+// @Failure      403  {object}  types.HTTPError
+// @Failure      404  {object}  types.HTTPError
+// @Failure      500  {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/policy [get]
+func (h *Handler) getMyWithdrawalPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userId := ctx.Value("userId")
+
+	if userId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	policy, err := h.db.GetUserWithdrawalPolicy(userId.(int))
+	if err != nil {
+		if err == types.ErrUserNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, policy, nil)
+}
+
+// getWithdrawalTiers godoc
+// @Summary      Get withdrawal tiers
+// @Description  Retrieves all the withdrawal tiers. Requires full withdrawal tiers access.
+// @Tags         wallet
+// @Produce      json
+// @Success      200  {array}   types.WithdrawalTier
+// @Failure      401  {object}  types.HTTPError
+// @Failure      403  {object}  types.HTTPError
+// @Failure      500  {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/tier [get]
+func (h *Handler) getWithdrawalTiers(w http.ResponseWriter, r *http.Request) {
+	tiers, err := h.db.GetWithdrawalTiers()
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, tiers, nil)
+}
+
+// getWithdrawalTier godoc
+// @Summary      Get a withdrawal tier
+// @Description  Retrieves details of a specific withdrawal tier by ID. Requires full withdrawal tiers access.
+// @Tags         wallet
+// @Produce      json
+// @Param        tierId  path      int  true  "Withdrawal tier ID"
+// @Success      200     {object}  types.WithdrawalTier
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/tier/{tierId} [get]
+func (h *Handler) getWithdrawalTier(w http.ResponseWriter, r *http.Request) {
+	tierId, err := utils.ParseIntURLParam("tierId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tier, err := h.db.GetWithdrawalTierById(tierId)
+	if err != nil {
+		if err == types.ErrWithdrawalTierNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, tier, nil)
+}
+
+// getUserWithdrawalProfile godoc
+// @Summary      Get the withdrawal profile of a user
+// @Description  Retrieves the KYC verification, the tier and the last password or email change of a user. Requires full withdrawal tiers access.
+// @Tags         wallet
+// @Produce      json
+// @Param        userId  path      int  true  "User ID"
+// @Success      200     {object}  types.UserWithdrawalProfile
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/user/{userId} [get]
+func (h *Handler) getUserWithdrawalProfile(w http.ResponseWriter, r *http.Request) {
+	userId, err := utils.ParseIntURLParam("userId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	profile, err := h.db.GetUserWithdrawalProfile(userId)
+	if err != nil {
+		if err == types.ErrUserNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, profile, nil)
+}
+
+// getUserWithdrawalPolicy godoc
+// @Summary      Get the withdrawal limits of a user
+// @Description  Returns the withdrawal limits of a user with the withdrawals that already count against them. Requires full withdrawal tiers access.
+// @Tags         wallet
+// @Produce      json
+// @Param        userId  path      int  true  "User ID"
+// @Success      200     {object}  types.WithdrawalPolicy
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/user/{userId}/policy [get]
+func (h *Handler) getUserWithdrawalPolicy(w http.ResponseWriter, r *http.Request) {
+	userId, err := utils.ParseIntURLParam("userId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	policy, err := h.db.GetUserWithdrawalPolicy(userId)
+	if err != nil {
+		if err == types.ErrUserNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, policy, nil)
+}
+
+// createWithdrawalTier godoc
+// @Summary      Create a withdrawal tier
+// @Description  Creates a new withdrawal tier, a limit of zero means that there is no limit. A tier that is created as the default replaces the current default tier.
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Param        tier  body      types.CreateWithdrawalTierPayload  true  "Withdrawal tier details"
+// @Success      201   {object}  types.NewWithdrawalTierResponse
+// @Failure      400   {object}  types.HTTPError
+// @Failure      401   {object}  types.HTTPError
+// @Failure      403   {object}  types.HTTPError
+// @Failure      500   {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/tier [post]
+func (h *Handler) createWithdrawalTier(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateWithdrawalTierPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	createdTier, err := h.db.CreateWithdrawalTier(payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := types.NewWithdrawalTierResponse{
+		WithdrawalTierId: createdTier,
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusCreated, res, nil)
+}
+
+// updateWithdrawalTier godoc
+// @Summary      Update a withdrawal tier
+// @Description  Updates the limits of an existing withdrawal tier, the withdrawals that are already requested are not affected
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Param        tierId  path      int                                true  "Withdrawal tier ID"
+// @Param        tier    body      types.UpdateWithdrawalTierPayload  true  "Withdrawal tier update details"
+// @Success      200     "Withdrawal tier updated"
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/tier/{tierId} [patch]
+func (h *Handler) updateWithdrawalTier(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateWithdrawalTierPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tierId, err := utils.ParseIntURLParam("tierId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.UpdateWithdrawalTier(tierId, payload)
+	if err != nil {
+		if err == types.ErrWithdrawalTierNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteWithdrawalTier godoc
+// @Summary      Delete a withdrawal tier
+// @Description  Deletes a withdrawal tier, its roles and users fall back to the default tier. The default tier cannot be deleted.
+// @Tags         wallet
+// @Produce      json
+// @Param        tierId  path      int  true  "Withdrawal tier ID"
+// @Success      200     "Withdrawal tier deleted"
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/tier/{tierId} [delete]
+func (h *Handler) deleteWithdrawalTier(w http.ResponseWriter, r *http.Request) {
+	tierId, err := utils.ParseIntURLParam("tierId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.DeleteWithdrawalTier(tierId)
+	if err != nil {
+		if err == types.ErrWithdrawalTierNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// setRoleWithdrawalTier godoc
+// @Summary      Set the withdrawal tier of a role
+// @Description  Sets the withdrawal tier of the users of a role that have no tier of their own
+// @Tags         wallet
+// @Produce      json
+// @Param        roleId  path      int  true  "Role ID"
+// @Param        tierId  path      int  true  "Withdrawal tier ID"
+// @Success      200     "Withdrawal tier of the role set"
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/role/{roleId}/tier/{tierId} [patch]
+func (h *Handler) setRoleWithdrawalTier(w http.ResponseWriter, r *http.Request) {
+	roleId, err := utils.ParseIntURLParam("roleId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tierId, err := utils.ParseIntURLParam("tierId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.SetRoleWithdrawalTier(roleId, tierId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteRoleWithdrawalTier godoc
+// @Summary      Remove the withdrawal tier of a role
+// @Description  Removes the withdrawal tier of a role, its users fall back to the default tier
+// @Tags         wallet
+// @Produce      json
+// @Param        roleId  path      int  true  "Role ID"
+// @Success      200     "Withdrawal tier of the role removed"
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/role/{roleId}/tier [delete]
+func (h *Handler) deleteRoleWithdrawalTier(w http.ResponseWriter, r *http.Request) {
+	roleId, err := utils.ParseIntURLParam("roleId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.DeleteRoleWithdrawalTier(roleId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// updateUserWithdrawalProfile godoc
+// @Summary      Update the withdrawal profile of a user
+// @Description  Sets the KYC verification of a user, which gives the user the KYC limits of its tier, or sets a tier for the user alone
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Param        userId   path      int                                       true  "User ID"
+// @Param        profile  body      types.UpdateUserWithdrawalProfilePayload  true  "Withdrawal profile update details"
+// @Success      200      "Withdrawal profile updated"
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/user/{userId} [patch]
+func (h *Handler) updateUserWithdrawalProfile(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateUserWithdrawalProfilePayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userId, err := utils.ParseIntURLParam("userId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.UpdateUserWithdrawalProfile(userId, payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// deleteUserWithdrawalTier godoc
+// @Summary      Remove the withdrawal tier of a user
+// @Description  Removes the tier that is set for a user alone, the user falls back to the tier of its role or the default tier
+// @Tags         wallet
+// @Produce      json
+// @Param        userId  path      int  true  "User ID"
+// @Success      200     "Withdrawal tier of the user removed"
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/withdraw/user/{userId}/tier [delete]
+func (h *Handler) deleteUserWithdrawalTier(w http.ResponseWriter, r *http.Request) {
+	userId, err := utils.ParseIntURLParam("userId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.db.DeleteUserWithdrawalTier(userId)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+// getMyStatement godoc
+// @Summary      Get current user's wallet statement
+// @Description  Retrieves the statement of the current user's wallet for a period, with the opening balance, each ledger entry with the balance after it and the closing balance. The statement can be downloaded as CSV or PDF.
+// @Tags         wallet
+// @Produce      json
+// @Produce      text/csv
+// @Produce      application/pdf
+// @Param        from    query     string  false  "Start of the period, inclusive (RFC3339, default: 30 days before the end)"
+// @Param        to      query     string  false  "End of the period, exclusive (RFC3339, default: now)"
+// @Param        format  query     string  false  "Format of the statement, json, csv or pdf (default: json)"
+// @Success      200     {object}  types.WalletStatement
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/me/statement [get]
+func (h *Handler) getMyStatement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	query, err := parseWalletStatementQuery(r)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	wallet, err := h.db.GetUserWallet(userId)
+	if err != nil {
+		if err == types.ErrWalletNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	statement, err := h.db.GetWalletStatement(wallet.Id, query.from, query.to)
+	if err != nil {
+		if err == types.ErrLedgerAccountNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	writeWalletStatement(w, statement, query.format)
+}
+
+// getUserStatement godoc
+// @Summary      Get user's wallet statement (admin)
+// @Description  Retrieves the statement of a specific user's wallet for a period, with the opening balance, each ledger entry with the balance after it and the closing balance. The statement can be downloaded as CSV or PDF (requires wallet transactions full access permission).
+// @Tags         wallet
+// @Produce      json
+// @Produce      text/csv
+// @Produce      application/pdf
+// @Param        userId  path      int     true   "User ID"
+// @Param        from    query     string  false  "Start of the period, inclusive (RFC3339, default: 30 days before the end)"
+// @Param        to      query     string  false  "End of the period, exclusive (RFC3339, default: now)"
+// @Param        format  query     string  false  "Format of the statement, json, csv or pdf (default: json)"
+// @Success      200     {object}  types.WalletStatement
+// @Failure      400     {object}  types.HTTPError
+// @Failure      401     {object}  types.HTTPError
+// @Failure      403     {object}  types.HTTPError
+// @Failure      404     {object}  types.HTTPError
+// @Failure      500     {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/user/{userId}/statement [get]
+func (h *Handler) getUserStatement(w http.ResponseWriter, r *http.Request) {
+	userId, err := utils.ParseIntURLParam("userId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query, err := parseWalletStatementQuery(r)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	wallet, err := h.db.GetUserWallet(userId)
+	if err != nil {
+		if err == types.ErrWalletNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	statement, err := h.db.GetWalletStatement(wallet.Id, query.from, query.to)
+	if err != nil {
+		if err == types.ErrLedgerAccountNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	writeWalletStatement(w, statement, query.format)
+}
+
+// getWalletDrifts godoc
+// @Summary      Get wallet drifts
+// @Description  Retrieves a paginated list of the wallets whose balance has drifted from their history, as found by the reconciliation, the latest first (requires wallet drifts full access permission)
+// @Tags         wallet
+// @Produce      json
+// @Param        resolved  query     bool  false  "Filter by whether the drift is resolved"
+// @Param        user      query     int   false  "Filter by the user who owns the wallet"
+// @Param        p         query     int   false  "Page number (default: 1)"
+// @Success      200       {array}   types.WalletDrift
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/drift [get]
+func (h *Handler) getWalletDrifts(w http.ResponseWriter, r *http.Request) {
+	query := types.WalletDriftSearchQuery{}
+	var page *int = nil
+
+	queryMapping := map[string]any{
+		"resolved": &query.Resolved,
+		"user":     &query.UserId,
+		"p":        &page,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query.Limit = utils.Ptr(int(config.Env.MaxWalletDriftsInPage))
+
+	if page != nil {
+		query.Offset = utils.Ptr((*query.Limit) * (*page - 1))
+	} else {
+		query.Offset = utils.Ptr(0)
+	}
+
+	drifts, err := h.db.GetWalletDrifts(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, drifts, nil)
+}
+
+// getWalletDriftsPages godoc
+// @Summary      Get wallet drift page count
+// @Description  Returns the total number of pages available for the wallet drifts based on filters (requires wallet drifts full access permission)
+// @Tags         wallet
+// @Produce      json
+// @Param        resolved  query     bool  false  "Filter by whether the drift is resolved"
+// @Param        user      query     int   false  "Filter by the user who owns the wallet"
+// @Success      200       {object}  types.TotalPageCountResponse
+// @Failure      400       {object}  types.HTTPError
+// @Failure      401       {object}  types.HTTPError
+// @Failure      403       {object}  types.HTTPError
+// @Failure      500       {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/drift/pages [get]
+func (h *Handler) getWalletDriftsPages(w http.ResponseWriter, r *http.Request) {
+	query := types.WalletDriftSearchQuery{}
+
+	queryMapping := map[string]any{
+		"resolved": &query.Resolved,
+		"user":     &query.UserId,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	count, err := h.db.GetWalletDriftsCount(query)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	pageCount := utils.GetPageCount(int64(count), int64(config.Env.MaxWalletDriftsInPage))
+
+	utils.WriteJSONInResponse(w, http.StatusOK, types.TotalPageCountResponse{
+		Pages: pageCount,
+	}, nil)
+}
+
+// getWalletDrift godoc
+// @Summary      Get a wallet drift
+// @Description  Retrieves a drift found by the reconciliation with the balances of its wallet (requires wallet drifts full access permission)
+// @Tags         wallet
+// @Produce      json
+// @Param        driftId  path      int  true  "Wallet drift ID"
+// @Success      200      {object}  types.WalletDrift
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/drift/{driftId} [get]
+func (h *Handler) getWalletDrift(w http.ResponseWriter, r *http.Request) {
+	driftId, err := utils.ParseIntURLParam("driftId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	drift, err := h.db.GetWalletDriftById(driftId)
+	if err != nil {
+		if err == types.ErrWalletDriftNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, drift, nil)
+}
+
+// resolveWalletDrift godoc
+// @Summary      Resolve a wallet drift
+// @Description  Closes an open wallet drift with a note about what was done, which unfreezes its wallet. If the wallet still does not match its history, the next reconciliation opens a new drift for it.
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Param        driftId  path      int                              true  "Wallet drift ID"
+// @Param        drift    body      types.ResolveWalletDriftPayload  true  "Resolution details"
+// @Success      200      "Wallet drift resolved"
+// @Failure      400      {object}  types.HTTPError
+// @Failure      401      {object}  types.HTTPError
+// @Failure      403      {object}  types.HTTPError
+// @Failure      404      {object}  types.HTTPError
+// @Failure      500      {object}  types.HTTPError
+// @Security     ApiKeyAuth
+// @Router       /wallet/drift/{driftId}/resolve [patch]
+func (h *Handler) resolveWalletDrift(w http.ResponseWriter, r *http.Request) {
+	var payload types.ResolveWalletDriftPayload
+	err := utils.ParseRequestPayload(r, &payload)
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	driftId, err := utils.ParseIntURLParam("driftId", mux.Vars(r))
+	if err != nil {
+		utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	cUserId := ctx.Value("userId")
+
+	if cUserId == nil {
+		utils.WriteErrorInResponse(
+			w,
+			http.StatusUnauthorized,
+			types.ErrAuthenticationCredentialsNotFound,
+		)
+		return
+	}
+
+	userId := cUserId.(int)
+
+	err = h.db.ResolveWalletDrift(driftId, userId, payload)
+	if err != nil {
+		if err == types.ErrWalletDriftNotFound {
+			utils.WriteErrorInResponse(w, http.StatusNotFound, err)
+		} else {
+			utils.WriteErrorInResponse(w, http.StatusBadRequest, err)
+		}
+
+		return
+	}
+
+	utils.WriteJSONInResponse(w, http.StatusOK, nil, nil)
+}
+
+type walletStatementQuery struct {
+	from   time.Time
+	to     time.Time
+	format types.WalletStatementFormat
+}
+
+// parseWalletStatementQuery parses the period and the format of a wallet
+// statement. The period ends now and starts 30 days before its end if
+// they are not given.
+func parseWalletStatementQuery(r *http.Request) (*walletStatementQuery, error) {
+	var from *time.Time = nil
+	var to *time.Time = nil
+	var format *types.WalletStatementFormat = nil
+
+	queryMapping := map[string]any{
+		"from":   &from,
+		"to":     &to,
+		"format": &format,
+	}
+
+	queryValues := r.URL.Query()
+
+	err := utils.ParseURLQuery(queryMapping, queryValues)
+	if err != nil {
+		return nil, err
+	}
+
+	query := walletStatementQuery{
+		to:     time.Now(),
+		format: types.WalletStatementFormatJSON,
+	}
+
+	if to != nil {
+		query.to = *to
+	}
+
+	if from != nil {
+		query.from = *from
+	} else {
+		query.from = query.to.AddDate(0, 0, -30)
+	}
+
+	if format != nil {
+		if !format.IsValid() {
+			return nil, types.ErrInvalidWalletStatementFormatEnum
+		}
+
+		query.format = *format
+	}
+
+	if !query.from.Before(query.to) {
+		return nil, types.ErrInvalidWalletStatementPeriod
+	}
+
+	maxDays := int(config.Env.MaxWalletStatementPeriodInDays)
+	if query.to.Sub(query.from) > time.Duration(maxDays)*24*time.Hour {
+		return nil, types.ErrWalletStatementPeriodTooLong(maxDays)
+	}
+
+	return &query, nil
+}
+
+// writeWalletStatement writes a wallet statement in the response in the
+// given format, the CSV and the PDF statements are sent as files.
+func writeWalletStatement(
+	w http.ResponseWriter,
+	statement *types.WalletStatement,
+	format types.WalletStatementFormat,
+) {
+	filename := fmt.Sprintf(
+		"statement-%d-%s-%s",
+		statement.WalletId,
+		statement.From.Format("20060102"),
+		statement.To.Format("20060102"),
+	)
+
+	switch format {
+	case types.WalletStatementFormatCSV:
+		data, err := renderStatementCSV(statement)
+		if err != nil {
+			utils.WriteErrorInResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteFileInResponse(w, http.StatusOK, data, "text/csv", filename+".csv")
+
+	case types.WalletStatementFormatPDF:
+		utils.WriteFileInResponse(
+			w,
+			http.StatusOK,
+			renderStatementPDF(statement),
+			"application/pdf",
+			filename+".pdf",
+		)
+
+	default:
+		utils.WriteJSONInResponse(w, http.StatusOK, statement, nil)
+	}
+}
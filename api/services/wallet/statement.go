@@ -0,0 +1,254 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+
+	"github.com/SaeedAlian/econest/api/types"
+	"github.com/SaeedAlian/econest/api/utils/pdf"
+)
+
+const (
+	statementMargin     = 40.0
+	statementLineHeight = 14.0
+	statementTextSize   = 9.0
+	statementSmallSize  = 7.5
+)
+
+// statement table columns, the date and description columns are left
+// aligned and the others are right aligned to their x
+var (
+	statementColumnDate        = statementMargin
+	statementColumnDescription = 130.0
+	statementColumnCredit      = 415.0
+	statementColumnDebit       = 485.0
+	statementColumnBalance     = pdf.PageWidth - statementMargin
+)
+
+const statementDateLayout = "2006-01-02 15:04"
+
+// renderStatementCSV renders a wallet statement as CSV, with a row for the
+// opening balance, a row for each entry and a row for the closing balance.
+func renderStatementCSV(statement *types.WalletStatement) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	cw := csv.NewWriter(buf)
+
+	records := [][]string{
+		{"Date", "Entry", "Kind", "Description", "Credit", "Debit", "Balance"},
+		{
+			statement.From.Format(statementDateLayout),
+			"",
+			"",
+			"Opening balance",
+			"",
+			"",
+			statement.OpeningBalance.String(),
+		},
+	}
+
+	for _, l := range statement.Lines {
+		credit, debit := splitStatementAmount(l.Amount)
+		records = append(records, []string{
+			l.CreatedAt.Format(statementDateLayout),
+			fmt.Sprint(l.EntryId),
+			l.Kind.String(),
+			l.Description,
+			credit,
+			debit,
+			l.Balance.String(),
+		})
+	}
+
+	records = append(records, []string{
+		statement.To.Format(statementDateLayout),
+		"",
+		"",
+		"Closing balance",
+		statement.TotalCredits.String(),
+		statement.TotalDebits.String(),
+		statement.ClosingBalance.String(),
+	})
+
+	if err := cw.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type statementWriter struct {
+	doc *pdf.Document
+	y   float64
+}
+
+// renderStatementPDF renders a wallet statement as a PDF document.
+func renderStatementPDF(statement *types.WalletStatement) []byte {
+	sw := &statementWriter{doc: pdf.New()}
+	sw.newPage()
+
+	sw.doc.Text(statementMargin, sw.y, 20, true, "WALLET STATEMENT")
+	sw.doc.TextRight(
+		statementColumnBalance,
+		sw.y,
+		statementTextSize,
+		true,
+		fmt.Sprintf("Wallet #%d", statement.WalletId),
+	)
+	sw.next()
+	sw.doc.TextRight(
+		statementColumnBalance,
+		sw.y,
+		statementTextSize,
+		false,
+		fmt.Sprintf(
+			"%s to %s",
+			statement.From.Format(statementDateLayout),
+			statement.To.Format(statementDateLayout),
+		),
+	)
+	sw.next()
+	sw.next()
+
+	sw.tableHeader()
+	sw.balance("Opening balance", statement.OpeningBalance)
+
+	for _, l := range statement.Lines {
+		sw.ensureSpace(statementLineHeight, true)
+
+		credit, debit := splitStatementAmount(l.Amount)
+
+		sw.doc.Text(
+			statementColumnDate,
+			sw.y,
+			statementTextSize,
+			false,
+			l.CreatedAt.Format(statementDateLayout),
+		)
+		sw.doc.Text(
+			statementColumnDescription,
+			sw.y,
+			statementTextSize,
+			false,
+			truncateStatementText(
+				fmt.Sprintf("#%d %s", l.EntryId, l.Description),
+				statementColumnCredit-statementColumnDescription-50,
+			),
+		)
+		sw.cell(statementColumnCredit, credit)
+		sw.cell(statementColumnDebit, debit)
+		sw.cell(statementColumnBalance, l.Balance.String())
+		sw.next()
+	}
+
+	sw.doc.Line(
+		statementMargin,
+		sw.y+statementLineHeight-4,
+		statementColumnBalance,
+		sw.y+statementLineHeight-4,
+	)
+
+	sw.ensureSpace(statementLineHeight, false)
+	sw.doc.Text(statementColumnDescription, sw.y, statementTextSize, true, "Closing balance")
+	sw.doc.TextRight(
+		statementColumnCredit,
+		sw.y,
+		statementTextSize,
+		true,
+		statement.TotalCredits.String(),
+	)
+	sw.doc.TextRight(
+		statementColumnDebit,
+		sw.y,
+		statementTextSize,
+		true,
+		statement.TotalDebits.String(),
+	)
+	sw.doc.TextRight(
+		statementColumnBalance,
+		sw.y,
+		statementTextSize,
+		true,
+		statement.ClosingBalance.String(),
+	)
+	sw.next()
+
+	sw.next()
+	sw.ensureSpace(statementLineHeight, false)
+	sw.doc.Text(
+		statementMargin,
+		sw.y,
+		statementSmallSize,
+		false,
+		fmt.Sprintf("%d entries", len(statement.Lines)),
+	)
+
+	return sw.doc.Bytes()
+}
+
+func (sw *statementWriter) newPage() {
+	sw.doc.AddPage()
+	sw.y = pdf.PageHeight - statementMargin - 20
+}
+
+func (sw *statementWriter) next() {
+	sw.y -= statementLineHeight
+}
+
+// ensureSpace starts a new page if the given height does not fit in the
+// current page, repeating the table header if the table is being written.
+func (sw *statementWriter) ensureSpace(height float64, inTable bool) {
+	if sw.y-height >= statementMargin {
+		return
+	}
+
+	sw.newPage()
+	if inTable {
+		sw.tableHeader()
+	}
+}
+
+func (sw *statementWriter) tableHeader() {
+	sw.doc.Text(statementColumnDate, sw.y, statementTextSize, true, "Date")
+	sw.doc.Text(statementColumnDescription, sw.y, statementTextSize, true, "Description")
+	sw.doc.TextRight(statementColumnCredit, sw.y, statementTextSize, true, "Credit")
+	sw.doc.TextRight(statementColumnDebit, sw.y, statementTextSize, true, "Debit")
+	sw.doc.TextRight(statementColumnBalance, sw.y, statementTextSize, true, "Balance")
+	sw.doc.Line(statementMargin, sw.y-4, statementColumnBalance, sw.y-4)
+	sw.next()
+	sw.y -= 4
+}
+
+func (sw *statementWriter) cell(x float64, s string) {
+	sw.doc.TextRight(x, sw.y, statementTextSize, false, s)
+}
+
+func (sw *statementWriter) balance(label string, amount types.Money) {
+	sw.ensureSpace(statementLineHeight, true)
+	sw.doc.Text(statementColumnDescription, sw.y, statementTextSize, true, label)
+	sw.doc.TextRight(statementColumnBalance, sw.y, statementTextSize, true, amount.String())
+	sw.next()
+}
+
+// splitStatementAmount returns the amount of an entry in the credit or the
+// debit column, the other one is left empty.
+func splitStatementAmount(amount types.Money) (string, string) {
+	if amount.IsNegative() {
+		return "", amount.Neg().String()
+	}
+
+	return amount.String(), ""
+}
+
+func truncateStatementText(s string, width float64) string {
+	if pdf.TextWidth(s, statementTextSize) <= width {
+		return s
+	}
+
+	r := []rune(s)
+	for len(r) > 0 && pdf.TextWidth(string(r)+"...", statementTextSize) > width {
+		r = r[:len(r)-1]
+	}
+
+	return string(r) + "..."
+}
@@ -0,0 +1,88 @@
+package types
+
+import "time"
+
+// Cart represents a user's persistent shopping cart
+// @model Cart
+type Cart struct {
+	// Unique identifier for the cart (private, needs permission)
+	Id int `json:"id"        exposure:"private,needPermission"`
+	// When the cart was created (private, needs permission)
+	CreatedAt time.Time `json:"createdAt" exposure:"private,needPermission"`
+	// When the cart was last updated (private, needs permission)
+	UpdatedAt time.Time `json:"updatedAt" exposure:"private,needPermission"`
+	// ID of the user who owns the cart (private, needs permission)
+	UserId int `json:"userId"    exposure:"private,needPermission"`
+}
+
+// CartItem represents a product variant inside a cart
+// @model CartItem
+type CartItem struct {
+	// Unique identifier for the cart item (private, needs permission)
+	Id int `json:"id"        exposure:"private,needPermission"`
+	// Selected quantity of the variant (private, needs permission)
+	Quantity int `json:"quantity"  exposure:"private,needPermission"`
+	// When the item was added to the cart (private, needs permission)
+	CreatedAt time.Time `json:"createdAt" exposure:"private,needPermission"`
+	// When the item was last updated (private, needs permission)
+	UpdatedAt time.Time `json:"updatedAt" exposure:"private,needPermission"`
+	// ID of the cart this item belongs to (private, needs permission)
+	CartId int `json:"cartId"    exposure:"private,needPermission"`
+	// ID of the selected product variant (private, needs permission)
+	VariantId int `json:"variantId" exposure:"private,needPermission"`
+}
+
+// CartItemInfo represents a cart item priced with the current product prices and offers
+// @model CartItemInfo
+type CartItemInfo struct {
+	CartItem
+	// Current price per unit of the variant including active offers (private, needs permission)
+	VariantPrice Money `json:"variantPrice"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Default shipping cost for this item, the final cost depends on the receiver address (private, needs permission)
+	ShippingPrice Money `json:"shippingPrice"     exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Quantity of the variant currently in stock (private, needs permission)
+	AvailableQuantity int `json:"availableQuantity" exposure:"private,needPermission"`
+	// Complete information about the selected variant (private, needs permission)
+	SelectedVariant ProductVariantWithAttributeSet `json:"selectedVariant"   exposure:"private,needPermission"`
+	// Information about the base product (private, needs permission)
+	Product Product `json:"product"           exposure:"private,needPermission"`
+}
+
+// CartWithItemsInfo represents a cart with its priced items and totals
+// @model CartWithItemsInfo
+type CartWithItemsInfo struct {
+	Cart
+	// Priced items of the cart (private, needs permission)
+	Items []CartItemInfo `json:"items"              exposure:"private,needPermission"`
+	// Total price of all items in the cart (private, needs permission)
+	TotalVariantsPrice Money `json:"totalVariantsPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total default shipping cost of the cart, the final cost depends on the receiver address (private, needs permission)
+	TotalShipmentPrice Money `json:"totalShipmentPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Fee that will be applied on checkout (private, needs permission)
+	Fee Money `json:"fee"                exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// AddCartItemPayload contains data for adding a product variant to the cart
+// @model AddCartItemPayload
+type AddCartItemPayload struct {
+	// Number of units to add (required)
+	Quantity int `json:"quantity"  validate:"required"`
+	// ID of the product variant to add (required)
+	VariantId int `json:"variantId" validate:"required"`
+}
+
+// UpdateCartItemPayload contains data for updating a cart item
+// @model UpdateCartItemPayload
+type UpdateCartItemPayload struct {
+	// New number of units (required)
+	Quantity int `json:"quantity" validate:"required"`
+}
+
+// CheckoutCartPayload contains data needed to turn the cart into an order
+// @model CheckoutCartPayload
+type CheckoutCartPayload struct {
+	// ID of the receiver's address (required)
+	ReceiverAddressId int `json:"receiverAddressId" validate:"required"`
+	// Code of the coupon to apply
+	CouponCode *string `json:"couponCode"`
+}
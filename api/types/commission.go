@@ -0,0 +1,122 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// CommissionRule represents the rate of the fee that the platform takes from the order lines of a store or a product category
+// @model CommissionRule
+type CommissionRule struct {
+	// Unique identifier for the commission rule (private, needs permission)
+	Id int `json:"id"         exposure:"private,needPermission"`
+	// Rate of the commission between 0 and 1 (private, needs permission)
+	Rate float64 `json:"rate"       exposure:"private,needPermission"`
+	// When the commission rule was created (private, needs permission)
+	CreatedAt time.Time `json:"createdAt"  exposure:"private,needPermission"`
+	// When the commission rule was last updated (private, needs permission)
+	UpdatedAt time.Time `json:"updatedAt"  exposure:"private,needPermission"`
+	// ID of the store the rule applies to, all stores if it is null (private, needs permission)
+	StoreId json_types.JSONNullInt32 `json:"storeId"    exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// ID of the category the rule applies to with its subcategories, all products if it is null (private, needs permission)
+	CategoryId json_types.JSONNullInt32 `json:"categoryId" exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// CreateCommissionRulePayload contains data needed to create a commission rule
+// @model CreateCommissionRulePayload
+type CreateCommissionRulePayload struct {
+	// Rate of the commission between 0 and 1
+	Rate float64 `json:"rate"`
+	// ID of the store the rule applies to, the rule applies to all stores if it is not set
+	StoreId *int `json:"storeId"`
+	// ID of the category the rule applies to, the rule applies to all products if it is not set
+	CategoryId *int `json:"categoryId"`
+}
+
+// UpdateCommissionRulePayload contains data for updating a commission rule
+// @model UpdateCommissionRulePayload
+type UpdateCommissionRulePayload struct {
+	// New rate
+	Rate *float64 `json:"rate"`
+}
+
+// CommissionRuleSearchQuery contains parameters for searching commission rules
+// @model CommissionRuleSearchQuery
+type CommissionRuleSearchQuery struct {
+	// Filter by store ID
+	StoreId *int `json:"storeId"`
+	// Filter by category ID
+	CategoryId *int `json:"categoryId"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
+
+// PlatformWallet represents the wallet that receives the fees of the paid orders
+// @model PlatformWallet
+type PlatformWallet struct {
+	// Current balance in the wallet (private, needs permission)
+	Balance Money `json:"balance"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// When the wallet was created (private, needs permission)
+	CreatedAt time.Time `json:"createdAt" exposure:"private,needPermission"`
+	// When the wallet was last updated (private, needs permission)
+	UpdatedAt time.Time `json:"updatedAt" exposure:"private,needPermission"`
+}
+
+// CommissionReport represents the commission earned from the paid orders of a period
+// @model CommissionReport
+type CommissionReport struct {
+	// Number of the paid orders (private, needs permission)
+	TotalOrders int `json:"totalOrders"     exposure:"private,needPermission"`
+	// Price of the order lines after the discounts that the commission is computed on (private, needs permission)
+	TotalSales Money `json:"totalSales"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Commission earned from the orders (private, needs permission)
+	TotalCommission Money `json:"totalCommission" exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// StoreCommissionReport represents the commission earned from the paid orders of a store
+// @model StoreCommissionReport
+type StoreCommissionReport struct {
+	// ID of the store (private, needs permission)
+	StoreId int `json:"storeId"         exposure:"private,needPermission"`
+	// Name of the store (private, needs permission)
+	StoreName string `json:"storeName"       exposure:"private,needPermission"`
+	// Number of the paid orders that have lines of the store (private, needs permission)
+	TotalOrders int `json:"totalOrders"     exposure:"private,needPermission"`
+	// Price of the store lines after the discounts (private, needs permission)
+	TotalSales Money `json:"totalSales"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Commission earned from the store lines (private, needs permission)
+	TotalCommission Money `json:"totalCommission" exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// CategoryCommissionReport represents the commission earned from the paid order lines of the products of a category
+// @model CategoryCommissionReport
+type CategoryCommissionReport struct {
+	// ID of the category of the products (private, needs permission)
+	CategoryId int `json:"categoryId"      exposure:"private,needPermission"`
+	// Name of the category (private, needs permission)
+	CategoryName string `json:"categoryName"    exposure:"private,needPermission"`
+	// Number of the paid orders that have lines of the category (private, needs permission)
+	TotalOrders int `json:"totalOrders"     exposure:"private,needPermission"`
+	// Price of the category lines after the discounts (private, needs permission)
+	TotalSales Money `json:"totalSales"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Commission earned from the category lines (private, needs permission)
+	TotalCommission Money `json:"totalCommission" exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// CommissionReportQuery contains parameters for the commission reports
+// @model CommissionReportQuery
+type CommissionReportQuery struct {
+	// Filter by store ID
+	StoreId *int `json:"storeId"`
+	// Filter by the orders paid after this date
+	AfterDate *time.Time `json:"afterDate"`
+	// Filter by the orders paid before this date
+	BeforeDate *time.Time `json:"beforeDate"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// Coupon represents a promo code that discounts the price of the eligible products of an order
+// @model Coupon
+type Coupon struct {
+	// Unique identifier for the coupon (private, needs permission)
+	Id int `json:"id"           exposure:"private,needPermission"`
+	// Code entered by the customer (private, needs permission)
+	Code string `json:"code"         exposure:"private,needPermission"`
+	// Description of the coupon (private, needs permission)
+	Description string `json:"description"  exposure:"private,needPermission"`
+	// How the discount is calculated (private, needs permission)
+	DiscountType CouponDiscountType `json:"discountType" exposure:"private,needPermission"`
+	// Discount fraction of percentage coupons (private, needs permission)
+	Rate json_types.JSONNullFloat64 `json:"rate"         exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Discount amount of fixed coupons (private, needs permission)
+	Amount NullMoney `json:"amount"       exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Minimum price of the eligible products for the coupon to apply (private, needs permission)
+	MinBasket Money `json:"minBasket"    exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Maximum number of orders that can use the coupon (private, needs permission)
+	UsageLimit json_types.JSONNullInt32 `json:"usageLimit"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Maximum number of orders of a single user that can use the coupon (private, needs permission)
+	PerUserLimit json_types.JSONNullInt32 `json:"perUserLimit" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// When the coupon becomes valid (private, needs permission)
+	StartsAt time.Time `json:"startsAt"     exposure:"private,needPermission"`
+	// When the coupon expires (private, needs permission)
+	ExpiresAt time.Time `json:"expiresAt"    exposure:"private,needPermission"`
+	// If the coupon can be used (private, needs permission)
+	IsActive bool `json:"isActive"     exposure:"private,needPermission"`
+	// When the coupon was created (private, needs permission)
+	CreatedAt time.Time `json:"createdAt"    exposure:"private,needPermission"`
+	// When the coupon was last updated (private, needs permission)
+	UpdatedAt time.Time `json:"updatedAt"    exposure:"private,needPermission"`
+	// Restricts the coupon to the products of this store (private, needs permission)
+	StoreId json_types.JSONNullInt32 `json:"storeId"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Restricts the coupon to the products of this category and its subcategories (private, needs permission)
+	CategoryId json_types.JSONNullInt32 `json:"categoryId"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Restricts the coupon to the products with this tag (private, needs permission)
+	TagId json_types.JSONNullInt32 `json:"tagId"        exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// CreateCouponPayload contains data needed to create a coupon
+// @model CreateCouponPayload
+type CreateCouponPayload struct {
+	// Code entered by the customer (required)
+	Code string `json:"code"         validate:"required"`
+	// Description of the coupon
+	Description string `json:"description"`
+	// How the discount is calculated (required)
+	DiscountType CouponDiscountType `json:"discountType" validate:"required"`
+	// Discount fraction (0 to 1), required for percentage coupons
+	Rate *float64 `json:"rate"`
+	// Discount amount, required for fixed coupons
+	Amount *Money `json:"amount" swaggertype:"primitive,number"`
+	// Minimum price of the eligible products for the coupon to apply
+	MinBasket Money `json:"minBasket" swaggertype:"primitive,number"`
+	// Maximum number of orders that can use the coupon
+	UsageLimit *int `json:"usageLimit"`
+	// Maximum number of orders of a single user that can use the coupon
+	PerUserLimit *int `json:"perUserLimit"`
+	// When the coupon becomes valid (required)
+	StartsAt time.Time `json:"startsAt"     validate:"required"`
+	// When the coupon expires (required)
+	ExpiresAt time.Time `json:"expiresAt"    validate:"required"`
+	// Restricts the coupon to the products of this store
+	StoreId *int `json:"storeId"`
+	// Restricts the coupon to the products of this category and its subcategories
+	CategoryId *int `json:"categoryId"`
+	// Restricts the coupon to the products with this tag
+	TagId *int `json:"tagId"`
+}
+
+// UpdateCouponPayload contains data for updating a coupon
+// @model UpdateCouponPayload
+type UpdateCouponPayload struct {
+	// New description
+	Description *string `json:"description"`
+	// New discount fraction of a percentage coupon
+	Rate *float64 `json:"rate"`
+	// New discount amount of a fixed coupon
+	Amount *Money `json:"amount" swaggertype:"primitive,number"`
+	// New minimum basket
+	MinBasket *Money `json:"minBasket" swaggertype:"primitive,number"`
+	// New usage limit
+	UsageLimit *int `json:"usageLimit"`
+	// New per user usage limit
+	PerUserLimit *int `json:"perUserLimit"`
+	// New start date
+	StartsAt *time.Time `json:"startsAt"`
+	// New expiration date
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// New active status
+	IsActive *bool `json:"isActive"`
+}
+
+// CouponSearchQuery contains parameters for searching coupons
+// @model CouponSearchQuery
+type CouponSearchQuery struct {
+	// Filter by code (partial match)
+	Code *string `json:"code"`
+	// Filter by store ID
+	StoreId *int `json:"storeId"`
+	// Filter by active status
+	IsActive *bool `json:"isActive"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
@@ -0,0 +1,118 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// NextCursorHeader is the response header that carries the cursor of the
+// next page of a list, it is left out on the last page.
+const NextCursorHeader = "X-Next-Cursor"
+
+// CursorSortNewest is the sort of the cursors of the lists that always come
+// newest first.
+const CursorSortNewest = "newest"
+
+// cursorTimeLayout writes the creation times in the cursors the same way as
+// the database, so they are compared without losing their microseconds.
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+// cursorDecimalKeyRegex matches the decimal sort keys as they are written by
+// the database, with an optional exponent
+var cursorDecimalKeyRegex = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?(e[-+]?[0-9]+)?$`)
+
+// cursorKeyParsers check the sort keys of the cursors of each sort, so a key
+// that has been edited is rejected before it is compared in the database.
+// ProductSortNewest is the same sort as CursorSortNewest.
+var cursorKeyParsers = map[string]func(key string) error{
+	CursorSortNewest:                    parseCursorTimeKey,
+	ProductSortRelevance.String():       parseCursorDecimalKey,
+	ProductSortPriceAsc.String():        parseCursorDecimalKey,
+	ProductSortPriceDesc.String():       parseCursorDecimalKey,
+	ProductSortTopRated.String():        parseCursorDecimalKey,
+	ProductSortBestSelling.String():     parseCursorIntegerKey,
+	ProductSortBiggestDiscount.String(): parseCursorDecimalKey,
+}
+
+// Cursor marks the last row of a page of a list, the next page starts right
+// after it. The rows are compared by their sort key and then by their id, so
+// the pages stay the same while new rows are being added.
+type Cursor struct {
+	// Sort that the list is ordered by
+	Sort string `json:"s"`
+	// Sort key of the last row, as written by the database
+	Key string `json:"k"`
+	// ID of the last row
+	Id int `json:"i"`
+}
+
+// Page is a page of a list with the cursor of the next page, which is nil on
+// the last page.
+type Page[T any] struct {
+	// Rows of the page
+	Items []T
+	// Cursor of the next page
+	NextCursor *Cursor
+}
+
+// NewNewestCursor returns the cursor of a row of a list that comes newest
+// first.
+func NewNewestCursor(createdAt time.Time, id int) *Cursor {
+	return &Cursor{
+		Sort: CursorSortNewest,
+		Key:  createdAt.Format(cursorTimeLayout),
+		Id:   id,
+	}
+}
+
+// Encode returns the cursor as an opaque string.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor decodes a cursor that was returned by Encode, checking that
+// it was made for the given sort and that its key is a key of that sort.
+func DecodeCursor(s string, sort string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := new(Cursor)
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if c.Sort != sort || c.Key == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	parseKey, ok := cursorKeyParsers[c.Sort]
+	if !ok || parseKey(c.Key) != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func parseCursorTimeKey(key string) error {
+	_, err := time.Parse(cursorTimeLayout, key)
+	return err
+}
+
+func parseCursorIntegerKey(key string) error {
+	_, err := strconv.ParseInt(key, 10, 64)
+	return err
+}
+
+func parseCursorDecimalKey(key string) error {
+	if !cursorDecimalKeyRegex.MatchString(key) {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Date(2026, 3, 4, 5, 6, 7, 123456000, time.UTC)
+	c := NewNewestCursor(createdAt, 42)
+
+	if c.Key != "2026-03-04 05:06:07.123456" {
+		t.Errorf("Expected the key to keep the microseconds, got %q", c.Key)
+	}
+
+	decoded, err := DecodeCursor(c.Encode(), CursorSortNewest)
+	if err != nil {
+		t.Fatalf("There was an error on decoding the cursor: %v", err)
+	}
+
+	if *decoded != *c {
+		t.Errorf("Expected the decoded cursor to be %+v, got %+v", *c, *decoded)
+	}
+
+	_, err = DecodeCursor(c.Encode(), ProductSortPriceAsc.String())
+	if err != ErrInvalidCursor {
+		t.Errorf("Expected an invalid cursor error on decoding with another sort, got %v", err)
+	}
+
+	for _, s := range []string{"", "abc", "e30", "!!"} {
+		_, err := DecodeCursor(s, CursorSortNewest)
+		if err != ErrInvalidCursor {
+			t.Errorf("Expected an invalid cursor error on decoding %q, got %v", s, err)
+		}
+	}
+
+	keys := []struct {
+		sort  string
+		key   string
+		valid bool
+	}{
+		{CursorSortNewest, "2026-03-04 05:06:07", true},
+		{CursorSortNewest, "yesterday", false},
+		{ProductSortPriceAsc.String(), "19.99", true},
+		{ProductSortPriceAsc.String(), "1e+20", true},
+		{ProductSortPriceAsc.String(), "NaN", false},
+		{ProductSortPriceDesc.String(), "1; DROP TABLE products", false},
+		{ProductSortTopRated.String(), "4.5000000000000000", true},
+		{ProductSortTopRated.String(), "four", false},
+		{ProductSortBestSelling.String(), "120", true},
+		{ProductSortBestSelling.String(), "1.5", false},
+		{"unknown", "1", false},
+	}
+
+	for _, k := range keys {
+		c := Cursor{Sort: k.sort, Key: k.key, Id: 1}
+
+		_, err := DecodeCursor(c.Encode(), k.sort)
+		if k.valid && err != nil {
+			t.Errorf("There was an error on decoding the %q key %q: %v", k.sort, k.key, err)
+		}
+		if !k.valid && err != ErrInvalidCursor {
+			t.Errorf("Expected an invalid cursor error on decoding the %q key %q, got %v", k.sort, k.key, err)
+		}
+	}
+}
+
+func TestProductSearchQueryResolvedSort(t *testing.T) {
+	keyword := "xbox"
+	relevance := ProductSortRelevance
+	priceAsc := ProductSortPriceAsc
+
+	cases := []struct {
+		query    ProductSearchQuery
+		expected ProductSort
+	}{
+		{ProductSearchQuery{}, ProductSortNewest},
+		{ProductSearchQuery{Keyword: &keyword}, ProductSortRelevance},
+		{ProductSearchQuery{Sort: &relevance}, ProductSortNewest},
+		{ProductSearchQuery{Sort: &priceAsc}, ProductSortPriceAsc},
+		{ProductSearchQuery{Keyword: &keyword, Sort: &priceAsc}, ProductSortPriceAsc},
+	}
+
+	for _, c := range cases {
+		if sort := c.query.ResolvedSort(); sort != c.expected {
+			t.Errorf("Expected the sort to be %q, got %q", c.expected, sort)
+		}
+	}
+}
@@ -65,11 +65,23 @@ const (
 	TransactionTypeDeposit TransactionType = "deposit"
 	// Money being withdrawn from the wallet
 	TransactionTypeWithdraw TransactionType = "withdraw"
+	// Money being refunded to the customer for a returned order item
+	TransactionTypeRefund TransactionType = "refund"
+	// Money being taken from the store owner for a returned order item
+	TransactionTypeRefundCharge TransactionType = "refund_charge"
+	// Money being sent from the wallet to another user
+	TransactionTypeTransferOut TransactionType = "transfer_out"
+	// Money being received in the wallet from another user
+	TransactionTypeTransferIn TransactionType = "transfer_in"
 )
 
 var ValidTransactionTypes = []TransactionType{
 	TransactionTypeDeposit,
 	TransactionTypeWithdraw,
+	TransactionTypeRefund,
+	TransactionTypeRefundCharge,
+	TransactionTypeTransferOut,
+	TransactionTypeTransferIn,
 }
 
 func (t TransactionType) IsValid() bool {
@@ -134,6 +146,17 @@ func (s OrderPaymentStatus) String() string {
 	return string(s)
 }
 
+// orderPaymentTransitions lists the statuses that each payment status can be
+// changed to, the payment is finalized once it succeeds or fails
+var orderPaymentTransitions = map[OrderPaymentStatus][]OrderPaymentStatus{
+	OrderPaymentStatusPending: {OrderPaymentStatusSuccessful, OrderPaymentStatusFailed},
+}
+
+// CanTransitionTo reports whether the payment status can be changed to next
+func (s OrderPaymentStatus) CanTransitionTo(next OrderPaymentStatus) bool {
+	return slices.Contains(orderPaymentTransitions[s], next)
+}
+
 // Action defines all possible permission actions in the system
 // @model Action
 type Action string
@@ -234,6 +257,46 @@ const (
 	ActionCanApproveWithdrawTransaction Action = "can_approve_withdraw_transaction"
 	// Permission to cancel withdrawal transactions
 	ActionCanCancelWithdrawTransaction Action = "can_cancel_withdraw_transaction"
+
+	// Permission to add coupons
+	ActionCanAddCoupon Action = "can_add_coupon"
+	// Permission to update coupons
+	ActionCanUpdateCoupon Action = "can_update_coupon"
+	// Permission to delete coupons
+	ActionCanDeleteCoupon Action = "can_delete_coupon"
+
+	// Permission to add shipping zones
+	ActionCanAddShippingZone Action = "can_add_shipping_zone"
+	// Permission to update shipping zones
+	ActionCanUpdateShippingZone Action = "can_update_shipping_zone"
+	// Permission to delete shipping zones
+	ActionCanDeleteShippingZone Action = "can_delete_shipping_zone"
+
+	// Permission to add tax rules
+	ActionCanAddTaxRule Action = "can_add_tax_rule"
+	// Permission to update tax rules
+	ActionCanUpdateTaxRule Action = "can_update_tax_rule"
+	// Permission to delete tax rules
+	ActionCanDeleteTaxRule Action = "can_delete_tax_rule"
+
+	// Permission to add commission rules
+	ActionCanAddCommissionRule Action = "can_add_commission_rule"
+	// Permission to update commission rules
+	ActionCanUpdateCommissionRule Action = "can_update_commission_rule"
+	// Permission to delete commission rules
+	ActionCanDeleteCommissionRule Action = "can_delete_commission_rule"
+
+	// Permission to add withdrawal tiers
+	ActionCanAddWithdrawalTier Action = "can_add_withdrawal_tier"
+	// Permission to update withdrawal tiers and the tiers of the roles
+	ActionCanUpdateWithdrawalTier Action = "can_update_withdrawal_tier"
+	// Permission to delete withdrawal tiers
+	ActionCanDeleteWithdrawalTier Action = "can_delete_withdrawal_tier"
+	// Permission to set the withdrawal tier and the KYC verification of users
+	ActionCanUpdateUserWithdrawalProfile Action = "can_update_user_withdrawal_profile"
+
+	// Permission to resolve wallet drifts, which unfreezes their wallets
+	ActionCanResolveWalletDrift Action = "can_resolve_wallet_drift"
 )
 
 var ValidActions = []Action{
@@ -292,6 +355,28 @@ var ValidActions = []Action{
 
 	ActionCanApproveWithdrawTransaction,
 	ActionCanCancelWithdrawTransaction,
+
+	ActionCanAddCoupon,
+	ActionCanUpdateCoupon,
+	ActionCanDeleteCoupon,
+
+	ActionCanAddShippingZone,
+	ActionCanUpdateShippingZone,
+	ActionCanDeleteShippingZone,
+
+	ActionCanAddTaxRule,
+	ActionCanUpdateTaxRule,
+	ActionCanDeleteTaxRule,
+
+	ActionCanAddCommissionRule,
+	ActionCanUpdateCommissionRule,
+	ActionCanDeleteCommissionRule,
+
+	ActionCanAddWithdrawalTier,
+	ActionCanUpdateWithdrawalTier,
+	ActionCanDeleteWithdrawalTier,
+	ActionCanUpdateUserWithdrawalProfile,
+	ActionCanResolveWalletDrift,
 }
 
 func (a Action) IsValid() bool {
@@ -324,6 +409,18 @@ const (
 
 	// Full access to order management
 	ResourceOrdersFullAccess Resource = "orders_full_access"
+
+	// Full access to coupons
+	ResourceCouponsFullAccess Resource = "coupons_full_access"
+
+	// Full access to commission rules, reports and the platform wallet
+	ResourceCommissionsFullAccess Resource = "commissions_full_access"
+
+	// Full access to withdrawal tiers and the withdrawal profiles of the users
+	ResourceWithdrawalTiersFullAccess Resource = "withdrawal_tiers_full_access"
+
+	// Full access to the wallet drifts found by the reconciliation
+	ResourceWalletDriftsFullAccess Resource = "wallet_drifts_full_access"
 )
 
 var ValidResources = []Resource{
@@ -333,6 +430,10 @@ var ValidResources = []Resource{
 	ResourceWalletTransactionsFullAccess,
 	ResourceStoresFullAccess,
 	ResourceOrdersFullAccess,
+	ResourceCouponsFullAccess,
+	ResourceCommissionsFullAccess,
+	ResourceWithdrawalTiersFullAccess,
+	ResourceWalletDriftsFullAccess,
 }
 
 func (r Resource) IsValid() bool {
@@ -373,6 +474,278 @@ func (s OrderShipmentStatus) String() string {
 	return string(s)
 }
 
+// orderShipmentTransitions lists the statuses that each shipment status can
+// be changed to, a shipment that is on the way can be sent back to be
+// determined again, delivered and cancelled shipments are final
+var orderShipmentTransitions = map[OrderShipmentStatus][]OrderShipmentStatus{
+	OrderShipmentStatusToBeDetermined: {
+		OrderShipmentStatusOnTheWay,
+		OrderShipmentStatusCancelled,
+	},
+	OrderShipmentStatusOnTheWay: {
+		OrderShipmentStatusToBeDetermined,
+		OrderShipmentStatusDelivered,
+		OrderShipmentStatusCancelled,
+	},
+}
+
+// CanTransitionTo reports whether the shipment status can be changed to next
+func (s OrderShipmentStatus) CanTransitionTo(next OrderShipmentStatus) bool {
+	return slices.Contains(orderShipmentTransitions[s], next)
+}
+
+// OrderStatusEventKind defines which status of an order a status event changed
+// @model OrderStatusEventKind
+type OrderStatusEventKind string
+
+const (
+	// The payment status of the order changed
+	OrderStatusEventKindPayment OrderStatusEventKind = "payment"
+	// The shipment status of a store in the order changed
+	OrderStatusEventKindShipment OrderStatusEventKind = "shipment"
+)
+
+var ValidOrderStatusEventKinds = []OrderStatusEventKind{
+	OrderStatusEventKindPayment,
+	OrderStatusEventKindShipment,
+}
+
+func (k OrderStatusEventKind) IsValid() bool {
+	return slices.Contains(ValidOrderStatusEventKinds, k)
+}
+
+func (k OrderStatusEventKind) String() string {
+	return string(k)
+}
+
+// LedgerAccountKind defines who owns the money of a ledger account
+// @model LedgerAccountKind
+type LedgerAccountKind string
+
+const (
+	// The account of a user wallet
+	LedgerAccountKindWallet LedgerAccountKind = "wallet"
+	// The account that collects the fees of the platform
+	LedgerAccountKindPlatform LedgerAccountKind = "platform"
+	// The account of the money that enters or leaves the system
+	LedgerAccountKindExternal LedgerAccountKind = "external"
+	// The account that holds the earnings of the stores until they are released
+	LedgerAccountKindEscrow LedgerAccountKind = "escrow"
+)
+
+var ValidLedgerAccountKinds = []LedgerAccountKind{
+	LedgerAccountKindWallet,
+	LedgerAccountKindPlatform,
+	LedgerAccountKindExternal,
+	LedgerAccountKindEscrow,
+}
+
+func (k LedgerAccountKind) IsValid() bool {
+	return slices.Contains(ValidLedgerAccountKinds, k)
+}
+
+func (k LedgerAccountKind) String() string {
+	return string(k)
+}
+
+// JournalEntryKind defines which money movement a journal entry records
+// @model JournalEntryKind
+type JournalEntryKind string
+
+const (
+	// The balance of an account before the ledger was introduced
+	JournalEntryKindOpeningBalance JournalEntryKind = "opening_balance"
+	// Money deposited to a wallet
+	JournalEntryKindDeposit JournalEntryKind = "deposit"
+	// Money withdrawn from a wallet
+	JournalEntryKindWithdrawal JournalEntryKind = "withdrawal"
+	// The payment of an order, split between the stores and the platform fee
+	JournalEntryKindOrderPayment JournalEntryKind = "order_payment"
+	// The refund of an order return from a store to the customer
+	JournalEntryKindOrderReturnRefund JournalEntryKind = "order_return_refund"
+	// Money sent from a wallet to another one
+	JournalEntryKindTransfer JournalEntryKind = "transfer"
+	// The earnings of a store in an order released from the escrow to the store owner
+	JournalEntryKindEscrowRelease JournalEntryKind = "escrow_release"
+	// The earnings of a store in an order refunded from the escrow to the customer
+	JournalEntryKindEscrowRefund JournalEntryKind = "escrow_refund"
+)
+
+var ValidJournalEntryKinds = []JournalEntryKind{
+	JournalEntryKindOpeningBalance,
+	JournalEntryKindDeposit,
+	JournalEntryKindWithdrawal,
+	JournalEntryKindOrderPayment,
+	JournalEntryKindOrderReturnRefund,
+	JournalEntryKindTransfer,
+	JournalEntryKindEscrowRelease,
+	JournalEntryKindEscrowRefund,
+}
+
+func (k JournalEntryKind) IsValid() bool {
+	return slices.Contains(ValidJournalEntryKinds, k)
+}
+
+func (k JournalEntryKind) String() string {
+	return string(k)
+}
+
+// PaymentIntentStatus defines possible states of a payment collected by a payment provider
+// @model PaymentIntentStatus
+type PaymentIntentStatus string
+
+const (
+	// Payment is waiting for the payer
+	PaymentIntentStatusPending PaymentIntentStatus = "pending"
+	// Payment was collected and applied
+	PaymentIntentStatusSucceeded PaymentIntentStatus = "succeeded"
+	// Payment was declined or abandoned
+	PaymentIntentStatusFailed PaymentIntentStatus = "failed"
+	// Payment was collected but could not be applied, so it was refunded
+	PaymentIntentStatusRefunded PaymentIntentStatus = "refunded"
+)
+
+var ValidPaymentIntentStatuses = []PaymentIntentStatus{
+	PaymentIntentStatusPending,
+	PaymentIntentStatusSucceeded,
+	PaymentIntentStatusFailed,
+	PaymentIntentStatusRefunded,
+}
+
+func (s PaymentIntentStatus) IsValid() bool {
+	return slices.Contains(ValidPaymentIntentStatuses, s)
+}
+
+func (s PaymentIntentStatus) String() string {
+	return string(s)
+}
+
+// PaymentWebhookEventType defines the events that a payment provider sends to the webhook
+// @model PaymentWebhookEventType
+type PaymentWebhookEventType string
+
+const (
+	// The payment was collected
+	PaymentWebhookEventTypeSucceeded PaymentWebhookEventType = "payment.succeeded"
+	// The payment was declined or abandoned
+	PaymentWebhookEventTypeFailed PaymentWebhookEventType = "payment.failed"
+)
+
+var ValidPaymentWebhookEventTypes = []PaymentWebhookEventType{
+	PaymentWebhookEventTypeSucceeded,
+	PaymentWebhookEventTypeFailed,
+}
+
+func (t PaymentWebhookEventType) IsValid() bool {
+	return slices.Contains(ValidPaymentWebhookEventTypes, t)
+}
+
+func (t PaymentWebhookEventType) String() string {
+	return string(t)
+}
+
+// OrderReturnStatus defines possible states of order return requests
+// @model OrderReturnStatus
+type OrderReturnStatus string
+
+const (
+	// Return is waiting for the store review
+	OrderReturnStatusPending OrderReturnStatus = "pending"
+	// Return was approved by the store and the items can be sent back
+	OrderReturnStatusApproved OrderReturnStatus = "approved"
+	// Return was rejected by the store
+	OrderReturnStatusRejected OrderReturnStatus = "rejected"
+	// Returned items were received and the customer has been refunded
+	OrderReturnStatusRefunded OrderReturnStatus = "refunded"
+)
+
+var ValidOrderReturnStatuses = []OrderReturnStatus{
+	OrderReturnStatusPending,
+	OrderReturnStatusApproved,
+	OrderReturnStatusRejected,
+	OrderReturnStatusRefunded,
+}
+
+func (s OrderReturnStatus) IsValid() bool {
+	return slices.Contains(ValidOrderReturnStatuses, s)
+}
+
+func (s OrderReturnStatus) String() string {
+	return string(s)
+}
+
+// CouponDiscountType defines how a coupon discount is calculated
+// @model CouponDiscountType
+type CouponDiscountType string
+
+const (
+	// Discount is a fraction of the eligible products price
+	CouponDiscountTypePercentage CouponDiscountType = "percentage"
+	// Discount is a fixed amount
+	CouponDiscountTypeFixed CouponDiscountType = "fixed"
+)
+
+var ValidCouponDiscountTypes = []CouponDiscountType{
+	CouponDiscountTypePercentage,
+	CouponDiscountTypeFixed,
+}
+
+func (t CouponDiscountType) IsValid() bool {
+	return slices.Contains(ValidCouponDiscountTypes, t)
+}
+
+func (t CouponDiscountType) String() string {
+	return string(t)
+}
+
+// ShippingRateBasis defines what the tiers of a shipping rate table are measured by
+// @model ShippingRateBasis
+type ShippingRateBasis string
+
+const (
+	// Tiers are measured by the number of units
+	ShippingRateBasisQuantity ShippingRateBasis = "quantity"
+	// Tiers are measured by the total shipment factor of the units
+	ShippingRateBasisWeight ShippingRateBasis = "weight"
+)
+
+var ValidShippingRateBases = []ShippingRateBasis{
+	ShippingRateBasisQuantity,
+	ShippingRateBasisWeight,
+}
+
+func (b ShippingRateBasis) IsValid() bool {
+	return slices.Contains(ValidShippingRateBases, b)
+}
+
+func (b ShippingRateBasis) String() string {
+	return string(b)
+}
+
+// TaxPricingMode defines whether a tax is part of the product price or added on top of it
+// @model TaxPricingMode
+type TaxPricingMode string
+
+const (
+	// Tax is included in the product price
+	TaxPricingModeInclusive TaxPricingMode = "inclusive"
+	// Tax is added on top of the product price
+	TaxPricingModeExclusive TaxPricingMode = "exclusive"
+)
+
+var ValidTaxPricingModes = []TaxPricingMode{
+	TaxPricingModeInclusive,
+	TaxPricingModeExclusive,
+}
+
+func (m TaxPricingMode) IsValid() bool {
+	return slices.Contains(ValidTaxPricingModes, m)
+}
+
+func (m TaxPricingMode) String() string {
+	return string(m)
+}
+
 // DefaultRole defines system default role types
 // @model DefaultRole
 type DefaultRole string
@@ -402,3 +775,150 @@ func (r DefaultRole) IsValid() bool {
 func (r DefaultRole) String() string {
 	return string(r)
 }
+
+// EscrowHoldStatus defines possible states of the earnings of a store held in escrow
+// @model EscrowHoldStatus
+type EscrowHoldStatus string
+
+const (
+	// Earnings are held until the shipment is delivered or the release date is reached
+	EscrowHoldStatusHeld EscrowHoldStatus = "held"
+	// Earnings were released to the wallet of the store owner
+	EscrowHoldStatusReleased EscrowHoldStatus = "released"
+	// Earnings were given back to the customer as the shipment was cancelled
+	EscrowHoldStatusRefunded EscrowHoldStatus = "refunded"
+)
+
+var ValidEscrowHoldStatuses = []EscrowHoldStatus{
+	EscrowHoldStatusHeld,
+	EscrowHoldStatusReleased,
+	EscrowHoldStatusRefunded,
+}
+
+func (s EscrowHoldStatus) IsValid() bool {
+	return slices.Contains(ValidEscrowHoldStatuses, s)
+}
+
+func (s EscrowHoldStatus) String() string {
+	return string(s)
+}
+
+// WalletStatementFormat defines the formats that a wallet statement can be downloaded in
+// @model WalletStatementFormat
+type WalletStatementFormat string
+
+const (
+	// Statement as a JSON object
+	WalletStatementFormatJSON WalletStatementFormat = "json"
+	// Statement as a CSV file
+	WalletStatementFormatCSV WalletStatementFormat = "csv"
+	// Statement as a PDF document
+	WalletStatementFormatPDF WalletStatementFormat = "pdf"
+)
+
+var ValidWalletStatementFormats = []WalletStatementFormat{
+	WalletStatementFormatJSON,
+	WalletStatementFormatCSV,
+	WalletStatementFormatPDF,
+}
+
+func (f WalletStatementFormat) IsValid() bool {
+	return slices.Contains(ValidWalletStatementFormats, f)
+}
+
+func (f WalletStatementFormat) String() string {
+	return string(f)
+}
+
+// ProductSort defines the orders that the products can be listed in
+// @model ProductSort
+type ProductSort string
+
+const (
+	// Best matches of the search keyword first, only used with a keyword
+	ProductSortRelevance ProductSort = "relevance"
+	// Lowest current price first
+	ProductSortPriceAsc ProductSort = "price_asc"
+	// Highest current price first
+	ProductSortPriceDesc ProductSort = "price_desc"
+	// Most recently created first
+	ProductSortNewest ProductSort = "newest"
+	// Highest average score first
+	ProductSortTopRated ProductSort = "top_rated"
+	// Most sold quantity in the paid orders first
+	ProductSortBestSelling ProductSort = "best_selling"
+	// Biggest active offer discount first
+	ProductSortBiggestDiscount ProductSort = "biggest_discount"
+)
+
+var ValidProductSorts = []ProductSort{
+	ProductSortRelevance,
+	ProductSortPriceAsc,
+	ProductSortPriceDesc,
+	ProductSortNewest,
+	ProductSortTopRated,
+	ProductSortBestSelling,
+	ProductSortBiggestDiscount,
+}
+
+func (s ProductSort) IsValid() bool {
+	return slices.Contains(ValidProductSorts, s)
+}
+
+func (s ProductSort) String() string {
+	return string(s)
+}
+
+// ProductImportFormat defines the file formats that the products of a store can be imported from and exported to
+// @model ProductImportFormat
+type ProductImportFormat string
+
+const (
+	// A CSV file with a header row and a row for each product
+	ProductImportFormatCSV ProductImportFormat = "csv"
+	// A JSON object on each line for each product
+	ProductImportFormatJSONL ProductImportFormat = "jsonl"
+)
+
+var ValidProductImportFormats = []ProductImportFormat{
+	ProductImportFormatCSV,
+	ProductImportFormatJSONL,
+}
+
+func (f ProductImportFormat) IsValid() bool {
+	return slices.Contains(ValidProductImportFormats, f)
+}
+
+func (f ProductImportFormat) String() string {
+	return string(f)
+}
+
+// ProductImportStatus defines the possible states of a product import
+// @model ProductImportStatus
+type ProductImportStatus string
+
+const (
+	// Import is waiting to be picked up
+	ProductImportStatusPending ProductImportStatus = "pending"
+	// Rows of the import are being applied
+	ProductImportStatusRunning ProductImportStatus = "running"
+	// Every row of the import was processed, some may have failed
+	ProductImportStatusCompleted ProductImportStatus = "completed"
+	// Import could not be read
+	ProductImportStatusFailed ProductImportStatus = "failed"
+)
+
+var ValidProductImportStatuses = []ProductImportStatus{
+	ProductImportStatusPending,
+	ProductImportStatusRunning,
+	ProductImportStatusCompleted,
+	ProductImportStatusFailed,
+}
+
+func (s ProductImportStatus) IsValid() bool {
+	return slices.Contains(ValidProductImportStatuses, s)
+}
+
+func (s ProductImportStatus) String() string {
+	return string(s)
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
@@ -32,6 +33,23 @@ var (
 	ErrStoreSettingsNotFound          = errors.New("store settings not found")
 	ErrStoreOwnerNotFound             = errors.New("store owner not found")
 	ErrOrderNotFound                  = errors.New("order not found")
+	ErrOrderShipmentNotFound          = errors.New("order shipment not found")
+	ErrOrderReturnNotFound            = errors.New("order return not found")
+	ErrCartNotFound                   = errors.New("cart not found")
+	ErrCartItemNotFound               = errors.New("cart item not found")
+	ErrCouponNotFound                 = errors.New("coupon not found")
+	ErrShippingZoneNotFound           = errors.New("shipping zone not found")
+	ErrShippingRateTableNotFound      = errors.New("shipping rate table not found")
+	ErrShippingTransitTimeNotFound    = errors.New("shipping transit time not found")
+	ErrTaxRuleNotFound                = errors.New("tax rule not found")
+	ErrInvoiceNotFound                = errors.New("invoice not found")
+	ErrCommissionRuleNotFound         = errors.New("commission rule not found")
+	ErrPlatformWalletNotFound         = errors.New("platform wallet not found")
+	ErrLedgerAccountNotFound          = errors.New("ledger account not found")
+	ErrPaymentIntentNotFound          = errors.New("payment intent not found")
+	ErrWithdrawalTierNotFound         = errors.New("withdrawal tier not found")
+	ErrWalletDriftNotFound            = errors.New("wallet drift not found")
+	ErrProductImportNotFound          = errors.New("product import not found")
 	ErrForeignKeyViolationForColumn   = errors.New(
 		"invalid reference: a related record does not exist",
 	)
@@ -54,6 +72,16 @@ var (
 	ErrInvalidRefreshToken = errors.New("refresh token is invalid")
 	ErrTokenIsMissing      = errors.New("token is missing")
 
+	ErrInvalidIdempotencyKey = errors.New(
+		"idempotency key must be at most 255 characters",
+	)
+	ErrIdempotencyKeyReused = errors.New(
+		"idempotency key is already used for a different request",
+	)
+	ErrIdempotentRequestInProgress = errors.New(
+		"a request with the same idempotency key is in progress",
+	)
+
 	ErrInvalidOptionId  = errors.New("invalid option id")
 	ErrInvalidPageQuery = errors.New("invalid page")
 
@@ -63,7 +91,174 @@ var (
 		)
 	}
 	ErrProductVariantsAreEmpty = errors.New("product variants are empty")
+	ErrCartIsEmpty             = errors.New("cart is empty")
+	ErrInvalidCartItemQuantity = errors.New("cart item quantity must be at least 1")
 	ErrBalanceInsufficient     = errors.New("insufficient wallet balance")
+	ErrUnbalancedJournalEntry  = errors.New("journal entry postings do not add up to zero")
+	ErrInvalidMoneyAmount      = errors.New("invalid money amount")
+	ErrWalletFrozen            = errors.New(
+		"the wallet is frozen until its balance is reviewed by an admin",
+	)
+	ErrWalletDriftAlreadyResolved = errors.New("wallet drift is already resolved")
+
+	ErrProductImportIsEmpty  = errors.New("product import has no rows")
+	ErrProductImportTooLarge = func(maxRows int) error {
+		return errors.New(
+			fmt.Sprintf("a product import cannot have more than %d rows", maxRows),
+		)
+	}
+	ErrProductSlugOwnedByAnotherStore = errors.New(
+		"the slug is used by a product of another store",
+	)
+	ErrInvalidProductSlug = errors.New(
+		"slug can only have lowercase letters, digits, dashes and underscores",
+	)
+	ErrDuplicateProductImportSlug = errors.New(
+		"the slug is used by an earlier row of the import",
+	)
+	ErrNegativeProductPrice      = errors.New("product price cannot be negative")
+	ErrNegativeVariantQuantity   = errors.New("variant quantity cannot be negative")
+	ErrDuplicateVariantAttribute = errors.New(
+		"a variant cannot have more than one option of the same attribute",
+	)
+	ErrAttributeOptionMismatch = errors.New(
+		"the option does not belong to the attribute",
+	)
+	ErrImportColumnMissing = func(column string) error {
+		return errors.New(fmt.Sprintf("column %s is missing", column))
+	}
+	ErrInvalidImportColumn = func(column string, err error) error {
+		return errors.New(fmt.Sprintf("invalid value for column %s: %v", column, err))
+	}
+
+	ErrUnknownPaymentProvider        = errors.New("unknown payment provider")
+	ErrFakePaymentProviderNotAllowed = errors.New(
+		"the fake payment provider can only be used in a development or test environment",
+	)
+	ErrPaymentProviderNotConfigured = errors.New(
+		"payments are not available because no payment provider is configured",
+	)
+	ErrInvalidWebhookSignature   = errors.New("webhook signature is missing or invalid")
+	ErrPaymentIntentNotPending   = errors.New("payment intent is already settled")
+	ErrPaymentVerificationFailed = errors.New(
+		"payment could not be verified with the payment provider",
+	)
+	ErrPaymentIntentCannotBeApplied = errors.New(
+		"payment can no longer be applied to its wallet transaction or order",
+	)
+	ErrOrderPaymentIsNotPending = errors.New("order payment is not pending")
+
+	ErrCannotTransferToSameWallet  = errors.New("cannot transfer money to the same wallet")
+	ErrTransferAmountLimitExceeded = func(limit Money) error {
+		return errors.New(
+			fmt.Sprintf("a single transfer cannot be more than %s", limit),
+		)
+	}
+	ErrTransferDailyLimitExceeded = func(limit Money) error {
+		return errors.New(
+			fmt.Sprintf("transfers of a day cannot add up to more than %s", limit),
+		)
+	}
+	ErrTransferDailyCountExceeded = func(limit int) error {
+		return errors.New(
+			fmt.Sprintf("cannot make more than %d transfers in a day", limit),
+		)
+	}
+
+	ErrWithdrawAmountBelowMinimum = func(min Money) error {
+		return errors.New(
+			fmt.Sprintf("a withdrawal must be at least %s", min),
+		)
+	}
+	ErrWithdrawDailyLimitExceeded = func(limit Money) error {
+		return errors.New(
+			fmt.Sprintf("withdrawals of a day cannot add up to more than %s", limit),
+		)
+	}
+	ErrWithdrawMonthlyLimitExceeded = func(limit Money) error {
+		return errors.New(
+			fmt.Sprintf("withdrawals of a month cannot add up to more than %s", limit),
+		)
+	}
+	ErrWithdrawCooldownActive = func(until time.Time) error {
+		return errors.New(
+			fmt.Sprintf(
+				"withdrawals are blocked after a password or email change until %s",
+				until.Format(time.RFC3339),
+			),
+		)
+	}
+	ErrCannotDeleteDefaultWithdrawalTier = errors.New("the default withdrawal tier cannot be deleted")
+	ErrCannotDeleteDefaultCommissionRule = errors.New("the default commission rule cannot be deleted")
+	ErrDefaultWithdrawalTierRequired     = errors.New(
+		"the default withdrawal tier can only be replaced by making another tier the default",
+	)
+	ErrInvalidWithdrawalTierLimit = errors.New(
+		"withdrawal tier amounts and cooldown cannot be negative",
+	)
+	ErrInvalidWalletStatementPeriod = errors.New(
+		"the start of a wallet statement must be before its end",
+	)
+	ErrWalletStatementPeriodTooLong = func(days int) error {
+		return errors.New(
+			fmt.Sprintf("a wallet statement cannot cover more than %d days", days),
+		)
+	}
+
+	ErrOrderReturnItemsAreEmpty      = errors.New("order return items are empty")
+	ErrOrderPaymentIsNotSuccessful   = errors.New("order payment is not successful")
+	ErrInvalidOrderPaymentTransition = errors.New(
+		"order payment status cannot be changed to the requested status",
+	)
+	ErrInvalidOrderShipmentTransition = errors.New(
+		"order shipment status cannot be changed to the requested status",
+	)
+	ErrOrderReturnItemNotInOrder      = errors.New("order return item is not part of the order")
+	ErrOrderReturnItemsFromManyStores = errors.New(
+		"all the items of a return must be sold by the same store",
+	)
+	ErrOrderReturnQuantityExceeded = func(orderProductVariantId int) error {
+		return errors.New(
+			fmt.Sprintf(
+				"return quantity for order item %d exceeds the remaining ordered quantity",
+				orderProductVariantId,
+			),
+		)
+	}
+	ErrInvalidOrderReturnStatusTransition = func(from OrderReturnStatus, to OrderReturnStatus) error {
+		return errors.New(
+			fmt.Sprintf("cannot change return status from %s to %s", from, to),
+		)
+	}
+
+	ErrCouponIsNotActive       = errors.New("coupon is not active")
+	ErrCouponUsageLimitReached = errors.New("coupon usage limit has been reached")
+	ErrCouponNotApplicable     = errors.New("coupon is not applicable to any of the products")
+	ErrCouponIsUsedByOrders    = errors.New("coupon is used by orders and cannot be deleted")
+	ErrCouponMinBasketNotMet   = func(minBasket Money) error {
+		return errors.New(
+			fmt.Sprintf("coupon requires a minimum basket of %s", minBasket),
+		)
+	}
+	ErrInvalidCouponAmount         = errors.New("invalid coupon amount")
+	ErrInvalidCouponRate           = errors.New("invalid coupon rate")
+	ErrInvalidCouponValidityWindow = errors.New("coupon must expire after it starts")
+
+	ErrShippingRateTiersAreEmpty = errors.New("shipping rate table must have at least one tier")
+	ErrInvalidShippingRateTiers  = errors.New(
+		"shipping rate tiers must have valid and non-overlapping ranges",
+	)
+	ErrStoreDoesNotShipToAddress = func(storeId int) error {
+		return errors.New(
+			fmt.Sprintf("store %d does not ship this order to the receiver address", storeId),
+		)
+	}
+	ErrInvalidShippingTransitDays = errors.New("shipping transit days cannot be negative")
+	ErrInvalidStoreHandlingDays   = errors.New("store handling days cannot be negative")
+
+	ErrInvalidTaxRate = errors.New("tax rate must be between 0 and 1")
+
+	ErrInvalidCommissionRate = errors.New("commission rate must be between 0 and 1")
 
 	ErrInvalidCredentials  = errors.New("invalid credentials received")
 	ErrInvalidPayload      = errors.New("invalid payload received")
@@ -96,20 +291,58 @@ var (
 	ErrDuplicateProductSlug = errors.New(
 		"another product with this slug already exists",
 	)
+	ErrDuplicateCouponCode = errors.New(
+		"another coupon with this code already exists",
+	)
+	ErrDuplicateShippingZoneName = errors.New(
+		"another shipping zone with this name already exists",
+	)
+	ErrDuplicateShippingZoneLocation = errors.New(
+		"another shipping zone with this state and city already exists",
+	)
+	ErrDuplicateShippingRateTable = errors.New(
+		"the store already has a shipping rate table for this zone",
+	)
+	ErrDuplicateShippingTransitTime = errors.New(
+		"another transit time between these zones already exists",
+	)
+	ErrDuplicateTaxRule = errors.New(
+		"the zone already has a tax rule for this category",
+	)
+	ErrDuplicateCommissionRule = errors.New(
+		"a commission rule for this store and category already exists",
+	)
+	ErrDuplicateWithdrawalTierName = errors.New(
+		"another withdrawal tier with this name already exists",
+	)
 	ErrUniqueConstraintViolation          = errors.New("a unique constraint has been violated")
 	ErrUniqueConstraintViolationForColumn = func(col string) error {
 		return errors.New(fmt.Sprintf("the value for '%s' must be unique.", col))
 	}
 
-	ErrInvalidActionEnum               = errors.New("invalid action specified")
-	ErrInvalidResourceEnum             = errors.New("invalid resource specified")
-	ErrInvalidTransactionTypeEnum      = errors.New("invalid transaction type specified")
-	ErrInvalidTransactionStatusEnum    = errors.New("invalid transaction status specified")
-	ErrInvalidOrderPaymentStatusEnum   = errors.New("invalid order payment status specified")
-	ErrInvalidOrderShipmentStatusEnum  = errors.New("invalid order shipment status specified")
-	ErrInvalidVisibilityStatusOption   = errors.New("invalid visibility status option")
-	ErrInvalidVerificationStatusOption = errors.New("invalid verification status option")
-	ErrInvalidInputFormat              = errors.New("invalid input format")
+	ErrInvalidActionEnum                = errors.New("invalid action specified")
+	ErrInvalidResourceEnum              = errors.New("invalid resource specified")
+	ErrInvalidTransactionTypeEnum       = errors.New("invalid transaction type specified")
+	ErrInvalidTransactionStatusEnum     = errors.New("invalid transaction status specified")
+	ErrInvalidOrderPaymentStatusEnum    = errors.New("invalid order payment status specified")
+	ErrInvalidOrderShipmentStatusEnum   = errors.New("invalid order shipment status specified")
+	ErrInvalidOrderReturnStatusEnum     = errors.New("invalid order return status specified")
+	ErrInvalidCouponDiscountTypeEnum    = errors.New("invalid coupon discount type specified")
+	ErrInvalidShippingRateBasisEnum     = errors.New("invalid shipping rate basis specified")
+	ErrInvalidTaxPricingModeEnum        = errors.New("invalid tax pricing mode specified")
+	ErrInvalidWalletStatementFormatEnum = errors.New("invalid wallet statement format specified")
+	ErrInvalidProductSortEnum           = errors.New("invalid product sort specified")
+	ErrInvalidProductImportFormatEnum   = errors.New("invalid product import format specified")
+	ErrInvalidProductImportStatusEnum   = errors.New("invalid product import status specified")
+	ErrInvalidCursor                    = errors.New("invalid cursor")
+	ErrInvalidVisibilityStatusOption    = errors.New("invalid visibility status option")
+	ErrInvalidVerificationStatusOption  = errors.New("invalid verification status option")
+	ErrInvalidInputFormat               = errors.New("invalid input format")
+
+	ErrInvalidPaymentIntentStatusEnum     = errors.New("invalid payment intent status specified")
+	ErrInvalidPaymentWebhookEventTypeEnum = errors.New(
+		"invalid payment webhook event type specified",
+	)
 
 	ErrNoFieldsReceivedToUpdate = errors.New("no fields received to update")
 
@@ -119,6 +352,7 @@ var (
 	ErrCannotBanThisUser             = errors.New("you cannot ban this user")
 	ErrCannotAccessStore             = errors.New("you cannot access this store")
 	ErrCannotAccessOrder             = errors.New("you cannot access this order")
+	ErrCannotAccessOrderReturn       = errors.New("you cannot access this order return")
 	ErrCannotAccessComment           = errors.New("you cannot access this comment")
 	ErrTransactionIsNotForWallet     = errors.New(
 		"this transaction is not for the provided user wallet",
@@ -14,6 +14,26 @@ type NewWalletTransactionResponse struct {
 	TxId int `json:"txId"`
 }
 
+// NewWalletTransferResponse contains the new transfer id
+// @model NewWalletTransferResponse
+type NewWalletTransferResponse struct {
+	// New transfer id
+	TransferId int `json:"transferId"`
+}
+
+// NewPaymentResponse contains the new payment intent with the url that the payer is sent to
+// @model NewPaymentResponse
+type NewPaymentResponse struct {
+	// New deposit transaction id
+	TxId int `json:"txId"`
+	// New payment intent id
+	PaymentIntentId int `json:"paymentIntentId"`
+	// Reference of the payment at the payment provider
+	Reference string `json:"reference"`
+	// URL that the payer is sent to for paying
+	CheckoutUrl string `json:"checkoutUrl"`
+}
+
 // NewUserResponse contains the new user id
 // @model NewUserResponse
 type NewUserResponse struct {
@@ -63,6 +83,13 @@ type NewProductResponse struct {
 	ProductId int `json:"productId"`
 }
 
+// NewProductImportResponse contains the id of the queued product import
+// @model NewProductImportResponse
+type NewProductImportResponse struct {
+	// Queued product import id
+	ImportId int `json:"importId"`
+}
+
 // NewProductOfferResponse contains the new product offer id
 // @model NewProductOfferResponse
 type NewProductOfferResponse struct {
@@ -105,6 +132,27 @@ type NewOrderResponse struct {
 	OrderId int `json:"orderId"`
 }
 
+// NewOrderReturnResponse contains the new order return id
+// @model NewOrderReturnResponse
+type NewOrderReturnResponse struct {
+	// New order return id
+	ReturnId int `json:"returnId"`
+}
+
+// NewCouponResponse contains the new coupon id
+// @model NewCouponResponse
+type NewCouponResponse struct {
+	// New coupon id
+	CouponId int `json:"couponId"`
+}
+
+// NewCartItemResponse contains the new cart item id
+// @model NewCartItemResponse
+type NewCartItemResponse struct {
+	// New cart item id
+	ItemId int `json:"itemId"`
+}
+
 // TotalPageCountResponse contains the total pages of a list
 // @model TotalPageCountResponse
 type TotalPageCountResponse struct {
@@ -124,6 +172,52 @@ type FileUploadResponse struct {
 type ProductInventoryResponse struct {
 	// Total quantity of the product
 	Total int `json:"total"`
-	// If the quantity is greater than 0
+	// Quantity held by pending orders
+	Reserved int `json:"reserved"`
+	// Quantity that can be ordered
+	Available int `json:"available"`
+	// If the available quantity is greater than 0
 	InStock bool `json:"inStock"`
 }
+
+// NewShippingZoneResponse contains the new shipping zone id
+// @model NewShippingZoneResponse
+type NewShippingZoneResponse struct {
+	// New shipping zone id
+	ZoneId int `json:"zoneId"`
+}
+
+// NewShippingRateTableResponse contains the new shipping rate table id
+// @model NewShippingRateTableResponse
+type NewShippingRateTableResponse struct {
+	// New shipping rate table id
+	RateTableId int `json:"rateTableId"`
+}
+
+// NewShippingTransitTimeResponse contains the new shipping transit time id
+// @model NewShippingTransitTimeResponse
+type NewShippingTransitTimeResponse struct {
+	// New shipping transit time id
+	TransitTimeId int `json:"transitTimeId"`
+}
+
+// NewTaxRuleResponse contains the new tax rule id
+// @model NewTaxRuleResponse
+type NewTaxRuleResponse struct {
+	// New tax rule id
+	TaxRuleId int `json:"taxRuleId"`
+}
+
+// NewCommissionRuleResponse contains the new commission rule id
+// @model NewCommissionRuleResponse
+type NewCommissionRuleResponse struct {
+	// New commission rule id
+	CommissionRuleId int `json:"commissionRuleId"`
+}
+
+// NewWithdrawalTierResponse contains the new withdrawal tier id
+// @model NewWithdrawalTierResponse
+type NewWithdrawalTierResponse struct {
+	// New withdrawal tier id
+	WithdrawalTierId int `json:"withdrawalTierId"`
+}
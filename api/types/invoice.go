@@ -0,0 +1,135 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// Invoice represents the invoice issued for an order once its payment succeeds
+// @model Invoice
+type Invoice struct {
+	// Unique identifier for the invoice (private, needs permission)
+	Id int `json:"id"        exposure:"private,needPermission"`
+	// Sequential number of the invoice (private, needs permission)
+	Number int `json:"number"    exposure:"private,needPermission"`
+	// Content of the invoice at the time it was issued (private, needs permission)
+	Snapshot InvoiceSnapshot `json:"snapshot"  exposure:"private,needPermission"`
+	// When the invoice was issued (private, needs permission)
+	CreatedAt time.Time `json:"createdAt" exposure:"private,needPermission"`
+	// ID of the invoiced order, null if the order is deleted (private, needs permission)
+	OrderId json_types.JSONNullInt32 `json:"orderId"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// InvoiceAddress represents an address printed on an invoice
+// @model InvoiceAddress
+type InvoiceAddress struct {
+	// State/Province (private, needs permission)
+	State string `json:"state"   exposure:"private,needPermission"`
+	// City (private, needs permission)
+	City string `json:"city"    exposure:"private,needPermission"`
+	// Street address (private, needs permission)
+	Street string `json:"street"  exposure:"private,needPermission"`
+	// Zip/Postal code (private, needs permission)
+	Zipcode string `json:"zipcode" exposure:"private,needPermission"`
+	// Additional address details (private, needs permission)
+	Details string `json:"details" exposure:"private,needPermission"`
+}
+
+// InvoiceBuyer represents the customer an invoice is issued to
+// @model InvoiceBuyer
+type InvoiceBuyer struct {
+	// ID of the customer (private, needs permission)
+	UserId int `json:"userId"   exposure:"private,needPermission"`
+	// Full name of the customer, or the username if it is not set (private, needs permission)
+	Name string `json:"name"     exposure:"private,needPermission"`
+	// Email address of the customer (private, needs permission)
+	Email string `json:"email"    exposure:"private,needPermission"`
+	// Receiver address of the order (private, needs permission)
+	Address InvoiceAddress `json:"address"  exposure:"private,needPermission"`
+}
+
+// InvoiceStore represents a store that sold products of an invoiced order
+// @model InvoiceStore
+type InvoiceStore struct {
+	// ID of the store (private, needs permission)
+	StoreId int `json:"storeId"            exposure:"private,needPermission"`
+	// Name of the store (private, needs permission)
+	Name string `json:"name"               exposure:"private,needPermission"`
+	// Address of the store, empty if the store has no address (private, needs permission)
+	Address InvoiceAddress `json:"address"            exposure:"private,needPermission"`
+	// Total price of the store's product variants (private, needs permission)
+	TotalVariantsPrice Money `json:"totalVariantsPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total shipping cost of the store's product variants (private, needs permission)
+	TotalShipmentPrice Money `json:"totalShipmentPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total coupon discount on the store's product variants (private, needs permission)
+	TotalDiscount Money `json:"totalDiscount"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total tax on the store's product variants (private, needs permission)
+	TotalTax Money `json:"totalTax"           exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Tax added on top of the prices of the store's product variants (private, needs permission)
+	ExclusiveTax Money `json:"exclusiveTax"       exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// InvoiceLine represents an ordered product variant printed on an invoice
+// @model InvoiceLine
+type InvoiceLine struct {
+	// ID of the order line (private, needs permission)
+	OrderProductVariantId int `json:"orderProductVariantId" exposure:"private,needPermission"`
+	// ID of the store selling the variant (private, needs permission)
+	StoreId int `json:"storeId"               exposure:"private,needPermission"`
+	// ID of the product (private, needs permission)
+	ProductId int `json:"productId"             exposure:"private,needPermission"`
+	// Name of the product (private, needs permission)
+	ProductName string `json:"productName"           exposure:"private,needPermission"`
+	// ID of the product variant (private, needs permission)
+	VariantId int `json:"variantId"             exposure:"private,needPermission"`
+	// Selected attribute options of the variant, such as "Color: Red, Size: M" (private, needs permission)
+	Attributes string `json:"attributes"            exposure:"private,needPermission"`
+	// Quantity ordered (private, needs permission)
+	Quantity int `json:"quantity"              exposure:"private,needPermission"`
+	// Price per unit of the variant (private, needs permission)
+	UnitPrice Money `json:"unitPrice"             exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Shipping cost of the line (private, needs permission)
+	ShippingPrice Money `json:"shippingPrice"         exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Coupon discount on the line (private, needs permission)
+	Discount Money `json:"discount"              exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Tax on the line (private, needs permission)
+	Tax Money `json:"tax"                   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Rate of the tax on the line (private, needs permission)
+	TaxRate float64 `json:"taxRate"               exposure:"private,needPermission"`
+	// Pricing mode of the tax on the line, empty if no tax rule applied (private, needs permission)
+	TaxPricingMode string `json:"taxPricingMode"        exposure:"private,needPermission"`
+	// Price of the line after the discount with the exclusive tax, without shipping (private, needs permission)
+	Total Money `json:"total"                 exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// InvoiceSnapshot represents the content of an invoice that is kept unchanged after it is issued
+// @model InvoiceSnapshot
+type InvoiceSnapshot struct {
+	// ID of the invoiced order (private, needs permission)
+	OrderId int `json:"orderId"            exposure:"private,needPermission"`
+	// When the order was placed (private, needs permission)
+	OrderedAt time.Time `json:"orderedAt"          exposure:"private,needPermission"`
+	// When the payment of the order succeeded (private, needs permission)
+	PaidAt time.Time `json:"paidAt"             exposure:"private,needPermission"`
+	// Customer of the order (private, needs permission)
+	Buyer InvoiceBuyer `json:"buyer"              exposure:"private,needPermission"`
+	// Stores that sold products of the order (private, needs permission)
+	Stores []InvoiceStore `json:"stores"             exposure:"private,needPermission"`
+	// Ordered product variants (private, needs permission)
+	Lines []InvoiceLine `json:"lines"              exposure:"private,needPermission"`
+	// Total price of all product variants (private, needs permission)
+	TotalVariantsPrice Money `json:"totalVariantsPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total shipping cost (private, needs permission)
+	TotalShipmentPrice Money `json:"totalShipmentPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Fee of the order (private, needs permission)
+	Fee Money `json:"fee"                exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Discount applied by the coupon (private, needs permission)
+	Discount Money `json:"discount"           exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total tax, including the tax that is part of the prices (private, needs permission)
+	TotalTax Money `json:"totalTax"           exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Tax added on top of the prices (private, needs permission)
+	ExclusiveTax Money `json:"exclusiveTax"       exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Amount paid by the customer (private, needs permission)
+	GrandTotal Money `json:"grandTotal"         exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
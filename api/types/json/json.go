@@ -48,3 +48,14 @@ func (nt JSONNullInt64) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(nt.Int64)
 }
+
+type JSONNullFloat64 struct {
+	sql.NullFloat64
+}
+
+func (nf JSONNullFloat64) MarshalJSON() ([]byte, error) {
+	if !nf.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(nf.Float64)
+}
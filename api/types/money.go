@@ -0,0 +1,365 @@
+package types
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// moneyDecimals is the number of the decimals of the minor units
+	moneyDecimals = 2
+	// moneyScale is the number of minor units in a major unit of the currency
+	moneyScale = 100
+)
+
+// Money is an exact amount of money kept as an integer number of the minor
+// units (cents) of the currency, so sums and differences of amounts never
+// drift. It is stored as NUMERIC(19, 2) in the database and encoded as a
+// JSON number with two decimals, the same way as the plain numbers that
+// were used for the amounts before.
+// @model Money
+type Money struct {
+	minor int64
+}
+
+// NullMoney is a Money that may be null
+// @model NullMoney
+type NullMoney struct {
+	Money Money
+	Valid bool
+}
+
+// MoneyFromMinor returns an amount of the given minor units.
+func MoneyFromMinor(minor int64) Money {
+	return Money{minor: minor}
+}
+
+// MoneyFromFloat returns the amount nearest to a float, the halves are
+// rounded away from zero.
+func MoneyFromFloat(f float64) Money {
+	return Money{minor: int64(math.Round(f * moneyScale))}
+}
+
+// ParseMoney parses a decimal amount such as "-12.5" or "3.456" exactly, the
+// digits after the minor units are rounded half away from zero.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, ErrInvalidMoneyAmount
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	integer, fraction, _ := strings.Cut(s, ".")
+	if integer == "" && fraction == "" {
+		return Money{}, ErrInvalidMoneyAmount
+	}
+
+	for _, r := range integer + fraction {
+		if r < '0' || r > '9' {
+			// the exponent notation of the floats is parsed as a float
+			if s[0] == '-' || s[0] == '+' {
+				return Money{}, ErrInvalidMoneyAmount
+			}
+
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+				return Money{}, ErrInvalidMoneyAmount
+			}
+
+			// float64(math.MaxInt64) is rounded up to 2^63, which is already
+			// out of the range
+			scaled := math.Round(f * moneyScale)
+			if scaled >= math.MaxInt64 {
+				return Money{}, ErrInvalidMoneyAmount
+			}
+
+			m := Money{minor: int64(scaled)}
+			if negative {
+				m = m.Neg()
+			}
+			return m, nil
+		}
+	}
+
+	if integer == "" {
+		integer = "0"
+	}
+
+	major, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil || major > math.MaxInt64/moneyScale {
+		return Money{}, ErrInvalidMoneyAmount
+	}
+
+	minor := major * moneyScale
+	fractionMinor := fractionToMinor(fraction)
+	if fractionMinor > math.MaxInt64-minor {
+		return Money{}, ErrInvalidMoneyAmount
+	}
+	minor += fractionMinor
+
+	if negative {
+		minor = -minor
+	}
+
+	return Money{minor: minor}, nil
+}
+
+// fractionToMinor returns the minor units of the digits after the decimal
+// point, rounding the rest of the digits half away from zero.
+func fractionToMinor(fraction string) int64 {
+	digits := fraction + strings.Repeat("0", moneyDecimals)
+
+	var minor int64 = 0
+	for _, d := range digits[:moneyDecimals] {
+		minor = minor*10 + int64(d-'0')
+	}
+
+	if len(fraction) > moneyDecimals && fraction[moneyDecimals] >= '5' {
+		minor++
+	}
+
+	return minor
+}
+
+// Minor returns the amount in the minor units of the currency.
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Float64 returns the amount as a float, it is only meant for displaying
+// and for the computations that are rounded back to an amount.
+func (m Money) Float64() float64 {
+	return float64(m.minor) / moneyScale
+}
+
+// String returns the amount with two decimals, such as "-12.50".
+func (m Money) String() string {
+	sign := ""
+	minor := m.minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, minor/moneyScale, minor%moneyScale)
+}
+
+func (m Money) Add(o Money) Money {
+	return Money{minor: m.minor + o.minor}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{minor: m.minor - o.minor}
+}
+
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor}
+}
+
+// Mul returns the amount multiplied by a quantity.
+func (m Money) Mul(quantity int) Money {
+	return Money{minor: m.minor * int64(quantity)}
+}
+
+// MulRate returns the amount multiplied by a rate such as a tax rate or a
+// weight, rounded to the nearest minor unit.
+func (m Money) MulRate(rate float64) Money {
+	return Money{minor: int64(math.Round(float64(m.minor) * rate))}
+}
+
+// Allocate splits the amount between parts in proportion to their weights,
+// the parts add up to the amount exactly. The minor units that are left
+// over by the rounding go to the parts with the largest remainders. If all
+// of the weights are zero, the amount is split equally.
+func (m Money) Allocate(weights []float64) []Money {
+	parts := make([]Money, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+
+	var total float64 = 0
+	for _, w := range weights {
+		total += w
+	}
+
+	remainders := make([]float64, len(weights))
+	var allocated int64 = 0
+	for i, w := range weights {
+		share := float64(m.minor) / float64(len(weights))
+		if total != 0 {
+			share = float64(m.minor) * w / total
+		}
+
+		parts[i] = Money{minor: int64(math.Floor(share))}
+		remainders[i] = share - math.Floor(share)
+		allocated += parts[i].minor
+	}
+
+	for left := m.minor - allocated; left > 0; left-- {
+		largest := 0
+		for i := range remainders {
+			if remainders[i] > remainders[largest] {
+				largest = i
+			}
+		}
+
+		parts[largest].minor++
+		remainders[largest] = -1
+	}
+
+	return parts
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+func (m Money) LessThan(o Money) bool {
+	return m.minor < o.minor
+}
+
+func (m Money) GreaterThan(o Money) bool {
+	return m.minor > o.minor
+}
+
+// MinMoney returns the smaller of two amounts.
+func MinMoney(a Money, b Money) Money {
+	if a.LessThan(b) {
+		return a
+	}
+
+	return b
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads an amount from a JSON number or a string, null leaves
+// the amount unchanged.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		err := json.Unmarshal(data, &s)
+		if err != nil {
+			return ErrInvalidMoneyAmount
+		}
+	} else {
+		var n json.Number
+		err := json.Unmarshal(data, &n)
+		if err != nil {
+			return ErrInvalidMoneyAmount
+		}
+		s = n.String()
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+func (m *Money) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*m = Money{}
+	case []byte:
+		parsed, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case float64:
+		*m = MoneyFromFloat(v)
+	case int64:
+		*m = Money{minor: v * moneyScale}
+	default:
+		return fmt.Errorf("cannot scan %T into money", value)
+	}
+
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (n NullMoney) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return n.Money.MarshalJSON()
+}
+
+func (n *NullMoney) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		n.Money, n.Valid = Money{}, false
+		return nil
+	}
+
+	err := n.Money.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+func (n *NullMoney) Scan(value any) error {
+	if value == nil {
+		n.Money, n.Valid = Money{}, false
+		return nil
+	}
+
+	err := n.Money.Scan(value)
+	if err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+func (n NullMoney) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	return n.Money.Value()
+}
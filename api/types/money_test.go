@@ -0,0 +1,139 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]int64{
+		"0":       0,
+		"12":      1200,
+		"12.5":    1250,
+		"-12.50":  -1250,
+		".99":     99,
+		"3.456":   346,
+		"-3.455":  -346,
+		"0.1":     10,
+		"1e2":     10000,
+		" 19.99 ": 1999,
+
+		"92233720368547758.07": 9223372036854775807,
+	}
+
+	for s, expected := range cases {
+		m, err := ParseMoney(s)
+		if err != nil {
+			t.Errorf("There was an error on parsing the amount %q: %v", s, err)
+			continue
+		}
+
+		if m.Minor() != expected {
+			t.Errorf("Expected %q to be parsed as %d minor units, got %d", s, expected, m.Minor())
+		}
+	}
+
+	invalid := []string{
+		"",
+		"-",
+		".",
+		"abc",
+		"1.2.3",
+		"Inf",
+		"-Inf",
+		"NaN",
+		"1e30",
+		"-1e30",
+		"--5",
+		"92233720368547758.08",
+		"92233720368547758.99",
+		"92233720368547759",
+	}
+
+	for _, s := range invalid {
+		_, err := ParseMoney(s)
+		if err != ErrInvalidMoneyAmount {
+			t.Errorf("Expected an invalid amount error on parsing %q, got %v", s, err)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	var sum Money
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(MoneyFromFloat(0.1))
+	}
+
+	if sum != MoneyFromFloat(1) {
+		t.Errorf("Expected ten times 0.10 to be 1.00, got %s", sum)
+	}
+
+	if s := MoneyFromFloat(-0.001).String(); s != "0.00" {
+		t.Errorf("Expected a rounded zero to be printed as 0.00, got %s", s)
+	}
+
+	if s := MoneyFromFloat(-5.5).String(); s != "-5.50" {
+		t.Errorf("Expected -5.5 to be printed as -5.50, got %s", s)
+	}
+
+	if m := MoneyFromFloat(10).MulRate(0.075); m != MoneyFromMinor(75) {
+		t.Errorf("Expected 7.5%% of 10.00 to be 0.75, got %s", m)
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	parts := MoneyFromFloat(10).Allocate([]float64{1, 1, 1})
+
+	var sum Money
+	for _, p := range parts {
+		sum = sum.Add(p)
+	}
+
+	if sum != MoneyFromFloat(10) {
+		t.Errorf("Expected the parts to add up to 10.00, got %s", sum)
+	}
+
+	if parts[0] != MoneyFromMinor(334) || parts[1] != MoneyFromMinor(333) {
+		t.Errorf("Expected the parts to be 3.34, 3.33, 3.33, got %v", parts)
+	}
+
+	parts = MoneyFromFloat(1).Allocate([]float64{0, 0})
+	if parts[0] != MoneyFromMinor(50) || parts[1] != MoneyFromMinor(50) {
+		t.Errorf("Expected the amount to be split equally on zero weights, got %v", parts)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	payload := struct {
+		Price     Money     `json:"price"`
+		Threshold NullMoney `json:"threshold"`
+	}{}
+
+	err := json.Unmarshal([]byte(`{"price": 19.9, "threshold": null}`), &payload)
+	if err != nil {
+		t.Errorf("There was an error on decoding the amounts: %v", err)
+	}
+
+	if payload.Price != MoneyFromMinor(1990) || payload.Threshold.Valid {
+		t.Errorf("Decoded amounts are not correct: %v", payload)
+	}
+
+	err = json.Unmarshal([]byte(`{"price": "5.25", "threshold": 100}`), &payload)
+	if err != nil {
+		t.Errorf("There was an error on decoding the amounts: %v", err)
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		t.Errorf("There was an error on encoding the amounts: %v", err)
+	}
+
+	if string(encoded) != `{"price":5.25,"threshold":100.00}` {
+		t.Errorf("Encoded amounts are not correct: %s", encoded)
+	}
+
+	err = json.Unmarshal([]byte(`{"price": true}`), &payload)
+	if err == nil {
+		t.Error("Expected an error on decoding a boolean as an amount")
+	}
+}
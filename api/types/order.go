@@ -1,6 +1,10 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
 
 // OrderBase represents basic order information
 // @model OrderBase
@@ -21,11 +25,11 @@ type OrderPayment struct {
 	// Unique identifier for the payment (private, needs permission)
 	Id int `json:"id"                 exposure:"private,needPermission"`
 	// Total price of all product variants in the order (private, needs permission)
-	TotalVariantsPrice float64 `json:"totalVariantsPrice" exposure:"private,needPermission"`
+	TotalVariantsPrice Money `json:"totalVariantsPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// Total shipping cost for the order (private, needs permission)
-	TotalShipmentPrice float64 `json:"totalShipmentPrice" exposure:"private,needPermission"`
+	TotalShipmentPrice Money `json:"totalShipmentPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// Any additional fees applied to the order (private, needs permission)
-	Fee float64 `json:"fee"                exposure:"private,needPermission"`
+	Fee Money `json:"fee"                exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// Current status of the payment (private, needs permission)
 	Status OrderPaymentStatus `json:"status"             exposure:"private,needPermission"`
 	// When the payment was created (private, needs permission)
@@ -34,14 +38,31 @@ type OrderPayment struct {
 	UpdatedAt time.Time `json:"updatedAt"          exposure:"private,needPermission"`
 	// ID of the order this payment belongs to (private, needs permission)
 	OrderId int `json:"orderId"            exposure:"private,needPermission"`
+	// Discount applied by the coupon (private, needs permission)
+	Discount Money `json:"discount"           exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// ID of the coupon applied to the order (private, needs permission)
+	CouponId json_types.JSONNullInt32 `json:"couponId"           exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total tax of the order, including the tax that is part of the prices (private, needs permission)
+	TotalTax Money `json:"totalTax"           exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Tax added on top of the prices of the order (private, needs permission)
+	ExclusiveTax Money `json:"exclusiveTax"       exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// Total returns the amount that the customer pays for the order
+func (p OrderPayment) Total() Money {
+	return p.TotalVariantsPrice.
+		Add(p.TotalShipmentPrice).
+		Add(p.Fee).
+		Sub(p.Discount).
+		Add(p.ExclusiveTax)
 }
 
-// OrderShipment represents shipment information for an order
+// OrderShipment represents the shipment of the part of an order fulfilled by a single store
 // @model OrderShipment
 type OrderShipment struct {
 	// Unique identifier for the shipment (private, needs permission)
 	Id int `json:"id"                exposure:"private,needPermission"`
-	// Estimated arrival date of the shipment (private, needs permission)
+	// Estimated arrival date of the shipment computed by the server, or the delivery date once delivered (private, needs permission)
 	ArrivalDate time.Time `json:"arrivalDate"       exposure:"private,needPermission"`
 	// Current status of the shipment (private, needs permission)
 	Status OrderShipmentStatus `json:"status"            exposure:"private,needPermission"`
@@ -53,6 +74,10 @@ type OrderShipment struct {
 	OrderId int `json:"orderId"           exposure:"private,needPermission"`
 	// ID of the receiver's address (private, needs permission)
 	ReceiverAddressId int `json:"receiverAddressId" exposure:"private,needPermission"`
+	// ID of the store fulfilling this shipment (private, needs permission)
+	StoreId int `json:"storeId"           exposure:"private,needPermission"`
+	// Tracking number given by the carrier (private, needs permission)
+	TrackingNumber json_types.JSONNullString `json:"trackingNumber"    exposure:"private,needPermission" swaggertype:"string"`
 }
 
 // Order represents a complete order with summarized information
@@ -61,14 +86,18 @@ type Order struct {
 	OrderBase
 	// Current status of the payment (private, needs permission)
 	PaymentStatus OrderPaymentStatus `json:"paymentStatus"      exposure:"private,needPermission"`
-	// Current status of the shipment (private, needs permission)
+	// Shipment status derived from the store shipments (private, needs permission)
 	ShipmentStatus OrderShipmentStatus `json:"shipmentStatus"     exposure:"private,needPermission"`
 	// Total price of all product variants (private, needs permission)
-	TotalVariantsPrice float64 `json:"totalVariantsPrice" exposure:"private,needPermission"`
+	TotalVariantsPrice Money `json:"totalVariantsPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// Total shipping cost (private, needs permission)
-	TotalShipmentPrice float64 `json:"totalShipmentPrice" exposure:"private,needPermission"`
+	TotalShipmentPrice Money `json:"totalShipmentPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// Any additional fees (private, needs permission)
-	Fee float64 `json:"fee"                exposure:"private,needPermission"`
+	Fee Money `json:"fee"                exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Discount applied by the coupon (private, needs permission)
+	Discount Money `json:"discount"           exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total tax of the order (private, needs permission)
+	TotalTax Money `json:"totalTax"           exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// Total number of products in the order (private, needs permission)
 	TotalProducts int `json:"totalProducts"      exposure:"private,needPermission"`
 }
@@ -81,16 +110,65 @@ type OrderShipmentWithAddress struct {
 	ReceiverAddress UserAddress `json:"receiverAddress" exposure:"private,needPermission"`
 }
 
+// OrderStatusEvent represents a change of the payment status or a shipment status of an order
+// @model OrderStatusEvent
+type OrderStatusEvent struct {
+	// Unique identifier for the event (private, needs permission)
+	Id int `json:"id"        exposure:"private,needPermission"`
+	// Whether the payment or a shipment status changed (private, needs permission)
+	Kind OrderStatusEventKind `json:"kind"      exposure:"private,needPermission"`
+	// Status before the change, null when the status was first set (private, needs permission)
+	OldStatus json_types.JSONNullString `json:"oldStatus" exposure:"private,needPermission" swaggertype:"string"`
+	// Status after the change (private, needs permission)
+	NewStatus string `json:"newStatus" exposure:"private,needPermission"`
+	// Reason of the change (private, needs permission)
+	Reason json_types.JSONNullString `json:"reason"    exposure:"private,needPermission" swaggertype:"string"`
+	// When the status changed (private, needs permission)
+	CreatedAt time.Time `json:"createdAt" exposure:"private,needPermission"`
+	// ID of the order (private, needs permission)
+	OrderId int `json:"orderId"   exposure:"private,needPermission"`
+	// ID of the store of the changed shipment, null for payment events (private, needs permission)
+	StoreId json_types.JSONNullInt32 `json:"storeId"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// ID of the user who changed the status, null if it was changed by the system (private, needs permission)
+	ActorId json_types.JSONNullInt32 `json:"actorId"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
 // OrderWithFullInfo represents an order with complete payment and shipment details
 // @model OrderWithFullInfo
 type OrderWithFullInfo struct {
 	OrderBase
 	// Detailed payment information (private, needs permission)
-	Payment OrderPayment `json:"payment"       exposure:"private,needPermission"`
-	// Detailed shipment information with address (private, needs permission)
-	Shipment OrderShipmentWithAddress `json:"shipment"      exposure:"private,needPermission"`
+	Payment OrderPayment `json:"payment"         exposure:"private,needPermission"`
+	// Shipment status derived from the store shipments (private, needs permission)
+	ShipmentStatus OrderShipmentStatus `json:"shipmentStatus"  exposure:"private,needPermission"`
+	// Complete receiver address information (private, needs permission)
+	ReceiverAddress UserAddress `json:"receiverAddress" exposure:"private,needPermission"`
+	// Shipments of the order, one per participating store (private, needs permission)
+	Shipments []OrderShipment `json:"shipments"       exposure:"private,needPermission"`
+	// Taxes of the order lines that a tax rule applied to (private, needs permission)
+	TaxLines []OrderTaxLine `json:"taxLines"        exposure:"private,needPermission"`
 	// Total number of products in the order (private, needs permission)
-	TotalProducts int `json:"totalProducts" exposure:"private,needPermission"`
+	TotalProducts int `json:"totalProducts"   exposure:"private,needPermission"`
+}
+
+// StoreOrder represents the part of an order that is fulfilled by a single store
+// @model StoreOrder
+type StoreOrder struct {
+	OrderBase
+	// Current status of the order payment (private, needs permission)
+	PaymentStatus OrderPaymentStatus `json:"paymentStatus"      exposure:"private,needPermission"`
+	// Shipment information of the store with address (private, needs permission)
+	Shipment OrderShipmentWithAddress `json:"shipment"           exposure:"private,needPermission"`
+	// Total price of the store's product variants (private, needs permission)
+	TotalVariantsPrice Money `json:"totalVariantsPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total shipping cost of the store's product variants (private, needs permission)
+	TotalShipmentPrice Money `json:"totalShipmentPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total coupon discount on the store's product variants (private, needs permission)
+	TotalDiscount Money `json:"totalDiscount"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total tax on the store's product variants (private, needs permission)
+	TotalTax Money `json:"totalTax"           exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Total number of the store's products in the order (private, needs permission)
+	TotalProducts int `json:"totalProducts"      exposure:"private,needPermission"`
 }
 
 // OrderProductSelectedAttribute represents selected attributes for an ordered product variant
@@ -110,17 +188,69 @@ type OrderProductSelectedAttribute struct {
 // @model OrderProductVariant
 type OrderProductVariant struct {
 	// Unique identifier for the order variant (private, needs permission)
-	Id int `json:"id"            exposure:"private,needPermission"`
+	Id int `json:"id"             exposure:"private,needPermission"`
 	// Quantity ordered (private, needs permission)
-	Quantity int `json:"quantity"      exposure:"private,needPermission"`
+	Quantity int `json:"quantity"       exposure:"private,needPermission"`
 	// Price per unit of the variant (private, needs permission)
-	VariantPrice float64 `json:"variantPrice"  exposure:"private,needPermission"`
+	VariantPrice Money `json:"variantPrice"   exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// Shipping cost for this variant (private, needs permission)
-	ShippingPrice float64 `json:"shippingPrice" exposure:"private,needPermission"`
+	ShippingPrice Money `json:"shippingPrice"  exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// ID of the order this variant belongs to (private, needs permission)
-	OrderId int `json:"orderId"       exposure:"private,needPermission"`
+	OrderId int `json:"orderId"        exposure:"private,needPermission"`
 	// ID of the product variant (private, needs permission)
-	VariantId int `json:"variantId"     exposure:"private,needPermission"`
+	VariantId int `json:"variantId"      exposure:"private,needPermission"`
+	// ID of the store selling the variant (private, needs permission)
+	StoreId int `json:"storeId"        exposure:"private,needPermission"`
+	// Coupon discount on this variant (private, needs permission)
+	Discount Money `json:"discount"       exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Tax on this variant (private, needs permission)
+	Tax Money `json:"tax"            exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Rate of the tax on this variant (private, needs permission)
+	TaxRate float64 `json:"taxRate"        exposure:"private,needPermission"`
+	// Pricing mode of the tax on this variant, null if no tax rule applied (private, needs permission)
+	TaxPricingMode json_types.JSONNullString `json:"taxPricingMode" exposure:"private,needPermission" swaggertype:"string"`
+	// Commission of the platform on this variant (private, needs permission)
+	Commission Money `json:"commission"     exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Rate of the commission on this variant (private, needs permission)
+	CommissionRate float64 `json:"commissionRate" exposure:"private,needPermission"`
+}
+
+// OrderTaxLine represents the tax of a single order line
+// @model OrderTaxLine
+type OrderTaxLine struct {
+	// ID of the order line (private, needs permission)
+	OrderProductVariantId int `json:"orderProductVariantId" exposure:"private,needPermission"`
+	// ID of the order (private, needs permission)
+	OrderId int `json:"orderId"               exposure:"private,needPermission"`
+	// ID of the product variant (private, needs permission)
+	VariantId int `json:"variantId"             exposure:"private,needPermission"`
+	// ID of the store selling the variant (private, needs permission)
+	StoreId int `json:"storeId"               exposure:"private,needPermission"`
+	// Price of the line after the discount that the tax is computed on (private, needs permission)
+	TaxableAmount Money `json:"taxableAmount"         exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Rate of the tax (private, needs permission)
+	Rate float64 `json:"rate"                  exposure:"private,needPermission"`
+	// Whether the tax is included in the price or added on top of it (private, needs permission)
+	PricingMode TaxPricingMode `json:"pricingMode"           exposure:"private,needPermission"`
+	// Amount of the tax (private, needs permission)
+	Tax Money `json:"tax"                   exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// InventoryReservation represents a hold on a product variant's stock by a pending order
+// @model InventoryReservation
+type InventoryReservation struct {
+	// Unique identifier for the reservation (private, needs permission)
+	Id int `json:"id"        exposure:"private,needPermission"`
+	// Number of held units (private, needs permission)
+	Quantity int `json:"quantity"  exposure:"private,needPermission"`
+	// When the hold is released if the order is not paid (private, needs permission)
+	ExpiresAt time.Time `json:"expiresAt" exposure:"private,needPermission"`
+	// When the hold was placed (private, needs permission)
+	CreatedAt time.Time `json:"createdAt" exposure:"private,needPermission"`
+	// ID of the order holding the stock (private, needs permission)
+	OrderId int `json:"orderId"   exposure:"private,needPermission"`
+	// ID of the held product variant (private, needs permission)
+	VariantId int `json:"variantId" exposure:"private,needPermission"`
 }
 
 // OrderProductVariantInfo represents detailed information about an ordered product variant
@@ -147,12 +277,12 @@ type OrderProductVariantAssignmentPayload struct {
 type CreateOrderPayload struct {
 	// ID of the user placing the order
 	UserId int `json:"userId"`
-	// Expected arrival date for the order (required)
-	ArrivalDate time.Time `json:"arrivalDate"       validate:"required"`
 	// List of product variants to order (required)
 	ProductVariants []OrderProductVariantAssignmentPayload `json:"productVariants"   validate:"required"`
 	// ID of the receiver's address (required)
 	ReceiverAddressId int `json:"receiverAddressId" validate:"required"`
+	// Code of the coupon to apply
+	CouponCode *string `json:"couponCode"`
 }
 
 // OrderSearchQuery contains parameters for searching orders
@@ -170,6 +300,8 @@ type OrderSearchQuery struct {
 	CreatedAtLessThan *time.Time `json:"createdAtLessThan"`
 	// Filter orders created after this date
 	CreatedAtMoreThan *time.Time `json:"createdAtMoreThan"`
+	// Start after the last order of the previous page, the newest orders come first
+	Cursor *Cursor `json:"cursor"`
 	// Maximum number of results to return
 	Limit *int `json:"limit"`
 	// Number of results to skip
@@ -181,8 +313,10 @@ type OrderSearchQuery struct {
 type UpdateOrderShipmentPayload struct {
 	// New status for the shipment
 	Status *OrderShipmentStatus `json:"status"`
-	// Updated estimated arrival date
-	ArrivalDate *time.Time `json:"arrivalDate"`
+	// Updated tracking number
+	TrackingNumber *string `json:"trackingNumber"`
+	// Reason of the status change, recorded in the order timeline
+	Reason *string `json:"reason"`
 }
 
 // UpdateOrderPaymentPayload contains data for updating order payment information
@@ -190,6 +324,8 @@ type UpdateOrderShipmentPayload struct {
 type UpdateOrderPaymentPayload struct {
 	// New status for the payment
 	Status *OrderPaymentStatus `json:"status"`
+	// Reason of the status change, recorded in the order timeline
+	Reason *string `json:"reason"`
 }
 
 // OrderProductVariantInsertData contains data for inserting a product variant into an order
@@ -198,11 +334,61 @@ type OrderProductVariantInsertData struct {
 	// Number of units ordered
 	Quantity int
 	// Price per unit at time of order
-	VariantPrice float64
+	VariantPrice Money
 	// Shipping cost per unit
-	ShippingPrice float64
+	ShippingPrice Money
 	// ID of the product variant
 	VariantId int
 	// ID of the order
 	OrderId int
+	// ID of the store selling the variant
+	StoreId int
+	// Coupon discount on this variant
+	Discount Money
+	// Tax on this variant
+	Tax Money
+	// Rate of the tax on this variant
+	TaxRate float64
+	// Pricing mode of the tax on this variant, nil if no tax rule applied
+	TaxPricingMode *TaxPricingMode
+	// Commission of the platform on this variant
+	Commission Money
+	// Rate of the commission on this variant
+	CommissionRate float64
+}
+
+// OrderStatusEventInsertData contains data for recording a status change of an order
+// @model OrderStatusEventInsertData
+type OrderStatusEventInsertData struct {
+	// Whether the payment or a shipment status changed
+	Kind OrderStatusEventKind
+	// Status before the change, nil when the status is first set
+	OldStatus *string
+	// Status after the change
+	NewStatus string
+	// Reason of the change
+	Reason *string
+	// ID of the order
+	OrderId int
+	// ID of the store of the changed shipment, nil for payment events
+	StoreId *int
+	// ID of the user who changed the status, nil if it is changed by the system
+	ActorId *int
+}
+
+// ProductVariantPricing contains the current pricing information of a product variant
+// @model ProductVariantPricing
+type ProductVariantPricing struct {
+	// ID of the product
+	ProductId int
+	// ID of the product variant
+	VariantId int
+	// ID of the store selling the product
+	StoreId int
+	// Quantity of the variant in stock that is not held by pending orders
+	Quantity int
+	// Shipment factor of the product
+	ShipmentFactor float64
+	// Price per unit including active offers
+	FinalPrice Money
 }
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// OrderReturn represents a customer's request to return items of an order to a store
+// @model OrderReturn
+type OrderReturn struct {
+	// Unique identifier for the return (private, needs permission)
+	Id int `json:"id"          exposure:"private,needPermission"`
+	// Reason given by the customer (private, needs permission)
+	Reason string `json:"reason"      exposure:"private,needPermission"`
+	// Current status of the return (private, needs permission)
+	Status OrderReturnStatus `json:"status"      exposure:"private,needPermission"`
+	// Note left by the store when reviewing the return (private, needs permission)
+	StoreNote json_types.JSONNullString `json:"storeNote"   exposure:"private,needPermission" swaggertype:"string"`
+	// Total amount refunded to the customer (private, needs permission)
+	TotalRefund Money `json:"totalRefund" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// If the returned items were put back into stock (private, needs permission)
+	Restocked bool `json:"restocked"   exposure:"private,needPermission"`
+	// When the return was requested (private, needs permission)
+	CreatedAt time.Time `json:"createdAt"   exposure:"private,needPermission"`
+	// When the return was last updated (private, needs permission)
+	UpdatedAt time.Time `json:"updatedAt"   exposure:"private,needPermission"`
+	// ID of the returned order (private, needs permission)
+	OrderId int `json:"orderId"     exposure:"private,needPermission"`
+	// ID of the store the items are returned to (private, needs permission)
+	StoreId int `json:"storeId"     exposure:"private,needPermission"`
+	// ID of the refund transaction of the customer wallet (private, needs permission)
+	RefundTxId json_types.JSONNullInt32 `json:"refundTxId"  exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// ID of the charge transaction of the store owner wallet (private, needs permission)
+	ChargeTxId json_types.JSONNullInt32 `json:"chargeTxId"  exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// OrderReturnItem represents a returned order product variant line
+// @model OrderReturnItem
+type OrderReturnItem struct {
+	// Unique identifier for the return item (private, needs permission)
+	Id int `json:"id"                    exposure:"private,needPermission"`
+	// Number of returned units (private, needs permission)
+	Quantity int `json:"quantity"              exposure:"private,needPermission"`
+	// Amount refunded for this item (private, needs permission)
+	RefundAmount Money `json:"refundAmount"          exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// ID of the return this item belongs to (private, needs permission)
+	ReturnId int `json:"returnId"              exposure:"private,needPermission"`
+	// ID of the returned order product variant (private, needs permission)
+	OrderProductVariantId int `json:"orderProductVariantId" exposure:"private,needPermission"`
+}
+
+// OrderReturnWithItems represents a return with its returned items
+// @model OrderReturnWithItems
+type OrderReturnWithItems struct {
+	OrderReturn
+	// Returned items (private, needs permission)
+	Items []OrderReturnItem `json:"items" exposure:"private,needPermission"`
+}
+
+// OrderReturnItemPayload contains data for returning an order product variant
+// @model OrderReturnItemPayload
+type OrderReturnItemPayload struct {
+	// Number of units to return (required)
+	Quantity int `json:"quantity"              validate:"required"`
+	// ID of the order product variant to return (required)
+	OrderProductVariantId int `json:"orderProductVariantId" validate:"required"`
+}
+
+// CreateOrderReturnPayload contains data needed to request an order return
+// @model CreateOrderReturnPayload
+type CreateOrderReturnPayload struct {
+	// ID of the order to return items from (required)
+	OrderId int `json:"orderId" validate:"required"`
+	// Reason of the return (required)
+	Reason string `json:"reason"  validate:"required"`
+	// Items to return (required)
+	Items []OrderReturnItemPayload `json:"items"   validate:"required"`
+}
+
+// ReviewOrderReturnPayload contains the store's decision on a return request
+// @model ReviewOrderReturnPayload
+type ReviewOrderReturnPayload struct {
+	// New status of the return, approved or rejected (required)
+	Status OrderReturnStatus `json:"status"    validate:"required"`
+	// Note for the customer
+	StoreNote *string `json:"storeNote"`
+}
+
+// ReceiveOrderReturnPayload contains data for confirming the receipt of returned items
+// @model ReceiveOrderReturnPayload
+type ReceiveOrderReturnPayload struct {
+	// Put the returned items back into stock
+	Restock bool `json:"restock"`
+}
+
+// OrderReturnSearchQuery contains parameters for searching order returns
+// @model OrderReturnSearchQuery
+type OrderReturnSearchQuery struct {
+	// Filter by customer ID
+	UserId *int `json:"userId"`
+	// Filter by store ID
+	StoreId *int `json:"storeId"`
+	// Filter by order ID
+	OrderId *int `json:"orderId"`
+	// Filter by return status
+	Status *OrderReturnStatus `json:"status"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
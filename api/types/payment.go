@@ -0,0 +1,80 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// PaymentIntent represents a payment collected by an external payment provider, its money is deposited to a wallet and spent on an order if it pays one
+// @model PaymentIntent
+type PaymentIntent struct {
+	// Unique identifier for the payment intent (private, needs permission)
+	Id int `json:"id"                  exposure:"private,needPermission"`
+	// Name of the payment provider that collects the payment (private, needs permission)
+	Provider string `json:"provider"            exposure:"private,needPermission"`
+	// Reference of the payment at the payment provider (private, needs permission)
+	Reference string `json:"reference"           exposure:"private,needPermission"`
+	// Amount of the payment (private, needs permission)
+	Amount Money `json:"amount"              exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Current status of the payment (private, needs permission)
+	Status PaymentIntentStatus `json:"status"              exposure:"private,needPermission"`
+	// When the payment intent was created (private, needs permission)
+	CreatedAt time.Time `json:"createdAt"           exposure:"private,needPermission"`
+	// When the payment intent was last updated (private, needs permission)
+	UpdatedAt time.Time `json:"updatedAt"           exposure:"private,needPermission"`
+	// ID of the deposit transaction that receives the money (private, needs permission)
+	WalletTransactionId int `json:"walletTransactionId" exposure:"private,needPermission"`
+	// ID of the order that is paid by the payment, if any (private, needs permission)
+	OrderId json_types.JSONNullInt32 `json:"orderId"             exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// CreatePaymentIntentPayload contains data needed to save a payment intent with its deposit transaction
+// @model CreatePaymentIntentPayload
+type CreatePaymentIntentPayload struct {
+	// Name of the payment provider
+	Provider string `json:"provider"`
+	// Reference of the payment at the payment provider
+	Reference string `json:"reference"`
+	// Amount of the payment
+	Amount Money `json:"amount"    swaggertype:"primitive,number"`
+	// ID of the wallet the money is deposited to
+	WalletId int `json:"walletId"`
+	// ID of the order that is paid by the payment
+	OrderId *int `json:"orderId"`
+}
+
+// CreateProviderPaymentPayload contains data needed to start a payment at a payment provider
+// @model CreateProviderPaymentPayload
+type CreateProviderPaymentPayload struct {
+	// Amount to collect
+	Amount Money `json:"amount"      swaggertype:"primitive,number"`
+	// Description shown to the payer
+	Description string `json:"description"`
+}
+
+// ProviderPayment represents the state of a payment at a payment provider
+// @model ProviderPayment
+type ProviderPayment struct {
+	// Reference of the payment at the payment provider
+	Reference string `json:"reference"   exposure:"private"`
+	// Amount of the payment
+	Amount Money `json:"amount"      exposure:"private" swaggertype:"primitive,number"`
+	// Current status of the payment
+	Status PaymentIntentStatus `json:"status"      exposure:"private"`
+	// URL that the payer is sent to for paying
+	CheckoutUrl string `json:"checkoutUrl" exposure:"private"`
+}
+
+// PaymentWebhookEvent is the body of a request sent by a payment provider to the webhook
+// @model PaymentWebhookEvent
+type PaymentWebhookEvent struct {
+	// Unique identifier of the event at the payment provider
+	Id string `json:"id"        validate:"required"`
+	// Type of the event
+	Type PaymentWebhookEventType `json:"type"      validate:"required"`
+	// Reference of the payment at the payment provider
+	Reference string `json:"reference" validate:"required"`
+	// Amount of the payment
+	Amount Money `json:"amount"    validate:"required" swaggertype:"primitive,number"`
+}
@@ -16,7 +16,7 @@ type ProductBase struct {
 	// URL-friendly product identifier (public)
 	Slug string `json:"slug"           exposure:"public"`
 	// Current price of the product (public)
-	Price float64 `json:"price"          exposure:"public"`
+	Price Money `json:"price"          exposure:"public" swaggertype:"primitive,number"`
 	// Factor used to calculate shipping costs (public)
 	ShipmentFactor float64 `json:"shipmentFactor" exposure:"public"`
 	// Detailed product description (public)
@@ -230,9 +230,9 @@ type ProductCommentWithUser struct {
 type Product struct {
 	ProductBase
 	// Product leaf subcategory info
-	Subcategory ProductCategory `json:"subcategory" exposure:"public"`
+	Subcategory ProductCategory `json:"subcategory"         exposure:"public"`
 	// Average score of the product based on the comments
-	AverageScore float32 `json:"averageScore" exposure:"public"`
+	AverageScore float32 `json:"averageScore"        exposure:"public"`
 	// Total available quantity across all variants (public)
 	TotalQuantity int `json:"totalQuantity"       exposure:"public"`
 	// Current offer/discount, if any (public, optional)
@@ -241,6 +241,10 @@ type Product struct {
 	MainImage *ProductImage `json:"mainImage,omitempty" exposure:"public"`
 	// Store information (public)
 	Store StoreInfo `json:"store"               exposure:"public"`
+	// Part of the name and the description that matches the search keyword,
+	// with the matched words wrapped in <mark> tags (public, only set when
+	// searching by keyword)
+	Snippet *string `json:"snippet,omitempty"   exposure:"public"`
 }
 
 // ProductExtended provides comprehensive product information
@@ -256,7 +260,7 @@ type ProductExtended struct {
 	// List of available variants with attributes (public)
 	Variants []ProductVariantWithAttributeSet `json:"variants"        exposure:"public"`
 	// List of available attributes with their available options based on the product variants (public)
-	Attributes []ProductAttributeWithOptions `json:"attributes"  exposure:"public"`
+	Attributes []ProductAttributeWithOptions `json:"attributes"      exposure:"public"`
 	// Current offer/discount, if any (public, optional)
 	Offer *ProductOffer `json:"offer,omitempty" exposure:"public"`
 	// List of product images (public)
@@ -333,7 +337,7 @@ type CreateProductBasePayload struct {
 	// URL-friendly slug
 	Slug string `json:"slug"`
 	// Product price (required)
-	Price float64 `json:"price"          validate:"required"`
+	Price Money `json:"price"          validate:"required" swaggertype:"primitive,number"`
 	// Shipping cost factor (required)
 	ShipmentFactor float64 `json:"shipmentFactor" validate:"required"`
 	// Product description
@@ -403,7 +407,7 @@ type UpdateProductBasePayload struct {
 	// New URL-friendly slug
 	Slug *string `json:"slug"`
 	// New product price
-	Price *float64 `json:"price"`
+	Price *Money `json:"price"          swaggertype:"primitive,number"`
 	// New shipping cost factor
 	ShipmentFactor *float64 `json:"shipmentFactor"`
 	// New product description
@@ -482,7 +486,9 @@ type UpdateProductPayload struct {
 // ProductSearchQuery contains parameters for searching products
 // @model ProductSearchQuery
 type ProductSearchQuery struct {
-	// General search keyword
+	// General search keyword, matched against the name, tags, categories,
+	// description and specs of the products. Quoted parts are matched as
+	// phrases and words ending with '*' as prefixes
 	Keyword *string `json:"keyword"`
 	// Filter by exact product name
 	Name *string `json:"name"`
@@ -501,21 +507,39 @@ type ProductSearchQuery struct {
 	// Filter by tag IDs (separated by comma ',')
 	TagIds *string `json:"tagIds"`
 	// Maximum price
-	PriceLessThan *float32 `json:"priceLessThan"`
+	PriceLessThan *Money `json:"priceLessThan" swaggertype:"primitive,number"`
 	// Minimum price
-	PriceMoreThan *float32 `json:"priceMoreThan"`
+	PriceMoreThan *Money `json:"priceMoreThan" swaggertype:"primitive,number"`
 	// Filter by store ID
 	StoreId *int `json:"storeId"`
 	// Minimum average score
 	AverageScore *float32 `json:"averageScore"`
 	// Filter by active status
 	IsActive *bool `json:"isActive"`
+	// Order of the results, relevance when there is a keyword and newest otherwise
+	Sort *ProductSort `json:"sort"`
+	// Start after the last product of the previous page, which was listed with the same sort
+	Cursor *Cursor `json:"cursor"`
 	// Maximum number of results
 	Limit *int `json:"limit"`
 	// Number of results to skip
 	Offset *int `json:"offset"`
 }
 
+// ResolvedSort returns the order that the products of the query are listed
+// in. The relevance is only used when there is a keyword.
+func (q ProductSearchQuery) ResolvedSort() ProductSort {
+	if q.Sort != nil && (*q.Sort != ProductSortRelevance || q.Keyword != nil) {
+		return *q.Sort
+	}
+
+	if q.Keyword != nil {
+		return ProductSortRelevance
+	}
+
+	return ProductSortNewest
+}
+
 // CreateProductOfferPayload contains data needed to create a product offer
 // @model CreateProductOfferPayload
 type CreateProductOfferPayload struct {
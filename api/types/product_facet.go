@@ -0,0 +1,66 @@
+package types
+
+// ProductFacetCount is the number of matching products in a category, with a tag or in a store
+// @model ProductFacetCount
+type ProductFacetCount struct {
+	// ID of the category, tag or store (public)
+	Id int `json:"id"    exposure:"public"`
+	// Name of the category, tag or store (public)
+	Name string `json:"name"  exposure:"public"`
+	// Number of matching products (public)
+	Count int `json:"count" exposure:"public"`
+}
+
+// ProductAttributeOptionFacet is the number of matching products that have a variant with an attribute option
+// @model ProductAttributeOptionFacet
+type ProductAttributeOptionFacet struct {
+	// ID of the attribute (public)
+	AttributeId int `json:"attributeId"    exposure:"public"`
+	// Label of the attribute (public)
+	AttributeLabel string `json:"attributeLabel" exposure:"public"`
+	// ID of the option (public)
+	OptionId int `json:"optionId"       exposure:"public"`
+	// Value of the option (public)
+	OptionValue string `json:"optionValue"    exposure:"public"`
+	// Number of matching products (public)
+	Count int `json:"count"          exposure:"public"`
+}
+
+// ProductOfferFacet is the number of matching products with and without an offer
+// @model ProductOfferFacet
+type ProductOfferFacet struct {
+	// Number of matching products that have an offer (public)
+	WithOffer int `json:"withOffer"    exposure:"public"`
+	// Number of matching products that have no offer (public)
+	WithoutOffer int `json:"withoutOffer" exposure:"public"`
+}
+
+// ProductPriceRangeFacet is the number of matching products whose current price is in a range
+// @model ProductPriceRangeFacet
+type ProductPriceRangeFacet struct {
+	// Lowest price of the range, inclusive (public, null for the first range)
+	Min *Money `json:"min"   exposure:"public" swaggertype:"primitive,number"`
+	// Highest price of the range, exclusive (public, null for the last range)
+	Max *Money `json:"max"   exposure:"public" swaggertype:"primitive,number"`
+	// Number of matching products (public)
+	Count int `json:"count" exposure:"public"`
+}
+
+// ProductFacets contains the counts of the products that match a search for each value of its filters
+// @model ProductFacets
+type ProductFacets struct {
+	// Number of matching products (public)
+	Total int `json:"total"            exposure:"public"`
+	// Counts per subcategory, the most common first (public)
+	Categories []ProductFacetCount `json:"categories"       exposure:"public"`
+	// Counts per tag, the most common first (public)
+	Tags []ProductFacetCount `json:"tags"             exposure:"public"`
+	// Counts per attribute option, grouped by attribute (public)
+	AttributeOptions []ProductAttributeOptionFacet `json:"attributeOptions" exposure:"public"`
+	// Counts per store, the most common first (public)
+	Stores []ProductFacetCount `json:"stores"           exposure:"public"`
+	// Counts of the products with and without an offer (public)
+	Offer ProductOfferFacet `json:"offer"            exposure:"public"`
+	// Counts per price range, in the order of the ranges (public)
+	PriceRanges []ProductPriceRangeFacet `json:"priceRanges"      exposure:"public"`
+}
@@ -0,0 +1,140 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// ProductCatalogRow represents a product of a store as a row of an import or an export file
+// @model ProductCatalogRow
+type ProductCatalogRow struct {
+	// URL-friendly slug, an existing product of the store with the same slug is updated (required)
+	Slug string `json:"slug"           validate:"required,max=255"`
+	// Product name (required)
+	Name string `json:"name"           validate:"required,max=150"`
+	// Base price, it cannot be negative
+	Price Money `json:"price"          swaggertype:"primitive,number"`
+	// Shipping cost factor between 0 and 1
+	ShipmentFactor float64 `json:"shipmentFactor" validate:"min=0,max=1"`
+	// Product description
+	Description string `json:"description"    validate:"max=4095"`
+	// Whether the product is active, new products are active if it is not set
+	IsActive *bool `json:"isActive"`
+	// Subcategory ID (required)
+	SubcategoryId int `json:"subcategoryId"  validate:"required"`
+	// IDs of the tags of the product, they replace the tags of an existing product
+	TagIds []int `json:"tagIds"`
+	// Specifications of the product, they replace the specs of an existing product
+	Specs []CreateProductSpecPayload `json:"specs"          validate:"dive"`
+	// Images of the product, they replace the images of an existing product
+	Images []CreateProductImagePayload `json:"images"`
+	// Variants of the product, the variants of an existing product with the same attribute set are updated and the others are added
+	Variants []CreateProductVariantPayload `json:"variants"`
+}
+
+// ProductImport represents a file of products that is imported into a store in the background
+// @model ProductImport
+type ProductImport struct {
+	// Import ID (private)
+	Id int `json:"id"          exposure:"private"`
+	// Format of the imported file (private)
+	Format ProductImportFormat `json:"format"      exposure:"private"`
+	// Whether the rows are only checked and not saved (private)
+	DryRun bool `json:"dryRun"      exposure:"private"`
+	// Current status of the import (private)
+	Status ProductImportStatus `json:"status"      exposure:"private"`
+	// Number of the rows in the file (private)
+	TotalRows int `json:"totalRows"   exposure:"private"`
+	// Number of the rows that created a product (private)
+	CreatedRows int `json:"createdRows" exposure:"private"`
+	// Number of the rows that updated a product (private)
+	UpdatedRows int `json:"updatedRows" exposure:"private"`
+	// Number of the rows that failed (private)
+	FailedRows int `json:"failedRows"  exposure:"private"`
+	// Why the file could not be read, if the import failed (private)
+	Error json_types.JSONNullString `json:"error"       exposure:"private" swaggertype:"string"`
+	// When the import was uploaded (private)
+	CreatedAt time.Time `json:"createdAt"   exposure:"private"`
+	// When the import was picked up (private)
+	StartedAt json_types.JSONNullTime `json:"startedAt"   exposure:"private" swaggertype:"string"`
+	// When the import finished (private)
+	FinishedAt json_types.JSONNullTime `json:"finishedAt"  exposure:"private" swaggertype:"string"`
+	// ID of the store that the products are imported into (private)
+	StoreId int `json:"storeId"     exposure:"private"`
+	// ID of the user who uploaded the import (private)
+	UserId json_types.JSONNullInt32 `json:"userId"      exposure:"private" swaggertype:"integer"`
+}
+
+// ProductImportError represents a row of an import that could not be applied
+// @model ProductImportError
+type ProductImportError struct {
+	// Error ID (private)
+	Id int `json:"id"        exposure:"private"`
+	// Number of the row in the file, the header of a CSV file is not counted (private)
+	RowNumber int `json:"rowNumber" exposure:"private"`
+	// Slug of the row, if it could be read (private)
+	Slug json_types.JSONNullString `json:"slug"      exposure:"private" swaggertype:"string"`
+	// Why the row could not be applied (private)
+	Message string `json:"message"   exposure:"private"`
+	// ID of the import (private)
+	ImportId int `json:"importId"  exposure:"private"`
+}
+
+// ProductImportWithErrors represents a product import with the errors of its rows
+// @model ProductImportWithErrors
+type ProductImportWithErrors struct {
+	ProductImport
+	// Errors of the rows that could not be applied, in the order of the rows (private)
+	Errors []ProductImportError `json:"errors" exposure:"private"`
+}
+
+// ProductImportSearchQuery contains parameters for searching product imports
+// @model ProductImportSearchQuery
+type ProductImportSearchQuery struct {
+	// Filter by the store
+	StoreId *int `json:"storeId"`
+	// Filter by the status
+	Status *ProductImportStatus `json:"status"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
+
+// CreateProductImportPayload contains data needed to queue a product import
+// @model CreateProductImportPayload
+type CreateProductImportPayload struct {
+	// Format of the file
+	Format ProductImportFormat `json:"format"`
+	// Whether the rows are only checked and not saved
+	DryRun bool `json:"dryRun"`
+	// Content of the file
+	Data string `json:"data"`
+	// ID of the store that the products are imported into
+	StoreId int `json:"storeId"`
+	// ID of the user who uploaded the file
+	UserId int `json:"userId"`
+}
+
+// ProductImportRowError is the error of a row of an import that is being applied
+type ProductImportRowError struct {
+	// Number of the row in the file
+	RowNumber int
+	// Slug of the row, if it could be read
+	Slug *string
+	// Why the row could not be applied
+	Message string
+}
+
+// ProductImportResult is the result of applying the rows of an import
+type ProductImportResult struct {
+	// Number of the rows in the file
+	TotalRows int
+	// Number of the rows that created a product
+	CreatedRows int
+	// Number of the rows that updated a product
+	UpdatedRows int
+	// Errors of the rows that failed
+	Errors []ProductImportRowError
+}
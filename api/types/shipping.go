@@ -0,0 +1,234 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// ShippingZone represents a region of receiver addresses, matched by the state and optionally the city of an address
+// @model ShippingZone
+type ShippingZone struct {
+	// Unique identifier for the zone (public)
+	Id int `json:"id"        exposure:"public"`
+	// Name of the zone (public)
+	Name string `json:"name"      exposure:"public"`
+	// State of the addresses in the zone (public)
+	State string `json:"state"     exposure:"public"`
+	// City of the addresses in the zone, the zone covers the whole state if it is null (public)
+	City json_types.JSONNullString `json:"city"      exposure:"public" swaggertype:"string"`
+	// When the zone was created (public)
+	CreatedAt time.Time `json:"createdAt" exposure:"public"`
+	// When the zone was last updated (public)
+	UpdatedAt time.Time `json:"updatedAt" exposure:"public"`
+}
+
+// ShippingRateTable represents the shipping rates of a store for a zone
+// @model ShippingRateTable
+type ShippingRateTable struct {
+	// Unique identifier for the rate table (public)
+	Id int `json:"id"                    exposure:"public"`
+	// What the tiers of the table are measured by (public)
+	Basis ShippingRateBasis `json:"basis"                 exposure:"public"`
+	// Minimum price of the store's products in an order for free shipping (public)
+	FreeShippingThreshold NullMoney `json:"freeShippingThreshold" exposure:"public" swaggertype:"primitive,number"`
+	// When the rate table was created (public)
+	CreatedAt time.Time `json:"createdAt"             exposure:"public"`
+	// When the rate table was last updated (public)
+	UpdatedAt time.Time `json:"updatedAt"             exposure:"public"`
+	// ID of the store that owns the rate table (public)
+	StoreId int `json:"storeId"               exposure:"public"`
+	// ID of the zone the rate table applies to, the default table of the store if it is null (public)
+	ZoneId json_types.JSONNullInt32 `json:"zoneId"                exposure:"public" swaggertype:"primitive,number"`
+}
+
+// ShippingRateTier represents a price range of a shipping rate table
+// @model ShippingRateTier
+type ShippingRateTier struct {
+	// Unique identifier for the tier (public)
+	Id int `json:"id"           exposure:"public"`
+	// Inclusive lower bound of the measured value (public)
+	MinValue float64 `json:"minValue"     exposure:"public"`
+	// Exclusive upper bound of the measured value, unbounded if it is null (public)
+	MaxValue json_types.JSONNullFloat64 `json:"maxValue"     exposure:"public" swaggertype:"primitive,number"`
+	// Flat price of the tier (public)
+	BasePrice Money `json:"basePrice"    exposure:"public" swaggertype:"primitive,number"`
+	// Price added per measured unit (public)
+	PricePerUnit Money `json:"pricePerUnit" exposure:"public" swaggertype:"primitive,number"`
+	// ID of the rate table the tier belongs to (public)
+	RateTableId int `json:"rateTableId"  exposure:"public"`
+}
+
+// ShippingRateTableWithTiers represents a shipping rate table with its tiers
+// @model ShippingRateTableWithTiers
+type ShippingRateTableWithTiers struct {
+	ShippingRateTable
+	// Tiers of the rate table ordered by their lower bound (public)
+	Tiers []ShippingRateTier `json:"tiers" exposure:"public"`
+}
+
+// ShippingTransitTime represents the number of days a shipment takes from an origin zone to a destination zone
+// @model ShippingTransitTime
+type ShippingTransitTime struct {
+	// Unique identifier for the transit time (public)
+	Id int `json:"id"                exposure:"public"`
+	// Number of days a shipment is on the way (public)
+	Days int `json:"days"              exposure:"public"`
+	// When the transit time was created (public)
+	CreatedAt time.Time `json:"createdAt"         exposure:"public"`
+	// When the transit time was last updated (public)
+	UpdatedAt time.Time `json:"updatedAt"         exposure:"public"`
+	// ID of the zone of the store's address (public)
+	OriginZoneId int `json:"originZoneId"      exposure:"public"`
+	// ID of the zone of the receiver's address (public)
+	DestinationZoneId int `json:"destinationZoneId" exposure:"public"`
+}
+
+// ShippingLine contains a priced order line that the shipping price is computed for
+// @model ShippingLine
+type ShippingLine struct {
+	// Pricing of the ordered variant
+	Pricing ProductVariantPricing
+	// Number of ordered units
+	Quantity int
+	// Computed shipping price of the line
+	ShippingPrice Money
+}
+
+// StoreShippingQuote represents the shipping price of the part of an order sent by a single store
+// @model StoreShippingQuote
+type StoreShippingQuote struct {
+	// ID of the store (private, needs permission)
+	StoreId int `json:"storeId"              exposure:"private,needPermission"`
+	// ID of the applied rate table, null if the default shipping price is applied (private, needs permission)
+	RateTableId json_types.JSONNullInt32 `json:"rateTableId"          exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Shipping price of the store's products (private, needs permission)
+	ShippingPrice Money `json:"shippingPrice"        exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// If the free shipping threshold of the store is reached (private, needs permission)
+	IsFreeShipping bool `json:"isFreeShipping"       exposure:"private,needPermission"`
+	// Estimated arrival date of the store's shipment if it is ordered now (private, needs permission)
+	EstimatedArrivalDate time.Time `json:"estimatedArrivalDate" exposure:"private,needPermission"`
+}
+
+// ShippingQuote represents the shipping price of a list of variants for a receiver address
+// @model ShippingQuote
+type ShippingQuote struct {
+	// Shipping prices per store (private, needs permission)
+	Stores []StoreShippingQuote `json:"stores"             exposure:"private,needPermission"`
+	// Total shipping price (private, needs permission)
+	TotalShipmentPrice Money `json:"totalShipmentPrice" exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// CreateShippingZonePayload contains data needed to create a shipping zone
+// @model CreateShippingZonePayload
+type CreateShippingZonePayload struct {
+	// Name of the zone (required)
+	Name string `json:"name"  validate:"required"`
+	// State of the addresses in the zone (required)
+	State string `json:"state" validate:"required"`
+	// City of the addresses in the zone
+	City *string `json:"city"`
+}
+
+// UpdateShippingZonePayload contains data for updating a shipping zone
+// @model UpdateShippingZonePayload
+type UpdateShippingZonePayload struct {
+	// New name
+	Name *string `json:"name"`
+	// New state
+	State *string `json:"state"`
+	// New city
+	City *string `json:"city"`
+}
+
+// ShippingZoneSearchQuery contains parameters for searching shipping zones
+// @model ShippingZoneSearchQuery
+type ShippingZoneSearchQuery struct {
+	// Filter by name (partial match)
+	Name *string `json:"name"`
+	// Filter by state
+	State *string `json:"state"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
+
+// CreateShippingTransitTimePayload contains data needed to create a shipping transit time
+// @model CreateShippingTransitTimePayload
+type CreateShippingTransitTimePayload struct {
+	// Number of days a shipment is on the way
+	Days int `json:"days"`
+	// ID of the zone of the store's address (required)
+	OriginZoneId int `json:"originZoneId"      validate:"required"`
+	// ID of the zone of the receiver's address (required)
+	DestinationZoneId int `json:"destinationZoneId" validate:"required"`
+}
+
+// UpdateShippingTransitTimePayload contains data for updating a shipping transit time
+// @model UpdateShippingTransitTimePayload
+type UpdateShippingTransitTimePayload struct {
+	// New number of days
+	Days *int `json:"days"`
+}
+
+// ShippingTransitTimeSearchQuery contains parameters for searching shipping transit times
+// @model ShippingTransitTimeSearchQuery
+type ShippingTransitTimeSearchQuery struct {
+	// Filter by origin zone ID
+	OriginZoneId *int `json:"originZoneId"`
+	// Filter by destination zone ID
+	DestinationZoneId *int `json:"destinationZoneId"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
+
+// CreateShippingRateTierPayload contains data needed to create a shipping rate tier
+// @model CreateShippingRateTierPayload
+type CreateShippingRateTierPayload struct {
+	// Inclusive lower bound of the measured value
+	MinValue float64 `json:"minValue"`
+	// Exclusive upper bound of the measured value
+	MaxValue *float64 `json:"maxValue"`
+	// Flat price of the tier
+	BasePrice Money `json:"basePrice" swaggertype:"primitive,number"`
+	// Price added per measured unit
+	PricePerUnit Money `json:"pricePerUnit" swaggertype:"primitive,number"`
+}
+
+// CreateShippingRateTablePayload contains data needed to create a shipping rate table
+// @model CreateShippingRateTablePayload
+type CreateShippingRateTablePayload struct {
+	// What the tiers of the table are measured by (required)
+	Basis ShippingRateBasis `json:"basis"                 validate:"required"`
+	// Minimum price of the store's products in an order for free shipping
+	FreeShippingThreshold *Money `json:"freeShippingThreshold" swaggertype:"primitive,number"`
+	// ID of the zone, the table is the default table of the store if it is not set
+	ZoneId *int `json:"zoneId"`
+	// Tiers of the table (required)
+	Tiers []CreateShippingRateTierPayload `json:"tiers"                 validate:"required"`
+	// ID of the store this rate table belongs to
+	StoreId int `json:"storeId"`
+}
+
+// UpdateShippingRateTablePayload contains data for updating a shipping rate table
+// @model UpdateShippingRateTablePayload
+type UpdateShippingRateTablePayload struct {
+	// New basis
+	Basis *ShippingRateBasis `json:"basis"`
+	// New free shipping threshold
+	FreeShippingThreshold *Money `json:"freeShippingThreshold" swaggertype:"primitive,number"`
+	// New tiers, replacing all of the current tiers
+	Tiers []CreateShippingRateTierPayload `json:"tiers"`
+}
+
+// ShippingQuotePayload contains data needed to quote the shipping price of an order
+// @model ShippingQuotePayload
+type ShippingQuotePayload struct {
+	// List of product variants to ship (required)
+	ProductVariants []OrderProductVariantAssignmentPayload `json:"productVariants"   validate:"required"`
+	// ID of the receiver's address (required)
+	ReceiverAddressId int `json:"receiverAddressId" validate:"required"`
+}
@@ -40,13 +40,15 @@ type StoreInfo struct {
 // @model StoreSettings
 type StoreSettings struct {
 	// Settings ID
-	Id int `json:"id"          exposure:"public"`
+	Id int `json:"id"           exposure:"public"`
 	// Whether owner information is public
-	PublicOwner bool `json:"publicOwner" exposure:"public"`
+	PublicOwner bool `json:"publicOwner"  exposure:"public"`
 	// When settings were last updated
-	UpdatedAt time.Time `json:"updatedAt"   exposure:"public"`
+	UpdatedAt time.Time `json:"updatedAt"    exposure:"public"`
 	// ID of the store these settings belong to
-	StoreId int `json:"storeId"     exposure:"public"`
+	StoreId int `json:"storeId"      exposure:"public"`
+	// Number of days the store takes to hand an order to the carrier
+	HandlingDays int `json:"handlingDays" exposure:"public"`
 }
 
 // StoreWithSettings combines Store with its settings
@@ -59,6 +61,8 @@ type StoreWithSettings struct {
 	PublicOwner bool `json:"publicOwner"       exposure:"public"`
 	// When settings were last updated
 	SettingsUpdatedAt time.Time `json:"settingsUpdatedAt" exposure:"public"`
+	// Number of days the store takes to hand an order to the carrier
+	HandlingDays int `json:"handlingDays"      exposure:"public"`
 }
 
 // StorePhoneNumber represents a store's contact number
@@ -230,4 +234,6 @@ type UpdateStorePayload struct {
 type UpdateStoreSettingsPayload struct {
 	// New owner visibility setting
 	PublicOwner *bool `json:"publicOwner"`
+	// New handling time in days
+	HandlingDays *int `json:"handlingDays"`
 }
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// TaxRule represents the tax rate of a product category for the receiver addresses of a shipping zone
+// @model TaxRule
+type TaxRule struct {
+	// Unique identifier for the tax rule (public)
+	Id int `json:"id"          exposure:"public"`
+	// Name of the tax rule (public)
+	Name string `json:"name"        exposure:"public"`
+	// Rate of the tax between 0 and 1 (public)
+	Rate float64 `json:"rate"        exposure:"public"`
+	// Whether the tax is included in the product price or added on top of it (public)
+	PricingMode TaxPricingMode `json:"pricingMode" exposure:"public"`
+	// When the tax rule was created (public)
+	CreatedAt time.Time `json:"createdAt"   exposure:"public"`
+	// When the tax rule was last updated (public)
+	UpdatedAt time.Time `json:"updatedAt"   exposure:"public"`
+	// ID of the zone of the receiver addresses the rule applies to (public)
+	ZoneId int `json:"zoneId"      exposure:"public"`
+	// ID of the category the rule applies to with its subcategories, all products if it is null (public)
+	CategoryId json_types.JSONNullInt32 `json:"categoryId"  exposure:"public" swaggertype:"primitive,number"`
+}
+
+// CreateTaxRulePayload contains data needed to create a tax rule
+// @model CreateTaxRulePayload
+type CreateTaxRulePayload struct {
+	// Name of the tax rule (required)
+	Name string `json:"name"        validate:"required"`
+	// Rate of the tax between 0 and 1
+	Rate float64 `json:"rate"`
+	// Whether the tax is included in the product price or added on top of it (required)
+	PricingMode TaxPricingMode `json:"pricingMode" validate:"required"`
+	// ID of the zone the rule applies to (required)
+	ZoneId int `json:"zoneId"      validate:"required"`
+	// ID of the category the rule applies to, the rule applies to all products if it is not set
+	CategoryId *int `json:"categoryId"`
+}
+
+// UpdateTaxRulePayload contains data for updating a tax rule
+// @model UpdateTaxRulePayload
+type UpdateTaxRulePayload struct {
+	// New name
+	Name *string `json:"name"`
+	// New rate
+	Rate *float64 `json:"rate"`
+	// New pricing mode
+	PricingMode *TaxPricingMode `json:"pricingMode"`
+}
+
+// TaxRuleSearchQuery contains parameters for searching tax rules
+// @model TaxRuleSearchQuery
+type TaxRuleSearchQuery struct {
+	// Filter by zone ID
+	ZoneId *int `json:"zoneId"`
+	// Filter by category ID
+	CategoryId *int `json:"categoryId"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
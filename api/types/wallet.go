@@ -1,46 +1,62 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
 
 // Wallet represents a user's digital wallet
 // @model Wallet
 type Wallet struct {
 	// Wallet ID (private, needs permission)
-	Id int `json:"id"        exposure:"private,needPermission"`
-	// Current balance in the wallet (private, needs permission)
-	Balance float64 `json:"balance"   exposure:"private,needPermission"`
+	Id int `json:"id"             exposure:"private,needPermission"`
+	// Available balance in the wallet, cached from its ledger postings (private, needs permission)
+	Balance Money `json:"balance"        exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// When the wallet was created (private, needs permission)
-	CreatedAt time.Time `json:"createdAt" exposure:"private,needPermission"`
+	CreatedAt time.Time `json:"createdAt"      exposure:"private,needPermission"`
 	// When the wallet was last updated (private, needs permission)
-	UpdatedAt time.Time `json:"updatedAt" exposure:"private,needPermission"`
+	UpdatedAt time.Time `json:"updatedAt"      exposure:"private,needPermission"`
 	// ID of the user who owns this wallet (private, needs permission)
-	UserId int `json:"userId"    exposure:"private,needPermission"`
+	UserId int `json:"userId"         exposure:"private,needPermission"`
+	// Earnings of the stores of the user held in escrow until their shipments are delivered (private, needs permission)
+	PendingBalance Money `json:"pendingBalance" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Whether the withdrawals and the outgoing transfers of the wallet are blocked until its drift is resolved (private, needs permission)
+	IsFrozen bool `json:"isFrozen"       exposure:"private,needPermission"`
 }
 
 // WalletTransaction represents a transaction in a user's wallet
 // @model WalletTransaction
 type WalletTransaction struct {
 	// Transaction ID (private, needs permission)
-	Id int `json:"id"        exposure:"private,needPermission"`
+	Id int `json:"id"                   exposure:"private,needPermission"`
 	// Transaction amount (private, needs permission)
-	Amount float64 `json:"amount"    exposure:"private,needPermission"`
+	Amount Money `json:"amount"               exposure:"private,needPermission" swaggertype:"primitive,number"`
 	// Type of transaction (credit/debit/etc) (private, needs permission)
-	TxType TransactionType `json:"txType"    exposure:"private,needPermission"`
+	TxType TransactionType `json:"txType"               exposure:"private,needPermission"`
 	// Current status of the transaction (private, needs permission)
-	Status TransactionStatus `json:"status"    exposure:"private,needPermission"`
+	Status TransactionStatus `json:"status"               exposure:"private,needPermission"`
 	// When the transaction was created (private, needs permission)
-	CreatedAt time.Time `json:"createdAt" exposure:"private,needPermission"`
+	CreatedAt time.Time `json:"createdAt"            exposure:"private,needPermission"`
 	// When the transaction was last updated (private, needs permission)
-	UpdatedAt time.Time `json:"updatedAt" exposure:"private,needPermission"`
+	UpdatedAt time.Time `json:"updatedAt"            exposure:"private,needPermission"`
 	// ID of the wallet this transaction belongs to (private, needs permission)
-	WalletId int `json:"walletId"  exposure:"private,needPermission"`
+	WalletId int `json:"walletId"             exposure:"private,needPermission"`
+	// ID of the transfer of the transaction (private, needs permission)
+	TransferId json_types.JSONNullInt32 `json:"transferId"           exposure:"private,needPermission" swaggertype:"integer"`
+	// Memo of the transfer of the transaction (private, needs permission)
+	Memo json_types.JSONNullString `json:"memo"                 exposure:"private,needPermission" swaggertype:"string"`
+	// ID of the user on the other side of the transfer (private, needs permission)
+	CounterpartyUserId json_types.JSONNullInt32 `json:"counterpartyUserId"   exposure:"private,needPermission" swaggertype:"integer"`
+	// Username of the user on the other side of the transfer (private, needs permission)
+	CounterpartyUsername json_types.JSONNullString `json:"counterpartyUsername" exposure:"private,needPermission" swaggertype:"string"`
 }
 
 // CreateWalletTransactionPayload contains data needed to create a new wallet transaction
 // @model CreateWalletTransactionPayload
 type CreateWalletTransactionPayload struct {
 	// Transaction amount (required)
-	Amount float64 `json:"amount"   validate:"required"`
+	Amount Money `json:"amount"   validate:"required" swaggertype:"primitive,number"`
 	// Type of transaction
 	TxType TransactionType `json:"txType"`
 	// ID of the wallet this transaction belongs to
@@ -54,11 +70,28 @@ type UpdateWalletTransactionPayload struct {
 	Status *TransactionStatus `json:"status"`
 }
 
-// UpdateWalletPayload contains data for updating a wallet
-// @model UpdateWalletPayload
-type UpdateWalletPayload struct {
-	// New balance for the wallet
-	Balance *float64 `json:"balance"`
+// CreateWalletTransferPayload contains data needed to send money to another user
+// @model CreateWalletTransferPayload
+type CreateWalletTransferPayload struct {
+	// Username of the user who receives the money (required)
+	ReceiverUsername string `json:"receiverUsername" validate:"required"`
+	// Amount to send (required)
+	Amount Money `json:"amount"           validate:"required"          swaggertype:"primitive,number"`
+	// Memo shown to both users
+	Memo *string `json:"memo"             validate:"omitempty,max=255"`
+}
+
+// WalletTransferInsertData contains data for moving money between two wallets
+// @model WalletTransferInsertData
+type WalletTransferInsertData struct {
+	// ID of the wallet that sends the money
+	SenderWalletId int
+	// ID of the wallet that receives the money
+	ReceiverWalletId int
+	// Amount to send
+	Amount Money
+	// Memo shown to both users
+	Memo *string
 }
 
 // WalletTransactionSearchQuery contains parameters for searching wallet transactions
@@ -74,8 +107,122 @@ type WalletTransactionSearchQuery struct {
 	AfterDate *time.Time `json:"afterDate"`
 	// Filter by user ID
 	UserId *int `json:"userId"`
+	// Start after the last transaction of the previous page, the newest transactions come first
+	Cursor *Cursor `json:"cursor"`
 	// Maximum number of results to return
 	Limit *int `json:"limit"`
 	// Number of results to skip
 	Offset *int `json:"offset"`
 }
+
+// WalletLedgerEntry is a journal entry seen from a wallet, with the amount
+// that the entry posted to the wallet
+// @model WalletLedgerEntry
+type WalletLedgerEntry struct {
+	// Journal entry ID (private, needs permission)
+	EntryId int `json:"entryId"             exposure:"private,needPermission"`
+	// Money movement that the entry records (private, needs permission)
+	Kind JournalEntryKind `json:"kind"                exposure:"private,needPermission"`
+	// Description of the entry (private, needs permission)
+	Description string `json:"description"         exposure:"private,needPermission"`
+	// Amount credited to the wallet, negative if it is debited (private, needs permission)
+	Amount Money `json:"amount"              exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// When the entry was posted (private, needs permission)
+	CreatedAt time.Time `json:"createdAt"           exposure:"private,needPermission"`
+	// ID of the wallet transaction of the entry (private, needs permission)
+	WalletTransactionId json_types.JSONNullInt32 `json:"walletTransactionId" exposure:"private,needPermission" swaggertype:"integer"`
+	// ID of the order of the entry (private, needs permission)
+	OrderId json_types.JSONNullInt32 `json:"orderId"             exposure:"private,needPermission" swaggertype:"integer"`
+	// ID of the order return of the entry (private, needs permission)
+	OrderReturnId json_types.JSONNullInt32 `json:"orderReturnId"       exposure:"private,needPermission" swaggertype:"integer"`
+	// ID of the wallet transfer of the entry (private, needs permission)
+	WalletTransferId json_types.JSONNullInt32 `json:"walletTransferId"    exposure:"private,needPermission" swaggertype:"integer"`
+}
+
+// WalletLedgerEntrySearchQuery contains parameters for searching the ledger entries of a wallet
+// @model WalletLedgerEntrySearchQuery
+type WalletLedgerEntrySearchQuery struct {
+	// Filter by entry kind
+	Kind *JournalEntryKind `json:"kind"`
+	// Filter entries before this date
+	BeforeDate *time.Time `json:"beforeDate"`
+	// Filter entries after this date
+	AfterDate *time.Time `json:"afterDate"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
+
+// JournalEntryInsertData contains data for posting a journal entry to the ledger
+// @model JournalEntryInsertData
+type JournalEntryInsertData struct {
+	// Money movement that the entry records
+	Kind JournalEntryKind
+	// Description of the entry
+	Description string
+	// ID of the wallet transaction of the entry
+	WalletTransactionId *int
+	// ID of the order of the entry
+	OrderId *int
+	// ID of the order return of the entry
+	OrderReturnId *int
+	// ID of the wallet transfer of the entry
+	WalletTransferId *int
+	// Postings of the entry, their amounts must add up to zero
+	Postings []LedgerPostingInsertData
+}
+
+// LedgerPostingInsertData contains data for a posting of a journal entry
+// @model LedgerPostingInsertData
+type LedgerPostingInsertData struct {
+	// ID of the ledger account
+	AccountId int
+	// Amount credited to the account, negative if it is debited
+	Amount Money
+}
+
+// EscrowHoldInsertData contains data for holding the earnings of a store in an order
+// @model EscrowHoldInsertData
+type EscrowHoldInsertData struct {
+	// Earnings of the store
+	Amount Money
+	// When the earnings are released if the shipment is not delivered before
+	ReleaseAt time.Time
+	// ID of the paid order
+	OrderId int
+	// ID of the store
+	StoreId int
+	// ID of the wallet of the store owner
+	WalletId int
+}
+
+// WalletStatementLine is a ledger entry of a wallet statement, with the
+// balance of the wallet after the entry
+// @model WalletStatementLine
+type WalletStatementLine struct {
+	WalletLedgerEntry
+	// Balance of the wallet after the entry (private, needs permission)
+	Balance Money `json:"balance" exposure:"private,needPermission" swaggertype:"primitive,number"`
+}
+
+// WalletStatement represents the money that moved in and out of a wallet in a period
+// @model WalletStatement
+type WalletStatement struct {
+	// ID of the wallet (private, needs permission)
+	WalletId int `json:"walletId"       exposure:"private,needPermission"`
+	// Start of the period, inclusive (private, needs permission)
+	From time.Time `json:"from"           exposure:"private,needPermission"`
+	// End of the period, exclusive (private, needs permission)
+	To time.Time `json:"to"             exposure:"private,needPermission"`
+	// Balance of the wallet at the start of the period (private, needs permission)
+	OpeningBalance Money `json:"openingBalance" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Balance of the wallet at the end of the period (private, needs permission)
+	ClosingBalance Money `json:"closingBalance" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Sum of the money credited to the wallet in the period (private, needs permission)
+	TotalCredits Money `json:"totalCredits"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Sum of the money debited from the wallet in the period (private, needs permission)
+	TotalDebits Money `json:"totalDebits"    exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Entries of the period, the oldest first (private, needs permission)
+	Lines []WalletStatementLine `json:"lines"          exposure:"private,needPermission"`
+}
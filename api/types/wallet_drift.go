@@ -0,0 +1,69 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// WalletDrift represents a wallet whose balance is different from the balance expected from its history
+// @model WalletDrift
+type WalletDrift struct {
+	// Drift ID (private, needs permission)
+	Id int `json:"id"              exposure:"private,needPermission"`
+	// Cached balance of the wallet when it was last checked (private, needs permission)
+	CachedBalance Money `json:"cachedBalance"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Sum of the ledger postings of the wallet when it was last checked (private, needs permission)
+	LedgerBalance Money `json:"ledgerBalance"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Balance expected from the transactions, the order payments and the released escrow holds of the wallet (private, needs permission)
+	ExpectedBalance Money `json:"expectedBalance" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Whether the wallet is frozen until the drift is resolved (private, needs permission)
+	Frozen bool `json:"frozen"          exposure:"private,needPermission"`
+	// Note of the admin who resolved the drift (private, needs permission)
+	Note json_types.JSONNullString `json:"note"            exposure:"private,needPermission" swaggertype:"string"`
+	// When the drift was first found (private, needs permission)
+	DetectedAt time.Time `json:"detectedAt"      exposure:"private,needPermission"`
+	// When the wallet was last checked while the drift was open (private, needs permission)
+	CheckedAt time.Time `json:"checkedAt"       exposure:"private,needPermission"`
+	// When the drift was resolved (private, needs permission)
+	ResolvedAt json_types.JSONNullTime `json:"resolvedAt"      exposure:"private,needPermission" swaggertype:"string"`
+	// ID of the wallet (private, needs permission)
+	WalletId int `json:"walletId"        exposure:"private,needPermission"`
+	// ID of the user who owns the wallet (private, needs permission)
+	UserId int `json:"userId"          exposure:"private,needPermission"`
+	// ID of the admin who resolved the drift (private, needs permission)
+	ResolvedBy json_types.JSONNullInt32 `json:"resolvedBy"      exposure:"private,needPermission" swaggertype:"integer"`
+}
+
+// WalletDriftSearchQuery contains parameters for searching wallet drifts
+// @model WalletDriftSearchQuery
+type WalletDriftSearchQuery struct {
+	// Filter by whether the drift is resolved
+	Resolved *bool `json:"resolved"`
+	// Filter by the user who owns the wallet
+	UserId *int `json:"userId"`
+	// Maximum number of results to return
+	Limit *int `json:"limit"`
+	// Number of results to skip
+	Offset *int `json:"offset"`
+}
+
+// ResolveWalletDriftPayload contains data needed to resolve a wallet drift
+// @model ResolveWalletDriftPayload
+type ResolveWalletDriftPayload struct {
+	// What was done about the drift (required)
+	Note string `json:"note" validate:"required,max=255"`
+}
+
+// WalletReconciliationReport represents the result of a reconciliation of the wallets
+// @model WalletReconciliationReport
+type WalletReconciliationReport struct {
+	// Number of the wallets that were checked
+	CheckedWallets int `json:"checkedWallets"`
+	// Number of the drifted wallets that are frozen
+	FrozenWallets int `json:"frozenWallets"`
+	// Open drifts of the wallets whose balance is different from their history
+	Drifts []WalletDrift `json:"drifts"`
+	// When the reconciliation ran
+	RanAt time.Time `json:"ranAt"`
+}
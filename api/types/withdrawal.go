@@ -0,0 +1,121 @@
+package types
+
+import (
+	"time"
+
+	json_types "github.com/SaeedAlian/econest/api/types/json"
+)
+
+// WithdrawalTier represents the limits of the withdrawals of the users that it applies to, a limit of zero means that there is no limit
+// @model WithdrawalTier
+type WithdrawalTier struct {
+	// Unique identifier for the tier (private, needs permission)
+	Id int `json:"id"              exposure:"private,needPermission"`
+	// Name of the tier (private, needs permission)
+	Name string `json:"name"            exposure:"private,needPermission"`
+	// Minimum amount of a withdrawal (private, needs permission)
+	MinAmount Money `json:"minAmount"       exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Maximum amount of the withdrawals of the last 24 hours (private, needs permission)
+	DailyLimit Money `json:"dailyLimit"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Maximum amount of the withdrawals of the last 30 days (private, needs permission)
+	MonthlyLimit Money `json:"monthlyLimit"    exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Daily limit of the users whose identity is verified (private, needs permission)
+	KycDailyLimit Money `json:"kycDailyLimit"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Monthly limit of the users whose identity is verified (private, needs permission)
+	KycMonthlyLimit Money `json:"kycMonthlyLimit" exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Hours that the withdrawals are blocked for after a password or email change (private, needs permission)
+	CooldownInHours int `json:"cooldownInHours" exposure:"private,needPermission"`
+	// Whether the tier applies to the users that have no other tier (private, needs permission)
+	IsDefault bool `json:"isDefault"       exposure:"private,needPermission"`
+	// When the tier was created (private, needs permission)
+	CreatedAt time.Time `json:"createdAt"       exposure:"private,needPermission"`
+	// When the tier was last updated (private, needs permission)
+	UpdatedAt time.Time `json:"updatedAt"       exposure:"private,needPermission"`
+}
+
+// CreateWithdrawalTierPayload contains data needed to create a withdrawal tier
+// @model CreateWithdrawalTierPayload
+type CreateWithdrawalTierPayload struct {
+	// Name of the tier (required)
+	Name string `json:"name"            validate:"required,max=255"`
+	// Minimum amount of a withdrawal
+	MinAmount Money `json:"minAmount"       swaggertype:"primitive,number"`
+	// Maximum amount of the withdrawals of the last 24 hours, zero for no limit
+	DailyLimit Money `json:"dailyLimit"      swaggertype:"primitive,number"`
+	// Maximum amount of the withdrawals of the last 30 days, zero for no limit
+	MonthlyLimit Money `json:"monthlyLimit"    swaggertype:"primitive,number"`
+	// Daily limit of the users whose identity is verified, zero for no limit
+	KycDailyLimit Money `json:"kycDailyLimit"   swaggertype:"primitive,number"`
+	// Monthly limit of the users whose identity is verified, zero for no limit
+	KycMonthlyLimit Money `json:"kycMonthlyLimit" swaggertype:"primitive,number"`
+	// Hours that the withdrawals are blocked for after a password or email change
+	CooldownInHours int `json:"cooldownInHours" validate:"min=0"`
+	// Whether the tier replaces the current default tier
+	IsDefault bool `json:"isDefault"`
+}
+
+// UpdateWithdrawalTierPayload contains data for updating a withdrawal tier
+// @model UpdateWithdrawalTierPayload
+type UpdateWithdrawalTierPayload struct {
+	// New name
+	Name *string `json:"name"            validate:"omitempty,max=255"`
+	// New minimum amount of a withdrawal
+	MinAmount *Money `json:"minAmount"       swaggertype:"primitive,number"`
+	// New daily limit
+	DailyLimit *Money `json:"dailyLimit"      swaggertype:"primitive,number"`
+	// New monthly limit
+	MonthlyLimit *Money `json:"monthlyLimit"    swaggertype:"primitive,number"`
+	// New daily limit of the users whose identity is verified
+	KycDailyLimit *Money `json:"kycDailyLimit"   swaggertype:"primitive,number"`
+	// New monthly limit of the users whose identity is verified
+	KycMonthlyLimit *Money `json:"kycMonthlyLimit" swaggertype:"primitive,number"`
+	// New cooldown after a password or email change
+	CooldownInHours *int `json:"cooldownInHours" validate:"omitempty,min=0"`
+	// Makes the tier the default tier, the default tier can only be replaced by another one
+	IsDefault *bool `json:"isDefault"`
+}
+
+// UserWithdrawalProfile represents the withdrawal settings of a user
+// @model UserWithdrawalProfile
+type UserWithdrawalProfile struct {
+	// ID of the user (private, needs permission)
+	UserId int `json:"userId"               exposure:"private,needPermission"`
+	// Whether the identity of the user is verified (private, needs permission)
+	KycVerified bool `json:"kycVerified"          exposure:"private,needPermission"`
+	// When the identity of the user was verified (private, needs permission)
+	KycVerifiedAt json_types.JSONNullTime `json:"kycVerifiedAt"        exposure:"private,needPermission" swaggertype:"string"`
+	// When the password or the email of the user was last changed (private, needs permission)
+	CredentialsChangedAt json_types.JSONNullTime `json:"credentialsChangedAt" exposure:"private,needPermission" swaggertype:"string"`
+	// ID of the tier that is set for the user alone (private, needs permission)
+	TierId json_types.JSONNullInt32 `json:"tierId"               exposure:"private,needPermission" swaggertype:"integer"`
+}
+
+// UpdateUserWithdrawalProfilePayload contains data for updating the withdrawal settings of a user
+// @model UpdateUserWithdrawalProfilePayload
+type UpdateUserWithdrawalProfilePayload struct {
+	// Whether the identity of the user is verified
+	KycVerified *bool `json:"kycVerified"`
+	// ID of the tier to set for the user alone
+	TierId *int `json:"tierId"`
+}
+
+// WithdrawalPolicy represents the limits that apply to the next withdrawal of a user
+// @model WithdrawalPolicy
+type WithdrawalPolicy struct {
+	// Tier of the user, which is the tier of the user alone, the tier of the role of the user or the default tier (private, needs permission)
+	Tier WithdrawalTier `json:"tier"           exposure:"private,needPermission"`
+	// Whether the identity of the user is verified (private, needs permission)
+	KycVerified bool `json:"kycVerified"    exposure:"private,needPermission"`
+	// Minimum amount of a withdrawal (private, needs permission)
+	MinAmount Money `json:"minAmount"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Daily limit of the user, zero if there is no limit (private, needs permission)
+	DailyLimit Money `json:"dailyLimit"     exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Monthly limit of the user, zero if there is no limit (private, needs permission)
+	MonthlyLimit Money `json:"monthlyLimit"   exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Pending and successful withdrawals of the last 24 hours (private, needs permission)
+	DailyUsed Money `json:"dailyUsed"      exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// Pending and successful withdrawals of the last 30 days (private, needs permission)
+	MonthlyUsed Money `json:"monthlyUsed"    exposure:"private,needPermission" swaggertype:"primitive,number"`
+	// When the cooldown after the last password or email change ends, null if there is no cooldown (private, needs permission)
+	CooldownEndsAt json_types.JSONNullTime `json:"cooldownEndsAt" exposure:"private,needPermission" swaggertype:"string"`
+}
@@ -0,0 +1,201 @@
+// Package pdf writes simple text documents in the PDF format, which is
+// enough for generated files such as invoices without a third-party renderer.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PageWidth is the width of an A4 page in points
+	PageWidth = 595.0
+	// PageHeight is the height of an A4 page in points
+	PageHeight = 842.0
+)
+
+type Document struct {
+	pages []*bytes.Buffer
+}
+
+func New() *Document {
+	return &Document{pages: []*bytes.Buffer{}}
+}
+
+// AddPage starts a new page, the following drawings are added to it.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, new(bytes.Buffer))
+}
+
+// PageCount returns the number of pages of the document.
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Text draws a line of text with its baseline starting at x and y, where
+// the origin is the bottom left corner of the page.
+func (d *Document) Text(x float64, y float64, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+
+	fmt.Fprintf(
+		d.page(),
+		"BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font,
+		formatNumber(size),
+		formatNumber(x),
+		formatNumber(y),
+		escapeText(s),
+	)
+}
+
+// TextRight draws a line of text that ends at x.
+func (d *Document) TextRight(x float64, y float64, size float64, bold bool, s string) {
+	d.Text(x-TextWidth(s, size), y, size, bold, s)
+}
+
+// Line draws a thin line from the first point to the second one.
+func (d *Document) Line(x1 float64, y1 float64, x2 float64, y2 float64) {
+	fmt.Fprintf(
+		d.page(),
+		"0.5 w %s %s m %s %s l S\n",
+		formatNumber(x1),
+		formatNumber(y1),
+		formatNumber(x2),
+		formatNumber(y2),
+	)
+}
+
+// Bytes returns the encoded document, a document without pages has a
+// single blank page.
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	out := new(bytes.Buffer)
+	offsets := []int{}
+
+	writeObject := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// the catalog, the page tree and the fonts take the first four objects,
+	// each page is followed by its content stream
+	kids := []string{}
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+i*2))
+	}
+
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf(
+		"<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "),
+		len(d.pages),
+	))
+	writeObject(
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	)
+	writeObject(
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+
+	for i, content := range d.pages {
+		writeObject(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			formatNumber(PageWidth),
+			formatNumber(PageHeight),
+			6+i*2,
+		))
+		writeObject(fmt.Sprintf(
+			"<< /Length %d >>\nstream\n%sendstream",
+			content.Len(),
+			content.String(),
+		))
+	}
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", o)
+	}
+
+	fmt.Fprintf(
+		out,
+		"trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1,
+		xrefOffset,
+	)
+
+	return out.Bytes()
+}
+
+// TextWidth returns the approximate width of a text in the regular font,
+// digits and punctuation have their exact Helvetica widths so that columns
+// of numbers line up.
+func TextWidth(s string, size float64) float64 {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			width += 556
+		case r == '.' || r == ',' || r == ' ' || r == ':' || r == '/':
+			width += 278
+		case r == '-' || r == '(' || r == ')':
+			width += 333
+		case r == '%':
+			width += 889
+		case r == 'i' || r == 'l' || r == 'j' || r == 'I':
+			width += 222
+		case r == 'm' || r == 'w' || r == 'M' || r == 'W':
+			width += 833
+		case r >= 'A' && r <= 'Z':
+			width += 667
+		default:
+			width += 556
+		}
+	}
+
+	return float64(width) * size / 1000
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	return d.pages[len(d.pages)-1]
+}
+
+// escapeText encodes a text as a PDF string in the WinAnsi encoding, the
+// characters that cannot be encoded are replaced by a question mark.
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+
+	return b.String()
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/SaeedAlian/econest/api/config"
 )
 
-func SetupTestDB(t *testing.T) *sql.DB {
+func SetupTestDB(t testing.TB) *sql.DB {
 	if config.Env.Env != "test" {
 		log.Panic("environment is not on test!!")
 		os.Exit(1)
@@ -27,8 +27,18 @@ var structPaths []string = []string{
 	"github.com/SaeedAlian/econest/api/types",
 }
 
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
 var Validator = validator.New()
 
+func init() {
+	// the amounts are validated by their minor units, so a required amount
+	// must not be zero
+	Validator.RegisterCustomTypeFunc(func(v reflect.Value) any {
+		return v.Interface().(types.Money).Minor()
+	}, types.Money{})
+}
+
 func ParseJSONFromRequest(r *http.Request, payload any) error {
 	body := r.Body
 
@@ -58,39 +68,35 @@ func WriteJSONInResponse(
 }
 
 func WriteErrorInResponse(w http.ResponseWriter, status int, err error) error {
-	var formattedErr error = err
+	res := types.HTTPError{
+		Message: FormatDBError(err).Error(),
+	}
 
+	return WriteJSONInResponse(w, status, res, nil)
+}
+
+// FormatDBError turns the errors of the database into messages that can be
+// shown to the users, the other errors are returned as they are.
+func FormatDBError(err error) error {
 	var pgErr *pq.Error
-	if errors.As(err, &pgErr) {
-		switch pgErr.Code {
-		case "23505":
-			{
-				formattedErr = formatUniqueViolation(pgErr)
-			}
-		case "23503":
-			{
-				formattedErr = formatForeignKeyViolation(pgErr)
-			}
-		case "P0001":
-			{
-				formattedErr = errors.New(pgErr.Message)
-			}
-		case "22P02":
-			{
-				formattedErr = formatEnumViolation(pgErr)
-			}
-		default:
-			{
-				formattedErr = errors.New("database error: " + pgErr.Message)
-			}
-		}
+	if !errors.As(err, &pgErr) {
+		return err
 	}
 
-	res := types.HTTPError{
-		Message: formattedErr.Error(),
+	switch pgErr.Code {
+	case "23505":
+		return formatUniqueViolation(pgErr)
+	case "23503":
+		return formatForeignKeyViolation(pgErr)
+	case "23514":
+		return formatCheckViolation(pgErr)
+	case "P0001":
+		return errors.New(pgErr.Message)
+	case "22P02":
+		return formatEnumViolation(pgErr)
+	default:
+		return errors.New("database error: " + pgErr.Message)
 	}
-
-	return WriteJSONInResponse(w, status, res, nil)
 }
 
 func DeleteCookie(w http.ResponseWriter, cookie *http.Cookie) {
@@ -169,7 +175,9 @@ func FilterStruct(input any, exposures map[string]bool) map[string]any {
 
 		isStruct := slices.Contains(structPaths, pkgPath)
 
-		if typ.Kind() == reflect.Struct && isStruct {
+		// the types that are encoded by themselves, such as the amounts of
+		// money, are kept as they are
+		if typ.Kind() == reflect.Struct && isStruct && !typ.Implements(jsonMarshalerType) {
 			res[tag] = FilterStruct(fieldVal.Interface(), exposures)
 		} else {
 			res[tag] = fieldVal.Interface()
@@ -402,6 +410,15 @@ func ParseURLQuery(mapping map[string]any, values url.Values) error {
 			continue
 		}
 
+		if vType == reflect.TypeOf(types.Money{}) {
+			parsed, err := types.ParseMoney(rawValue)
+			if err != nil {
+				return types.ErrInvalidQueryValue(key)
+			}
+			v.Set(reflect.ValueOf(&parsed))
+			continue
+		}
+
 		switch vKind {
 		case reflect.Bool:
 			{
@@ -483,7 +500,11 @@ func ParseURLQuery(mapping map[string]any, values url.Values) error {
 
 		case reflect.String:
 			{
-				v.Set(reflect.ValueOf(&rawValue))
+				// the value is set through its own type, so the named string
+				// types such as the enums can be parsed too
+				res := reflect.New(vType)
+				res.Elem().SetString(rawValue)
+				v.Set(res)
 			}
 		}
 
@@ -533,6 +554,22 @@ func CopyFileIntoResponse(dir string, filename string, w http.ResponseWriter) {
 	}
 }
 
+func WriteFileInResponse(
+	w http.ResponseWriter,
+	status int,
+	data []byte,
+	contentType string,
+	filename string,
+) error {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.WriteHeader(status)
+
+	_, err := w.Write(data)
+	return err
+}
+
 func formatUniqueViolation(e *pq.Error) error {
 	if e.Column != "" {
 		return types.ErrUniqueConstraintViolationForColumn(e.Column)
@@ -566,6 +603,30 @@ func formatUniqueViolation(e *pq.Error) error {
 	case "products_slug_key":
 		return types.ErrDuplicateProductSlug
 
+	case "coupons_code_key":
+		return types.ErrDuplicateCouponCode
+
+	case "shipping_zones_name_key":
+		return types.ErrDuplicateShippingZoneName
+
+	case "shipping_zones_state_city_key":
+		return types.ErrDuplicateShippingZoneLocation
+
+	case "shipping_rate_tables_store_id_zone_id_key":
+		return types.ErrDuplicateShippingRateTable
+
+	case "shipping_transit_times_origin_zone_id_destination_zone_id_key":
+		return types.ErrDuplicateShippingTransitTime
+
+	case "tax_rules_zone_id_category_id_key":
+		return types.ErrDuplicateTaxRule
+
+	case "commission_rules_store_id_category_id_key":
+		return types.ErrDuplicateCommissionRule
+
+	case "withdrawal_tiers_name_key":
+		return types.ErrDuplicateWithdrawalTierName
+
 	default:
 		return types.ErrUniqueConstraintViolation
 	}
@@ -750,6 +811,131 @@ func formatForeignKeyViolation(e *pq.Error) error {
 				return types.ErrProductVariantNotFound
 			}
 
+		case "order_shipments_store_id_fkey":
+			{
+				return types.ErrStoreNotFound
+			}
+
+		case "order_product_variants_store_id_fkey":
+			{
+				return types.ErrStoreNotFound
+			}
+
+		case "order_returns_order_id_fkey":
+			{
+				return types.ErrOrderNotFound
+			}
+
+		case "order_returns_store_id_fkey":
+			{
+				return types.ErrStoreNotFound
+			}
+
+		case "order_return_items_return_id_fkey":
+			{
+				return types.ErrOrderReturnNotFound
+			}
+
+		case "order_return_items_order_product_variant_id_fkey":
+			{
+				return types.ErrOrderReturnItemNotInOrder
+			}
+
+		case "carts_user_id_fkey":
+			{
+				return types.ErrUserNotFound
+			}
+
+		case "cart_items_cart_id_fkey":
+			{
+				return types.ErrCartNotFound
+			}
+
+		case "cart_items_variant_id_fkey":
+			{
+				return types.ErrProductVariantNotFound
+			}
+
+		case "coupons_store_id_fkey":
+			{
+				return types.ErrStoreNotFound
+			}
+
+		case "coupons_category_id_fkey":
+			{
+				return types.ErrProductCategoryNotFound
+			}
+
+		case "coupons_tag_id_fkey":
+			{
+				return types.ErrProductTagNotFound
+			}
+
+		case "order_payments_coupon_id_fkey":
+			{
+				return types.ErrCouponIsUsedByOrders
+			}
+
+		case "shipping_rate_tables_store_id_fkey":
+			{
+				return types.ErrStoreNotFound
+			}
+
+		case "shipping_rate_tables_zone_id_fkey":
+			{
+				return types.ErrShippingZoneNotFound
+			}
+
+		case "shipping_rate_tiers_rate_table_id_fkey":
+			{
+				return types.ErrShippingRateTableNotFound
+			}
+
+		case "shipping_transit_times_origin_zone_id_fkey":
+			{
+				return types.ErrShippingZoneNotFound
+			}
+
+		case "shipping_transit_times_destination_zone_id_fkey":
+			{
+				return types.ErrShippingZoneNotFound
+			}
+
+		case "tax_rules_zone_id_fkey":
+			{
+				return types.ErrShippingZoneNotFound
+			}
+
+		case "tax_rules_category_id_fkey":
+			{
+				return types.ErrProductCategoryNotFound
+			}
+
+		case "commission_rules_store_id_fkey":
+			{
+				return types.ErrStoreNotFound
+			}
+
+		case "commission_rules_category_id_fkey":
+			{
+				return types.ErrProductCategoryNotFound
+			}
+
+		case "role_withdrawal_tiers_role_id_fkey":
+			{
+				return types.ErrRoleNotFound
+			}
+
+		case "role_withdrawal_tiers_tier_id_fkey", "user_withdrawal_profiles_tier_id_fkey":
+			{
+				return types.ErrWithdrawalTierNotFound
+			}
+
+		case "user_withdrawal_profiles_user_id_fkey":
+			{
+				return types.ErrUserNotFound
+			}
+
 		default:
 			return types.ErrForeignKeyViolationForColumn
 		}
@@ -758,6 +944,39 @@ func formatForeignKeyViolation(e *pq.Error) error {
 	return types.ErrForeignKeyViolationForColumn
 }
 
+func formatCheckViolation(e *pq.Error) error {
+	switch e.Constraint {
+	case "coupons_amount_check", "coupons_percentage_amount_check":
+		return types.ErrInvalidCouponAmount
+
+	case "coupons_check":
+		return types.ErrInvalidCouponValidityWindow
+
+	case "shipping_transit_times_days_check":
+		return types.ErrInvalidShippingTransitDays
+
+	case "stores_settings_handling_days_check":
+		return types.ErrInvalidStoreHandlingDays
+
+	case "tax_rules_rate_check":
+		return types.ErrInvalidTaxRate
+
+	case "commission_rules_rate_check":
+		return types.ErrInvalidCommissionRate
+
+	case "withdrawal_tiers_min_amount_check",
+		"withdrawal_tiers_daily_limit_check",
+		"withdrawal_tiers_monthly_limit_check",
+		"withdrawal_tiers_kyc_daily_limit_check",
+		"withdrawal_tiers_kyc_monthly_limit_check",
+		"withdrawal_tiers_cooldown_in_hours_check":
+		return types.ErrInvalidWithdrawalTierLimit
+
+	default:
+		return errors.New("database error: " + e.Message)
+	}
+}
+
 func formatEnumViolation(e *pq.Error) error {
 	msg := e.Message
 	switch {
@@ -779,6 +998,18 @@ func formatEnumViolation(e *pq.Error) error {
 	case strings.Contains(msg, `"order_shipment_statuses"`):
 		return types.ErrInvalidOrderShipmentStatusEnum
 
+	case strings.Contains(msg, `"order_return_statuses"`):
+		return types.ErrInvalidOrderReturnStatusEnum
+
+	case strings.Contains(msg, `"coupon_discount_types"`):
+		return types.ErrInvalidCouponDiscountTypeEnum
+
+	case strings.Contains(msg, `"shipping_rate_bases"`):
+		return types.ErrInvalidShippingRateBasisEnum
+
+	case strings.Contains(msg, `"tax_pricing_modes"`):
+		return types.ErrInvalidTaxPricingModeEnum
+
 	default:
 		return types.ErrInvalidInputFormat
 	}
@@ -793,3 +1024,15 @@ func RoundToNDecimals32(val float32, n int) float32 {
 	factor := float32(math.Pow(10, float64(n)))
 	return float32(math.Round(float64(val)*float64(factor)) / float64(factor))
 }
+
+// NextCursorHeaders returns the headers that carry the cursor of the next page
+// of a list, or nil on its last page.
+func NextCursorHeaders(c *types.Cursor) *map[string]string {
+	if c == nil {
+		return nil
+	}
+
+	return &map[string]string{
+		types.NextCursorHeader: c.Encode(),
+	}
+}